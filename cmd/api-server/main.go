@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -48,11 +49,31 @@ func main() {
 	container := container.NewContainerWithDB(db)
 	ginRouter := router.InitRouter(container)
 
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	for _, run := range []func(context.Context){container.WebhookDispatcher.Run, container.OutboxRelay.Run, container.JobWorkerPool.Run, container.Scheduler.Run} {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workersCtx)
+		}()
+	}
+
 	server := &http.Server{
 		Addr:    ":" + config.ServicePort,
 		Handler: ginRouter,
 	}
 
+	// Open streams would keep the server from shutting down, so the stream is stopped as soon as shutdown starts,
+	// which ends them.
+	streamCtx, stopStream := context.WithCancel(workersCtx)
+	server.RegisterOnShutdown(stopStream)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		container.SimpleStream.Run(streamCtx)
+	}()
+
 	serverErrors := make(chan error, 1)
 	go func() {
 		log.Info("Server starting", zap.String("address", server.Addr))
@@ -86,5 +107,8 @@ func main() {
 		}
 	}
 
+	stopWorkers()
+	workers.Wait()
+
 	log.Info("Application shutdown completed")
 }
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE organizations (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL CHECK (name <> ''),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE memberships (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(32) NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'admin', 'member')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (organization_id, user_id)
+);
+
+CREATE INDEX idx_memberships_user_id ON memberships(user_id);
+
+-- Existing users and Simples were shared by everyone, so they move into a single default organization.
+INSERT INTO organizations (name)
+SELECT 'Default' WHERE EXISTS (SELECT 1 FROM users) OR EXISTS (SELECT 1 FROM simples);
+
+INSERT INTO memberships (organization_id, user_id, role)
+SELECT organizations.id, users.id, CASE WHEN users.role = 'admin' THEN 'owner' ELSE 'member' END
+FROM organizations CROSS JOIN users
+WHERE users.deleted_at IS NULL;
+
+ALTER TABLE simples ADD COLUMN organization_id INTEGER REFERENCES organizations(id);
+UPDATE simples SET organization_id = (SELECT MIN(id) FROM organizations);
+ALTER TABLE simples ALTER COLUMN organization_id SET NOT NULL;
+
+CREATE INDEX idx_simples_organization_id ON simples(organization_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE simples DROP COLUMN IF EXISTS organization_id;
+DROP TABLE IF EXISTS memberships;
+DROP TABLE IF EXISTS organizations;
+-- +goose StatementEnd
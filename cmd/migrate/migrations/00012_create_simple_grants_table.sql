@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE simples ADD COLUMN owner_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+-- Existing Simples have no known creator, so they are given to an owner of their organization.
+UPDATE simples SET owner_id = (
+    SELECT MIN(memberships.user_id) FROM memberships
+    WHERE memberships.organization_id = simples.organization_id AND memberships.role = 'owner'
+);
+
+CREATE INDEX idx_simples_owner_id ON simples(owner_id);
+
+CREATE TABLE simple_grants (
+    id SERIAL PRIMARY KEY,
+    simple_id INTEGER NOT NULL REFERENCES simples(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    permission VARCHAR(32) NOT NULL CHECK (permission IN ('read', 'write')),
+    granted_by_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (simple_id, user_id)
+);
+
+CREATE INDEX idx_simple_grants_user_id ON simple_grants(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS simple_grants;
+ALTER TABLE simples DROP COLUMN IF EXISTS owner_id;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE tags (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (organization_id, name)
+);
+
+-- Supports prefix matching for tag autocomplete.
+CREATE INDEX idx_tags_organization_id_name_pattern ON tags(organization_id, name varchar_pattern_ops);
+
+CREATE TABLE simple_tags (
+    simple_id INTEGER NOT NULL REFERENCES simples(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (simple_id, tag_id)
+);
+
+CREATE INDEX idx_simple_tags_tag_id ON simple_tags(tag_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS simple_tags;
+DROP TABLE IF EXISTS tags;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE simples ADD COLUMN kind VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE simples ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
+
+-- jsonb_path_ops indexes the @> containment queries used by metadata filters.
+CREATE INDEX idx_simples_metadata ON simples USING GIN (metadata jsonb_path_ops);
+CREATE INDEX idx_simples_organization_id_kind ON simples(organization_id, kind);
+
+-- A schema with an empty kind applies to every Simple of the organization.
+CREATE TABLE metadata_schemas (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    kind VARCHAR(64) NOT NULL DEFAULT '',
+    schema JSONB NOT NULL,
+    created_by_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (organization_id, kind)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS metadata_schemas;
+DROP INDEX IF EXISTS idx_simples_organization_id_kind;
+DROP INDEX IF EXISTS idx_simples_metadata;
+ALTER TABLE simples DROP COLUMN IF EXISTS metadata;
+ALTER TABLE simples DROP COLUMN IF EXISTS kind;
+-- +goose StatementEnd
@@ -2,8 +2,13 @@ package config
 
 import (
 	"fmt"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/Verano-20/stage-zero/internal/cron"
 )
 
 var globalConfig *Config
@@ -16,6 +21,17 @@ type Config struct {
 	JwtSecret      string
 	Database       DatabaseConfig
 	Telemetry      TelemetryConfig
+	Auth           AuthConfig
+	Mail           MailConfig
+	Password       PasswordConfig
+	Storage        StorageConfig
+	Attachment     AttachmentConfig
+	Webhook        WebhookConfig
+	Outbox         OutboxConfig
+	SimpleStream   SimpleStreamConfig
+	WebSocket      WebSocketConfig
+	Jobs           JobsConfig
+	Scheduler      SchedulerConfig
 }
 
 type DatabaseConfig struct {
@@ -24,6 +40,11 @@ type DatabaseConfig struct {
 	Password string
 	Name     string
 	Port     string
+	// Connection strings of the read replicas.
+	ReplicaDSNs                []string
+	ReplicaHealthCheckInterval time.Duration
+	// How long reads by a user go to the primary after they write, so they see their own writes.
+	ReadYourWritesWindow time.Duration
 }
 
 type TelemetryConfig struct {
@@ -34,6 +55,123 @@ type TelemetryConfig struct {
 	MetricInterval time.Duration
 }
 
+type AuthConfig struct {
+	MagicLinkURL         string
+	MagicLinkTTL         time.Duration
+	EmailVerificationURL string
+	EmailVerificationTTL time.Duration
+	InvitationURL        string
+	InvitationTTL        time.Duration
+	RateLimit            int
+	RateLimitWindow      time.Duration
+	MaxFailedLogins      int
+	LockoutDuration      time.Duration
+	ImpersonationTTL     time.Duration
+	Cookie               CookieConfig
+}
+
+type CookieConfig struct {
+	Name     string
+	Domain   string
+	Secure   bool
+	SameSite http.SameSite
+	MaxAge   time.Duration
+}
+
+type MailConfig struct {
+	From string
+}
+
+type PasswordConfig struct {
+	HashAlgorithm     string
+	BcryptCost        int
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+	MinLength         int
+	MaxLength         int
+	RequireUppercase  bool
+	RequireLowercase  bool
+	RequireDigit      bool
+	RequireSymbol     bool
+	ForbidEmail       bool
+	HistorySize       int
+	BreachedCorpus    string
+}
+
+type StorageConfig struct {
+	Backend           string
+	LocalPath         string
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3UsePathStyle    bool
+}
+
+type AttachmentConfig struct {
+	MaxSize             int64
+	AllowedContentTypes []string
+}
+
+type OutboxConfig struct {
+	Publisher         string
+	PollInterval      time.Duration
+	BatchSize         int
+	BaseBackoff       time.Duration
+	MaxBackoff        time.Duration
+	PublishTimeout    time.Duration
+	WebhookURL        string
+	WebhookSecret     string
+	NATSURL           string
+	NATSSubjectPrefix string
+}
+
+type SimpleStreamConfig struct {
+	LogSize           int
+	BufferSize        int
+	HeartbeatInterval time.Duration
+	RetryDelay        time.Duration
+}
+
+type WebSocketConfig struct {
+	SendQueueSize       int
+	HeartbeatTimeout    time.Duration
+	WriteTimeout        time.Duration
+	MaxSubscriptions    int
+	AllowedOrigins      []string
+	ReauthorizeInterval time.Duration
+}
+
+type JobsConfig struct {
+	Workers      int
+	PollInterval time.Duration
+	Timeout      time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+}
+
+type SchedulerConfig struct {
+	PollInterval          time.Duration
+	Timeout               time.Duration
+	Instance              string
+	TokenCleanupSchedule  string
+	TokenCleanupRetention time.Duration
+}
+
+type WebhookConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Timeout      time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	// Lets endpoints on loopback, private and link-local addresses receive deliveries, for local development.
+	AllowPrivateNetworks bool
+}
+
 func InitConfig() {
 	config := &Config{
 		ServiceName:    getEnvOrDefault("SERVICE_NAME", "stage-zero-api"),
@@ -43,18 +181,49 @@ func InitConfig() {
 		JwtSecret:      getEnvOrDefault("JWT_SECRET", ""),
 		Database:       *initDatabaseConfig(),
 		Telemetry:      *initTelemetryConfig(),
+		Auth:           *initAuthConfig(),
+		Mail:           *initMailConfig(),
+		Password:       *initPasswordConfig(),
+		Storage:        *initStorageConfig(),
+		Attachment:     *initAttachmentConfig(),
+		Webhook:        *initWebhookConfig(),
+		Outbox:         *initOutboxConfig(),
+		SimpleStream:   *initSimpleStreamConfig(),
+		WebSocket:      *initWebSocketConfig(),
+		Jobs:           *initJobsConfig(),
+		Scheduler:      *initSchedulerConfig(),
 	}
 
 	globalConfig = config
 }
 
 func initDatabaseConfig() *DatabaseConfig {
+	var replicaDSNs []string
+	for _, dsn := range strings.Split(getEnvOrDefault("DB_REPLICA_DSNS", ""), ",") {
+		if dsn = strings.TrimSpace(dsn); dsn != "" {
+			replicaDSNs = append(replicaDSNs, dsn)
+		}
+	}
+
+	replicaHealthCheckInterval, err := time.ParseDuration(getEnvOrDefault("DB_REPLICA_HEALTH_CHECK_INTERVAL", "5s"))
+	if err != nil || replicaHealthCheckInterval <= 0 {
+		panic("Invalid DB_REPLICA_HEALTH_CHECK_INTERVAL: must be a positive duration")
+	}
+
+	readYourWritesWindow, err := time.ParseDuration(getEnvOrDefault("DB_READ_YOUR_WRITES_WINDOW", "5s"))
+	if err != nil || readYourWritesWindow < 0 {
+		panic("Invalid DB_READ_YOUR_WRITES_WINDOW: must be a duration of at least 0")
+	}
+
 	return &DatabaseConfig{
-		Host:     getEnvOrDefault("DB_HOST", "localhost"),
-		User:     getEnvOrDefault("DB_USER", "postgres"),
-		Password: getEnvOrDefault("DB_PASSWORD", "postgres"),
-		Name:     getEnvOrDefault("DB_NAME", "go_crud"),
-		Port:     getEnvOrDefault("DB_PORT", "5432"),
+		Host:                       getEnvOrDefault("DB_HOST", "localhost"),
+		User:                       getEnvOrDefault("DB_USER", "postgres"),
+		Password:                   getEnvOrDefault("DB_PASSWORD", "postgres"),
+		Name:                       getEnvOrDefault("DB_NAME", "go_crud"),
+		Port:                       getEnvOrDefault("DB_PORT", "5432"),
+		ReplicaDSNs:                replicaDSNs,
+		ReplicaHealthCheckInterval: replicaHealthCheckInterval,
+		ReadYourWritesWindow:       readYourWritesWindow,
 	}
 }
 
@@ -73,6 +242,438 @@ func initTelemetryConfig() *TelemetryConfig {
 	}
 }
 
+func initAuthConfig() *AuthConfig {
+	magicLinkTTL, err := time.ParseDuration(getEnvOrDefault("MAGIC_LINK_TTL", "15m"))
+	if err != nil {
+		panic("Invalid MAGIC_LINK_TTL: " + err.Error())
+	}
+
+	emailVerificationTTL, err := time.ParseDuration(getEnvOrDefault("EMAIL_VERIFICATION_TTL", "24h"))
+	if err != nil {
+		panic("Invalid EMAIL_VERIFICATION_TTL: " + err.Error())
+	}
+
+	invitationTTL, err := time.ParseDuration(getEnvOrDefault("INVITATION_TTL", "168h"))
+	if err != nil {
+		panic("Invalid INVITATION_TTL: " + err.Error())
+	}
+
+	rateLimit, err := strconv.Atoi(getEnvOrDefault("AUTH_RATE_LIMIT", "10"))
+	if err != nil {
+		panic("Invalid AUTH_RATE_LIMIT: " + err.Error())
+	}
+
+	rateLimitWindow, err := time.ParseDuration(getEnvOrDefault("AUTH_RATE_LIMIT_WINDOW", "1m"))
+	if err != nil {
+		panic("Invalid AUTH_RATE_LIMIT_WINDOW: " + err.Error())
+	}
+
+	maxFailedLogins, err := strconv.Atoi(getEnvOrDefault("LOGIN_MAX_FAILED_ATTEMPTS", "5"))
+	if err != nil {
+		panic("Invalid LOGIN_MAX_FAILED_ATTEMPTS: " + err.Error())
+	}
+
+	lockoutDuration, err := time.ParseDuration(getEnvOrDefault("LOGIN_LOCKOUT_DURATION", "15m"))
+	if err != nil {
+		panic("Invalid LOGIN_LOCKOUT_DURATION: " + err.Error())
+	}
+
+	impersonationTTL, err := time.ParseDuration(getEnvOrDefault("IMPERSONATION_TTL", "15m"))
+	if err != nil {
+		panic("Invalid IMPERSONATION_TTL: " + err.Error())
+	}
+
+	return &AuthConfig{
+		MagicLinkURL:         getEnvOrDefault("MAGIC_LINK_URL", "http://localhost:3000/auth/magic-link"),
+		MagicLinkTTL:         magicLinkTTL,
+		EmailVerificationURL: getEnvOrDefault("EMAIL_VERIFICATION_URL", "http://localhost:3000/auth/verify-email"),
+		EmailVerificationTTL: emailVerificationTTL,
+		InvitationURL:        getEnvOrDefault("INVITATION_URL", "http://localhost:3000/invitations/accept"),
+		InvitationTTL:        invitationTTL,
+		RateLimit:            rateLimit,
+		RateLimitWindow:      rateLimitWindow,
+		MaxFailedLogins:      maxFailedLogins,
+		LockoutDuration:      lockoutDuration,
+		ImpersonationTTL:     impersonationTTL,
+		Cookie:               *initCookieConfig(),
+	}
+}
+
+func initCookieConfig() *CookieConfig {
+	var sameSite http.SameSite
+	switch getEnvOrDefault("AUTH_COOKIE_SAME_SITE", "lax") {
+	case "lax":
+		sameSite = http.SameSiteLaxMode
+	case "strict":
+		sameSite = http.SameSiteStrictMode
+	case "none":
+		sameSite = http.SameSiteNoneMode
+	default:
+		panic("Invalid AUTH_COOKIE_SAME_SITE: must be lax, strict or none")
+	}
+
+	maxAge, err := time.ParseDuration(getEnvOrDefault("AUTH_COOKIE_MAX_AGE", "24h"))
+	if err != nil {
+		panic("Invalid AUTH_COOKIE_MAX_AGE: " + err.Error())
+	}
+
+	return &CookieConfig{
+		Name:     getEnvOrDefault("AUTH_COOKIE_NAME", "stage_zero_session"),
+		Domain:   getEnvOrDefault("AUTH_COOKIE_DOMAIN", ""),
+		Secure:   getEnvOrDefault("AUTH_COOKIE_SECURE", "true") == "true",
+		SameSite: sameSite,
+		MaxAge:   maxAge,
+	}
+}
+
+func initMailConfig() *MailConfig {
+	return &MailConfig{
+		From: getEnvOrDefault("MAIL_FROM", "no-reply@stage-zero.local"),
+	}
+}
+
+func initPasswordConfig() *PasswordConfig {
+	hashAlgorithm := getEnvOrDefault("PASSWORD_HASH_ALGORITHM", "argon2id")
+	if hashAlgorithm != "argon2id" && hashAlgorithm != "bcrypt" {
+		panic("Invalid PASSWORD_HASH_ALGORITHM: must be argon2id or bcrypt")
+	}
+
+	bcryptCost, err := strconv.Atoi(getEnvOrDefault("BCRYPT_COST", "10"))
+	if err != nil || bcryptCost < 4 || bcryptCost > 31 {
+		panic("Invalid BCRYPT_COST: must be between 4 and 31")
+	}
+
+	argon2Memory, err := strconv.ParseUint(getEnvOrDefault("ARGON2_MEMORY_KIB", "65536"), 10, 32)
+	if err != nil {
+		panic("Invalid ARGON2_MEMORY_KIB: " + err.Error())
+	}
+
+	argon2Iterations, err := strconv.ParseUint(getEnvOrDefault("ARGON2_ITERATIONS", "3"), 10, 32)
+	if err != nil {
+		panic("Invalid ARGON2_ITERATIONS: " + err.Error())
+	}
+
+	argon2Parallelism, err := strconv.ParseUint(getEnvOrDefault("ARGON2_PARALLELISM", "2"), 10, 8)
+	if err != nil {
+		panic("Invalid ARGON2_PARALLELISM: " + err.Error())
+	}
+
+	minLength, err := strconv.Atoi(getEnvOrDefault("PASSWORD_MIN_LENGTH", "8"))
+	if err != nil || minLength < 1 {
+		panic("Invalid PASSWORD_MIN_LENGTH: must be a positive integer")
+	}
+
+	maxLength, err := strconv.Atoi(getEnvOrDefault("PASSWORD_MAX_LENGTH", "256"))
+	if err != nil || maxLength < minLength {
+		panic("Invalid PASSWORD_MAX_LENGTH: must be an integer of at least PASSWORD_MIN_LENGTH")
+	}
+
+	historySize, err := strconv.Atoi(getEnvOrDefault("PASSWORD_HISTORY_SIZE", "5"))
+	if err != nil || historySize < 0 {
+		panic("Invalid PASSWORD_HISTORY_SIZE: must be a non-negative integer")
+	}
+
+	return &PasswordConfig{
+		HashAlgorithm:     hashAlgorithm,
+		BcryptCost:        bcryptCost,
+		Argon2Memory:      uint32(argon2Memory),
+		Argon2Iterations:  uint32(argon2Iterations),
+		Argon2Parallelism: uint8(argon2Parallelism),
+		MinLength:         minLength,
+		MaxLength:         maxLength,
+		RequireUppercase:  getEnvOrDefault("PASSWORD_REQUIRE_UPPERCASE", "false") == "true",
+		RequireLowercase:  getEnvOrDefault("PASSWORD_REQUIRE_LOWERCASE", "false") == "true",
+		RequireDigit:      getEnvOrDefault("PASSWORD_REQUIRE_DIGIT", "false") == "true",
+		RequireSymbol:     getEnvOrDefault("PASSWORD_REQUIRE_SYMBOL", "false") == "true",
+		ForbidEmail:       getEnvOrDefault("PASSWORD_FORBID_EMAIL", "true") == "true",
+		HistorySize:       historySize,
+		BreachedCorpus:    getEnvOrDefault("PASSWORD_BREACHED_CORPUS_PATH", ""),
+	}
+}
+
+func initStorageConfig() *StorageConfig {
+	backend := getEnvOrDefault("STORAGE_BACKEND", "local")
+	if backend != "local" && backend != "s3" {
+		panic("Invalid STORAGE_BACKEND: must be local or s3")
+	}
+
+	return &StorageConfig{
+		Backend:           backend,
+		LocalPath:         getEnvOrDefault("STORAGE_LOCAL_PATH", "data/blobs"),
+		S3Endpoint:        getEnvOrDefault("S3_ENDPOINT", "https://s3.amazonaws.com"),
+		S3Region:          getEnvOrDefault("S3_REGION", "us-east-1"),
+		S3Bucket:          getEnvOrDefault("S3_BUCKET", ""),
+		S3AccessKeyID:     getEnvOrDefault("S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey: getEnvOrDefault("S3_SECRET_ACCESS_KEY", ""),
+		S3UsePathStyle:    getEnvOrDefault("S3_USE_PATH_STYLE", "false") == "true",
+	}
+}
+
+func initAttachmentConfig() *AttachmentConfig {
+	maxSize, err := strconv.ParseInt(getEnvOrDefault("ATTACHMENT_MAX_SIZE", "10485760"), 10, 64)
+	if err != nil || maxSize < 1 {
+		panic("Invalid ATTACHMENT_MAX_SIZE: must be a positive number of bytes")
+	}
+
+	var allowedContentTypes []string
+	for _, contentType := range strings.Split(getEnvOrDefault("ATTACHMENT_ALLOWED_CONTENT_TYPES", "application/pdf,image/png,image/jpeg,image/gif,image/webp,text/plain"), ",") {
+		if contentType = strings.TrimSpace(contentType); contentType != "" {
+			allowedContentTypes = append(allowedContentTypes, strings.ToLower(contentType))
+		}
+	}
+	if len(allowedContentTypes) == 0 {
+		panic("Invalid ATTACHMENT_ALLOWED_CONTENT_TYPES: at least one content type is required")
+	}
+
+	return &AttachmentConfig{
+		MaxSize:             maxSize,
+		AllowedContentTypes: allowedContentTypes,
+	}
+}
+
+func initWebhookConfig() *WebhookConfig {
+	pollInterval, err := time.ParseDuration(getEnvOrDefault("WEBHOOK_POLL_INTERVAL", "5s"))
+	if err != nil || pollInterval <= 0 {
+		panic("Invalid WEBHOOK_POLL_INTERVAL: must be a positive duration")
+	}
+
+	batchSize, err := strconv.Atoi(getEnvOrDefault("WEBHOOK_BATCH_SIZE", "20"))
+	if err != nil || batchSize < 1 {
+		panic("Invalid WEBHOOK_BATCH_SIZE: must be a positive number")
+	}
+
+	timeout, err := time.ParseDuration(getEnvOrDefault("WEBHOOK_TIMEOUT", "10s"))
+	if err != nil || timeout <= 0 {
+		panic("Invalid WEBHOOK_TIMEOUT: must be a positive duration")
+	}
+
+	maxAttempts, err := strconv.Atoi(getEnvOrDefault("WEBHOOK_MAX_ATTEMPTS", "8"))
+	if err != nil || maxAttempts < 1 {
+		panic("Invalid WEBHOOK_MAX_ATTEMPTS: must be a positive number")
+	}
+
+	baseBackoff, err := time.ParseDuration(getEnvOrDefault("WEBHOOK_BASE_BACKOFF", "30s"))
+	if err != nil || baseBackoff <= 0 {
+		panic("Invalid WEBHOOK_BASE_BACKOFF: must be a positive duration")
+	}
+
+	maxBackoff, err := time.ParseDuration(getEnvOrDefault("WEBHOOK_MAX_BACKOFF", "6h"))
+	if err != nil || maxBackoff < baseBackoff {
+		panic("Invalid WEBHOOK_MAX_BACKOFF: must be a duration of at least WEBHOOK_BASE_BACKOFF")
+	}
+
+	return &WebhookConfig{
+		PollInterval: pollInterval,
+		BatchSize:    batchSize,
+		Timeout:      timeout,
+		MaxAttempts:  maxAttempts,
+		BaseBackoff:  baseBackoff,
+		MaxBackoff:   maxBackoff,
+
+		AllowPrivateNetworks: getEnvOrDefault("WEBHOOK_ALLOW_PRIVATE_NETWORKS", "false") == "true",
+	}
+}
+
+func initOutboxConfig() *OutboxConfig {
+	publisher := getEnvOrDefault("OUTBOX_PUBLISHER", "log")
+	if publisher != "log" && publisher != "webhook" && publisher != "nats" {
+		panic("Invalid OUTBOX_PUBLISHER: must be log, webhook or nats")
+	}
+
+	pollInterval, err := time.ParseDuration(getEnvOrDefault("OUTBOX_POLL_INTERVAL", "1s"))
+	if err != nil || pollInterval <= 0 {
+		panic("Invalid OUTBOX_POLL_INTERVAL: must be a positive duration")
+	}
+
+	batchSize, err := strconv.Atoi(getEnvOrDefault("OUTBOX_BATCH_SIZE", "100"))
+	if err != nil || batchSize < 1 {
+		panic("Invalid OUTBOX_BATCH_SIZE: must be a positive number")
+	}
+
+	baseBackoff, err := time.ParseDuration(getEnvOrDefault("OUTBOX_BASE_BACKOFF", "1s"))
+	if err != nil || baseBackoff <= 0 {
+		panic("Invalid OUTBOX_BASE_BACKOFF: must be a positive duration")
+	}
+
+	maxBackoff, err := time.ParseDuration(getEnvOrDefault("OUTBOX_MAX_BACKOFF", "5m"))
+	if err != nil || maxBackoff < baseBackoff {
+		panic("Invalid OUTBOX_MAX_BACKOFF: must be a duration of at least OUTBOX_BASE_BACKOFF")
+	}
+
+	publishTimeout, err := time.ParseDuration(getEnvOrDefault("OUTBOX_PUBLISH_TIMEOUT", "10s"))
+	if err != nil || publishTimeout <= 0 {
+		panic("Invalid OUTBOX_PUBLISH_TIMEOUT: must be a positive duration")
+	}
+
+	webhookURL := getEnvOrDefault("OUTBOX_WEBHOOK_URL", "")
+	if publisher == "webhook" && webhookURL == "" {
+		panic("OUTBOX_WEBHOOK_URL is required with OUTBOX_PUBLISHER=webhook")
+	}
+
+	return &OutboxConfig{
+		Publisher:         publisher,
+		PollInterval:      pollInterval,
+		BatchSize:         batchSize,
+		BaseBackoff:       baseBackoff,
+		MaxBackoff:        maxBackoff,
+		PublishTimeout:    publishTimeout,
+		WebhookURL:        webhookURL,
+		WebhookSecret:     getEnvOrDefault("OUTBOX_WEBHOOK_SECRET", ""),
+		NATSURL:           getEnvOrDefault("NATS_URL", "nats://localhost:4222"),
+		NATSSubjectPrefix: getEnvOrDefault("NATS_SUBJECT_PREFIX", "stage-zero"),
+	}
+}
+
+func initSimpleStreamConfig() *SimpleStreamConfig {
+	logSize, err := strconv.Atoi(getEnvOrDefault("SIMPLE_STREAM_LOG_SIZE", "1000"))
+	if err != nil || logSize < 1 {
+		panic("Invalid SIMPLE_STREAM_LOG_SIZE: must be a positive number")
+	}
+
+	bufferSize, err := strconv.Atoi(getEnvOrDefault("SIMPLE_STREAM_BUFFER_SIZE", "100"))
+	if err != nil || bufferSize < 1 {
+		panic("Invalid SIMPLE_STREAM_BUFFER_SIZE: must be a positive number")
+	}
+
+	heartbeatInterval, err := time.ParseDuration(getEnvOrDefault("SIMPLE_STREAM_HEARTBEAT_INTERVAL", "15s"))
+	if err != nil || heartbeatInterval <= 0 {
+		panic("Invalid SIMPLE_STREAM_HEARTBEAT_INTERVAL: must be a positive duration")
+	}
+
+	retryDelay, err := time.ParseDuration(getEnvOrDefault("SIMPLE_STREAM_RETRY_DELAY", "5s"))
+	if err != nil || retryDelay <= 0 {
+		panic("Invalid SIMPLE_STREAM_RETRY_DELAY: must be a positive duration")
+	}
+
+	return &SimpleStreamConfig{
+		LogSize:           logSize,
+		BufferSize:        bufferSize,
+		HeartbeatInterval: heartbeatInterval,
+		RetryDelay:        retryDelay,
+	}
+}
+
+func initWebSocketConfig() *WebSocketConfig {
+	sendQueueSize, err := strconv.Atoi(getEnvOrDefault("WEBSOCKET_SEND_QUEUE_SIZE", "64"))
+	if err != nil || sendQueueSize < 1 {
+		panic("Invalid WEBSOCKET_SEND_QUEUE_SIZE: must be a positive number")
+	}
+
+	heartbeatTimeout, err := time.ParseDuration(getEnvOrDefault("WEBSOCKET_HEARTBEAT_TIMEOUT", "60s"))
+	if err != nil || heartbeatTimeout <= 0 {
+		panic("Invalid WEBSOCKET_HEARTBEAT_TIMEOUT: must be a positive duration")
+	}
+
+	writeTimeout, err := time.ParseDuration(getEnvOrDefault("WEBSOCKET_WRITE_TIMEOUT", "10s"))
+	if err != nil || writeTimeout <= 0 {
+		panic("Invalid WEBSOCKET_WRITE_TIMEOUT: must be a positive duration")
+	}
+
+	maxSubscriptions, err := strconv.Atoi(getEnvOrDefault("WEBSOCKET_MAX_SUBSCRIPTIONS", "100"))
+	if err != nil || maxSubscriptions < 1 {
+		panic("Invalid WEBSOCKET_MAX_SUBSCRIPTIONS: must be a positive number")
+	}
+
+	reauthorizeInterval, err := time.ParseDuration(getEnvOrDefault("WEBSOCKET_REAUTHORIZE_INTERVAL", "30s"))
+	if err != nil || reauthorizeInterval <= 0 {
+		panic("Invalid WEBSOCKET_REAUTHORIZE_INTERVAL: must be a positive duration")
+	}
+
+	var allowedOrigins []string
+	for _, origin := range strings.Split(getEnvOrDefault("WEBSOCKET_ALLOWED_ORIGINS", ""), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			allowedOrigins = append(allowedOrigins, origin)
+		}
+	}
+
+	return &WebSocketConfig{
+		SendQueueSize:       sendQueueSize,
+		HeartbeatTimeout:    heartbeatTimeout,
+		WriteTimeout:        writeTimeout,
+		MaxSubscriptions:    maxSubscriptions,
+		AllowedOrigins:      allowedOrigins,
+		ReauthorizeInterval: reauthorizeInterval,
+	}
+}
+
+func initJobsConfig() *JobsConfig {
+	workers, err := strconv.Atoi(getEnvOrDefault("JOB_WORKERS", "4"))
+	if err != nil || workers < 1 {
+		panic("Invalid JOB_WORKERS: must be a positive number")
+	}
+
+	pollInterval, err := time.ParseDuration(getEnvOrDefault("JOB_POLL_INTERVAL", "1s"))
+	if err != nil || pollInterval <= 0 {
+		panic("Invalid JOB_POLL_INTERVAL: must be a positive duration")
+	}
+
+	timeout, err := time.ParseDuration(getEnvOrDefault("JOB_TIMEOUT", "5m"))
+	if err != nil || timeout <= 0 {
+		panic("Invalid JOB_TIMEOUT: must be a positive duration")
+	}
+
+	maxAttempts, err := strconv.Atoi(getEnvOrDefault("JOB_MAX_ATTEMPTS", "5"))
+	if err != nil || maxAttempts < 1 {
+		panic("Invalid JOB_MAX_ATTEMPTS: must be a positive number")
+	}
+
+	baseBackoff, err := time.ParseDuration(getEnvOrDefault("JOB_BASE_BACKOFF", "10s"))
+	if err != nil || baseBackoff <= 0 {
+		panic("Invalid JOB_BASE_BACKOFF: must be a positive duration")
+	}
+
+	maxBackoff, err := time.ParseDuration(getEnvOrDefault("JOB_MAX_BACKOFF", "1h"))
+	if err != nil || maxBackoff < baseBackoff {
+		panic("Invalid JOB_MAX_BACKOFF: must be a duration of at least JOB_BASE_BACKOFF")
+	}
+
+	return &JobsConfig{
+		Workers:      workers,
+		PollInterval: pollInterval,
+		Timeout:      timeout,
+		MaxAttempts:  maxAttempts,
+		BaseBackoff:  baseBackoff,
+		MaxBackoff:   maxBackoff,
+	}
+}
+
+func initSchedulerConfig() *SchedulerConfig {
+	pollInterval, err := time.ParseDuration(getEnvOrDefault("SCHEDULER_POLL_INTERVAL", "15s"))
+	if err != nil || pollInterval <= 0 {
+		panic("Invalid SCHEDULER_POLL_INTERVAL: must be a positive duration")
+	}
+
+	timeout, err := time.ParseDuration(getEnvOrDefault("SCHEDULER_TIMEOUT", "10m"))
+	if err != nil || timeout <= 0 {
+		panic("Invalid SCHEDULER_TIMEOUT: must be a positive duration")
+	}
+
+	// Names the instance in the run history, the pod name when running in Kubernetes.
+	hostname, _ := os.Hostname()
+	instance := getEnvOrDefault("SCHEDULER_INSTANCE", hostname)
+	if instance == "" {
+		panic("Invalid SCHEDULER_INSTANCE: must not be empty when the hostname is unknown")
+	}
+
+	tokenCleanupSchedule := getEnvOrDefault("SCHEDULER_TOKEN_CLEANUP_SCHEDULE", "0 * * * *")
+	if schedule, err := cron.Parse(tokenCleanupSchedule); err != nil || schedule.Next(time.Now()).IsZero() {
+		panic("Invalid SCHEDULER_TOKEN_CLEANUP_SCHEDULE: must be a cron expression that fires")
+	}
+
+	tokenCleanupRetention, err := time.ParseDuration(getEnvOrDefault("SCHEDULER_TOKEN_RETENTION", "24h"))
+	if err != nil || tokenCleanupRetention < 0 {
+		panic("Invalid SCHEDULER_TOKEN_RETENTION: must be a duration of at least 0")
+	}
+
+	return &SchedulerConfig{
+		PollInterval:          pollInterval,
+		Timeout:               timeout,
+		Instance:              instance,
+		TokenCleanupSchedule:  tokenCleanupSchedule,
+		TokenCleanupRetention: tokenCleanupRetention,
+	}
+}
+
 func Get() *Config {
 	if globalConfig == nil {
 		panic("Config not initialized")
@@ -1,53 +1,334 @@
 package container
 
 import (
+	"net/http"
+
+	"github.com/Verano-20/stage-zero/internal/config"
 	"github.com/Verano-20/stage-zero/internal/controller"
+	"github.com/Verano-20/stage-zero/internal/cron"
+	"github.com/Verano-20/stage-zero/internal/events"
+	"github.com/Verano-20/stage-zero/internal/mail"
+	"github.com/Verano-20/stage-zero/internal/password"
 	"github.com/Verano-20/stage-zero/internal/repository"
 	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/storage"
 	"gorm.io/gorm"
 )
 
 type Container struct {
-	DB *gorm.DB
+	DB             *gorm.DB
+	MailSender     mail.Sender
+	PasswordHasher password.PasswordHasher
+	BlobStore      storage.BlobStore
+	EventPublisher events.EventPublisher
 
 	// Repositories
-	UserRepository   repository.UserRepository
-	SimpleRepository repository.SimpleRepository
+	UserRepository              repository.UserRepository
+	SimpleRepository            repository.SimpleRepository
+	SimpleGrantRepository       repository.SimpleGrantRepository
+	TagRepository               repository.TagRepository
+	MetadataSchemaRepository    repository.MetadataSchemaRepository
+	AttachmentRepository        repository.AttachmentRepository
+	MagicLinkRepository         repository.MagicLinkRepository
+	EmailVerificationRepository repository.EmailVerificationRepository
+	ImpersonationRepository     repository.ImpersonationRepository
+	PasswordHistoryRepository   repository.PasswordHistoryRepository
+	AuditRepository             repository.AuditRepository
+	SessionRepository           repository.SessionRepository
+	OrganizationRepository      repository.OrganizationRepository
+	MembershipRepository        repository.MembershipRepository
+	InvitationRepository        repository.InvitationRepository
+	WebhookEndpointRepository   repository.WebhookEndpointRepository
+	WebhookDeliveryRepository   repository.WebhookDeliveryRepository
+	OutboxRepository            repository.OutboxRepository
+	SimpleChangeListener        repository.SimpleChangeListener
+	JobRepository               repository.JobRepository
+	ScheduledTaskRepository     repository.ScheduledTaskRepository
+	SchedulerLock               repository.SchedulerLock
+	TxManager                   repository.TxManager
 
 	// Services
-	UserService   service.UserService
-	AuthService   service.AuthService
-	SimpleService service.SimpleService
+	UserService           service.UserService
+	AuthService           service.AuthService
+	SimpleService         service.SimpleService
+	MagicLinkService      service.MagicLinkService
+	ImpersonationService  service.ImpersonationService
+	AuditLogger           service.AuditLogger
+	SessionService        service.SessionService
+	OrganizationService   service.OrganizationService
+	InvitationService     service.InvitationService
+	SignUpService         service.SignUpService
+	MetadataSchemaService service.MetadataSchemaService
+	AttachmentService     service.AttachmentService
+	WebhookService        service.WebhookService
+	WebhookDispatcher     service.WebhookDispatcher
+	OutboxRelay           service.OutboxRelay
+	SimpleStream          service.SimpleStream
+	StreamAuthorizer      service.StreamAuthorizer
+	JobWorkerPool         service.JobWorkerPool
+	Scheduler             service.Scheduler
 
 	// Controllers
-	AuthController   *controller.AuthController
-	SimpleController *controller.SimpleController
+	AuthController           *controller.AuthController
+	UserController           *controller.UserController
+	AdminController          *controller.AdminController
+	AuditController          *controller.AuditController
+	OrganizationController   *controller.OrganizationController
+	InvitationController     *controller.InvitationController
+	MetadataSchemaController *controller.MetadataSchemaController
+	SimpleController         *controller.SimpleController
+	AttachmentController     *controller.AttachmentController
+	WebhookController        *controller.WebhookController
+	WebSocketController      *controller.WebSocketController
+	SchedulerController      *controller.SchedulerController
 }
 
 func NewContainerWithDB(db *gorm.DB) *Container {
 	userRepository := repository.NewUserRepository(db)
 	simpleRepository := repository.NewSimpleRepository(db)
+	simpleGrantRepository := repository.NewSimpleGrantRepository(db)
+	tagRepository := repository.NewTagRepository(db)
+	metadataSchemaRepository := repository.NewMetadataSchemaRepository(db)
+	attachmentRepository := repository.NewAttachmentRepository(db)
+	magicLinkRepository := repository.NewMagicLinkRepository(db)
+	emailVerificationRepository := repository.NewEmailVerificationRepository(db)
+	impersonationRepository := repository.NewImpersonationRepository(db)
+	passwordHistoryRepository := repository.NewPasswordHistoryRepository(db)
+	auditRepository := repository.NewAuditRepository(db)
+	sessionRepository := repository.NewSessionRepository(db)
+	organizationRepository := repository.NewOrganizationRepository(db)
+	membershipRepository := repository.NewMembershipRepository(db)
+	invitationRepository := repository.NewInvitationRepository(db)
+	webhookEndpointRepository := repository.NewWebhookEndpointRepository(db)
+	webhookDeliveryRepository := repository.NewWebhookDeliveryRepository(db)
+	outboxRepository := repository.NewOutboxRepository(db)
+	simpleChangeListener := repository.NewSimpleChangeListener(config.Get().GetDBConnectionString())
+	jobRepository := repository.NewJobRepository(db)
+	scheduledTaskRepository := repository.NewScheduledTaskRepository(db)
+	schedulerLock := repository.NewSchedulerLock(config.Get().GetDBConnectionString())
+	txManager := repository.NewTxManager(db)
+	mailSender := mail.NewLogSender(config.Get().Mail.From)
+	blobStore := newBlobStore(config.Get().Storage)
+	eventPublisher := newEventPublisher(config.Get().Outbox)
 
-	container := NewContainerWithInterfaces(userRepository, simpleRepository)
+	container := NewContainerWithInterfaces(userRepository, simpleRepository, simpleGrantRepository, tagRepository, metadataSchemaRepository, attachmentRepository, magicLinkRepository, emailVerificationRepository, impersonationRepository, passwordHistoryRepository, auditRepository, sessionRepository, organizationRepository, membershipRepository, invitationRepository, webhookEndpointRepository, webhookDeliveryRepository, outboxRepository, simpleChangeListener, jobRepository, scheduledTaskRepository, schedulerLock, txManager, mailSender, blobStore, eventPublisher)
 	container.DB = db
 	return container
 }
 
-func NewContainerWithInterfaces(userRepository repository.UserRepository, simpleRepository repository.SimpleRepository) *Container {
-	userService := service.NewUserService(userRepository)
-	authService := service.NewAuthService(userService)
-	simpleService := service.NewSimpleService(simpleRepository)
+func NewContainerWithInterfaces(userRepository repository.UserRepository, simpleRepository repository.SimpleRepository, simpleGrantRepository repository.SimpleGrantRepository, tagRepository repository.TagRepository, metadataSchemaRepository repository.MetadataSchemaRepository, attachmentRepository repository.AttachmentRepository, magicLinkRepository repository.MagicLinkRepository, emailVerificationRepository repository.EmailVerificationRepository, impersonationRepository repository.ImpersonationRepository, passwordHistoryRepository repository.PasswordHistoryRepository, auditRepository repository.AuditRepository, sessionRepository repository.SessionRepository, organizationRepository repository.OrganizationRepository, membershipRepository repository.MembershipRepository, invitationRepository repository.InvitationRepository, webhookEndpointRepository repository.WebhookEndpointRepository, webhookDeliveryRepository repository.WebhookDeliveryRepository, outboxRepository repository.OutboxRepository, simpleChangeListener repository.SimpleChangeListener, jobRepository repository.JobRepository, scheduledTaskRepository repository.ScheduledTaskRepository, schedulerLock repository.SchedulerLock, txManager repository.TxManager, mailSender mail.Sender, blobStore storage.BlobStore, eventPublisher events.EventPublisher) *Container {
+	authConfig := config.Get().Auth
+	attachmentConfig := config.Get().Attachment
+	webhookConfig := config.Get().Webhook
+	outboxConfig := config.Get().Outbox
+	simpleStreamConfig := config.Get().SimpleStream
+	jobsConfig := config.Get().Jobs
+	schedulerConfig := config.Get().Scheduler
+	webSocketConfig := config.Get().WebSocket
+	passwordConfig := config.Get().Password
+	passwordHasher := password.NewHasher(passwordConfig.HashAlgorithm, passwordConfig.BcryptCost, password.Argon2idParams{
+		Memory:      passwordConfig.Argon2Memory,
+		Iterations:  passwordConfig.Argon2Iterations,
+		Parallelism: passwordConfig.Argon2Parallelism,
+	})
+	passwordPolicy := password.NewPolicy(password.PolicyConfig{
+		MinLength:        passwordConfig.MinLength,
+		MaxLength:        passwordConfig.MaxLength,
+		RequireUppercase: passwordConfig.RequireUppercase,
+		RequireLowercase: passwordConfig.RequireLowercase,
+		RequireDigit:     passwordConfig.RequireDigit,
+		RequireSymbol:    passwordConfig.RequireSymbol,
+		ForbidEmail:      passwordConfig.ForbidEmail,
+		HistorySize:      passwordConfig.HistorySize,
+	}, passwordHasher, loadBreachedPasswordCorpus(passwordConfig.BreachedCorpus))
+
+	jobWorkerPool := service.NewJobWorkerPool(jobRepository, service.JobWorkerPoolConfig{
+		Workers:      jobsConfig.Workers,
+		PollInterval: jobsConfig.PollInterval,
+		Timeout:      jobsConfig.Timeout,
+		MaxAttempts:  jobsConfig.MaxAttempts,
+		BaseBackoff:  jobsConfig.BaseBackoff,
+		MaxBackoff:   jobsConfig.MaxBackoff,
+	})
+	service.RegisterMailJob(jobWorkerPool, mailSender, config.Get().GetJwtSecret())
+	queuedMailSender := service.NewQueuedMailSender(jobWorkerPool, config.Get().GetJwtSecret())
+
+	scheduler := service.NewScheduler(scheduledTaskRepository, schedulerLock, service.SchedulerConfig{
+		PollInterval: schedulerConfig.PollInterval,
+		Timeout:      schedulerConfig.Timeout,
+		Instance:     schedulerConfig.Instance,
+	})
+	scheduler.Register(service.TokenCleanupTaskName, cron.MustParse(schedulerConfig.TokenCleanupSchedule), service.NewTokenCleanupTask(magicLinkRepository, emailVerificationRepository, schedulerConfig.TokenCleanupRetention))
+
+	auditLogger := service.NewAuditLogger(auditRepository)
+	userService := service.NewUserService(txManager, userRepository, emailVerificationRepository, passwordHistoryRepository, sessionRepository, membershipRepository, queuedMailSender, passwordHasher, passwordPolicy, authConfig.EmailVerificationURL, authConfig.EmailVerificationTTL)
+	sessionService := service.NewSessionService(sessionRepository, auditLogger)
+	authService := service.NewAuthService(userService, sessionService, passwordHasher, authConfig.MaxFailedLogins, authConfig.LockoutDuration)
+	metadataSchemaService := service.NewMetadataSchemaService(metadataSchemaRepository, membershipRepository)
+	webhookService := service.NewWebhookService(webhookEndpointRepository, webhookDeliveryRepository, membershipRepository, auditLogger, webhookConfig.AllowPrivateNetworks)
+	webhookDispatcher := service.NewWebhookDispatcher(webhookDeliveryRepository, service.WebhookDispatcherConfig{
+		PollInterval: webhookConfig.PollInterval,
+		BatchSize:    webhookConfig.BatchSize,
+		Timeout:      webhookConfig.Timeout,
+		MaxAttempts:  webhookConfig.MaxAttempts,
+		BaseBackoff:  webhookConfig.BaseBackoff,
+		MaxBackoff:   webhookConfig.MaxBackoff,
+
+		AllowPrivateNetworks: webhookConfig.AllowPrivateNetworks,
+	})
+	outboxRelay := service.NewOutboxRelay(outboxRepository, eventPublisher, service.OutboxRelayConfig{
+		PollInterval: outboxConfig.PollInterval,
+		BatchSize:    outboxConfig.BatchSize,
+		BaseBackoff:  outboxConfig.BaseBackoff,
+		MaxBackoff:   outboxConfig.MaxBackoff,
+	})
+	simpleStream := service.NewSimpleStream(simpleRepository, simpleChangeListener, service.SimpleStreamConfig{
+		LogSize:    simpleStreamConfig.LogSize,
+		BufferSize: simpleStreamConfig.BufferSize,
+		RetryDelay: simpleStreamConfig.RetryDelay,
+	})
+	streamAuthorizer := service.NewStreamAuthorizer(userRepository, sessionRepository, membershipRepository)
+	simpleService := service.NewSimpleService(txManager, simpleRepository, simpleGrantRepository, tagRepository, membershipRepository, userService, metadataSchemaService, webhookService, auditLogger)
+	attachmentService := service.NewAttachmentService(txManager, attachmentRepository, blobStore, auditLogger, attachmentConfig.MaxSize, attachmentConfig.AllowedContentTypes)
+	magicLinkService := service.NewMagicLinkService(userService, magicLinkRepository, queuedMailSender, authConfig.MagicLinkURL, authConfig.MagicLinkTTL)
+	impersonationService := service.NewImpersonationService(authService, impersonationRepository, authConfig.ImpersonationTTL)
+	organizationService := service.NewOrganizationService(txManager, organizationRepository, membershipRepository, userService)
+	invitationService := service.NewInvitationService(invitationRepository, organizationRepository, membershipRepository, userService, queuedMailSender, auditLogger, authConfig.InvitationURL, authConfig.InvitationTTL)
 
-	authController := controller.NewAuthController(userService, authService)
-	simpleController := controller.NewSimpleController(simpleService)
+	signUpService := service.NewSignUpService(txManager, userService, organizationService, invitationService)
+
+	authController := controller.NewAuthController(userService, authService, magicLinkService, signUpService, auditLogger)
+	userController := controller.NewUserController(userService, authService, sessionService)
+	adminController := controller.NewAdminController(userService, impersonationService)
+	auditController := controller.NewAuditController(auditLogger)
+	organizationController := controller.NewOrganizationController(organizationService)
+	invitationController := controller.NewInvitationController(invitationService)
+	metadataSchemaController := controller.NewMetadataSchemaController(metadataSchemaService)
+	simpleController := controller.NewSimpleController(simpleService, simpleStream, streamAuthorizer, simpleStreamConfig.HeartbeatInterval)
+	attachmentController := controller.NewAttachmentController(simpleService, attachmentService, attachmentConfig.MaxSize)
+	webhookController := controller.NewWebhookController(webhookService)
+	webSocketController := controller.NewWebSocketController(simpleService, simpleStream, streamAuthorizer, controller.WebSocketConfig{
+		SendQueueSize:       webSocketConfig.SendQueueSize,
+		HeartbeatTimeout:    webSocketConfig.HeartbeatTimeout,
+		WriteTimeout:        webSocketConfig.WriteTimeout,
+		MaxSubscriptions:    webSocketConfig.MaxSubscriptions,
+		AllowedOrigins:      webSocketConfig.AllowedOrigins,
+		ReauthorizeInterval: webSocketConfig.ReauthorizeInterval,
+	})
+	schedulerController := controller.NewSchedulerController(scheduler)
 
 	return &Container{
-		UserRepository:   userRepository,
-		SimpleRepository: simpleRepository,
-		UserService:      userService,
-		AuthService:      authService,
-		SimpleService:    simpleService,
-		AuthController:   authController,
-		SimpleController: simpleController,
+		MailSender:                  mailSender,
+		PasswordHasher:              passwordHasher,
+		BlobStore:                   blobStore,
+		EventPublisher:              eventPublisher,
+		UserRepository:              userRepository,
+		SimpleRepository:            simpleRepository,
+		SimpleGrantRepository:       simpleGrantRepository,
+		TagRepository:               tagRepository,
+		MetadataSchemaRepository:    metadataSchemaRepository,
+		AttachmentRepository:        attachmentRepository,
+		MagicLinkRepository:         magicLinkRepository,
+		EmailVerificationRepository: emailVerificationRepository,
+		ImpersonationRepository:     impersonationRepository,
+		PasswordHistoryRepository:   passwordHistoryRepository,
+		AuditRepository:             auditRepository,
+		SessionRepository:           sessionRepository,
+		OrganizationRepository:      organizationRepository,
+		MembershipRepository:        membershipRepository,
+		InvitationRepository:        invitationRepository,
+		WebhookEndpointRepository:   webhookEndpointRepository,
+		WebhookDeliveryRepository:   webhookDeliveryRepository,
+		OutboxRepository:            outboxRepository,
+		SimpleChangeListener:        simpleChangeListener,
+		JobRepository:               jobRepository,
+		ScheduledTaskRepository:     scheduledTaskRepository,
+		SchedulerLock:               schedulerLock,
+		TxManager:                   txManager,
+		UserService:                 userService,
+		AuthService:                 authService,
+		SimpleService:               simpleService,
+		MagicLinkService:            magicLinkService,
+		ImpersonationService:        impersonationService,
+		AuditLogger:                 auditLogger,
+		SessionService:              sessionService,
+		OrganizationService:         organizationService,
+		InvitationService:           invitationService,
+		SignUpService:               signUpService,
+		MetadataSchemaService:       metadataSchemaService,
+		AttachmentService:           attachmentService,
+		WebhookService:              webhookService,
+		WebhookDispatcher:           webhookDispatcher,
+		OutboxRelay:                 outboxRelay,
+		SimpleStream:                simpleStream,
+		StreamAuthorizer:            streamAuthorizer,
+		JobWorkerPool:               jobWorkerPool,
+		Scheduler:                   scheduler,
+		AuthController:              authController,
+		UserController:              userController,
+		AdminController:             adminController,
+		AuditController:             auditController,
+		OrganizationController:      organizationController,
+		InvitationController:        invitationController,
+		MetadataSchemaController:    metadataSchemaController,
+		SimpleController:            simpleController,
+		AttachmentController:        attachmentController,
+		WebhookController:           webhookController,
+		WebSocketController:         webSocketController,
+		SchedulerController:         schedulerController,
+	}
+}
+
+func loadBreachedPasswordCorpus(path string) password.BreachedPasswordChecker {
+	if path == "" {
+		return nil
+	}
+
+	breachedPasswords, err := password.LoadBreachedPasswordCorpus(path)
+	if err != nil {
+		panic("Failed to load PASSWORD_BREACHED_CORPUS_PATH: " + err.Error())
+	}
+	return breachedPasswords
+}
+
+func newBlobStore(storageConfig config.StorageConfig) storage.BlobStore {
+	if storageConfig.Backend == storage.BackendS3 {
+		blobStore, err := storage.NewS3BlobStore(storage.S3Config{
+			Endpoint:        storageConfig.S3Endpoint,
+			Region:          storageConfig.S3Region,
+			Bucket:          storageConfig.S3Bucket,
+			AccessKeyID:     storageConfig.S3AccessKeyID,
+			SecretAccessKey: storageConfig.S3SecretAccessKey,
+			UsePathStyle:    storageConfig.S3UsePathStyle,
+		}, nil)
+		if err != nil {
+			panic("Invalid S3 storage configuration: " + err.Error())
+		}
+		return blobStore
+	}
+
+	blobStore, err := storage.NewLocalBlobStore(storageConfig.LocalPath)
+	if err != nil {
+		panic("Failed to prepare STORAGE_LOCAL_PATH: " + err.Error())
+	}
+	return blobStore
+}
+
+func newEventPublisher(outboxConfig config.OutboxConfig) events.EventPublisher {
+	switch outboxConfig.Publisher {
+	case events.PublisherWebhook:
+		return events.NewWebhookPublisher(outboxConfig.WebhookURL, outboxConfig.WebhookSecret, &http.Client{Timeout: outboxConfig.PublishTimeout})
+	case events.PublisherNATS:
+		publisher, err := events.NewNATSPublisher(events.NATSConfig{
+			URL:           outboxConfig.NATSURL,
+			SubjectPrefix: outboxConfig.NATSSubjectPrefix,
+			Timeout:       outboxConfig.PublishTimeout,
+		})
+		if err != nil {
+			panic("Invalid NATS_URL: " + err.Error())
+		}
+		return publisher
+	default:
+		return events.NewLogPublisher()
 	}
 }
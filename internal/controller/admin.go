@@ -0,0 +1,249 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Verano-20/stage-zero/internal/config"
+	"github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/response"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type AdminController struct {
+	UserService          service.UserService
+	ImpersonationService service.ImpersonationService
+}
+
+func NewAdminController(userService service.UserService, impersonationService service.ImpersonationService) *AdminController {
+	return &AdminController{UserService: userService, ImpersonationService: impersonationService}
+}
+
+// ListUsers godoc
+// @Summary List users
+// @Description List users ordered by ID, optionally filtered by a case-insensitive email search. Requires the admin role.
+// @Tags Admin
+// @Produce json
+// @Param search query string false "Email search term"
+// @Param page query int false "Page number, starting at 1"
+// @Param page_size query int false "Page size, at most 100"
+// @Success 200 {object} response.ApiResponse{data=response.PageData{items=[]model.AdminUserDTO}} "Users retrieved successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid query parameters"
+// @Failure 403 {object} response.ErrorResponse "Admin access required"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while retrieving users"
+// @Router /admin/users [get]
+func (c *AdminController) ListUsers(ctx *gin.Context) {
+	var filter model.UserFilter
+	if formErr := ctx.ShouldBindQuery(&filter); formErr != nil {
+		utils.HandleBindingErrors(ctx, formErr, "list_users")
+		return
+	}
+	filter.Normalize()
+
+	users, total, err := c.UserService.ListUsers(ctx, filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to retrieve users"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Users retrieved successfully", Data: response.PageData{
+		Items:    users.ToAdminDTOs(),
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+		Total:    total,
+	}})
+}
+
+// GetUser godoc
+// @Summary Get a user
+// @Description Get a user by ID, including account status. Requires the admin role.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} response.ApiResponse{data=model.AdminUserDTO} "User retrieved successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID format or value"
+// @Failure 403 {object} response.ErrorResponse "Admin access required"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Router /admin/users/{id} [get]
+func (c *AdminController) GetUser(ctx *gin.Context) {
+	user, ok := c.getUserFromPath(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "User retrieved successfully", Data: user.ToAdminDTO()})
+}
+
+// DisableUser godoc
+// @Summary Disable a user
+// @Description Prevent a user from logging in and revoke all of their tokens. Requires the admin role.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} response.ApiResponse{data=model.AdminUserDTO} "User disabled successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID or attempt to disable own account"
+// @Failure 403 {object} response.ErrorResponse "Admin access required"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while disabling user"
+// @Router /admin/users/{id}/disable [post]
+func (c *AdminController) DisableUser(ctx *gin.Context) {
+	user, ok := c.getUserFromPath(ctx)
+	if !ok {
+		return
+	}
+
+	if user.ID == ctx.GetUint("user_id") {
+		ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Cannot disable your own account"})
+		return
+	}
+
+	user, err := c.UserService.DisableUser(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to disable user"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "User disabled successfully", Data: user.ToAdminDTO()})
+}
+
+// EnableUser godoc
+// @Summary Enable a user
+// @Description Re-enable a previously disabled user. Requires the admin role.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} response.ApiResponse{data=model.AdminUserDTO} "User enabled successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID format or value"
+// @Failure 403 {object} response.ErrorResponse "Admin access required"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while enabling user"
+// @Router /admin/users/{id}/enable [post]
+func (c *AdminController) EnableUser(ctx *gin.Context) {
+	user, ok := c.getUserFromPath(ctx)
+	if !ok {
+		return
+	}
+
+	user, err := c.UserService.EnableUser(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to enable user"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "User enabled successfully", Data: user.ToAdminDTO()})
+}
+
+// ForcePasswordReset godoc
+// @Summary Force a password reset
+// @Description Revoke all of a user's tokens and require them to change their password before using the API again. Requires the admin role.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} response.ApiResponse{data=model.AdminUserDTO} "Password reset forced successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID format or value"
+// @Failure 403 {object} response.ErrorResponse "Admin access required"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while forcing password reset"
+// @Router /admin/users/{id}/force-password-reset [post]
+func (c *AdminController) ForcePasswordReset(ctx *gin.Context) {
+	user, ok := c.getUserFromPath(ctx)
+	if !ok {
+		return
+	}
+
+	user, err := c.UserService.ForcePasswordReset(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to force password reset"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Password reset forced successfully", Data: user.ToAdminDTO()})
+}
+
+// UnlockUser godoc
+// @Summary Unlock a user
+// @Description Clear the failed login counter and any lockout on a user. Requires the admin role.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} response.ApiResponse{data=model.AdminUserDTO} "User unlocked successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID format or value"
+// @Failure 403 {object} response.ErrorResponse "Admin access required"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while unlocking user"
+// @Router /admin/users/{id}/unlock [post]
+func (c *AdminController) UnlockUser(ctx *gin.Context) {
+	user, ok := c.getUserFromPath(ctx)
+	if !ok {
+		return
+	}
+
+	user, err := c.UserService.UnlockUser(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to unlock user"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "User unlocked successfully", Data: user.ToAdminDTO()})
+}
+
+// ImpersonateUser godoc
+// @Summary Impersonate a user
+// @Description Issue a short-lived JWT token that acts as the given user on behalf of the calling admin. Responses to requests made with it carry an X-Impersonated-By header and every request is recorded in the impersonation audit trail. Admins and disabled users cannot be impersonated. Requires the admin role.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} response.ApiResponse "Impersonation started, returns JWT token"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID or user cannot be impersonated"
+// @Failure 403 {object} response.ErrorResponse "Admin access required"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while starting impersonation"
+// @Router /admin/users/{id}/impersonate [post]
+func (c *AdminController) ImpersonateUser(ctx *gin.Context) {
+	config := config.Get()
+
+	user, ok := c.getUserFromPath(ctx)
+	if !ok {
+		return
+	}
+
+	tokenString, impersonateErr := c.ImpersonationService.StartImpersonation(ctx, utils.GetRealUser(ctx), user, config.GetJwtSecret())
+	if impersonateErr != nil {
+		var apiError *err.ApiError
+		if errors.As(impersonateErr, &apiError) && apiError.Type == err.ErrorTypeImpersonation {
+			ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "User cannot be impersonated"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to start impersonation"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Impersonation started", Data: map[string]string{"token": tokenString}})
+}
+
+// Loads the user identified by the id path parameter, writing an error response if it is invalid or not found.
+func (c *AdminController) getUserFromPath(ctx *gin.Context) (*model.User, bool) {
+	log := logger.GetFromContext(ctx)
+
+	idParam := ctx.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 64)
+	if err != nil {
+		log.Warn("Invalid user ID format", zap.String("id_param", idParam), zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid ID"})
+		return nil, false
+	}
+
+	user, err := c.UserService.GetUserByID(ctx, uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, response.ErrorResponse{Error: "User not found"})
+		return nil, false
+	}
+
+	return user, true
+}
@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/response"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Allowance for the multipart boundaries and part headers around the uploaded file.
+const multipartOverhead = 1 << 20
+
+type AttachmentController struct {
+	SimpleService     service.SimpleService
+	AttachmentService service.AttachmentService
+	MaxSize           int64
+}
+
+func NewAttachmentController(simpleService service.SimpleService, attachmentService service.AttachmentService, maxSize int64) *AttachmentController {
+	return &AttachmentController{SimpleService: simpleService, AttachmentService: attachmentService, MaxSize: maxSize}
+}
+
+// ListAttachments godoc
+// @Summary List attachments of a Simple
+// @Description List the files attached to a Simple, oldest first.
+// @Tags Simple
+// @Produce json
+// @Param id path int true "Simple ID"
+// @Success 200 {object} response.ApiResponse{data=[]model.AttachmentDTO} "Attachments retrieved successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID format or value"
+// @Failure 404 {object} response.ErrorResponse "Simple not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while retrieving attachments"
+// @Router /simple/{id}/attachments [get]
+func (c *AttachmentController) ListAttachments(ctx *gin.Context) {
+	simple, ok := getSimple(ctx, c.SimpleService)
+	if !ok {
+		return
+	}
+
+	attachments, listErr := c.AttachmentService.ListAttachments(ctx, simple)
+	if listErr != nil {
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to retrieve attachments"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Attachments retrieved successfully", Data: attachments.ToDTOs()})
+}
+
+// UploadAttachment godoc
+// @Summary Attach a file to a Simple
+// @Description Upload a file as multipart form data in the file field. The content type is detected from the file itself and must be one of the allowed types, and the file must not exceed the size limit. Requires write permission on the Simple.
+// @Tags Simple
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Simple ID"
+// @Param file formData file true "File to attach"
+// @Success 201 {object} response.ApiResponse{data=model.AttachmentDTO} "Attachment uploaded successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID or missing file"
+// @Failure 403 {object} response.ErrorResponse "Not allowed to change the Simple"
+// @Failure 404 {object} response.ErrorResponse "Simple not found"
+// @Failure 413 {object} response.ErrorResponse "Attachment is too large"
+// @Failure 415 {object} response.ErrorResponse "Attachment content type is not allowed"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while uploading the attachment"
+// @Router /simple/{id}/attachments [post]
+func (c *AttachmentController) UploadAttachment(ctx *gin.Context) {
+	log := logger.GetFromContext(ctx)
+
+	simple, ok := getSimple(ctx, c.SimpleService)
+	if !ok {
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, c.MaxSize+multipartOverhead)
+	fileHeader, formErr := ctx.FormFile("file")
+	if formErr != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(formErr, &maxBytesErr) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, response.ErrorResponse{Error: "Attachment is too large"})
+			return
+		}
+		log.Warn("Invalid attachment upload", zap.Error(formErr))
+		ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "A file is required in the file field"})
+		return
+	}
+
+	file, openErr := fileHeader.Open()
+	if openErr != nil {
+		log.Error("Failed to open uploaded file", zap.Error(openErr))
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to upload attachment"})
+		return
+	}
+	defer file.Close()
+
+	attachment, uploadErr := c.AttachmentService.UploadAttachment(ctx, simple, model.AttachmentUpload{FileName: fileHeader.Filename, Content: file})
+	if uploadErr != nil {
+		respondWithAttachmentError(ctx, uploadErr, "Failed to upload attachment")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response.ApiResponse{Message: "Attachment uploaded successfully", Data: attachment.ToDTO()})
+}
+
+// DownloadAttachment godoc
+// @Summary Download an attachment
+// @Description Stream the content of a file attached to a Simple, with its detected content type and original file name.
+// @Tags Simple
+// @Produce octet-stream
+// @Param id path int true "Simple ID"
+// @Param attachmentId path int true "Attachment ID"
+// @Success 200 {file} file "Attachment content"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID format or value"
+// @Failure 404 {object} response.ErrorResponse "Simple or attachment not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while reading the attachment"
+// @Router /simple/{id}/attachments/{attachmentId}/content [get]
+func (c *AttachmentController) DownloadAttachment(ctx *gin.Context) {
+	_, attachment, ok := c.getAttachment(ctx)
+	if !ok {
+		return
+	}
+
+	content, openErr := c.AttachmentService.OpenAttachment(ctx, attachment)
+	if openErr != nil {
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to read attachment"})
+		return
+	}
+	defer content.Close()
+
+	ctx.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, content, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}),
+		"ETag":                   `"` + attachment.SHA256 + `"`,
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// DeleteAttachment godoc
+// @Summary Delete an attachment
+// @Description Remove a file attached to a Simple. Requires write permission on the Simple.
+// @Tags Simple
+// @Produce json
+// @Param id path int true "Simple ID"
+// @Param attachmentId path int true "Attachment ID"
+// @Success 200 {object} response.ApiResponse "Attachment deleted successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID format or value"
+// @Failure 403 {object} response.ErrorResponse "Not allowed to change the Simple"
+// @Failure 404 {object} response.ErrorResponse "Simple or attachment not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while deleting the attachment"
+// @Router /simple/{id}/attachments/{attachmentId} [delete]
+func (c *AttachmentController) DeleteAttachment(ctx *gin.Context) {
+	simple, attachment, ok := c.getAttachment(ctx)
+	if !ok {
+		return
+	}
+
+	if deleteErr := c.AttachmentService.DeleteAttachment(ctx, simple, attachment); deleteErr != nil {
+		respondWithAttachmentError(ctx, deleteErr, "Failed to delete attachment")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Attachment deleted successfully", Data: nil})
+}
+
+// Loads the Simple named by the id path parameter and its attachment named by the attachmentId path parameter,
+// writing an error response if either is invalid or not accessible.
+func (c *AttachmentController) getAttachment(ctx *gin.Context) (*model.Simple, *model.Attachment, bool) {
+	simple, ok := getSimple(ctx, c.SimpleService)
+	if !ok {
+		return nil, nil, false
+	}
+
+	attachmentID, ok := parseIDParam(ctx, "attachmentId")
+	if !ok {
+		return nil, nil, false
+	}
+
+	attachment, getErr := c.AttachmentService.GetAttachment(ctx, simple, attachmentID)
+	if getErr != nil {
+		respondWithAttachmentError(ctx, getErr, "Failed to retrieve attachment")
+		return nil, nil, false
+	}
+	return simple, attachment, true
+}
+
+func respondWithAttachmentError(ctx *gin.Context, attachmentErr error, fallbackMessage string) {
+	if errors.Is(attachmentErr, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, response.ErrorResponse{Error: "Attachment not found"})
+		return
+	}
+
+	var apiError *err.ApiError
+	if errors.As(attachmentErr, &apiError) {
+		switch apiError.Type {
+		case err.ErrorTypeAttachmentTooLarge:
+			ctx.JSON(http.StatusRequestEntityTooLarge, response.ErrorResponse{Error: apiError.Err.Error()})
+			return
+		case err.ErrorTypeAttachmentType:
+			ctx.JSON(http.StatusUnsupportedMediaType, response.ErrorResponse{Error: apiError.Err.Error()})
+			return
+		}
+	}
+	respondWithSimpleError(ctx, attachmentErr, fallbackMessage)
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/response"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type AuditController struct {
+	AuditLogger service.AuditLogger
+}
+
+func NewAuditController(auditLogger service.AuditLogger) *AuditController {
+	return &AuditController{AuditLogger: auditLogger}
+}
+
+// ListEvents godoc
+// @Summary List audit events
+// @Description List security audit events, newest first, optionally filtered by actor, action, target, outcome and time range. Requires the admin role.
+// @Tags Admin
+// @Produce json
+// @Param actor_id query int false "Actor user ID"
+// @Param action query string false "Action, e.g. auth.login"
+// @Param target_type query string false "Target type, e.g. user or simple"
+// @Param target_id query string false "Target ID"
+// @Param outcome query string false "Outcome" Enums(success, failure)
+// @Param from query string false "Only events at or after this RFC 3339 time"
+// @Param to query string false "Only events before this RFC 3339 time"
+// @Param page query int false "Page number, starting at 1"
+// @Param page_size query int false "Page size, at most 200"
+// @Success 200 {object} response.ApiResponse{data=response.PageData{items=[]model.AuditEvent}} "Audit events retrieved successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid query parameters"
+// @Failure 403 {object} response.ErrorResponse "Admin access required"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while retrieving audit events"
+// @Router /admin/audit-events [get]
+func (c *AuditController) ListEvents(ctx *gin.Context) {
+	var filter model.AuditEventFilter
+	if formErr := ctx.ShouldBindQuery(&filter); formErr != nil {
+		utils.HandleBindingErrors(ctx, formErr, "list_audit_events")
+		return
+	}
+	filter.Normalize()
+
+	events, total, err := c.AuditLogger.ListEvents(ctx, filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to retrieve audit events"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Audit events retrieved successfully", Data: response.PageData{
+		Items:    events,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+		Total:    total,
+	}})
+}
+
+// VerifyChain godoc
+// @Summary Verify the audit log
+// @Description Recompute the audit log hash chain to detect modified, inserted or deleted events. Requires the admin role.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} response.ApiResponse{data=model.AuditChainVerification} "Audit log verified, see valid for the result"
+// @Failure 403 {object} response.ErrorResponse "Admin access required"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while verifying the audit log"
+// @Router /admin/audit-events/verify [get]
+func (c *AuditController) VerifyChain(ctx *gin.Context) {
+	result, err := c.AuditLogger.VerifyChain(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to verify audit log"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Audit log verified", Data: result})
+}
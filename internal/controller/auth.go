@@ -3,6 +3,8 @@ package controller
 import (
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/Verano-20/stage-zero/internal/config"
 	"github.com/Verano-20/stage-zero/internal/err"
@@ -15,38 +17,43 @@ import (
 )
 
 type AuthController struct {
-	UserService service.UserService
-	AuthService service.AuthService
+	UserService      service.UserService
+	AuthService      service.AuthService
+	MagicLinkService service.MagicLinkService
+	SignUpService    service.SignUpService
+	AuditLogger      service.AuditLogger
 }
 
-func NewAuthController(userService service.UserService, authService service.AuthService) *AuthController {
-	return &AuthController{UserService: userService, AuthService: authService}
+func NewAuthController(userService service.UserService, authService service.AuthService, magicLinkService service.MagicLinkService, signUpService service.SignUpService, auditLogger service.AuditLogger) *AuthController {
+	return &AuthController{UserService: userService, AuthService: authService, MagicLinkService: magicLinkService, SignUpService: signUpService, AuditLogger: auditLogger}
 }
 
 // SignUp godoc
 // @Summary Sign up a new user
-// @Description Create a new user with email and password. The email must be unique. The password must be at least 8 characters long.
+// @Description Create a new user with email and password. The email must be unique. The password must satisfy the password policy; violated rules are listed in details. A personal organization owned by the user is created with it, unless an invitation token for the same email is given, in which case the user joins the inviting organization instead and the email is marked verified.
 // @Tags Authentication
 // @Accept json
 // @Produce json
-// @Param user body model.UserForm true "User details"
+// @Param user body model.SignUpForm true "User details, and optionally an invitation token"
 // @Success 201 {object} model.UserDTO "User created successfully"
-// @Failure 400 {object} response.ErrorResponse "Invalid request format or validation failed"
+// @Failure 400 {object} response.ErrorResponse "Invalid request format, validation failed, password does not meet policy or invalid invitation"
 // @Failure 409 {object} response.ErrorResponse "User already exists"
 // @Failure 500 {object} response.ErrorResponse "Internal server error during user creation"
 // @Router /auth/signup [post]
 func (c *AuthController) SignUp(ctx *gin.Context) {
 	metrics := telemetry.GetMetrics()
 
-	var userForm model.UserForm
-	if formErr := ctx.ShouldBindJSON(&userForm); formErr != nil {
+	var signUpForm model.SignUpForm
+	if formErr := ctx.ShouldBindJSON(&signUpForm); formErr != nil {
 		utils.HandleBindingErrors(ctx, formErr, "signup")
 		return
 	}
+	userForm := signUpForm.UserForm
 
-	user, createErr := c.UserService.CreateUser(ctx, userForm)
+	user, _, createErr := c.SignUpService.SignUp(ctx, signUpForm, config.Get().GetJwtSecret())
 	if createErr != nil {
 		metrics.RecordAuthAttempt(ctx, false, "signup")
+		c.recordAuthFailure(ctx, model.AuditActionSignUp, userForm.Email, createErr)
 		var apiError *err.ApiError
 		if errors.As(createErr, &apiError) {
 			switch apiError.Type {
@@ -56,6 +63,12 @@ func (c *AuthController) SignUp(ctx *gin.Context) {
 			case err.ErrorTypeEmailExists:
 				ctx.JSON(http.StatusConflict, response.ErrorResponse{Error: "User already exists"})
 				return
+			case err.ErrorTypePasswordPolicy:
+				ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Password does not meet policy", Details: apiError.Details})
+				return
+			case err.ErrorTypeInvitationInvalid:
+				ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid or expired invitation"})
+				return
 			}
 		}
 		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to create user"})
@@ -63,6 +76,7 @@ func (c *AuthController) SignUp(ctx *gin.Context) {
 	}
 
 	metrics.RecordAuthAttempt(ctx, true, "signup")
+	c.recordAuthSuccess(ctx, model.AuditActionSignUp, user)
 	ctx.JSON(http.StatusCreated, response.ApiResponse{Message: "User created successfully", Data: user.ToDTO()})
 }
 
@@ -73,14 +87,17 @@ func (c *AuthController) SignUp(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param user body model.UserForm true "User login credentials"
-// @Success 200 {object} response.ApiResponse "Authentication successful, returns JWT token"
+// @Param mode query string false "Set to cookie to receive the token in an HttpOnly session cookie instead of the body" Enums(cookie)
+// @Success 200 {object} response.ApiResponse "Authentication successful, returns JWT token, or a CSRF token in cookie mode"
 // @Failure 400 {object} response.ErrorResponse "Invalid request format or validation failed"
 // @Failure 401 {object} response.ErrorResponse "Invalid credentials"
+// @Failure 403 {object} response.ErrorResponse "Account disabled"
+// @Failure 423 {object} response.ErrorResponse "Account locked"
+// @Failure 429 {object} response.ErrorResponse "Too many requests"
 // @Failure 500 {object} response.ErrorResponse "Internal server error during authentication"
 // @Router /auth/login [post]
 func (c *AuthController) Login(ctx *gin.Context) {
 	metrics := telemetry.GetMetrics()
-	config := config.Get()
 
 	var userForm model.UserForm
 	if formErr := ctx.ShouldBindJSON(&userForm); formErr != nil {
@@ -88,19 +105,176 @@ func (c *AuthController) Login(ctx *gin.Context) {
 		return
 	}
 
-	user, err := c.AuthService.ValidateUserCredentials(ctx, userForm)
-	if err != nil {
+	user, validateErr := c.AuthService.ValidateUserCredentials(ctx, userForm)
+	if validateErr != nil {
 		metrics.RecordAuthAttempt(ctx, false, "login")
+		c.recordAuthFailure(ctx, model.AuditActionLogin, userForm.Email, validateErr)
+		var apiError *err.ApiError
+		if errors.As(validateErr, &apiError) {
+			switch apiError.Type {
+			case err.ErrorTypeAccountDisabled:
+				ctx.JSON(http.StatusForbidden, response.ErrorResponse{Error: "Account disabled"})
+				return
+			case err.ErrorTypeAccountLocked:
+				ctx.JSON(http.StatusLocked, response.ErrorResponse{Error: "Account locked"})
+				return
+			}
+		}
 		ctx.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: "Invalid credentials"})
 		return
 	}
 
+	c.respondWithToken(ctx, user, "login", model.AuditActionLogin)
+}
+
+// RequestMagicLink godoc
+// @Summary Request a magic login link
+// @Description Email a single-use, short-lived login link to the given address. The response is the same whether or not the email is registered.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param magicLink body model.MagicLinkForm true "Email to send the login link to"
+// @Success 200 {object} response.ApiResponse "Magic link sent if the email is registered"
+// @Failure 400 {object} response.ErrorResponse "Invalid request format or validation failed"
+// @Failure 429 {object} response.ErrorResponse "Too many requests"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while sending the magic link"
+// @Router /auth/magic-link [post]
+func (c *AuthController) RequestMagicLink(ctx *gin.Context) {
+	var magicLinkForm model.MagicLinkForm
+	if formErr := ctx.ShouldBindJSON(&magicLinkForm); formErr != nil {
+		utils.HandleBindingErrors(ctx, formErr, "magic_link")
+		return
+	}
+
+	if err := c.MagicLinkService.SendMagicLink(ctx, magicLinkForm); err != nil {
+		c.recordAuthFailure(ctx, model.AuditActionMagicLinkSent, magicLinkForm.Email, err)
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to send magic link"})
+		return
+	}
+
+	c.AuditLogger.Record(ctx, &model.AuditEvent{Action: model.AuditActionMagicLinkSent, ActorEmail: magicLinkForm.Email, Outcome: model.AuditOutcomeSuccess})
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "If the email is registered, a login link has been sent"})
+}
+
+// ConsumeMagicLink godoc
+// @Summary Log in with a magic link
+// @Description Exchange a magic link token for a JWT token. Each magic link can only be used once.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param magicLink body model.MagicLinkConsumeForm true "Magic link token"
+// @Param mode query string false "Set to cookie to receive the token in an HttpOnly session cookie instead of the body" Enums(cookie)
+// @Success 200 {object} response.ApiResponse "Authentication successful, returns JWT token, or a CSRF token in cookie mode"
+// @Failure 400 {object} response.ErrorResponse "Invalid request format or validation failed"
+// @Failure 401 {object} response.ErrorResponse "Invalid or expired magic link"
+// @Failure 429 {object} response.ErrorResponse "Too many requests"
+// @Failure 500 {object} response.ErrorResponse "Internal server error during authentication"
+// @Router /auth/magic-link/consume [post]
+func (c *AuthController) ConsumeMagicLink(ctx *gin.Context) {
+	metrics := telemetry.GetMetrics()
+
+	var consumeForm model.MagicLinkConsumeForm
+	if formErr := ctx.ShouldBindJSON(&consumeForm); formErr != nil {
+		utils.HandleBindingErrors(ctx, formErr, "magic_link_consume")
+		return
+	}
+
+	user, err := c.MagicLinkService.ConsumeMagicLink(ctx, consumeForm.Token)
+	if err != nil {
+		metrics.RecordAuthAttempt(ctx, false, "magic_link")
+		c.recordAuthFailure(ctx, model.AuditActionMagicLinkLogin, "", err)
+		ctx.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: "Invalid or expired magic link"})
+		return
+	}
+
+	c.respondWithToken(ctx, user, "magic_link", model.AuditActionMagicLinkLogin)
+}
+
+func (c *AuthController) respondWithToken(ctx *gin.Context, user *model.User, method string, auditAction string) {
+	metrics := telemetry.GetMetrics()
+	config := config.Get()
+
 	tokenString, err := c.AuthService.GenerateTokenString(ctx, user, config.GetJwtSecret())
 	if err != nil {
+		c.recordAuthFailure(ctx, auditAction, user.Email, err)
 		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to generate token"})
 		return
 	}
 
-	metrics.RecordAuthAttempt(ctx, true, "login")
-	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Login successful", Data: map[string]string{"token": tokenString}})
+	metrics.RecordAuthAttempt(ctx, true, method)
+	c.recordAuthSuccess(ctx, auditAction, user)
+	respondWithNewToken(ctx, tokenString, "Login successful", ctx.Query("mode") == loginModeCookie)
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Revoke the session of the Bearer token, or of the session cookie set by a cookie mode login, so the token is rejected from then on, and clear the session and CSRF cookies. Cookie sessions must send their CSRF token in the X-CSRF-Token header.
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} response.ApiResponse "Logged out"
+// @Failure 401 {object} response.ErrorResponse "Invalid authorization header format"
+// @Failure 403 {object} response.ErrorResponse "Invalid CSRF token"
+// @Failure 500 {object} response.ErrorResponse "Internal server error during session revocation"
+// @Router /auth/logout [post]
+func (c *AuthController) Logout(ctx *gin.Context) {
+	config := config.Get()
+
+	tokenString, fromCookie, tokenErr := extractLogoutToken(ctx, config.Auth.Cookie.Name)
+	if tokenErr != nil {
+		ctx.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: tokenErr.Error()})
+		return
+	}
+
+	if fromCookie && !utils.ValidateCSRFToken(tokenString, ctx.GetHeader(utils.CSRFHeaderName), config.GetJwtSecret()) {
+		ctx.JSON(http.StatusForbidden, response.ErrorResponse{Error: "invalid CSRF token"})
+		return
+	}
+
+	if tokenString != "" {
+		if revokeErr := c.AuthService.RevokeTokenSession(ctx, tokenString, config.GetJwtSecret()); revokeErr != nil {
+			ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to log out"})
+			return
+		}
+	}
+
+	utils.ClearSessionCookies(ctx, config.Auth.Cookie)
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Logged out"})
+}
+
+func (c *AuthController) recordAuthSuccess(ctx *gin.Context, action string, user *model.User) {
+	c.AuditLogger.Record(ctx, &model.AuditEvent{
+		Action:     action,
+		ActorID:    &user.ID,
+		ActorEmail: user.Email,
+		TargetType: model.AuditTargetTypeUser,
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+		Outcome:    model.AuditOutcomeSuccess,
+	})
+}
+
+// Records a failed attempt against the email that was given, as the user may not exist.
+func (c *AuthController) recordAuthFailure(ctx *gin.Context, action string, email string, failureErr error) {
+	c.AuditLogger.Record(ctx, &model.AuditEvent{
+		Action:     action,
+		ActorEmail: email,
+		Outcome:    model.AuditOutcomeFailure,
+		Reason:     failureErr.Error(),
+	})
+}
+
+// Returns the token to log out of from the Authorization header or, failing that, the session cookie, and whether it
+// came from the cookie.
+func extractLogoutToken(ctx *gin.Context, cookieName string) (string, bool, error) {
+	if authHeader := ctx.GetHeader("Authorization"); authHeader != "" {
+		tokenString, found := strings.CutPrefix(authHeader, "Bearer ")
+		if !found || tokenString == "" {
+			return "", false, errors.New("invalid authorization header format")
+		}
+		return tokenString, false, nil
+	}
+
+	if cookie, err := ctx.Cookie(cookieName); err == nil && cookie != "" {
+		return cookie, true, nil
+	}
+	return "", false, nil
 }
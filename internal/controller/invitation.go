@@ -0,0 +1,236 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Verano-20/stage-zero/internal/config"
+	"github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/response"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type InvitationController struct {
+	InvitationService service.InvitationService
+}
+
+func NewInvitationController(invitationService service.InvitationService) *InvitationController {
+	return &InvitationController{InvitationService: invitationService}
+}
+
+// CreateInvitation godoc
+// @Summary Invite someone to an organization
+// @Description Email an invitation to join the organization. Requires the owner or admin role, and only owners can invite owners. The invitation link expires after INVITATION_TTL.
+// @Tags Organization
+// @Accept json
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param invitation body model.InvitationForm true "Email to invite and the role to grant"
+// @Success 201 {object} response.ApiResponse{data=model.InvitationDTO} "Invitation sent successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID, request format or validation failed"
+// @Failure 403 {object} response.ErrorResponse "Not allowed to invite with this role"
+// @Failure 404 {object} response.ErrorResponse "Organization not found"
+// @Failure 409 {object} response.ErrorResponse "User is already a member or already invited"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while sending the invitation"
+// @Router /organizations/{id}/invitations [post]
+func (c *InvitationController) CreateInvitation(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	organizationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var invitationForm model.InvitationForm
+	if formErr := ctx.ShouldBindJSON(&invitationForm); formErr != nil {
+		utils.HandleBindingErrors(ctx, formErr, "create_invitation")
+		return
+	}
+
+	invitation, createErr := c.InvitationService.CreateInvitation(ctx, user, organizationID, invitationForm, config.Get().GetJwtSecret())
+	if createErr != nil {
+		respondWithInvitationError(ctx, createErr, "Failed to send invitation")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response.ApiResponse{Message: "Invitation sent successfully", Data: invitation.ToDTO()})
+}
+
+// ListInvitations godoc
+// @Summary List an organization's pending invitations
+// @Description List the invitations of the organization that have not been answered or revoked, including expired ones that can be resent. Requires the owner or admin role.
+// @Tags Organization
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Success 200 {object} response.ApiResponse{data=[]model.InvitationDTO} "Invitations retrieved successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 403 {object} response.ErrorResponse "Not allowed to manage invitations"
+// @Failure 404 {object} response.ErrorResponse "Organization not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while listing invitations"
+// @Router /organizations/{id}/invitations [get]
+func (c *InvitationController) ListInvitations(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	organizationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	invitations, listErr := c.InvitationService.ListInvitations(ctx, user, organizationID)
+	if listErr != nil {
+		respondWithInvitationError(ctx, listErr, "Failed to list invitations")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Invitations retrieved successfully", Data: invitations.ToDTOs()})
+}
+
+// ResendInvitation godoc
+// @Summary Resend an invitation
+// @Description Email a new link for a pending invitation and restart its expiry. Links sent before stop working.
+// @Tags Organization
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param invitationId path int true "Invitation ID"
+// @Success 200 {object} response.ApiResponse{data=model.InvitationDTO} "Invitation resent successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 403 {object} response.ErrorResponse "Not allowed to manage this invitation"
+// @Failure 404 {object} response.ErrorResponse "Organization or invitation not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while resending the invitation"
+// @Router /organizations/{id}/invitations/{invitationId}/resend [post]
+func (c *InvitationController) ResendInvitation(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	organizationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	invitationID, ok := parseIDParam(ctx, "invitationId")
+	if !ok {
+		return
+	}
+
+	invitation, resendErr := c.InvitationService.ResendInvitation(ctx, user, organizationID, invitationID, config.Get().GetJwtSecret())
+	if resendErr != nil {
+		respondWithInvitationError(ctx, resendErr, "Failed to resend invitation")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Invitation resent successfully", Data: invitation.ToDTO()})
+}
+
+// RevokeInvitation godoc
+// @Summary Revoke an invitation
+// @Description Withdraw a pending invitation so its link can no longer be used.
+// @Tags Organization
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param invitationId path int true "Invitation ID"
+// @Success 200 {object} response.ApiResponse "Invitation revoked successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 403 {object} response.ErrorResponse "Not allowed to manage this invitation"
+// @Failure 404 {object} response.ErrorResponse "Organization or invitation not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while revoking the invitation"
+// @Router /organizations/{id}/invitations/{invitationId} [delete]
+func (c *InvitationController) RevokeInvitation(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	organizationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	invitationID, ok := parseIDParam(ctx, "invitationId")
+	if !ok {
+		return
+	}
+
+	if revokeErr := c.InvitationService.RevokeInvitation(ctx, user, organizationID, invitationID); revokeErr != nil {
+		respondWithInvitationError(ctx, revokeErr, "Failed to revoke invitation")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Invitation revoked successfully", Data: nil})
+}
+
+// AcceptInvitation godoc
+// @Summary Accept an invitation
+// @Description Join the organization with the account registered to the invited email. If there is none, an account is created with the given password. Either way the email is marked verified.
+// @Tags Invitation
+// @Accept json
+// @Produce json
+// @Param invitation body model.AcceptInvitationForm true "Invitation token, and a password if no account exists"
+// @Success 200 {object} response.ApiResponse{data=model.OrganizationDTO} "Invitation accepted successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid or expired invitation, password required or password does not meet policy"
+// @Failure 403 {object} response.ErrorResponse "Account disabled"
+// @Failure 409 {object} response.ErrorResponse "User is already a member"
+// @Failure 429 {object} response.ErrorResponse "Too many requests"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while accepting the invitation"
+// @Router /invitations/accept [post]
+func (c *InvitationController) AcceptInvitation(ctx *gin.Context) {
+	var acceptForm model.AcceptInvitationForm
+	if formErr := ctx.ShouldBindJSON(&acceptForm); formErr != nil {
+		utils.HandleBindingErrors(ctx, formErr, "accept_invitation")
+		return
+	}
+
+	membership, acceptErr := c.InvitationService.AcceptInvitation(ctx, acceptForm, config.Get().GetJwtSecret())
+	if acceptErr != nil {
+		respondWithInvitationError(ctx, acceptErr, "Failed to accept invitation")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Invitation accepted successfully", Data: membership.ToOrganizationDTO()})
+}
+
+// DeclineInvitation godoc
+// @Summary Decline an invitation
+// @Description Decline an invitation so its link can no longer be used.
+// @Tags Invitation
+// @Accept json
+// @Produce json
+// @Param invitation body model.InvitationTokenForm true "Invitation token"
+// @Success 200 {object} response.ApiResponse "Invitation declined successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid or expired invitation"
+// @Failure 429 {object} response.ErrorResponse "Too many requests"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while declining the invitation"
+// @Router /invitations/decline [post]
+func (c *InvitationController) DeclineInvitation(ctx *gin.Context) {
+	var tokenForm model.InvitationTokenForm
+	if formErr := ctx.ShouldBindJSON(&tokenForm); formErr != nil {
+		utils.HandleBindingErrors(ctx, formErr, "decline_invitation")
+		return
+	}
+
+	if declineErr := c.InvitationService.DeclineInvitation(ctx, tokenForm.Token, config.Get().GetJwtSecret()); declineErr != nil {
+		respondWithInvitationError(ctx, declineErr, "Failed to decline invitation")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Invitation declined successfully", Data: nil})
+}
+
+func respondWithInvitationError(ctx *gin.Context, invitationErr error, fallbackMessage string) {
+	var apiError *err.ApiError
+	if errors.As(invitationErr, &apiError) {
+		switch apiError.Type {
+		case err.ErrorTypeInvitationNotFound:
+			ctx.JSON(http.StatusNotFound, response.ErrorResponse{Error: "Invitation not found"})
+			return
+		case err.ErrorTypeInvitationInvalid:
+			ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid or expired invitation"})
+			return
+		case err.ErrorTypeInvitationPending:
+			ctx.JSON(http.StatusConflict, response.ErrorResponse{Error: "An invitation is already pending for this email"})
+			return
+		case err.ErrorTypePasswordRequired:
+			ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Password is required to create an account"})
+			return
+		case err.ErrorTypePasswordPolicy:
+			ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Password does not meet policy", Details: apiError.Details})
+			return
+		case err.ErrorTypeAccountDisabled:
+			ctx.JSON(http.StatusForbidden, response.ErrorResponse{Error: "Account disabled"})
+			return
+		}
+	}
+	respondWithOrganizationError(ctx, invitationErr, fallbackMessage)
+}
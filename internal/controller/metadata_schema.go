@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/response"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type MetadataSchemaController struct {
+	MetadataSchemaService service.MetadataSchemaService
+}
+
+func NewMetadataSchemaController(metadataSchemaService service.MetadataSchemaService) *MetadataSchemaController {
+	return &MetadataSchemaController{MetadataSchemaService: metadataSchemaService}
+}
+
+// ListSchemas godoc
+// @Summary List an organization's metadata schemas
+// @Description List the JSON Schemas that Simple metadata is validated against. The schema with an empty kind applies to every Simple. Requires the owner or admin role.
+// @Tags Organization
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Success 200 {object} response.ApiResponse{data=[]model.MetadataSchemaDTO} "Metadata schemas retrieved successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 403 {object} response.ErrorResponse "Not allowed to manage metadata schemas"
+// @Failure 404 {object} response.ErrorResponse "Organization not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while listing metadata schemas"
+// @Router /organizations/{id}/metadata-schemas [get]
+func (c *MetadataSchemaController) ListSchemas(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	organizationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	schemas, listErr := c.MetadataSchemaService.ListSchemas(ctx, user, organizationID)
+	if listErr != nil {
+		respondWithMetadataSchemaError(ctx, listErr, "Failed to list metadata schemas")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Metadata schemas retrieved successfully", Data: schemas.ToDTOs()})
+}
+
+// PutSchema godoc
+// @Summary Register a metadata schema
+// @Description Register the JSON Schema that Simple metadata must match, for every Simple (empty kind) or for Simples of one kind. Replaces the schema registered before for the same kind. Requires the owner or admin role.
+// @Tags Organization
+// @Accept json
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param schema body model.MetadataSchemaForm true "Kind and JSON Schema"
+// @Success 200 {object} response.ApiResponse{data=model.MetadataSchemaDTO} "Metadata schema registered successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID, request format or JSON Schema"
+// @Failure 403 {object} response.ErrorResponse "Not allowed to manage metadata schemas"
+// @Failure 404 {object} response.ErrorResponse "Organization not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while registering the metadata schema"
+// @Router /organizations/{id}/metadata-schemas [put]
+func (c *MetadataSchemaController) PutSchema(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	organizationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var schemaForm model.MetadataSchemaForm
+	if formErr := ctx.ShouldBindJSON(&schemaForm); formErr != nil {
+		utils.HandleBindingErrors(ctx, formErr, "put_metadata_schema")
+		return
+	}
+
+	schema, putErr := c.MetadataSchemaService.PutSchema(ctx, user, organizationID, schemaForm)
+	if putErr != nil {
+		respondWithMetadataSchemaError(ctx, putErr, "Failed to register metadata schema")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Metadata schema registered successfully", Data: schema.ToDTO()})
+}
+
+// DeleteSchema godoc
+// @Summary Delete a metadata schema
+// @Description Stop validating Simple metadata against the schema. Requires the owner or admin role.
+// @Tags Organization
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param schemaId path int true "Metadata schema ID"
+// @Success 200 {object} response.ApiResponse "Metadata schema deleted successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 403 {object} response.ErrorResponse "Not allowed to manage metadata schemas"
+// @Failure 404 {object} response.ErrorResponse "Organization or metadata schema not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while deleting the metadata schema"
+// @Router /organizations/{id}/metadata-schemas/{schemaId} [delete]
+func (c *MetadataSchemaController) DeleteSchema(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	organizationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	schemaID, ok := parseIDParam(ctx, "schemaId")
+	if !ok {
+		return
+	}
+
+	if deleteErr := c.MetadataSchemaService.DeleteSchema(ctx, user, organizationID, schemaID); deleteErr != nil {
+		respondWithMetadataSchemaError(ctx, deleteErr, "Failed to delete metadata schema")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Metadata schema deleted successfully", Data: nil})
+}
+
+func respondWithMetadataSchemaError(ctx *gin.Context, schemaErr error, fallbackMessage string) {
+	var apiError *err.ApiError
+	if errors.As(schemaErr, &apiError) {
+		switch apiError.Type {
+		case err.ErrorTypeSchemaInvalid:
+			ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid JSON Schema", Details: map[string]string{"schema": apiError.Err.Error()}})
+			return
+		case err.ErrorTypeSchemaNotFound:
+			ctx.JSON(http.StatusNotFound, response.ErrorResponse{Error: "Metadata schema not found"})
+			return
+		}
+	}
+	respondWithOrganizationError(ctx, schemaErr, fallbackMessage)
+}
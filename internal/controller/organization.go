@@ -0,0 +1,219 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/response"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type OrganizationController struct {
+	OrganizationService service.OrganizationService
+}
+
+func NewOrganizationController(organizationService service.OrganizationService) *OrganizationController {
+	return &OrganizationController{OrganizationService: organizationService}
+}
+
+// CreateOrganization godoc
+// @Summary Create an organization
+// @Description Create an organization owned by the authenticated user.
+// @Tags Organization
+// @Accept json
+// @Produce json
+// @Param organization body model.OrganizationForm true "Organization details"
+// @Success 201 {object} response.ApiResponse{data=model.OrganizationDTO} "Organization created successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid request format or validation failed"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while creating organization"
+// @Router /organizations [post]
+func (c *OrganizationController) CreateOrganization(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	if user == nil {
+		ctx.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	var organizationForm model.OrganizationForm
+	if formErr := ctx.ShouldBindJSON(&organizationForm); formErr != nil {
+		utils.HandleBindingErrors(ctx, formErr, "create_organization")
+		return
+	}
+
+	organization, createErr := c.OrganizationService.CreateOrganization(ctx, user, organizationForm)
+	if createErr != nil {
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to create organization"})
+		return
+	}
+
+	membership := &model.Membership{OrganizationID: organization.ID, UserID: user.ID, Role: model.OrganizationRoleOwner, Organization: organization}
+	ctx.JSON(http.StatusCreated, response.ApiResponse{Message: "Organization created successfully", Data: membership.ToOrganizationDTO()})
+}
+
+// ListOrganizations godoc
+// @Summary List the authenticated user's organizations
+// @Description List the organizations the authenticated user belongs to, with their role in each.
+// @Tags Organization
+// @Produce json
+// @Success 200 {object} response.ApiResponse{data=[]model.OrganizationDTO} "Organizations retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while listing organizations"
+// @Router /organizations [get]
+func (c *OrganizationController) ListOrganizations(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	if user == nil {
+		ctx.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	memberships, listErr := c.OrganizationService.ListMemberships(ctx, user)
+	if listErr != nil {
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to list organizations"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Organizations retrieved successfully", Data: memberships.ToOrganizationDTOs()})
+}
+
+// ListMembers godoc
+// @Summary List the members of an organization
+// @Description List the members of an organization the authenticated user belongs to.
+// @Tags Organization
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Success 200 {object} response.ApiResponse{data=[]model.MemberDTO} "Members retrieved successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 404 {object} response.ErrorResponse "Organization not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while listing members"
+// @Router /organizations/{id}/members [get]
+func (c *OrganizationController) ListMembers(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	organizationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	members, listErr := c.OrganizationService.ListMembers(ctx, user, organizationID)
+	if listErr != nil {
+		respondWithOrganizationError(ctx, listErr, "Failed to list members")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Members retrieved successfully", Data: members.ToMemberDTOs()})
+}
+
+// AddMember godoc
+// @Summary Add a member to an organization
+// @Description Add an existing user to the organization by email. Requires the owner or admin role, and only owners can add owners.
+// @Tags Organization
+// @Accept json
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param membership body model.MembershipForm true "Member email and role"
+// @Success 201 {object} response.ApiResponse{data=model.MemberDTO} "Member added successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID, request format or validation failed"
+// @Failure 403 {object} response.ErrorResponse "Not allowed to add members with this role"
+// @Failure 404 {object} response.ErrorResponse "Organization or user not found"
+// @Failure 409 {object} response.ErrorResponse "User is already a member"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while adding member"
+// @Router /organizations/{id}/members [post]
+func (c *OrganizationController) AddMember(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	organizationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var membershipForm model.MembershipForm
+	if formErr := ctx.ShouldBindJSON(&membershipForm); formErr != nil {
+		utils.HandleBindingErrors(ctx, formErr, "add_member")
+		return
+	}
+
+	membership, addErr := c.OrganizationService.AddMember(ctx, user, organizationID, membershipForm)
+	if addErr != nil {
+		respondWithOrganizationError(ctx, addErr, "Failed to add member")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response.ApiResponse{Message: "Member added successfully", Data: membership.ToMemberDTO()})
+}
+
+// RemoveMember godoc
+// @Summary Remove a member from an organization
+// @Description Remove a member from the organization, or leave it by passing your own user ID. Removing others requires the owner or admin role, and only owners can remove owners. The last owner cannot be removed.
+// @Tags Organization
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param userId path int true "User ID of the member"
+// @Success 200 {object} response.ApiResponse "Member removed successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 403 {object} response.ErrorResponse "Not allowed to remove this member"
+// @Failure 404 {object} response.ErrorResponse "Organization or member not found"
+// @Failure 409 {object} response.ErrorResponse "Organization must keep an owner"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while removing member"
+// @Router /organizations/{id}/members/{userId} [delete]
+func (c *OrganizationController) RemoveMember(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	organizationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	memberID, ok := parseIDParam(ctx, "userId")
+	if !ok {
+		return
+	}
+
+	if removeErr := c.OrganizationService.RemoveMember(ctx, user, organizationID, memberID); removeErr != nil {
+		respondWithOrganizationError(ctx, removeErr, "Failed to remove member")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Member removed successfully", Data: nil})
+}
+
+func respondWithOrganizationError(ctx *gin.Context, organizationErr error, fallbackMessage string) {
+	var apiError *err.ApiError
+	if errors.As(organizationErr, &apiError) {
+		switch apiError.Type {
+		case err.ErrorTypeMembershipNotFound:
+			ctx.JSON(http.StatusNotFound, response.ErrorResponse{Error: "Organization or member not found"})
+			return
+		case err.ErrorTypeUserNotFound:
+			ctx.JSON(http.StatusNotFound, response.ErrorResponse{Error: "User not found"})
+			return
+		case err.ErrorTypeOrganizationAccess:
+			ctx.JSON(http.StatusForbidden, response.ErrorResponse{Error: apiError.Err.Error()})
+			return
+		case err.ErrorTypeMembershipExists:
+			ctx.JSON(http.StatusConflict, response.ErrorResponse{Error: "User is already a member"})
+			return
+		case err.ErrorTypeLastOwner:
+			ctx.JSON(http.StatusConflict, response.ErrorResponse{Error: "Organization must keep an owner"})
+			return
+		}
+	}
+	ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: fallbackMessage})
+}
+
+// Parses a numeric ID path parameter, writing a Bad Request response if it is invalid.
+func parseIDParam(ctx *gin.Context, name string) (uint, bool) {
+	log := logger.GetFromContext(ctx)
+
+	idParam := ctx.Param(name)
+	id, parseErr := strconv.ParseUint(idParam, 10, 64)
+	if parseErr != nil {
+		log.Warn("Invalid ID format", zap.String("param", name), zap.String("id_param", idParam), zap.Error(parseErr))
+		ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/response"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type SchedulerController struct {
+	Scheduler service.Scheduler
+}
+
+func NewSchedulerController(scheduler service.Scheduler) *SchedulerController {
+	return &SchedulerController{Scheduler: scheduler}
+}
+
+// GetStatus godoc
+// @Summary Get the status of scheduled tasks
+// @Description Get each scheduled task with its schedule, next run and the outcome of its last run, along with the instance answering and whether it is the scheduler leader, the one instance running tasks. Requires the admin role.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} response.ApiResponse{data=model.SchedulerStatusDTO} "Scheduled tasks retrieved successfully"
+// @Failure 403 {object} response.ErrorResponse "Admin access required"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while retrieving scheduled tasks"
+// @Router /admin/scheduled-tasks [get]
+func (c *SchedulerController) GetStatus(ctx *gin.Context) {
+	tasks, listErr := c.Scheduler.ListTasks(ctx)
+	if listErr != nil {
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to retrieve scheduled tasks"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Scheduled tasks retrieved successfully", Data: model.SchedulerStatusDTO{
+		Instance: c.Scheduler.Instance(),
+		Leader:   c.Scheduler.IsLeader(),
+		Tasks:    tasks.ToDTOs(),
+	}})
+}
+
+// ListRuns godoc
+// @Summary List the runs of a scheduled task
+// @Description List the latest runs of a scheduled task, newest first, with the instance that ran each and its outcome. Requires the admin role.
+// @Tags Admin
+// @Produce json
+// @Param name path string true "Task name"
+// @Param limit query int false "Maximum number of runs (default 20, max 100)"
+// @Success 200 {object} response.ApiResponse{data=[]model.ScheduledTaskRunDTO} "Scheduled task runs retrieved successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid query parameters"
+// @Failure 403 {object} response.ErrorResponse "Admin access required"
+// @Failure 404 {object} response.ErrorResponse "Scheduled task not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while retrieving scheduled task runs"
+// @Router /admin/scheduled-tasks/{name}/runs [get]
+func (c *SchedulerController) ListRuns(ctx *gin.Context) {
+	var filter model.ScheduledTaskRunFilter
+	if formErr := ctx.ShouldBindQuery(&filter); formErr != nil {
+		utils.HandleBindingErrors(ctx, formErr, "list_scheduled_task_runs")
+		return
+	}
+	filter.Normalize()
+
+	runs, listErr := c.Scheduler.ListRuns(ctx, ctx.Param("name"), filter.Limit)
+	if listErr != nil {
+		var apiError *err.ApiError
+		if errors.As(listErr, &apiError) && apiError.Type == err.ErrorTypeTaskNotFound {
+			ctx.JSON(http.StatusNotFound, response.ErrorResponse{Error: "Scheduled task not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to retrieve scheduled task runs"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Scheduled task runs retrieved successfully", Data: runs.ToDTOs()})
+}
@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/Verano-20/stage-zero/internal/config"
+	"github.com/Verano-20/stage-zero/internal/response"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Login mode that returns the token in an HttpOnly session cookie instead of the response body.
+const loginModeCookie = "cookie"
+
+// Sends a newly issued token to the client. Browser clients using cookie auth get it in the session cookie,
+// along with a CSRF token they must send in the X-CSRF-Token header. Other clients get it in the body.
+func respondWithNewToken(ctx *gin.Context, tokenString string, message string, useCookie bool) {
+	if !useCookie {
+		ctx.JSON(http.StatusOK, response.ApiResponse{Message: message, Data: map[string]string{"token": tokenString}})
+		return
+	}
+
+	config := config.Get()
+	csrfToken := utils.GenerateCSRFToken(tokenString, config.GetJwtSecret())
+	utils.SetSessionCookies(ctx, config.Auth.Cookie, tokenString, csrfToken)
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: message, Data: map[string]string{"csrf_token": csrfToken}})
+}
@@ -1,9 +1,14 @@
 package controller
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/Verano-20/stage-zero/internal/err"
 	"github.com/Verano-20/stage-zero/internal/logger"
 	"github.com/Verano-20/stage-zero/internal/model"
 	"github.com/Verano-20/stage-zero/internal/response"
@@ -11,25 +16,31 @@ import (
 	"github.com/Verano-20/stage-zero/internal/utils"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type SimpleController struct {
-	SimpleService service.SimpleService
+	SimpleService     service.SimpleService
+	SimpleStream      service.SimpleStream
+	StreamAuthorizer  service.StreamAuthorizer
+	HeartbeatInterval time.Duration
 }
 
-func NewSimpleController(simpleService service.SimpleService) *SimpleController {
-	return &SimpleController{SimpleService: simpleService}
+func NewSimpleController(simpleService service.SimpleService, simpleStream service.SimpleStream, streamAuthorizer service.StreamAuthorizer, heartbeatInterval time.Duration) *SimpleController {
+	return &SimpleController{SimpleService: simpleService, SimpleStream: simpleStream, StreamAuthorizer: streamAuthorizer, HeartbeatInterval: heartbeatInterval}
 }
 
 // Create godoc
 // @Summary Create a new Simple
-// @Description Create a new Simple with the provided details. The name field is required and must be a non-empty string.
+// @Description Create a new Simple with the provided details. The name field is required and must be a non-empty string. Metadata must match the organization's metadata schemas for the Simple's kind.
 // @Tags Simple
 // @Accept json
 // @Produce json
+// @Param X-Organization-ID header int false "Organization to act in, required if the user belongs to more than one"
 // @Param simple body model.SimpleForm true "Simple details"
 // @Success 201 {object} response.ApiResponse "Simple created successfully"
-// @Failure 400 {object} response.ErrorResponse "Invalid request format"
+// @Failure 400 {object} response.ErrorResponse "Invalid request format or metadata does not match schema"
+// @Failure 403 {object} response.ErrorResponse "Not a member of the organization"
 // @Failure 500 {object} response.ErrorResponse "Internal server error during resource creation"
 // @Router /simple [post]
 func (c *SimpleController) Create(ctx *gin.Context) {
@@ -41,7 +52,7 @@ func (c *SimpleController) Create(ctx *gin.Context) {
 
 	simple, err := c.SimpleService.CreateSimple(ctx, simpleForm)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to create Simple"})
+		respondWithSimpleError(ctx, err, "Failed to create Simple")
 		return
 	}
 
@@ -50,14 +61,31 @@ func (c *SimpleController) Create(ctx *gin.Context) {
 
 // GetAll godoc
 // @Summary Get all Simples
-// @Description Get all Simples. Returns an array of Simple objects. Returns an empty array if none exist.
+// @Description Get the Simples the user owns or that are shared with them, flagged with owned, shared and the user's permission. Organization owners and admins get every Simple of the organization. Returns an empty array if none exist.
 // @Tags Simple
 // @Produce json
+// @Param X-Organization-ID header int false "Organization to act in, required if the user belongs to more than one"
+// @Param tag query []string false "Only return Simples with these tags" collectionFormat(multi)
+// @Param match query string false "Whether Simples need any (default) or all of the tags" Enums(any, all)
+// @Param kind query string false "Only return Simples of this kind"
+// @Param meta.path query string false "Only return Simples whose metadata has this value at the dotted path, e.g. meta.priority=high"
 // @Success 200 {object} response.ApiResponse "Simples retrieved successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid query parameters"
+// @Failure 403 {object} response.ErrorResponse "Not a member of the organization"
 // @Failure 500 {object} response.ErrorResponse "Internal server error while retrieving Simples"
 // @Router /simple [get]
 func (c *SimpleController) GetAll(ctx *gin.Context) {
-	simples, err := c.SimpleService.GetAllSimples(ctx)
+	var filter model.SimpleFilter
+	if formErr := ctx.ShouldBindQuery(&filter); formErr != nil {
+		utils.HandleBindingErrors(ctx, formErr, "list_simples")
+		return
+	}
+	if metadataErr := filter.BindMetadata(ctx.Request.URL.Query()); metadataErr != nil {
+		ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Error: metadataErr.Error()})
+		return
+	}
+
+	simples, err := c.SimpleService.GetAllSimples(ctx, filter)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to retrieve Simples"})
 		return
@@ -66,14 +94,122 @@ func (c *SimpleController) GetAll(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Simples retrieved successfully", Data: simples.ToDTOs()})
 }
 
+// SuggestTags godoc
+// @Summary Suggest tags
+// @Description Autocomplete tags of the organization starting with the query, most used first, with the number of Simples using each. Only Simples the user can see are counted.
+// @Tags Simple
+// @Produce json
+// @Param X-Organization-ID header int false "Organization to act in, required if the user belongs to more than one"
+// @Param q query string false "Tag prefix"
+// @Param limit query int false "Maximum number of tags (default 10, max 50)"
+// @Success 200 {object} response.ApiResponse{data=[]model.TagCount} "Tags retrieved successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid query parameters"
+// @Failure 403 {object} response.ErrorResponse "Not a member of the organization"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while retrieving tags"
+// @Router /simple/tags [get]
+func (c *SimpleController) SuggestTags(ctx *gin.Context) {
+	var filter model.TagSuggestionFilter
+	if formErr := ctx.ShouldBindQuery(&filter); formErr != nil {
+		utils.HandleBindingErrors(ctx, formErr, "suggest_tags")
+		return
+	}
+
+	tagCounts, suggestErr := c.SimpleService.SuggestTags(ctx, filter)
+	if suggestErr != nil {
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to retrieve tags"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Tags retrieved successfully", Data: tagCounts})
+}
+
+// Stream godoc
+// @Summary Stream changes to Simples
+// @Description Stream the creation, update and deletion of the Simples the user can see as Server-Sent Events. Each event is named after the change type, e.g. simple.updated, and its data holds the type and the Simple as returned by GET /simple/{id}, in its state when the change was received. Send the id of the last event received in the Last-Event-ID header when reconnecting to receive the changes made meanwhile; when they are no longer available the stream starts with a reset event, after which the Simples should be reloaded. Comments are sent as heartbeats. The stream ends when the token expires, or when the session is revoked, the user is disabled or leaves the organization, which is checked with every heartbeat.
+// @Tags Simple
+// @Produce text/event-stream
+// @Param X-Organization-ID header int false "Organization to act in, required if the user belongs to more than one"
+// @Param Last-Event-ID header int false "ID of the last event received, to resume from"
+// @Success 200 {object} model.SimpleChangeDTO "Stream of change events"
+// @Failure 400 {object} response.ErrorResponse "Invalid Last-Event-ID"
+// @Failure 403 {object} response.ErrorResponse "Not a member of the organization"
+// @Router /simple/stream [get]
+func (c *SimpleController) Stream(ctx *gin.Context) {
+	log := logger.GetFromContext(ctx)
+
+	var lastEventID uint64
+	if header := ctx.GetHeader("Last-Event-ID"); header != "" {
+		id, parseErr := strconv.ParseUint(header, 10, 64)
+		if parseErr != nil {
+			log.Warn("Invalid Last-Event-ID for Simple stream", zap.String("last_event_id", header), zap.Error(parseErr))
+			ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid Last-Event-ID"})
+			return
+		}
+		lastEventID = id
+	}
+
+	subscription := c.SimpleStream.Subscribe(ctx, lastEventID)
+	defer subscription.Close()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	if lastEventID != 0 && !subscription.Resumed {
+		fmt.Fprint(ctx.Writer, "event: reset\ndata: {}\n\n")
+	}
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(c.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	var expired <-chan time.Time
+	if expiresAt := utils.GetTokenExpiry(ctx); !expiresAt.IsZero() {
+		expiry := time.NewTimer(time.Until(expiresAt))
+		defer expiry.Stop()
+		expired = expiry.C
+	}
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-expired:
+			log.Info("Token expired, ending Simple stream")
+			return
+		case <-heartbeat.C:
+			membership, authErr := c.StreamAuthorizer.Reauthorize(ctx)
+			if authErr != nil {
+				log.Info("No longer authorized, ending Simple stream", zap.Error(authErr))
+				return
+			}
+			subscription.UpdateMembership(membership)
+			fmt.Fprint(ctx.Writer, ": heartbeat\n\n")
+		case change, ok := <-subscription.Changes:
+			if !ok {
+				return
+			}
+			data, marshalErr := json.Marshal(change.ToDTO())
+			if marshalErr != nil {
+				log.Error("Failed to encode Simple change", zap.Object("change", change), zap.Error(marshalErr))
+				return
+			}
+			fmt.Fprintf(ctx.Writer, "id: %d\nevent: %s\ndata: %s\n\n", change.ID, change.Type, data)
+		}
+		ctx.Writer.Flush()
+	}
+}
+
 // GetByID godoc
 // @Summary Get Simple by ID
-// @Description Find a Simple by its unique ID
+// @Description Find a Simple by its unique ID. Simples the user cannot access are reported as not found.
 // @Tags Simple
 // @Param id path int true "Simple ID"
 // @Produce json
 // @Success 200 {object} response.ApiResponse "Simple retrieved successfully"
 // @Failure 400 {object} response.ErrorResponse "Invalid ID format or value"
+// @Failure 403 {object} response.ErrorResponse "Not a member of the organization"
 // @Failure 404 {object} response.ErrorResponse "Simple not found"
 // @Router /simple/{id} [get]
 func (c *SimpleController) GetByID(ctx *gin.Context) {
@@ -98,14 +234,16 @@ func (c *SimpleController) GetByID(ctx *gin.Context) {
 
 // Update godoc
 // @Summary Update an existing Simple
-// @Description Update a Simple identified by its ID with new data. The ID must exist and the request body must contain valid data.
+// @Description Update a Simple identified by its ID with new data. The ID must exist and the request body must contain valid data. Tags are replaced when given and kept when omitted.
 // @Tags Simple
 // @Accept json
 // @Produce json
+// @Param X-Organization-ID header int false "Organization to act in, required if the user belongs to more than one"
 // @Param id path int true "Simple ID to update"
 // @Param simple body model.SimpleForm true "Updated Simple details"
 // @Success 200 {object} response.ApiResponse "Simple updated successfully"
-// @Failure 400 {object} response.ErrorResponse "Invalid ID or request body format"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID, request body format, or metadata does not match schema"
+// @Failure 403 {object} response.ErrorResponse "Not a member of the organization or no write permission"
 // @Failure 404 {object} response.ErrorResponse "Simple not found"
 // @Failure 500 {object} response.ErrorResponse "Internal server error during update operation"
 // @Router /simple/{id} [put]
@@ -134,7 +272,7 @@ func (c *SimpleController) Update(ctx *gin.Context) {
 
 	simple, err := c.SimpleService.UpdateSimple(ctx, existingSimple, simpleForm)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to update Simple"})
+		respondWithSimpleError(ctx, err, "Failed to update Simple")
 		return
 	}
 
@@ -143,12 +281,14 @@ func (c *SimpleController) Update(ctx *gin.Context) {
 
 // Delete godoc
 // @Summary Delete a Simple
-// @Description Permanently delete a Simple identified by its ID. This operation cannot be undone.
+// @Description Permanently delete a Simple identified by its ID. Requires ownership of the Simple, or the owner or admin role in the organization. This operation cannot be undone.
 // @Tags Simple
 // @Produce json
+// @Param X-Organization-ID header int false "Organization to act in, required if the user belongs to more than one"
 // @Param id path int true "Simple ID to delete"
 // @Success 200 {object} response.ApiResponse "Simple deleted successfully"
 // @Failure 400 {object} response.ErrorResponse "Invalid ID format or value"
+// @Failure 403 {object} response.ErrorResponse "Not a member of the organization or not allowed to delete the Simple"
 // @Failure 404 {object} response.ErrorResponse "Simple not found"
 // @Failure 500 {object} response.ErrorResponse "Internal server error during deletion"
 // @Router /simple/{id} [delete]
@@ -171,9 +311,151 @@ func (c *SimpleController) Delete(ctx *gin.Context) {
 
 	err = c.SimpleService.DeleteSimple(ctx, existingSimple)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to delete Simple"})
+		respondWithSimpleError(ctx, err, "Failed to delete Simple")
 		return
 	}
 
 	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Simple deleted successfully", Data: nil})
 }
+
+// ListShares godoc
+// @Summary List who a Simple is shared with
+// @Description List the users a Simple is shared with and their permission. Requires ownership of the Simple, or the owner or admin role in the organization.
+// @Tags Simple
+// @Produce json
+// @Param X-Organization-ID header int false "Organization to act in, required if the user belongs to more than one"
+// @Param id path int true "Simple ID"
+// @Success 200 {object} response.ApiResponse{data=[]model.SimpleGrantDTO} "Shares retrieved successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 403 {object} response.ErrorResponse "Not allowed to manage the Simple"
+// @Failure 404 {object} response.ErrorResponse "Simple not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while listing shares"
+// @Router /simple/{id}/shares [get]
+func (c *SimpleController) ListShares(ctx *gin.Context) {
+	existingSimple, ok := getSimple(ctx, c.SimpleService)
+	if !ok {
+		return
+	}
+
+	grants, listErr := c.SimpleService.ListShares(ctx, existingSimple)
+	if listErr != nil {
+		respondWithSimpleError(ctx, listErr, "Failed to list shares")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Shares retrieved successfully", Data: grants.ToDTOs()})
+}
+
+// Share godoc
+// @Summary Share a Simple with another member
+// @Description Give another member of the organization read or write access to a Simple. Sharing again with the same user changes their permission. Requires ownership of the Simple, or the owner or admin role in the organization.
+// @Tags Simple
+// @Accept json
+// @Produce json
+// @Param X-Organization-ID header int false "Organization to act in, required if the user belongs to more than one"
+// @Param id path int true "Simple ID"
+// @Param share body model.SimpleShareForm true "Email of the member to share with and the permission to grant"
+// @Success 200 {object} response.ApiResponse{data=model.SimpleGrantDTO} "Simple shared successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID, request format, or the user owns the Simple or is not a member"
+// @Failure 403 {object} response.ErrorResponse "Not allowed to manage the Simple"
+// @Failure 404 {object} response.ErrorResponse "Simple or user not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while sharing"
+// @Router /simple/{id}/shares [post]
+func (c *SimpleController) Share(ctx *gin.Context) {
+	var shareForm model.SimpleShareForm
+	if formErr := ctx.ShouldBindJSON(&shareForm); formErr != nil {
+		utils.HandleBindingErrors(ctx, formErr, "share_simple")
+		return
+	}
+
+	existingSimple, ok := getSimple(ctx, c.SimpleService)
+	if !ok {
+		return
+	}
+
+	grant, shareErr := c.SimpleService.ShareSimple(ctx, existingSimple, shareForm)
+	if shareErr != nil {
+		respondWithSimpleError(ctx, shareErr, "Failed to share Simple")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Simple shared successfully", Data: grant.ToDTO()})
+}
+
+// Unshare godoc
+// @Summary Stop sharing a Simple with a user
+// @Description Remove a user's access to a Simple. Requires ownership of the Simple, or the owner or admin role in the organization, except that users can always remove their own access.
+// @Tags Simple
+// @Produce json
+// @Param X-Organization-ID header int false "Organization to act in, required if the user belongs to more than one"
+// @Param id path int true "Simple ID"
+// @Param userId path int true "ID of the user to remove"
+// @Success 200 {object} response.ApiResponse "Simple unshared successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 403 {object} response.ErrorResponse "Not allowed to manage the Simple"
+// @Failure 404 {object} response.ErrorResponse "Simple or share not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while unsharing"
+// @Router /simple/{id}/shares/{userId} [delete]
+func (c *SimpleController) Unshare(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "userId")
+	if !ok {
+		return
+	}
+
+	existingSimple, ok := getSimple(ctx, c.SimpleService)
+	if !ok {
+		return
+	}
+
+	if unshareErr := c.SimpleService.UnshareSimple(ctx, existingSimple, userID); unshareErr != nil {
+		if errors.Is(unshareErr, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, response.ErrorResponse{Error: "Share not found"})
+			return
+		}
+		respondWithSimpleError(ctx, unshareErr, "Failed to unshare Simple")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Simple unshared successfully", Data: nil})
+}
+
+// Loads the Simple named by the id path parameter, writing an error response if it is invalid or not accessible.
+func getSimple(ctx *gin.Context, simpleService service.SimpleService) (*model.Simple, bool) {
+	id, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return nil, false
+	}
+
+	simple, getErr := simpleService.GetSimpleByID(ctx, uint64(id))
+	if getErr != nil {
+		ctx.JSON(http.StatusNotFound, response.ErrorResponse{Error: "Simple not found"})
+		return nil, false
+	}
+	return simple, true
+}
+
+func respondWithSimpleError(ctx *gin.Context, simpleErr error, fallbackMessage string) {
+	if errors.Is(simpleErr, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, response.ErrorResponse{Error: "Simple not found"})
+		return
+	}
+
+	var apiError *err.ApiError
+	if errors.As(simpleErr, &apiError) {
+		switch apiError.Type {
+		case err.ErrorTypeSimpleAccess:
+			ctx.JSON(http.StatusForbidden, response.ErrorResponse{Error: "Not allowed to perform this action on the Simple"})
+			return
+		case err.ErrorTypeShareInvalid:
+			ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Error: apiError.Err.Error()})
+			return
+		case err.ErrorTypeMetadataInvalid:
+			ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Metadata does not match schema", Details: apiError.Details})
+			return
+		case err.ErrorTypeUserNotFound:
+			ctx.JSON(http.StatusNotFound, response.ErrorResponse{Error: "User not found"})
+			return
+		}
+	}
+	ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: fallbackMessage})
+}
@@ -0,0 +1,279 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Verano-20/stage-zero/internal/config"
+	"github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/response"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type UserController struct {
+	UserService    service.UserService
+	AuthService    service.AuthService
+	SessionService service.SessionService
+}
+
+func NewUserController(userService service.UserService, authService service.AuthService, sessionService service.SessionService) *UserController {
+	return &UserController{UserService: userService, AuthService: authService, SessionService: sessionService}
+}
+
+// GetMe godoc
+// @Summary Get the authenticated user
+// @Description Get the profile of the user the request is authenticated as.
+// @Tags User
+// @Produce json
+// @Success 200 {object} response.ApiResponse "User retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Router /me [get]
+func (c *UserController) GetMe(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	if user == nil {
+		ctx.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "User retrieved successfully", Data: user.ToDTO()})
+}
+
+// ChangePassword godoc
+// @Summary Change the authenticated user's password
+// @Description Change the password after confirming the current one. All other sessions are logged out and a new JWT token is returned for the current one.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param password body model.ChangePasswordForm true "Current and new password"
+// @Success 200 {object} response.ApiResponse "Password changed successfully, returns new JWT token, or sets a new session cookie and returns its CSRF token for cookie sessions"
+// @Failure 400 {object} response.ErrorResponse "Invalid request format, validation failed or password does not meet policy"
+// @Failure 401 {object} response.ErrorResponse "Invalid current password"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while changing password"
+// @Router /me/password [put]
+func (c *UserController) ChangePassword(ctx *gin.Context) {
+	config := config.Get()
+
+	user := utils.GetAuthenticatedUser(ctx)
+	if user == nil {
+		ctx.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	var changePasswordForm model.ChangePasswordForm
+	if formErr := ctx.ShouldBindJSON(&changePasswordForm); formErr != nil {
+		utils.HandleBindingErrors(ctx, formErr, "change_password")
+		return
+	}
+
+	user, changeErr := c.UserService.ChangePassword(ctx, user, changePasswordForm)
+	if changeErr != nil {
+		var apiError *err.ApiError
+		if errors.As(changeErr, &apiError) {
+			switch apiError.Type {
+			case err.ErrorTypeInvalidCredentials:
+				ctx.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: "Invalid current password"})
+				return
+			case err.ErrorTypePasswordPolicy:
+				ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Password does not meet policy", Details: apiError.Details})
+				return
+			}
+		}
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to change password"})
+		return
+	}
+
+	tokenString, tokenErr := c.AuthService.GenerateTokenString(ctx, user, config.GetJwtSecret())
+	if tokenErr != nil {
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to generate token"})
+		return
+	}
+
+	respondWithNewToken(ctx, tokenString, "Password changed successfully", utils.IsCookieAuthenticated(ctx))
+}
+
+// ChangeEmail godoc
+// @Summary Change the authenticated user's email
+// @Description Request an email change after confirming the current password. A verification link is sent to the new address and the email is only changed once it is verified.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param email body model.ChangeEmailForm true "New email and current password"
+// @Success 202 {object} response.ApiResponse "Verification email sent"
+// @Failure 400 {object} response.ErrorResponse "Invalid request format or validation failed"
+// @Failure 401 {object} response.ErrorResponse "Invalid current password"
+// @Failure 409 {object} response.ErrorResponse "Email already in use"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while requesting email change"
+// @Router /me/email [put]
+func (c *UserController) ChangeEmail(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	if user == nil {
+		ctx.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	var changeEmailForm model.ChangeEmailForm
+	if formErr := ctx.ShouldBindJSON(&changeEmailForm); formErr != nil {
+		utils.HandleBindingErrors(ctx, formErr, "change_email")
+		return
+	}
+
+	if changeErr := c.UserService.RequestEmailChange(ctx, user, changeEmailForm); changeErr != nil {
+		var apiError *err.ApiError
+		if errors.As(changeErr, &apiError) {
+			switch apiError.Type {
+			case err.ErrorTypeInvalidCredentials:
+				ctx.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: "Invalid current password"})
+				return
+			case err.ErrorTypeEmailExists:
+				ctx.JSON(http.StatusConflict, response.ErrorResponse{Error: "Email already in use"})
+				return
+			}
+		}
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to request email change"})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, response.ApiResponse{Message: "Verification email sent"})
+}
+
+// VerifyEmail godoc
+// @Summary Verify an email change
+// @Description Confirm a pending email change using the token from the verification link.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param token body model.VerifyEmailForm true "Email verification token"
+// @Success 200 {object} response.ApiResponse "Email changed successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid or expired verification token"
+// @Failure 409 {object} response.ErrorResponse "Email already in use"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while changing email"
+// @Router /auth/verify-email [post]
+func (c *UserController) VerifyEmail(ctx *gin.Context) {
+	var verifyEmailForm model.VerifyEmailForm
+	if formErr := ctx.ShouldBindJSON(&verifyEmailForm); formErr != nil {
+		utils.HandleBindingErrors(ctx, formErr, "verify_email")
+		return
+	}
+
+	user, verifyErr := c.UserService.VerifyEmailChange(ctx, verifyEmailForm.Token)
+	if verifyErr != nil {
+		var apiError *err.ApiError
+		if errors.As(verifyErr, &apiError) {
+			switch apiError.Type {
+			case err.ErrorTypeEmailVerification:
+				ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid or expired verification token"})
+				return
+			case err.ErrorTypeEmailExists:
+				ctx.JSON(http.StatusConflict, response.ErrorResponse{Error: "Email already in use"})
+				return
+			}
+		}
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to change email"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Email changed successfully", Data: user.ToDTO()})
+}
+
+// DeleteMe godoc
+// @Summary Delete the authenticated user's account
+// @Description Delete the account of the authenticated user. The email address is anonymised, the user leaves their organizations and all tokens stop working. The only owner of an organization with other members cannot delete their account.
+// @Tags User
+// @Produce json
+// @Success 200 {object} response.ApiResponse "Account deleted successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 409 {object} response.ErrorResponse "Organization must keep an owner"
+// @Failure 500 {object} response.ErrorResponse "Internal server error during deletion"
+// @Router /me [delete]
+func (c *UserController) DeleteMe(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	if user == nil {
+		ctx.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	if deleteErr := c.UserService.DeleteUser(ctx, user); deleteErr != nil {
+		var apiError *err.ApiError
+		if errors.As(deleteErr, &apiError) && apiError.Type == err.ErrorTypeLastOwner {
+			ctx.JSON(http.StatusConflict, response.ErrorResponse{Error: "Organization must keep an owner"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to delete account"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Account deleted successfully", Data: nil})
+}
+
+// ListSessions godoc
+// @Summary List the authenticated user's sessions
+// @Description List the devices the user is logged in on, most recently used first. The session the request was made with is flagged as current.
+// @Tags User
+// @Produce json
+// @Success 200 {object} response.ApiResponse{data=[]model.SessionDTO} "Sessions retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while listing sessions"
+// @Router /me/sessions [get]
+func (c *UserController) ListSessions(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	if user == nil {
+		ctx.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	sessions, listErr := c.SessionService.ListSessions(ctx, user)
+	if listErr != nil {
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to list sessions"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Sessions retrieved successfully", Data: sessions.ToDTOs(utils.GetSessionID(ctx))})
+}
+
+// RevokeSession godoc
+// @Summary Revoke one of the authenticated user's sessions
+// @Description Log out the device with the given session. Its token stops working immediately.
+// @Tags User
+// @Produce json
+// @Param id path int true "Session ID"
+// @Success 200 {object} response.ApiResponse "Session revoked successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Session not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while revoking session"
+// @Router /me/sessions/{id} [delete]
+func (c *UserController) RevokeSession(ctx *gin.Context) {
+	log := logger.GetFromContext(ctx)
+
+	user := utils.GetAuthenticatedUser(ctx)
+	if user == nil {
+		ctx.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	idParam := ctx.Param("id")
+	id, parseErr := strconv.ParseUint(idParam, 10, 64)
+	if parseErr != nil {
+		log.Warn("Invalid session ID format", zap.String("id_param", idParam), zap.Error(parseErr))
+		ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid ID"})
+		return
+	}
+
+	if revokeErr := c.SessionService.RevokeSession(ctx, user, uint(id)); revokeErr != nil {
+		var apiError *err.ApiError
+		if errors.As(revokeErr, &apiError) && apiError.Type == err.ErrorTypeSessionNotFound {
+			ctx.JSON(http.StatusNotFound, response.ErrorResponse{Error: "Session not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Failed to revoke session"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Session revoked successfully", Data: nil})
+}
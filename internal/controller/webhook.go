@@ -0,0 +1,270 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/response"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type WebhookController struct {
+	WebhookService service.WebhookService
+}
+
+func NewWebhookController(webhookService service.WebhookService) *WebhookController {
+	return &WebhookController{WebhookService: webhookService}
+}
+
+// ListWebhooks godoc
+// @Summary List an organization's webhook endpoints
+// @Description List the endpoints that receive the organization's Simple lifecycle events. Requires the owner or admin role.
+// @Tags Organization
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Success 200 {object} response.ApiResponse{data=[]model.WebhookEndpointDTO} "Webhook endpoints retrieved successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 403 {object} response.ErrorResponse "Not allowed to manage webhooks"
+// @Failure 404 {object} response.ErrorResponse "Organization not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while listing webhook endpoints"
+// @Router /organizations/{id}/webhooks [get]
+func (c *WebhookController) ListWebhooks(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	organizationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	endpoints, listErr := c.WebhookService.ListEndpoints(ctx, user, organizationID)
+	if listErr != nil {
+		respondWithWebhookError(ctx, listErr, "Failed to list webhook endpoints")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Webhook endpoints retrieved successfully", Data: endpoints.ToDTOs()})
+}
+
+// CreateWebhook godoc
+// @Summary Register a webhook endpoint
+// @Description Register an http or https URL to receive the subscribed Simple lifecycle events. Each delivery is signed in the X-Webhook-Signature header as t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<body>">, keyed with the secret returned here, which is not shown again. Requires the owner or admin role.
+// @Tags Organization
+// @Accept json
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param webhook body model.WebhookEndpointForm true "URL and subscribed events"
+// @Success 201 {object} response.ApiResponse{data=model.WebhookEndpointCreatedDTO} "Webhook endpoint created successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID, request format or URL"
+// @Failure 403 {object} response.ErrorResponse "Not allowed to manage webhooks"
+// @Failure 404 {object} response.ErrorResponse "Organization not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while creating the webhook endpoint"
+// @Router /organizations/{id}/webhooks [post]
+func (c *WebhookController) CreateWebhook(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	organizationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var endpointForm model.WebhookEndpointForm
+	if formErr := ctx.ShouldBindJSON(&endpointForm); formErr != nil {
+		utils.HandleBindingErrors(ctx, formErr, "create_webhook")
+		return
+	}
+
+	endpoint, createErr := c.WebhookService.CreateEndpoint(ctx, user, organizationID, endpointForm)
+	if createErr != nil {
+		respondWithWebhookError(ctx, createErr, "Failed to create webhook endpoint")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response.ApiResponse{Message: "Webhook endpoint created successfully", Data: model.WebhookEndpointCreatedDTO{
+		WebhookEndpointDTO: *endpoint.ToDTO(),
+		Secret:             endpoint.Secret,
+	}})
+}
+
+// UpdateWebhook godoc
+// @Summary Update a webhook endpoint
+// @Description Replace the URL, description, subscribed events and active flag of a webhook endpoint. The secret is kept. Requires the owner or admin role.
+// @Tags Organization
+// @Accept json
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param webhookId path int true "Webhook endpoint ID"
+// @Param webhook body model.WebhookEndpointForm true "URL and subscribed events"
+// @Success 200 {object} response.ApiResponse{data=model.WebhookEndpointDTO} "Webhook endpoint updated successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID, request format or URL"
+// @Failure 403 {object} response.ErrorResponse "Not allowed to manage webhooks"
+// @Failure 404 {object} response.ErrorResponse "Organization or webhook endpoint not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while updating the webhook endpoint"
+// @Router /organizations/{id}/webhooks/{webhookId} [put]
+func (c *WebhookController) UpdateWebhook(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	organizationID, endpointID, ok := parseWebhookParams(ctx)
+	if !ok {
+		return
+	}
+
+	var endpointForm model.WebhookEndpointForm
+	if formErr := ctx.ShouldBindJSON(&endpointForm); formErr != nil {
+		utils.HandleBindingErrors(ctx, formErr, "update_webhook")
+		return
+	}
+
+	endpoint, updateErr := c.WebhookService.UpdateEndpoint(ctx, user, organizationID, endpointID, endpointForm)
+	if updateErr != nil {
+		respondWithWebhookError(ctx, updateErr, "Failed to update webhook endpoint")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Webhook endpoint updated successfully", Data: endpoint.ToDTO()})
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook endpoint
+// @Description Stop sending events to the endpoint. Its pending deliveries are dropped. Requires the owner or admin role.
+// @Tags Organization
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param webhookId path int true "Webhook endpoint ID"
+// @Success 200 {object} response.ApiResponse "Webhook endpoint deleted successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 403 {object} response.ErrorResponse "Not allowed to manage webhooks"
+// @Failure 404 {object} response.ErrorResponse "Organization or webhook endpoint not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while deleting the webhook endpoint"
+// @Router /organizations/{id}/webhooks/{webhookId} [delete]
+func (c *WebhookController) DeleteWebhook(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	organizationID, endpointID, ok := parseWebhookParams(ctx)
+	if !ok {
+		return
+	}
+
+	if deleteErr := c.WebhookService.DeleteEndpoint(ctx, user, organizationID, endpointID); deleteErr != nil {
+		respondWithWebhookError(ctx, deleteErr, "Failed to delete webhook endpoint")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Webhook endpoint deleted successfully", Data: nil})
+}
+
+// ListDeliveries godoc
+// @Summary List a webhook endpoint's deliveries
+// @Description List the events sent, or waiting to be sent, to the endpoint, newest first, with every attempt made. Requires the owner or admin role.
+// @Tags Organization
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param webhookId path int true "Webhook endpoint ID"
+// @Param status query string false "Only deliveries with this status" Enums(pending, succeeded, failed)
+// @Param page query int false "Page number, starting at 1"
+// @Param page_size query int false "Page size, at most 200"
+// @Success 200 {object} response.ApiResponse{data=response.PageData{items=[]model.WebhookDeliveryDTO}} "Webhook deliveries retrieved successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID or query parameters"
+// @Failure 403 {object} response.ErrorResponse "Not allowed to manage webhooks"
+// @Failure 404 {object} response.ErrorResponse "Organization or webhook endpoint not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while listing webhook deliveries"
+// @Router /organizations/{id}/webhooks/{webhookId}/deliveries [get]
+func (c *WebhookController) ListDeliveries(ctx *gin.Context) {
+	user := utils.GetAuthenticatedUser(ctx)
+	organizationID, endpointID, ok := parseWebhookParams(ctx)
+	if !ok {
+		return
+	}
+
+	var filter model.WebhookDeliveryFilter
+	if formErr := ctx.ShouldBindQuery(&filter); formErr != nil {
+		utils.HandleBindingErrors(ctx, formErr, "list_webhook_deliveries")
+		return
+	}
+	filter.Normalize()
+
+	deliveries, total, listErr := c.WebhookService.ListDeliveries(ctx, user, organizationID, endpointID, filter)
+	if listErr != nil {
+		respondWithWebhookError(ctx, listErr, "Failed to list webhook deliveries")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.ApiResponse{Message: "Webhook deliveries retrieved successfully", Data: response.PageData{
+		Items:    deliveries.ToDTOs(),
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+		Total:    total,
+	}})
+}
+
+// Redeliver godoc
+// @Summary Redeliver a webhook event
+// @Description Queue the delivery's event to be sent to the endpoint again, as a new delivery with the same event ID. Requires the owner or admin role.
+// @Tags Organization
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Param webhookId path int true "Webhook endpoint ID"
+// @Param deliveryId path int true "Webhook delivery ID"
+// @Success 202 {object} response.ApiResponse{data=model.WebhookDeliveryDTO} "Webhook redelivery queued"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 403 {object} response.ErrorResponse "Not allowed to manage webhooks"
+// @Failure 404 {object} response.ErrorResponse "Organization, webhook endpoint or delivery not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error while queueing the redelivery"
+// @Router /organizations/{id}/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver [post]
+func (c *WebhookController) Redeliver(ctx *gin.Context) {
+	log := logger.GetFromContext(ctx)
+
+	user := utils.GetAuthenticatedUser(ctx)
+	organizationID, endpointID, ok := parseWebhookParams(ctx)
+	if !ok {
+		return
+	}
+
+	deliveryParam := ctx.Param("deliveryId")
+	deliveryID, parseErr := strconv.ParseUint(deliveryParam, 10, 64)
+	if parseErr != nil {
+		log.Warn("Invalid ID format", zap.String("param", "deliveryId"), zap.String("id_param", deliveryParam), zap.Error(parseErr))
+		ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid ID"})
+		return
+	}
+
+	delivery, redeliverErr := c.WebhookService.Redeliver(ctx, user, organizationID, endpointID, deliveryID)
+	if redeliverErr != nil {
+		respondWithWebhookError(ctx, redeliverErr, "Failed to queue webhook redelivery")
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, response.ApiResponse{Message: "Webhook redelivery queued", Data: delivery.ToDTO()})
+}
+
+func parseWebhookParams(ctx *gin.Context) (uint, uint, bool) {
+	organizationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return 0, 0, false
+	}
+	endpointID, ok := parseIDParam(ctx, "webhookId")
+	if !ok {
+		return 0, 0, false
+	}
+	return organizationID, endpointID, true
+}
+
+func respondWithWebhookError(ctx *gin.Context, webhookErr error, fallbackMessage string) {
+	var apiError *err.ApiError
+	if errors.As(webhookErr, &apiError) {
+		switch apiError.Type {
+		case err.ErrorTypeWebhookInvalid:
+			ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid webhook endpoint", Details: map[string]string{"url": apiError.Err.Error()}})
+			return
+		case err.ErrorTypeWebhookNotFound:
+			ctx.JSON(http.StatusNotFound, response.ErrorResponse{Error: "Webhook endpoint not found"})
+			return
+		case err.ErrorTypeDeliveryNotFound:
+			ctx.JSON(http.StatusNotFound, response.ErrorResponse{Error: "Webhook delivery not found"})
+			return
+		}
+	}
+	respondWithOrganizationError(ctx, webhookErr, fallbackMessage)
+}
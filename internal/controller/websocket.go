@@ -0,0 +1,356 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/telemetry"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Largest message accepted from clients, which fits a subscribe message for thousands of Simples.
+const webSocketReadLimit = 64 << 10
+
+type WebSocketConfig struct {
+	SendQueueSize       int
+	HeartbeatTimeout    time.Duration
+	WriteTimeout        time.Duration
+	MaxSubscriptions    int
+	AllowedOrigins      []string
+	ReauthorizeInterval time.Duration
+}
+
+type WebSocketController struct {
+	SimpleService    service.SimpleService
+	SimpleStream     service.SimpleStream
+	StreamAuthorizer service.StreamAuthorizer
+	Config           WebSocketConfig
+	upgrader         websocket.Upgrader
+}
+
+func NewWebSocketController(simpleService service.SimpleService, simpleStream service.SimpleStream, streamAuthorizer service.StreamAuthorizer, config WebSocketConfig) *WebSocketController {
+	controller := &WebSocketController{SimpleService: simpleService, SimpleStream: simpleStream, StreamAuthorizer: streamAuthorizer, Config: config}
+	controller.upgrader = websocket.Upgrader{CheckOrigin: controller.checkOrigin}
+	return controller
+}
+
+// Connect godoc
+// @Summary Open a WebSocket connection
+// @Description Upgrade to a WebSocket that pushes changes to the Simples the client subscribes to. Messages are JSON objects with a type. Send {"type":"subscribe","simple_ids":[1,2]} and {"type":"unsubscribe","simple_ids":[1]} to choose the Simples, which are acknowledged with subscribed and unsubscribed messages listing them; Simples the user cannot see are reported in an error message. Changes are pushed as {"type":"change","event_id":42,"change":{...}}, with the change as streamed by GET /simple/stream. Send {"type":"ping"} more often than the heartbeat timeout, answered with {"type":"pong"}, or the connection is closed. A client that does not keep up with its messages is disconnected with close code 1013 and should reconnect and subscribe again. The connection is closed with close code 1008 when the token expires, or when the session is revoked, the user is disabled or leaves the organization, which is checked periodically; Simples the user can no longer see are reported in an unsubscribed message.
+// @Tags WebSocket
+// @Param X-Organization-ID header int false "Organization to act in, required if the user belongs to more than one"
+// @Success 101 {object} model.WebSocketServerMessage "Switching protocols"
+// @Failure 400 {object} response.ErrorResponse "Not a WebSocket handshake"
+// @Failure 401 {object} response.ErrorResponse "Not authenticated"
+// @Failure 403 {object} response.ErrorResponse "Not a member of the organization, or origin not allowed"
+// @Router /ws [get]
+func (c *WebSocketController) Connect(ctx *gin.Context) {
+	log := logger.GetFromContext(ctx)
+
+	conn, upgradeErr := c.upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if upgradeErr != nil {
+		// The upgrader has already responded with the reason.
+		log.Warn("WebSocket upgrade failed", zap.Error(upgradeErr))
+		return
+	}
+
+	metrics := telemetry.GetMetrics()
+	metrics.RecordWebSocketConnection(ctx, 1)
+	defer metrics.RecordWebSocketConnection(ctx, -1)
+
+	log.Info("WebSocket connected")
+	session := &webSocketSession{
+		ctx:        ctx.Request.Context(),
+		accessCtx:  ctx.Request.Context(),
+		conn:       conn,
+		controller: c,
+		send:       make(chan *model.WebSocketServerMessage, c.Config.SendQueueSize),
+		closing:    make(chan struct{}),
+		subscribed: make(map[uint]struct{}),
+	}
+	session.run()
+	log.Info("WebSocket disconnected")
+}
+
+// Browsers send cookies with cross-site WebSocket handshakes too, so connections are only accepted from the API's
+// own origin and the configured ones. Clients other than browsers send no origin.
+func (c *WebSocketController) checkOrigin(request *http.Request) bool {
+	origin := request.Header.Get("Origin")
+	if origin == "" || slices.Contains(c.Config.AllowedOrigins, origin) {
+		return true
+	}
+
+	originURL, parseErr := url.Parse(origin)
+	return parseErr == nil && strings.EqualFold(originURL.Host, request.Host)
+}
+
+// webSocketSession serves one connection. It is read by run and written only by the writer from the send queue, so a
+// client that reads slowly never holds up the change stream; once its queue is full it is disconnected instead.
+type webSocketSession struct {
+	ctx        context.Context
+	conn       *websocket.Conn
+	controller *WebSocketController
+	send       chan *model.WebSocketServerMessage
+	closing    chan struct{}
+	closeOnce  sync.Once
+	closeFrame []byte
+
+	mutex      sync.Mutex
+	subscribed map[uint]struct{}
+	// Context Simples are looked up with, holding the membership as of the last reauthorization.
+	accessCtx context.Context
+}
+
+func (s *webSocketSession) run() {
+	changes := s.controller.SimpleStream.Subscribe(s.ctx, 0)
+	defer changes.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		s.write()
+	}()
+	go func() {
+		defer wg.Done()
+		s.forward(changes)
+	}()
+
+	s.read()
+	s.close(websocket.CloseNormalClosure, "")
+	wg.Wait()
+}
+
+// Reads and handles client messages until the connection fails or is closed, or the client misses a heartbeat.
+func (s *webSocketSession) read() {
+	log := logger.GetFromContext(s.ctx)
+
+	s.conn.SetReadLimit(webSocketReadLimit)
+	s.extendReadDeadline()
+	s.conn.SetPingHandler(func(data string) error {
+		s.extendReadDeadline()
+		err := s.conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(s.controller.Config.WriteTimeout))
+		if err == websocket.ErrCloseSent {
+			return nil
+		}
+		return err
+	})
+
+	for {
+		_, data, readErr := s.conn.ReadMessage()
+		if readErr != nil {
+			if websocket.IsUnexpectedCloseError(readErr, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Debug("WebSocket read failed", zap.Error(readErr))
+			}
+			return
+		}
+		s.extendReadDeadline()
+
+		var message model.WebSocketClientMessage
+		if unmarshalErr := json.Unmarshal(data, &message); unmarshalErr != nil {
+			s.enqueue(&model.WebSocketServerMessage{Type: model.WebSocketMessageError, Error: "Invalid message"})
+			continue
+		}
+
+		switch message.Type {
+		case model.WebSocketMessagePing:
+			s.enqueue(&model.WebSocketServerMessage{Type: model.WebSocketMessagePong})
+		case model.WebSocketMessageSubscribe:
+			s.subscribe(message.SimpleIDs)
+		case model.WebSocketMessageUnsubscribe:
+			s.unsubscribe(message.SimpleIDs)
+		default:
+			s.enqueue(&model.WebSocketServerMessage{Type: model.WebSocketMessageError, Error: "Unknown message type"})
+		}
+	}
+}
+
+// Subscribes to the Simples the user can see, up to the maximum number of subscriptions, reporting the others.
+func (s *webSocketSession) subscribe(ids []uint) {
+	log := logger.GetFromContext(s.ctx)
+
+	var subscribed, notFound, overLimit []uint
+	for _, id := range ids {
+		if s.isSubscribed(id) {
+			subscribed = append(subscribed, id)
+			continue
+		}
+		if s.subscriptionCount() >= s.controller.Config.MaxSubscriptions {
+			overLimit = append(overLimit, id)
+			continue
+		}
+		if _, getErr := s.controller.SimpleService.GetSimpleByID(s.accessContext(), uint64(id)); getErr != nil {
+			notFound = append(notFound, id)
+			continue
+		}
+
+		s.mutex.Lock()
+		s.subscribed[id] = struct{}{}
+		s.mutex.Unlock()
+		subscribed = append(subscribed, id)
+	}
+
+	log.Debug("WebSocket subscribed to Simples", zap.Uints("simple_ids", subscribed))
+	if len(notFound) > 0 {
+		s.enqueue(&model.WebSocketServerMessage{Type: model.WebSocketMessageError, SimpleIDs: notFound, Error: "Simple not found"})
+	}
+	if len(overLimit) > 0 {
+		s.enqueue(&model.WebSocketServerMessage{Type: model.WebSocketMessageError, SimpleIDs: overLimit, Error: "Too many subscriptions"})
+	}
+	s.enqueue(&model.WebSocketServerMessage{Type: model.WebSocketMessageSubscribed, SimpleIDs: subscribed})
+}
+
+func (s *webSocketSession) unsubscribe(ids []uint) {
+	s.mutex.Lock()
+	for _, id := range ids {
+		delete(s.subscribed, id)
+	}
+	s.mutex.Unlock()
+
+	s.enqueue(&model.WebSocketServerMessage{Type: model.WebSocketMessageUnsubscribed, SimpleIDs: ids})
+}
+
+func (s *webSocketSession) isSubscribed(id uint) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, ok := s.subscribed[id]
+	return ok
+}
+
+func (s *webSocketSession) subscriptionCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.subscribed)
+}
+
+func (s *webSocketSession) accessContext() context.Context {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.accessCtx
+}
+
+// Queues the changes to subscribed Simples until the token expires or the user is no longer authorized.
+func (s *webSocketSession) forward(changes *service.SimpleSubscription) {
+	reauthorize := time.NewTicker(s.controller.Config.ReauthorizeInterval)
+	defer reauthorize.Stop()
+
+	var expired <-chan time.Time
+	if expiresAt := utils.GetTokenExpiry(s.ctx); !expiresAt.IsZero() {
+		expiry := time.NewTimer(time.Until(expiresAt))
+		defer expiry.Stop()
+		expired = expiry.C
+	}
+
+	for {
+		select {
+		case <-s.closing:
+			return
+		case <-expired:
+			logger.GetFromContext(s.ctx).Info("WebSocket token expired, disconnecting")
+			s.close(websocket.ClosePolicyViolation, "token expired")
+			return
+		case <-reauthorize.C:
+			if !s.reauthorize(changes) {
+				return
+			}
+		case change, ok := <-changes.Changes:
+			if !ok {
+				s.close(websocket.CloseTryAgainLater, "change stream interrupted")
+				return
+			}
+			if s.isSubscribed(change.Simple.ID) {
+				s.enqueue(&model.WebSocketServerMessage{Type: model.WebSocketMessageChange, EventID: change.ID, Change: change.ToDTO()})
+			}
+		}
+	}
+}
+
+// Reports whether the user is still authorized, disconnecting them if not, and unsubscribes Simples they cannot see.
+func (s *webSocketSession) reauthorize(changes *service.SimpleSubscription) bool {
+	log := logger.GetFromContext(s.ctx)
+
+	membership, authErr := s.controller.StreamAuthorizer.Reauthorize(s.ctx)
+	if authErr != nil {
+		log.Info("WebSocket no longer authorized, disconnecting", zap.Error(authErr))
+		s.close(websocket.ClosePolicyViolation, "no longer authorized")
+		return false
+	}
+	changes.UpdateMembership(membership)
+
+	accessCtx := utils.WithMembership(s.ctx, membership)
+	s.mutex.Lock()
+	s.accessCtx = accessCtx
+	ids := slices.Sorted(maps.Keys(s.subscribed))
+	s.mutex.Unlock()
+
+	var lost []uint
+	for _, id := range ids {
+		_, getErr := s.controller.SimpleService.GetSimpleByID(accessCtx, uint64(id))
+		if errors.Is(getErr, gorm.ErrRecordNotFound) {
+			lost = append(lost, id)
+		}
+	}
+	if len(lost) > 0 {
+		log.Debug("WebSocket lost access to Simples", zap.Uints("simple_ids", lost))
+		s.unsubscribe(lost)
+	}
+	return true
+}
+
+// Queues the message without waiting, disconnecting the client if its queue is full.
+func (s *webSocketSession) enqueue(message *model.WebSocketServerMessage) {
+	select {
+	case s.send <- message:
+	default:
+		logger.GetFromContext(s.ctx).Warn("WebSocket send queue full, disconnecting", zap.Int("queue_size", cap(s.send)))
+		s.close(websocket.CloseTryAgainLater, "send queue full")
+	}
+}
+
+// Writes queued messages until the session is closing, then sends the close message and closes the connection.
+func (s *webSocketSession) write() {
+	log := logger.GetFromContext(s.ctx)
+	defer s.conn.Close()
+
+	for {
+		select {
+		case <-s.closing:
+			s.conn.WriteControl(websocket.CloseMessage, s.closeFrame, time.Now().Add(s.controller.Config.WriteTimeout))
+			return
+		case message := <-s.send:
+			s.conn.SetWriteDeadline(time.Now().Add(s.controller.Config.WriteTimeout))
+			if writeErr := s.conn.WriteJSON(message); writeErr != nil {
+				log.Debug("WebSocket write failed", zap.Error(writeErr))
+				s.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		}
+	}
+}
+
+// Starts closing the session with the close code and reason. Only the first call has an effect.
+func (s *webSocketSession) close(code int, reason string) {
+	s.closeOnce.Do(func() {
+		s.closeFrame = websocket.FormatCloseMessage(code, reason)
+		close(s.closing)
+	})
+}
+
+func (s *webSocketSession) extendReadDeadline() {
+	s.conn.SetReadDeadline(time.Now().Add(s.controller.Config.HeartbeatTimeout))
+}
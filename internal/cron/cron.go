@@ -0,0 +1,219 @@
+// Package cron parses cron expressions and computes when they next fire.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How far ahead Next looks for a matching time, so impossible schedules such as 30 February end.
+const searchYears = 5
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type field struct {
+	name  string
+	min   int
+	max   int
+	names []string
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	dayField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	// 7 is accepted for Sunday, as well as 0.
+	weekdayField = field{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// Schedule is a parsed cron expression. Times are matched in the location of the time passed to Next.
+type Schedule struct {
+	expression string
+	minutes    uint64
+	hours      uint64
+	days       uint64
+	months     uint64
+	weekdays   uint64
+	// When both the day of month and the day of week are restricted, a day matching either matches, as in Vixie cron.
+	daysRestricted     bool
+	weekdaysRestricted bool
+}
+
+// Parse parses a standard five field cron expression, "minute hour day-of-month month day-of-week", or one of the
+// macros @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly. Fields are lists of values, ranges
+// and steps, such as "*/15", "1-5" or "0,30"; months and days of the week can be given by their English
+// abbreviations.
+func Parse(expression string) (*Schedule, error) {
+	spec := strings.TrimSpace(expression)
+	if macro, ok := macros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, has %d", expression, len(fields))
+	}
+
+	schedule := &Schedule{expression: expression}
+	var err error
+	if schedule.minutes, err = parseField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if schedule.hours, err = parseField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if schedule.days, err = parseField(fields[2], dayField); err != nil {
+		return nil, err
+	}
+	if schedule.months, err = parseField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if schedule.weekdays, err = parseField(fields[4], weekdayField); err != nil {
+		return nil, err
+	}
+	if schedule.weekdays&(1<<7) != 0 {
+		schedule.weekdays = schedule.weekdays&^(1<<7) | 1
+	}
+	schedule.daysRestricted = !strings.HasPrefix(fields[2], "*")
+	schedule.weekdaysRestricted = !strings.HasPrefix(fields[4], "*")
+	return schedule, nil
+}
+
+// MustParse is like Parse but panics if the expression cannot be parsed.
+func MustParse(expression string) *Schedule {
+	schedule, err := Parse(expression)
+	if err != nil {
+		panic(err)
+	}
+	return schedule
+}
+
+func (s *Schedule) String() string {
+	return s.expression
+}
+
+// Next returns the first time after the given one that the schedule fires, or the zero time if it never does.
+func (s *Schedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(searchYears, 0, 0)
+
+	for t.Before(limit) {
+		if !has(s.months, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !has(s.hours, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !has(s.minutes, t.Minute()) {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	day := has(s.days, t.Day())
+	weekday := has(s.weekdays, int(t.Weekday()))
+	if s.daysRestricted && s.weekdaysRestricted {
+		return day || weekday
+	}
+	return day && weekday
+}
+
+// Parses a comma separated list of values, ranges and steps into a bit set of the values it matches.
+func parseField(spec string, f field) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(spec, ",") {
+		partSet, err := parsePart(part, f)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q: %w", f.name, spec, err)
+		}
+		set |= partSet
+	}
+	return set, nil
+}
+
+func parsePart(part string, f field) (uint64, error) {
+	rangeSpec, stepSpec, hasStep := strings.Cut(part, "/")
+	step := 1
+	if hasStep {
+		var err error
+		step, err = strconv.Atoi(stepSpec)
+		if err != nil || step < 1 {
+			return 0, errors.New("step must be a positive number")
+		}
+	}
+
+	low, high := f.min, f.max
+	switch {
+	case rangeSpec == "*":
+	case strings.Contains(rangeSpec, "-"):
+		lowSpec, highSpec, _ := strings.Cut(rangeSpec, "-")
+		var err error
+		if low, err = parseValue(lowSpec, f); err != nil {
+			return 0, err
+		}
+		if high, err = parseValue(highSpec, f); err != nil {
+			return 0, err
+		}
+		if low > high {
+			return 0, fmt.Errorf("range %s is backwards", rangeSpec)
+		}
+	default:
+		var err error
+		if low, err = parseValue(rangeSpec, f); err != nil {
+			return 0, err
+		}
+		// A single value with a step, such as 5/15, runs from the value to the end of the range.
+		if !hasStep {
+			high = low
+		}
+	}
+
+	var set uint64
+	for value := low; value <= high; value += step {
+		set |= 1 << value
+	}
+	return set, nil
+}
+
+func parseValue(spec string, f field) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(spec, name) {
+			return f.min + i, nil
+		}
+	}
+
+	value, err := strconv.Atoi(spec)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", spec)
+	}
+	if value < f.min || value > f.max {
+		return 0, fmt.Errorf("%d is out of range %d-%d", value, f.min, f.max)
+	}
+	return value, nil
+}
+
+func has(set uint64, value int) bool {
+	return set&(1<<value) != 0
+}
@@ -8,11 +8,20 @@ import (
 	"github.com/Verano-20/stage-zero/internal/config"
 	"github.com/Verano-20/stage-zero/internal/logger"
 	"github.com/Verano-20/stage-zero/internal/telemetry"
+	_ "github.com/jackc/pgx/v5/stdlib"
 	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// Connection pool settings, for the primary and every read replica.
+const (
+	maxOpenConns    = 25
+	maxIdleConns    = 5
+	connMaxLifetime = 5 * time.Minute
+	connMaxIdleTime = 1 * time.Minute
+)
+
 func InitDatabase() *gorm.DB {
 	config := config.Get()
 	log := logger.Get()
@@ -30,16 +39,15 @@ func InitDatabase() *gorm.DB {
 			zap.String("dsn", dsn))
 	}
 
+	if err := db.Use(TelemetryPlugin{}); err != nil {
+		log.Fatal("Failed to register database telemetry plugin", zap.Error(err))
+	}
+
 	sqlDB, err := db.DB()
 	if err != nil {
 		log.Fatal("Failed to get underlying database connection", zap.Error(err))
 	}
 
-	maxOpenConns := 25
-	maxIdleConns := 5
-	connMaxLifetime := 5 * time.Minute
-	connMaxIdleTime := 1 * time.Minute
-
 	sqlDB.SetMaxOpenConns(maxOpenConns)
 	sqlDB.SetMaxIdleConns(maxIdleConns)
 	sqlDB.SetConnMaxLifetime(connMaxLifetime)
@@ -51,13 +59,55 @@ func InitDatabase() *gorm.DB {
 		zap.Duration("conn_max_lifetime", connMaxLifetime),
 		zap.Duration("conn_max_idle_time", connMaxIdleTime))
 
+	if len(config.Database.ReplicaDSNs) > 0 {
+		initReplicaRouter(db, config.Database)
+	}
+
 	// Start connection metrics collection
 	go startConnectionMetricsCollector(sqlDB)
 
 	return db
 }
 
-var shutdownMetricsCollector = make(chan struct{})
+var (
+	shutdownMetricsCollector = make(chan struct{})
+	replicaRouter            *ReplicaRouter
+	stopReplicaHealthChecks  context.CancelFunc
+)
+
+// Opens the read replicas and routes reads to them, with their health checked in the background until Shutdown.
+func initReplicaRouter(db *gorm.DB, databaseConfig config.DatabaseConfig) {
+	log := logger.Get()
+
+	pools := make([]ReplicaPool, 0, len(databaseConfig.ReplicaDSNs))
+	for _, dsn := range databaseConfig.ReplicaDSNs {
+		pool, err := sql.Open("pgx", dsn)
+		if err != nil {
+			log.Fatal("Failed to open read replica", zap.Error(err))
+		}
+		pool.SetMaxOpenConns(maxOpenConns)
+		pool.SetMaxIdleConns(maxIdleConns)
+		pool.SetConnMaxLifetime(connMaxLifetime)
+		pool.SetConnMaxIdleTime(connMaxIdleTime)
+		pools = append(pools, pool)
+	}
+
+	replicaRouter = NewReplicaRouter(pools, ReplicaRouterConfig{
+		HealthCheckInterval:  databaseConfig.ReplicaHealthCheckInterval,
+		ReadYourWritesWindow: databaseConfig.ReadYourWritesWindow,
+	})
+	if err := db.Use(replicaRouter); err != nil {
+		log.Fatal("Failed to register read replica router", zap.Error(err))
+	}
+
+	var ctx context.Context
+	ctx, stopReplicaHealthChecks = context.WithCancel(context.Background())
+	go replicaRouter.Run(ctx)
+
+	log.Info("Read replicas configured",
+		zap.Int("replicas", len(pools)),
+		zap.Duration("read_your_writes_window", databaseConfig.ReadYourWritesWindow))
+}
 
 func startConnectionMetricsCollector(sqlDB *sql.DB) {
 	log := logger.Get()
@@ -106,6 +156,13 @@ func Shutdown(db *gorm.DB) {
 
 	close(shutdownMetricsCollector)
 
+	if replicaRouter != nil {
+		stopReplicaHealthChecks()
+		if err := replicaRouter.Close(); err != nil {
+			log.Error("Failed to close read replica connections", zap.Error(err))
+		}
+	}
+
 	if sqlDB, err := db.DB(); err == nil {
 		if err := sqlDB.Close(); err != nil {
 			log.Error("Failed to close database connection", zap.Error(err))
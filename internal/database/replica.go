@@ -0,0 +1,207 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/telemetry"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	replicaReadKey = "replica_router:read"
+	queryTargetKey = "replica_router:target"
+
+	// Names the primary as the target of queries in metrics and traces.
+	PrimaryTarget = "primary"
+)
+
+// PreferReplica marks the queries made with db as reads that a replica can serve.
+func PreferReplica(db *gorm.DB) *gorm.DB {
+	return db.Set(replicaReadKey, true)
+}
+
+// ReplicaPool is the connection pool of a read replica, such as a *sql.DB.
+type ReplicaPool interface {
+	gorm.ConnPool
+	PingContext(ctx context.Context) error
+	Close() error
+}
+
+type ReplicaRouterConfig struct {
+	HealthCheckInterval  time.Duration
+	ReadYourWritesWindow time.Duration
+}
+
+// ReplicaRouter is a GORM plugin that sends reads marked with PreferReplica to the healthy read replicas in turn.
+type ReplicaRouter struct {
+	Config   ReplicaRouterConfig
+	replicas []*replica
+	next     atomic.Uint64
+
+	mu         sync.Mutex
+	lastWrites map[uint]time.Time
+}
+
+type replica struct {
+	name    string
+	pool    ReplicaPool
+	healthy atomic.Bool
+}
+
+var _ gorm.Plugin = &ReplicaRouter{}
+
+// NewReplicaRouter routes reads to the pools, named replica1, replica2, ... in the order given.
+func NewReplicaRouter(pools []ReplicaPool, config ReplicaRouterConfig) *ReplicaRouter {
+	router := &ReplicaRouter{Config: config, lastWrites: make(map[uint]time.Time)}
+	for i, pool := range pools {
+		replica := &replica{name: "replica" + strconv.Itoa(i+1), pool: pool}
+		replica.healthy.Store(true)
+		router.replicas = append(router.replicas, replica)
+	}
+	return router
+}
+
+func (r *ReplicaRouter) Name() string {
+	return "replica_router"
+}
+
+func (r *ReplicaRouter) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	return errors.Join(
+		callback.Query().Before("gorm:query").Register("replica_router:route_query", r.route),
+		callback.Row().Before("gorm:row").Register("replica_router:route_row", r.route),
+		callback.Create().After("gorm:create").Register("replica_router:record_create", r.recordWrite),
+		callback.Update().After("gorm:update").Register("replica_router:record_update", r.recordWrite),
+		callback.Delete().After("gorm:delete").Register("replica_router:record_delete", r.recordWrite),
+		callback.Raw().After("gorm:raw").Register("replica_router:record_raw", r.recordWrite),
+	)
+}
+
+func (r *ReplicaRouter) route(db *gorm.DB) {
+	if read, _ := db.Get(replicaReadKey); read != true {
+		return
+	}
+	// Preloads, and queries reusing a statement, stay on the replica the statement was first sent to.
+	if _, routed := db.Get(queryTargetKey); routed {
+		return
+	}
+	if _, inTransaction := db.Statement.ConnPool.(gorm.TxCommitter); inTransaction {
+		return
+	}
+	if info := utils.GetRequestInfo(db.Statement.Context); info != nil && utils.IsStateChangingMethod(info.Method) {
+		return
+	}
+	if user := utils.GetAuthenticatedUser(db.Statement.Context); user != nil && r.wroteRecently(user.ID) {
+		return
+	}
+
+	replica := r.pick()
+	if replica == nil {
+		return
+	}
+	db.Statement.ConnPool = replica.pool
+	db.Statement.Settings.Store(queryTargetKey, replica.name)
+}
+
+// Returns the next healthy replica in turn, or nil if there is none.
+func (r *ReplicaRouter) pick() *replica {
+	count := uint64(len(r.replicas))
+	start := r.next.Add(1) - 1
+	for i := range count {
+		if replica := r.replicas[(start+i)%count]; replica.healthy.Load() {
+			return replica
+		}
+	}
+	return nil
+}
+
+func (r *ReplicaRouter) recordWrite(db *gorm.DB) {
+	if db.Error != nil || r.Config.ReadYourWritesWindow <= 0 {
+		return
+	}
+	if user := utils.GetAuthenticatedUser(db.Statement.Context); user != nil {
+		r.mu.Lock()
+		r.lastWrites[user.ID] = time.Now()
+		r.mu.Unlock()
+	}
+}
+
+func (r *ReplicaRouter) wroteRecently(userID uint) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lastWrite, ok := r.lastWrites[userID]
+	return ok && time.Since(lastWrite) < r.Config.ReadYourWritesWindow
+}
+
+func (r *ReplicaRouter) Run(ctx context.Context) {
+	log := logger.Get()
+
+	log.Info("Replica health checks started", zap.Int("replicas", len(r.replicas)), zap.Duration("interval", r.Config.HealthCheckInterval))
+	ticker := time.NewTicker(r.Config.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		r.CheckHealth(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Info("Replica health checks stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckHealth pings every replica, ejecting the ones that do not answer and restoring the ones that do.
+func (r *ReplicaRouter) CheckHealth(ctx context.Context) {
+	log := logger.Get()
+
+	for _, replica := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, r.Config.HealthCheckInterval)
+		err := replica.pool.PingContext(pingCtx)
+		cancel()
+
+		healthy := err == nil
+		if replica.healthy.Swap(healthy) != healthy {
+			if healthy {
+				log.Info("Replica restored", zap.String("replica", replica.name))
+			} else {
+				log.Warn("Replica ejected", zap.String("replica", replica.name), zap.Error(err))
+			}
+		}
+		telemetry.GetMetrics().RecordDBReplicaHealth(ctx, replica.name, healthy)
+	}
+
+	r.mu.Lock()
+	for userID, lastWrite := range r.lastWrites {
+		if time.Since(lastWrite) >= r.Config.ReadYourWritesWindow {
+			delete(r.lastWrites, userID)
+		}
+	}
+	r.mu.Unlock()
+}
+
+// Close closes the replicas' connection pools.
+func (r *ReplicaRouter) Close() error {
+	var errs []error
+	for _, replica := range r.replicas {
+		errs = append(errs, replica.pool.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Returns the database a query ran on, the primary unless the ReplicaRouter sent it to a replica.
+func queryTarget(db *gorm.DB) string {
+	if target, ok := db.Get(queryTargetKey); ok {
+		return target.(string)
+	}
+	return PrimaryTarget
+}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const queryKey = "telemetry:query"
+
+// The query being traced, kept on the statement between the callbacks that run before and after it.
+type tracedQuery struct {
+	parent context.Context
+	span   trace.Span
+	start  time.Time
+}
+
+// TelemetryPlugin traces and measures every query made through GORM. Each query gets a span, a child of the
+// span in the query's context, so repositories need to pass their context with DB.WithContext for queries to
+// show up under the request that made them.
+//
+// The span's db.statement is the SQL with placeholders for its parameters, which are never recorded, so values
+// such as password hashes and tokens stay out of traces.
+type TelemetryPlugin struct{}
+
+var _ gorm.Plugin = TelemetryPlugin{}
+
+func (TelemetryPlugin) Name() string {
+	return "telemetry"
+}
+
+func (p TelemetryPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	return errors.Join(
+		callback.Create().Before("gorm:create").Register("telemetry:before_create", p.before("insert")),
+		callback.Create().After("gorm:create").Register("telemetry:after_create", p.after("insert")),
+		callback.Query().Before("gorm:query").Register("telemetry:before_query", p.before("select")),
+		callback.Query().After("gorm:query").Register("telemetry:after_query", p.after("select")),
+		callback.Update().Before("gorm:update").Register("telemetry:before_update", p.before("update")),
+		callback.Update().After("gorm:update").Register("telemetry:after_update", p.after("update")),
+		callback.Delete().Before("gorm:delete").Register("telemetry:before_delete", p.before("delete")),
+		callback.Delete().After("gorm:delete").Register("telemetry:after_delete", p.after("delete")),
+		callback.Row().Before("gorm:row").Register("telemetry:before_row", p.before("row")),
+		callback.Row().After("gorm:row").Register("telemetry:after_row", p.after("row")),
+		callback.Raw().Before("gorm:raw").Register("telemetry:before_raw", p.before("raw")),
+		callback.Raw().After("gorm:raw").Register("telemetry:after_raw", p.after("raw")),
+	)
+}
+
+func (TelemetryPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		parent := db.Statement.Context
+		ctx, span := telemetry.StartDBSpan(parent, operation)
+		db.Statement.Context = ctx
+		db.InstanceSet(queryKey, &tracedQuery{parent: parent, span: span, start: time.Now()})
+	}
+}
+
+func (TelemetryPlugin) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(queryKey)
+		if !ok {
+			return
+		}
+		query := value.(*tracedQuery)
+		duration := time.Since(query.start)
+
+		// A statement can be reused for another query, which should not be traced as part of this one.
+		ctx := db.Statement.Context
+		db.Statement.Context = query.parent
+		span := query.span
+		defer span.End()
+
+		table := db.Statement.Table
+		target := queryTarget(db)
+		if table != "" {
+			span.SetName(operation + " " + table)
+		}
+		span.SetAttributes(
+			semconv.DBCollectionName(table),
+			attribute.String("db.target", target),
+			attribute.String("db.statement", db.Statement.SQL.String()),
+			attribute.Int64("db.rows_affected", db.RowsAffected),
+		)
+
+		status := "ok"
+		switch {
+		case errors.Is(db.Error, gorm.ErrRecordNotFound):
+			status = "not_found"
+		case db.Error != nil:
+			status = "error"
+			span.RecordError(db.Error)
+			span.SetStatus(codes.Error, db.Error.Error())
+		}
+
+		telemetry.GetMetrics().RecordDBQuery(ctx, operation, table, target, status, db.RowsAffected, duration.Seconds())
+	}
+}
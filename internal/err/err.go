@@ -7,8 +7,9 @@ import (
 )
 
 type ApiError struct {
-	Type string
-	Err  error
+	Type    string
+	Err     error
+	Details map[string]string
 }
 
 func (e *ApiError) Error() string {
@@ -20,8 +21,36 @@ func (e *ApiError) Unwrap() error {
 }
 
 const (
-	ErrorTypePasswordHash = "password_hash_failure"
-	ErrorTypeEmailExists  = "email_already_exists"
+	ErrorTypePasswordHash       = "password_hash_failure"
+	ErrorTypeEmailExists        = "email_already_exists"
+	ErrorTypeMagicLink          = "magic_link_invalid"
+	ErrorTypeInvalidCredentials = "invalid_credentials"
+	ErrorTypeEmailVerification  = "email_verification_invalid"
+	ErrorTypeAccountDisabled    = "account_disabled"
+	ErrorTypeAccountLocked      = "account_locked"
+	ErrorTypeImpersonation      = "impersonation_not_allowed"
+	ErrorTypePasswordPolicy     = "password_policy_violation"
+	ErrorTypeSessionNotFound    = "session_not_found"
+	ErrorTypeUserNotFound       = "user_not_found"
+	ErrorTypeMembershipNotFound = "membership_not_found"
+	ErrorTypeMembershipExists   = "membership_already_exists"
+	ErrorTypeOrganizationAccess = "organization_access_denied"
+	ErrorTypeLastOwner          = "last_owner"
+	ErrorTypeInvitationNotFound = "invitation_not_found"
+	ErrorTypeInvitationInvalid  = "invitation_invalid"
+	ErrorTypeInvitationPending  = "invitation_already_pending"
+	ErrorTypePasswordRequired   = "password_required"
+	ErrorTypeSimpleAccess       = "simple_access_denied"
+	ErrorTypeShareInvalid       = "share_invalid"
+	ErrorTypeMetadataInvalid    = "metadata_invalid"
+	ErrorTypeSchemaInvalid      = "metadata_schema_invalid"
+	ErrorTypeSchemaNotFound     = "metadata_schema_not_found"
+	ErrorTypeAttachmentTooLarge = "attachment_too_large"
+	ErrorTypeAttachmentType     = "attachment_type_not_allowed"
+	ErrorTypeWebhookNotFound    = "webhook_not_found"
+	ErrorTypeWebhookInvalid     = "webhook_invalid"
+	ErrorTypeDeliveryNotFound   = "webhook_delivery_not_found"
+	ErrorTypeTaskNotFound       = "scheduled_task_not_found"
 )
 
 func NewPasswordHashError(err error) *ApiError {
@@ -38,6 +67,206 @@ func NewEmailExistsError(err error) *ApiError {
 	}
 }
 
+func NewMagicLinkInvalidError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeMagicLink,
+		Err:  err,
+	}
+}
+
+func NewInvalidCredentialsError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeInvalidCredentials,
+		Err:  err,
+	}
+}
+
+func NewEmailVerificationError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeEmailVerification,
+		Err:  err,
+	}
+}
+
+func NewAccountDisabledError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeAccountDisabled,
+		Err:  err,
+	}
+}
+
+func NewAccountLockedError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeAccountLocked,
+		Err:  err,
+	}
+}
+
+func NewImpersonationNotAllowedError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeImpersonation,
+		Err:  err,
+	}
+}
+
+func NewSessionNotFoundError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeSessionNotFound,
+		Err:  err,
+	}
+}
+
+func NewUserNotFoundError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeUserNotFound,
+		Err:  err,
+	}
+}
+
+func NewMembershipNotFoundError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeMembershipNotFound,
+		Err:  err,
+	}
+}
+
+func NewMembershipExistsError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeMembershipExists,
+		Err:  err,
+	}
+}
+
+func NewOrganizationAccessError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeOrganizationAccess,
+		Err:  err,
+	}
+}
+
+func NewLastOwnerError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeLastOwner,
+		Err:  err,
+	}
+}
+
+func NewInvitationNotFoundError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeInvitationNotFound,
+		Err:  err,
+	}
+}
+
+func NewInvitationInvalidError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeInvitationInvalid,
+		Err:  err,
+	}
+}
+
+func NewInvitationPendingError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeInvitationPending,
+		Err:  err,
+	}
+}
+
+func NewPasswordRequiredError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypePasswordRequired,
+		Err:  err,
+	}
+}
+
+func NewSimpleAccessError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeSimpleAccess,
+		Err:  err,
+	}
+}
+
+func NewShareInvalidError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeShareInvalid,
+		Err:  err,
+	}
+}
+
+// NewMetadataInvalidError carries the schema violations in Details, keyed by the location in the metadata.
+func NewMetadataInvalidError(err error, violations map[string]string) *ApiError {
+	return &ApiError{
+		Type:    ErrorTypeMetadataInvalid,
+		Err:     err,
+		Details: violations,
+	}
+}
+
+func NewSchemaInvalidError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeSchemaInvalid,
+		Err:  err,
+	}
+}
+
+func NewSchemaNotFoundError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeSchemaNotFound,
+		Err:  err,
+	}
+}
+
+func NewAttachmentTooLargeError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeAttachmentTooLarge,
+		Err:  err,
+	}
+}
+
+func NewAttachmentTypeError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeAttachmentType,
+		Err:  err,
+	}
+}
+
+func NewWebhookNotFoundError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeWebhookNotFound,
+		Err:  err,
+	}
+}
+
+func NewWebhookInvalidError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeWebhookInvalid,
+		Err:  err,
+	}
+}
+
+func NewDeliveryNotFoundError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeDeliveryNotFound,
+		Err:  err,
+	}
+}
+
+func NewTaskNotFoundError(err error) *ApiError {
+	return &ApiError{
+		Type: ErrorTypeTaskNotFound,
+		Err:  err,
+	}
+}
+
+// NewPasswordPolicyError carries the violated rules in Details so they can be returned to the client.
+func NewPasswordPolicyError(err error, violations map[string]string) *ApiError {
+	return &ApiError{
+		Type:    ErrorTypePasswordPolicy,
+		Err:     err,
+		Details: violations,
+	}
+}
+
 func GetValidationErrorMessage(e validator.FieldError) string {
 	switch e.Tag() {
 	case "required":
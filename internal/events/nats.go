@@ -0,0 +1,186 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+)
+
+const natsDefaultPort = "4222"
+
+// NATSConfig configures the publisher returned by NewNATSPublisher. The URL is of the form nats://[user:password@]host[:port].
+type NATSConfig struct {
+	URL           string
+	SubjectPrefix string
+	Timeout       time.Duration
+}
+
+// natsPublisher speaks just enough of the NATS client protocol to publish: it connects, sends each event with PUB
+// and flushes it with PING, so an event only counts as published once the server has answered with PONG. TLS,
+// headers and JetStream acknowledgements are not supported.
+type natsPublisher struct {
+	Address       string
+	User          string
+	Password      string
+	SubjectPrefix string
+	Timeout       time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+var _ EventPublisher = &natsPublisher{}
+
+// NewNATSPublisher returns an EventPublisher that publishes each event to a NATS compatible server on the subject
+// "<prefix>.<event type>". The connection is opened on the first publish and reopened after any failure.
+func NewNATSPublisher(config NATSConfig) (EventPublisher, error) {
+	serverURL, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	if serverURL.Scheme != "nats" || serverURL.Hostname() == "" {
+		return nil, errors.New("NATS URL must be of the form nats://host:port")
+	}
+
+	address := serverURL.Host
+	if serverURL.Port() == "" {
+		address = net.JoinHostPort(serverURL.Hostname(), natsDefaultPort)
+	}
+	password, _ := serverURL.User.Password()
+
+	return &natsPublisher{
+		Address:       address,
+		User:          serverURL.User.Username(),
+		Password:      password,
+		SubjectPrefix: config.SubjectPrefix,
+		Timeout:       config.Timeout,
+	}, nil
+}
+
+func (p *natsPublisher) Publish(ctx context.Context, event *model.DomainEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.publish(ctx, p.subject(event.Type), body); err != nil {
+		p.close()
+		return err
+	}
+	return nil
+}
+
+func (p *natsPublisher) publish(ctx context.Context, subject string, body []byte) error {
+	if p.conn == nil {
+		if err := p.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	deadline := time.Now().Add(p.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := p.conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("PUB %s %d\r\n%s\r\nPING\r\n", subject, len(body), body)
+	if _, err := p.conn.Write([]byte(message)); err != nil {
+		return err
+	}
+	return p.awaitPong()
+}
+
+func (p *natsPublisher) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: p.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.Address)
+	if err != nil {
+		return err
+	}
+	p.conn = conn
+	p.reader = bufio.NewReader(conn)
+
+	if err := conn.SetDeadline(time.Now().Add(p.Timeout)); err != nil {
+		return err
+	}
+	info, err := p.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(info, "INFO ") {
+		return fmt.Errorf("unexpected NATS greeting %q", info)
+	}
+
+	options, err := json.Marshal(map[string]any{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "stage-zero-outbox",
+		"lang":     "go",
+		"protocol": 0,
+		"user":     p.User,
+		"pass":     p.Password,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = p.conn.Write([]byte("CONNECT " + string(options) + "\r\n"))
+	return err
+}
+
+// Reads until the server answers the PING sent after a message, replying to the server's own PINGs meanwhile.
+func (p *natsPublisher) awaitPong() error {
+	for {
+		line, err := p.readLine()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS server error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+func (p *natsPublisher) readLine() (string, error) {
+	line, err := p.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (p *natsPublisher) subject(eventType string) string {
+	if p.SubjectPrefix == "" {
+		return eventType
+	}
+	return p.SubjectPrefix + "." + eventType
+}
+
+func (p *natsPublisher) close() {
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn = nil
+		p.reader = nil
+	}
+}
@@ -0,0 +1,49 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"go.uber.org/zap"
+)
+
+const (
+	PublisherLog     = "log"
+	PublisherWebhook = "webhook"
+	PublisherNATS    = "nats"
+)
+
+// EventPublisher sends domain events to other systems. Publish returns once the event has been accepted, and an
+// error if it may not have been, in which case the relay publishes it again.
+type EventPublisher interface {
+	Publish(ctx context.Context, event *model.DomainEvent) error
+}
+
+type logPublisher struct{}
+
+var _ EventPublisher = &logPublisher{}
+
+// NewLogPublisher returns an EventPublisher that writes events to the application log instead of sending them.
+// It is intended for local development and environments without a consumer.
+func NewLogPublisher() EventPublisher {
+	return &logPublisher{}
+}
+
+func (p *logPublisher) Publish(ctx context.Context, event *model.DomainEvent) error {
+	log := logger.Get()
+
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Publishing domain event",
+		zap.Uint64("id", event.ID),
+		zap.String("type", event.Type),
+		zap.String("aggregate_type", event.AggregateType),
+		zap.String("aggregate_id", event.AggregateID),
+		zap.ByteString("data", data))
+	return nil
+}
@@ -0,0 +1,71 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/utils"
+)
+
+const (
+	HeaderEventID   = "X-Event-ID"
+	HeaderEventType = "X-Event-Type"
+	HeaderSignature = "X-Event-Signature"
+)
+
+// Response bodies are discarded, but read up to this size so connections can be reused.
+const responseDrainLimit = 64 << 10
+
+type webhookPublisher struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+var _ EventPublisher = &webhookPublisher{}
+
+// NewWebhookPublisher returns an EventPublisher that POSTs each event as JSON to a single URL, signed with the
+// secret in the same format as organization webhooks. Only a 2xx response counts as published.
+func NewWebhookPublisher(url string, secret string, client *http.Client) EventPublisher {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &webhookPublisher{URL: url, Secret: secret, Client: client}
+}
+
+func (p *webhookPublisher) Publish(ctx context.Context, event *model.DomainEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(HeaderEventID, strconv.FormatUint(event.ID, 10))
+	request.Header.Set(HeaderEventType, event.Type)
+	if p.Secret != "" {
+		request.Header.Set(HeaderSignature, utils.SignRequestBody(p.Secret, time.Now(), body))
+	}
+
+	response, err := p.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, responseDrainLimit))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"context"
+
 	"github.com/Verano-20/stage-zero/internal/config"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -9,6 +11,8 @@ import (
 
 var globalLogger *zap.Logger
 
+type contextKey struct{}
+
 func InitLogger() {
 	var err error
 
@@ -73,11 +77,14 @@ func Get() *zap.Logger {
 	return globalLogger
 }
 
-func GetFromContext(ctx *gin.Context) *zap.Logger {
-	if logger, exists := ctx.Get("logger"); exists {
-		if zapLogger, ok := logger.(*zap.Logger); ok {
-			return zapLogger
-		}
+// WithLogger returns a context carrying the request-scoped logger.
+func WithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+func GetFromContext(ctx context.Context) *zap.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*zap.Logger); ok {
+		return logger
 	}
 	return zap.L() // fallback to global logger
 }
@@ -0,0 +1,43 @@
+package mail
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+type logSender struct {
+	From string
+}
+
+var _ Sender = &logSender{}
+
+// NewLogSender returns a Sender that logs the recipient and subject of outgoing mail instead of delivering it.
+func NewLogSender(from string) Sender {
+	return &logSender{From: from}
+}
+
+func (s *logSender) Send(ctx context.Context, message Message) error {
+	log := logger.Get()
+
+	log.Info("Sending mail", zap.String("from", s.From), zap.Object("message", &message))
+	return nil
+}
+
+func (m *Message) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("to", m.To)
+	enc.AddString("subject", m.Subject)
+	return nil
+}
@@ -3,75 +3,289 @@ package middleware
 import (
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
 	"github.com/Verano-20/stage-zero/internal/repository"
 	"github.com/Verano-20/stage-zero/internal/response"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 	"go.uber.org/zap"
 )
 
 type AuthMiddleware struct {
-	jwtSecret      []byte
-	userRepository repository.UserRepository
+	jwtSecret               []byte
+	sessionCookieName       string
+	userRepository          repository.UserRepository
+	sessionRepository       repository.SessionRepository
+	membershipRepository    repository.MembershipRepository
+	impersonationRepository repository.ImpersonationRepository
+	auditLogger             service.AuditLogger
 }
 
+// Header clients send to choose the organization a request acts in.
+const OrganizationHeaderName = "X-Organization-ID"
+
+// How stale a session's last seen time may get before a request updates it, to avoid a write on every request.
+const sessionTouchInterval = time.Minute
+
 var validSigningMethods = []string{
 	jwt.SigningMethodHS256.Alg(),
 	jwt.SigningMethodHS384.Alg(),
 	jwt.SigningMethodHS512.Alg(),
 }
 
-func NewAuthMiddleware(jwtSecret []byte, userRepository repository.UserRepository) *AuthMiddleware {
+func NewAuthMiddleware(jwtSecret []byte, sessionCookieName string, userRepository repository.UserRepository, sessionRepository repository.SessionRepository, membershipRepository repository.MembershipRepository, impersonationRepository repository.ImpersonationRepository, auditLogger service.AuditLogger) *AuthMiddleware {
 	return &AuthMiddleware{
-		jwtSecret:      jwtSecret,
-		userRepository: userRepository,
+		jwtSecret:               jwtSecret,
+		sessionCookieName:       sessionCookieName,
+		userRepository:          userRepository,
+		sessionRepository:       sessionRepository,
+		membershipRepository:    membershipRepository,
+		impersonationRepository: impersonationRepository,
+		auditLogger:             auditLogger,
 	}
 }
 
 func (m *AuthMiddleware) AuthenticateRequest(ctx *gin.Context) {
+	m.authenticate(ctx, false)
+}
+
+// AuthenticatePasswordChange authenticates like AuthenticateRequest but also accepts users who have been
+// forced to reset their password, so they are able to do so.
+func (m *AuthMiddleware) AuthenticatePasswordChange(ctx *gin.Context) {
+	m.authenticate(ctx, true)
+}
+
+// RequireAdmin rejects requests from users without the admin role. It must run after AuthenticateRequest.
+func (m *AuthMiddleware) RequireAdmin(ctx *gin.Context) {
+	log := logger.GetFromContext(ctx)
+
+	user := utils.GetAuthenticatedUser(ctx)
+	if user == nil || !user.IsAdmin() {
+		log.Warn("Admin access denied", zap.Uint("user_id", ctx.GetUint("user_id")))
+		m.recordFailure(ctx, model.AuditActionAuthorize, "admin access required")
+		ctx.JSON(http.StatusForbidden, response.ErrorResponse{Error: "admin access required"})
+		ctx.Abort()
+		return
+	}
+
+	ctx.Next()
+}
+
+// ForbidImpersonation rejects requests made with an impersonation token. It guards account changes that only
+// the real user should be able to make. It must run after AuthenticateRequest.
+func (m *AuthMiddleware) ForbidImpersonation(ctx *gin.Context) {
+	log := logger.GetFromContext(ctx)
+
+	if utils.IsImpersonating(ctx) {
+		log.Warn("Action not allowed while impersonating",
+			zap.Uint("user_id", ctx.GetUint("user_id")),
+			zap.Uint("real_user_id", utils.GetRealUser(ctx).ID))
+		m.recordFailure(ctx, model.AuditActionAuthorize, "not allowed while impersonating")
+		ctx.JSON(http.StatusForbidden, response.ErrorResponse{Error: "not allowed while impersonating"})
+		ctx.Abort()
+		return
+	}
+
+	ctx.Next()
+}
+
+// RequireOrganization resolves the organization the request acts in, rejecting non-members, after AuthenticateRequest.
+func (m *AuthMiddleware) RequireOrganization(ctx *gin.Context) {
+	log := logger.GetFromContext(ctx)
+
+	membership, status, err := m.resolveMembership(ctx)
+	if err != nil {
+		log.Warn("Organization resolution failed", zap.Uint("user_id", ctx.GetUint("user_id")), zap.Error(err))
+		m.recordFailure(ctx, model.AuditActionAuthorize, err.Error())
+		ctx.JSON(status, response.ErrorResponse{Error: err.Error()})
+		ctx.Abort()
+		return
+	}
+
+	utils.SetRequestContext(ctx, utils.WithMembership(ctx.Request.Context(), membership))
+	ctx.Next()
+}
+
+func (m *AuthMiddleware) authenticate(ctx *gin.Context, allowPasswordReset bool) {
 	log := logger.GetFromContext(ctx)
 
 	log.Debug("Authentcating request...")
 
-	token, err := m.validateToken(ctx)
+	tokenString, err := m.extractToken(ctx)
+	if err != nil {
+		log.Warn("Token extraction failed", zap.Error(err))
+		m.recordFailure(ctx, model.AuditActionAuthenticate, err.Error())
+		ctx.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: err.Error()})
+		ctx.Abort()
+		return
+	}
+
+	token, err := m.validateToken(ctx, tokenString)
 	if err != nil {
 		log.Warn("Token validation failed", zap.Error(err))
+		m.recordFailure(ctx, model.AuditActionAuthenticate, err.Error())
 		ctx.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: err.Error()})
 		ctx.Abort()
 		return
 	}
 
+	if err := m.validateCSRFToken(ctx, tokenString); err != nil {
+		log.Warn("CSRF validation failed", zap.Error(err))
+		m.recordFailure(ctx, model.AuditActionAuthenticate, err.Error())
+		ctx.JSON(http.StatusForbidden, response.ErrorResponse{Error: err.Error()})
+		ctx.Abort()
+		return
+	}
+
 	if err := m.validateClaims(ctx, token); err != nil {
 		log.Warn("Token claims validation failed", zap.Error(err))
+		m.recordFailure(ctx, model.AuditActionAuthenticate, err.Error())
 		ctx.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: err.Error()})
 		ctx.Abort()
 		return
 	}
 
+	user := utils.GetAuthenticatedUser(ctx)
+	if user.IsDisabled() {
+		log.Warn("Request from disabled user", zap.Uint("user_id", user.ID))
+		m.recordFailure(ctx, model.AuditActionAuthenticate, "account disabled")
+		ctx.JSON(http.StatusForbidden, response.ErrorResponse{Error: "account disabled"})
+		ctx.Abort()
+		return
+	}
+
+	if user.PasswordResetRequired && !allowPasswordReset {
+		log.Warn("Request from user with pending password reset", zap.Uint("user_id", user.ID))
+		m.recordFailure(ctx, model.AuditActionAuthenticate, "password reset required")
+		ctx.JSON(http.StatusForbidden, response.ErrorResponse{Error: "password reset required"})
+		ctx.Abort()
+		return
+	}
+
+	if utils.IsImpersonating(ctx) {
+		m.handleImpersonatedRequest(ctx)
+		return
+	}
+
 	log.Debug("Authentication successful")
 	ctx.Next()
 }
 
-func (m *AuthMiddleware) validateToken(ctx *gin.Context) (*jwt.Token, error) {
+// Flags the response so clients can show an impersonation banner, then records the request in the
+// impersonation audit trail once it has been handled.
+func (m *AuthMiddleware) handleImpersonatedRequest(ctx *gin.Context) {
+	log := logger.GetFromContext(ctx)
+
+	user := utils.GetAuthenticatedUser(ctx)
+	realUser := utils.GetRealUser(ctx)
+
+	log.Info("Handling impersonated request", zap.Uint("user_id", user.ID), zap.Uint("real_user_id", realUser.ID))
+	ctx.Header("X-Impersonated-By", realUser.Email)
+
+	ctx.Next()
+
+	event := &model.ImpersonationEvent{
+		ActorID:   realUser.ID,
+		UserID:    user.ID,
+		Action:    model.ImpersonationActionRequest,
+		Method:    ctx.Request.Method,
+		Path:      ctx.Request.URL.Path,
+		Status:    ctx.Writer.Status(),
+		ClientIP:  ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+	}
+	if _, err := m.impersonationRepository.Create(ctx, event); err != nil {
+		log.Error("Failed to record impersonated request", zap.Object("event", event), zap.Error(err))
+	}
+}
+
+// Returns the user's membership of the requested organization, or the status to respond with if it has none.
+func (m *AuthMiddleware) resolveMembership(ctx *gin.Context) (*model.Membership, int, error) {
+	log := logger.GetFromContext(ctx)
+
+	user := utils.GetAuthenticatedUser(ctx)
+	if user == nil {
+		return nil, http.StatusUnauthorized, errors.New("authentication required")
+	}
+
+	if header := ctx.GetHeader(OrganizationHeaderName); header != "" {
+		organizationID, err := strconv.ParseUint(header, 10, 64)
+		if err != nil || organizationID == 0 {
+			return nil, http.StatusBadRequest, errors.New("invalid organization id")
+		}
+		membership, err := m.membershipRepository.Get(ctx, uint(organizationID), user.ID)
+		if err != nil {
+			log.Warn("Membership not found", zap.Uint64("organization_id", organizationID), zap.Uint("user_id", user.ID), zap.Error(err))
+			return nil, http.StatusForbidden, errors.New("not a member of this organization")
+		}
+		return membership, http.StatusOK, nil
+	}
+
+	memberships, err := m.membershipRepository.ListByUserID(ctx, user.ID)
+	if err != nil {
+		log.Error("Failed to list memberships", zap.Uint("user_id", user.ID), zap.Error(err))
+		return nil, http.StatusInternalServerError, errors.New("failed to resolve organization")
+	}
+
+	switch len(memberships) {
+	case 0:
+		return nil, http.StatusForbidden, errors.New("organization membership required")
+	case 1:
+		return memberships[0], http.StatusOK, nil
+	default:
+		return nil, http.StatusBadRequest, errors.New("organization required, set the " + OrganizationHeaderName + " header")
+	}
+}
+
+// Returns the JWT from the Authorization header or, for browser clients, the session cookie. The header takes
+// precedence when both are sent.
+func (m *AuthMiddleware) extractToken(ctx *gin.Context) (string, error) {
 	log := logger.GetFromContext(ctx)
 
 	authHeader := ctx.GetHeader("Authorization")
 	if authHeader == "" {
+		if cookie, err := ctx.Cookie(m.sessionCookieName); err == nil && cookie != "" {
+			ctx.Set("auth_method", utils.AuthMethodCookie)
+			return cookie, nil
+		}
+
 		log.Warn("Missing authorization header")
-		return nil, errors.New("authorization header required")
+		return "", errors.New("authorization header required")
 	}
 
 	tokenParts := strings.Split(authHeader, " ")
 	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
 		log.Warn("Invalid authorization header format", zap.String("header", authHeader))
-		return nil, errors.New("invalid authorization header format")
+		return "", errors.New("invalid authorization header format")
+	}
+
+	ctx.Set("auth_method", utils.AuthMethodBearer)
+	return tokenParts[1], nil
+}
+
+// Requires state-changing requests authenticated by the session cookie to echo the CSRF token in the
+// X-CSRF-Token header. Cookies are sent by the browser automatically, but another site cannot read the token.
+func (m *AuthMiddleware) validateCSRFToken(ctx *gin.Context, tokenString string) error {
+	if !utils.IsCookieAuthenticated(ctx) || !utils.IsStateChangingMethod(ctx.Request.Method) {
+		return nil
 	}
 
-	tokenString := tokenParts[1]
+	if !utils.ValidateCSRFToken(tokenString, ctx.GetHeader(utils.CSRFHeaderName), m.jwtSecret) {
+		return errors.New("invalid CSRF token")
+	}
+	return nil
+}
+
+func (m *AuthMiddleware) validateToken(ctx *gin.Context, tokenString string) (*jwt.Token, error) {
+	log := logger.GetFromContext(ctx)
+
 	log.Debug("Parsing JWT token...")
 
 	parser := jwt.NewParser(
@@ -121,14 +335,127 @@ func (m *AuthMiddleware) validateClaims(ctx *gin.Context, token *jwt.Token) erro
 		return errors.New("invalid user id")
 	}
 
+	if err := checkTokenNotRevoked(user, claims); err != nil {
+		log.Warn("JWT token has been revoked",
+			zap.Uint("user_id", userID),
+			zap.Time("tokens_valid_after", *user.TokensValidAfter))
+		return err
+	}
+
+	realUser := user
+	if actClaim, exists := claims["act"]; exists {
+		if realUser, err = m.validateActorClaim(ctx, actClaim, claims); err != nil {
+			return err
+		}
+	} else if err := m.validateSessionClaim(ctx, user, claims); err != nil {
+		return err
+	}
+
 	log.Debug("JWT token claims validated successfully",
 		zap.Float64("exp", claims["exp"].(float64)),
 		zap.Float64("now", float64(time.Now().Unix())),
 		zap.Uint("user_id", user.ID),
 		zap.String("email", user.Email))
 
+	requestCtx := utils.WithAuthenticatedUser(ctx.Request.Context(), user, realUser)
+	if expiresAt, ok := claims["exp"].(float64); ok {
+		requestCtx = utils.WithTokenExpiry(requestCtx, time.Unix(int64(expiresAt), 0))
+	}
+	utils.SetRequestContext(ctx, requestCtx)
 	ctx.Set("user_id", user.ID)
 	ctx.Set("user_email", user.Email)
 
 	return nil
 }
+
+// Validates the "sid" claim binding the token to a session, so revoking the session rejects the token straight
+// away. Impersonation tokens are not bound to a session and are checked by validateActorClaim instead.
+func (m *AuthMiddleware) validateSessionClaim(ctx *gin.Context, user *model.User, claims jwt.MapClaims) error {
+	log := logger.GetFromContext(ctx)
+
+	sessionID, ok := claims["sid"].(float64)
+	if !ok {
+		log.Warn("Missing JWT sid claim", zap.Uint("user_id", user.ID))
+		return errors.New("invalid token claims")
+	}
+
+	session, err := m.sessionRepository.GetByID(ctx, uint(sessionID))
+	if err != nil {
+		log.Warn("Session not found during token validation", zap.Uint("session_id", uint(sessionID)), zap.Error(err))
+		return errors.New("invalid session")
+	}
+
+	if session.UserID != user.ID || !session.IsActive(user) {
+		log.Warn("Session is no longer active", zap.Object("session", session))
+		return errors.New("session revoked")
+	}
+
+	if time.Since(session.LastSeenAt) > sessionTouchInterval {
+		if err := m.sessionRepository.Touch(ctx, session.ID, time.Now()); err != nil {
+			log.Error("Failed to update session last seen time", zap.Object("session", session), zap.Error(err))
+		}
+	}
+
+	utils.SetRequestContext(ctx, utils.WithSessionID(ctx.Request.Context(), session.ID))
+	return nil
+}
+
+// Validates the "act" claim of an impersonation token and returns the admin acting as the token subject.
+// The admin must still hold the admin role, be enabled and not have had their own tokens revoked.
+func (m *AuthMiddleware) validateActorClaim(ctx *gin.Context, actClaim any, claims jwt.MapClaims) (*model.User, error) {
+	log := logger.GetFromContext(ctx)
+
+	act, ok := actClaim.(map[string]any)
+	if !ok {
+		log.Warn("Invalid JWT act claim format")
+		return nil, errors.New("invalid token claims")
+	}
+
+	actorID, ok := act["sub"].(float64)
+	if !ok {
+		log.Warn("Missing JWT act sub claim")
+		return nil, errors.New("invalid token claims")
+	}
+
+	actor, err := m.userRepository.GetByID(ctx, uint(actorID))
+	if err != nil {
+		log.Warn("Actor not found during token validation", zap.Uint("actor_id", uint(actorID)), zap.Error(err))
+		return nil, errors.New("invalid actor id")
+	}
+
+	if !actor.IsAdmin() || actor.IsDisabled() {
+		log.Warn("Actor is no longer allowed to impersonate", zap.Object("actor", actor))
+		return nil, errors.New("impersonation no longer allowed")
+	}
+
+	if err := checkTokenNotRevoked(actor, claims); err != nil {
+		log.Warn("Actor tokens have been revoked", zap.Object("actor", actor))
+		return nil, err
+	}
+
+	return actor, nil
+}
+
+// Records a rejected request in the audit log. Successful authentications are not recorded, as that would be
+// every request; logins are recorded by the AuthController instead.
+func (m *AuthMiddleware) recordFailure(ctx *gin.Context, action string, reason string) {
+	event := &model.AuditEvent{Action: action, Outcome: model.AuditOutcomeFailure, Reason: reason}
+	if ctx.Request != nil {
+		event.TargetType = model.AuditTargetTypeRoute
+		event.TargetID = ctx.Request.Method + " " + ctx.FullPath()
+	}
+	m.auditLogger.Record(ctx, event)
+}
+
+// Rejects tokens without a session that were issued before the user's tokens were last revoked.
+func checkTokenNotRevoked(user *model.User, claims jwt.MapClaims) error {
+	if _, hasSession := claims["sid"]; hasSession || user.TokensValidAfter == nil {
+		return nil
+	}
+
+	issuedAt, ok := claims["iat"].(float64)
+	if !ok || int64(issuedAt) <= user.TokensValidAfter.Unix() {
+		return errors.New("token revoked")
+	}
+	return nil
+}
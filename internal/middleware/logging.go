@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/utils"
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
@@ -36,7 +37,9 @@ func LoggingMiddleware() gin.HandlerFunc {
 
 		log := logger.Get().With(logFields...)
 
-		ctx.Set("logger", log)
+		requestCtx := logger.WithLogger(ctx.Request.Context(), log)
+		requestCtx = utils.WithRequestInfo(requestCtx, &utils.RequestInfo{Method: method, Path: path, ClientIP: clientIP, UserAgent: userAgent})
+		utils.SetRequestContext(ctx, requestCtx)
 
 		log.Info("Incoming HTTP request")
 
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/response"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RateLimiter is a fixed window, per client IP request limiter, shared by the routes using the same instance.
+type RateLimiter struct {
+	limit     int
+	window    time.Duration
+	mutex     sync.Mutex
+	clients   map[string]*rateLimitWindow
+	lastSweep time.Time
+}
+
+type rateLimitWindow struct {
+	start time.Time
+	count int
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:   limit,
+		window:  window,
+		clients: make(map[string]*rateLimitWindow),
+	}
+}
+
+func (l *RateLimiter) LimitRequest(ctx *gin.Context) {
+	log := logger.GetFromContext(ctx)
+
+	clientIP := ctx.ClientIP()
+	allowed, retryAfter := l.allow(clientIP)
+	if !allowed {
+		log.Warn("Rate limit exceeded", zap.String("client_ip", clientIP), zap.Duration("retry_after", retryAfter))
+		ctx.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+		ctx.JSON(http.StatusTooManyRequests, response.ErrorResponse{Error: "Too many requests"})
+		ctx.Abort()
+		return
+	}
+
+	ctx.Next()
+}
+
+func (l *RateLimiter) allow(key string) (bool, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	l.evictExpired(now)
+
+	client, exists := l.clients[key]
+	if !exists || now.Sub(client.start) >= l.window {
+		client = &rateLimitWindow{start: now}
+		l.clients[key] = client
+	}
+
+	if client.count >= l.limit {
+		return false, client.start.Add(l.window).Sub(now)
+	}
+
+	client.count++
+	return true, 0
+}
+
+func (l *RateLimiter) evictExpired(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+
+	for key, client := range l.clients {
+		if now.Sub(client.start) >= l.window {
+			delete(l.clients, key)
+		}
+	}
+}
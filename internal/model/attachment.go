@@ -0,0 +1,113 @@
+package model
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	attachmentFileNameMaxLength = 255
+	attachmentDefaultFileName   = "attachment"
+)
+
+// Attachment describes a file attached to a Simple. The content is kept in the blob store, once per organization
+// and SHA-256 hash, so uploading the same file again does not store it twice.
+type Attachment struct {
+	ID             uint      `json:"id"`
+	OrganizationID uint      `json:"organization_id"`
+	SimpleID       uint      `json:"simple_id"`
+	FileName       string    `json:"file_name"`
+	ContentType    string    `json:"content_type"`
+	Size           int64     `json:"size"`
+	SHA256         string    `json:"sha256" gorm:"column:sha256"`
+	UploadedByID   *uint     `json:"uploaded_by_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type AttachmentDTO struct {
+	ID           uint      `json:"id" example:"1"`
+	SimpleID     uint      `json:"simple_id" example:"1"`
+	FileName     string    `json:"file_name" example:"invoice.pdf"`
+	ContentType  string    `json:"content_type" example:"application/pdf"`
+	Size         int64     `json:"size" example:"48213"`
+	SHA256       string    `json:"sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	UploadedByID *uint     `json:"uploaded_by_id" example:"1"`
+	CreatedAt    time.Time `json:"created_at" example:"2025-01-01T00:00:00Z"`
+}
+
+// AttachmentUpload is a file received for a Simple. The content is read more than once, to detect its type and to
+// hash and store it, so it must be seekable.
+type AttachmentUpload struct {
+	FileName string
+	Content  io.ReadSeeker
+}
+
+type Attachments []*Attachment
+
+func (Attachment) TableName() string {
+	return "attachments"
+}
+
+// BlobKey is where the attachment's content is kept in the blob store.
+func (attachment *Attachment) BlobKey() string {
+	return fmt.Sprintf("organizations/%d/sha256/%s/%s", attachment.OrganizationID, attachment.SHA256[:2], attachment.SHA256)
+}
+
+func (attachment *Attachment) ToDTO() *AttachmentDTO {
+	return &AttachmentDTO{
+		ID:           attachment.ID,
+		SimpleID:     attachment.SimpleID,
+		FileName:     attachment.FileName,
+		ContentType:  attachment.ContentType,
+		Size:         attachment.Size,
+		SHA256:       attachment.SHA256,
+		UploadedByID: attachment.UploadedByID,
+		CreatedAt:    attachment.CreatedAt,
+	}
+}
+
+func (attachments Attachments) ToDTOs() []*AttachmentDTO {
+	dtos := make([]*AttachmentDTO, len(attachments))
+	for i, attachment := range attachments {
+		dtos[i] = attachment.ToDTO()
+	}
+	return dtos
+}
+
+// SanitizeFileName keeps the last element of a client supplied file name, without control characters, so it is
+// safe to store and to send back in a Content-Disposition header.
+func SanitizeFileName(fileName string) string {
+	if i := strings.LastIndexAny(fileName, `/\`); i >= 0 {
+		fileName = fileName[i+1:]
+	}
+	fileName = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, fileName)
+	fileName = strings.TrimSpace(fileName)
+
+	if runes := []rune(fileName); len(runes) > attachmentFileNameMaxLength {
+		fileName = string(runes[:attachmentFileNameMaxLength])
+	}
+	if fileName == "" || fileName == "." || fileName == ".." {
+		return attachmentDefaultFileName
+	}
+	return fileName
+}
+
+func (attachment *Attachment) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddUint("id", attachment.ID)
+	enc.AddUint("simple_id", attachment.SimpleID)
+	enc.AddString("file_name", attachment.FileName)
+	enc.AddString("content_type", attachment.ContentType)
+	enc.AddInt64("size", attachment.Size)
+	enc.AddString("sha256", attachment.SHA256)
+	return nil
+}
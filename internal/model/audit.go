@@ -0,0 +1,176 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	AuditActionSignUp         = "auth.signup"
+	AuditActionLogin          = "auth.login"
+	AuditActionMagicLinkSent  = "auth.magic_link_requested"
+	AuditActionMagicLinkLogin = "auth.magic_link_login"
+	AuditActionAuthenticate   = "auth.authenticate"
+	AuditActionAuthorize      = "auth.authorize"
+	AuditActionSessionRevoke  = "auth.session_revoke"
+	AuditActionInviteCreate   = "organization.invitation_create"
+	AuditActionInviteAccept   = "organization.invitation_accept"
+	AuditActionInviteDecline  = "organization.invitation_decline"
+	AuditActionInviteRevoke   = "organization.invitation_revoke"
+	AuditActionSimpleCreate   = "simple.create"
+	AuditActionSimpleUpdate   = "simple.update"
+	AuditActionSimpleDelete   = "simple.delete"
+	AuditActionSimpleShare    = "simple.share"
+	AuditActionSimpleUnshare  = "simple.unshare"
+	AuditActionAttachAdd      = "simple.attachment_upload"
+	AuditActionAttachDelete   = "simple.attachment_delete"
+	AuditActionWebhookCreate  = "organization.webhook_create"
+	AuditActionWebhookUpdate  = "organization.webhook_update"
+	AuditActionWebhookDelete  = "organization.webhook_delete"
+)
+
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+const (
+	AuditTargetTypeUser    = "user"
+	AuditTargetTypeSimple  = "simple"
+	AuditTargetTypeRoute   = "route"
+	AuditTargetTypeSession = "session"
+	AuditTargetTypeInvite  = "invitation"
+	AuditTargetTypeWebhook = "webhook"
+)
+
+// AuditChainGenesisHash is the previous hash of the first event in the chain.
+const AuditChainGenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+const (
+	auditHashTimeFormat         = "2006-01-02T15:04:05.000000Z07:00"
+	auditEventDefaultPageSize   = 50
+	auditEventMaximumPageSize   = 200
+	auditEventReasonMaxLength   = 255
+	auditEventAgentMaxLength    = 512
+	auditEventTargetIDMaxLength = 64
+)
+
+// AuditEvent is an entry in the append-only security audit log. Each event stores the hash of the event before it
+// and a hash over its own contents, so editing, inserting or removing an event breaks the chain.
+type AuditEvent struct {
+	ID             uint64    `json:"id" example:"1"`
+	ActorID        *uint     `json:"actor_id" example:"1"`
+	ActorEmail     string    `json:"actor_email" example:"user1@example.com"`
+	ImpersonatorID *uint     `json:"impersonator_id" example:"2"`
+	Action         string    `json:"action" example:"auth.login"`
+	TargetType     string    `json:"target_type" example:"user"`
+	TargetID       string    `json:"target_id" example:"1"`
+	Outcome        string    `json:"outcome" example:"success"`
+	Reason         string    `json:"reason" example:""`
+	ClientIP       string    `json:"client_ip" example:"127.0.0.1"`
+	UserAgent      string    `json:"user_agent" example:"curl/8.0.1"`
+	TraceID        string    `json:"trace_id" example:"4bf92f3577b34da6a3ce929d0e0e4736"`
+	CreatedAt      time.Time `json:"created_at" example:"2025-01-01T00:00:00Z"`
+	PrevHash       string    `json:"prev_hash" example:"0000000000000000000000000000000000000000000000000000000000000000"`
+	Hash           string    `json:"hash" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+type AuditEvents []*AuditEvent
+
+type AuditEventFilter struct {
+	ActorID    *uint      `form:"actor_id" example:"1"`
+	Action     string     `form:"action" binding:"max=64" example:"auth.login"`
+	TargetType string     `form:"target_type" binding:"max=64" example:"user"`
+	TargetID   string     `form:"target_id" binding:"max=64" example:"1"`
+	Outcome    string     `form:"outcome" binding:"omitempty,oneof=success failure" example:"failure"`
+	From       *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00" example:"2025-01-01T00:00:00Z"`
+	To         *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00" example:"2025-02-01T00:00:00Z"`
+	Page       int        `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize   int        `form:"page_size" binding:"omitempty,min=1,max=200" example:"50"`
+}
+
+// AuditChainVerification is the result of re-computing the audit log hash chain.
+type AuditChainVerification struct {
+	Valid          bool    `json:"valid" example:"true"`
+	EventsChecked  int64   `json:"events_checked" example:"1024"`
+	FirstInvalidID *uint64 `json:"first_invalid_id" example:"17"`
+	Reason         string  `json:"reason" example:""`
+}
+
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
+
+// Seal links the event to the previous one in the chain and sets its hash. CreatedAt is truncated to the
+// precision Postgres stores, so the hash can be recomputed from the stored row.
+func (event *AuditEvent) Seal(prevHash string) {
+	event.CreatedAt = event.CreatedAt.UTC().Truncate(time.Microsecond)
+	event.PrevHash = prevHash
+	event.Hash = event.ComputeHash()
+}
+
+// ComputeHash returns the SHA-256 of the previous hash and the event's contents. The ID is not included as it is
+// assigned by the database after the hash is computed.
+func (event *AuditEvent) ComputeHash() string {
+	content, _ := json.Marshal([]any{
+		event.PrevHash,
+		event.ActorID,
+		event.ActorEmail,
+		event.ImpersonatorID,
+		event.Action,
+		event.TargetType,
+		event.TargetID,
+		event.Outcome,
+		event.Reason,
+		event.ClientIP,
+		event.UserAgent,
+		event.TraceID,
+		event.CreatedAt.UTC().Format(auditHashTimeFormat),
+	})
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Truncate trims free-text fields to their column sizes before the event is sealed.
+func (event *AuditEvent) Truncate() {
+	event.Reason = truncateString(event.Reason, auditEventReasonMaxLength)
+	event.UserAgent = truncateString(event.UserAgent, auditEventAgentMaxLength)
+	event.TargetID = truncateString(event.TargetID, auditEventTargetIDMaxLength)
+}
+
+func (filter *AuditEventFilter) Normalize() {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = auditEventDefaultPageSize
+	}
+	if filter.PageSize > auditEventMaximumPageSize {
+		filter.PageSize = auditEventMaximumPageSize
+	}
+}
+
+func (event *AuditEvent) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if event.ActorID != nil {
+		enc.AddUint("actor_id", *event.ActorID)
+	}
+	enc.AddString("action", event.Action)
+	enc.AddString("target_type", event.TargetType)
+	enc.AddString("target_id", event.TargetID)
+	enc.AddString("outcome", event.Outcome)
+	enc.AddString("reason", event.Reason)
+	return nil
+}
+
+func truncateString(value string, maxLength int) string {
+	if len(value) <= maxLength {
+		return value
+	}
+	return strings.ToValidUTF8(value[:maxLength], "")
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type EmailVerification struct {
+	ID        uint       `json:"id"`
+	UserID    uint       `json:"user_id"`
+	Email     string     `json:"email"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type VerifyEmailForm struct {
+	Token string `json:"token" binding:"required" example:"Zm9vYmFyYmF6..."`
+}
+
+func (EmailVerification) TableName() string {
+	return "email_verifications"
+}
+
+func (verification *EmailVerification) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddUint("id", verification.ID)
+	enc.AddUint("user_id", verification.UserID)
+	enc.AddString("email", verification.Email)
+	enc.AddTime("expires_at", verification.ExpiresAt)
+	if verification.UsedAt != nil {
+		enc.AddTime("used_at", *verification.UsedAt)
+	} else {
+		enc.AddString("used_at", "null")
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	ImpersonationActionStarted = "impersonation_started"
+	ImpersonationActionRequest = "impersonated_request"
+)
+
+type ImpersonationEvent struct {
+	ID        uint      `json:"id"`
+	ActorID   uint      `json:"actor_id"`
+	UserID    uint      `json:"user_id"`
+	Action    string    `json:"action"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+	ClientIP  string    `json:"client_ip"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (ImpersonationEvent) TableName() string {
+	return "impersonation_events"
+}
+
+func (event *ImpersonationEvent) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddUint("actor_id", event.ActorID)
+	enc.AddUint("user_id", event.UserID)
+	enc.AddString("action", event.Action)
+	enc.AddString("method", event.Method)
+	enc.AddString("path", event.Path)
+	enc.AddInt("status", event.Status)
+	return nil
+}
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusDeclined = "declined"
+	InvitationStatusRevoked  = "revoked"
+)
+
+// Invitation asks the owner of an email address to join an organization with a role. Only the hash of the
+// emailed token is stored.
+type Invitation struct {
+	ID             uint          `json:"id"`
+	OrganizationID uint          `json:"organization_id"`
+	Email          string        `json:"email"`
+	Role           string        `json:"role"`
+	Status         string        `json:"status" gorm:"default:pending"`
+	TokenHash      string        `json:"-"`
+	InvitedByID    *uint         `json:"invited_by_id"`
+	ExpiresAt      time.Time     `json:"expires_at"`
+	RespondedAt    *time.Time    `json:"responded_at"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+	Organization   *Organization `json:"organization,omitempty"`
+}
+
+type InvitationDTO struct {
+	ID             uint      `json:"id" example:"1"`
+	OrganizationID uint      `json:"organization_id" example:"1"`
+	Email          string    `json:"email" example:"user2@example.com"`
+	Role           string    `json:"role" example:"member"`
+	Status         string    `json:"status" example:"pending"`
+	Expired        bool      `json:"expired" example:"false"`
+	ExpiresAt      time.Time `json:"expires_at" example:"2025-01-08T00:00:00Z"`
+	CreatedAt      time.Time `json:"created_at" example:"2025-01-01T00:00:00Z"`
+}
+
+type InvitationForm struct {
+	Email string `json:"email" binding:"required,email" example:"user2@example.com"`
+	Role  string `json:"role" binding:"required,oneof=owner admin member" example:"member"`
+}
+
+type InvitationTokenForm struct {
+	Token string `json:"token" binding:"required,max=512" example:"Zm9vYmFyYmF6..."`
+}
+
+// AcceptInvitationForm accepts an invitation. The password is only needed when no account exists for the
+// invited email, in which case one is created with it.
+type AcceptInvitationForm struct {
+	Token    string `json:"token" binding:"required,max=512" example:"Zm9vYmFyYmF6..."`
+	Password string `json:"password" binding:"omitempty,min=8,max=256" example:"securePassword1234"`
+}
+
+type Invitations []*Invitation
+
+func (Invitation) TableName() string {
+	return "invitations"
+}
+
+// IsOpen reports whether the invitation can still be accepted or declined.
+func (invitation *Invitation) IsOpen() bool {
+	return invitation.Status == InvitationStatusPending && invitation.ExpiresAt.After(time.Now())
+}
+
+func (invitation *Invitation) ToDTO() *InvitationDTO {
+	return &InvitationDTO{
+		ID:             invitation.ID,
+		OrganizationID: invitation.OrganizationID,
+		Email:          invitation.Email,
+		Role:           invitation.Role,
+		Status:         invitation.Status,
+		Expired:        invitation.Status == InvitationStatusPending && !invitation.IsOpen(),
+		ExpiresAt:      invitation.ExpiresAt,
+		CreatedAt:      invitation.CreatedAt,
+	}
+}
+
+func (invitations Invitations) ToDTOs() []*InvitationDTO {
+	dtos := make([]*InvitationDTO, len(invitations))
+	for i, invitation := range invitations {
+		dtos[i] = invitation.ToDTO()
+	}
+	return dtos
+}
+
+func (invitation *Invitation) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddUint("id", invitation.ID)
+	enc.AddUint("organization_id", invitation.OrganizationID)
+	enc.AddString("email", invitation.Email)
+	enc.AddString("role", invitation.Role)
+	enc.AddString("status", invitation.Status)
+	enc.AddTime("expires_at", invitation.ExpiresAt)
+	return nil
+}
+
+func (form *InvitationForm) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("email", form.Email)
+	enc.AddString("role", form.Role)
+	return nil
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	JobStatusPending = "pending"
+	JobStatusRunning = "running"
+	JobStatusDead    = "dead"
+)
+
+const jobErrorMaxLength = 1024
+
+// Job is deferred work run at least once by the background workers of any instance of the application.
+type Job struct {
+	ID          uint64     `json:"id"`
+	Type        string     `json:"type"`
+	Payload     JSONMap    `json:"payload"`
+	Status      string     `json:"status" gorm:"default:pending"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"max_attempts"`
+	RunAt       time.Time  `json:"run_at" gorm:"default:CURRENT_TIMESTAMP"`
+	LockedUntil *time.Time `json:"locked_until"`
+	LastError   string     `json:"last_error"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type Jobs []*Job
+
+func (Job) TableName() string {
+	return "jobs"
+}
+
+// NewJob returns a job of the type with a JSON object payload, run from runAt, or as soon as possible if it is zero.
+func NewJob(jobType string, payload any, maxAttempts int, runAt time.Time) (*Job, error) {
+	payloadMap, err := NewJSONMap(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Job{
+		Type:        jobType,
+		Payload:     payloadMap,
+		MaxAttempts: maxAttempts,
+		RunAt:       runAt,
+	}, nil
+}
+
+// DecodePayload decodes the job's payload into target.
+func (job *Job) DecodePayload(target any) error {
+	data, err := json.Marshal(job.Payload)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// RecordFailure records a failed attempt, retrying the job at nextRunAt or, when nil, dropping its payload as dead.
+func (job *Job) RecordFailure(err error, nextRunAt *time.Time) {
+	job.LastError = truncateString(err.Error(), jobErrorMaxLength)
+	job.LockedUntil = nil
+	if nextRunAt != nil {
+		job.Status = JobStatusPending
+		job.RunAt = *nextRunAt
+	} else {
+		job.Status = JobStatusDead
+		job.Payload = JSONMap{}
+	}
+}
+
+func (job *Job) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddUint64("id", job.ID)
+	enc.AddString("type", job.Type)
+	enc.AddString("status", job.Status)
+	enc.AddInt("attempts", job.Attempts)
+	enc.AddInt("max_attempts", job.MaxAttempts)
+	return nil
+}
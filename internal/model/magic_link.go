@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type MagicLinkToken struct {
+	ID        uint       `json:"id"`
+	UserID    uint       `json:"user_id"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type MagicLinkForm struct {
+	Email string `json:"email" binding:"required,email" example:"user1@example.com"`
+}
+
+type MagicLinkConsumeForm struct {
+	Token string `json:"token" binding:"required" example:"Zm9vYmFyYmF6..."`
+}
+
+func (MagicLinkToken) TableName() string {
+	return "magic_link_tokens"
+}
+
+func (token *MagicLinkToken) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddUint("id", token.ID)
+	enc.AddUint("user_id", token.UserID)
+	enc.AddTime("expires_at", token.ExpiresAt)
+	if token.UsedAt != nil {
+		enc.AddTime("used_at", *token.UsedAt)
+	} else {
+		enc.AddString("used_at", "null")
+	}
+	return nil
+}
+
+func (form *MagicLinkForm) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("email", form.Email)
+	return nil
+}
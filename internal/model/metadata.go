@@ -0,0 +1,178 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	metadataFilterPrefix        = "meta."
+	metadataFilterMaximumCount  = 10
+	metadataFilterMaximumLength = 64
+)
+
+// JSONMap is a JSON object stored in a JSONB column.
+type JSONMap map[string]any
+
+// MetadataSchema is a JSON Schema that the metadata of an organization's Simples must satisfy. A schema with an
+// empty Kind applies to every Simple, otherwise only to Simples of that kind.
+type MetadataSchema struct {
+	ID             uint      `json:"id"`
+	OrganizationID uint      `json:"organization_id"`
+	Kind           string    `json:"kind"`
+	Schema         JSONMap   `json:"schema"`
+	CreatedByID    *uint     `json:"created_by_id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type MetadataSchemaDTO struct {
+	ID        uint      `json:"id" example:"1"`
+	Kind      string    `json:"kind" example:"ticket"`
+	Schema    JSONMap   `json:"schema" swaggertype:"object"`
+	CreatedAt time.Time `json:"created_at" example:"2025-01-01T00:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" example:"2025-01-01T00:00:00Z"`
+}
+
+type MetadataSchemaForm struct {
+	Kind   string  `json:"kind" binding:"max=64" example:"ticket"`
+	Schema JSONMap `json:"schema" binding:"required" swaggertype:"object"`
+}
+
+type MetadataSchemas []*MetadataSchema
+
+func (MetadataSchema) TableName() string {
+	return "metadata_schemas"
+}
+
+// NewJSONMap returns the JSON object that value encodes to, such as a DTO to be stored as JSONB.
+func NewJSONMap(value any) (JSONMap, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var jsonMap JSONMap
+	if err := json.Unmarshal(data, &jsonMap); err != nil {
+		return nil, err
+	}
+	return jsonMap, nil
+}
+
+func (m JSONMap) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	value, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(value), nil
+}
+
+func (m *JSONMap) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into JSONMap", value)
+	}
+	return json.Unmarshal(data, m)
+}
+
+func (JSONMap) GormDataType() string {
+	return "jsonb"
+}
+
+func (schema *MetadataSchema) ToDTO() *MetadataSchemaDTO {
+	return &MetadataSchemaDTO{
+		ID:        schema.ID,
+		Kind:      schema.Kind,
+		Schema:    schema.Schema,
+		CreatedAt: schema.CreatedAt,
+		UpdatedAt: schema.UpdatedAt,
+	}
+}
+
+func (schemas MetadataSchemas) ToDTOs() []*MetadataSchemaDTO {
+	dtos := make([]*MetadataSchemaDTO, len(schemas))
+	for i, schema := range schemas {
+		dtos[i] = schema.ToDTO()
+	}
+	return dtos
+}
+
+// BindMetadata reads the `meta.<path>=<value>` query parameters into the filter. Nested fields are addressed with
+// dots, e.g. `meta.owner.team=core`.
+func (filter *SimpleFilter) BindMetadata(query url.Values) error {
+	for key, values := range query {
+		if !strings.HasPrefix(key, metadataFilterPrefix) {
+			continue
+		}
+
+		path := strings.TrimPrefix(key, metadataFilterPrefix)
+		for _, segment := range strings.Split(path, ".") {
+			if segment == "" || len(segment) > metadataFilterMaximumLength {
+				return fmt.Errorf("invalid metadata filter %q", key)
+			}
+		}
+		if len(values) != 1 {
+			return fmt.Errorf("metadata filter %q must be given once", key)
+		}
+
+		if filter.Metadata == nil {
+			filter.Metadata = map[string]string{}
+		}
+		filter.Metadata[path] = values[0]
+	}
+
+	if len(filter.Metadata) > metadataFilterMaximumCount {
+		return errors.New("too many metadata filters")
+	}
+	return nil
+}
+
+// MetadataContainment returns the JSON documents that metadata must contain for the filter on path to match.
+// Values that are valid JSON numbers, booleans or null also match the typed value, so `meta.count=3` matches
+// both "3" and 3.
+func MetadataContainment(path string, value string) []JSONMap {
+	candidates := []any{value}
+	var typed any
+	if err := json.Unmarshal([]byte(value), &typed); err == nil {
+		switch typed.(type) {
+		case float64, bool, nil:
+			candidates = append(candidates, typed)
+		}
+	}
+
+	segments := strings.Split(path, ".")
+	documents := make([]JSONMap, len(candidates))
+	for i, candidate := range candidates {
+		var nested any = candidate
+		for j := len(segments) - 1; j > 0; j-- {
+			nested = map[string]any{segments[j]: nested}
+		}
+		documents[i] = JSONMap{segments[0]: nested}
+	}
+	return documents
+}
+
+func (schema *MetadataSchema) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddUint("id", schema.ID)
+	enc.AddUint("organization_id", schema.OrganizationID)
+	enc.AddString("kind", schema.Kind)
+	return nil
+}
@@ -0,0 +1,134 @@
+package model
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	OrganizationRoleOwner  = "owner"
+	OrganizationRoleAdmin  = "admin"
+	OrganizationRoleMember = "member"
+)
+
+// Organization is a tenant, which every Simple belongs to.
+type Organization struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Membership gives a user a role in an organization.
+type Membership struct {
+	ID             uint          `json:"id"`
+	OrganizationID uint          `json:"organization_id"`
+	UserID         uint          `json:"user_id"`
+	Role           string        `json:"role"`
+	CreatedAt      time.Time     `json:"created_at"`
+	Organization   *Organization `json:"organization,omitempty"`
+	User           *User         `json:"user,omitempty"`
+}
+
+type OrganizationDTO struct {
+	ID        uint      `json:"id" example:"1"`
+	Name      string    `json:"name" example:"Acme"`
+	Role      string    `json:"role" example:"owner"`
+	CreatedAt time.Time `json:"created_at" example:"2025-01-01T00:00:00Z"`
+}
+
+type MemberDTO struct {
+	UserID    uint      `json:"user_id" example:"1"`
+	Email     string    `json:"email" example:"user1@example.com"`
+	Role      string    `json:"role" example:"member"`
+	CreatedAt time.Time `json:"created_at" example:"2025-01-01T00:00:00Z"`
+}
+
+type OrganizationForm struct {
+	Name string `json:"name" binding:"required,max=255" example:"Acme"`
+}
+
+type MembershipForm struct {
+	Email string `json:"email" binding:"required,email" example:"user2@example.com"`
+	Role  string `json:"role" binding:"required,oneof=owner admin member" example:"member"`
+}
+
+type Memberships []*Membership
+
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+func (Membership) TableName() string {
+	return "memberships"
+}
+
+// CanManageMembers reports whether the member may add and remove other members.
+func (membership *Membership) CanManageMembers() bool {
+	return membership.Role == OrganizationRoleOwner || membership.Role == OrganizationRoleAdmin
+}
+
+func (membership *Membership) IsOwner() bool {
+	return membership.Role == OrganizationRoleOwner
+}
+
+// ToOrganizationDTO converts the membership to its loaded Organization, as seen by the member.
+func (membership *Membership) ToOrganizationDTO() *OrganizationDTO {
+	return &OrganizationDTO{
+		ID:        membership.Organization.ID,
+		Name:      membership.Organization.Name,
+		Role:      membership.Role,
+		CreatedAt: membership.Organization.CreatedAt,
+	}
+}
+
+// ToMemberDTO converts the membership to the member it belongs to. The User must be loaded.
+func (membership *Membership) ToMemberDTO() *MemberDTO {
+	return &MemberDTO{
+		UserID:    membership.UserID,
+		Email:     membership.User.Email,
+		Role:      membership.Role,
+		CreatedAt: membership.CreatedAt,
+	}
+}
+
+func (memberships Memberships) ToOrganizationDTOs() []*OrganizationDTO {
+	dtos := make([]*OrganizationDTO, len(memberships))
+	for i, membership := range memberships {
+		dtos[i] = membership.ToOrganizationDTO()
+	}
+	return dtos
+}
+
+func (memberships Memberships) ToMemberDTOs() []*MemberDTO {
+	dtos := make([]*MemberDTO, len(memberships))
+	for i, membership := range memberships {
+		dtos[i] = membership.ToMemberDTO()
+	}
+	return dtos
+}
+
+func (organization *Organization) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddUint("id", organization.ID)
+	enc.AddString("name", organization.Name)
+	return nil
+}
+
+func (membership *Membership) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddUint("organization_id", membership.OrganizationID)
+	enc.AddUint("user_id", membership.UserID)
+	enc.AddString("role", membership.Role)
+	return nil
+}
+
+func (form *OrganizationForm) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", form.Name)
+	return nil
+}
+
+func (form *MembershipForm) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("email", form.Email)
+	enc.AddString("role", form.Role)
+	return nil
+}
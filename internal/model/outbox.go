@@ -0,0 +1,110 @@
+package model
+
+import (
+	"strconv"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	AggregateTypeSimple = "simple"
+	AggregateTypeUser   = "user"
+)
+
+const (
+	EventSimpleCreated = "simple.created"
+	EventSimpleUpdated = "simple.updated"
+	EventSimpleDeleted = "simple.deleted"
+	EventUserCreated   = "user.created"
+	EventUserUpdated   = "user.updated"
+	EventUserDeleted   = "user.deleted"
+)
+
+const outboxErrorMaxLength = 1024
+
+// OutboxEvent is a domain event written in the same transaction as the change it describes, so it is published if
+// and only if the change is committed. Events are published at least once and, per aggregate, in the order they
+// were written.
+type OutboxEvent struct {
+	ID            uint64     `json:"id"`
+	AggregateType string     `json:"aggregate_type"`
+	AggregateID   string     `json:"aggregate_id"`
+	EventType     string     `json:"event_type"`
+	Payload       JSONMap    `json:"payload"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"default:CURRENT_TIMESTAMP"`
+	LastError     string     `json:"last_error"`
+	PublishedAt   *time.Time `json:"published_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// DomainEvent is the message published for an outbox event. Consumers should deduplicate on ID, as an event can be
+// published more than once.
+type DomainEvent struct {
+	ID            uint64    `json:"id" example:"42"`
+	Type          string    `json:"type" example:"simple.created"`
+	AggregateType string    `json:"aggregate_type" example:"simple"`
+	AggregateID   string    `json:"aggregate_id" example:"1"`
+	OccurredAt    time.Time `json:"occurred_at" example:"2025-01-01T00:00:00Z"`
+	Data          JSONMap   `json:"data" swaggertype:"object"`
+}
+
+type OutboxEvents []*OutboxEvent
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// NewOutboxEvent returns an event of the aggregate with data, typically one of its event DTOs, as the payload.
+func NewOutboxEvent(aggregateType string, aggregateID uint, eventType string, data any) (*OutboxEvent, error) {
+	payload, err := NewJSONMap(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutboxEvent{
+		AggregateType: aggregateType,
+		AggregateID:   strconv.FormatUint(uint64(aggregateID), 10),
+		EventType:     eventType,
+		Payload:       payload,
+	}, nil
+}
+
+// AggregateKey identifies the aggregate the event belongs to, which its events must be published in order for.
+func (event *OutboxEvent) AggregateKey() string {
+	return event.AggregateType + ":" + event.AggregateID
+}
+
+func (event *OutboxEvent) ToDomainEvent() *DomainEvent {
+	return &DomainEvent{
+		ID:            event.ID,
+		Type:          event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		OccurredAt:    event.CreatedAt,
+		Data:          event.Payload,
+	}
+}
+
+func (event *OutboxEvent) MarkPublished(publishedAt time.Time) {
+	event.Attempts++
+	event.LastError = ""
+	event.PublishedAt = &publishedAt
+}
+
+// RecordFailure counts a failed attempt to publish the event, which is retried at nextAttemptAt.
+func (event *OutboxEvent) RecordFailure(err error, nextAttemptAt time.Time) {
+	event.Attempts++
+	event.LastError = truncateString(err.Error(), outboxErrorMaxLength)
+	event.NextAttemptAt = nextAttemptAt
+}
+
+func (event *OutboxEvent) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddUint64("id", event.ID)
+	enc.AddString("aggregate_type", event.AggregateType)
+	enc.AddString("aggregate_id", event.AggregateID)
+	enc.AddString("event_type", event.EventType)
+	enc.AddInt("attempts", event.Attempts)
+	return nil
+}
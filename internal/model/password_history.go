@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// PasswordHistoryEntry is a password hash a User has used before, kept to prevent password reuse.
+type PasswordHistoryEntry struct {
+	ID           uint      `json:"id"`
+	UserID       uint      `json:"user_id"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (PasswordHistoryEntry) TableName() string {
+	return "password_history"
+}
+
+func (entry *PasswordHistoryEntry) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddUint("id", entry.ID)
+	enc.AddUint("user_id", entry.UserID)
+	enc.AddTime("created_at", entry.CreatedAt)
+	return nil
+}
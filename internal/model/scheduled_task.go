@@ -0,0 +1,167 @@
+package model
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	ScheduledTaskOutcomeRunning   = "running"
+	ScheduledTaskOutcomeSucceeded = "succeeded"
+	ScheduledTaskOutcomeFailed    = "failed"
+)
+
+const (
+	scheduledTaskErrorMaxLength   = 1024
+	scheduledTaskRunsDefaultLimit = 20
+)
+
+// ScheduledTask is the state of a periodic task run by the scheduler on a cron schedule, shared by every instance
+// of the application. Only the instance elected leader runs tasks, and each run is claimed by moving NextRunAt on,
+// so a task runs once per scheduled time however many instances there are.
+type ScheduledTask struct {
+	Name           string     `json:"name" gorm:"primaryKey"`
+	Schedule       string     `json:"schedule"`
+	NextRunAt      time.Time  `json:"next_run_at"`
+	LastStartedAt  *time.Time `json:"last_started_at"`
+	LastFinishedAt *time.Time `json:"last_finished_at"`
+	LastOutcome    string     `json:"last_outcome"`
+	LastError      string     `json:"last_error"`
+	LastDurationMs int64      `json:"last_duration_ms"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type ScheduledTaskDTO struct {
+	Name           string     `json:"name" example:"tokens.cleanup"`
+	Schedule       string     `json:"schedule" example:"0 * * * *"`
+	NextRunAt      time.Time  `json:"next_run_at" example:"2025-01-01T01:00:00Z"`
+	Running        bool       `json:"running" example:"false"`
+	LastStartedAt  *time.Time `json:"last_started_at" example:"2025-01-01T00:00:00Z"`
+	LastFinishedAt *time.Time `json:"last_finished_at" example:"2025-01-01T00:00:01Z"`
+	LastOutcome    string     `json:"last_outcome" example:"succeeded"`
+	LastError      string     `json:"last_error" example:""`
+	LastDurationMs int64      `json:"last_duration_ms" example:"120"`
+}
+
+// ScheduledTaskRun is one run of a scheduled task, kept for operators to see when and where tasks ran.
+type ScheduledTaskRun struct {
+	ID           uint64     `json:"id"`
+	TaskName     string     `json:"task_name"`
+	ScheduledFor time.Time  `json:"scheduled_for"`
+	Instance     string     `json:"instance"`
+	Outcome      string     `json:"outcome" gorm:"default:running"`
+	Error        string     `json:"error"`
+	DurationMs   int64      `json:"duration_ms"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+}
+
+type ScheduledTaskRunDTO struct {
+	ID           uint64     `json:"id" example:"1"`
+	ScheduledFor time.Time  `json:"scheduled_for" example:"2025-01-01T00:00:00Z"`
+	Instance     string     `json:"instance" example:"api-7d9f8b6c5-x2k4q"`
+	Outcome      string     `json:"outcome" example:"succeeded"`
+	Error        string     `json:"error" example:""`
+	DurationMs   int64      `json:"duration_ms" example:"120"`
+	StartedAt    time.Time  `json:"started_at" example:"2025-01-01T00:00:00Z"`
+	FinishedAt   *time.Time `json:"finished_at" example:"2025-01-01T00:00:01Z"`
+}
+
+// SchedulerStatusDTO describes the scheduled tasks as seen by the instance answering the request.
+type SchedulerStatusDTO struct {
+	Instance string              `json:"instance" example:"api-7d9f8b6c5-x2k4q"`
+	Leader   bool                `json:"leader" example:"true"`
+	Tasks    []*ScheduledTaskDTO `json:"tasks"`
+}
+
+type ScheduledTaskRunFilter struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100" example:"20"`
+}
+
+type ScheduledTasks []*ScheduledTask
+type ScheduledTaskRuns []*ScheduledTaskRun
+
+func (ScheduledTask) TableName() string {
+	return "scheduled_tasks"
+}
+
+func (ScheduledTaskRun) TableName() string {
+	return "scheduled_task_runs"
+}
+
+// IsRunning reports whether the task's last run has started but not finished.
+func (task *ScheduledTask) IsRunning() bool {
+	return task.LastStartedAt != nil && (task.LastFinishedAt == nil || task.LastFinishedAt.Before(*task.LastStartedAt))
+}
+
+func (task *ScheduledTask) ToDTO() *ScheduledTaskDTO {
+	return &ScheduledTaskDTO{
+		Name:           task.Name,
+		Schedule:       task.Schedule,
+		NextRunAt:      task.NextRunAt,
+		Running:        task.IsRunning(),
+		LastStartedAt:  task.LastStartedAt,
+		LastFinishedAt: task.LastFinishedAt,
+		LastOutcome:    task.LastOutcome,
+		LastError:      task.LastError,
+		LastDurationMs: task.LastDurationMs,
+	}
+}
+
+func (tasks ScheduledTasks) ToDTOs() []*ScheduledTaskDTO {
+	dtos := make([]*ScheduledTaskDTO, len(tasks))
+	for i, task := range tasks {
+		dtos[i] = task.ToDTO()
+	}
+	return dtos
+}
+
+func (filter *ScheduledTaskRunFilter) Normalize() {
+	if filter.Limit < 1 {
+		filter.Limit = scheduledTaskRunsDefaultLimit
+	}
+}
+
+// Finish records the outcome of the run, failed if err is not nil.
+func (run *ScheduledTaskRun) Finish(err error, finishedAt time.Time) {
+	run.FinishedAt = &finishedAt
+	run.DurationMs = finishedAt.Sub(run.StartedAt).Milliseconds()
+	run.Outcome = ScheduledTaskOutcomeSucceeded
+	run.Error = ""
+	if err != nil {
+		run.Outcome = ScheduledTaskOutcomeFailed
+		run.Error = truncateString(err.Error(), scheduledTaskErrorMaxLength)
+	}
+}
+
+func (run *ScheduledTaskRun) ToDTO() *ScheduledTaskRunDTO {
+	return &ScheduledTaskRunDTO{
+		ID:           run.ID,
+		ScheduledFor: run.ScheduledFor,
+		Instance:     run.Instance,
+		Outcome:      run.Outcome,
+		Error:        run.Error,
+		DurationMs:   run.DurationMs,
+		StartedAt:    run.StartedAt,
+		FinishedAt:   run.FinishedAt,
+	}
+}
+
+func (runs ScheduledTaskRuns) ToDTOs() []*ScheduledTaskRunDTO {
+	dtos := make([]*ScheduledTaskRunDTO, len(runs))
+	for i, run := range runs {
+		dtos[i] = run.ToDTO()
+	}
+	return dtos
+}
+
+func (run *ScheduledTaskRun) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddUint64("id", run.ID)
+	enc.AddString("task", run.TaskName)
+	enc.AddTime("scheduled_for", run.ScheduledFor)
+	enc.AddString("outcome", run.Outcome)
+	enc.AddInt64("duration_ms", run.DurationMs)
+	return nil
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Session is a login on one device. Every token issued at login carries the session id, so revoking the
+// session invalidates the token straight away.
+type Session struct {
+	ID         uint       `json:"id"`
+	UserID     uint       `json:"user_id"`
+	UserAgent  string     `json:"user_agent"`
+	ClientIP   string     `json:"client_ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type SessionDTO struct {
+	ID         uint      `json:"id" example:"1"`
+	UserAgent  string    `json:"user_agent" example:"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7)"`
+	ClientIP   string    `json:"client_ip" example:"203.0.113.7"`
+	CreatedAt  time.Time `json:"created_at" example:"2025-01-01T00:00:00Z"`
+	LastSeenAt time.Time `json:"last_seen_at" example:"2025-01-01T00:00:00Z"`
+	ExpiresAt  time.Time `json:"expires_at" example:"2025-01-02T00:00:00Z"`
+	Current    bool      `json:"current" example:"true"`
+}
+
+type Sessions []*Session
+
+func (Session) TableName() string {
+	return "sessions"
+}
+
+// IsActive reports whether the session can still be used: it has not been revoked or expired, and was not
+// started before the user's tokens were last revoked.
+func (session *Session) IsActive(user *User) bool {
+	if session.RevokedAt != nil || !session.ExpiresAt.After(time.Now()) {
+		return false
+	}
+	return user.TokensValidAfter == nil || !session.CreatedAt.Before(*user.TokensValidAfter)
+}
+
+// ToDTO converts the session, flagging it as current if it is the session the request was made with.
+func (session *Session) ToDTO(currentSessionID uint) *SessionDTO {
+	return &SessionDTO{
+		ID:         session.ID,
+		UserAgent:  session.UserAgent,
+		ClientIP:   session.ClientIP,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		ExpiresAt:  session.ExpiresAt,
+		Current:    session.ID == currentSessionID,
+	}
+}
+
+func (sessions Sessions) ToDTOs(currentSessionID uint) []*SessionDTO {
+	dtos := make([]*SessionDTO, len(sessions))
+	for i, session := range sessions {
+		dtos[i] = session.ToDTO(currentSessionID)
+	}
+	return dtos
+}
+
+func (session *Session) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddUint("id", session.ID)
+	enc.AddUint("user_id", session.UserID)
+	enc.AddString("client_ip", session.ClientIP)
+	enc.AddTime("expires_at", session.ExpiresAt)
+	return nil
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"strings"
 	"time"
 
 	"go.uber.org/zap/zapcore"
@@ -8,44 +9,142 @@ import (
 )
 
 type Simple struct {
-	ID        uint           `json:"id"`
-	Name      string         `json:"name"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"deleted_at"`
+	ID             uint           `json:"id"`
+	OrganizationID uint           `json:"organization_id"`
+	OwnerID        *uint          `json:"owner_id"`
+	Name           string         `json:"name"`
+	Kind           string         `json:"kind"`
+	Metadata       JSONMap        `json:"metadata"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"deleted_at"`
+	Grants         SimpleGrants   `json:"-" gorm:"foreignKey:SimpleID"`
+	Tags           Tags           `json:"-" gorm:"many2many:simple_tags"`
+
+	// Resolved for the requesting user by ResolveAccess, never stored.
+	Permission string `json:"-" gorm:"-"`
+	Owned      bool   `json:"-" gorm:"-"`
+	Shared     bool   `json:"-" gorm:"-"`
 }
 
 type SimpleDTO struct {
-	ID        uint      `json:"id" example:"1"`
-	Name      string    `json:"name" example:"My Simple"`
-	CreatedAt time.Time `json:"created_at" example:"2025-01-01T00:00:00Z"`
-	UpdatedAt time.Time `json:"updated_at" example:"2025-01-01T00:00:00Z"`
+	ID             uint      `json:"id" example:"1"`
+	OrganizationID uint      `json:"organization_id" example:"1"`
+	OwnerID        *uint     `json:"owner_id" example:"1"`
+	Name           string    `json:"name" example:"My Simple"`
+	Kind           string    `json:"kind" example:"ticket"`
+	Metadata       JSONMap   `json:"metadata" swaggertype:"object"`
+	Owned          bool      `json:"owned" example:"true"`
+	Shared         bool      `json:"shared" example:"false"`
+	Permission     string    `json:"permission,omitempty" example:"manage"`
+	Tags           []string  `json:"tags" example:"urgent,billing"`
+	CreatedAt      time.Time `json:"created_at" example:"2025-01-01T00:00:00Z"`
+	UpdatedAt      time.Time `json:"updated_at" example:"2025-01-01T00:00:00Z"`
+}
+
+// SimpleEventDTO describes a Simple in events sent to other systems, without the requesting user's access.
+type SimpleEventDTO struct {
+	ID             uint      `json:"id" example:"1"`
+	OrganizationID uint      `json:"organization_id" example:"1"`
+	OwnerID        *uint     `json:"owner_id" example:"1"`
+	Name           string    `json:"name" example:"My Simple"`
+	Kind           string    `json:"kind" example:"ticket"`
+	Metadata       JSONMap   `json:"metadata" swaggertype:"object"`
+	Tags           []string  `json:"tags" example:"urgent,billing"`
+	CreatedAt      time.Time `json:"created_at" example:"2025-01-01T00:00:00Z"`
+	UpdatedAt      time.Time `json:"updated_at" example:"2025-01-01T00:00:00Z"`
 }
 
+// SimpleForm replaces the Simple's tags and metadata when they are given, and leaves them unchanged when they are
+// omitted. The kind is set when the Simple is created and cannot be changed.
 type SimpleForm struct {
-	Name string `json:"name" binding:"required,max=255" example:"My Simple"`
+	Name     string   `json:"name" binding:"required,max=255" example:"My Simple"`
+	Kind     string   `json:"kind" binding:"max=64" example:"ticket"`
+	Tags     []string `json:"tags" binding:"omitempty,max=20,dive,required,max=64" example:"urgent,billing"`
+	Metadata JSONMap  `json:"metadata" swaggertype:"object"`
+}
+
+// SimpleFilter narrows the Simples listed to those of a kind, tagged with any or all of the given tags, and whose
+// metadata contains the given values. Metadata is bound separately with BindMetadata.
+type SimpleFilter struct {
+	Kind     string            `form:"kind" binding:"max=64" example:"ticket"`
+	Tags     []string          `form:"tag" binding:"max=20,dive,max=64" example:"urgent"`
+	Match    string            `form:"match" binding:"omitempty,oneof=any all" example:"any"`
+	Metadata map[string]string `form:"-"`
 }
 
 type Simples []*Simple
 
 func (simple *Simple) ToDTO() *SimpleDTO {
 	return &SimpleDTO{
-		ID:        simple.ID,
-		Name:      simple.Name,
-		CreatedAt: simple.CreatedAt,
-		UpdatedAt: simple.UpdatedAt,
+		ID:             simple.ID,
+		OrganizationID: simple.OrganizationID,
+		OwnerID:        simple.OwnerID,
+		Name:           simple.Name,
+		Kind:           simple.Kind,
+		Metadata:       simple.Metadata,
+		Owned:          simple.Owned,
+		Shared:         simple.Shared,
+		Permission:     simple.Permission,
+		Tags:           simple.Tags.Names(),
+		CreatedAt:      simple.CreatedAt,
+		UpdatedAt:      simple.UpdatedAt,
 	}
 }
 
+func (simple *Simple) ToEventDTO() *SimpleEventDTO {
+	return &SimpleEventDTO{
+		ID:             simple.ID,
+		OrganizationID: simple.OrganizationID,
+		OwnerID:        simple.OwnerID,
+		Name:           simple.Name,
+		Kind:           simple.Kind,
+		Metadata:       simple.Metadata,
+		Tags:           simple.Tags.Names(),
+		CreatedAt:      simple.CreatedAt,
+		UpdatedAt:      simple.UpdatedAt,
+	}
+}
+
+// ResolveAccess sets the permission the user has on the Simple and reports whether they can see it at all. Owners,
+// and the organization's owners and admins, can manage it; anyone else needs a grant. The Grants must be loaded.
+func (simple *Simple) ResolveAccess(userID uint, membership *Membership) bool {
+	simple.Owned = simple.OwnerID != nil && *simple.OwnerID == userID
+	simple.Shared = false
+	simple.Permission = ""
+
+	if simple.Owned || (membership != nil && membership.CanManageMembers()) {
+		simple.Permission = SimplePermissionManage
+		return true
+	}
+	for _, grant := range simple.Grants {
+		if grant.UserID == userID {
+			simple.Permission = grant.Permission
+			simple.Shared = true
+			return true
+		}
+	}
+	return false
+}
+
+// Allows reports whether the permission resolved by ResolveAccess includes the given one.
+func (simple *Simple) Allows(permission string) bool {
+	return simplePermissionRanks[simple.Permission] >= simplePermissionRanks[permission]
+}
+
 func (simple *Simple) ToForm() *SimpleForm {
-	return &SimpleForm{
-		Name: simple.Name,
+	simpleForm := &SimpleForm{Name: simple.Name, Kind: simple.Kind, Metadata: simple.Metadata}
+	if len(simple.Tags) > 0 {
+		simpleForm.Tags = simple.Tags.Names()
 	}
+	return simpleForm
 }
 
 func (simpleForm *SimpleForm) ToModel() *Simple {
 	return &Simple{
-		Name: simpleForm.Name,
+		Name:     simpleForm.Name,
+		Kind:     simpleForm.Kind,
+		Metadata: simpleForm.Metadata,
 	}
 }
 
@@ -64,7 +163,9 @@ func (Simple) TableName() string {
 
 func (s *Simple) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 	enc.AddUint("id", s.ID)
+	enc.AddUint("organization_id", s.OrganizationID)
 	enc.AddString("name", s.Name)
+	enc.AddString("kind", s.Kind)
 	enc.AddTime("created_at", s.CreatedAt)
 	enc.AddTime("updated_at", s.UpdatedAt)
 	return nil
@@ -72,5 +173,22 @@ func (s *Simple) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 
 func (s *SimpleForm) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 	enc.AddString("name", s.Name)
+	enc.AddString("kind", s.Kind)
+	enc.AddString("tags", strings.Join(s.Tags, ","))
+	return nil
+}
+
+func (filter *SimpleFilter) Normalize() {
+	filter.Tags = NormalizeTagNames(filter.Tags)
+	if filter.Match == "" {
+		filter.Match = TagMatchAny
+	}
+}
+
+func (filter *SimpleFilter) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("kind", filter.Kind)
+	enc.AddString("tags", strings.Join(filter.Tags, ","))
+	enc.AddString("match", filter.Match)
+	enc.AddInt("metadata_filters", len(filter.Metadata))
 	return nil
 }
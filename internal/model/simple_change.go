@@ -0,0 +1,67 @@
+package model
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// SimpleChangeChannel is the Postgres notification channel every change to a Simple is announced on.
+const SimpleChangeChannel = "simple_changes"
+
+// SimpleChangeNotification is the payload of a notification on SimpleChangeChannel. It only identifies the change,
+// as notification payloads are limited in size, so listeners load the Simple themselves.
+type SimpleChangeNotification struct {
+	EventID        uint64 `json:"event_id"`
+	Type           string `json:"type"`
+	OrganizationID uint   `json:"organization_id"`
+	SimpleID       uint   `json:"simple_id"`
+}
+
+// SimpleChange is a change to a Simple as streamed to clients. It is identified by the ID of its outbox event, so
+// every instance of the application numbers it the same. The Simple, with its grants, is its state when the change
+// was received, which may already include later changes.
+type SimpleChange struct {
+	ID             uint64
+	Type           string
+	OrganizationID uint
+	Simple         *Simple
+}
+
+type SimpleChangeDTO struct {
+	Type   string     `json:"type" example:"simple.updated"`
+	Simple *SimpleDTO `json:"simple"`
+}
+
+func (notification *SimpleChangeNotification) ToChange(simple *Simple) *SimpleChange {
+	return &SimpleChange{
+		ID:             notification.EventID,
+		Type:           notification.Type,
+		OrganizationID: notification.OrganizationID,
+		Simple:         simple,
+	}
+}
+
+// VisibleTo returns the change with the Simple resolved for the user, or nil if they cannot see the Simple. A change
+// is shared by every subscriber, so the Simple is copied before its access is resolved.
+func (change *SimpleChange) VisibleTo(organizationID uint, userID uint, membership *Membership) *SimpleChange {
+	if change.OrganizationID != organizationID {
+		return nil
+	}
+
+	simple := *change.Simple
+	if !simple.ResolveAccess(userID, membership) {
+		return nil
+	}
+	return &SimpleChange{ID: change.ID, Type: change.Type, OrganizationID: change.OrganizationID, Simple: &simple}
+}
+
+func (change *SimpleChange) ToDTO() *SimpleChangeDTO {
+	return &SimpleChangeDTO{Type: change.Type, Simple: change.Simple.ToDTO()}
+}
+
+func (change *SimpleChange) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddUint64("id", change.ID)
+	enc.AddString("type", change.Type)
+	enc.AddUint("organization_id", change.OrganizationID)
+	enc.AddUint("simple_id", change.Simple.ID)
+	return nil
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	SimplePermissionRead   = "read"
+	SimplePermissionWrite  = "write"
+	SimplePermissionManage = "manage"
+)
+
+// Each permission includes the ones ranked below it.
+var simplePermissionRanks = map[string]int{
+	SimplePermissionRead:   1,
+	SimplePermissionWrite:  2,
+	SimplePermissionManage: 3,
+}
+
+// SimpleGrant shares a Simple with a user who does not own it.
+type SimpleGrant struct {
+	ID          uint      `json:"id"`
+	SimpleID    uint      `json:"simple_id"`
+	UserID      uint      `json:"user_id"`
+	Permission  string    `json:"permission"`
+	GrantedByID *uint     `json:"granted_by_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	User        *User     `json:"user,omitempty"`
+}
+
+type SimpleGrantDTO struct {
+	UserID     uint      `json:"user_id" example:"2"`
+	Email      string    `json:"email" example:"user2@example.com"`
+	Permission string    `json:"permission" example:"read"`
+	CreatedAt  time.Time `json:"created_at" example:"2025-01-01T00:00:00Z"`
+	UpdatedAt  time.Time `json:"updated_at" example:"2025-01-01T00:00:00Z"`
+}
+
+type SimpleShareForm struct {
+	Email      string `json:"email" binding:"required,email" example:"user2@example.com"`
+	Permission string `json:"permission" binding:"required,oneof=read write" example:"read"`
+}
+
+type SimpleGrants []*SimpleGrant
+
+func (SimpleGrant) TableName() string {
+	return "simple_grants"
+}
+
+// ToDTO converts the grant to the user it shares the Simple with. The User must be loaded.
+func (grant *SimpleGrant) ToDTO() *SimpleGrantDTO {
+	return &SimpleGrantDTO{
+		UserID:     grant.UserID,
+		Email:      grant.User.Email,
+		Permission: grant.Permission,
+		CreatedAt:  grant.CreatedAt,
+		UpdatedAt:  grant.UpdatedAt,
+	}
+}
+
+func (grants SimpleGrants) ToDTOs() []*SimpleGrantDTO {
+	dtos := make([]*SimpleGrantDTO, len(grants))
+	for i, grant := range grants {
+		dtos[i] = grant.ToDTO()
+	}
+	return dtos
+}
+
+func (grant *SimpleGrant) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddUint("simple_id", grant.SimpleID)
+	enc.AddUint("user_id", grant.UserID)
+	enc.AddString("permission", grant.Permission)
+	return nil
+}
+
+func (form *SimpleShareForm) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("email", form.Email)
+	enc.AddString("permission", form.Permission)
+	return nil
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+const (
+	tagSuggestionDefaultLimit = 10
+	tagSuggestionMaximumLimit = 50
+)
+
+// Tag categorises Simples within an organization. Names are stored lower case, so tags differing only in case
+// are the same tag.
+type Tag struct {
+	ID             uint      `json:"id"`
+	OrganizationID uint      `json:"organization_id"`
+	Name           string    `json:"name"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TagCount is a tag suggested for autocomplete, with the number of Simples using it.
+type TagCount struct {
+	Name  string `json:"name" example:"urgent"`
+	Count int64  `json:"count" example:"3"`
+}
+
+type TagSuggestionFilter struct {
+	Query string `form:"q" binding:"max=64" example:"urg"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=50" example:"10"`
+}
+
+type Tags []*Tag
+
+func (Tag) TableName() string {
+	return "tags"
+}
+
+func (tags Tags) Names() []string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return names
+}
+
+// NormalizeTagNames lower cases and trims the names, dropping empty ones and duplicates. A nil slice stays nil,
+// so callers can tell "no tags given" from "no tags".
+func NormalizeTagNames(names []string) []string {
+	if names == nil {
+		return nil
+	}
+
+	normalized := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		normalized = append(normalized, name)
+	}
+	return normalized
+}
+
+func (filter *TagSuggestionFilter) Normalize() {
+	filter.Query = strings.ToLower(strings.TrimSpace(filter.Query))
+	if filter.Limit < 1 {
+		filter.Limit = tagSuggestionDefaultLimit
+	}
+	if filter.Limit > tagSuggestionMaximumLimit {
+		filter.Limit = tagSuggestionMaximumLimit
+	}
+}
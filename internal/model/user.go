@@ -7,13 +7,25 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	UserRoleUser  = "user"
+	UserRoleAdmin = "admin"
+)
+
 type User struct {
-	ID           uint           `json:"id"`
-	Email        string         `json:"email"`
-	PasswordHash string         `json:"password_hash"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `json:"deleted_at"`
+	ID                    uint           `json:"id"`
+	Email                 string         `json:"email"`
+	PasswordHash          string         `json:"password_hash"`
+	Role                  string         `json:"role" gorm:"default:user"`
+	DisabledAt            *time.Time     `json:"disabled_at"`
+	PasswordResetRequired bool           `json:"password_reset_required"`
+	FailedLoginAttempts   int            `json:"failed_login_attempts"`
+	LockedUntil           *time.Time     `json:"locked_until"`
+	TokensValidAfter      *time.Time     `json:"tokens_valid_after"`
+	EmailVerifiedAt       *time.Time     `json:"email_verified_at"`
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+	DeletedAt             gorm.DeletedAt `json:"deleted_at"`
 }
 
 type UserDTO struct {
@@ -23,9 +35,56 @@ type UserDTO struct {
 	UpdatedAt time.Time `json:"updated_at" example:"2025-01-01T00:00:00Z"`
 }
 
+type AdminUserDTO struct {
+	ID                    uint       `json:"id" example:"1"`
+	Email                 string     `json:"email" example:"user1@example.com"`
+	Role                  string     `json:"role" example:"user"`
+	DisabledAt            *time.Time `json:"disabled_at" example:"2025-01-01T00:00:00Z"`
+	PasswordResetRequired bool       `json:"password_reset_required" example:"false"`
+	FailedLoginAttempts   int        `json:"failed_login_attempts" example:"0"`
+	LockedUntil           *time.Time `json:"locked_until" example:"2025-01-01T00:00:00Z"`
+	EmailVerifiedAt       *time.Time `json:"email_verified_at" example:"2025-01-01T00:00:00Z"`
+	CreatedAt             time.Time  `json:"created_at" example:"2025-01-01T00:00:00Z"`
+	UpdatedAt             time.Time  `json:"updated_at" example:"2025-01-01T00:00:00Z"`
+}
+
+// UserEventDTO is the user carried by user domain events. Credentials and login state are left out.
+type UserEventDTO struct {
+	ID              uint       `json:"id" example:"1"`
+	Email           string     `json:"email" example:"user1@example.com"`
+	Role            string     `json:"role" example:"user"`
+	DisabledAt      *time.Time `json:"disabled_at" example:"2025-01-01T00:00:00Z"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at" example:"2025-01-01T00:00:00Z"`
+	CreatedAt       time.Time  `json:"created_at" example:"2025-01-01T00:00:00Z"`
+	UpdatedAt       time.Time  `json:"updated_at" example:"2025-01-01T00:00:00Z"`
+}
+
+type UserFilter struct {
+	Search   string `form:"search" binding:"max=255" example:"example.com"`
+	Page     int    `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+}
+
 type UserForm struct {
 	Email    string `json:"email" binding:"required,email" example:"user1@example.com"`
-	Password string `json:"password" binding:"required,min=8,max=72" example:"securePassword1234"`
+	Password string `json:"password" binding:"required,min=8,max=256" example:"securePassword1234"`
+}
+
+// SignUpForm creates an account. With an invitation token for the same email the account joins the inviting
+// organization and its email is treated as verified.
+type SignUpForm struct {
+	UserForm
+	InvitationToken string `json:"invitation_token" binding:"omitempty,max=512" example:"Zm9vYmFyYmF6..."`
+}
+
+type ChangePasswordForm struct {
+	CurrentPassword string `json:"current_password" binding:"required" example:"securePassword1234"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,max=256" example:"newSecurePassword1234"`
+}
+
+type ChangeEmailForm struct {
+	Email           string `json:"email" binding:"required,email" example:"user2@example.com"`
+	CurrentPassword string `json:"current_password" binding:"required" example:"securePassword1234"`
 }
 
 type Users []*User
@@ -39,6 +98,63 @@ func (user *User) ToDTO() *UserDTO {
 	}
 }
 
+func (user *User) ToAdminDTO() *AdminUserDTO {
+	return &AdminUserDTO{
+		ID:                    user.ID,
+		Email:                 user.Email,
+		Role:                  user.Role,
+		DisabledAt:            user.DisabledAt,
+		PasswordResetRequired: user.PasswordResetRequired,
+		FailedLoginAttempts:   user.FailedLoginAttempts,
+		LockedUntil:           user.LockedUntil,
+		EmailVerifiedAt:       user.EmailVerifiedAt,
+		CreatedAt:             user.CreatedAt,
+		UpdatedAt:             user.UpdatedAt,
+	}
+}
+
+func (user *User) ToEventDTO() *UserEventDTO {
+	return &UserEventDTO{
+		ID:              user.ID,
+		Email:           user.Email,
+		Role:            user.Role,
+		DisabledAt:      user.DisabledAt,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}
+}
+
+func (users Users) ToAdminDTOs() []*AdminUserDTO {
+	userDTOs := make([]*AdminUserDTO, len(users))
+	for i, user := range users {
+		userDTOs[i] = user.ToAdminDTO()
+	}
+	return userDTOs
+}
+
+func (user *User) IsAdmin() bool {
+	return user.Role == UserRoleAdmin
+}
+
+func (user *User) IsDisabled() bool {
+	return user.DisabledAt != nil
+}
+
+func (user *User) IsLocked() bool {
+	return user.LockedUntil != nil && user.LockedUntil.After(time.Now())
+}
+
+// Fills in default paging values for any that were not provided
+func (filter *UserFilter) Normalize() {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = 20
+	}
+}
+
 func (userForm *UserForm) ToModel(hashedPassword string) *User {
 	return &User{
 		Email:        userForm.Email,
@@ -49,6 +165,9 @@ func (userForm *UserForm) ToModel(hashedPassword string) *User {
 func (user *User) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 	enc.AddUint("id", user.ID)
 	enc.AddString("email", user.Email)
+	enc.AddString("role", user.Role)
+	enc.AddBool("disabled", user.IsDisabled())
+	enc.AddBool("locked", user.IsLocked())
 	enc.AddTime("created_at", user.CreatedAt)
 	enc.AddTime("updated_at", user.UpdatedAt)
 	if user.DeletedAt.Valid {
@@ -69,3 +188,21 @@ func (userForm *UserForm) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 	}
 	return nil
 }
+
+func (form *ChangePasswordForm) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("current_password", "[PROVIDED]")
+	enc.AddInt("new_password_length", len(form.NewPassword))
+	return nil
+}
+
+func (form *ChangeEmailForm) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("email", form.Email)
+	return nil
+}
+
+func (filter *UserFilter) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("search", filter.Search)
+	enc.AddInt("page", filter.Page)
+	enc.AddInt("page_size", filter.PageSize)
+	return nil
+}
@@ -0,0 +1,275 @@
+package model
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Webhooks are sent for the Simple domain events.
+const (
+	WebhookEventSimpleCreated = EventSimpleCreated
+	WebhookEventSimpleUpdated = EventSimpleUpdated
+	WebhookEventSimpleDeleted = EventSimpleDeleted
+)
+
+const (
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusSucceeded = "succeeded"
+	WebhookDeliveryStatusFailed    = "failed"
+)
+
+const (
+	webhookDeliveryDefaultPageSize = 50
+	webhookDeliveryMaximumPageSize = 200
+	webhookErrorMaxLength          = 1024
+)
+
+// WebhookEndpoint receives the organization's Simple lifecycle events it subscribes to, signed with its secret.
+type WebhookEndpoint struct {
+	ID             uint      `json:"id"`
+	OrganizationID uint      `json:"organization_id"`
+	URL            string    `json:"url"`
+	Description    string    `json:"description"`
+	Events         []string  `json:"events" gorm:"serializer:json"`
+	Secret         string    `json:"-"`
+	Active         bool      `json:"active"`
+	CreatedByID    *uint     `json:"created_by_id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type WebhookEndpointDTO struct {
+	ID          uint      `json:"id" example:"1"`
+	URL         string    `json:"url" example:"https://example.com/hooks/stage-zero"`
+	Description string    `json:"description" example:"Sync to billing"`
+	Events      []string  `json:"events" example:"simple.created,simple.deleted"`
+	Active      bool      `json:"active" example:"true"`
+	CreatedAt   time.Time `json:"created_at" example:"2025-01-01T00:00:00Z"`
+	UpdatedAt   time.Time `json:"updated_at" example:"2025-01-01T00:00:00Z"`
+}
+
+// WebhookEndpointCreatedDTO is returned once, when the endpoint is created, with the secret to verify signatures.
+type WebhookEndpointCreatedDTO struct {
+	WebhookEndpointDTO
+	Secret string `json:"secret" example:"whsec_3q2-7wEAAAB0ZXN0LXNlY3JldC1mb3ItZG9jcw"`
+}
+
+// WebhookEndpointForm registers or replaces an endpoint. Endpoints are active unless Active is set to false.
+type WebhookEndpointForm struct {
+	URL         string   `json:"url" binding:"required,url,max=2048" example:"https://example.com/hooks/stage-zero"`
+	Description string   `json:"description" binding:"max=255" example:"Sync to billing"`
+	Events      []string `json:"events" binding:"required,min=1,max=3,dive,oneof=simple.created simple.updated simple.deleted" example:"simple.created,simple.deleted"`
+	Active      *bool    `json:"active" example:"true"`
+}
+
+// WebhookEvent is the JSON body POSTed to endpoints. Redeliveries carry the same ID, so receivers can deduplicate.
+type WebhookEvent struct {
+	ID             string    `json:"id" example:"evt_5f0c6e1a9b7d4c2e8f3a1b6d0c9e7f2a"`
+	Type           string    `json:"type" example:"simple.created"`
+	OrganizationID uint      `json:"organization_id" example:"1"`
+	CreatedAt      time.Time `json:"created_at" example:"2025-01-01T00:00:00Z"`
+	Data           any       `json:"data"`
+}
+
+// WebhookDelivery is one event sent, or to be sent, to an endpoint.
+type WebhookDelivery struct {
+	ID                 uint64                  `json:"id"`
+	EndpointID         uint                    `json:"endpoint_id"`
+	EventID            string                  `json:"event_id"`
+	EventType          string                  `json:"event_type"`
+	Payload            JSONMap                 `json:"payload"`
+	Status             string                  `json:"status"`
+	Attempts           int                     `json:"attempts"`
+	NextAttemptAt      *time.Time              `json:"next_attempt_at"`
+	LastResponseStatus *int                    `json:"last_response_status"`
+	LastError          string                  `json:"last_error"`
+	RedeliveryOfID     *uint64                 `json:"redelivery_of_id"`
+	CreatedAt          time.Time               `json:"created_at"`
+	UpdatedAt          time.Time               `json:"updated_at"`
+	AttemptLog         WebhookDeliveryAttempts `json:"-" gorm:"foreignKey:DeliveryID"`
+	Endpoint           *WebhookEndpoint        `json:"-"`
+}
+
+type WebhookDeliveryDTO struct {
+	ID                 uint64                       `json:"id" example:"1"`
+	EventID            string                       `json:"event_id" example:"evt_5f0c6e1a9b7d4c2e8f3a1b6d0c9e7f2a"`
+	EventType          string                       `json:"event_type" example:"simple.created"`
+	Payload            JSONMap                      `json:"payload" swaggertype:"object"`
+	Status             string                       `json:"status" example:"pending"`
+	Attempts           int                          `json:"attempts" example:"2"`
+	NextAttemptAt      *time.Time                   `json:"next_attempt_at" example:"2025-01-01T00:01:00Z"`
+	LastResponseStatus *int                         `json:"last_response_status" example:"503"`
+	LastError          string                       `json:"last_error" example:"unexpected status 503"`
+	RedeliveryOfID     *uint64                      `json:"redelivery_of_id" example:"1"`
+	AttemptLog         []*WebhookDeliveryAttemptDTO `json:"attempt_log"`
+	CreatedAt          time.Time                    `json:"created_at" example:"2025-01-01T00:00:00Z"`
+}
+
+type WebhookDeliveryAttempt struct {
+	ID             uint64    `json:"id"`
+	DeliveryID     uint64    `json:"delivery_id"`
+	ResponseStatus *int      `json:"response_status"`
+	Error          string    `json:"error"`
+	DurationMs     int64     `json:"duration_ms"`
+	AttemptedAt    time.Time `json:"attempted_at"`
+}
+
+type WebhookDeliveryAttemptDTO struct {
+	ResponseStatus *int      `json:"response_status" example:"503"`
+	Error          string    `json:"error" example:"unexpected status 503"`
+	DurationMs     int64     `json:"duration_ms" example:"142"`
+	AttemptedAt    time.Time `json:"attempted_at" example:"2025-01-01T00:00:00Z"`
+}
+
+type WebhookDeliveryFilter struct {
+	Status   string `form:"status" binding:"omitempty,oneof=pending succeeded failed" example:"failed"`
+	Page     int    `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=200" example:"50"`
+}
+
+type WebhookEndpoints []*WebhookEndpoint
+type WebhookDeliveries []*WebhookDelivery
+type WebhookDeliveryAttempts []*WebhookDeliveryAttempt
+
+func (WebhookEndpoint) TableName() string {
+	return "webhook_endpoints"
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+func (WebhookDeliveryAttempt) TableName() string {
+	return "webhook_delivery_attempts"
+}
+
+func (endpoint *WebhookEndpoint) Subscribes(eventType string) bool {
+	return endpoint.Active && slices.Contains(endpoint.Events, eventType)
+}
+
+// Apply copies the form onto the endpoint, removing duplicate events.
+func (form *WebhookEndpointForm) Apply(endpoint *WebhookEndpoint) {
+	endpoint.URL = form.URL
+	endpoint.Description = form.Description
+	endpoint.Events = nil
+	for _, event := range form.Events {
+		if !slices.Contains(endpoint.Events, event) {
+			endpoint.Events = append(endpoint.Events, event)
+		}
+	}
+	endpoint.Active = form.Active == nil || *form.Active
+}
+
+func (endpoint *WebhookEndpoint) ToDTO() *WebhookEndpointDTO {
+	return &WebhookEndpointDTO{
+		ID:          endpoint.ID,
+		URL:         endpoint.URL,
+		Description: endpoint.Description,
+		Events:      endpoint.Events,
+		Active:      endpoint.Active,
+		CreatedAt:   endpoint.CreatedAt,
+		UpdatedAt:   endpoint.UpdatedAt,
+	}
+}
+
+func (endpoints WebhookEndpoints) ToDTOs() []*WebhookEndpointDTO {
+	dtos := make([]*WebhookEndpointDTO, len(endpoints))
+	for i, endpoint := range endpoints {
+		dtos[i] = endpoint.ToDTO()
+	}
+	return dtos
+}
+
+func (delivery *WebhookDelivery) ToDTO() *WebhookDeliveryDTO {
+	attemptLog := make([]*WebhookDeliveryAttemptDTO, len(delivery.AttemptLog))
+	for i, attempt := range delivery.AttemptLog {
+		attemptLog[i] = &WebhookDeliveryAttemptDTO{
+			ResponseStatus: attempt.ResponseStatus,
+			Error:          attempt.Error,
+			DurationMs:     attempt.DurationMs,
+			AttemptedAt:    attempt.AttemptedAt,
+		}
+	}
+
+	return &WebhookDeliveryDTO{
+		ID:                 delivery.ID,
+		EventID:            delivery.EventID,
+		EventType:          delivery.EventType,
+		Payload:            delivery.Payload,
+		Status:             delivery.Status,
+		Attempts:           delivery.Attempts,
+		NextAttemptAt:      delivery.NextAttemptAt,
+		LastResponseStatus: delivery.LastResponseStatus,
+		LastError:          delivery.LastError,
+		RedeliveryOfID:     delivery.RedeliveryOfID,
+		AttemptLog:         attemptLog,
+		CreatedAt:          delivery.CreatedAt,
+	}
+}
+
+func (deliveries WebhookDeliveries) ToDTOs() []*WebhookDeliveryDTO {
+	dtos := make([]*WebhookDeliveryDTO, len(deliveries))
+	for i, delivery := range deliveries {
+		dtos[i] = delivery.ToDTO()
+	}
+	return dtos
+}
+
+// RecordAttempt records an attempt, retrying a failed one at nextAttemptAt or, when nil, failing the delivery.
+func (delivery *WebhookDelivery) RecordAttempt(attempt *WebhookDeliveryAttempt, nextAttemptAt *time.Time) {
+	attempt.DeliveryID = delivery.ID
+	attempt.Error = truncateString(attempt.Error, webhookErrorMaxLength)
+
+	delivery.Attempts++
+	delivery.LastResponseStatus = attempt.ResponseStatus
+	delivery.LastError = attempt.Error
+	delivery.NextAttemptAt = nil
+	switch {
+	case attempt.Error == "":
+		delivery.Status = WebhookDeliveryStatusSucceeded
+	case nextAttemptAt != nil:
+		delivery.Status = WebhookDeliveryStatusPending
+		delivery.NextAttemptAt = nextAttemptAt
+	default:
+		delivery.Status = WebhookDeliveryStatusFailed
+	}
+}
+
+func (filter *WebhookDeliveryFilter) Normalize() {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = webhookDeliveryDefaultPageSize
+	}
+	if filter.PageSize > webhookDeliveryMaximumPageSize {
+		filter.PageSize = webhookDeliveryMaximumPageSize
+	}
+}
+
+func (endpoint *WebhookEndpoint) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddUint("id", endpoint.ID)
+	enc.AddUint("organization_id", endpoint.OrganizationID)
+	enc.AddString("url", endpoint.URL)
+	enc.AddBool("active", endpoint.Active)
+	return nil
+}
+
+func (form *WebhookEndpointForm) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("url", form.URL)
+	enc.AddString("events", strings.Join(form.Events, ","))
+	return nil
+}
+
+func (delivery *WebhookDelivery) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddUint64("id", delivery.ID)
+	enc.AddUint("endpoint_id", delivery.EndpointID)
+	enc.AddString("event_id", delivery.EventID)
+	enc.AddString("event_type", delivery.EventType)
+	enc.AddString("status", delivery.Status)
+	enc.AddInt("attempts", delivery.Attempts)
+	return nil
+}
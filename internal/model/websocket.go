@@ -0,0 +1,27 @@
+package model
+
+// Types of the JSON messages exchanged over the WebSocket API. Clients send subscribe, unsubscribe and ping; the
+// server answers with subscribed, unsubscribed and pong, and pushes change and error messages.
+const (
+	WebSocketMessageSubscribe    = "subscribe"
+	WebSocketMessageUnsubscribe  = "unsubscribe"
+	WebSocketMessagePing         = "ping"
+	WebSocketMessageSubscribed   = "subscribed"
+	WebSocketMessageUnsubscribed = "unsubscribed"
+	WebSocketMessagePong         = "pong"
+	WebSocketMessageChange       = "change"
+	WebSocketMessageError        = "error"
+)
+
+type WebSocketClientMessage struct {
+	Type      string `json:"type" example:"subscribe"`
+	SimpleIDs []uint `json:"simple_ids,omitempty" example:"1,2"`
+}
+
+type WebSocketServerMessage struct {
+	Type      string           `json:"type" example:"change"`
+	EventID   uint64           `json:"event_id,omitempty" example:"42"`
+	SimpleIDs []uint           `json:"simple_ids,omitempty" example:"1,2"`
+	Change    *SimpleChangeDTO `json:"change,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
@@ -0,0 +1,66 @@
+package password
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcrypt only uses the first 72 bytes of a password, so longer passwords are reduced to a base64 encoded
+// SHA-256 digest first. Passwords of 72 bytes or less are hashed as-is to stay compatible with existing hashes.
+const bcryptMaxPasswordLength = 72
+
+type bcryptHasher struct {
+	cost int
+}
+
+var _ PasswordHasher = &bcryptHasher{}
+
+func NewBcryptHasher(cost int) PasswordHasher {
+	return &bcryptHasher{cost: cost}
+}
+
+func (h *bcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword(bcryptInput(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h *bcryptHasher) Verify(password string, encodedHash string) error {
+	return verify(password, encodedHash)
+}
+
+func (h *bcryptHasher) NeedsRehash(encodedHash string) bool {
+	if !isBcryptHash(encodedHash) {
+		return true
+	}
+
+	cost, err := bcrypt.Cost([]byte(encodedHash))
+	return err != nil || cost != h.cost
+}
+
+func verifyBcrypt(password string, encodedHash string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(encodedHash), bcryptInput(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrPasswordMismatch
+	}
+	return err
+}
+
+func bcryptInput(password string) []byte {
+	if len(password) <= bcryptMaxPasswordLength {
+		return []byte(password)
+	}
+
+	digest := sha256.Sum256([]byte(password))
+	return []byte(base64.StdEncoding.EncodeToString(digest[:]))
+}
+
+func isBcryptHash(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$2a$") || strings.HasPrefix(encodedHash, "$2b$") || strings.HasPrefix(encodedHash, "$2y$")
+}
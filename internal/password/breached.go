@@ -0,0 +1,82 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Length of the SHA-1 hex prefix used to bucket the corpus, matching the Have I Been Pwned range API.
+const breachedHashPrefixLength = 5
+
+type BreachedPasswordChecker interface {
+	IsBreached(password string) bool
+}
+
+// breachedPasswordCorpus holds SHA-1 hashes of breached passwords, bucketed by hash prefix in the same way as
+// the k-anonymity range API, so the corpus can be built from its downloads.
+type breachedPasswordCorpus struct {
+	suffixesByPrefix map[string]map[string]struct{}
+}
+
+var _ BreachedPasswordChecker = &breachedPasswordCorpus{}
+
+// LoadBreachedPasswordCorpus reads a corpus file with one upper or lower case SHA-1 hex hash per line,
+// optionally followed by ":<count>" as in the Have I Been Pwned downloads. Blank lines and lines starting with
+// "#" are ignored.
+func LoadBreachedPasswordCorpus(path string) (BreachedPasswordChecker, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return NewBreachedPasswordCorpus(file)
+}
+
+func NewBreachedPasswordCorpus(reader io.Reader) (BreachedPasswordChecker, error) {
+	corpus := &breachedPasswordCorpus{suffixesByPrefix: make(map[string]map[string]struct{})}
+
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		hash, _, _ := strings.Cut(line, ":")
+		hash = strings.ToUpper(hash)
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("invalid SHA-1 hash on line %d", lineNumber)
+		}
+
+		prefix, suffix := hash[:breachedHashPrefixLength], hash[breachedHashPrefixLength:]
+		if corpus.suffixesByPrefix[prefix] == nil {
+			corpus.suffixesByPrefix[prefix] = make(map[string]struct{})
+		}
+		corpus.suffixesByPrefix[prefix][suffix] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return corpus, nil
+}
+
+func (c *breachedPasswordCorpus) IsBreached(password string) bool {
+	digest := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(digest[:]))
+
+	suffixes, ok := c.suffixesByPrefix[hash[:breachedHashPrefixLength]]
+	if !ok {
+		return false
+	}
+	_, breached := suffixes[hash[breachedHashPrefixLength:]]
+	return breached
+}
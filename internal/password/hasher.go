@@ -0,0 +1,43 @@
+package password
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+var (
+	ErrPasswordMismatch = errors.New("password does not match hash")
+	ErrUnsupportedHash  = errors.New("unsupported password hash format")
+)
+
+// PasswordHasher hashes passwords into PHC style strings for storage in users.password_hash. Every
+// implementation verifies hashes of all supported algorithms, so the configured algorithm can be changed and
+// existing hashes upgraded on the next successful login.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password string, encodedHash string) error
+	NeedsRehash(encodedHash string) bool
+}
+
+func NewHasher(algorithm string, bcryptCost int, argon2idParams Argon2idParams) PasswordHasher {
+	if algorithm == AlgorithmArgon2id {
+		return NewArgon2idHasher(argon2idParams)
+	}
+	return NewBcryptHasher(bcryptCost)
+}
+
+func verify(password string, encodedHash string) error {
+	switch {
+	case strings.HasPrefix(encodedHash, "$"+AlgorithmArgon2id+"$"):
+		return verifyArgon2id(password, encodedHash)
+	case isBcryptHash(encodedHash):
+		return verifyBcrypt(password, encodedHash)
+	default:
+		return ErrUnsupportedHash
+	}
+}
@@ -0,0 +1,137 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Policy rule names, used as the keys of the violations returned by Policy.Check.
+const (
+	RuleMinLength     = "min_length"
+	RuleMaxLength     = "max_length"
+	RuleUppercase     = "uppercase"
+	RuleLowercase     = "lowercase"
+	RuleDigit         = "digit"
+	RuleSymbol        = "symbol"
+	RuleContainsEmail = "contains_email"
+	RuleReused        = "reused"
+	RuleBreached      = "breached"
+)
+
+// Local parts shorter than this are too common to be worth rejecting.
+const minEmailLocalPartLength = 3
+
+type PolicyConfig struct {
+	MinLength        int
+	MaxLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	ForbidEmail      bool
+	HistorySize      int
+}
+
+// Policy checks new passwords against the configured rules. Check returns a map of rule name to message for
+// every rule the password breaks, or nil when it satisfies them all.
+type Policy interface {
+	Check(password string, email string, previousHashes []string) map[string]string
+	HistorySize() int
+}
+
+type policy struct {
+	config            PolicyConfig
+	passwordHasher    PasswordHasher
+	breachedPasswords BreachedPasswordChecker
+}
+
+var _ Policy = &policy{}
+
+// NewPolicy creates a Policy. breachedPasswords may be nil to skip the breached password check.
+func NewPolicy(config PolicyConfig, passwordHasher PasswordHasher, breachedPasswords BreachedPasswordChecker) Policy {
+	return &policy{config: config, passwordHasher: passwordHasher, breachedPasswords: breachedPasswords}
+}
+
+func (p *policy) HistorySize() int {
+	return p.config.HistorySize
+}
+
+func (p *policy) Check(password string, email string, previousHashes []string) map[string]string {
+	violations := make(map[string]string)
+
+	length := utf8.RuneCountInString(password)
+	if p.config.MinLength > 0 && length < p.config.MinLength {
+		violations[RuleMinLength] = fmt.Sprintf("Password must be at least %d characters long", p.config.MinLength)
+	}
+	if p.config.MaxLength > 0 && length > p.config.MaxLength {
+		violations[RuleMaxLength] = fmt.Sprintf("Password must be at most %d characters long", p.config.MaxLength)
+	}
+
+	p.checkCharacterClasses(password, violations)
+
+	if p.config.ForbidEmail && containsEmail(password, email) {
+		violations[RuleContainsEmail] = "Password must not contain your email address"
+	}
+
+	for _, previousHash := range previousHashes {
+		if p.passwordHasher.Verify(password, previousHash) == nil {
+			violations[RuleReused] = fmt.Sprintf("Password must not match any of your last %d passwords", p.config.HistorySize)
+			break
+		}
+	}
+
+	if p.breachedPasswords != nil && p.breachedPasswords.IsBreached(password) {
+		violations[RuleBreached] = "Password has appeared in a known data breach"
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return violations
+}
+
+func (p *policy) checkCharacterClasses(password string, violations map[string]string) {
+	var hasUppercase, hasLowercase, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUppercase = true
+		case unicode.IsLower(r):
+			hasLowercase = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsLetter(r) && !unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.config.RequireUppercase && !hasUppercase {
+		violations[RuleUppercase] = "Password must contain an uppercase letter"
+	}
+	if p.config.RequireLowercase && !hasLowercase {
+		violations[RuleLowercase] = "Password must contain a lowercase letter"
+	}
+	if p.config.RequireDigit && !hasDigit {
+		violations[RuleDigit] = "Password must contain a digit"
+	}
+	if p.config.RequireSymbol && !hasSymbol {
+		violations[RuleSymbol] = "Password must contain a symbol"
+	}
+}
+
+func containsEmail(password string, email string) bool {
+	if email == "" {
+		return false
+	}
+
+	password = strings.ToLower(password)
+	email = strings.ToLower(email)
+	if strings.Contains(password, email) {
+		return true
+	}
+
+	localPart, _, _ := strings.Cut(email, "@")
+	return utf8.RuneCountInString(localPart) >= minEmailLocalPartLength && strings.Contains(password, localPart)
+}
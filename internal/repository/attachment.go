@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"gorm.io/gorm"
+)
+
+// AttachmentRepository stores the metadata of files attached to Simples. Callers are expected to have loaded the
+// Simple through the organization scoped SimpleRepository first.
+type AttachmentRepository interface {
+	Create(ctx context.Context, attachment *model.Attachment) (*model.Attachment, error)
+	ListBySimpleID(ctx context.Context, simpleID uint) (model.Attachments, error)
+	GetByID(ctx context.Context, simpleID uint, id uint) (*model.Attachment, error)
+	Delete(ctx context.Context, id uint) error
+	CountByHash(ctx context.Context, organizationID uint, sha256 string) (int64, error)
+	LockContent(ctx context.Context, organizationID uint, sha256 string) error
+}
+
+type attachmentRepository struct {
+	DB *gorm.DB
+}
+
+var _ AttachmentRepository = &attachmentRepository{}
+
+func NewAttachmentRepository(db *gorm.DB) AttachmentRepository {
+	return &attachmentRepository{DB: db}
+}
+
+func (r attachmentRepository) Create(ctx context.Context, attachment *model.Attachment) (*model.Attachment, error) {
+	if err := conn(ctx, r.DB).Create(&attachment).Error; err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func (r attachmentRepository) ListBySimpleID(ctx context.Context, simpleID uint) (model.Attachments, error) {
+	var attachments model.Attachments
+	err := conn(ctx, r.DB).Where("simple_id = ?", simpleID).
+		Order("created_at ASC, id ASC").
+		Find(&attachments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+func (r attachmentRepository) GetByID(ctx context.Context, simpleID uint, id uint) (*model.Attachment, error) {
+	var attachment model.Attachment
+	if err := conn(ctx, r.DB).Where("simple_id = ?", simpleID).First(&attachment, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+func (r attachmentRepository) Delete(ctx context.Context, id uint) error {
+	result := conn(ctx, r.DB).Delete(&model.Attachment{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Counts the organization's attachments with the given content, which share a blob.
+func (r attachmentRepository) CountByHash(ctx context.Context, organizationID uint, sha256 string) (int64, error) {
+	var count int64
+	err := conn(ctx, r.DB).Model(&model.Attachment{}).
+		Where("organization_id = ? AND sha256 = ?", organizationID, sha256).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Locks the organization's content with the given hash until the unit of work it is called in ends.
+func (r attachmentRepository) LockContent(ctx context.Context, organizationID uint, sha256 string) error {
+	return conn(ctx, r.DB).Exec("SELECT pg_advisory_xact_lock(?, hashtext(?))", organizationID, sha256).Error
+}
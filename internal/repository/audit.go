@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"gorm.io/gorm"
+)
+
+// Advisory lock key serialising appends to the audit log, so every event is chained to the one before it.
+const auditChainLockKey = 0x61756469746c6f67 // "auditlog"
+
+type AuditRepository interface {
+	Append(ctx context.Context, event *model.AuditEvent) (*model.AuditEvent, error)
+	List(ctx context.Context, filter model.AuditEventFilter) (model.AuditEvents, int64, error)
+	ListAfter(ctx context.Context, afterID uint64, limit int) (model.AuditEvents, error)
+}
+
+type auditRepository struct {
+	DB *gorm.DB
+}
+
+var _ AuditRepository = &auditRepository{}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{DB: db}
+}
+
+// Seals the event against the latest event in the chain and inserts it. The table is append-only, so this is
+// the only way events are written.
+func (r auditRepository) Append(ctx context.Context, event *model.AuditEvent) (*model.AuditEvent, error) {
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLockKey).Error; err != nil {
+			return err
+		}
+
+		var hashes []string
+		if err := tx.Model(&model.AuditEvent{}).Order("id DESC").Limit(1).Pluck("hash", &hashes).Error; err != nil {
+			return err
+		}
+
+		prevHash := model.AuditChainGenesisHash
+		if len(hashes) > 0 {
+			prevHash = hashes[0]
+		}
+
+		event.Seal(prevHash)
+		return tx.Create(event).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func (r auditRepository) List(ctx context.Context, filter model.AuditEventFilter) (model.AuditEvents, int64, error) {
+	query := conn(ctx, r.DB).Model(&model.AuditEvent{})
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events model.AuditEvents
+	if err := query.Order("id DESC").Offset((filter.Page - 1) * filter.PageSize).Limit(filter.PageSize).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// Returns up to limit events with an ID greater than afterID in chain order, for walking the whole log in batches.
+func (r auditRepository) ListAfter(ctx context.Context, afterID uint64, limit int) (model.AuditEvents, error) {
+	var events model.AuditEvents
+	if err := conn(ctx, r.DB).Where("id > ?", afterID).Order("id").Limit(limit).Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
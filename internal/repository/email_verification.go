@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EmailVerificationRepository interface {
+	Create(ctx context.Context, verification *model.EmailVerification) (*model.EmailVerification, error)
+	Consume(ctx context.Context, tokenHash string) (*model.EmailVerification, error)
+	// DeleteExpired deletes the tokens that expired before the given time, used or not, and returns how many.
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type emailVerificationRepository struct {
+	DB *gorm.DB
+}
+
+var _ EmailVerificationRepository = &emailVerificationRepository{}
+
+func NewEmailVerificationRepository(db *gorm.DB) EmailVerificationRepository {
+	return &emailVerificationRepository{DB: db}
+}
+
+func (r emailVerificationRepository) Create(ctx context.Context, verification *model.EmailVerification) (*model.EmailVerification, error) {
+	if err := conn(ctx, r.DB).Create(&verification).Error; err != nil {
+		return nil, err
+	}
+
+	return verification, nil
+}
+
+// Marks an unused, unexpired verification as used and returns it. The check and update happen in a single
+// statement so concurrent requests cannot consume the same token twice.
+func (r emailVerificationRepository) Consume(ctx context.Context, tokenHash string) (*model.EmailVerification, error) {
+	verification := &model.EmailVerification{}
+	now := time.Now()
+	result := conn(ctx, r.DB).Model(verification).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return verification, nil
+}
+
+func (r emailVerificationRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := conn(ctx, r.DB).Where("expires_at < ?", before).Delete(&model.EmailVerification{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
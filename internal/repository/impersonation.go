@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"gorm.io/gorm"
+)
+
+type ImpersonationRepository interface {
+	Create(ctx context.Context, event *model.ImpersonationEvent) (*model.ImpersonationEvent, error)
+}
+
+type impersonationRepository struct {
+	DB *gorm.DB
+}
+
+var _ ImpersonationRepository = &impersonationRepository{}
+
+func NewImpersonationRepository(db *gorm.DB) ImpersonationRepository {
+	return &impersonationRepository{DB: db}
+}
+
+func (r impersonationRepository) Create(ctx context.Context, event *model.ImpersonationEvent) (*model.ImpersonationEvent, error) {
+	if err := conn(ctx, r.DB).Create(&event).Error; err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
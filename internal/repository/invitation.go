@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"gorm.io/gorm"
+)
+
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation *model.Invitation) (*model.Invitation, error)
+	GetByID(ctx context.Context, organizationID uint, id uint) (*model.Invitation, error)
+	GetPendingByTokenHash(ctx context.Context, tokenHash string) (*model.Invitation, error)
+	GetPendingByEmail(ctx context.Context, organizationID uint, email string) (*model.Invitation, error)
+	ListPendingByOrganizationID(ctx context.Context, organizationID uint) (model.Invitations, error)
+	Reissue(ctx context.Context, invitation *model.Invitation) error
+	Respond(ctx context.Context, invitation *model.Invitation, status string) error
+	Accept(ctx context.Context, invitation *model.Invitation, userID uint) (*model.Membership, error)
+}
+
+type invitationRepository struct {
+	DB *gorm.DB
+}
+
+var _ InvitationRepository = &invitationRepository{}
+
+func NewInvitationRepository(db *gorm.DB) InvitationRepository {
+	return &invitationRepository{DB: db}
+}
+
+func (r invitationRepository) Create(ctx context.Context, invitation *model.Invitation) (*model.Invitation, error) {
+	if err := conn(ctx, r.DB).Create(&invitation).Error; err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (r invitationRepository) GetByID(ctx context.Context, organizationID uint, id uint) (*model.Invitation, error) {
+	invitation := &model.Invitation{}
+	if err := conn(ctx, r.DB).First(&invitation, "id = ? AND organization_id = ?", id, organizationID).Error; err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+// Returns the pending invitation issued with the token, with its organization loaded. Expired invitations are
+// returned too, so callers must check IsOpen.
+func (r invitationRepository) GetPendingByTokenHash(ctx context.Context, tokenHash string) (*model.Invitation, error) {
+	invitation := &model.Invitation{}
+	err := conn(ctx, r.DB).Preload("Organization").
+		First(&invitation, "token_hash = ? AND status = ?", tokenHash, model.InvitationStatusPending).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (r invitationRepository) GetPendingByEmail(ctx context.Context, organizationID uint, email string) (*model.Invitation, error) {
+	invitation := &model.Invitation{}
+	err := conn(ctx, r.DB).First(&invitation, "organization_id = ? AND LOWER(email) = LOWER(?) AND status = ?", organizationID, email, model.InvitationStatusPending).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (r invitationRepository) ListPendingByOrganizationID(ctx context.Context, organizationID uint) (model.Invitations, error) {
+	var invitations model.Invitations
+	err := conn(ctx, r.DB).Where("organization_id = ? AND status = ?", organizationID, model.InvitationStatusPending).
+		Order("created_at DESC").
+		Find(&invitations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+// Stores the new token hash and expiry of a pending invitation, which invalidates the token issued before.
+func (r invitationRepository) Reissue(ctx context.Context, invitation *model.Invitation) error {
+	result := conn(ctx, r.DB).Model(invitation).
+		Where("status = ?", model.InvitationStatusPending).
+		Updates(map[string]any{"token_hash": invitation.TokenHash, "expires_at": invitation.ExpiresAt, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Moves a pending invitation to the given status. The invitation must still carry the token hash it was loaded
+// with, so an invitation cannot be answered with a token that was replaced in the meantime.
+func (r invitationRepository) Respond(ctx context.Context, invitation *model.Invitation, status string) error {
+	if err := respondToInvitation(conn(ctx, r.DB), invitation, status); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Accepts the invitation and adds the user to the organization in a single transaction, so an invitation is
+// never accepted without the membership it grants.
+func (r invitationRepository) Accept(ctx context.Context, invitation *model.Invitation, userID uint) (*model.Membership, error) {
+	membership := &model.Membership{OrganizationID: invitation.OrganizationID, UserID: userID, Role: invitation.Role}
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := respondToInvitation(tx, invitation, model.InvitationStatusAccepted); err != nil {
+			return err
+		}
+		return tx.Create(membership).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+func respondToInvitation(db *gorm.DB, invitation *model.Invitation, status string) error {
+	now := time.Now()
+	result := db.Model(&model.Invitation{}).
+		Where("id = ? AND token_hash = ? AND status = ?", invitation.ID, invitation.TokenHash, model.InvitationStatusPending).
+		Updates(map[string]any{"status": status, "responded_at": now, "updated_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	invitation.Status = status
+	invitation.RespondedAt = &now
+	return nil
+}
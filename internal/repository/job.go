@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"gorm.io/gorm"
+)
+
+// JobRepository stores the background job queue, shared by the workers of every instance.
+type JobRepository interface {
+	Create(ctx context.Context, job *model.Job) error
+	// ClaimDue leases up to limit due jobs of the given types to the caller, counting an attempt for each.
+	ClaimDue(ctx context.Context, types []string, limit int, lease time.Duration) (model.Jobs, error)
+	// Complete deletes a job that succeeded.
+	Complete(ctx context.Context, job *model.Job) error
+	// SaveFailure saves a failed attempt of the job, to be retried or left dead, clearing the payload of a dead job.
+	SaveFailure(ctx context.Context, job *model.Job) error
+}
+
+type jobRepository struct {
+	DB *gorm.DB
+}
+
+var _ JobRepository = &jobRepository{}
+
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepository{DB: db}
+}
+
+func (r jobRepository) Create(ctx context.Context, job *model.Job) error {
+	if err := conn(ctx, r.DB).Create(job).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Leases up to limit due jobs of the types, counting the attempt, and skips the ones another instance is claiming.
+func (r jobRepository) ClaimDue(ctx context.Context, types []string, limit int, lease time.Duration) (model.Jobs, error) {
+	var jobs model.Jobs
+	if len(types) == 0 || limit < 1 {
+		return jobs, nil
+	}
+
+	err := conn(ctx, r.DB).Raw(`
+		UPDATE jobs SET status = ?, attempts = attempts + 1, locked_until = NOW() + make_interval(secs => ?), updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM jobs
+			WHERE type IN ? AND (
+				(status = ? AND run_at <= NOW())
+				OR (status = ? AND locked_until <= NOW()))
+			ORDER BY run_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, model.JobStatusRunning, lease.Seconds(), types, model.JobStatusPending, model.JobStatusRunning, limit).
+		Scan(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+func (r jobRepository) Complete(ctx context.Context, job *model.Job) error {
+	if err := conn(ctx, r.DB).Delete(job).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r jobRepository) SaveFailure(ctx context.Context, job *model.Job) error {
+	err := conn(ctx, r.DB).Model(job).
+		Select("payload", "status", "run_at", "locked_until", "last_error", "updated_at").
+		Updates(job).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
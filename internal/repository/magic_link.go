@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MagicLinkRepository interface {
+	Create(ctx context.Context, token *model.MagicLinkToken) (*model.MagicLinkToken, error)
+	Consume(ctx context.Context, tokenHash string) (*model.MagicLinkToken, error)
+	// DeleteExpired deletes the tokens that expired before the given time, used or not, and returns how many.
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type magicLinkRepository struct {
+	DB *gorm.DB
+}
+
+var _ MagicLinkRepository = &magicLinkRepository{}
+
+func NewMagicLinkRepository(db *gorm.DB) MagicLinkRepository {
+	return &magicLinkRepository{DB: db}
+}
+
+func (r magicLinkRepository) Create(ctx context.Context, token *model.MagicLinkToken) (*model.MagicLinkToken, error) {
+	if err := conn(ctx, r.DB).Create(&token).Error; err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// Marks an unused, unexpired token as used and returns it, in one statement so it is consumed only once.
+func (r magicLinkRepository) Consume(ctx context.Context, tokenHash string) (*model.MagicLinkToken, error) {
+	token := &model.MagicLinkToken{}
+	now := time.Now()
+	result := conn(ctx, r.DB).Model(token).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return token, nil
+}
+
+func (r magicLinkRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := conn(ctx, r.DB).Where("expires_at < ?", before).Delete(&model.MagicLinkToken{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MembershipRepository interface {
+	Create(ctx context.Context, membership *model.Membership) (*model.Membership, error)
+	Get(ctx context.Context, organizationID uint, userID uint) (*model.Membership, error)
+	ListByUserID(ctx context.Context, userID uint) (model.Memberships, error)
+	ListByOrganizationID(ctx context.Context, organizationID uint) (model.Memberships, error)
+	LockOwners(ctx context.Context, organizationID uint) (int64, error)
+	Delete(ctx context.Context, membership *model.Membership) error
+}
+
+type membershipRepository struct {
+	DB *gorm.DB
+}
+
+var _ MembershipRepository = &membershipRepository{}
+
+func NewMembershipRepository(db *gorm.DB) MembershipRepository {
+	return &membershipRepository{DB: db}
+}
+
+func (r membershipRepository) Create(ctx context.Context, membership *model.Membership) (*model.Membership, error) {
+	if err := conn(ctx, r.DB).Create(&membership).Error; err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+func (r membershipRepository) Get(ctx context.Context, organizationID uint, userID uint) (*model.Membership, error) {
+	membership := &model.Membership{}
+	if err := conn(ctx, r.DB).First(&membership, "organization_id = ? AND user_id = ?", organizationID, userID).Error; err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+// Lists the user's memberships with their organizations loaded.
+func (r membershipRepository) ListByUserID(ctx context.Context, userID uint) (model.Memberships, error) {
+	var memberships model.Memberships
+	if err := conn(ctx, r.DB).Preload("Organization").Where("user_id = ?", userID).Order("organization_id").Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+
+	return memberships, nil
+}
+
+// Lists the organization's memberships with their users loaded.
+func (r membershipRepository) ListByOrganizationID(ctx context.Context, organizationID uint) (model.Memberships, error) {
+	var memberships model.Memberships
+	if err := conn(ctx, r.DB).Preload("User").Where("organization_id = ?", organizationID).Order("id").Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+
+	return memberships, nil
+}
+
+// Counts the organization's owners, locking their memberships until the transaction ends so concurrent removals
+// cannot both see another owner left.
+func (r membershipRepository) LockOwners(ctx context.Context, organizationID uint) (int64, error) {
+	var userIDs []uint
+	err := conn(ctx, r.DB).Model(&model.Membership{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("organization_id = ? AND role = ?", organizationID, model.OrganizationRoleOwner).
+		Pluck("user_id", &userIDs).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(userIDs)), nil
+}
+
+func (r membershipRepository) Delete(ctx context.Context, membership *model.Membership) error {
+	if err := conn(ctx, r.DB).Delete(membership).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MetadataSchemaRepository interface {
+	Upsert(ctx context.Context, schema *model.MetadataSchema) (*model.MetadataSchema, error)
+	ListByOrganizationID(ctx context.Context, organizationID uint) (model.MetadataSchemas, error)
+	ListForKind(ctx context.Context, organizationID uint, kind string) (model.MetadataSchemas, error)
+	Delete(ctx context.Context, organizationID uint, id uint) error
+}
+
+type metadataSchemaRepository struct {
+	DB *gorm.DB
+}
+
+var _ MetadataSchemaRepository = &metadataSchemaRepository{}
+
+func NewMetadataSchemaRepository(db *gorm.DB) MetadataSchemaRepository {
+	return &metadataSchemaRepository{DB: db}
+}
+
+// Registers the schema for its organization and kind, replacing the one registered before.
+func (r metadataSchemaRepository) Upsert(ctx context.Context, schema *model.MetadataSchema) (*model.MetadataSchema, error) {
+	err := conn(ctx, r.DB).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "organization_id"}, {Name: "kind"}},
+		DoUpdates: clause.AssignmentColumns([]string{"schema", "created_by_id", "updated_at"}),
+	}).Create(&schema).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return schema, nil
+}
+
+func (r metadataSchemaRepository) ListByOrganizationID(ctx context.Context, organizationID uint) (model.MetadataSchemas, error) {
+	var schemas model.MetadataSchemas
+	if err := conn(ctx, r.DB).Where("organization_id = ?", organizationID).Order("kind ASC").Find(&schemas).Error; err != nil {
+		return nil, err
+	}
+
+	return schemas, nil
+}
+
+// Returns the schemas that apply to Simples of the kind: the organization wide schema and the kind's own.
+func (r metadataSchemaRepository) ListForKind(ctx context.Context, organizationID uint, kind string) (model.MetadataSchemas, error) {
+	var schemas model.MetadataSchemas
+	err := conn(ctx, r.DB).Where("organization_id = ? AND kind IN ?", organizationID, []string{"", kind}).
+		Order("kind ASC").
+		Find(&schemas).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return schemas, nil
+}
+
+func (r metadataSchemaRepository) Delete(ctx context.Context, organizationID uint, id uint) error {
+	result := conn(ctx, r.DB).Where("id = ? AND organization_id = ?", id, organizationID).Delete(&model.MetadataSchema{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
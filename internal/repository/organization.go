@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"gorm.io/gorm"
+)
+
+type OrganizationRepository interface {
+	Create(ctx context.Context, organization *model.Organization, ownerID uint) (*model.Organization, error)
+	GetByID(ctx context.Context, id uint) (*model.Organization, error)
+}
+
+type organizationRepository struct {
+	DB *gorm.DB
+}
+
+var _ OrganizationRepository = &organizationRepository{}
+
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepository{DB: db}
+}
+
+// Creates the organization with ownerID as its owner in one transaction.
+func (r organizationRepository) Create(ctx context.Context, organization *model.Organization, ownerID uint) (*model.Organization, error) {
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(organization).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.Membership{OrganizationID: organization.ID, UserID: ownerID, Role: model.OrganizationRoleOwner}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return organization, nil
+}
+
+func (r organizationRepository) GetByID(ctx context.Context, id uint) (*model.Organization, error) {
+	organization := &model.Organization{}
+	if err := conn(ctx, r.DB).First(&organization, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+
+	return organization, nil
+}
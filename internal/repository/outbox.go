@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"gorm.io/gorm"
+)
+
+// Key of the transaction level advisory lock that lets a single relay publish at a time, so events of an aggregate
+// are never published by two relays out of order.
+const outboxRelayLockKey int64 = 0x6f7574626f78
+
+// Selects the due events that no earlier event of the same aggregate is still waiting to be retried behind.
+const outboxDueCondition = `published_at IS NULL AND next_attempt_at <= NOW() AND NOT EXISTS (
+	SELECT 1 FROM outbox_events earlier
+	WHERE earlier.published_at IS NULL
+		AND earlier.aggregate_type = outbox_events.aggregate_type
+		AND earlier.aggregate_id = outbox_events.aggregate_id
+		AND earlier.id < outbox_events.id
+		AND earlier.next_attempt_at > NOW())`
+
+// OutboxRepository hands pending outbox events to the relay. Events are written by the repositories of the
+// aggregates they describe, in the same transaction as the change.
+type OutboxRepository interface {
+	// Relay passes up to limit due events, oldest first, to publish and saves the outcome of the events it returns.
+	// It returns the number of events saved, which is zero when another relay holds the lock.
+	Relay(ctx context.Context, limit int, publish func(events model.OutboxEvents) model.OutboxEvents) (int, error)
+}
+
+type outboxRepository struct {
+	DB *gorm.DB
+}
+
+var _ OutboxRepository = &outboxRepository{}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{DB: db}
+}
+
+// The lock and the loaded events are held until the outcome is saved, so a relay that dies while publishing leaves
+// its events to be published again by the next one.
+func (r outboxRepository) Relay(ctx context.Context, limit int, publish func(events model.OutboxEvents) model.OutboxEvents) (int, error) {
+	saved := 0
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", outboxRelayLockKey).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		var events model.OutboxEvents
+		if err := tx.Where(outboxDueCondition).Order("id ASC").Limit(limit).Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		for _, event := range publish(events) {
+			err := tx.Model(event).
+				Select("attempts", "next_attempt_at", "last_error", "published_at").
+				Updates(event).Error
+			if err != nil {
+				return err
+			}
+			saved++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return saved, nil
+}
+
+// Writes an event of the aggregate to the outbox as part of the transaction.
+func appendOutboxEvent(tx *gorm.DB, aggregateType string, aggregateID uint, eventType string, data any) (*model.OutboxEvent, error) {
+	event, err := model.NewOutboxEvent(aggregateType, aggregateID, eventType, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Create(event).Error; err != nil {
+		return nil, err
+	}
+	return event, nil
+}
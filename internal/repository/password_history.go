@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"gorm.io/gorm"
+)
+
+type PasswordHistoryRepository interface {
+	Create(ctx context.Context, entry *model.PasswordHistoryEntry) (*model.PasswordHistoryEntry, error)
+	ListRecent(ctx context.Context, userID uint, limit int) ([]*model.PasswordHistoryEntry, error)
+}
+
+type passwordHistoryRepository struct {
+	DB *gorm.DB
+}
+
+var _ PasswordHistoryRepository = &passwordHistoryRepository{}
+
+func NewPasswordHistoryRepository(db *gorm.DB) PasswordHistoryRepository {
+	return &passwordHistoryRepository{DB: db}
+}
+
+func (r passwordHistoryRepository) Create(ctx context.Context, entry *model.PasswordHistoryEntry) (*model.PasswordHistoryEntry, error) {
+	if err := conn(ctx, r.DB).Create(&entry).Error; err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// Returns the User's most recent previous password hashes, newest first.
+func (r passwordHistoryRepository) ListRecent(ctx context.Context, userID uint, limit int) ([]*model.PasswordHistoryEntry, error) {
+	var entries []*model.PasswordHistoryEntry
+	if err := conn(ctx, r.DB).Where("user_id = ?", userID).Order("created_at DESC, id DESC").Limit(limit).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
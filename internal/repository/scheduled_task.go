@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// Key of the advisory lock held by the scheduler leader, "schedule" in ASCII.
+const schedulerLockKey int64 = 0x7363686564756c65
+
+// ScheduledTaskRepository stores the state and run history of the scheduled tasks.
+type ScheduledTaskRepository interface {
+	// Sync adds the tasks that are not stored yet, and moves the next run of those whose schedule changed.
+	Sync(ctx context.Context, tasks model.ScheduledTasks) error
+	ListAll(ctx context.Context) (model.ScheduledTasks, error)
+	// Start claims the run of the task due at run.ScheduledFor, moving its next run to nextRunAt, and records the run.
+	// It returns false when the run was already claimed.
+	Start(ctx context.Context, run *model.ScheduledTaskRun, nextRunAt time.Time) (bool, error)
+	// Finish records the outcome of a run.
+	Finish(ctx context.Context, run *model.ScheduledTaskRun) error
+	// ListRuns returns the latest runs of a task, newest first.
+	ListRuns(ctx context.Context, name string, limit int) (model.ScheduledTaskRuns, error)
+}
+
+// SchedulerLock elects the instance that runs scheduled tasks, with a Postgres advisory lock. The lock is held by a
+// connection of its own, outside of the pool, and is released by Postgres if the instance dies.
+type SchedulerLock interface {
+	// Acquire takes the lock if no other instance holds it, and reports whether this instance holds it. Once held,
+	// it checks the connection holding the lock is still alive, letting the lock go if not.
+	Acquire(ctx context.Context) (bool, error)
+	// Release lets another instance take the lock.
+	Release(ctx context.Context) error
+}
+
+type scheduledTaskRepository struct {
+	DB *gorm.DB
+}
+
+var _ ScheduledTaskRepository = &scheduledTaskRepository{}
+
+func NewScheduledTaskRepository(db *gorm.DB) ScheduledTaskRepository {
+	return &scheduledTaskRepository{DB: db}
+}
+
+func (r scheduledTaskRepository) Sync(ctx context.Context, tasks model.ScheduledTasks) error {
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		for _, task := range tasks {
+			err := tx.Exec(`
+				INSERT INTO scheduled_tasks (name, schedule, next_run_at) VALUES (?, ?, ?)
+				ON CONFLICT (name) DO UPDATE SET schedule = EXCLUDED.schedule, next_run_at = EXCLUDED.next_run_at, updated_at = NOW()
+				WHERE scheduled_tasks.schedule <> EXCLUDED.schedule`, task.Name, task.Schedule, task.NextRunAt).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r scheduledTaskRepository) ListAll(ctx context.Context) (model.ScheduledTasks, error) {
+	var tasks model.ScheduledTasks
+	if err := conn(ctx, r.DB).Order("name").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// The run is claimed by moving the task's next run on only if it is still the one being started, so a run is started
+// once even if a former leader that lost its lock has not noticed yet.
+func (r scheduledTaskRepository) Start(ctx context.Context, run *model.ScheduledTaskRun, nextRunAt time.Time) (bool, error) {
+	started := false
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.ScheduledTask{}).
+			Where("name = ? AND next_run_at = ?", run.TaskName, run.ScheduledFor).
+			Updates(map[string]any{"next_run_at": nextRunAt, "last_started_at": run.StartedAt, "last_outcome": model.ScheduledTaskOutcomeRunning})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		run.Outcome = model.ScheduledTaskOutcomeRunning
+		if err := tx.Create(run).Error; err != nil {
+			return err
+		}
+		started = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return started, nil
+}
+
+func (r scheduledTaskRepository) Finish(ctx context.Context, run *model.ScheduledTaskRun) error {
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(run).
+			Select("outcome", "error", "duration_ms", "finished_at").
+			Updates(run).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&model.ScheduledTask{}).
+			Where("name = ?", run.TaskName).
+			Updates(map[string]any{
+				"last_finished_at": run.FinishedAt,
+				"last_outcome":     run.Outcome,
+				"last_error":       run.Error,
+				"last_duration_ms": run.DurationMs,
+			}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r scheduledTaskRepository) ListRuns(ctx context.Context, name string, limit int) (model.ScheduledTaskRuns, error) {
+	var runs model.ScheduledTaskRuns
+	if err := conn(ctx, r.DB).Where("task_name = ?", name).Order("id DESC").Limit(limit).Find(&runs).Error; err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+type schedulerLock struct {
+	DSN string
+
+	conn *pgx.Conn
+}
+
+var _ SchedulerLock = &schedulerLock{}
+
+// NewSchedulerLock returns a lock to be used by a single goroutine.
+func NewSchedulerLock(dsn string) SchedulerLock {
+	return &schedulerLock{DSN: dsn}
+}
+
+func (l *schedulerLock) Acquire(ctx context.Context) (bool, error) {
+	if l.conn != nil {
+		if err := l.conn.Ping(ctx); err != nil {
+			l.close(ctx)
+			return false, err
+		}
+		return true, nil
+	}
+
+	conn, err := pgx.Connect(ctx, l.DSN)
+	if err != nil {
+		return false, err
+	}
+
+	var acquired bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", schedulerLockKey).Scan(&acquired); err != nil {
+		conn.Close(context.WithoutCancel(ctx))
+		return false, err
+	}
+	if !acquired {
+		return false, conn.Close(ctx)
+	}
+
+	l.conn = conn
+	return true, nil
+}
+
+func (l *schedulerLock) Release(ctx context.Context) error {
+	if l.conn == nil {
+		return nil
+	}
+	defer l.close(ctx)
+
+	_, err := l.conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", schedulerLockKey)
+	return err
+}
+
+// Closing the connection releases the lock, if Postgres still holds it for the session.
+func (l *schedulerLock) close(ctx context.Context) {
+	l.conn.Close(context.WithoutCancel(ctx))
+	l.conn = nil
+}
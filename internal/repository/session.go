@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *model.Session) (*model.Session, error)
+	GetByID(ctx context.Context, id uint) (*model.Session, error)
+	ListActiveByUserID(ctx context.Context, userID uint) (model.Sessions, error)
+	Revoke(ctx context.Context, id uint) error
+	RevokeAllByUserID(ctx context.Context, userID uint) error
+	Touch(ctx context.Context, id uint, lastSeenAt time.Time) error
+}
+
+type sessionRepository struct {
+	DB *gorm.DB
+}
+
+var _ SessionRepository = &sessionRepository{}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{DB: db}
+}
+
+func (r sessionRepository) Create(ctx context.Context, session *model.Session) (*model.Session, error) {
+	if err := conn(ctx, r.DB).Create(&session).Error; err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (r sessionRepository) GetByID(ctx context.Context, id uint) (*model.Session, error) {
+	session := &model.Session{}
+	if err := conn(ctx, r.DB).First(&session, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// Lists the user's unrevoked, unexpired sessions, most recently used first.
+func (r sessionRepository) ListActiveByUserID(ctx context.Context, userID uint) (model.Sessions, error) {
+	var sessions model.Sessions
+	err := conn(ctx, r.DB).Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r sessionRepository) Revoke(ctx context.Context, id uint) error {
+	err := conn(ctx, r.DB).Model(&model.Session{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Revokes every session of the user that is not already revoked.
+func (r sessionRepository) RevokeAllByUserID(ctx context.Context, userID uint) error {
+	err := conn(ctx, r.DB).Model(&model.Session{}).Where("user_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r sessionRepository) Touch(ctx context.Context, id uint, lastSeenAt time.Time) error {
+	err := conn(ctx, r.DB).Model(&model.Session{}).Where("id = ?", id).Update("last_seen_at", lastSeenAt).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -1,20 +1,34 @@
 package repository
 
 import (
-	"time"
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
 
 	"github.com/Verano-20/stage-zero/internal/model"
 	"github.com/Verano-20/stage-zero/internal/telemetry"
-	"github.com/gin-gonic/gin"
+	"github.com/Verano-20/stage-zero/internal/utils"
 	"gorm.io/gorm"
 )
 
+// Matches Simples owned by, or shared with, the user given twice as the condition's arguments.
+const simpleAccessibleByCondition = "simples.owner_id = ? OR EXISTS (SELECT 1 FROM simple_grants WHERE simple_grants.simple_id = simples.id AND simple_grants.user_id = ?)"
+
+// ErrOrganizationRequired is returned when a Simple is accessed from a request that was not resolved to an organization.
+var ErrOrganizationRequired = errors.New("organization required")
+
 type SimpleRepository interface {
-	Create(ctx *gin.Context, simple *model.Simple) (*model.Simple, error)
-	GetAll(ctx *gin.Context) (model.Simples, error)
-	GetByID(ctx *gin.Context, id uint) (*model.Simple, error)
-	Update(ctx *gin.Context, simple *model.Simple) (*model.Simple, error)
-	Delete(ctx *gin.Context, id uint) error
+	Create(ctx context.Context, simple *model.Simple) (*model.Simple, error)
+	GetAll(ctx context.Context, filter model.SimpleFilter) (model.Simples, error)
+	GetAllAccessibleBy(ctx context.Context, userID uint, filter model.SimpleFilter) (model.Simples, error)
+	GetByID(ctx context.Context, id uint) (*model.Simple, error)
+	Update(ctx context.Context, simple *model.Simple, tags model.Tags) (*model.Simple, error)
+	Delete(ctx context.Context, id uint) error
+	// GetChanged loads a Simple, even once deleted, for the change stream. Unlike the other methods it is not scoped to
+	// an organization, as the stream runs outside of any request.
+	GetChanged(ctx context.Context, id uint) (*model.Simple, error)
 }
 
 type simpleRepository struct {
@@ -27,66 +41,234 @@ func NewSimpleRepository(db *gorm.DB) SimpleRepository {
 	return &simpleRepository{DB: db}
 }
 
-func (r simpleRepository) Create(ctx *gin.Context, simple *model.Simple) (*model.Simple, error) {
-	metrics := telemetry.GetMetrics()
-	start := time.Now()
+// Returns a query scoped to the request's organization, failing for requests without one.
+func (r simpleRepository) scoped(ctx context.Context) (*gorm.DB, uint, error) {
+	return scopeToOrganization(ctx, conn(ctx, r.DB))
+}
+
+// Like scoped, for reads that a read replica can serve.
+func (r simpleRepository) scopedRead(ctx context.Context) (*gorm.DB, error) {
+	query, _, err := scopeToOrganization(ctx, readConn(ctx, r.DB))
+	return query, err
+}
+
+func scopeToOrganization(ctx context.Context, db *gorm.DB) (*gorm.DB, uint, error) {
+	organizationID := utils.GetOrganizationID(ctx)
+	if organizationID == 0 {
+		return nil, 0, ErrOrganizationRequired
+	}
+	return db.Where("organization_id = ?", organizationID), organizationID, nil
+}
+
+func (r simpleRepository) Create(ctx context.Context, simple *model.Simple) (*model.Simple, error) {
+	_, organizationID, err := r.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := r.DB.Create(&simple).Error; err != nil {
+	simple.OrganizationID = organizationID
+	err = conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Tags.*").Create(&simple).Error; err != nil {
+			return err
+		}
+		return recordSimpleChange(tx, organizationID, simple.ID, model.EventSimpleCreated, simple.ToEventDTO())
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	metrics.RecordDBQuery(ctx, "create_simple", time.Since(start).Seconds())
-	metrics.UpdateSimpleCount(ctx, 1)
+	telemetry.GetMetrics().UpdateSimpleCount(ctx, 1)
 	return simple, nil
 }
 
-func (r simpleRepository) GetAll(ctx *gin.Context) (model.Simples, error) {
-	metrics := telemetry.GetMetrics()
-	start := time.Now()
+func (r simpleRepository) GetAll(ctx context.Context, filter model.SimpleFilter) (model.Simples, error) {
+	query, err := r.scopedRead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var simples model.Simples
+	if err := filterSimples(query, filter).Preload("Grants").Preload("Tags").Find(&simples).Error; err != nil {
+		return nil, err
+	}
+
+	return simples, nil
+}
+
+// Returns the organization's Simples that the user owns or has been granted access to.
+func (r simpleRepository) GetAllAccessibleBy(ctx context.Context, userID uint, filter model.SimpleFilter) (model.Simples, error) {
+	query, err := r.scopedRead(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	var simples model.Simples
-	if err := r.DB.Find(&simples).Error; err != nil {
+	err = filterSimples(query, filter).Preload("Grants").Preload("Tags").
+		Where(simpleAccessibleByCondition, userID, userID).
+		Find(&simples).Error
+	if err != nil {
 		return nil, err
 	}
 
-	metrics.RecordDBQuery(ctx, "get_all_simples", time.Since(start).Seconds())
 	return simples, nil
 }
 
-func (r simpleRepository) GetByID(ctx *gin.Context, id uint) (*model.Simple, error) {
-	metrics := telemetry.GetMetrics()
-	start := time.Now()
+func (r simpleRepository) GetByID(ctx context.Context, id uint) (*model.Simple, error) {
+	query, err := r.scopedRead(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	simple := &model.Simple{}
-	if err := r.DB.First(&simple, id).Error; err != nil {
+	if err := query.Preload("Grants").Preload("Tags").First(&simple, id).Error; err != nil {
 		return nil, err
 	}
 
-	metrics.RecordDBQuery(ctx, "get_simple_by_id", time.Since(start).Seconds())
 	return simple, nil
 }
 
-func (r simpleRepository) Update(ctx *gin.Context, simple *model.Simple) (*model.Simple, error) {
-	metrics := telemetry.GetMetrics()
-	start := time.Now()
+// Updates the Simple's fields, and replaces its tags unless tags is nil. Unlike Save, this never inserts a row when
+// the Simple is not in the organization, and the organization cannot be changed.
+func (r simpleRepository) Update(ctx context.Context, simple *model.Simple, tags model.Tags) (*model.Simple, error) {
+	_, organizationID, err := r.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := r.DB.Save(&simple).Error; err != nil {
+	simple.OrganizationID = organizationID
+	err = conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := updateSimple(tx.Where("organization_id = ?", organizationID), simple); err != nil {
+			return err
+		}
+		if tags != nil {
+			if err := replaceSimpleTags(tx, simple, tags); err != nil {
+				return err
+			}
+			simple.Tags = tags
+		}
+		return recordSimpleChange(tx, organizationID, simple.ID, model.EventSimpleUpdated, simple.ToEventDTO())
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	metrics.RecordDBQuery(ctx, "update_simple", time.Since(start).Seconds())
 	return simple, nil
 }
 
-func (r simpleRepository) Delete(ctx *gin.Context, id uint) error {
-	metrics := telemetry.GetMetrics()
-	start := time.Now()
+func updateSimple(query *gorm.DB, simple *model.Simple) error {
+	result := query.Model(simple).Select("name", "metadata", "updated_at").Updates(simple)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func replaceSimpleTags(tx *gorm.DB, simple *model.Simple, tags model.Tags) error {
+	if len(tags) == 0 {
+		return tx.Model(simple).Association("Tags").Clear()
+	}
+	return tx.Model(simple).Omit("Tags.*").Association("Tags").Replace(tags)
+}
+
+// Narrows the query to the Simples matching the filter. The tag names must be normalized.
+func filterSimples(query *gorm.DB, filter model.SimpleFilter) *gorm.DB {
+	if filter.Kind != "" {
+		query = query.Where("simples.kind = ?", filter.Kind)
+	}
+	return filterByMetadata(filterByTags(query, filter), filter)
+}
+
+// Narrows the query to Simples tagged with any, or all, of the filter's tags.
+func filterByTags(query *gorm.DB, filter model.SimpleFilter) *gorm.DB {
+	if len(filter.Tags) == 0 {
+		return query
+	}
+
+	tagged := query.Session(&gorm.Session{NewDB: true}).
+		Table("simple_tags").
+		Select("simple_tags.simple_id").
+		Joins("JOIN tags ON tags.id = simple_tags.tag_id").
+		Where("tags.name IN ?", filter.Tags)
+	if filter.Match == model.TagMatchAll {
+		tagged = tagged.Group("simple_tags.simple_id").Having("COUNT(DISTINCT tags.id) = ?", len(filter.Tags))
+	}
+	return query.Where("simples.id IN (?)", tagged)
+}
+
+// Narrows the query to Simples whose metadata contains every filtered value, using containment so the GIN index
+// on metadata is used.
+func filterByMetadata(query *gorm.DB, filter model.SimpleFilter) *gorm.DB {
+	paths := make([]string, 0, len(filter.Metadata))
+	for path := range filter.Metadata {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		documents := model.MetadataContainment(path, filter.Metadata[path])
+		conditions := make([]string, len(documents))
+		values := make([]any, len(documents))
+		for i, document := range documents {
+			conditions[i] = "simples.metadata @> ?"
+			values[i] = document
+		}
+		query = query.Where("("+strings.Join(conditions, " OR ")+")", values...)
+	}
+	return query
+}
+
+func (r simpleRepository) Delete(ctx context.Context, id uint) error {
+	_, organizationID, err := r.scoped(ctx)
+	if err != nil {
+		return err
+	}
 
-	if err := r.DB.Delete(&model.Simple{}, id).Error; err != nil {
+	err = conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("organization_id = ?", organizationID).Delete(&model.Simple{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return recordSimpleChange(tx, organizationID, id, model.EventSimpleDeleted, map[string]uint{"id": id, "organization_id": organizationID})
+	})
+	if err != nil {
 		return err
 	}
 
-	metrics.RecordDBQuery(ctx, "delete_simple", time.Since(start).Seconds())
-	metrics.UpdateSimpleCount(ctx, -1)
+	telemetry.GetMetrics().UpdateSimpleCount(ctx, -1)
 	return nil
 }
+
+func (r simpleRepository) GetChanged(ctx context.Context, id uint) (*model.Simple, error) {
+	simple := &model.Simple{}
+	if err := conn(ctx, r.DB).Unscoped().Preload("Grants").Preload("Tags").First(&simple, id).Error; err != nil {
+		return nil, err
+	}
+
+	return simple, nil
+}
+
+// Writes the change to the outbox and announces it on the Simple change channel, which Postgres only delivers to the
+// listeners once the transaction commits.
+func recordSimpleChange(tx *gorm.DB, organizationID uint, id uint, eventType string, data any) error {
+	event, err := appendOutboxEvent(tx, model.AggregateTypeSimple, id, eventType, data)
+	if err != nil {
+		return err
+	}
+
+	notification, err := json.Marshal(model.SimpleChangeNotification{
+		EventID:        event.ID,
+		Type:           eventType,
+		OrganizationID: organizationID,
+		SimpleID:       id,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Exec("SELECT pg_notify(?, ?)", model.SimpleChangeChannel, string(notification)).Error
+}
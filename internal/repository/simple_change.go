@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// SimpleChangeListener receives the notifications sent on every change to a Simple, made through any instance of the
+// application. It holds a connection of its own, outside of the pool, for as long as it listens.
+type SimpleChangeListener interface {
+	// Listen calls ready with the ID of the newest outbox event once it is listening, then handle with every
+	// notification received, until the context is cancelled or the connection fails. It returns nil once cancelled.
+	Listen(ctx context.Context, ready func(latestEventID uint64), handle func(notification *model.SimpleChangeNotification)) error
+}
+
+type simpleChangeListener struct {
+	DSN string
+}
+
+var _ SimpleChangeListener = &simpleChangeListener{}
+
+func NewSimpleChangeListener(dsn string) SimpleChangeListener {
+	return &simpleChangeListener{DSN: dsn}
+}
+
+func (l simpleChangeListener) Listen(ctx context.Context, ready func(latestEventID uint64), handle func(notification *model.SimpleChangeNotification)) error {
+	conn, err := pgx.Connect(ctx, l.DSN)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{model.SimpleChangeChannel}.Sanitize()); err != nil {
+		return err
+	}
+
+	// Read once listening, so every change committed after this event is received.
+	var latestEventID int64
+	if err := conn.QueryRow(ctx, "SELECT COALESCE(MAX(id), 0) FROM outbox_events").Scan(&latestEventID); err != nil {
+		return err
+	}
+	ready(uint64(latestEventID))
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		var change model.SimpleChangeNotification
+		if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil {
+			logger.GetFromContext(ctx).Warn("Ignoring malformed Simple change notification", zap.String("payload", notification.Payload), zap.Error(err))
+			continue
+		}
+		handle(&change)
+	}
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SimpleGrantRepository stores who a Simple is shared with. Callers are expected to have loaded the Simple through
+// the organization scoped SimpleRepository first.
+type SimpleGrantRepository interface {
+	Upsert(ctx context.Context, grant *model.SimpleGrant) (*model.SimpleGrant, error)
+	ListBySimpleID(ctx context.Context, simpleID uint) (model.SimpleGrants, error)
+	Delete(ctx context.Context, simpleID uint, userID uint) error
+}
+
+type simpleGrantRepository struct {
+	DB *gorm.DB
+}
+
+var _ SimpleGrantRepository = &simpleGrantRepository{}
+
+func NewSimpleGrantRepository(db *gorm.DB) SimpleGrantRepository {
+	return &simpleGrantRepository{DB: db}
+}
+
+// Shares the Simple with the user, or changes the permission if it is already shared with them.
+func (r simpleGrantRepository) Upsert(ctx context.Context, grant *model.SimpleGrant) (*model.SimpleGrant, error) {
+	err := conn(ctx, r.DB).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "simple_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"permission", "granted_by_id", "updated_at"}),
+	}).Create(&grant).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return grant, nil
+}
+
+func (r simpleGrantRepository) ListBySimpleID(ctx context.Context, simpleID uint) (model.SimpleGrants, error) {
+	var grants model.SimpleGrants
+	err := conn(ctx, r.DB).Preload("User").
+		Where("simple_id = ?", simpleID).
+		Order("created_at ASC").
+		Find(&grants).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return grants, nil
+}
+
+func (r simpleGrantRepository) Delete(ctx context.Context, simpleID uint, userID uint) error {
+	result := conn(ctx, r.DB).Where("simple_id = ? AND user_id = ?", simpleID, userID).Delete(&model.SimpleGrant{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TagRepository interface {
+	FindOrCreate(ctx context.Context, names []string) (model.Tags, error)
+	Suggest(ctx context.Context, filter model.TagSuggestionFilter, accessibleBy *uint) ([]*model.TagCount, error)
+}
+
+type tagRepository struct {
+	DB *gorm.DB
+}
+
+var _ TagRepository = &tagRepository{}
+
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{DB: db}
+}
+
+// Returns the organization's tags with the given names, creating the ones that do not exist yet. The names must
+// be normalized.
+func (r tagRepository) FindOrCreate(ctx context.Context, names []string) (model.Tags, error) {
+	organizationID := utils.GetOrganizationID(ctx)
+	if organizationID == 0 {
+		return nil, ErrOrganizationRequired
+	}
+	if len(names) == 0 {
+		return model.Tags{}, nil
+	}
+
+	newTags := make(model.Tags, len(names))
+	for i, name := range names {
+		newTags[i] = &model.Tag{OrganizationID: organizationID, Name: name}
+	}
+	err := conn(ctx, r.DB).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "organization_id"}, {Name: "name"}},
+		DoNothing: true,
+	}).Create(&newTags).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Tags that already existed are not returned by the insert, so all of them are read back.
+	var tags model.Tags
+	if err := conn(ctx, r.DB).Where("organization_id = ? AND name IN ?", organizationID, names).Find(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// Returns the organization's tags starting with the filter's query, most used first. When accessibleBy is set,
+// only the Simples that user owns or has been shared are counted, so tags used only on other Simples are left out.
+func (r tagRepository) Suggest(ctx context.Context, filter model.TagSuggestionFilter, accessibleBy *uint) ([]*model.TagCount, error) {
+	organizationID := utils.GetOrganizationID(ctx)
+	if organizationID == 0 {
+		return nil, ErrOrganizationRequired
+	}
+
+	query := conn(ctx, r.DB).Table("tags").
+		Select("tags.name, COUNT(simples.id) AS count").
+		Joins("JOIN simple_tags ON simple_tags.tag_id = tags.id").
+		Joins("JOIN simples ON simples.id = simple_tags.simple_id AND simples.deleted_at IS NULL").
+		Where("tags.organization_id = ? AND tags.name LIKE ?", organizationID, escapeLike(filter.Query)+"%")
+	if accessibleBy != nil {
+		query = query.Where(simpleAccessibleByCondition, *accessibleBy, *accessibleBy)
+	}
+
+	var tagCounts []*model.TagCount
+	err := query.Group("tags.name").
+		Order("count DESC, tags.name ASC").
+		Limit(filter.Limit).
+		Scan(&tagCounts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return tagCounts, nil
+}
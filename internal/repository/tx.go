@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/database"
+	"gorm.io/gorm"
+)
+
+// TxManager runs units of work that write through several repositories, or several services, in one transaction.
+type TxManager interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txContextKey struct{}
+
+type txManager struct {
+	DB *gorm.DB
+}
+
+var _ TxManager = &txManager{}
+
+func NewTxManager(db *gorm.DB) TxManager {
+	return &txManager{DB: db}
+}
+
+func (m txManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return conn(ctx, m.DB).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txContextKey{}, tx))
+	})
+}
+
+// WithoutTransaction returns a context whose queries do not join the unit of work ctx belongs to.
+func WithoutTransaction(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(txContextKey{}).(*gorm.DB); !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, txContextKey{}, nil)
+}
+
+// Returns the unit of work's transaction the context carries, or db otherwise.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// Returns the connection for a read that a read replica can serve, or the unit of work's transaction.
+func readConn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return database.PreferReplica(db.WithContext(ctx))
+}
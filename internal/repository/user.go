@@ -1,18 +1,24 @@
 package repository
 
 import (
+	"context"
 	"time"
 
 	"github.com/Verano-20/stage-zero/internal/model"
 	"github.com/Verano-20/stage-zero/internal/telemetry"
-	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type UserRepository interface {
-	Create(ctx *gin.Context, user *model.User) (*model.User, error)
-	GetByID(ctx *gin.Context, id uint) (*model.User, error)
-	GetByEmail(ctx *gin.Context, email string) (*model.User, error)
+	Create(ctx context.Context, user *model.User) (*model.User, error)
+	GetByID(ctx context.Context, id uint) (*model.User, error)
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	Update(ctx context.Context, user *model.User) (*model.User, error)
+	Delete(ctx context.Context, user *model.User) error
+	List(ctx context.Context, filter model.UserFilter) (model.Users, int64, error)
+	RecordFailedLogin(ctx context.Context, id uint, maxAttempts int, lockoutDuration time.Duration) error
+	ResetFailedLogins(ctx context.Context, id uint) error
+	UpdatePasswordHash(ctx context.Context, id uint, currentHash string, newHash string) error
 }
 
 type userRepository struct {
@@ -25,41 +31,137 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r userRepository) Create(ctx *gin.Context, user *model.User) (*model.User, error) {
-	metrics := telemetry.GetMetrics()
-	start := time.Now()
-
-	if err := r.db.Create(&user).Error; err != nil {
+func (r userRepository) Create(ctx context.Context, user *model.User) (*model.User, error) {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		_, err := appendOutboxEvent(tx, model.AggregateTypeUser, user.ID, model.EventUserCreated, user.ToEventDTO())
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	metrics.RecordDBQuery(ctx, "create_user", time.Since(start).Seconds())
-	metrics.UpdateUserCount(ctx, 1)
+	telemetry.GetMetrics().UpdateUserCount(ctx, 1)
 	return user, nil
 }
 
-func (r userRepository) GetByID(ctx *gin.Context, id uint) (*model.User, error) {
-	metrics := telemetry.GetMetrics()
-	start := time.Now()
-
+// Reads the primary, as requests are authenticated with the user it returns and a replica may not yet see them disabled.
+func (r userRepository) GetByID(ctx context.Context, id uint) (*model.User, error) {
 	user := &model.User{}
-	if err := r.db.First(&user, "id = ?", id).Error; err != nil {
+	if err := conn(ctx, r.db).First(&user, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 
-	metrics.RecordDBQuery(ctx, "get_user_by_id", time.Since(start).Seconds())
 	return user, nil
 }
 
-func (r userRepository) GetByEmail(ctx *gin.Context, email string) (*model.User, error) {
-	metrics := telemetry.GetMetrics()
-	start := time.Now()
-
+// Reads the primary, as logins are checked against the user it returns.
+func (r userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	user := &model.User{}
-	if err := r.db.First(&user, "email = ?", email).Error; err != nil {
+	if err := conn(ctx, r.db).First(&user, "email = ?", email).Error; err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Updates the user's account fields, leaving the failed login count and lockout to RecordFailedLogin.
+func (r userRepository) Update(ctx context.Context, user *model.User) (*model.User, error) {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(user).
+			Select("email", "password_hash", "disabled_at", "password_reset_required", "tokens_valid_after", "email_verified_at", "updated_at").
+			Updates(user)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		_, err := appendOutboxEvent(tx, model.AggregateTypeUser, user.ID, model.EventUserUpdated, user.ToEventDTO())
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	metrics.RecordDBQuery(ctx, "get_user_by_email", time.Since(start).Seconds())
 	return user, nil
 }
+
+// Writes the user's email, which the caller anonymises, and soft deletes the user in a single transaction.
+// The deleted event only carries the user's ID.
+func (r userRepository) Delete(ctx context.Context, user *model.User) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("email", user.Email).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(user).Error; err != nil {
+			return err
+		}
+		_, err := appendOutboxEvent(tx, model.AggregateTypeUser, user.ID, model.EventUserDeleted, map[string]uint{"id": user.ID})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	telemetry.GetMetrics().UpdateUserCount(ctx, -1)
+	return nil
+}
+
+func (r userRepository) List(ctx context.Context, filter model.UserFilter) (model.Users, int64, error) {
+	query := conn(ctx, r.db).Model(&model.User{})
+	if filter.Search != "" {
+		query = query.Where("email ILIKE ?", "%"+escapeLike(filter.Search)+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users model.Users
+	if err := query.Order("id").Offset((filter.Page - 1) * filter.PageSize).Limit(filter.PageSize).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// Increments the failed login counter and locks the account once it reaches maxAttempts. The update is done
+// in a single statement so concurrent failed logins are all counted.
+func (r userRepository) RecordFailedLogin(ctx context.Context, id uint, maxAttempts int, lockoutDuration time.Duration) error {
+	err := conn(ctx, r.db).Model(&model.User{}).Where("id = ?", id).Updates(map[string]any{
+		"failed_login_attempts": gorm.Expr("failed_login_attempts + 1"),
+		"locked_until":          gorm.Expr("CASE WHEN failed_login_attempts + 1 >= ? THEN ?::timestamptz ELSE locked_until END", maxAttempts, time.Now().Add(lockoutDuration)),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r userRepository) ResetFailedLogins(ctx context.Context, id uint) error {
+	err := conn(ctx, r.db).Model(&model.User{}).Where("id = ?", id).Updates(map[string]any{
+		"failed_login_attempts": 0,
+		"locked_until":          nil,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Replaces the password hash only if it is still currentHash, so a rehash racing with a password change cannot
+// restore the old password.
+func (r userRepository) UpdatePasswordHash(ctx context.Context, id uint, currentHash string, newHash string) error {
+	err := conn(ctx, r.db).Model(&model.User{}).Where("id = ? AND password_hash = ?", id, currentHash).Update("password_hash", newHash).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
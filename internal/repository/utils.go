@@ -0,0 +1,10 @@
+package repository
+
+import "strings"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Escapes LIKE wildcards in user input so it is matched literally.
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
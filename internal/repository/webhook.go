@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"gorm.io/gorm"
+)
+
+type WebhookEndpointRepository interface {
+	Create(ctx context.Context, endpoint *model.WebhookEndpoint) (*model.WebhookEndpoint, error)
+	ListByOrganizationID(ctx context.Context, organizationID uint) (model.WebhookEndpoints, error)
+	ListSubscribed(ctx context.Context, organizationID uint, eventType string) (model.WebhookEndpoints, error)
+	GetByID(ctx context.Context, organizationID uint, id uint) (*model.WebhookEndpoint, error)
+	Update(ctx context.Context, endpoint *model.WebhookEndpoint) (*model.WebhookEndpoint, error)
+	Delete(ctx context.Context, organizationID uint, id uint) error
+}
+
+// WebhookDeliveryRepository stores deliveries and their attempts.
+type WebhookDeliveryRepository interface {
+	CreateMany(ctx context.Context, deliveries model.WebhookDeliveries) error
+	ListByEndpointID(ctx context.Context, endpointID uint, filter model.WebhookDeliveryFilter) (model.WebhookDeliveries, int64, error)
+	GetByID(ctx context.Context, endpointID uint, id uint64) (*model.WebhookDelivery, error)
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) (model.WebhookDeliveries, error)
+	SaveAttempt(ctx context.Context, delivery *model.WebhookDelivery, attempt *model.WebhookDeliveryAttempt) error
+}
+
+type webhookEndpointRepository struct {
+	DB *gorm.DB
+}
+
+type webhookDeliveryRepository struct {
+	DB *gorm.DB
+}
+
+var _ WebhookEndpointRepository = &webhookEndpointRepository{}
+var _ WebhookDeliveryRepository = &webhookDeliveryRepository{}
+
+func NewWebhookEndpointRepository(db *gorm.DB) WebhookEndpointRepository {
+	return &webhookEndpointRepository{DB: db}
+}
+
+func NewWebhookDeliveryRepository(db *gorm.DB) WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{DB: db}
+}
+
+func (r webhookEndpointRepository) Create(ctx context.Context, endpoint *model.WebhookEndpoint) (*model.WebhookEndpoint, error) {
+	if err := conn(ctx, r.DB).Create(&endpoint).Error; err != nil {
+		return nil, err
+	}
+
+	return endpoint, nil
+}
+
+func (r webhookEndpointRepository) ListByOrganizationID(ctx context.Context, organizationID uint) (model.WebhookEndpoints, error) {
+	var endpoints model.WebhookEndpoints
+	if err := conn(ctx, r.DB).Where("organization_id = ?", organizationID).Order("id ASC").Find(&endpoints).Error; err != nil {
+		return nil, err
+	}
+
+	return endpoints, nil
+}
+
+// Returns the organization's active endpoints subscribed to the event type.
+func (r webhookEndpointRepository) ListSubscribed(ctx context.Context, organizationID uint, eventType string) (model.WebhookEndpoints, error) {
+	events, err := json.Marshal([]string{eventType})
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints model.WebhookEndpoints
+	err = conn(ctx, r.DB).Where("organization_id = ? AND active AND events @> ?::jsonb", organizationID, string(events)).
+		Order("id ASC").
+		Find(&endpoints).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return endpoints, nil
+}
+
+func (r webhookEndpointRepository) GetByID(ctx context.Context, organizationID uint, id uint) (*model.WebhookEndpoint, error) {
+	var endpoint model.WebhookEndpoint
+	if err := conn(ctx, r.DB).Where("organization_id = ?", organizationID).First(&endpoint, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &endpoint, nil
+}
+
+func (r webhookEndpointRepository) Update(ctx context.Context, endpoint *model.WebhookEndpoint) (*model.WebhookEndpoint, error) {
+	err := conn(ctx, r.DB).Model(endpoint).
+		Select("url", "description", "events", "active", "updated_at").
+		Updates(endpoint).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return endpoint, nil
+}
+
+func (r webhookEndpointRepository) Delete(ctx context.Context, organizationID uint, id uint) error {
+	result := conn(ctx, r.DB).Where("organization_id = ?", organizationID).Delete(&model.WebhookEndpoint{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r webhookDeliveryRepository) CreateMany(ctx context.Context, deliveries model.WebhookDeliveries) error {
+	if err := conn(ctx, r.DB).Omit("AttemptLog", "Endpoint").Create(&deliveries).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Lists the endpoint's deliveries, newest first, with their attempts.
+func (r webhookDeliveryRepository) ListByEndpointID(ctx context.Context, endpointID uint, filter model.WebhookDeliveryFilter) (model.WebhookDeliveries, int64, error) {
+	query := conn(ctx, r.DB).Model(&model.WebhookDelivery{}).Where("endpoint_id = ?", endpointID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries model.WebhookDeliveries
+	err := query.Preload("AttemptLog", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Order("id DESC").
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+func (r webhookDeliveryRepository) GetByID(ctx context.Context, endpointID uint, id uint64) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	if err := conn(ctx, r.DB).Where("endpoint_id = ?", endpointID).First(&delivery, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+// ClaimDue leases up to limit due pending deliveries, with their endpoints, skipping the ones another instance claims.
+func (r webhookDeliveryRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) (model.WebhookDeliveries, error) {
+	var ids []uint64
+	err := conn(ctx, r.DB).Raw(`
+		UPDATE webhook_deliveries SET next_attempt_at = NOW() + make_interval(secs => ?), updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id`, lease.Seconds(), model.WebhookDeliveryStatusPending, limit).
+		Scan(&ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries model.WebhookDeliveries
+	if len(ids) > 0 {
+		if err := conn(ctx, r.DB).Preload("Endpoint").Order("id ASC").Find(&deliveries, ids).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return deliveries, nil
+}
+
+func (r webhookDeliveryRepository) SaveAttempt(ctx context.Context, delivery *model.WebhookDelivery, attempt *model.WebhookDeliveryAttempt) error {
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+		return tx.Model(delivery).
+			Select("status", "attempts", "next_attempt_at", "last_response_status", "last_error", "updated_at").
+			Updates(delivery).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
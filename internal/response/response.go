@@ -9,3 +9,10 @@ type ErrorResponse struct {
 	Error   string            `json:"error" example:"An error occurred"`
 	Details map[string]string `json:"details"`
 }
+
+type PageData struct {
+	Items    any   `json:"items"`
+	Page     int   `json:"page" example:"1"`
+	PageSize int   `json:"page_size" example:"20"`
+	Total    int64 `json:"total" example:"42"`
+}
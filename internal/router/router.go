@@ -19,12 +19,15 @@ func InitRouter(container *container.Container) *gin.Engine {
 	log.Info("Configuring router...")
 
 	router := gin.New()
+	// Lets a *gin.Context be passed on as the request's context.Context, with the values the middleware adds to it.
+	router.ContextWithFallback = true
 	router.Use(gin.Recovery())
 	router.Use(otelgin.Middleware(config.ServiceName))
 	router.Use(middleware.LoggingMiddleware())
 	router.Use(middleware.MetricsMiddleware())
 
-	authMiddleware := middleware.NewAuthMiddleware(config.GetJwtSecret(), container.UserRepository)
+	authMiddleware := middleware.NewAuthMiddleware(config.GetJwtSecret(), config.Auth.Cookie.Name, container.UserRepository, container.SessionRepository, container.MembershipRepository, container.ImpersonationRepository, container.AuditLogger)
+	authRateLimiter := middleware.NewRateLimiter(config.Auth.RateLimit, config.Auth.RateLimitWindow)
 
 	router.GET("/health", controller.GetHealth)
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -34,20 +37,100 @@ func InitRouter(container *container.Container) *gin.Engine {
 	auth := router.Group("/auth")
 	{
 		auth.POST("/signup", authController.SignUp)
-		auth.POST("/login", authController.Login)
+		auth.POST("/login", authRateLimiter.LimitRequest, authController.Login)
+		auth.POST("/magic-link", authRateLimiter.LimitRequest, authController.RequestMagicLink)
+		auth.POST("/magic-link/consume", authRateLimiter.LimitRequest, authController.ConsumeMagicLink)
+		auth.POST("/logout", authController.Logout)
+		auth.POST("/verify-email", container.UserController.VerifyEmail)
+	}
+
+	// Me
+	userController := container.UserController
+	me := router.Group("/me")
+	{
+		me.GET("", authMiddleware.AuthenticateRequest, userController.GetMe)
+		me.PUT("/password", authMiddleware.AuthenticatePasswordChange, authMiddleware.ForbidImpersonation, userController.ChangePassword)
+		me.PUT("/email", authMiddleware.AuthenticateRequest, authMiddleware.ForbidImpersonation, userController.ChangeEmail)
+		me.DELETE("", authMiddleware.AuthenticateRequest, authMiddleware.ForbidImpersonation, userController.DeleteMe)
+		me.GET("/sessions", authMiddleware.AuthenticateRequest, userController.ListSessions)
+		me.DELETE("/sessions/:id", authMiddleware.AuthenticateRequest, authMiddleware.ForbidImpersonation, userController.RevokeSession)
+	}
+
+	// Admin
+	adminController := container.AdminController
+	admin := router.Group("/admin", authMiddleware.AuthenticateRequest, authMiddleware.RequireAdmin)
+	{
+		admin.GET("/users", adminController.ListUsers)
+		admin.GET("/users/:id", adminController.GetUser)
+		admin.POST("/users/:id/disable", adminController.DisableUser)
+		admin.POST("/users/:id/enable", adminController.EnableUser)
+		admin.POST("/users/:id/force-password-reset", adminController.ForcePasswordReset)
+		admin.POST("/users/:id/unlock", adminController.UnlockUser)
+		admin.POST("/users/:id/impersonate", adminController.ImpersonateUser)
+		admin.GET("/audit-events", container.AuditController.ListEvents)
+		admin.GET("/audit-events/verify", container.AuditController.VerifyChain)
+		admin.GET("/scheduled-tasks", container.SchedulerController.GetStatus)
+		admin.GET("/scheduled-tasks/:name/runs", container.SchedulerController.ListRuns)
+	}
+
+	// Organizations
+	organizationController := container.OrganizationController
+	invitationController := container.InvitationController
+	metadataSchemaController := container.MetadataSchemaController
+	webhookController := container.WebhookController
+	organizations := router.Group("/organizations", authMiddleware.AuthenticateRequest)
+	{
+		organizations.POST("", authMiddleware.ForbidImpersonation, organizationController.CreateOrganization)
+		organizations.GET("", organizationController.ListOrganizations)
+		organizations.GET("/:id/members", organizationController.ListMembers)
+		organizations.POST("/:id/members", authMiddleware.ForbidImpersonation, organizationController.AddMember)
+		organizations.DELETE("/:id/members/:userId", authMiddleware.ForbidImpersonation, organizationController.RemoveMember)
+		organizations.GET("/:id/invitations", invitationController.ListInvitations)
+		organizations.POST("/:id/invitations", authMiddleware.ForbidImpersonation, invitationController.CreateInvitation)
+		organizations.POST("/:id/invitations/:invitationId/resend", authMiddleware.ForbidImpersonation, invitationController.ResendInvitation)
+		organizations.DELETE("/:id/invitations/:invitationId", authMiddleware.ForbidImpersonation, invitationController.RevokeInvitation)
+		organizations.GET("/:id/metadata-schemas", metadataSchemaController.ListSchemas)
+		organizations.PUT("/:id/metadata-schemas", authMiddleware.ForbidImpersonation, metadataSchemaController.PutSchema)
+		organizations.DELETE("/:id/metadata-schemas/:schemaId", authMiddleware.ForbidImpersonation, metadataSchemaController.DeleteSchema)
+		organizations.GET("/:id/webhooks", webhookController.ListWebhooks)
+		organizations.POST("/:id/webhooks", authMiddleware.ForbidImpersonation, webhookController.CreateWebhook)
+		organizations.PUT("/:id/webhooks/:webhookId", authMiddleware.ForbidImpersonation, webhookController.UpdateWebhook)
+		organizations.DELETE("/:id/webhooks/:webhookId", authMiddleware.ForbidImpersonation, webhookController.DeleteWebhook)
+		organizations.GET("/:id/webhooks/:webhookId/deliveries", webhookController.ListDeliveries)
+		organizations.POST("/:id/webhooks/:webhookId/deliveries/:deliveryId/redeliver", authMiddleware.ForbidImpersonation, webhookController.Redeliver)
+	}
+
+	// Invitations
+	invitations := router.Group("/invitations", authRateLimiter.LimitRequest)
+	{
+		invitations.POST("/accept", invitationController.AcceptInvitation)
+		invitations.POST("/decline", invitationController.DeclineInvitation)
 	}
 
 	// Simple
 	simpleController := container.SimpleController
-	simples := router.Group("/simple", authMiddleware.AuthenticateRequest)
+	attachmentController := container.AttachmentController
+	simples := router.Group("/simple", authMiddleware.AuthenticateRequest, authMiddleware.RequireOrganization)
 	{
 		simples.POST("/", simpleController.Create)
 		simples.GET("/", simpleController.GetAll)
+		simples.GET("/tags", simpleController.SuggestTags)
+		simples.GET("/stream", simpleController.Stream)
 		simples.GET("/:id", simpleController.GetByID)
 		simples.PUT("/:id", simpleController.Update)
 		simples.DELETE("/:id", simpleController.Delete)
+		simples.GET("/:id/shares", simpleController.ListShares)
+		simples.POST("/:id/shares", authMiddleware.ForbidImpersonation, simpleController.Share)
+		simples.DELETE("/:id/shares/:userId", authMiddleware.ForbidImpersonation, simpleController.Unshare)
+		simples.GET("/:id/attachments", attachmentController.ListAttachments)
+		simples.POST("/:id/attachments", attachmentController.UploadAttachment)
+		simples.GET("/:id/attachments/:attachmentId/content", attachmentController.DownloadAttachment)
+		simples.DELETE("/:id/attachments/:attachmentId", attachmentController.DeleteAttachment)
 	}
 
+	// WebSocket
+	router.GET("/ws", authMiddleware.AuthenticateRequest, authMiddleware.RequireOrganization, container.WebSocketController.Connect)
+
 	log.Info("Router configured")
 	return router
 }
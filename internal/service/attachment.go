@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+
+	apiErr "github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/Verano-20/stage-zero/internal/storage"
+	"go.uber.org/zap"
+)
+
+// Number of leading bytes used to detect an upload's content type, as in http.DetectContentType.
+const contentSniffLength = 512
+
+type AttachmentService interface {
+	ListAttachments(ctx context.Context, simple *model.Simple) (model.Attachments, error)
+	GetAttachment(ctx context.Context, simple *model.Simple, id uint) (*model.Attachment, error)
+	UploadAttachment(ctx context.Context, simple *model.Simple, upload model.AttachmentUpload) (*model.Attachment, error)
+	OpenAttachment(ctx context.Context, attachment *model.Attachment) (io.ReadCloser, error)
+	DeleteAttachment(ctx context.Context, simple *model.Simple, attachment *model.Attachment) error
+}
+
+// attachmentService expects Simples loaded through SimpleService, so only users who can see a Simple reach its files.
+type attachmentService struct {
+	TxManager            repository.TxManager
+	AttachmentRepository repository.AttachmentRepository
+	BlobStore            storage.BlobStore
+	AuditLogger          AuditLogger
+	MaxSize              int64
+	AllowedContentTypes  []string
+}
+
+var _ AttachmentService = &attachmentService{}
+
+func NewAttachmentService(txManager repository.TxManager, attachmentRepository repository.AttachmentRepository, blobStore storage.BlobStore, auditLogger AuditLogger, maxSize int64, allowedContentTypes []string) AttachmentService {
+	return &attachmentService{
+		TxManager:            txManager,
+		AttachmentRepository: attachmentRepository,
+		BlobStore:            blobStore,
+		AuditLogger:          auditLogger,
+		MaxSize:              maxSize,
+		AllowedContentTypes:  allowedContentTypes,
+	}
+}
+
+func (s *attachmentService) ListAttachments(ctx context.Context, simple *model.Simple) (model.Attachments, error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Listing attachments", zap.Object("simple", simple))
+
+	attachments, err := s.AttachmentRepository.ListBySimpleID(ctx, simple.ID)
+	if err != nil {
+		log.Error("Failed to list attachments", zap.Object("simple", simple), zap.Error(err))
+		return nil, err
+	}
+
+	log.Debug("Attachments listed successfully", zap.Int("count", len(attachments)))
+	return attachments, nil
+}
+
+func (s *attachmentService) GetAttachment(ctx context.Context, simple *model.Simple, id uint) (*model.Attachment, error) {
+	log := logger.GetFromContext(ctx)
+
+	attachment, err := s.AttachmentRepository.GetByID(ctx, simple.ID, id)
+	if err != nil {
+		log.Warn("Attachment not found", zap.Object("simple", simple), zap.Uint("id", id), zap.Error(err))
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// UploadAttachment checks the upload's size and detected content type, then records the attachment and stores its
+// content unless the organization already has a blob with the same hash.
+func (s *attachmentService) UploadAttachment(ctx context.Context, simple *model.Simple, upload model.AttachmentUpload) (*model.Attachment, error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Uploading attachment", zap.Object("simple", simple), zap.String("file_name", upload.FileName))
+
+	if err := authorizeSimple(ctx, simple, model.SimplePermissionWrite); err != nil {
+		log.Warn("Not allowed to upload attachment", zap.Object("simple", simple))
+		s.recordAudit(ctx, model.AuditActionAttachAdd, simple.ID, err)
+		return nil, err
+	}
+
+	contentType, err := s.detectContentType(upload.Content)
+	if err != nil {
+		s.recordAudit(ctx, model.AuditActionAttachAdd, simple.ID, err)
+		return nil, err
+	}
+
+	digest, size, err := s.hashContent(upload.Content)
+	if err != nil {
+		s.recordAudit(ctx, model.AuditActionAttachAdd, simple.ID, err)
+		return nil, err
+	}
+
+	uploadedByID := authenticatedUserID(ctx)
+	var attachment *model.Attachment
+	err = s.TxManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.AttachmentRepository.LockContent(ctx, simple.OrganizationID, digest); err != nil {
+			log.Error("Failed to lock attachment content", zap.Object("simple", simple), zap.Error(err))
+			return err
+		}
+
+		var err error
+		attachment, err = s.AttachmentRepository.Create(ctx, &model.Attachment{
+			OrganizationID: simple.OrganizationID,
+			SimpleID:       simple.ID,
+			FileName:       model.SanitizeFileName(upload.FileName),
+			ContentType:    contentType,
+			Size:           size,
+			SHA256:         digest,
+			UploadedByID:   &uploadedByID,
+		})
+		if err != nil {
+			log.Error("Failed to create attachment", zap.Object("simple", simple), zap.Error(err))
+			return err
+		}
+
+		if err := s.storeContent(ctx, attachment, upload.Content); err != nil {
+			log.Error("Failed to store attachment content", zap.Object("attachment", attachment), zap.Error(err))
+			return err
+		}
+		return nil
+	})
+	s.recordAudit(ctx, model.AuditActionAttachAdd, simple.ID, err)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("Attachment uploaded successfully", zap.Object("attachment", attachment))
+	return attachment, nil
+}
+
+func (s *attachmentService) OpenAttachment(ctx context.Context, attachment *model.Attachment) (io.ReadCloser, error) {
+	content, err := s.BlobStore.Get(ctx, attachment.BlobKey())
+	if err != nil {
+		logger.GetFromContext(ctx).Error("Failed to open attachment content", zap.Object("attachment", attachment), zap.Error(err))
+		return nil, err
+	}
+	return content, nil
+}
+
+// DeleteAttachment removes the attachment, and its blob once no other attachment of the organization shares it.
+func (s *attachmentService) DeleteAttachment(ctx context.Context, simple *model.Simple, attachment *model.Attachment) error {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Deleting attachment", zap.Object("attachment", attachment))
+
+	if err := authorizeSimple(ctx, simple, model.SimplePermissionWrite); err != nil {
+		log.Warn("Not allowed to delete attachment", zap.Object("simple", simple))
+		s.recordAudit(ctx, model.AuditActionAttachDelete, simple.ID, err)
+		return err
+	}
+
+	var blobUnused bool
+	err := s.TxManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.AttachmentRepository.LockContent(ctx, attachment.OrganizationID, attachment.SHA256); err != nil {
+			log.Error("Failed to lock attachment content", zap.Object("attachment", attachment), zap.Error(err))
+			return err
+		}
+
+		if err := s.AttachmentRepository.Delete(ctx, attachment.ID); err != nil {
+			log.Error("Failed to delete attachment", zap.Object("attachment", attachment), zap.Error(err))
+			return err
+		}
+
+		remaining, err := s.AttachmentRepository.CountByHash(ctx, attachment.OrganizationID, attachment.SHA256)
+		if err != nil {
+			log.Error("Failed to count attachments sharing blob", zap.Object("attachment", attachment), zap.Error(err))
+			return err
+		}
+		blobUnused = remaining == 0
+		return nil
+	})
+	s.recordAudit(ctx, model.AuditActionAttachDelete, simple.ID, err)
+	if err != nil {
+		return err
+	}
+
+	if blobUnused {
+		s.deleteUnusedBlob(ctx, attachment)
+	}
+
+	log.Info("Attachment deleted successfully", zap.Object("attachment", attachment))
+	return nil
+}
+
+// Deletes the blob of a deleted attachment, unless an upload of the same content has used it since. The attachment
+// is already gone, so failing to delete the blob only leaves unreferenced content behind.
+func (s *attachmentService) deleteUnusedBlob(ctx context.Context, attachment *model.Attachment) {
+	err := s.TxManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.AttachmentRepository.LockContent(ctx, attachment.OrganizationID, attachment.SHA256); err != nil {
+			return err
+		}
+
+		remaining, err := s.AttachmentRepository.CountByHash(ctx, attachment.OrganizationID, attachment.SHA256)
+		if err != nil || remaining > 0 {
+			return err
+		}
+		return s.BlobStore.Delete(ctx, attachment.BlobKey())
+	})
+	if err != nil {
+		logger.GetFromContext(ctx).Warn("Failed to delete attachment blob", zap.Object("attachment", attachment), zap.Error(err))
+	}
+}
+
+// Detects the content type from the upload's leading bytes rather than trusting the client, and checks it is allowed.
+func (s *attachmentService) detectContentType(content io.ReadSeeker) (string, error) {
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	buffer := make([]byte, contentSniffLength)
+	n, err := io.ReadFull(content, buffer)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", err
+	}
+
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(buffer[:n]))
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(s.AllowedContentTypes, contentType) {
+		return "", apiErr.NewAttachmentTypeError(fmt.Errorf("content type %s is not allowed", contentType))
+	}
+	return contentType, nil
+}
+
+// Returns the hex SHA-256 and size of the upload, reading at most one byte past the size limit.
+func (s *attachmentService) hashContent(content io.ReadSeeker) (string, int64, error) {
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", 0, err
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, io.LimitReader(content, s.MaxSize+1))
+	if err != nil {
+		return "", 0, err
+	}
+	if size > s.MaxSize {
+		return "", 0, apiErr.NewAttachmentTooLargeError(fmt.Errorf("attachment is larger than %d bytes", s.MaxSize))
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+func (s *attachmentService) storeContent(ctx context.Context, attachment *model.Attachment, content io.ReadSeeker) error {
+	exists, err := s.BlobStore.Exists(ctx, attachment.BlobKey())
+	if err != nil {
+		return err
+	}
+	if exists {
+		logger.GetFromContext(ctx).Debug("Attachment content already stored", zap.Object("attachment", attachment))
+		return nil
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return s.BlobStore.Put(ctx, attachment.BlobKey(), io.LimitReader(content, attachment.Size), attachment.Size)
+}
+
+func (s *attachmentService) recordAudit(ctx context.Context, action string, simpleID uint, err error) {
+	event := &model.AuditEvent{Action: action, TargetType: model.AuditTargetTypeSimple, TargetID: strconv.FormatUint(uint64(simpleID), 10), Outcome: model.AuditOutcomeSuccess}
+	if err != nil {
+		event.Outcome = model.AuditOutcomeFailure
+		event.Reason = err.Error()
+	}
+	s.AuditLogger.Record(ctx, event)
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Number of events read at a time when verifying the hash chain.
+const auditVerifyBatchSize = 1000
+
+// AuditLogger records security relevant events in the append-only audit log.
+//
+// Record fills in the request details (actor, impersonator, client IP, user agent and trace id) from the
+// context, so callers only need to set the action, target and outcome. The actor is taken from the
+// authenticated user unless the caller sets ActorID or ActorEmail, e.g. for logins. Events are written outside any
+// unit of work, so they are kept when it rolls back. Failing to write an event is logged but does not fail the
+// request being audited.
+type AuditLogger interface {
+	Record(ctx context.Context, event *model.AuditEvent)
+	ListEvents(ctx context.Context, filter model.AuditEventFilter) (model.AuditEvents, int64, error)
+	VerifyChain(ctx context.Context) (*model.AuditChainVerification, error)
+}
+
+type auditLogger struct {
+	AuditRepository repository.AuditRepository
+}
+
+var _ AuditLogger = &auditLogger{}
+
+func NewAuditLogger(auditRepository repository.AuditRepository) AuditLogger {
+	return &auditLogger{AuditRepository: auditRepository}
+}
+
+func (s *auditLogger) Record(ctx context.Context, event *model.AuditEvent) {
+	log := logger.GetFromContext(ctx)
+
+	if user := utils.GetAuthenticatedUser(ctx); user != nil && event.ActorID == nil && event.ActorEmail == "" {
+		event.ActorID = &user.ID
+		event.ActorEmail = user.Email
+	}
+	if utils.IsImpersonating(ctx) {
+		event.ImpersonatorID = &utils.GetRealUser(ctx).ID
+	}
+
+	if request := utils.GetRequestInfo(ctx); request != nil {
+		event.ClientIP = request.ClientIP
+		event.UserAgent = request.UserAgent
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		event.TraceID = spanContext.TraceID().String()
+	}
+
+	event.CreatedAt = time.Now()
+	event.Truncate()
+
+	if _, err := s.AuditRepository.Append(repository.WithoutTransaction(ctx), event); err != nil {
+		log.Error("Failed to record audit event", zap.Object("event", event), zap.Error(err))
+		return
+	}
+
+	log.Debug("Audit event recorded", zap.Object("event", event))
+}
+
+func (s *auditLogger) ListEvents(ctx context.Context, filter model.AuditEventFilter) (model.AuditEvents, int64, error) {
+	log := logger.GetFromContext(ctx)
+
+	filter.Normalize()
+	log.Debug("Listing audit events...", zap.Int("page", filter.Page), zap.Int("page_size", filter.PageSize))
+
+	events, total, err := s.AuditRepository.List(ctx, filter)
+	if err != nil {
+		log.Error("Failed to list audit events", zap.Error(err))
+		return nil, 0, err
+	}
+
+	log.Debug("Audit events listed successfully", zap.Int("count", len(events)), zap.Int64("total", total))
+	return events, total, nil
+}
+
+// VerifyChain recomputes the hash of every event in order. An event whose hash does not match its contents has
+// been modified, and one whose previous hash does not match the event before it follows an inserted or deleted
+// event.
+func (s *auditLogger) VerifyChain(ctx context.Context) (*model.AuditChainVerification, error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Verifying audit log hash chain...")
+
+	result := &model.AuditChainVerification{Valid: true}
+	prevHash := model.AuditChainGenesisHash
+	var lastID uint64
+
+	for {
+		events, err := s.AuditRepository.ListAfter(ctx, lastID, auditVerifyBatchSize)
+		if err != nil {
+			log.Error("Failed to read audit events", zap.Uint64("after_id", lastID), zap.Error(err))
+			return nil, err
+		}
+
+		for _, event := range events {
+			result.EventsChecked++
+			if reason := verifyAuditEvent(event, prevHash); reason != "" {
+				result.Valid = false
+				result.FirstInvalidID = &event.ID
+				result.Reason = reason
+				log.Warn("Audit log hash chain is broken", zap.Uint64("event_id", event.ID), zap.String("reason", reason))
+				return result, nil
+			}
+			prevHash = event.Hash
+			lastID = event.ID
+		}
+
+		if len(events) < auditVerifyBatchSize {
+			break
+		}
+	}
+
+	log.Debug("Audit log hash chain verified", zap.Int64("events_checked", result.EventsChecked))
+	return result, nil
+}
+
+func verifyAuditEvent(event *model.AuditEvent, prevHash string) string {
+	if event.PrevHash != prevHash {
+		return "previous hash does not match the preceding event"
+	}
+	if event.ComputeHash() != event.Hash {
+		return "hash does not match the event contents"
+	}
+	return ""
+}
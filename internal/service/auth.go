@@ -1,33 +1,40 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"time"
 
+	apiErr "github.com/Verano-20/stage-zero/internal/err"
 	"github.com/Verano-20/stage-zero/internal/logger"
 	"github.com/Verano-20/stage-zero/internal/model"
-	"github.com/gin-gonic/gin"
+	"github.com/Verano-20/stage-zero/internal/password"
 	"github.com/golang-jwt/jwt/v4"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthService interface {
-	ValidateUserCredentials(ctx *gin.Context, userForm model.UserForm) (user *model.User, err error)
-	GenerateTokenString(ctx *gin.Context, user *model.User, jwtSecret []byte) (tokenString string, err error)
+	ValidateUserCredentials(ctx context.Context, userForm model.UserForm) (user *model.User, err error)
+	GenerateTokenString(ctx context.Context, user *model.User, jwtSecret []byte) (tokenString string, err error)
+	GenerateImpersonationTokenString(ctx context.Context, user *model.User, actor *model.User, jwtSecret []byte, ttl time.Duration) (tokenString string, err error)
+	RevokeTokenSession(ctx context.Context, tokenString string, jwtSecret []byte) error
 }
 
 type authService struct {
-	UserService UserService
+	UserService     UserService
+	SessionService  SessionService
+	PasswordHasher  password.PasswordHasher
+	MaxFailedLogins int
+	LockoutDuration time.Duration
 }
 
 var _ AuthService = &authService{}
 
-func NewAuthService(userService UserService) AuthService {
-	return &authService{UserService: userService}
+func NewAuthService(userService UserService, sessionService SessionService, passwordHasher password.PasswordHasher, maxFailedLogins int, lockoutDuration time.Duration) AuthService {
+	return &authService{UserService: userService, SessionService: sessionService, PasswordHasher: passwordHasher, MaxFailedLogins: maxFailedLogins, LockoutDuration: lockoutDuration}
 }
 
-func (s *authService) ValidateUserCredentials(ctx *gin.Context, userForm model.UserForm) (user *model.User, err error) {
+func (s *authService) ValidateUserCredentials(ctx context.Context, userForm model.UserForm) (user *model.User, err error) {
 	log := logger.GetFromContext(ctx)
 
 	log.Debug("Validating User credentials...", zap.Object("userForm", &userForm))
@@ -36,32 +43,65 @@ func (s *authService) ValidateUserCredentials(ctx *gin.Context, userForm model.U
 		return nil, err
 	}
 
-	if err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(userForm.Password)); err != nil {
+	if user.IsDisabled() {
+		log.Warn("Login failed - account disabled", zap.Object("user", user))
+		return nil, apiErr.NewAccountDisabledError(errors.New("account disabled"))
+	}
+
+	if user.IsLocked() {
+		log.Warn("Login failed - account locked", zap.Object("user", user), zap.Time("locked_until", *user.LockedUntil))
+		return nil, apiErr.NewAccountLockedError(errors.New("account locked"))
+	}
+
+	if err = s.PasswordHasher.Verify(userForm.Password, user.PasswordHash); err != nil {
 		log.Warn("Login failed - invalid password", zap.Object("userForm", &userForm), zap.Error(err))
+		if recordErr := s.UserService.RecordFailedLogin(ctx, user, s.MaxFailedLogins, s.LockoutDuration); recordErr != nil {
+			log.Error("Failed to record failed login", zap.Object("user", user), zap.Error(recordErr))
+		}
 		return nil, err
 	}
 
+	if user.FailedLoginAttempts > 0 || user.LockedUntil != nil {
+		if user, err = s.UserService.UnlockUser(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.PasswordHasher.NeedsRehash(user.PasswordHash) {
+		if rehashedUser, rehashErr := s.UserService.RehashPassword(ctx, user, userForm.Password); rehashErr != nil {
+			log.Error("Failed to upgrade outdated password hash", zap.Object("user", user), zap.Error(rehashErr))
+		} else {
+			user = rehashedUser
+		}
+	}
+
 	log.Debug("User credentials valid", zap.Object("user", user))
 	return user, nil
 }
 
-func (s *authService) GenerateTokenString(ctx *gin.Context, user *model.User, jwtSecret []byte) (tokenString string, err error) {
+// GenerateTokenString starts a new session for user and issues a token bound to it with the "sid" claim.
+func (s *authService) GenerateTokenString(ctx context.Context, user *model.User, jwtSecret []byte) (tokenString string, err error) {
 	log := logger.GetFromContext(ctx)
 	log.Debug("Generating JWT token...", zap.Object("user", user))
 
 	if jwtSecret == nil {
-		err = errors.New("jwtSecret is nil")
-		log.Error("JWT secret is nil", zap.Object("user", user), zap.Error(err))
+		return "", errors.New("jwtSecret is nil")
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Hour * 24)
+	session, err := s.SessionService.CreateSession(ctx, user, expiresAt)
+	if err != nil {
 		return "", err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	tokenString, err = signToken(jwtSecret, jwt.MapClaims{
 		"sub": user.ID,
-		"iat": time.Now().Unix(),
-		"exp": time.Now().Add(time.Hour * 24).Unix(),
+		"sid": session.ID,
+		"iat": now.Unix(),
+		"exp": expiresAt.Unix(),
 	})
-
-	if tokenString, err = token.SignedString(jwtSecret); err != nil {
+	if err != nil {
 		log.Error("Failed to generate JWT token", zap.Object("user", user), zap.Error(err))
 		return "", err
 	}
@@ -69,3 +109,60 @@ func (s *authService) GenerateTokenString(ctx *gin.Context, user *model.User, jw
 	log.Debug("JWT token generated successfully", zap.Object("user", user))
 	return tokenString, nil
 }
+
+// GenerateImpersonationTokenString issues a short-lived token for user that carries the acting admin in an
+// RFC 8693 style "act" claim, so requests made with it can be attributed to the real user.
+func (s *authService) GenerateImpersonationTokenString(ctx context.Context, user *model.User, actor *model.User, jwtSecret []byte, ttl time.Duration) (tokenString string, err error) {
+	log := logger.GetFromContext(ctx)
+	log.Debug("Generating impersonation JWT token...", zap.Object("user", user), zap.Object("actor", actor))
+
+	now := time.Now()
+	tokenString, err = signToken(jwtSecret, jwt.MapClaims{
+		"sub": user.ID,
+		"act": map[string]any{"sub": actor.ID},
+		"iat": now.Unix(),
+		"exp": now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		log.Error("Failed to generate impersonation JWT token", zap.Object("user", user), zap.Object("actor", actor), zap.Error(err))
+		return "", err
+	}
+
+	log.Debug("Impersonation JWT token generated successfully", zap.Object("user", user), zap.Object("actor", actor))
+	return tokenString, nil
+}
+
+// RevokeTokenSession ends the session a token is bound to by its "sid" claim, so the token, and any copy of it, is
+// rejected from then on. Tokens that are invalid or expired, or not bound to a session, have nothing to revoke.
+func (s *authService) RevokeTokenSession(ctx context.Context, tokenString string, jwtSecret []byte) error {
+	log := logger.GetFromContext(ctx)
+	log.Debug("Revoking JWT token session...")
+
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	token, err := parser.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	})
+	if err != nil || !token.Valid {
+		log.Debug("JWT token not valid, no session to revoke", zap.Error(err))
+		return nil
+	}
+
+	claims, _ := token.Claims.(jwt.MapClaims)
+	userID, hasSub := claims["sub"].(float64)
+	sessionID, hasSid := claims["sid"].(float64)
+	if !hasSub || !hasSid {
+		log.Debug("JWT token not bound to a session")
+		return nil
+	}
+
+	return s.SessionService.EndSession(ctx, uint(userID), uint(sessionID))
+}
+
+func signToken(jwtSecret []byte, claims jwt.MapClaims) (string, error) {
+	if jwtSecret == nil {
+		return "", errors.New("jwtSecret is nil")
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
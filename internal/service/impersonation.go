@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"go.uber.org/zap"
+)
+
+type ImpersonationService interface {
+	StartImpersonation(ctx context.Context, actor *model.User, user *model.User, jwtSecret []byte) (tokenString string, err error)
+}
+
+type impersonationService struct {
+	AuthService             AuthService
+	ImpersonationRepository repository.ImpersonationRepository
+	TTL                     time.Duration
+}
+
+var _ ImpersonationService = &impersonationService{}
+
+func NewImpersonationService(authService AuthService, impersonationRepository repository.ImpersonationRepository, ttl time.Duration) ImpersonationService {
+	return &impersonationService{
+		AuthService:             authService,
+		ImpersonationRepository: impersonationRepository,
+		TTL:                     ttl,
+	}
+}
+
+// StartImpersonation issues a short-lived token that lets actor act as user and records it in the
+// impersonation audit trail. Admins and disabled users cannot be impersonated.
+func (s *impersonationService) StartImpersonation(ctx context.Context, actor *model.User, user *model.User, jwtSecret []byte) (tokenString string, startErr error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Starting impersonation...", zap.Object("actor", actor), zap.Object("user", user))
+
+	if actor.ID == user.ID || user.IsAdmin() || user.IsDisabled() {
+		log.Warn("Impersonation not allowed", zap.Object("actor", actor), zap.Object("user", user))
+		return "", err.NewImpersonationNotAllowedError(errors.New("user cannot be impersonated"))
+	}
+
+	event := &model.ImpersonationEvent{ActorID: actor.ID, UserID: user.ID, Action: model.ImpersonationActionStarted}
+	if request := utils.GetRequestInfo(ctx); request != nil {
+		event.Method = request.Method
+		event.Path = request.Path
+		event.ClientIP = request.ClientIP
+		event.UserAgent = request.UserAgent
+	}
+	_, dbErr := s.ImpersonationRepository.Create(ctx, event)
+	if dbErr != nil {
+		log.Error("Failed to record impersonation", zap.Object("actor", actor), zap.Object("user", user), zap.Error(dbErr))
+		return "", dbErr
+	}
+
+	tokenString, tokenErr := s.AuthService.GenerateImpersonationTokenString(ctx, user, actor, jwtSecret, s.TTL)
+	if tokenErr != nil {
+		return "", tokenErr
+	}
+
+	log.Info("Impersonation started", zap.Object("actor", actor), zap.Object("user", user), zap.Duration("ttl", s.TTL))
+	return tokenString, nil
+}
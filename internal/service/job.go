@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/Verano-20/stage-zero/internal/telemetry"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+// Outcomes of a job attempt, as recorded in the job metrics.
+const (
+	JobOutcomeSucceeded = "succeeded"
+	JobOutcomeRetried   = "retried"
+	JobOutcomeDead      = "dead"
+)
+
+type JobWorkerPoolConfig struct {
+	Workers      int
+	PollInterval time.Duration
+	Timeout      time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+}
+
+// JobHandler runs a job, returning an error to have it retried; it may be run more than once.
+type JobHandler func(ctx context.Context, job *model.Job) error
+
+// JobQueue defers work to the background workers of any instance of the application.
+type JobQueue interface {
+	// Enqueue adds a job of a registered type, run from runAt or as soon as possible when runAt is zero.
+	Enqueue(ctx context.Context, jobType string, payload any, runAt time.Time) (*model.Job, error)
+}
+
+// JobWorkerPool runs the queued jobs of the types registered with it, with a fixed number of workers.
+type JobWorkerPool interface {
+	JobQueue
+	// Register sets the handler and attempts, or the default when zero, of a job type before the pool is run.
+	Register(jobType string, maxAttempts int, handler JobHandler)
+	// Run claims due jobs for idle workers until the context is cancelled, then waits for the jobs in flight.
+	Run(ctx context.Context)
+	// RunDue claims one batch of due jobs, up to one per worker, runs them and returns how many were run.
+	RunDue(ctx context.Context) (int, error)
+}
+
+// JobKind is a job type with a payload of type T, which must encode to a JSON object.
+type JobKind[T any] struct {
+	Type string
+	// MaxAttempts overrides the configured number of attempts, when set.
+	MaxAttempts int
+}
+
+type jobRegistration struct {
+	maxAttempts int
+	handler     JobHandler
+}
+
+type jobWorkerPool struct {
+	JobRepository repository.JobRepository
+	Config        JobWorkerPoolConfig
+
+	mutex         sync.RWMutex
+	registrations map[string]*jobRegistration
+}
+
+var _ JobWorkerPool = &jobWorkerPool{}
+
+func NewJobWorkerPool(jobRepository repository.JobRepository, config JobWorkerPoolConfig) JobWorkerPool {
+	return &jobWorkerPool{
+		JobRepository: jobRepository,
+		Config:        config,
+		registrations: make(map[string]*jobRegistration),
+	}
+}
+
+// Register sets the handler of the kind's jobs, which are passed their decoded payload.
+func (kind JobKind[T]) Register(pool JobWorkerPool, handle func(ctx context.Context, payload T) error) {
+	pool.Register(kind.Type, kind.MaxAttempts, func(ctx context.Context, job *model.Job) error {
+		var payload T
+		if err := job.DecodePayload(&payload); err != nil {
+			return fmt.Errorf("invalid payload: %w", err)
+		}
+		return handle(ctx, payload)
+	})
+}
+
+// Enqueue adds a job of the kind to be run as soon as possible.
+func (kind JobKind[T]) Enqueue(ctx context.Context, queue JobQueue, payload T) (*model.Job, error) {
+	return queue.Enqueue(ctx, kind.Type, payload, time.Time{})
+}
+
+// EnqueueAt adds a job of the kind to be run from runAt.
+func (kind JobKind[T]) EnqueueAt(ctx context.Context, queue JobQueue, payload T, runAt time.Time) (*model.Job, error) {
+	return queue.Enqueue(ctx, kind.Type, payload, runAt)
+}
+
+func (p *jobWorkerPool) Register(jobType string, maxAttempts int, handler JobHandler) {
+	if maxAttempts < 1 {
+		maxAttempts = p.Config.MaxAttempts
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.registrations[jobType] = &jobRegistration{maxAttempts: maxAttempts, handler: handler}
+}
+
+func (p *jobWorkerPool) Enqueue(ctx context.Context, jobType string, payload any, runAt time.Time) (*model.Job, error) {
+	log := logger.GetFromContext(ctx)
+
+	registration := p.registration(jobType)
+	if registration == nil {
+		return nil, fmt.Errorf("job type %q is not registered", jobType)
+	}
+
+	job, err := model.NewJob(jobType, payload, registration.maxAttempts, runAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.JobRepository.Create(ctx, job); err != nil {
+		log.Error("Failed to enqueue job", zap.String("type", jobType), zap.Error(err))
+		return nil, err
+	}
+
+	telemetry.GetMetrics().RecordJobEnqueued(ctx, jobType)
+	log.Debug("Job enqueued", zap.Object("job", job), zap.Time("run_at", job.RunAt))
+	return job, nil
+}
+
+func (p *jobWorkerPool) Run(ctx context.Context) {
+	log := logger.GetFromContext(ctx)
+
+	log.Info("Job workers started", zap.Int("workers", p.Config.Workers), zap.Duration("poll_interval", p.Config.PollInterval), zap.Strings("types", p.types()))
+	ticker := time.NewTicker(p.Config.PollInterval)
+	defer ticker.Stop()
+
+	// Holds a token per running job, so jobs are only claimed for idle workers.
+	workers := make(chan struct{}, p.Config.Workers)
+	var running sync.WaitGroup
+	for {
+		select {
+		case <-ctx.Done():
+			running.Wait()
+			log.Info("Job workers stopped")
+			return
+		case <-ticker.C:
+		}
+
+		// Keep claiming while every idle worker gets a job, so a backlog drains without waiting for the next tick.
+		for ctx.Err() == nil {
+			idle := cap(workers) - len(workers)
+			if idle == 0 {
+				break
+			}
+
+			jobs, err := p.JobRepository.ClaimDue(context.WithoutCancel(ctx), p.types(), idle, p.lease())
+			if err != nil {
+				log.Error("Failed to claim jobs", zap.Error(err))
+				break
+			}
+			for _, job := range jobs {
+				workers <- struct{}{}
+				running.Add(1)
+				go func() {
+					defer running.Done()
+					defer func() { <-workers }()
+					p.run(ctx, job)
+				}()
+			}
+			if len(jobs) < idle {
+				break
+			}
+		}
+	}
+}
+
+func (p *jobWorkerPool) RunDue(ctx context.Context) (int, error) {
+	jobs, err := p.JobRepository.ClaimDue(ctx, p.types(), p.Config.Workers, p.lease())
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.run(ctx, job)
+		}()
+	}
+	wg.Wait()
+
+	return len(jobs), nil
+}
+
+// Runs an attempt of the job until it times out, even if the pool stops meanwhile, and saves its outcome.
+func (p *jobWorkerPool) run(ctx context.Context, job *model.Job) {
+	log := logger.GetFromContext(ctx)
+	metrics := telemetry.GetMetrics()
+
+	ctx, span := telemetry.StartJobSpan(context.WithoutCancel(ctx), job.Type, job.ID, job.Attempts)
+	defer span.End()
+
+	metrics.RecordRunningJob(ctx, job.Type, 1)
+	defer metrics.RecordRunningJob(ctx, job.Type, -1)
+
+	start := time.Now()
+	err := p.handle(ctx, job)
+	duration := time.Since(start)
+
+	var outcome string
+	var saveErr error
+	if err == nil {
+		outcome = JobOutcomeSucceeded
+		saveErr = p.JobRepository.Complete(ctx, job)
+	} else {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		job.RecordFailure(err, p.nextRunAt(job))
+		outcome = JobOutcomeRetried
+		if job.Status == model.JobStatusDead {
+			outcome = JobOutcomeDead
+		}
+		saveErr = p.JobRepository.SaveFailure(ctx, job)
+	}
+	metrics.RecordJobProcessed(ctx, job.Type, outcome, duration.Seconds())
+
+	if saveErr != nil {
+		// The job is run again once its lease expires.
+		log.Error("Failed to save job outcome", zap.Object("job", job), zap.String("outcome", outcome), zap.Error(saveErr))
+		return
+	}
+
+	switch outcome {
+	case JobOutcomeSucceeded:
+		log.Debug("Job succeeded", zap.Object("job", job), zap.Duration("duration", duration))
+	case JobOutcomeRetried:
+		log.Warn("Job failed, will retry", zap.Object("job", job), zap.Time("run_at", job.RunAt), zap.Error(err))
+	default:
+		log.Error("Job failed permanently", zap.Object("job", job), zap.Error(err))
+	}
+}
+
+// Calls the job's handler with the job timeout, turning a panic into an error so it does not take down the worker.
+func (p *jobWorkerPool) handle(ctx context.Context, job *model.Job) (err error) {
+	// A job claimed again after its worker died may already have used its last attempt.
+	if job.Attempts > job.MaxAttempts {
+		return fmt.Errorf("lease expired on the last of %d attempts", job.MaxAttempts)
+	}
+
+	registration := p.registration(job.Type)
+	if registration == nil {
+		return fmt.Errorf("job type %q is not registered", job.Type)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.Config.Timeout)
+	defer cancel()
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+	return registration.handler(ctx, job)
+}
+
+// Returns when to retry the job after its current attempt failed, or nil once it has used its attempts.
+func (p *jobWorkerPool) nextRunAt(job *model.Job) *time.Time {
+	if job.Attempts >= job.MaxAttempts {
+		return nil
+	}
+
+	next := time.Now().Add(exponentialBackoff(p.Config.BaseBackoff, p.Config.MaxBackoff, job.Attempts))
+	return &next
+}
+
+// Claimed jobs are run again by another worker if this one has not saved the outcome within the lease.
+func (p *jobWorkerPool) lease() time.Duration {
+	return p.Config.Timeout + time.Minute
+}
+
+func (p *jobWorkerPool) registration(jobType string) *jobRegistration {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.registrations[jobType]
+}
+
+// Returns the registered job types, which are the ones this pool claims.
+func (p *jobWorkerPool) types() []string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	types := make([]string, 0, len(p.registrations))
+	for jobType := range p.registrations {
+		types = append(types, jobType)
+	}
+	slices.Sort(types)
+	return types
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/mail"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type MagicLinkService interface {
+	SendMagicLink(ctx context.Context, magicLinkForm model.MagicLinkForm) error
+	ConsumeMagicLink(ctx context.Context, token string) (*model.User, error)
+}
+
+type magicLinkService struct {
+	UserService         UserService
+	MagicLinkRepository repository.MagicLinkRepository
+	MailSender          mail.Sender
+	LinkURL             string
+	TTL                 time.Duration
+}
+
+var _ MagicLinkService = &magicLinkService{}
+
+func NewMagicLinkService(userService UserService, magicLinkRepository repository.MagicLinkRepository, mailSender mail.Sender, linkURL string, ttl time.Duration) MagicLinkService {
+	return &magicLinkService{
+		UserService:         userService,
+		MagicLinkRepository: magicLinkRepository,
+		MailSender:          mailSender,
+		LinkURL:             linkURL,
+		TTL:                 ttl,
+	}
+}
+
+// SendMagicLink emails a single-use login link to the given address, silently ignoring unknown emails.
+func (s *magicLinkService) SendMagicLink(ctx context.Context, magicLinkForm model.MagicLinkForm) error {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Sending magic link...", zap.Object("magicLinkForm", &magicLinkForm))
+
+	user, userErr := s.UserService.GetUserByEmail(ctx, magicLinkForm.Email)
+	if userErr != nil {
+		if errors.Is(userErr, gorm.ErrRecordNotFound) {
+			log.Info("Magic link requested for unknown email", zap.Object("magicLinkForm", &magicLinkForm))
+			return nil
+		}
+		return userErr
+	}
+
+	if user.IsDisabled() {
+		log.Warn("Magic link requested for disabled user", zap.Object("user", user))
+		return nil
+	}
+
+	token, tokenErr := utils.GenerateToken()
+	if tokenErr != nil {
+		log.Error("Failed to generate magic link token", zap.Object("user", user), zap.Error(tokenErr))
+		return tokenErr
+	}
+
+	magicLinkToken, dbErr := s.MagicLinkRepository.Create(ctx, &model.MagicLinkToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(s.TTL),
+	})
+	if dbErr != nil {
+		log.Error("Failed to store magic link token", zap.Object("user", user), zap.Error(dbErr))
+		return dbErr
+	}
+
+	message := mail.Message{
+		To:      user.Email,
+		Subject: "Your login link",
+		Body: fmt.Sprintf("Use the link below to log in. It expires in %s and can only be used once.\n\n%s?token=%s",
+			s.TTL, s.LinkURL, url.QueryEscape(token)),
+	}
+	if mailErr := s.MailSender.Send(ctx, message); mailErr != nil {
+		log.Error("Failed to send magic link email", zap.Object("user", user), zap.Error(mailErr))
+		return mailErr
+	}
+
+	log.Debug("Magic link sent successfully", zap.Object("user", user), zap.Object("magicLinkToken", magicLinkToken))
+	return nil
+}
+
+// ConsumeMagicLink exchanges a single-use magic link token for the user it was issued to.
+func (s *magicLinkService) ConsumeMagicLink(ctx context.Context, token string) (*model.User, error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Consuming magic link...")
+
+	magicLinkToken, dbErr := s.MagicLinkRepository.Consume(ctx, utils.HashToken(token))
+	if dbErr != nil {
+		log.Warn("Magic link invalid, expired or already used", zap.Error(dbErr))
+		return nil, err.NewMagicLinkInvalidError(dbErr)
+	}
+
+	user, userErr := s.UserService.GetUserByID(ctx, magicLinkToken.UserID)
+	if userErr != nil {
+		log.Warn("User for magic link not found", zap.Object("magicLinkToken", magicLinkToken), zap.Error(userErr))
+		return nil, err.NewMagicLinkInvalidError(userErr)
+	}
+
+	if user.IsDisabled() {
+		log.Warn("Magic link consumed for disabled user", zap.Object("user", user))
+		return nil, err.NewAccountDisabledError(errors.New("account disabled"))
+	}
+
+	log.Debug("Magic link consumed successfully", zap.Object("user", user))
+	return user, nil
+}
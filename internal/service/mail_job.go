@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Verano-20/stage-zero/internal/mail"
+	"github.com/Verano-20/stage-zero/internal/utils"
+)
+
+// MailJob sends a mail message in the background.
+var MailJob = JobKind[SealedMail]{Type: "mail.send"}
+
+const mailJobKeyLabel = "mail-job"
+
+// SealedMail is the payload of a MailJob: a mail message sealed with the application secret.
+type SealedMail struct {
+	Message string `json:"message"`
+}
+
+type queuedMailSender struct {
+	JobQueue JobQueue
+	Secret   []byte
+}
+
+var _ mail.Sender = &queuedMailSender{}
+
+// NewQueuedMailSender returns a Sender that enqueues a MailJob per message, to be run by RegisterMailJob's handler.
+func NewQueuedMailSender(jobQueue JobQueue, secret []byte) mail.Sender {
+	return &queuedMailSender{JobQueue: jobQueue, Secret: secret}
+}
+
+func (s *queuedMailSender) Send(ctx context.Context, message mail.Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	sealed, err := utils.Seal(data, s.Secret, mailJobKeyLabel)
+	if err != nil {
+		return err
+	}
+
+	_, err = MailJob.Enqueue(ctx, s.JobQueue, SealedMail{Message: sealed})
+	return err
+}
+
+// RegisterMailJob registers MailJob to send the messages queued by a queued Sender with the secret, with sender.
+func RegisterMailJob(pool JobWorkerPool, sender mail.Sender, secret []byte) {
+	MailJob.Register(pool, func(ctx context.Context, payload SealedMail) error {
+		data, err := utils.Unseal(payload.Message, secret, mailJobKeyLabel)
+		if err != nil {
+			return fmt.Errorf("invalid message: %w", err)
+		}
+		var message mail.Message
+		if err := json.Unmarshal(data, &message); err != nil {
+			return fmt.Errorf("invalid message: %w", err)
+		}
+		return sender.Send(ctx, message)
+	})
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	apiErr "github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const metadataSchemaURL = "metadata-schema.json"
+
+type MetadataSchemaService interface {
+	ListSchemas(ctx context.Context, user *model.User, organizationID uint) (model.MetadataSchemas, error)
+	PutSchema(ctx context.Context, user *model.User, organizationID uint, schemaForm model.MetadataSchemaForm) (*model.MetadataSchema, error)
+	DeleteSchema(ctx context.Context, user *model.User, organizationID uint, schemaID uint) error
+	ValidateMetadata(ctx context.Context, organizationID uint, kind string, metadata model.JSONMap) error
+}
+
+type metadataSchemaService struct {
+	MetadataSchemaRepository repository.MetadataSchemaRepository
+	MembershipRepository     repository.MembershipRepository
+}
+
+var _ MetadataSchemaService = &metadataSchemaService{}
+
+func NewMetadataSchemaService(metadataSchemaRepository repository.MetadataSchemaRepository, membershipRepository repository.MembershipRepository) MetadataSchemaService {
+	return &metadataSchemaService{MetadataSchemaRepository: metadataSchemaRepository, MembershipRepository: membershipRepository}
+}
+
+func (s *metadataSchemaService) ListSchemas(ctx context.Context, user *model.User, organizationID uint) (model.MetadataSchemas, error) {
+	log := logger.GetFromContext(ctx)
+
+	if err := s.authorize(ctx, user, organizationID); err != nil {
+		return nil, err
+	}
+
+	schemas, err := s.MetadataSchemaRepository.ListByOrganizationID(ctx, organizationID)
+	if err != nil {
+		log.Error("Failed to list metadata schemas", zap.Uint("organization_id", organizationID), zap.Error(err))
+		return nil, err
+	}
+
+	return schemas, nil
+}
+
+// PutSchema registers the JSON Schema for the organization and kind, replacing the one registered before. Simples
+// already stored are not re-validated; the schema applies to metadata written from now on.
+func (s *metadataSchemaService) PutSchema(ctx context.Context, user *model.User, organizationID uint, schemaForm model.MetadataSchemaForm) (*model.MetadataSchema, error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Registering metadata schema...", zap.Uint("organization_id", organizationID), zap.String("kind", schemaForm.Kind))
+
+	if err := s.authorize(ctx, user, organizationID); err != nil {
+		return nil, err
+	}
+
+	if _, err := compileMetadataSchema(schemaForm.Schema); err != nil {
+		log.Warn("Invalid metadata schema", zap.Uint("organization_id", organizationID), zap.String("kind", schemaForm.Kind), zap.Error(err))
+		return nil, apiErr.NewSchemaInvalidError(err)
+	}
+
+	schema, err := s.MetadataSchemaRepository.Upsert(ctx, &model.MetadataSchema{
+		OrganizationID: organizationID,
+		Kind:           schemaForm.Kind,
+		Schema:         schemaForm.Schema,
+		CreatedByID:    &user.ID,
+	})
+	if err != nil {
+		log.Error("Failed to register metadata schema", zap.Uint("organization_id", organizationID), zap.String("kind", schemaForm.Kind), zap.Error(err))
+		return nil, err
+	}
+
+	log.Info("Metadata schema registered successfully", zap.Object("schema", schema))
+	return schema, nil
+}
+
+func (s *metadataSchemaService) DeleteSchema(ctx context.Context, user *model.User, organizationID uint, schemaID uint) error {
+	log := logger.GetFromContext(ctx)
+
+	if err := s.authorize(ctx, user, organizationID); err != nil {
+		return err
+	}
+
+	if err := s.MetadataSchemaRepository.Delete(ctx, organizationID, schemaID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apiErr.NewSchemaNotFoundError(err)
+		}
+		log.Error("Failed to delete metadata schema", zap.Uint("organization_id", organizationID), zap.Uint("schema_id", schemaID), zap.Error(err))
+		return err
+	}
+
+	log.Info("Metadata schema deleted successfully", zap.Uint("organization_id", organizationID), zap.Uint("schema_id", schemaID))
+	return nil
+}
+
+// ValidateMetadata checks the metadata against the organization wide schema and the schema of the kind, if they
+// are registered. Violations of either are returned together.
+func (s *metadataSchemaService) ValidateMetadata(ctx context.Context, organizationID uint, kind string, metadata model.JSONMap) error {
+	log := logger.GetFromContext(ctx)
+
+	schemas, err := s.MetadataSchemaRepository.ListForKind(ctx, organizationID, kind)
+	if err != nil {
+		log.Error("Failed to load metadata schemas", zap.Uint("organization_id", organizationID), zap.String("kind", kind), zap.Error(err))
+		return err
+	}
+
+	instance, err := toJSONSchemaValue(metadata)
+	if err != nil {
+		return err
+	}
+
+	violations := map[string]string{}
+	for _, schema := range schemas {
+		compiled, err := compileMetadataSchema(schema.Schema)
+		if err != nil {
+			log.Error("Stored metadata schema does not compile", zap.Object("schema", schema), zap.Error(err))
+			return err
+		}
+
+		var validationErr *jsonschema.ValidationError
+		if err := compiled.Validate(instance); errors.As(err, &validationErr) {
+			for _, unit := range validationErr.BasicOutput().Errors {
+				if unit.Error == nil {
+					continue
+				}
+				location := "metadata" + strings.ReplaceAll(unit.InstanceLocation, "/", ".")
+				if _, exists := violations[location]; !exists {
+					violations[location] = unit.Error.String()
+				}
+			}
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if len(violations) > 0 {
+		log.Warn("Metadata does not match schema", zap.Uint("organization_id", organizationID), zap.String("kind", kind), zap.Any("violations", violations))
+		return apiErr.NewMetadataInvalidError(errors.New("metadata does not match schema"), violations)
+	}
+	return nil
+}
+
+func (s *metadataSchemaService) authorize(ctx context.Context, user *model.User, organizationID uint) error {
+	log := logger.GetFromContext(ctx)
+
+	membership, err := s.MembershipRepository.Get(ctx, organizationID, user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Warn("Membership not found", zap.Uint("organization_id", organizationID), zap.Uint("user_id", user.ID))
+			return apiErr.NewMembershipNotFoundError(err)
+		}
+		log.Error("Failed to get membership", zap.Uint("organization_id", organizationID), zap.Uint("user_id", user.ID), zap.Error(err))
+		return err
+	}
+
+	if !membership.CanManageMembers() {
+		log.Warn("Not allowed to manage metadata schemas", zap.Object("membership", membership))
+		return apiErr.NewOrganizationAccessError(errors.New("not allowed to manage metadata schemas"))
+	}
+	return nil
+}
+
+// Compiles a stored or submitted schema. Schemas may only reference themselves and the standard meta-schemas, so
+// a schema cannot make the server read files or fetch URLs.
+func compileMetadataSchema(schema model.JSONMap) (*jsonschema.Schema, error) {
+	document, err := toJSONSchemaValue(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.UseLoader(refusingURLLoader{})
+	if err := compiler.AddResource(metadataSchemaURL, document); err != nil {
+		return nil, err
+	}
+	return compiler.Compile(metadataSchemaURL)
+}
+
+// Converts a value to the representation the jsonschema package expects, with numbers as json.Number.
+func toJSONSchemaValue(value model.JSONMap) (any, error) {
+	if value == nil {
+		value = model.JSONMap{}
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return jsonschema.UnmarshalJSON(bytes.NewReader(encoded))
+}
+
+type refusingURLLoader struct{}
+
+func (refusingURLLoader) Load(url string) (any, error) {
+	return nil, fmt.Errorf("external schema references are not allowed: %s", url)
+}
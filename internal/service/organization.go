@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	apiErr "github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type OrganizationService interface {
+	CreateOrganization(ctx context.Context, user *model.User, organizationForm model.OrganizationForm) (*model.Organization, error)
+	ListMemberships(ctx context.Context, user *model.User) (model.Memberships, error)
+	ListMembers(ctx context.Context, user *model.User, organizationID uint) (model.Memberships, error)
+	AddMember(ctx context.Context, user *model.User, organizationID uint, membershipForm model.MembershipForm) (*model.Membership, error)
+	RemoveMember(ctx context.Context, user *model.User, organizationID uint, memberID uint) error
+}
+
+type organizationService struct {
+	TxManager              repository.TxManager
+	OrganizationRepository repository.OrganizationRepository
+	MembershipRepository   repository.MembershipRepository
+	UserService            UserService
+}
+
+var _ OrganizationService = &organizationService{}
+
+func NewOrganizationService(txManager repository.TxManager, organizationRepository repository.OrganizationRepository, membershipRepository repository.MembershipRepository, userService UserService) OrganizationService {
+	return &organizationService{
+		TxManager:              txManager,
+		OrganizationRepository: organizationRepository,
+		MembershipRepository:   membershipRepository,
+		UserService:            userService,
+	}
+}
+
+// CreateOrganization creates an organization owned by user.
+func (s *organizationService) CreateOrganization(ctx context.Context, user *model.User, organizationForm model.OrganizationForm) (*model.Organization, error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Creating Organization...", zap.Object("user", user), zap.Object("organization", &organizationForm))
+
+	organization, err := s.OrganizationRepository.Create(ctx, &model.Organization{Name: organizationForm.Name}, user.ID)
+	if err != nil {
+		log.Error("Failed to create Organization", zap.Object("user", user), zap.Object("organization", &organizationForm), zap.Error(err))
+		return nil, err
+	}
+
+	log.Info("Organization created", zap.Object("organization", organization), zap.Object("owner", user))
+	return organization, nil
+}
+
+// ListMemberships returns the user's memberships with their organizations.
+func (s *organizationService) ListMemberships(ctx context.Context, user *model.User) (model.Memberships, error) {
+	log := logger.GetFromContext(ctx)
+
+	memberships, err := s.MembershipRepository.ListByUserID(ctx, user.ID)
+	if err != nil {
+		log.Error("Failed to list memberships", zap.Object("user", user), zap.Error(err))
+		return nil, err
+	}
+
+	return memberships, nil
+}
+
+// ListMembers returns the members of an organization the user belongs to.
+func (s *organizationService) ListMembers(ctx context.Context, user *model.User, organizationID uint) (model.Memberships, error) {
+	log := logger.GetFromContext(ctx)
+
+	if _, err := s.getMembership(ctx, organizationID, user.ID); err != nil {
+		return nil, err
+	}
+
+	members, err := s.MembershipRepository.ListByOrganizationID(ctx, organizationID)
+	if err != nil {
+		log.Error("Failed to list members", zap.Uint("organization_id", organizationID), zap.Error(err))
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// AddMember adds the user with the given email to the organization, as an owner or admin of it.
+func (s *organizationService) AddMember(ctx context.Context, user *model.User, organizationID uint, membershipForm model.MembershipForm) (*model.Membership, error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Adding member...", zap.Object("user", user), zap.Uint("organization_id", organizationID), zap.Object("membership", &membershipForm))
+
+	actingMembership, err := s.getMembership(ctx, organizationID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actingMembership.CanManageMembers() || (membershipForm.Role == model.OrganizationRoleOwner && !actingMembership.IsOwner()) {
+		log.Warn("Not allowed to add member", zap.Object("acting_membership", actingMembership), zap.Object("membership", &membershipForm))
+		return nil, apiErr.NewOrganizationAccessError(errors.New("not allowed to add members with this role"))
+	}
+
+	member, err := s.UserService.GetUserByEmail(ctx, membershipForm.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apiErr.NewUserNotFoundError(err)
+		}
+		return nil, err
+	}
+
+	if _, err := s.MembershipRepository.Get(ctx, organizationID, member.ID); err == nil {
+		log.Warn("User is already a member", zap.Uint("organization_id", organizationID), zap.Object("member", member))
+		return nil, apiErr.NewMembershipExistsError(errors.New("user is already a member"))
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Error("Failed to check existing membership", zap.Uint("organization_id", organizationID), zap.Object("member", member), zap.Error(err))
+		return nil, err
+	}
+
+	membership, err := s.MembershipRepository.Create(ctx, &model.Membership{OrganizationID: organizationID, UserID: member.ID, Role: membershipForm.Role})
+	if err != nil {
+		log.Error("Failed to add member", zap.Uint("organization_id", organizationID), zap.Object("member", member), zap.Error(err))
+		return nil, err
+	}
+
+	membership.User = member
+	log.Info("Member added", zap.Object("membership", membership), zap.Object("added_by", user))
+	return membership, nil
+}
+
+// RemoveMember removes a member, or the user themselves, from the organization, except its last owner.
+func (s *organizationService) RemoveMember(ctx context.Context, user *model.User, organizationID uint, memberID uint) error {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Removing member...", zap.Object("user", user), zap.Uint("organization_id", organizationID), zap.Uint("member_id", memberID))
+
+	actingMembership, err := s.getMembership(ctx, organizationID, user.ID)
+	if err != nil {
+		return err
+	}
+
+	membership := actingMembership
+	if memberID != user.ID {
+		if membership, err = s.getMembership(ctx, organizationID, memberID); err != nil {
+			return err
+		}
+		if !actingMembership.CanManageMembers() || (membership.IsOwner() && !actingMembership.IsOwner()) {
+			log.Warn("Not allowed to remove member", zap.Object("acting_membership", actingMembership), zap.Object("membership", membership))
+			return apiErr.NewOrganizationAccessError(errors.New("not allowed to remove this member"))
+		}
+	}
+
+	// The owners stay locked until the membership is deleted, so concurrent removals cannot leave the organization without one.
+	err = s.TxManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if membership.IsOwner() {
+			owners, err := s.MembershipRepository.LockOwners(ctx, organizationID)
+			if err != nil {
+				log.Error("Failed to lock owners", zap.Uint("organization_id", organizationID), zap.Error(err))
+				return err
+			}
+			if owners <= 1 {
+				log.Warn("Cannot remove the last owner", zap.Object("membership", membership))
+				return apiErr.NewLastOwnerError(errors.New("organization must keep an owner"))
+			}
+		}
+
+		if err := s.MembershipRepository.Delete(ctx, membership); err != nil {
+			log.Error("Failed to remove member", zap.Object("membership", membership), zap.Error(err))
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Info("Member removed", zap.Object("membership", membership), zap.Object("removed_by", user))
+	return nil
+}
+
+// Returns the user's membership of the organization, reporting organizations they are not in as not found.
+func (s *organizationService) getMembership(ctx context.Context, organizationID uint, userID uint) (*model.Membership, error) {
+	log := logger.GetFromContext(ctx)
+
+	membership, err := s.MembershipRepository.Get(ctx, organizationID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Warn("Membership not found", zap.Uint("organization_id", organizationID), zap.Uint("user_id", userID))
+			return nil, apiErr.NewMembershipNotFoundError(err)
+		}
+		log.Error("Failed to get membership", zap.Uint("organization_id", organizationID), zap.Uint("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	return membership, nil
+}
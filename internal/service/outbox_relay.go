@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/events"
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"go.uber.org/zap"
+)
+
+type OutboxRelayConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+}
+
+// OutboxRelay publishes the domain events written to the outbox. Every instance of the application can run one, as
+// only one relay publishes at a time.
+type OutboxRelay interface {
+	// Run relays pending events every poll interval until the context is cancelled, finishing the batch in flight.
+	Run(ctx context.Context)
+	// RelayPending publishes one batch of due events and returns how many were published.
+	RelayPending(ctx context.Context) (int, error)
+}
+
+type outboxRelay struct {
+	OutboxRepository repository.OutboxRepository
+	Publisher        events.EventPublisher
+	Config           OutboxRelayConfig
+}
+
+var _ OutboxRelay = &outboxRelay{}
+
+func NewOutboxRelay(outboxRepository repository.OutboxRepository, publisher events.EventPublisher, config OutboxRelayConfig) OutboxRelay {
+	return &outboxRelay{
+		OutboxRepository: outboxRepository,
+		Publisher:        publisher,
+		Config:           config,
+	}
+}
+
+func (r *outboxRelay) Run(ctx context.Context) {
+	log := logger.GetFromContext(ctx)
+
+	log.Info("Outbox relay started", zap.Duration("poll_interval", r.Config.PollInterval))
+	ticker := time.NewTicker(r.Config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("Outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+
+		// Keep going while full batches are published, so a backlog drains without waiting for the next tick.
+		for ctx.Err() == nil {
+			published, err := r.RelayPending(context.WithoutCancel(ctx))
+			if err != nil {
+				log.Error("Failed to relay outbox events", zap.Error(err))
+			}
+			if err != nil || published < r.Config.BatchSize {
+				break
+			}
+		}
+	}
+}
+
+func (r *outboxRelay) RelayPending(ctx context.Context) (int, error) {
+	published := 0
+	_, err := r.OutboxRepository.Relay(ctx, r.Config.BatchSize, func(events model.OutboxEvents) model.OutboxEvents {
+		processed := r.publishInOrder(ctx, events)
+		for _, event := range processed {
+			if event.PublishedAt != nil {
+				published++
+			}
+		}
+		return processed
+	})
+	if err != nil {
+		return 0, err
+	}
+	return published, nil
+}
+
+// Publishes the events one at a time in order. Once an event of an aggregate fails, the aggregate's later events
+// are left for the next batch so they are not published before it. Returns the events that were attempted.
+func (r *outboxRelay) publishInOrder(ctx context.Context, events model.OutboxEvents) model.OutboxEvents {
+	log := logger.GetFromContext(ctx)
+
+	blocked := make(map[string]bool)
+	processed := make(model.OutboxEvents, 0, len(events))
+	for _, event := range events {
+		if blocked[event.AggregateKey()] {
+			continue
+		}
+
+		if err := r.Publisher.Publish(ctx, event.ToDomainEvent()); err != nil {
+			event.RecordFailure(err, time.Now().Add(exponentialBackoff(r.Config.BaseBackoff, r.Config.MaxBackoff, event.Attempts+1)))
+			blocked[event.AggregateKey()] = true
+			log.Warn("Failed to publish outbox event, will retry", zap.Object("event", event), zap.Time("next_attempt_at", event.NextAttemptAt), zap.Error(err))
+		} else {
+			event.MarkPublished(time.Now())
+			log.Debug("Outbox event published", zap.Object("event", event))
+		}
+		processed = append(processed, event)
+	}
+	return processed
+}
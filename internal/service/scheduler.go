@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/cron"
+	apiErr "github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/Verano-20/stage-zero/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+type SchedulerConfig struct {
+	PollInterval time.Duration
+	Timeout      time.Duration
+	// Instance names this instance of the application in the run history.
+	Instance string
+}
+
+// ScheduledTaskFunc runs a scheduled task. It should stop when the context is cancelled, which it is once the task
+// times out or the application shuts down.
+type ScheduledTaskFunc func(ctx context.Context) error
+
+// Scheduler runs the tasks registered with it on their cron schedules. Every instance of the application can run
+// one, as only the instance holding the scheduler lock runs tasks, and each run is claimed in the database, so a task
+// runs once per scheduled time. A run missed while no instance was leading is made up once, not once per time missed.
+type Scheduler interface {
+	// Register adds a task, which must be done before the scheduler is run. It panics if the schedule never fires.
+	Register(name string, schedule *cron.Schedule, task ScheduledTaskFunc)
+	// Run tries to become the leader every poll interval, running the due tasks while leading, until the context is
+	// cancelled. It then lets another instance lead.
+	Run(ctx context.Context)
+	// RunDue runs the due tasks one after the other if this instance is the leader, and returns how many it ran.
+	RunDue(ctx context.Context) (int, error)
+	// IsLeader reports whether this instance held the scheduler lock when it last checked.
+	IsLeader() bool
+	Instance() string
+	ListTasks(ctx context.Context) (model.ScheduledTasks, error)
+	// ListRuns returns the latest runs of a registered task, newest first.
+	ListRuns(ctx context.Context, name string, limit int) (model.ScheduledTaskRuns, error)
+}
+
+type scheduledTaskRegistration struct {
+	schedule *cron.Schedule
+	task     ScheduledTaskFunc
+}
+
+type scheduler struct {
+	ScheduledTaskRepository repository.ScheduledTaskRepository
+	SchedulerLock           repository.SchedulerLock
+	Config                  SchedulerConfig
+
+	mutex         sync.RWMutex
+	registrations map[string]*scheduledTaskRegistration
+
+	// Serializes RunDue, which owns the lock and synced.
+	runMutex sync.Mutex
+	synced   bool
+	leader   atomic.Bool
+}
+
+var _ Scheduler = &scheduler{}
+
+func NewScheduler(scheduledTaskRepository repository.ScheduledTaskRepository, schedulerLock repository.SchedulerLock, config SchedulerConfig) Scheduler {
+	return &scheduler{
+		ScheduledTaskRepository: scheduledTaskRepository,
+		SchedulerLock:           schedulerLock,
+		Config:                  config,
+		registrations:           make(map[string]*scheduledTaskRegistration),
+	}
+}
+
+func (s *scheduler) Register(name string, schedule *cron.Schedule, task ScheduledTaskFunc) {
+	if schedule.Next(time.Now()).IsZero() {
+		panic(fmt.Sprintf("schedule %q of task %s never fires", schedule, name))
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.registrations[name] = &scheduledTaskRegistration{schedule: schedule, task: task}
+}
+
+func (s *scheduler) Run(ctx context.Context) {
+	log := logger.GetFromContext(ctx)
+
+	log.Info("Scheduler started", zap.String("instance", s.Config.Instance), zap.Duration("poll_interval", s.Config.PollInterval), zap.Strings("tasks", s.names()))
+	ticker := time.NewTicker(s.Config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.resign(context.WithoutCancel(ctx))
+			log.Info("Scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := s.RunDue(ctx); err != nil {
+			log.Error("Failed to run scheduled tasks", zap.Error(err))
+		}
+	}
+}
+
+func (s *scheduler) RunDue(ctx context.Context) (int, error) {
+	s.runMutex.Lock()
+	defer s.runMutex.Unlock()
+
+	if leading, err := s.lead(ctx); err != nil || !leading {
+		return 0, err
+	}
+
+	tasks, err := s.ScheduledTaskRepository.ListAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	ran := 0
+	now := time.Now()
+	for _, task := range tasks {
+		registration := s.registration(task.Name)
+		if registration == nil || task.NextRunAt.After(now) || ctx.Err() != nil {
+			continue
+		}
+		if s.run(ctx, task, registration) {
+			ran++
+		}
+	}
+	return ran, nil
+}
+
+func (s *scheduler) IsLeader() bool {
+	return s.leader.Load()
+}
+
+func (s *scheduler) Instance() string {
+	return s.Config.Instance
+}
+
+func (s *scheduler) ListTasks(ctx context.Context) (model.ScheduledTasks, error) {
+	return s.ScheduledTaskRepository.ListAll(ctx)
+}
+
+func (s *scheduler) ListRuns(ctx context.Context, name string, limit int) (model.ScheduledTaskRuns, error) {
+	if s.registration(name) == nil {
+		return nil, apiErr.NewTaskNotFoundError(fmt.Errorf("scheduled task %s not found", name))
+	}
+	return s.ScheduledTaskRepository.ListRuns(ctx, name, limit)
+}
+
+// Takes or checks the scheduler lock and reports whether this instance leads. A new leader first stores the tasks
+// registered with it, as the instance last leading may have run a different version of the application.
+func (s *scheduler) lead(ctx context.Context) (bool, error) {
+	log := logger.GetFromContext(ctx)
+
+	leading, err := s.SchedulerLock.Acquire(ctx)
+	if err != nil || !leading {
+		s.setLeader(ctx, false)
+		return false, err
+	}
+	if s.setLeader(ctx, true) {
+		log.Info("Elected scheduler leader", zap.String("instance", s.Config.Instance))
+		s.synced = false
+	}
+
+	if !s.synced {
+		if err := s.ScheduledTaskRepository.Sync(ctx, s.tasks(time.Now())); err != nil {
+			return false, err
+		}
+		s.synced = true
+	}
+	return true, nil
+}
+
+// Records whether this instance leads, and reports whether that changed.
+func (s *scheduler) setLeader(ctx context.Context, leading bool) bool {
+	if s.leader.Swap(leading) == leading {
+		return false
+	}
+
+	delta := int64(1)
+	if !leading {
+		delta = -1
+		logger.GetFromContext(ctx).Warn("No longer the scheduler leader", zap.String("instance", s.Config.Instance))
+	}
+	telemetry.GetMetrics().RecordSchedulerLeader(ctx, delta)
+	return true
+}
+
+func (s *scheduler) resign(ctx context.Context) {
+	s.runMutex.Lock()
+	defer s.runMutex.Unlock()
+
+	if err := s.SchedulerLock.Release(ctx); err != nil {
+		logger.GetFromContext(ctx).Error("Failed to release scheduler lock", zap.Error(err))
+	}
+	if s.leader.Swap(false) {
+		telemetry.GetMetrics().RecordSchedulerLeader(ctx, -1)
+	}
+}
+
+// Claims the due run of the task, runs it and records its outcome. Returns whether the task was run.
+func (s *scheduler) run(ctx context.Context, task *model.ScheduledTask, registration *scheduledTaskRegistration) bool {
+	log := logger.GetFromContext(ctx)
+
+	nextRunAt := registration.schedule.Next(time.Now())
+	if nextRunAt.IsZero() {
+		log.Error("Schedule of task no longer fires", zap.String("task", task.Name), zap.Stringer("schedule", registration.schedule))
+		return false
+	}
+
+	run := &model.ScheduledTaskRun{
+		TaskName:     task.Name,
+		ScheduledFor: task.NextRunAt,
+		Instance:     s.Config.Instance,
+		StartedAt:    time.Now(),
+	}
+	started, startErr := s.ScheduledTaskRepository.Start(ctx, run, nextRunAt)
+	if startErr != nil {
+		log.Error("Failed to start scheduled task", zap.String("task", task.Name), zap.Error(startErr))
+		return false
+	}
+	if !started {
+		log.Debug("Scheduled task already started", zap.String("task", task.Name), zap.Time("scheduled_for", run.ScheduledFor))
+		return false
+	}
+
+	taskErr := s.execute(ctx, registration)
+	run.Finish(taskErr, time.Now())
+	telemetry.GetMetrics().RecordScheduledTaskRun(ctx, run.TaskName, run.Outcome, float64(run.DurationMs)/1000)
+
+	if err := s.ScheduledTaskRepository.Finish(context.WithoutCancel(ctx), run); err != nil {
+		log.Error("Failed to save scheduled task outcome", zap.Object("run", run), zap.Error(err))
+	}
+	if taskErr != nil {
+		log.Error("Scheduled task failed", zap.Object("run", run), zap.Time("next_run_at", nextRunAt), zap.Error(taskErr))
+	} else {
+		log.Info("Scheduled task succeeded", zap.Object("run", run), zap.Time("next_run_at", nextRunAt))
+	}
+	return true
+}
+
+// Calls the task with the task timeout, turning a panic into an error so it does not take down the scheduler.
+func (s *scheduler) execute(ctx context.Context, registration *scheduledTaskRegistration) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.Config.Timeout)
+	defer cancel()
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("task panicked: %v", recovered)
+		}
+	}()
+	return registration.task(ctx)
+}
+
+func (s *scheduler) registration(name string) *scheduledTaskRegistration {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.registrations[name]
+}
+
+// Returns the registered tasks as stored when they are first added, due at their next scheduled time.
+func (s *scheduler) tasks(now time.Time) model.ScheduledTasks {
+	names := s.names()
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	tasks := make(model.ScheduledTasks, 0, len(names))
+	for _, name := range names {
+		schedule := s.registrations[name].schedule
+		tasks = append(tasks, &model.ScheduledTask{Name: name, Schedule: schedule.String(), NextRunAt: schedule.Next(now)})
+	}
+	return tasks
+}
+
+func (s *scheduler) names() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	names := make([]string, 0, len(s.registrations))
+	for name := range s.registrations {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
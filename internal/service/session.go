@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	apiErr "github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type SessionService interface {
+	CreateSession(ctx context.Context, user *model.User, expiresAt time.Time) (*model.Session, error)
+	ListSessions(ctx context.Context, user *model.User) (model.Sessions, error)
+	RevokeSession(ctx context.Context, user *model.User, sessionID uint) error
+	EndSession(ctx context.Context, userID uint, sessionID uint) error
+}
+
+type sessionService struct {
+	SessionRepository repository.SessionRepository
+	AuditLogger       AuditLogger
+}
+
+var _ SessionService = &sessionService{}
+
+func NewSessionService(sessionRepository repository.SessionRepository, auditLogger AuditLogger) SessionService {
+	return &sessionService{SessionRepository: sessionRepository, AuditLogger: auditLogger}
+}
+
+// CreateSession records a login from the device making the request.
+func (s *sessionService) CreateSession(ctx context.Context, user *model.User, expiresAt time.Time) (*model.Session, error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Creating session...", zap.Object("user", user))
+
+	now := time.Now()
+	session := &model.Session{UserID: user.ID, CreatedAt: now, LastSeenAt: now, ExpiresAt: expiresAt}
+	if request := utils.GetRequestInfo(ctx); request != nil {
+		session.ClientIP = request.ClientIP
+		session.UserAgent = request.UserAgent
+	}
+
+	session, err := s.SessionRepository.Create(ctx, session)
+	if err != nil {
+		log.Error("Failed to create session", zap.Object("user", user), zap.Error(err))
+		return nil, err
+	}
+
+	log.Debug("Session created successfully", zap.Object("session", session))
+	return session, nil
+}
+
+// ListSessions returns the user's active sessions. Sessions started before the user's tokens were last revoked,
+// e.g. by a password change, are left out as their tokens no longer work.
+func (s *sessionService) ListSessions(ctx context.Context, user *model.User) (model.Sessions, error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Listing sessions...", zap.Object("user", user))
+
+	sessions, err := s.SessionRepository.ListActiveByUserID(ctx, user.ID)
+	if err != nil {
+		log.Error("Failed to list sessions", zap.Object("user", user), zap.Error(err))
+		return nil, err
+	}
+
+	activeSessions := model.Sessions{}
+	for _, session := range sessions {
+		if session.IsActive(user) {
+			activeSessions = append(activeSessions, session)
+		}
+	}
+
+	log.Debug("Sessions listed successfully", zap.Object("user", user), zap.Int("count", len(activeSessions)))
+	return activeSessions, nil
+}
+
+// RevokeSession logs the device out. Sessions belonging to other users are reported as not found.
+func (s *sessionService) RevokeSession(ctx context.Context, user *model.User, sessionID uint) error {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Revoking session...", zap.Object("user", user), zap.Uint("session_id", sessionID))
+
+	session, err := s.SessionRepository.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Warn("Session not found", zap.Uint("session_id", sessionID))
+			return apiErr.NewSessionNotFoundError(err)
+		}
+		log.Error("Failed to get session", zap.Uint("session_id", sessionID), zap.Error(err))
+		return err
+	}
+
+	if session.UserID != user.ID || !session.IsActive(user) {
+		log.Warn("Session not found for user", zap.Object("user", user), zap.Object("session", session))
+		return apiErr.NewSessionNotFoundError(errors.New("session not found"))
+	}
+
+	err = s.SessionRepository.Revoke(ctx, session.ID)
+	s.recordAudit(ctx, session.ID, err)
+	if err != nil {
+		log.Error("Failed to revoke session", zap.Object("session", session), zap.Error(err))
+		return err
+	}
+
+	log.Info("Session revoked", zap.Object("session", session))
+	return nil
+}
+
+// EndSession revokes the session a user is logging out of. Sessions that are already revoked, or belong to another
+// user, are left alone.
+func (s *sessionService) EndSession(ctx context.Context, userID uint, sessionID uint) error {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Ending session...", zap.Uint("user_id", userID), zap.Uint("session_id", sessionID))
+
+	session, err := s.SessionRepository.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Warn("Session not found", zap.Uint("session_id", sessionID))
+			return nil
+		}
+		log.Error("Failed to get session", zap.Uint("session_id", sessionID), zap.Error(err))
+		return err
+	}
+
+	if session.UserID != userID || session.RevokedAt != nil {
+		log.Debug("Session already ended", zap.Object("session", session))
+		return nil
+	}
+
+	err = s.SessionRepository.Revoke(ctx, session.ID)
+	s.recordAudit(ctx, session.ID, err)
+	if err != nil {
+		log.Error("Failed to end session", zap.Object("session", session), zap.Error(err))
+		return err
+	}
+
+	log.Info("Session ended", zap.Object("session", session))
+	return nil
+}
+
+func (s *sessionService) recordAudit(ctx context.Context, id uint, err error) {
+	event := &model.AuditEvent{
+		Action:     model.AuditActionSessionRevoke,
+		TargetType: model.AuditTargetTypeSession,
+		TargetID:   strconv.FormatUint(uint64(id), 10),
+		Outcome:    model.AuditOutcomeSuccess,
+	}
+	if err != nil {
+		event.Outcome = model.AuditOutcomeFailure
+		event.Reason = err.Error()
+	}
+	s.AuditLogger.Record(ctx, event)
+}
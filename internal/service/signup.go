@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"go.uber.org/zap"
+)
+
+type SignUpService interface {
+	SignUp(ctx context.Context, signUpForm model.SignUpForm, secret []byte) (*model.User, *model.Membership, error)
+}
+
+type signUpService struct {
+	TxManager           repository.TxManager
+	UserService         UserService
+	OrganizationService OrganizationService
+	InvitationService   InvitationService
+}
+
+var _ SignUpService = &signUpService{}
+
+func NewSignUpService(txManager repository.TxManager, userService UserService, organizationService OrganizationService, invitationService InvitationService) SignUpService {
+	return &signUpService{
+		TxManager:           txManager,
+		UserService:         userService,
+		OrganizationService: organizationService,
+		InvitationService:   invitationService,
+	}
+}
+
+// SignUp creates the user in one transaction with the inviting organization's membership, or a personal organization.
+func (s *signUpService) SignUp(ctx context.Context, signUpForm model.SignUpForm, secret []byte) (*model.User, *model.Membership, error) {
+	log := logger.GetFromContext(ctx)
+
+	var user *model.User
+	var membership *model.Membership
+	err := s.TxManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if signUpForm.InvitationToken != "" {
+			user, membership, err = s.InvitationService.SignUpWithInvitation(ctx, signUpForm, secret)
+		} else {
+			user, err = s.UserService.CreateUser(ctx, signUpForm.UserForm)
+		}
+		if err != nil || membership != nil {
+			return err
+		}
+
+		_, err = s.OrganizationService.CreateOrganization(ctx, user, model.OrganizationForm{Name: user.Email})
+		return err
+	})
+	if err != nil {
+		log.Debug("Sign up rolled back", zap.Object("user", &signUpForm.UserForm), zap.Error(err))
+		return nil, nil, err
+	}
+
+	log.Info("User signed up", zap.Object("user", user))
+	return user, membership, nil
+}
@@ -1,64 +1,140 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"strconv"
+
+	apiErr "github.com/Verano-20/stage-zero/internal/err"
 	"github.com/Verano-20/stage-zero/internal/logger"
 	"github.com/Verano-20/stage-zero/internal/model"
 	"github.com/Verano-20/stage-zero/internal/repository"
-	"github.com/gin-gonic/gin"
+	"github.com/Verano-20/stage-zero/internal/utils"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type SimpleService interface {
-	CreateSimple(ctx *gin.Context, simpleForm model.SimpleForm) (*model.Simple, error)
-	GetAllSimples(ctx *gin.Context) (model.Simples, error)
-	GetSimpleByID(ctx *gin.Context, id uint64) (*model.Simple, error)
-	UpdateSimple(ctx *gin.Context, existingSimple *model.Simple, simpleForm model.SimpleForm) (*model.Simple, error)
-	DeleteSimple(ctx *gin.Context, existingSimple *model.Simple) error
+	CreateSimple(ctx context.Context, simpleForm model.SimpleForm) (*model.Simple, error)
+	GetAllSimples(ctx context.Context, filter model.SimpleFilter) (model.Simples, error)
+	GetSimpleByID(ctx context.Context, id uint64) (*model.Simple, error)
+	UpdateSimple(ctx context.Context, existingSimple *model.Simple, simpleForm model.SimpleForm) (*model.Simple, error)
+	DeleteSimple(ctx context.Context, existingSimple *model.Simple) error
+	ListShares(ctx context.Context, existingSimple *model.Simple) (model.SimpleGrants, error)
+	ShareSimple(ctx context.Context, existingSimple *model.Simple, shareForm model.SimpleShareForm) (*model.SimpleGrant, error)
+	UnshareSimple(ctx context.Context, existingSimple *model.Simple, userID uint) error
+	SuggestTags(ctx context.Context, filter model.TagSuggestionFilter) ([]*model.TagCount, error)
 }
 
+// simpleService only returns Simples the authenticated user can see: the ones they own, the ones shared with them,
+// and every Simple of the organization for its owners and admins. Simples the user cannot see are reported as not
+// found, so their existence is not leaked.
 type simpleService struct {
-	SimpleRepository repository.SimpleRepository
+	TxManager             repository.TxManager
+	SimpleRepository      repository.SimpleRepository
+	SimpleGrantRepository repository.SimpleGrantRepository
+	TagRepository         repository.TagRepository
+	MembershipRepository  repository.MembershipRepository
+	UserService           UserService
+	MetadataSchemaService MetadataSchemaService
+	WebhookService        WebhookService
+	AuditLogger           AuditLogger
 }
 
 var _ SimpleService = &simpleService{}
 
-func NewSimpleService(simpleRepository repository.SimpleRepository) SimpleService {
-	return &simpleService{SimpleRepository: simpleRepository}
+func NewSimpleService(txManager repository.TxManager, simpleRepository repository.SimpleRepository, simpleGrantRepository repository.SimpleGrantRepository, tagRepository repository.TagRepository, membershipRepository repository.MembershipRepository, userService UserService, metadataSchemaService MetadataSchemaService, webhookService WebhookService, auditLogger AuditLogger) SimpleService {
+	return &simpleService{
+		TxManager:             txManager,
+		SimpleRepository:      simpleRepository,
+		SimpleGrantRepository: simpleGrantRepository,
+		TagRepository:         tagRepository,
+		MembershipRepository:  membershipRepository,
+		UserService:           userService,
+		MetadataSchemaService: metadataSchemaService,
+		WebhookService:        webhookService,
+		AuditLogger:           auditLogger,
+	}
 }
 
-func (s *simpleService) CreateSimple(ctx *gin.Context, simpleForm model.SimpleForm) (*model.Simple, error) {
+func (s *simpleService) CreateSimple(ctx context.Context, simpleForm model.SimpleForm) (*model.Simple, error) {
 	log := logger.GetFromContext(ctx)
 
 	log.Debug("Creating Simple...", zap.Object("simple", &simpleForm))
 
-	simple, err := s.SimpleRepository.Create(ctx, simpleForm.ToModel())
+	if err := s.MetadataSchemaService.ValidateMetadata(ctx, utils.GetOrganizationID(ctx), simpleForm.Kind, simpleForm.Metadata); err != nil {
+		s.recordAudit(ctx, model.AuditActionSimpleCreate, 0, err)
+		return nil, err
+	}
+
+	newSimple := simpleForm.ToModel()
+	if user := utils.GetAuthenticatedUser(ctx); user != nil {
+		newSimple.OwnerID = &user.ID
+	}
+	if names := model.NormalizeTagNames(simpleForm.Tags); len(names) > 0 {
+		tags, err := s.TagRepository.FindOrCreate(ctx, names)
+		if err != nil {
+			log.Error("Failed to resolve Simple tags", zap.Object("simple", &simpleForm), zap.Error(err))
+			s.recordAudit(ctx, model.AuditActionSimpleCreate, 0, err)
+			return nil, err
+		}
+		newSimple.Tags = tags
+	}
+
+	var simple *model.Simple
+	err := s.TxManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		simple, err = s.SimpleRepository.Create(ctx, newSimple)
+		if err != nil {
+			log.Error("Failed to create Simple",
+				zap.Object("simple", &simpleForm),
+				zap.Error(err))
+			return err
+		}
+		return s.WebhookService.EnqueueSimpleEvent(ctx, model.WebhookEventSimpleCreated, simple)
+	})
 	if err != nil {
-		log.Error("Failed to create Simple",
-			zap.Object("simple", &simpleForm),
-			zap.Error(err))
+		s.recordAudit(ctx, model.AuditActionSimpleCreate, 0, err)
 		return nil, err
 	}
+	s.recordAudit(ctx, model.AuditActionSimpleCreate, simple.ID, nil)
+	s.resolveAccess(ctx, simple)
 
 	log.Debug("Simple created successfully", zap.Object("simple", simple))
 	return simple, nil
 }
 
-func (s *simpleService) GetAllSimples(ctx *gin.Context) (model.Simples, error) {
+func (s *simpleService) GetAllSimples(ctx context.Context, filter model.SimpleFilter) (model.Simples, error) {
 	log := logger.GetFromContext(ctx)
 
-	log.Debug("Retrieving all Simples")
+	filter.Normalize()
+	log.Debug("Retrieving all Simples", zap.Object("filter", &filter))
 
-	simples, err := s.SimpleRepository.GetAll(ctx)
+	var simples model.Simples
+	var err error
+	if canSeeAllSimples(ctx) {
+		simples, err = s.SimpleRepository.GetAll(ctx, filter)
+	} else {
+		simples, err = s.SimpleRepository.GetAllAccessibleBy(ctx, authenticatedUserID(ctx), filter)
+	}
 	if err != nil {
 		log.Error("Failed to retrieve Simples", zap.Error(err))
 		return nil, err
 	}
 
+	accessible := make(model.Simples, 0, len(simples))
+	for _, simple := range simples {
+		if s.resolveAccess(ctx, simple) {
+			accessible = append(accessible, simple)
+		}
+	}
+	simples = accessible
+
 	log.Debug("Simples retrieved successfully", zap.Int("count", len(simples)))
 	return simples, nil
 }
 
-func (s *simpleService) GetSimpleByID(ctx *gin.Context, id uint64) (*model.Simple, error) {
+func (s *simpleService) GetSimpleByID(ctx context.Context, id uint64) (*model.Simple, error) {
 	log := logger.GetFromContext(ctx)
 
 	log.Debug("Retrieving Simple by ID", zap.Uint64("id", id))
@@ -68,26 +144,71 @@ func (s *simpleService) GetSimpleByID(ctx *gin.Context, id uint64) (*model.Simpl
 		log.Warn("Simple not found", zap.Uint64("id", id), zap.Error(err))
 		return nil, err
 	}
+	if !s.resolveAccess(ctx, simple) {
+		log.Warn("Simple not accessible to user", zap.Uint64("id", id))
+		return nil, gorm.ErrRecordNotFound
+	}
 
 	log.Debug("Simple retrieved successfully", zap.Object("simple", simple))
 	return simple, nil
 }
 
-func (s *simpleService) UpdateSimple(ctx *gin.Context, existingSimple *model.Simple, simpleForm model.SimpleForm) (*model.Simple, error) {
+func (s *simpleService) UpdateSimple(ctx context.Context, existingSimple *model.Simple, simpleForm model.SimpleForm) (*model.Simple, error) {
 	log := logger.GetFromContext(ctx)
 
 	log.Debug("Updating Simple",
 		zap.Object("existing", existingSimple),
 		zap.Object("update", &simpleForm))
 
+	if err := authorizeSimple(ctx, existingSimple, model.SimplePermissionWrite); err != nil {
+		log.Warn("Not allowed to update Simple", zap.Object("simple", existingSimple))
+		s.recordAudit(ctx, model.AuditActionSimpleUpdate, existingSimple.ID, err)
+		return nil, err
+	}
+
+	// Metadata and tags are only replaced when the form carries them.
+	if simpleForm.Metadata != nil {
+		if err := s.MetadataSchemaService.ValidateMetadata(ctx, existingSimple.OrganizationID, existingSimple.Kind, simpleForm.Metadata); err != nil {
+			s.recordAudit(ctx, model.AuditActionSimpleUpdate, existingSimple.ID, err)
+			return nil, err
+		}
+	}
+
+	var tags model.Tags
+	if simpleForm.Tags != nil {
+		var err error
+		tags, err = s.TagRepository.FindOrCreate(ctx, model.NormalizeTagNames(simpleForm.Tags))
+		if err != nil {
+			log.Error("Failed to resolve Simple tags", zap.Object("update", &simpleForm), zap.Error(err))
+			s.recordAudit(ctx, model.AuditActionSimpleUpdate, existingSimple.ID, err)
+			return nil, err
+		}
+	}
+
 	existingSimple.Name = simpleForm.Name
+	if simpleForm.Metadata != nil {
+		existingSimple.Metadata = simpleForm.Metadata
+	}
+
+	var simple *model.Simple
+	err := s.TxManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		simple, err = s.SimpleRepository.Update(ctx, existingSimple, tags)
+		if err != nil {
+			log.Error("Failed to update Simple",
+				zap.Object("existing", existingSimple),
+				zap.Object("update", &simpleForm),
+				zap.Error(err))
+			return err
+		}
 
-	simple, err := s.SimpleRepository.Update(ctx, existingSimple)
+		if tags != nil {
+			simple.Tags = tags
+		}
+		return s.WebhookService.EnqueueSimpleEvent(ctx, model.WebhookEventSimpleUpdated, simple)
+	})
+	s.recordAudit(ctx, model.AuditActionSimpleUpdate, existingSimple.ID, err)
 	if err != nil {
-		log.Error("Failed to update Simple",
-			zap.Object("existing", existingSimple),
-			zap.Object("update", &simpleForm),
-			zap.Error(err))
 		return nil, err
 	}
 
@@ -95,19 +216,188 @@ func (s *simpleService) UpdateSimple(ctx *gin.Context, existingSimple *model.Sim
 	return simple, nil
 }
 
-func (s *simpleService) DeleteSimple(ctx *gin.Context, existingSimple *model.Simple) error {
+func (s *simpleService) DeleteSimple(ctx context.Context, existingSimple *model.Simple) error {
 	log := logger.GetFromContext(ctx)
 
 	log.Debug("Deleting Simple", zap.Object("simple", existingSimple))
 
-	err := s.SimpleRepository.Delete(ctx, existingSimple.ID)
+	if err := authorizeSimple(ctx, existingSimple, model.SimplePermissionManage); err != nil {
+		log.Warn("Not allowed to delete Simple", zap.Object("simple", existingSimple))
+		s.recordAudit(ctx, model.AuditActionSimpleDelete, existingSimple.ID, err)
+		return err
+	}
+
+	err := s.TxManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.SimpleRepository.Delete(ctx, existingSimple.ID); err != nil {
+			log.Error("Failed to delete Simple",
+				zap.Object("simple", existingSimple),
+				zap.Error(err))
+			return err
+		}
+		return s.WebhookService.EnqueueSimpleEvent(ctx, model.WebhookEventSimpleDeleted, existingSimple)
+	})
+	s.recordAudit(ctx, model.AuditActionSimpleDelete, existingSimple.ID, err)
 	if err != nil {
-		log.Error("Failed to delete Simple",
-			zap.Object("simple", existingSimple),
-			zap.Error(err))
 		return err
 	}
 
 	log.Debug("Simple deleted successfully", zap.Object("simple", existingSimple))
 	return nil
 }
+
+func (s *simpleService) ListShares(ctx context.Context, existingSimple *model.Simple) (model.SimpleGrants, error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Listing Simple shares", zap.Object("simple", existingSimple))
+
+	if err := authorizeSimple(ctx, existingSimple, model.SimplePermissionManage); err != nil {
+		log.Warn("Not allowed to list Simple shares", zap.Object("simple", existingSimple))
+		return nil, err
+	}
+
+	grants, err := s.SimpleGrantRepository.ListBySimpleID(ctx, existingSimple.ID)
+	if err != nil {
+		log.Error("Failed to list Simple shares", zap.Object("simple", existingSimple), zap.Error(err))
+		return nil, err
+	}
+
+	log.Debug("Simple shares listed successfully", zap.Int("count", len(grants)))
+	return grants, nil
+}
+
+// ShareSimple grants a member of the Simple's organization read or write access, or changes the permission they
+// already have. Only those who can manage the Simple may share it.
+func (s *simpleService) ShareSimple(ctx context.Context, existingSimple *model.Simple, shareForm model.SimpleShareForm) (*model.SimpleGrant, error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Sharing Simple", zap.Object("simple", existingSimple), zap.Object("share", &shareForm))
+
+	if err := authorizeSimple(ctx, existingSimple, model.SimplePermissionManage); err != nil {
+		log.Warn("Not allowed to share Simple", zap.Object("simple", existingSimple))
+		s.recordAudit(ctx, model.AuditActionSimpleShare, existingSimple.ID, err)
+		return nil, err
+	}
+
+	grantee, err := s.UserService.GetUserByEmail(ctx, shareForm.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apiErr.NewUserNotFoundError(err)
+		}
+		return nil, err
+	}
+	if existingSimple.OwnerID != nil && *existingSimple.OwnerID == grantee.ID {
+		return nil, apiErr.NewShareInvalidError(errors.New("user already owns the Simple"))
+	}
+	if _, err := s.MembershipRepository.Get(ctx, existingSimple.OrganizationID, grantee.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apiErr.NewShareInvalidError(errors.New("user is not a member of the organization"))
+		}
+		log.Error("Failed to check grantee membership", zap.Object("simple", existingSimple), zap.Object("grantee", grantee), zap.Error(err))
+		return nil, err
+	}
+
+	grantedByID := authenticatedUserID(ctx)
+	grant, err := s.SimpleGrantRepository.Upsert(ctx, &model.SimpleGrant{
+		SimpleID:    existingSimple.ID,
+		UserID:      grantee.ID,
+		Permission:  shareForm.Permission,
+		GrantedByID: &grantedByID,
+	})
+	s.recordAudit(ctx, model.AuditActionSimpleShare, existingSimple.ID, err)
+	if err != nil {
+		log.Error("Failed to share Simple", zap.Object("simple", existingSimple), zap.Object("share", &shareForm), zap.Error(err))
+		return nil, err
+	}
+	grant.User = grantee
+
+	log.Info("Simple shared successfully", zap.Object("grant", grant))
+	return grant, nil
+}
+
+// UnshareSimple removes a user's access to the Simple. Those who can manage the Simple may remove anyone's access,
+// and users may always remove their own.
+func (s *simpleService) UnshareSimple(ctx context.Context, existingSimple *model.Simple, userID uint) error {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Unsharing Simple", zap.Object("simple", existingSimple), zap.Uint("user_id", userID))
+
+	if userID != authenticatedUserID(ctx) {
+		if err := authorizeSimple(ctx, existingSimple, model.SimplePermissionManage); err != nil {
+			log.Warn("Not allowed to unshare Simple", zap.Object("simple", existingSimple))
+			s.recordAudit(ctx, model.AuditActionSimpleUnshare, existingSimple.ID, err)
+			return err
+		}
+	}
+
+	err := s.SimpleGrantRepository.Delete(ctx, existingSimple.ID, userID)
+	s.recordAudit(ctx, model.AuditActionSimpleUnshare, existingSimple.ID, err)
+	if err != nil {
+		log.Warn("Failed to unshare Simple", zap.Object("simple", existingSimple), zap.Uint("user_id", userID), zap.Error(err))
+		return err
+	}
+
+	log.Info("Simple unshared successfully", zap.Object("simple", existingSimple), zap.Uint("user_id", userID))
+	return nil
+}
+
+// SuggestTags returns the organization's tags matching the filter's prefix, for autocomplete. Usage counts only
+// include the Simples the user can see.
+func (s *simpleService) SuggestTags(ctx context.Context, filter model.TagSuggestionFilter) ([]*model.TagCount, error) {
+	log := logger.GetFromContext(ctx)
+
+	filter.Normalize()
+	log.Debug("Suggesting tags", zap.String("query", filter.Query), zap.Int("limit", filter.Limit))
+
+	var accessibleBy *uint
+	if !canSeeAllSimples(ctx) {
+		userID := authenticatedUserID(ctx)
+		accessibleBy = &userID
+	}
+
+	tagCounts, err := s.TagRepository.Suggest(ctx, filter, accessibleBy)
+	if err != nil {
+		log.Error("Failed to suggest tags", zap.String("query", filter.Query), zap.Error(err))
+		return nil, err
+	}
+
+	log.Debug("Tags suggested successfully", zap.Int("count", len(tagCounts)))
+	return tagCounts, nil
+}
+
+// Resolves the authenticated user's permission on the Simple and reports whether they can see it.
+func (s *simpleService) resolveAccess(ctx context.Context, simple *model.Simple) bool {
+	return simple.ResolveAccess(authenticatedUserID(ctx), utils.GetMembership(ctx))
+}
+
+// Fails unless the authenticated user has the permission on the Simple.
+func authorizeSimple(ctx context.Context, simple *model.Simple, permission string) error {
+	if !simple.ResolveAccess(authenticatedUserID(ctx), utils.GetMembership(ctx)) || !simple.Allows(permission) {
+		return apiErr.NewSimpleAccessError(errors.New("missing " + permission + " permission on Simple"))
+	}
+	return nil
+}
+
+// Reports whether the authenticated user is an owner or admin of the organization, who can see all its Simples.
+func canSeeAllSimples(ctx context.Context) bool {
+	membership := utils.GetMembership(ctx)
+	return membership != nil && membership.CanManageMembers()
+}
+
+func authenticatedUserID(ctx context.Context) uint {
+	if user := utils.GetAuthenticatedUser(ctx); user != nil {
+		return user.ID
+	}
+	return 0
+}
+
+func (s *simpleService) recordAudit(ctx context.Context, action string, id uint, err error) {
+	event := &model.AuditEvent{Action: action, TargetType: model.AuditTargetTypeSimple, Outcome: model.AuditOutcomeSuccess}
+	if id != 0 {
+		event.TargetID = strconv.FormatUint(uint64(id), 10)
+	}
+	if err != nil {
+		event.Outcome = model.AuditOutcomeFailure
+		event.Reason = err.Error()
+	}
+	s.AuditLogger.Record(ctx, event)
+}
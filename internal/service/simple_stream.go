@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"go.uber.org/zap"
+)
+
+type SimpleStreamConfig struct {
+	LogSize    int
+	BufferSize int
+	RetryDelay time.Duration
+}
+
+// SimpleStream fans the changes to Simples out to the clients streaming them. Every instance of the application
+// listens for the changes made through any instance and keeps the latest in a bounded log, so a client that
+// reconnects, to any instance, can resume from the last change it received.
+type SimpleStream interface {
+	// Run listens for changes until the context is cancelled, reconnecting after failures, then closes every
+	// subscription.
+	Run(ctx context.Context)
+	// Subscribe streams the changes to the Simples the authenticated user can see in the request's organization.
+	// Given the ID of the last change the client received, the changes since are replayed first.
+	Subscribe(ctx context.Context, lastEventID uint64) *SimpleSubscription
+}
+
+// SimpleSubscription receives the changes visible to one user. Changes is closed when the stream stops, when
+// notifications may have been lost, or when the subscriber falls more than a buffer behind; the client should then
+// reconnect with the ID of the last change it received.
+type SimpleSubscription struct {
+	Changes <-chan *model.SimpleChange
+	// Resumed is false when changes after the last event ID given to Subscribe are no longer in the log, so the
+	// client has to reload the Simples instead.
+	Resumed bool
+
+	changes        chan *model.SimpleChange
+	organizationID uint
+	userID         uint
+	membership     *model.Membership
+	stream         *simpleStream
+}
+
+type simpleStream struct {
+	SimpleRepository     repository.SimpleRepository
+	SimpleChangeListener repository.SimpleChangeListener
+	Config               SimpleStreamConfig
+
+	mutex     sync.Mutex
+	log       []*model.SimpleChange
+	listening bool
+	stopped   bool
+	// Changes up to this ID may have been missed, or dropped from the log, so clients cannot resume from before it.
+	horizon     uint64
+	subscribers map[*SimpleSubscription]struct{}
+}
+
+var _ SimpleStream = &simpleStream{}
+
+func NewSimpleStream(simpleRepository repository.SimpleRepository, simpleChangeListener repository.SimpleChangeListener, config SimpleStreamConfig) SimpleStream {
+	return &simpleStream{
+		SimpleRepository:     simpleRepository,
+		SimpleChangeListener: simpleChangeListener,
+		Config:               config,
+		subscribers:          make(map[*SimpleSubscription]struct{}),
+	}
+}
+
+func (s *simpleStream) Run(ctx context.Context) {
+	log := logger.GetFromContext(ctx)
+
+	log.Info("Simple stream started")
+	for ctx.Err() == nil {
+		err := s.SimpleChangeListener.Listen(ctx, s.startListening, func(notification *model.SimpleChangeNotification) {
+			s.receive(ctx, notification)
+		})
+		if ctx.Err() != nil {
+			break
+		}
+		if err == nil {
+			err = errors.New("listener stopped")
+		}
+
+		// Changes made until listening again are missed, so subscribers have to reconnect and reload.
+		log.Error("Stopped listening for Simple changes, will retry", zap.Duration("retry_delay", s.Config.RetryDelay), zap.Error(err))
+		s.stopListening(false)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(s.Config.RetryDelay):
+		}
+	}
+
+	s.stopListening(true)
+	log.Info("Simple stream stopped")
+}
+
+func (s *simpleStream) Subscribe(ctx context.Context, lastEventID uint64) *SimpleSubscription {
+	subscription := &SimpleSubscription{
+		organizationID: utils.GetOrganizationID(ctx),
+		userID:         authenticatedUserID(ctx),
+		membership:     utils.GetMembership(ctx),
+		stream:         s,
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var replay []*model.SimpleChange
+	if lastEventID != 0 {
+		var missed []*model.SimpleChange
+		missed, subscription.Resumed = s.since(lastEventID)
+		for _, change := range missed {
+			if visible := change.VisibleTo(subscription.organizationID, subscription.userID, subscription.membership); visible != nil {
+				replay = append(replay, visible)
+			}
+		}
+	}
+
+	subscription.changes = make(chan *model.SimpleChange, len(replay)+s.Config.BufferSize)
+	subscription.Changes = subscription.changes
+	for _, change := range replay {
+		subscription.changes <- change
+	}
+
+	if s.stopped {
+		close(subscription.changes)
+	} else {
+		s.subscribers[subscription] = struct{}{}
+	}
+	return subscription
+}
+
+// Close stops the subscription. It can be called more than once.
+func (subscription *SimpleSubscription) Close() {
+	stream := subscription.stream
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	stream.unsubscribe(subscription)
+}
+
+// UpdateMembership sets the membership the changes are filtered by, e.g. once the user's role has changed.
+func (subscription *SimpleSubscription) UpdateMembership(membership *model.Membership) {
+	stream := subscription.stream
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	subscription.membership = membership
+}
+
+// Returns the changes in the log received after the one with the given ID, and whether they are all the changes
+// since. Changes are committed, and so received, in a different order than their IDs were assigned, so the log is
+// replayed from the position of the last change when it is still there.
+func (s *simpleStream) since(lastEventID uint64) ([]*model.SimpleChange, bool) {
+	if !s.listening || lastEventID < s.horizon {
+		return nil, false
+	}
+
+	for i := len(s.log) - 1; i >= 0; i-- {
+		if s.log[i].ID == lastEventID {
+			return s.log[i+1:], true
+		}
+	}
+
+	var missed []*model.SimpleChange
+	for _, change := range s.log {
+		if change.ID > lastEventID {
+			missed = append(missed, change)
+		}
+	}
+	return missed, true
+}
+
+func (s *simpleStream) startListening(latestEventID uint64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.listening = true
+	s.horizon = max(s.horizon, latestEventID)
+	logger.Get().Info("Listening for Simple changes", zap.Uint64("latest_event_id", latestEventID))
+}
+
+// Closes every subscription, and refuses new ones once the stream is stopped.
+func (s *simpleStream) stopListening(stopped bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.listening = false
+	s.stopped = stopped
+	for subscription := range s.subscribers {
+		s.unsubscribe(subscription)
+	}
+}
+
+func (s *simpleStream) receive(ctx context.Context, notification *model.SimpleChangeNotification) {
+	log := logger.GetFromContext(ctx)
+
+	simple, err := s.SimpleRepository.GetChanged(ctx, notification.SimpleID)
+	if err != nil {
+		log.Error("Failed to load changed Simple", zap.Uint64("event_id", notification.EventID), zap.Uint("simple_id", notification.SimpleID), zap.Error(err))
+		return
+	}
+	change := notification.ToChange(simple)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.log = append(s.log, change)
+	if overflow := len(s.log) - s.Config.LogSize; overflow > 0 {
+		for _, dropped := range s.log[:overflow] {
+			s.horizon = max(s.horizon, dropped.ID)
+		}
+		s.log = s.log[overflow:]
+	}
+
+	for subscription := range s.subscribers {
+		visible := change.VisibleTo(subscription.organizationID, subscription.userID, subscription.membership)
+		if visible == nil {
+			continue
+		}
+		select {
+		case subscription.changes <- visible:
+		default:
+			log.Warn("Simple stream subscriber fell behind, closing", zap.Uint("user_id", subscription.userID))
+			s.unsubscribe(subscription)
+		}
+	}
+	log.Debug("Simple change streamed", zap.Object("change", change))
+}
+
+// Must be called with the mutex held.
+func (s *simpleStream) unsubscribe(subscription *SimpleSubscription) {
+	if _, ok := s.subscribers[subscription]; ok {
+		delete(s.subscribers, subscription)
+		close(subscription.changes)
+	}
+}
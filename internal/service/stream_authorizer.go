@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// StreamAuthorizer re-checks the authorization of a long-lived request, such as a change stream, after it started.
+type StreamAuthorizer interface {
+	// Reauthorize returns the request's current membership, or an error once the request may no longer be served.
+	Reauthorize(ctx context.Context) (*model.Membership, error)
+}
+
+type streamAuthorizer struct {
+	UserRepository       repository.UserRepository
+	SessionRepository    repository.SessionRepository
+	MembershipRepository repository.MembershipRepository
+}
+
+var _ StreamAuthorizer = &streamAuthorizer{}
+
+func NewStreamAuthorizer(userRepository repository.UserRepository, sessionRepository repository.SessionRepository, membershipRepository repository.MembershipRepository) StreamAuthorizer {
+	return &streamAuthorizer{UserRepository: userRepository, SessionRepository: sessionRepository, MembershipRepository: membershipRepository}
+}
+
+func (s *streamAuthorizer) Reauthorize(ctx context.Context) (*model.Membership, error) {
+	log := logger.GetFromContext(ctx)
+
+	if expiresAt := utils.GetTokenExpiry(ctx); !expiresAt.IsZero() && !time.Now().Before(expiresAt) {
+		return nil, errors.New("token expired")
+	}
+
+	authenticatedUser := utils.GetAuthenticatedUser(ctx)
+	if authenticatedUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	user, err := s.reloadUser(ctx, authenticatedUser)
+	if err != nil {
+		return nil, err
+	}
+
+	if sessionID := utils.GetSessionID(ctx); sessionID != 0 {
+		session, err := s.SessionRepository.GetByID(ctx, sessionID)
+		if err != nil {
+			log.Warn("Session not found during reauthorization", zap.Uint("session_id", sessionID), zap.Error(err))
+			return nil, errors.New("invalid session")
+		}
+		if session.UserID != user.ID || !session.IsActive(user) {
+			return nil, errors.New("session revoked")
+		}
+	} else if utils.IsImpersonating(ctx) {
+		actor, err := s.reloadUser(ctx, utils.GetRealUser(ctx))
+		if err != nil {
+			return nil, err
+		}
+		if !actor.IsAdmin() {
+			return nil, errors.New("impersonation no longer allowed")
+		}
+	}
+
+	membership := utils.GetMembership(ctx)
+	if membership == nil {
+		return nil, nil
+	}
+	membership, err = s.MembershipRepository.Get(ctx, membership.OrganizationID, user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("not a member of this organization")
+		}
+		log.Error("Failed to get membership during reauthorization", zap.Uint("user_id", user.ID), zap.Error(err))
+		return nil, err
+	}
+	return membership, nil
+}
+
+// Returns the current user, unless they were disabled or had their tokens revoked since the request was authenticated.
+func (s *streamAuthorizer) reloadUser(ctx context.Context, authenticatedUser *model.User) (*model.User, error) {
+	user, err := s.UserRepository.GetByID(ctx, authenticatedUser.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid user id")
+		}
+		logger.GetFromContext(ctx).Error("Failed to get user during reauthorization", zap.Uint("user_id", authenticatedUser.ID), zap.Error(err))
+		return nil, err
+	}
+
+	if user.IsDisabled() {
+		return nil, errors.New("account disabled")
+	}
+	if user.PasswordResetRequired {
+		return nil, errors.New("password reset required")
+	}
+	if user.TokensValidAfter != nil && (authenticatedUser.TokensValidAfter == nil || user.TokensValidAfter.After(*authenticatedUser.TokensValidAfter)) {
+		return nil, errors.New("token revoked")
+	}
+	return user, nil
+}
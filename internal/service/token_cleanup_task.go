@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"go.uber.org/zap"
+)
+
+const TokenCleanupTaskName = "tokens.cleanup"
+
+// NewTokenCleanupTask returns a scheduled task deleting the magic link and email verification tokens that expired
+// longer ago than the retention. Expired tokens can no longer be used, but are kept a while to investigate sign-in
+// issues.
+func NewTokenCleanupTask(magicLinkRepository repository.MagicLinkRepository, emailVerificationRepository repository.EmailVerificationRepository, retention time.Duration) ScheduledTaskFunc {
+	return func(ctx context.Context) error {
+		before := time.Now().Add(-retention)
+
+		magicLinks, err := magicLinkRepository.DeleteExpired(ctx, before)
+		if err != nil {
+			return err
+		}
+		verifications, err := emailVerificationRepository.DeleteExpired(ctx, before)
+		if err != nil {
+			return err
+		}
+
+		logger.GetFromContext(ctx).Info("Expired tokens deleted", zap.Int64("magic_link_tokens", magicLinks), zap.Int64("email_verifications", verifications))
+		return nil
+	}
+}
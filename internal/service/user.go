@@ -1,49 +1,93 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net/url"
+	"time"
 
 	"github.com/Verano-20/stage-zero/internal/err"
 	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/mail"
 	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/password"
 	"github.com/Verano-20/stage-zero/internal/repository"
-	"github.com/gin-gonic/gin"
+	"github.com/Verano-20/stage-zero/internal/utils"
 	"github.com/jackc/pgx/v5/pgconn"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type UserService interface {
-	CreateUser(ctx *gin.Context, userForm model.UserForm) (user *model.User, createErr error)
-	GetUserByEmail(ctx *gin.Context, email string) (user *model.User, err error)
+	CreateUser(ctx context.Context, userForm model.UserForm) (user *model.User, createErr error)
+	GetUserByID(ctx context.Context, id uint) (user *model.User, err error)
+	GetUserByEmail(ctx context.Context, email string) (user *model.User, err error)
+	ChangePassword(ctx context.Context, user *model.User, changePasswordForm model.ChangePasswordForm) (*model.User, error)
+	RequestEmailChange(ctx context.Context, user *model.User, changeEmailForm model.ChangeEmailForm) error
+	VerifyEmailChange(ctx context.Context, token string) (*model.User, error)
+	MarkEmailVerified(ctx context.Context, user *model.User) (*model.User, error)
+	DeleteUser(ctx context.Context, user *model.User) error
+	ListUsers(ctx context.Context, filter model.UserFilter) (model.Users, int64, error)
+	DisableUser(ctx context.Context, user *model.User) (*model.User, error)
+	EnableUser(ctx context.Context, user *model.User) (*model.User, error)
+	ForcePasswordReset(ctx context.Context, user *model.User) (*model.User, error)
+	RecordFailedLogin(ctx context.Context, user *model.User, maxAttempts int, lockoutDuration time.Duration) error
+	UnlockUser(ctx context.Context, user *model.User) (*model.User, error)
+	RehashPassword(ctx context.Context, user *model.User, plainPassword string) (*model.User, error)
 }
 
 type userService struct {
-	UserRepository repository.UserRepository
+	TxManager                   repository.TxManager
+	UserRepository              repository.UserRepository
+	EmailVerificationRepository repository.EmailVerificationRepository
+	PasswordHistoryRepository   repository.PasswordHistoryRepository
+	SessionRepository           repository.SessionRepository
+	MembershipRepository        repository.MembershipRepository
+	MailSender                  mail.Sender
+	PasswordHasher              password.PasswordHasher
+	PasswordPolicy              password.Policy
+	EmailVerificationURL        string
+	EmailVerificationTTL        time.Duration
 }
 
 var _ UserService = &userService{}
 
-func NewUserService(userRepository repository.UserRepository) UserService {
-	return &userService{UserRepository: userRepository}
+func NewUserService(txManager repository.TxManager, userRepository repository.UserRepository, emailVerificationRepository repository.EmailVerificationRepository, passwordHistoryRepository repository.PasswordHistoryRepository, sessionRepository repository.SessionRepository, membershipRepository repository.MembershipRepository, mailSender mail.Sender, passwordHasher password.PasswordHasher, passwordPolicy password.Policy, emailVerificationURL string, emailVerificationTTL time.Duration) UserService {
+	return &userService{
+		TxManager:                   txManager,
+		UserRepository:              userRepository,
+		EmailVerificationRepository: emailVerificationRepository,
+		PasswordHistoryRepository:   passwordHistoryRepository,
+		SessionRepository:           sessionRepository,
+		MembershipRepository:        membershipRepository,
+		MailSender:                  mailSender,
+		PasswordHasher:              passwordHasher,
+		PasswordPolicy:              passwordPolicy,
+		EmailVerificationURL:        emailVerificationURL,
+		EmailVerificationTTL:        emailVerificationTTL,
+	}
 }
 
-func (s *userService) CreateUser(ctx *gin.Context, userForm model.UserForm) (user *model.User, createErr error) {
+func (s *userService) CreateUser(ctx context.Context, userForm model.UserForm) (user *model.User, createErr error) {
 	log := logger.GetFromContext(ctx)
 
 	log.Debug("Creating User...", zap.Object("user", &userForm))
 
-	passwordHash, hashErr := bcrypt.GenerateFromPassword([]byte(userForm.Password), bcrypt.DefaultCost)
+	if violations := s.PasswordPolicy.Check(userForm.Password, userForm.Email, nil); violations != nil {
+		log.Warn("User creation failed - password policy violated", zap.Object("user", &userForm), zap.Any("violations", violations))
+		return nil, err.NewPasswordPolicyError(errors.New("password does not meet policy"), violations)
+	}
+
+	passwordHash, hashErr := s.PasswordHasher.Hash(userForm.Password)
 	if hashErr != nil {
 		log.Error("Failed to hash password", zap.Object("user", &userForm), zap.Error(hashErr))
 		return nil, err.NewPasswordHashError(hashErr)
 	}
 
-	user, dbErr := s.UserRepository.Create(ctx, userForm.ToModel(string(passwordHash)))
+	user, dbErr := s.UserRepository.Create(ctx, userForm.ToModel(passwordHash))
 	if dbErr != nil {
-		var pgErr *pgconn.PgError
-		// Check if the error is a unique constraint violation (SQLSTATE 23505)
-		if errors.As(dbErr, &pgErr) && pgErr.Code == "23505" {
+		if isUniqueViolation(dbErr) {
 			log.Warn("User creation failed - email already in use", zap.Object("user", &userForm), zap.Error(dbErr))
 			return nil, err.NewEmailExistsError(dbErr)
 		}
@@ -55,7 +99,22 @@ func (s *userService) CreateUser(ctx *gin.Context, userForm model.UserForm) (use
 	return user, nil
 }
 
-func (s *userService) GetUserByEmail(ctx *gin.Context, email string) (user *model.User, err error) {
+func (s *userService) GetUserByID(ctx context.Context, id uint) (user *model.User, err error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Getting User by ID...", zap.Uint("id", id))
+
+	user, err = s.UserRepository.GetByID(ctx, id)
+	if err != nil {
+		log.Warn("Failed to find User with ID", zap.Uint("id", id), zap.Error(err))
+		return nil, err
+	}
+
+	log.Debug("User retrieved successfully", zap.Object("user", user))
+	return user, nil
+}
+
+func (s *userService) GetUserByEmail(ctx context.Context, email string) (user *model.User, err error) {
 	log := logger.GetFromContext(ctx)
 
 	log.Debug("Getting User by email...", zap.String("email", email))
@@ -69,3 +128,426 @@ func (s *userService) GetUserByEmail(ctx *gin.Context, email string) (user *mode
 	log.Debug("User retrieved successfully", zap.Object("user", user))
 	return user, nil
 }
+
+// ChangePassword sets a new password after checking the current one and revokes every session of the user.
+func (s *userService) ChangePassword(ctx context.Context, user *model.User, changePasswordForm model.ChangePasswordForm) (*model.User, error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Changing User password...", zap.Object("user", user))
+
+	if compareErr := s.PasswordHasher.Verify(changePasswordForm.CurrentPassword, user.PasswordHash); compareErr != nil {
+		log.Warn("Password change failed - invalid current password", zap.Object("user", user), zap.Error(compareErr))
+		return nil, err.NewInvalidCredentialsError(compareErr)
+	}
+
+	previousHashes, historyErr := s.getPreviousPasswordHashes(ctx, user)
+	if historyErr != nil {
+		log.Error("Failed to get User password history", zap.Object("user", user), zap.Error(historyErr))
+		return nil, historyErr
+	}
+
+	if violations := s.PasswordPolicy.Check(changePasswordForm.NewPassword, user.Email, previousHashes); violations != nil {
+		log.Warn("Password change failed - password policy violated", zap.Object("user", user), zap.Any("violations", violations))
+		return nil, err.NewPasswordPolicyError(errors.New("password does not meet policy"), violations)
+	}
+
+	passwordHash, hashErr := s.PasswordHasher.Hash(changePasswordForm.NewPassword)
+	if hashErr != nil {
+		log.Error("Failed to hash password", zap.Object("user", user), zap.Error(hashErr))
+		return nil, err.NewPasswordHashError(hashErr)
+	}
+
+	previousHash := user.PasswordHash
+	user.PasswordHash = passwordHash
+	user.PasswordResetRequired = false
+	revokeTokens(user)
+
+	dbErr := s.TxManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		updatedUser, updateErr := s.UserRepository.Update(ctx, user)
+		if updateErr != nil {
+			log.Error("Failed to update User password", zap.Error(updateErr))
+			return updateErr
+		}
+		user = updatedUser
+
+		if revokeErr := s.SessionRepository.RevokeAllByUserID(ctx, user.ID); revokeErr != nil {
+			log.Error("Failed to revoke User sessions", zap.Object("user", user), zap.Error(revokeErr))
+			return revokeErr
+		}
+
+		if s.PasswordPolicy.HistorySize() > 0 {
+			entry := &model.PasswordHistoryEntry{UserID: user.ID, PasswordHash: previousHash}
+			if _, historyErr := s.PasswordHistoryRepository.Create(ctx, entry); historyErr != nil {
+				log.Error("Failed to record previous password in history", zap.Object("user", user), zap.Error(historyErr))
+				return historyErr
+			}
+		}
+		return nil
+	})
+	if dbErr != nil {
+		return nil, dbErr
+	}
+
+	log.Debug("User password changed successfully", zap.Object("user", user))
+	return user, nil
+}
+
+// RequestEmailChange sends a verification link to the new address. The email is only changed once the link
+// is consumed through VerifyEmailChange.
+func (s *userService) RequestEmailChange(ctx context.Context, user *model.User, changeEmailForm model.ChangeEmailForm) error {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Requesting User email change...", zap.Object("user", user), zap.Object("changeEmailForm", &changeEmailForm))
+
+	if compareErr := s.PasswordHasher.Verify(changeEmailForm.CurrentPassword, user.PasswordHash); compareErr != nil {
+		log.Warn("Email change failed - invalid current password", zap.Object("user", user), zap.Error(compareErr))
+		return err.NewInvalidCredentialsError(compareErr)
+	}
+
+	if _, lookupErr := s.UserRepository.GetByEmail(ctx, changeEmailForm.Email); lookupErr == nil {
+		log.Warn("Email change failed - email already in use", zap.Object("changeEmailForm", &changeEmailForm))
+		return err.NewEmailExistsError(errors.New("email already in use"))
+	} else if !errors.Is(lookupErr, gorm.ErrRecordNotFound) {
+		log.Error("Failed to check email availability", zap.Error(lookupErr))
+		return lookupErr
+	}
+
+	token, tokenErr := utils.GenerateToken()
+	if tokenErr != nil {
+		log.Error("Failed to generate email verification token", zap.Object("user", user), zap.Error(tokenErr))
+		return tokenErr
+	}
+
+	verification, dbErr := s.EmailVerificationRepository.Create(ctx, &model.EmailVerification{
+		UserID:    user.ID,
+		Email:     changeEmailForm.Email,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(s.EmailVerificationTTL),
+	})
+	if dbErr != nil {
+		log.Error("Failed to store email verification", zap.Object("user", user), zap.Error(dbErr))
+		return dbErr
+	}
+
+	message := mail.Message{
+		To:      changeEmailForm.Email,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Use the link below to confirm your new email address. It expires in %s.\n\n%s?token=%s",
+			s.EmailVerificationTTL, s.EmailVerificationURL, url.QueryEscape(token)),
+	}
+	if mailErr := s.MailSender.Send(ctx, message); mailErr != nil {
+		log.Error("Failed to send email verification", zap.Object("user", user), zap.Error(mailErr))
+		return mailErr
+	}
+
+	log.Debug("Email change verification sent", zap.Object("verification", verification))
+	return nil
+}
+
+func (s *userService) VerifyEmailChange(ctx context.Context, token string) (*model.User, error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Verifying User email change...")
+
+	verification, consumeErr := s.EmailVerificationRepository.Consume(ctx, utils.HashToken(token))
+	if consumeErr != nil {
+		log.Warn("Email verification invalid, expired or already used", zap.Error(consumeErr))
+		return nil, err.NewEmailVerificationError(consumeErr)
+	}
+
+	user, userErr := s.UserRepository.GetByID(ctx, verification.UserID)
+	if userErr != nil {
+		log.Warn("User for email verification not found", zap.Object("verification", verification), zap.Error(userErr))
+		return nil, err.NewEmailVerificationError(userErr)
+	}
+
+	verifiedAt := time.Now()
+	user.Email = verification.Email
+	user.EmailVerifiedAt = &verifiedAt
+	user, dbErr := s.UserRepository.Update(ctx, user)
+	if dbErr != nil {
+		if isUniqueViolation(dbErr) {
+			log.Warn("Email change failed - email already in use", zap.Object("verification", verification), zap.Error(dbErr))
+			return nil, err.NewEmailExistsError(dbErr)
+		}
+		log.Error("Failed to update User email", zap.Object("verification", verification), zap.Error(dbErr))
+		return nil, dbErr
+	}
+
+	log.Debug("User email changed successfully", zap.Object("user", user))
+	return user, nil
+}
+
+// MarkEmailVerified records that the user has proven they own their email address, for example by following an
+// invitation sent to it.
+func (s *userService) MarkEmailVerified(ctx context.Context, user *model.User) (*model.User, error) {
+	log := logger.GetFromContext(ctx)
+
+	verifiedAt := time.Now()
+	user.EmailVerifiedAt = &verifiedAt
+
+	user, dbErr := s.UserRepository.Update(ctx, user)
+	if dbErr != nil {
+		log.Error("Failed to mark User email verified", zap.Error(dbErr))
+		return nil, dbErr
+	}
+
+	log.Debug("User email marked verified", zap.Object("user", user))
+	return user, nil
+}
+
+// DeleteUser soft deletes the user, removing their memberships, and anonymises the email so the address can be
+// registered again. The only owner of an organization with other members cannot be deleted.
+func (s *userService) DeleteUser(ctx context.Context, user *model.User) error {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Deleting User...", zap.Object("user", user))
+
+	user.Email = fmt.Sprintf("deleted-user-%d@deleted.invalid", user.ID)
+
+	dbErr := s.TxManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if membershipErr := s.removeMemberships(ctx, user); membershipErr != nil {
+			return membershipErr
+		}
+
+		if deleteErr := s.UserRepository.Delete(ctx, user); deleteErr != nil {
+			log.Error("Failed to delete User", zap.Object("user", user), zap.Error(deleteErr))
+			return deleteErr
+		}
+		return nil
+	})
+	if dbErr != nil {
+		return dbErr
+	}
+
+	log.Debug("User deleted successfully", zap.Object("user", user))
+	return nil
+}
+
+// Removes the user's memberships, unless the user is the only owner of an organization with other members. The
+// owners stay locked until the transaction ends, as when removing a member.
+func (s *userService) removeMemberships(ctx context.Context, user *model.User) error {
+	log := logger.GetFromContext(ctx)
+
+	memberships, listErr := s.MembershipRepository.ListByUserID(ctx, user.ID)
+	if listErr != nil {
+		log.Error("Failed to list User memberships", zap.Object("user", user), zap.Error(listErr))
+		return listErr
+	}
+
+	for _, membership := range memberships {
+		if membership.IsOwner() {
+			owners, lockErr := s.MembershipRepository.LockOwners(ctx, membership.OrganizationID)
+			if lockErr != nil {
+				log.Error("Failed to lock owners", zap.Uint("organization_id", membership.OrganizationID), zap.Error(lockErr))
+				return lockErr
+			}
+			if owners <= 1 {
+				members, membersErr := s.MembershipRepository.ListByOrganizationID(ctx, membership.OrganizationID)
+				if membersErr != nil {
+					log.Error("Failed to list members", zap.Uint("organization_id", membership.OrganizationID), zap.Error(membersErr))
+					return membersErr
+				}
+				if len(members) > 1 {
+					log.Warn("Cannot delete the last owner", zap.Object("membership", membership))
+					return err.NewLastOwnerError(errors.New("organization must keep an owner"))
+				}
+			}
+		}
+
+		if deleteErr := s.MembershipRepository.Delete(ctx, membership); deleteErr != nil {
+			log.Error("Failed to remove membership", zap.Object("membership", membership), zap.Error(deleteErr))
+			return deleteErr
+		}
+	}
+	return nil
+}
+
+func (s *userService) ListUsers(ctx context.Context, filter model.UserFilter) (model.Users, int64, error) {
+	log := logger.GetFromContext(ctx)
+
+	filter.Normalize()
+	log.Debug("Listing Users...", zap.Object("filter", &filter))
+
+	users, total, err := s.UserRepository.List(ctx, filter)
+	if err != nil {
+		log.Error("Failed to list Users", zap.Object("filter", &filter), zap.Error(err))
+		return nil, 0, err
+	}
+
+	log.Debug("Users listed successfully", zap.Int("count", len(users)), zap.Int64("total", total))
+	return users, total, nil
+}
+
+// DisableUser blocks the user from logging in and revokes all of their tokens.
+func (s *userService) DisableUser(ctx context.Context, user *model.User) (*model.User, error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Disabling User...", zap.Object("user", user))
+
+	disabledAt := time.Now()
+	user.DisabledAt = &disabledAt
+	revokeTokens(user)
+
+	err := s.TxManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		updatedUser, err := s.UserRepository.Update(ctx, user)
+		if err != nil {
+			log.Error("Failed to disable User", zap.Error(err))
+			return err
+		}
+		user = updatedUser
+
+		if err := s.SessionRepository.RevokeAllByUserID(ctx, user.ID); err != nil {
+			log.Error("Failed to revoke User sessions", zap.Object("user", user), zap.Error(err))
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("User disabled", zap.Object("user", user))
+	return user, nil
+}
+
+func (s *userService) EnableUser(ctx context.Context, user *model.User) (*model.User, error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Enabling User...", zap.Object("user", user))
+
+	user.DisabledAt = nil
+
+	user, err := s.UserRepository.Update(ctx, user)
+	if err != nil {
+		log.Error("Failed to enable User", zap.Error(err))
+		return nil, err
+	}
+
+	log.Info("User enabled", zap.Object("user", user))
+	return user, nil
+}
+
+// ForcePasswordReset revokes all of the user's tokens and restricts new ones to changing the password until
+// the user has done so.
+func (s *userService) ForcePasswordReset(ctx context.Context, user *model.User) (*model.User, error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Forcing User password reset...", zap.Object("user", user))
+
+	user.PasswordResetRequired = true
+	revokeTokens(user)
+
+	err := s.TxManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		updatedUser, err := s.UserRepository.Update(ctx, user)
+		if err != nil {
+			log.Error("Failed to force User password reset", zap.Error(err))
+			return err
+		}
+		user = updatedUser
+
+		if err := s.SessionRepository.RevokeAllByUserID(ctx, user.ID); err != nil {
+			log.Error("Failed to revoke User sessions", zap.Object("user", user), zap.Error(err))
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("User password reset forced", zap.Object("user", user))
+	return user, nil
+}
+
+func (s *userService) RecordFailedLogin(ctx context.Context, user *model.User, maxAttempts int, lockoutDuration time.Duration) error {
+	log := logger.GetFromContext(ctx)
+
+	if err := s.UserRepository.RecordFailedLogin(ctx, user.ID, maxAttempts, lockoutDuration); err != nil {
+		log.Error("Failed to record failed login", zap.Object("user", user), zap.Error(err))
+		return err
+	}
+
+	if user.FailedLoginAttempts+1 >= maxAttempts {
+		log.Warn("User locked after too many failed logins", zap.Object("user", user), zap.Int("max_attempts", maxAttempts))
+	}
+	return nil
+}
+
+// UnlockUser clears the failed login counter and any lockout.
+func (s *userService) UnlockUser(ctx context.Context, user *model.User) (*model.User, error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Unlocking User...", zap.Object("user", user))
+
+	if err := s.UserRepository.ResetFailedLogins(ctx, user.ID); err != nil {
+		log.Error("Failed to unlock User", zap.Object("user", user), zap.Error(err))
+		return nil, err
+	}
+
+	user.FailedLoginAttempts = 0
+	user.LockedUntil = nil
+
+	log.Debug("User unlocked", zap.Object("user", user))
+	return user, nil
+}
+
+// RehashPassword replaces the User's password hash with one from the configured hasher. It is called after a
+// successful login with an outdated hash, so existing sessions are left alone.
+func (s *userService) RehashPassword(ctx context.Context, user *model.User, plainPassword string) (*model.User, error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Rehashing User password...", zap.Object("user", user))
+
+	passwordHash, hashErr := s.PasswordHasher.Hash(plainPassword)
+	if hashErr != nil {
+		log.Error("Failed to hash password", zap.Object("user", user), zap.Error(hashErr))
+		return nil, err.NewPasswordHashError(hashErr)
+	}
+
+	if dbErr := s.UserRepository.UpdatePasswordHash(ctx, user.ID, user.PasswordHash, passwordHash); dbErr != nil {
+		log.Error("Failed to update User password hash", zap.Object("user", user), zap.Error(dbErr))
+		return nil, dbErr
+	}
+
+	user.PasswordHash = passwordHash
+
+	log.Debug("User password rehashed", zap.Object("user", user))
+	return user, nil
+}
+
+// Returns the hashes a new password must not match: the current one and, when the policy keeps a history of
+// more than one password, the most recent previous ones.
+func (s *userService) getPreviousPasswordHashes(ctx context.Context, user *model.User) ([]string, error) {
+	historySize := s.PasswordPolicy.HistorySize()
+	if historySize == 0 {
+		return nil, nil
+	}
+
+	previousHashes := []string{user.PasswordHash}
+	if historySize == 1 {
+		return previousHashes, nil
+	}
+
+	entries, dbErr := s.PasswordHistoryRepository.ListRecent(ctx, user.ID, historySize-1)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+
+	for _, entry := range entries {
+		previousHashes = append(previousHashes, entry.PasswordHash)
+	}
+	return previousHashes, nil
+}
+
+// Invalidates every session started, and impersonation token issued, for the user before now.
+func revokeTokens(user *model.User) {
+	tokensValidAfter := time.Now()
+	user.TokensValidAfter = &tokensValidAfter
+}
+
+// Checks if the error is a unique constraint violation (SQLSTATE 23505)
+func isUniqueViolation(dbErr error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(dbErr, &pgErr) && pgErr.Code == "23505"
+}
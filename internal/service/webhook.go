@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	apiErr "github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	webhookSecretPrefix = "whsec_"
+	webhookEventPrefix  = "evt_"
+)
+
+type WebhookService interface {
+	ListEndpoints(ctx context.Context, user *model.User, organizationID uint) (model.WebhookEndpoints, error)
+	CreateEndpoint(ctx context.Context, user *model.User, organizationID uint, endpointForm model.WebhookEndpointForm) (*model.WebhookEndpoint, error)
+	UpdateEndpoint(ctx context.Context, user *model.User, organizationID uint, endpointID uint, endpointForm model.WebhookEndpointForm) (*model.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, user *model.User, organizationID uint, endpointID uint) error
+	ListDeliveries(ctx context.Context, user *model.User, organizationID uint, endpointID uint, filter model.WebhookDeliveryFilter) (model.WebhookDeliveries, int64, error)
+	Redeliver(ctx context.Context, user *model.User, organizationID uint, endpointID uint, deliveryID uint64) (*model.WebhookDelivery, error)
+	EnqueueSimpleEvent(ctx context.Context, eventType string, simple *model.Simple) error
+}
+
+// webhookService lets an organization's owners and admins manage its webhook endpoints, and queues their deliveries.
+type webhookService struct {
+	WebhookEndpointRepository repository.WebhookEndpointRepository
+	WebhookDeliveryRepository repository.WebhookDeliveryRepository
+	MembershipRepository      repository.MembershipRepository
+	AuditLogger               AuditLogger
+	AllowPrivateNetworks      bool
+}
+
+var _ WebhookService = &webhookService{}
+
+func NewWebhookService(webhookEndpointRepository repository.WebhookEndpointRepository, webhookDeliveryRepository repository.WebhookDeliveryRepository, membershipRepository repository.MembershipRepository, auditLogger AuditLogger, allowPrivateNetworks bool) WebhookService {
+	return &webhookService{
+		WebhookEndpointRepository: webhookEndpointRepository,
+		WebhookDeliveryRepository: webhookDeliveryRepository,
+		MembershipRepository:      membershipRepository,
+		AuditLogger:               auditLogger,
+		AllowPrivateNetworks:      allowPrivateNetworks,
+	}
+}
+
+func (s *webhookService) ListEndpoints(ctx context.Context, user *model.User, organizationID uint) (model.WebhookEndpoints, error) {
+	log := logger.GetFromContext(ctx)
+
+	if err := s.authorize(ctx, user, organizationID); err != nil {
+		return nil, err
+	}
+
+	endpoints, err := s.WebhookEndpointRepository.ListByOrganizationID(ctx, organizationID)
+	if err != nil {
+		log.Error("Failed to list webhook endpoints", zap.Uint("organization_id", organizationID), zap.Error(err))
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+// CreateEndpoint registers an endpoint with a new random signing secret.
+func (s *webhookService) CreateEndpoint(ctx context.Context, user *model.User, organizationID uint, endpointForm model.WebhookEndpointForm) (*model.WebhookEndpoint, error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Creating webhook endpoint", zap.Uint("organization_id", organizationID), zap.Object("endpoint", &endpointForm))
+
+	if err := s.authorize(ctx, user, organizationID); err != nil {
+		return nil, err
+	}
+	if err := s.validateWebhookURL(ctx, endpointForm.URL); err != nil {
+		return nil, err
+	}
+
+	secret, err := utils.GenerateToken()
+	if err != nil {
+		log.Error("Failed to generate webhook secret", zap.Error(err))
+		return nil, err
+	}
+
+	endpoint := &model.WebhookEndpoint{
+		OrganizationID: organizationID,
+		Secret:         webhookSecretPrefix + secret,
+		CreatedByID:    &user.ID,
+	}
+	endpointForm.Apply(endpoint)
+
+	endpoint, err = s.WebhookEndpointRepository.Create(ctx, endpoint)
+	if err != nil {
+		log.Error("Failed to create webhook endpoint", zap.Object("endpoint", &endpointForm), zap.Error(err))
+		return nil, err
+	}
+	s.recordAudit(ctx, model.AuditActionWebhookCreate, endpoint)
+
+	log.Info("Webhook endpoint created successfully", zap.Object("endpoint", endpoint))
+	return endpoint, nil
+}
+
+func (s *webhookService) UpdateEndpoint(ctx context.Context, user *model.User, organizationID uint, endpointID uint, endpointForm model.WebhookEndpointForm) (*model.WebhookEndpoint, error) {
+	log := logger.GetFromContext(ctx)
+
+	log.Debug("Updating webhook endpoint", zap.Uint("organization_id", organizationID), zap.Uint("endpoint_id", endpointID), zap.Object("endpoint", &endpointForm))
+
+	endpoint, err := s.getEndpoint(ctx, user, organizationID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateWebhookURL(ctx, endpointForm.URL); err != nil {
+		return nil, err
+	}
+
+	endpointForm.Apply(endpoint)
+	endpoint, err = s.WebhookEndpointRepository.Update(ctx, endpoint)
+	if err != nil {
+		log.Error("Failed to update webhook endpoint", zap.Uint("endpoint_id", endpointID), zap.Error(err))
+		return nil, err
+	}
+	s.recordAudit(ctx, model.AuditActionWebhookUpdate, endpoint)
+
+	log.Info("Webhook endpoint updated successfully", zap.Object("endpoint", endpoint))
+	return endpoint, nil
+}
+
+func (s *webhookService) DeleteEndpoint(ctx context.Context, user *model.User, organizationID uint, endpointID uint) error {
+	log := logger.GetFromContext(ctx)
+
+	endpoint, err := s.getEndpoint(ctx, user, organizationID, endpointID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.WebhookEndpointRepository.Delete(ctx, organizationID, endpointID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apiErr.NewWebhookNotFoundError(err)
+		}
+		log.Error("Failed to delete webhook endpoint", zap.Uint("endpoint_id", endpointID), zap.Error(err))
+		return err
+	}
+	s.recordAudit(ctx, model.AuditActionWebhookDelete, endpoint)
+
+	log.Info("Webhook endpoint deleted successfully", zap.Object("endpoint", endpoint))
+	return nil
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, user *model.User, organizationID uint, endpointID uint, filter model.WebhookDeliveryFilter) (model.WebhookDeliveries, int64, error) {
+	log := logger.GetFromContext(ctx)
+
+	if _, err := s.getEndpoint(ctx, user, organizationID, endpointID); err != nil {
+		return nil, 0, err
+	}
+
+	filter.Normalize()
+	deliveries, total, err := s.WebhookDeliveryRepository.ListByEndpointID(ctx, endpointID, filter)
+	if err != nil {
+		log.Error("Failed to list webhook deliveries", zap.Uint("endpoint_id", endpointID), zap.Error(err))
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+// Redeliver queues the delivery's event to be sent to the endpoint again, as a new delivery with the same event ID.
+func (s *webhookService) Redeliver(ctx context.Context, user *model.User, organizationID uint, endpointID uint, deliveryID uint64) (*model.WebhookDelivery, error) {
+	log := logger.GetFromContext(ctx)
+
+	if _, err := s.getEndpoint(ctx, user, organizationID, endpointID); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.WebhookDeliveryRepository.GetByID(ctx, endpointID, deliveryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apiErr.NewDeliveryNotFoundError(err)
+		}
+		log.Error("Failed to get webhook delivery", zap.Uint64("delivery_id", deliveryID), zap.Error(err))
+		return nil, err
+	}
+
+	now := time.Now()
+	redelivery := &model.WebhookDelivery{
+		EndpointID:     delivery.EndpointID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         model.WebhookDeliveryStatusPending,
+		NextAttemptAt:  &now,
+		RedeliveryOfID: &delivery.ID,
+	}
+	if err := s.WebhookDeliveryRepository.CreateMany(ctx, model.WebhookDeliveries{redelivery}); err != nil {
+		log.Error("Failed to queue webhook redelivery", zap.Object("delivery", delivery), zap.Error(err))
+		return nil, err
+	}
+
+	log.Info("Webhook redelivery queued", zap.Object("delivery", redelivery))
+	return redelivery, nil
+}
+
+// EnqueueSimpleEvent queues the event for the subscribed endpoints of the Simple's organization. Called in the
+// transaction of the write, so deliveries are queued if and only if the write commits.
+func (s *webhookService) EnqueueSimpleEvent(ctx context.Context, eventType string, simple *model.Simple) error {
+	log := logger.GetFromContext(ctx)
+
+	endpoints, err := s.WebhookEndpointRepository.ListSubscribed(ctx, simple.OrganizationID, eventType)
+	if err != nil {
+		log.Error("Failed to list webhook endpoints for event", zap.String("event_type", eventType), zap.Object("simple", simple), zap.Error(err))
+		return err
+	}
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	eventID, err := utils.GenerateToken()
+	if err != nil {
+		log.Error("Failed to generate webhook event ID", zap.Error(err))
+		return err
+	}
+	payload, err := model.NewJSONMap(&model.WebhookEvent{
+		ID:             webhookEventPrefix + eventID,
+		Type:           eventType,
+		OrganizationID: simple.OrganizationID,
+		CreatedAt:      time.Now().UTC(),
+		Data:           map[string]any{"simple": simple.ToEventDTO()},
+	})
+	if err != nil {
+		log.Error("Failed to encode webhook event", zap.String("event_type", eventType), zap.Error(err))
+		return err
+	}
+
+	now := time.Now()
+	deliveries := make(model.WebhookDeliveries, len(endpoints))
+	for i, endpoint := range endpoints {
+		deliveries[i] = &model.WebhookDelivery{
+			EndpointID:    endpoint.ID,
+			EventID:       webhookEventPrefix + eventID,
+			EventType:     eventType,
+			Payload:       payload,
+			Status:        model.WebhookDeliveryStatusPending,
+			NextAttemptAt: &now,
+		}
+	}
+	if err := s.WebhookDeliveryRepository.CreateMany(ctx, deliveries); err != nil {
+		log.Error("Failed to queue webhook deliveries", zap.String("event_type", eventType), zap.Object("simple", simple), zap.Error(err))
+		return err
+	}
+
+	log.Debug("Webhook deliveries queued", zap.String("event_type", eventType), zap.Int("count", len(deliveries)))
+	return nil
+}
+
+func (s *webhookService) getEndpoint(ctx context.Context, user *model.User, organizationID uint, endpointID uint) (*model.WebhookEndpoint, error) {
+	if err := s.authorize(ctx, user, organizationID); err != nil {
+		return nil, err
+	}
+
+	endpoint, err := s.WebhookEndpointRepository.GetByID(ctx, organizationID, endpointID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apiErr.NewWebhookNotFoundError(err)
+		}
+		logger.GetFromContext(ctx).Error("Failed to get webhook endpoint", zap.Uint("endpoint_id", endpointID), zap.Error(err))
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+func (s *webhookService) authorize(ctx context.Context, user *model.User, organizationID uint) error {
+	log := logger.GetFromContext(ctx)
+
+	membership, err := s.MembershipRepository.Get(ctx, organizationID, user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Warn("Membership not found", zap.Uint("organization_id", organizationID), zap.Uint("user_id", user.ID))
+			return apiErr.NewMembershipNotFoundError(err)
+		}
+		log.Error("Failed to get membership", zap.Uint("organization_id", organizationID), zap.Uint("user_id", user.ID), zap.Error(err))
+		return err
+	}
+
+	if !membership.CanManageMembers() {
+		log.Warn("Not allowed to manage webhooks", zap.Object("membership", membership))
+		return apiErr.NewOrganizationAccessError(errors.New("not allowed to manage webhooks"))
+	}
+	return nil
+}
+
+func (s *webhookService) recordAudit(ctx context.Context, action string, endpoint *model.WebhookEndpoint) {
+	s.AuditLogger.Record(ctx, &model.AuditEvent{
+		Action:     action,
+		TargetType: model.AuditTargetTypeWebhook,
+		TargetID:   strconv.FormatUint(uint64(endpoint.ID), 10),
+		Outcome:    model.AuditOutcomeSuccess,
+	})
+}
+
+// Accepts absolute http and https URLs on the public internet, or on private networks when they are allowed.
+func (s *webhookService) validateWebhookURL(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || parsed.User != nil {
+		return apiErr.NewWebhookInvalidError(errors.New("url must be an http or https URL without credentials"))
+	}
+	if s.AllowPrivateNetworks {
+		return nil
+	}
+	if err := utils.CheckPublicHost(ctx, parsed.Hostname()); err != nil {
+		return apiErr.NewWebhookInvalidError(errors.New("url must not point to a private network address"))
+	}
+	return nil
+}
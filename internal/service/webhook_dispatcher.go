@@ -0,0 +1,233 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	WebhookHeaderDelivery  = "X-Webhook-Delivery"
+	WebhookHeaderEvent     = "X-Webhook-Event"
+	WebhookHeaderSignature = "X-Webhook-Signature"
+)
+
+// Response bodies are discarded, but read up to this size so connections can be reused.
+const webhookResponseDrainLimit = 64 << 10
+
+type WebhookDispatcherConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Timeout      time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	// Lets deliveries connect to loopback, private and link-local addresses, for local development.
+	AllowPrivateNetworks bool
+}
+
+// WebhookDispatcher sends pending webhook deliveries.
+type WebhookDispatcher interface {
+	// Run dispatches due deliveries every poll interval until the context is cancelled, finishing the batch in flight.
+	Run(ctx context.Context)
+	// DispatchDue sends one batch of due deliveries and returns how many were attempted.
+	DispatchDue(ctx context.Context) (int, error)
+}
+
+type webhookDispatcher struct {
+	WebhookDeliveryRepository repository.WebhookDeliveryRepository
+	Client                    *http.Client
+	Config                    WebhookDispatcherConfig
+}
+
+var _ WebhookDispatcher = &webhookDispatcher{}
+
+// NewWebhookDispatcher posts without redirects or proxies, refusing private addresses unless they are allowed.
+func NewWebhookDispatcher(webhookDeliveryRepository repository.WebhookDeliveryRepository, config WebhookDispatcherConfig) WebhookDispatcher {
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	if !config.AllowPrivateNetworks {
+		dialer.Control = utils.RefuseNonPublicAddresses
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &webhookDispatcher{
+		WebhookDeliveryRepository: webhookDeliveryRepository,
+		Client: &http.Client{
+			Transport: transport,
+			Timeout:   config.Timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		Config: config,
+	}
+}
+
+func (d *webhookDispatcher) Run(ctx context.Context) {
+	log := logger.GetFromContext(ctx)
+
+	log.Info("Webhook dispatcher started", zap.Duration("poll_interval", d.Config.PollInterval))
+	ticker := time.NewTicker(d.Config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("Webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+
+		// Keep going while full batches are claimed, so a backlog drains without waiting for the next tick.
+		for ctx.Err() == nil {
+			dispatched, err := d.DispatchDue(context.WithoutCancel(ctx))
+			if err != nil {
+				log.Error("Failed to dispatch webhook deliveries", zap.Error(err))
+			}
+			if err != nil || dispatched < d.Config.BatchSize {
+				break
+			}
+		}
+	}
+}
+
+func (d *webhookDispatcher) DispatchDue(ctx context.Context) (int, error) {
+	deliveries, err := d.WebhookDeliveryRepository.ClaimDue(ctx, d.Config.BatchSize, d.lease())
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.deliver(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+func (d *webhookDispatcher) deliver(ctx context.Context, delivery *model.WebhookDelivery) {
+	log := logger.GetFromContext(ctx)
+
+	attempt := &model.WebhookDeliveryAttempt{AttemptedAt: time.Now()}
+	if delivery.Endpoint == nil || !delivery.Endpoint.Active {
+		attempt.Error = "endpoint is disabled"
+		delivery.RecordAttempt(attempt, nil)
+	} else {
+		status, err := d.send(ctx, delivery)
+		if err != nil {
+			log.Debug("Webhook request failed", zap.Object("delivery", delivery), zap.Error(err))
+		}
+		attempt.ResponseStatus, attempt.Error = status, describeDeliveryFailure(status, err)
+		attempt.DurationMs = time.Since(attempt.AttemptedAt).Milliseconds()
+		delivery.RecordAttempt(attempt, d.nextAttemptAt(delivery.Attempts+1, attempt.Error))
+	}
+
+	if err := d.WebhookDeliveryRepository.SaveAttempt(ctx, delivery, attempt); err != nil {
+		log.Error("Failed to save webhook delivery attempt", zap.Object("delivery", delivery), zap.Error(err))
+		return
+	}
+
+	switch delivery.Status {
+	case model.WebhookDeliveryStatusSucceeded:
+		log.Debug("Webhook delivered", zap.Object("delivery", delivery))
+	case model.WebhookDeliveryStatusPending:
+		log.Warn("Webhook delivery failed, will retry", zap.Object("delivery", delivery), zap.String("error", attempt.Error), zap.Timep("next_attempt_at", delivery.NextAttemptAt))
+	default:
+		log.Warn("Webhook delivery failed permanently", zap.Object("delivery", delivery), zap.String("error", attempt.Error))
+	}
+}
+
+// Posts the delivery's payload to its endpoint, returning the response status and an error unless it was 2xx.
+func (d *webhookDispatcher) send(ctx context.Context, delivery *model.WebhookDelivery) (*int, error) {
+	body, err := json.Marshal(delivery.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "Stage-Zero-Webhooks/1.0")
+	request.Header.Set(WebhookHeaderDelivery, strconv.FormatUint(delivery.ID, 10))
+	request.Header.Set(WebhookHeaderEvent, delivery.EventType)
+	request.Header.Set(WebhookHeaderSignature, utils.SignRequestBody(delivery.Endpoint.Secret, time.Now(), body))
+
+	response, err := d.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, webhookResponseDrainLimit))
+
+	status := response.StatusCode
+	if status < 200 || status >= 300 {
+		return &status, fmt.Errorf("unexpected status %d", status)
+	}
+	return &status, nil
+}
+
+// Describes why an attempt failed, reducing transport errors to their kind, or returns "" if it succeeded.
+func describeDeliveryFailure(status *int, err error) string {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return ""
+	case status != nil:
+		return fmt.Sprintf("unexpected status %d", *status)
+	case errors.Is(err, utils.ErrNonPublicAddress):
+		return "endpoint address is not allowed"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "request timed out"
+	default:
+		return "request failed"
+	}
+}
+
+// Returns when to retry after the given number of failed attempts, or nil once the maximum number of attempts is
+// reached or the attempt succeeded.
+func (d *webhookDispatcher) nextAttemptAt(attempts int, failure string) *time.Time {
+	if failure == "" || attempts >= d.Config.MaxAttempts {
+		return nil
+	}
+
+	next := time.Now().Add(exponentialBackoff(d.Config.BaseBackoff, d.Config.MaxBackoff, attempts))
+	return &next
+}
+
+// Claimed deliveries are retried by another dispatcher if this one has not saved the attempt within the lease.
+func (d *webhookDispatcher) lease() time.Duration {
+	return 2*d.Config.Timeout + time.Minute
+}
+
+// Returns the delay before retrying after the given number of failed attempts: the base delay after the first
+// failure, doubling with each further failure, up to the maximum delay.
+func exponentialBackoff(baseDelay time.Duration, maxDelay time.Duration, attempts int) time.Duration {
+	delay := baseDelay
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
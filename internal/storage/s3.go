@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	s3SigningAlgorithm = "AWS4-HMAC-SHA256"
+	s3UnsignedPayload  = "UNSIGNED-PAYLOAD"
+	s3AmzDateFormat    = "20060102T150405Z"
+	s3SignedHeaders    = "host;x-amz-content-sha256;x-amz-date"
+)
+
+// SHA-256 of an empty request body.
+const s3EmptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// Addresses the bucket as the first path segment instead of a subdomain, as most S3 compatible servers expect.
+	UsePathStyle bool
+}
+
+// s3BlobStore talks to S3 or an S3 compatible server, such as MinIO, over its REST API with Signature Version 4.
+// Uploads are streamed with an unsigned payload, so the endpoint should use TLS outside of local development.
+type s3BlobStore struct {
+	Config   S3Config
+	Client   *http.Client
+	endpoint *url.URL
+}
+
+var _ BlobStore = &s3BlobStore{}
+
+func NewS3BlobStore(config S3Config, client *http.Client) (BlobStore, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, errors.New("invalid S3 endpoint: scheme must be http or https")
+	}
+	if config.Bucket == "" || config.Region == "" {
+		return nil, errors.New("S3 bucket and region are required")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &s3BlobStore{Config: config, Client: client, endpoint: endpoint}, nil
+}
+
+func (s *s3BlobStore) Put(ctx context.Context, key string, content io.Reader, size int64) error {
+	request, err := s.newRequest(ctx, http.MethodPut, key, content)
+	if err != nil {
+		return err
+	}
+	request.ContentLength = size
+	if size == 0 {
+		request.Body = http.NoBody
+	}
+	request.Header.Set("Content-Type", "application/octet-stream")
+	s.sign(request, s3UnsignedPayload)
+
+	response, err := s.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return s.checkResponse(response, key)
+}
+
+func (s *s3BlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	request, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(request, s3EmptyPayloadHash)
+
+	response, err := s.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkResponse(response, key); err != nil {
+		response.Body.Close()
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+func (s *s3BlobStore) Exists(ctx context.Context, key string) (bool, error) {
+	request, err := s.newRequest(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return false, err
+	}
+	s.sign(request, s3EmptyPayloadHash)
+
+	response, err := s.Client.Do(request)
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+
+	err = s.checkResponse(response, key)
+	if errors.Is(err, ErrBlobNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *s3BlobStore) Delete(ctx context.Context, key string) error {
+	request, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	s.sign(request, s3EmptyPayloadHash)
+
+	response, err := s.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if err := s.checkResponse(response, key); err != nil && !errors.Is(err, ErrBlobNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (s *s3BlobStore) newRequest(ctx context.Context, method string, key string, body io.Reader) (*http.Request, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	objectURL := *s.endpoint
+	escapedPath := strings.TrimSuffix(objectURL.EscapedPath(), "/")
+	if s.Config.UsePathStyle {
+		escapedPath += "/" + s3URIEncode(s.Config.Bucket, true)
+	} else {
+		objectURL.Host = s.Config.Bucket + "." + objectURL.Host
+	}
+	escapedPath += "/" + s3URIEncode(key, false)
+
+	path, err := url.PathUnescape(escapedPath)
+	if err != nil {
+		return nil, err
+	}
+	objectURL.Path, objectURL.RawPath = path, escapedPath
+
+	return http.NewRequestWithContext(ctx, method, objectURL.String(), body)
+}
+
+// Signs the request with AWS Signature Version 4 in the Authorization header.
+func (s *s3BlobStore) sign(request *http.Request, payloadHash string) {
+	now := time.Now().UTC()
+	amzDate := now.Format(s3AmzDateFormat)
+	scope := strings.Join([]string{amzDate[:8], s.Config.Region, "s3", "aws4_request"}, "/")
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.RawQuery,
+		"host:" + request.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		s3SignedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{s3SigningAlgorithm, amzDate, scope, hex.EncodeToString(canonicalRequestHash[:])}, "\n")
+
+	signingKey := []byte("AWS4" + s.Config.SecretAccessKey)
+	for _, part := range []string{amzDate[:8], s.Config.Region, "s3", "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3SigningAlgorithm, s.Config.AccessKeyID, scope, s3SignedHeaders, signature))
+}
+
+func (s *s3BlobStore) checkResponse(response *http.Response, key string) error {
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return nil
+	}
+	if response.StatusCode == http.StatusNotFound {
+		return ErrBlobNotFound
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+	return fmt.Errorf("S3 %s %s failed with status %d: %s", response.Request.Method, key, response.StatusCode, strings.TrimSpace(string(body)))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// Percent-encodes everything except unreserved characters, as Signature Version 4 requires. Slashes are kept
+// unless encodeSlash is set.
+func s3URIEncode(value string, encodeSlash bool) string {
+	var builder strings.Builder
+	for _, b := range []byte(value) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9', b == '-', b == '_', b == '.', b == '~':
+			builder.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			builder.WriteByte(b)
+		default:
+			fmt.Fprintf(&builder, "%%%02X", b)
+		}
+	}
+	return builder.String()
+}
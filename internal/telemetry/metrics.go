@@ -20,11 +20,27 @@ type AppMetrics struct {
 	DBConnectionsIdle  metric.Int64Gauge
 	DBQueriesTotal     metric.Int64Counter
 	DBQueryDuration    metric.Float64Histogram
+	DBQueryRows        metric.Int64Histogram
+	DBReplicaHealthy   metric.Int64Gauge
 
 	// Auth metrics
 	AuthAttemptsTotal metric.Int64Counter
 	AuthFailuresTotal metric.Int64Counter
 
+	// WebSocket metrics
+	WebSocketConnectionsActive metric.Int64UpDownCounter
+
+	// Job metrics
+	JobsEnqueuedTotal  metric.Int64Counter
+	JobsProcessedTotal metric.Int64Counter
+	JobDuration        metric.Float64Histogram
+	JobsRunning        metric.Int64UpDownCounter
+
+	// Scheduled task metrics
+	ScheduledTaskRunsTotal metric.Int64Counter
+	ScheduledTaskDuration  metric.Float64Histogram
+	SchedulerLeader        metric.Int64UpDownCounter
+
 	// Business metrics
 	UsersTotal   metric.Int64UpDownCounter
 	SimplesTotal metric.Int64UpDownCounter
@@ -110,6 +126,25 @@ func NewAppMetrics(meter metric.Meter) (*AppMetrics, error) {
 		return nil, err
 	}
 
+	metrics.DBQueryRows, err = meter.Int64Histogram(
+		"db_query_rows",
+		metric.WithDescription("Number of rows returned or affected by database queries"),
+		metric.WithUnit("1"),
+		metric.WithExplicitBucketBoundaries(0, 1, 5, 10, 50, 100, 500, 1000, 5000),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.DBReplicaHealthy, err = meter.Int64Gauge(
+		"db_replica_healthy",
+		metric.WithDescription("Whether a read replica is taking reads, 1 if so"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	// Auth metrics
 	metrics.AuthAttemptsTotal, err = meter.Int64Counter(
 		"auth_attempts_total",
@@ -129,6 +164,83 @@ func NewAppMetrics(meter metric.Meter) (*AppMetrics, error) {
 		return nil, err
 	}
 
+	// WebSocket metrics
+	metrics.WebSocketConnectionsActive, err = meter.Int64UpDownCounter(
+		"websocket_connections_active",
+		metric.WithDescription("Number of open WebSocket connections"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Job metrics
+	metrics.JobsEnqueuedTotal, err = meter.Int64Counter(
+		"jobs_enqueued_total",
+		metric.WithDescription("Total number of background jobs enqueued"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.JobsProcessedTotal, err = meter.Int64Counter(
+		"jobs_processed_total",
+		metric.WithDescription("Total number of background job attempts"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.JobDuration, err = meter.Float64Histogram(
+		"job_duration_seconds",
+		metric.WithDescription("Duration of background job attempts"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.JobsRunning, err = meter.Int64UpDownCounter(
+		"jobs_running",
+		metric.WithDescription("Number of background jobs currently running"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Scheduled task metrics
+	metrics.ScheduledTaskRunsTotal, err = meter.Int64Counter(
+		"scheduled_task_runs_total",
+		metric.WithDescription("Total number of scheduled task runs"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.ScheduledTaskDuration, err = meter.Float64Histogram(
+		"scheduled_task_duration_seconds",
+		metric.WithDescription("Duration of scheduled task runs"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.SchedulerLeader, err = meter.Int64UpDownCounter(
+		"scheduler_leader",
+		metric.WithDescription("Whether this instance is the scheduler leader, 1 if so"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	// Business metrics
 	metrics.UsersTotal, err = meter.Int64UpDownCounter(
 		"users_total",
@@ -168,13 +280,26 @@ func (m *AppMetrics) RecordActiveHTTPRequest(ctx context.Context, delta int64) {
 }
 
 // Database metrics methods
-func (m *AppMetrics) RecordDBQuery(ctx context.Context, operation string, duration float64) {
+// RecordDBQuery records a query on the table by the database that ran it, with its status: ok, not_found or error.
+func (m *AppMetrics) RecordDBQuery(ctx context.Context, operation, table, target, status string, rows int64, duration float64) {
 	attrs := []attribute.KeyValue{
 		attribute.String("operation", operation),
+		attribute.String("table", table),
+		attribute.String("target", target),
+		attribute.String("status", status),
 	}
 
 	m.DBQueriesTotal.Add(ctx, 1, metric.WithAttributes(attrs...))
 	m.DBQueryDuration.Record(ctx, duration, metric.WithAttributes(attrs...))
+	m.DBQueryRows.Record(ctx, rows, metric.WithAttributes(attrs...))
+}
+
+func (m *AppMetrics) RecordDBReplicaHealth(ctx context.Context, target string, healthy bool) {
+	var value int64
+	if healthy {
+		value = 1
+	}
+	m.DBReplicaHealthy.Record(ctx, value, metric.WithAttributes(attribute.String("target", target)))
 }
 
 func (m *AppMetrics) RecordDBConnectionStats(ctx context.Context, stats sql.DBStats) {
@@ -196,6 +321,47 @@ func (m *AppMetrics) RecordAuthAttempt(ctx context.Context, success bool, method
 	}
 }
 
+// WebSocket metrics methods
+func (m *AppMetrics) RecordWebSocketConnection(ctx context.Context, delta int64) {
+	m.WebSocketConnectionsActive.Add(ctx, delta)
+}
+
+// Job metrics methods
+func (m *AppMetrics) RecordJobEnqueued(ctx context.Context, jobType string) {
+	m.JobsEnqueuedTotal.Add(ctx, 1, metric.WithAttributes(attribute.String("type", jobType)))
+}
+
+// RecordJobProcessed records an attempt to run a job, with its outcome: succeeded, retried or dead.
+func (m *AppMetrics) RecordJobProcessed(ctx context.Context, jobType, outcome string, duration float64) {
+	attrs := []attribute.KeyValue{
+		attribute.String("type", jobType),
+		attribute.String("outcome", outcome),
+	}
+
+	m.JobsProcessedTotal.Add(ctx, 1, metric.WithAttributes(attrs...))
+	m.JobDuration.Record(ctx, duration, metric.WithAttributes(attrs...))
+}
+
+func (m *AppMetrics) RecordRunningJob(ctx context.Context, jobType string, delta int64) {
+	m.JobsRunning.Add(ctx, delta, metric.WithAttributes(attribute.String("type", jobType)))
+}
+
+// Scheduled task metrics methods
+func (m *AppMetrics) RecordSchedulerLeader(ctx context.Context, delta int64) {
+	m.SchedulerLeader.Add(ctx, delta)
+}
+
+// RecordScheduledTaskRun records a run of a scheduled task, with its outcome: succeeded or failed.
+func (m *AppMetrics) RecordScheduledTaskRun(ctx context.Context, task, outcome string, duration float64) {
+	attrs := []attribute.KeyValue{
+		attribute.String("task", task),
+		attribute.String("outcome", outcome),
+	}
+
+	m.ScheduledTaskRunsTotal.Add(ctx, 1, metric.WithAttributes(attrs...))
+	m.ScheduledTaskDuration.Record(ctx, duration, metric.WithAttributes(attrs...))
+}
+
 // Business metrics methods
 func (m *AppMetrics) UpdateUserCount(ctx context.Context, count int64) {
 	m.UsersTotal.Add(ctx, count)
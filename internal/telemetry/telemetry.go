@@ -34,6 +34,11 @@ type TelemetryProvider struct {
 
 var globalProvider *TelemetryProvider
 
+const (
+	jobTracerName = "github.com/Verano-20/stage-zero/jobs"
+	dbTracerName  = "github.com/Verano-20/stage-zero/database"
+)
+
 func InitTelemetry() {
 	log := logger.Get()
 	log.Info("Initializing Telemetry...")
@@ -236,3 +241,28 @@ func GetMetrics() *AppMetrics {
 	}
 	return globalProvider.AppMetrics
 }
+
+// StartJobSpan starts the span of an attempt to run a background job.
+func StartJobSpan(ctx context.Context, jobType string, jobID uint64, attempt int) (context.Context, trace.Span) {
+	return otel.Tracer(jobTracerName).Start(ctx, "job "+jobType,
+		trace.WithNewRoot(),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("job.type", jobType),
+			attribute.Int64("job.id", int64(jobID)),
+			attribute.Int("job.attempt", attempt),
+		),
+	)
+}
+
+// StartDBSpan starts the span of a database query, as a child of the span in ctx if there is one. Like
+// StartJobSpan, it does nothing until telemetry is initialized.
+func StartDBSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return otel.Tracer(dbTracerName).Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(operation),
+		),
+	)
+}
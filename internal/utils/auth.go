@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"context"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// The identity of a request is carried in its context, so services and repositories can read it from any
+// context.Context. The router enables gin's context fallback, so a *gin.Context can be read as well.
+type contextKey int
+
+const (
+	userKey contextKey = iota
+	realUserKey
+	sessionIDKey
+	tokenExpiryKey
+	membershipKey
+	requestInfoKey
+)
+
+// RequestInfo describes the HTTP request a context belongs to, for the records that keep where an action came from.
+type RequestInfo struct {
+	Method    string
+	Path      string
+	ClientIP  string
+	UserAgent string
+}
+
+// WithAuthenticatedUser returns a context acting as user. The real user differs when an admin is impersonating
+// another user, and is the user otherwise.
+func WithAuthenticatedUser(ctx context.Context, user *model.User, realUser *model.User) context.Context {
+	ctx = context.WithValue(ctx, userKey, user)
+	return context.WithValue(ctx, realUserKey, realUser)
+}
+
+// WithSessionID returns a context authenticated with the session.
+func WithSessionID(ctx context.Context, sessionID uint) context.Context {
+	return context.WithValue(ctx, sessionIDKey, sessionID)
+}
+
+// WithTokenExpiry returns a context authenticated with a token that expires at expiresAt.
+func WithTokenExpiry(ctx context.Context, expiresAt time.Time) context.Context {
+	return context.WithValue(ctx, tokenExpiryKey, expiresAt)
+}
+
+// WithMembership returns a context acting in the organization of the membership.
+func WithMembership(ctx context.Context, membership *model.Membership) context.Context {
+	return context.WithValue(ctx, membershipKey, membership)
+}
+
+// WithRequestInfo returns a context belonging to the HTTP request described by info.
+func WithRequestInfo(ctx context.Context, info *RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey, info)
+}
+
+// SetRequestContext replaces the context of the request being handled, such as with one returned by
+// WithAuthenticatedUser, so the handlers that follow see it.
+func SetRequestContext(ctx *gin.Context, requestCtx context.Context) {
+	ctx.Request = ctx.Request.WithContext(requestCtx)
+}
+
+// Returns the user loaded by the AuthMiddleware for the current request, or nil if the request is not authenticated.
+func GetAuthenticatedUser(ctx context.Context) *model.User {
+	user, _ := ctx.Value(userKey).(*model.User)
+	return user
+}
+
+// Returns the user who is really making the request. This differs from GetAuthenticatedUser when an admin
+// is impersonating another user.
+func GetRealUser(ctx context.Context) *model.User {
+	if user, ok := ctx.Value(realUserKey).(*model.User); ok && user != nil {
+		return user
+	}
+	return GetAuthenticatedUser(ctx)
+}
+
+// Reports whether the request was made with an impersonation token.
+func IsImpersonating(ctx context.Context) bool {
+	user := GetAuthenticatedUser(ctx)
+	realUser := GetRealUser(ctx)
+	return user != nil && realUser != nil && user.ID != realUser.ID
+}
+
+// Returns the id of the session the request was authenticated with, or 0 for tokens without a session such as
+// impersonation tokens.
+func GetSessionID(ctx context.Context) uint {
+	sessionID, _ := ctx.Value(sessionIDKey).(uint)
+	return sessionID
+}
+
+// Returns when the token the request was authenticated with expires, or the zero time if it is not authenticated.
+func GetTokenExpiry(ctx context.Context) time.Time {
+	expiresAt, _ := ctx.Value(tokenExpiryKey).(time.Time)
+	return expiresAt
+}
+
+// Returns the id of the organization the request was resolved to by the AuthMiddleware, or 0 if it has none.
+func GetOrganizationID(ctx context.Context) uint {
+	if membership := GetMembership(ctx); membership != nil {
+		return membership.OrganizationID
+	}
+	return 0
+}
+
+// Returns the authenticated user's membership of the organization the request was resolved to, or nil if it has none.
+func GetMembership(ctx context.Context) *model.Membership {
+	membership, _ := ctx.Value(membershipKey).(*model.Membership)
+	return membership
+}
+
+// Returns the HTTP request the context belongs to, or nil outside of a request.
+func GetRequestInfo(ctx context.Context) *RequestInfo {
+	info, _ := ctx.Value(requestInfoKey).(*RequestInfo)
+	return info
+}
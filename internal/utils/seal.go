@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+// ErrUnsealable is returned when sealed data was not sealed with the same secret and label, or was altered.
+var ErrUnsealable = errors.New("sealed data cannot be opened")
+
+// Encrypts plaintext with AES-256-GCM under the secret's key for the label, returning the base64 nonce and ciphertext.
+func Seal(plaintext []byte, secret []byte, label string) (string, error) {
+	aead, err := newSealAEAD(secret, label)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+// Decrypts data sealed with Seal with the same secret and label.
+func Unseal(sealed string, secret []byte, label string) ([]byte, error) {
+	aead, err := newSealAEAD(secret, label)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil || len(data) < aead.NonceSize() {
+		return nil, ErrUnsealable
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrUnsealable
+	}
+	return plaintext, nil
+}
+
+func newSealAEAD(secret []byte, label string) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(deriveSealKey(secret, label))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Returns the sealing key for the label, HMAC(secret, label), so the secret is not used as a key directly.
+func deriveSealKey(secret []byte, label string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(label))
+	return mac.Sum(nil)
+}
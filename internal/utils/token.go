@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Generates a URL-safe random token with 256 bits of entropy.
+func GenerateToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// Returns the hex encoded SHA-256 hash of a token, which is persisted instead of the token.
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// Returns the payload followed by an HMAC of it, so a token handed out to a client cannot be altered or forged
+// without the secret. The payload must be URL safe and is not encrypted.
+func SignToken(payload string, secret []byte) string {
+	return payload + "." + signPayload(payload, secret)
+}
+
+// Returns the payload of a token created by SignToken, and false if the signature does not match.
+func VerifySignedToken(token string, secret []byte) (string, bool) {
+	separator := strings.LastIndex(token, ".")
+	if separator < 0 {
+		return "", false
+	}
+	payload, signature := token[:separator], token[separator+1:]
+	if !hmac.Equal([]byte(signPayload(payload, secret)), []byte(signature)) {
+		return "", false
+	}
+	return payload, true
+}
+
+// SignRequestBody returns the signature header value for an outgoing request body: the Unix timestamp and the hex
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret, as "t=<timestamp>,v1=<signature>". Receivers should
+// recompute the signature and reject old timestamps to prevent replays.
+func SignRequestBody(secret string, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "t=" + unix + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func signPayload(payload string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
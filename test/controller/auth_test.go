@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/config"
+	"github.com/Verano-20/stage-zero/internal/controller"
+	"github.com/Verano-20/stage-zero/internal/middleware"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"github.com/Verano-20/stage-zero/test/mocks/repository"
+	mockService "github.com/Verano-20/stage-zero/test/mocks/service"
+	"github.com/Verano-20/stage-zero/test/testutils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Sends a request authenticated with tokenString in the session cookie, with the CSRF header set to csrfToken, or in
+// the Authorization header when bearer is set.
+func sendWithToken(router *gin.Engine, method string, path string, tokenString string, bearer bool, csrfToken string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, nil)
+	if bearer {
+		request.Header.Set("Authorization", "Bearer "+tokenString)
+	} else {
+		request.AddCookie(&http.Cookie{Name: config.Get().Auth.Cookie.Name, Value: tokenString})
+	}
+	if csrfToken != "" {
+		request.Header.Set(utils.CSRFHeaderName, csrfToken)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// Creates a router with the logout route and an authenticated GET /me, and a token for a session the mocked session
+// repository revokes when asked to.
+func createLogoutRouter(t *testing.T) (*gin.Engine, string, *repository.MockSessionRepository) {
+	jwtSecret := config.Get().GetJwtSecret()
+	user := &model.User{ID: 1, Email: "test1@example.com"}
+	now := time.Now()
+	session := &model.Session{ID: 10, UserID: user.ID, CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}
+
+	userRepository := repository.NewMockUserRepository()
+	sessionRepository := repository.NewMockSessionRepository()
+	auditLogger := mockService.NewMockAuditLogger()
+	auditLogger.On("Record", mock.Anything, mock.Anything).Maybe()
+	userRepository.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	sessionRepository.On("Create", mock.Anything, mock.Anything).Return(session, nil).Once()
+	sessionRepository.On("GetByID", mock.Anything, session.ID).Return(session, nil)
+	sessionRepository.On("Revoke", mock.Anything, session.ID).Run(func(mock.Arguments) {
+		revokedAt := time.Now()
+		session.RevokedAt = &revokedAt
+	}).Return(nil).Maybe()
+
+	sessionService := service.NewSessionService(sessionRepository, auditLogger)
+	authService := service.NewAuthService(mockService.NewMockUserService(), sessionService, testutils.PasswordHasher, 5, time.Minute)
+	authController := controller.NewAuthController(mockService.NewMockUserService(), authService, nil, nil, auditLogger)
+	authMiddleware := middleware.NewAuthMiddleware(jwtSecret, config.Get().Auth.Cookie.Name, userRepository, sessionRepository, repository.NewMockMembershipRepository(), repository.NewMockImpersonationRepository(), auditLogger)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.POST("/auth/logout", authController.Logout)
+	router.GET("/me", authMiddleware.AuthenticateRequest, func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	tokenString, err := authService.GenerateTokenString(context.Background(), user, jwtSecret)
+	require.NoError(t, err)
+	return router, tokenString, sessionRepository
+}
+
+func TestLogout_RevokesSession(t *testing.T) {
+	tokenCSRF := func(tokenString string) string {
+		return utils.GenerateCSRFToken(tokenString, config.Get().GetJwtSecret())
+	}
+	tests := []struct {
+		testName           string
+		bearer             bool
+		csrfToken          func(tokenString string) string
+		expectedStatusCode int
+		expectRevoked      bool
+	}{
+		{testName: "Cookie With CSRF Token", csrfToken: tokenCSRF, expectedStatusCode: http.StatusOK, expectRevoked: true},
+		{testName: "Cookie Without CSRF Token", csrfToken: func(string) string { return "" }, expectedStatusCode: http.StatusForbidden},
+		{testName: "Cookie With Wrong CSRF Token", csrfToken: func(string) string { return "forged" }, expectedStatusCode: http.StatusForbidden},
+		{testName: "Bearer Token", bearer: true, csrfToken: func(string) string { return "" }, expectedStatusCode: http.StatusOK, expectRevoked: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			router, tokenString, sessionRepository := createLogoutRouter(t)
+			// when
+			beforeLogout := sendWithToken(router, http.MethodGet, "/me", tokenString, test.bearer, "")
+			logout := sendWithToken(router, http.MethodPost, "/auth/logout", tokenString, test.bearer, test.csrfToken(tokenString))
+			afterLogout := sendWithToken(router, http.MethodGet, "/me", tokenString, test.bearer, "")
+			// then
+			assert.Equal(t, http.StatusOK, beforeLogout.Code)
+			assert.Equal(t, test.expectedStatusCode, logout.Code)
+			if test.expectRevoked {
+				assert.Equal(t, http.StatusUnauthorized, afterLogout.Code)
+				sessionRepository.AssertCalled(t, "Revoke", mock.Anything, uint(10))
+			} else {
+				assert.Equal(t, http.StatusOK, afterLogout.Code)
+				sessionRepository.AssertNotCalled(t, "Revoke", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/controller"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/test/mocks/repository"
+	mockService "github.com/Verano-20/stage-zero/test/mocks/service"
+	"github.com/Verano-20/stage-zero/test/testutils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Streams to the organization member, with a token expiring at expiresAt, until the handler returns.
+func streamSimpleChanges(t *testing.T, streamAuthorizer service.StreamAuthorizer, heartbeatInterval time.Duration, expiresAt time.Time) *httptest.ResponseRecorder {
+	simpleStream := service.NewSimpleStream(repository.NewMockSimpleRepository(), repository.NewMockSimpleChangeListener(), service.SimpleStreamConfig{LogSize: 10, BufferSize: 10, RetryDelay: time.Hour})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.GET("/simple/stream", func(ctx *gin.Context) {
+		testutils.SetAuthenticatedUser(ctx, &organizationMember, &organizationMember)
+		testutils.SetMembership(ctx, &model.Membership{OrganizationID: organizationID, UserID: organizationMember.ID, Role: model.OrganizationRoleMember})
+		testutils.SetTokenExpiry(ctx, expiresAt)
+	}, controller.NewSimpleController(mockService.NewMockSimpleService(), simpleStream, streamAuthorizer, heartbeatInterval).Stream)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/simple/stream", nil))
+	return recorder
+}
+
+func TestStream_EndsWhenNoLongerAuthorized(t *testing.T) {
+	// given
+	streamAuthorizer := mockService.NewMockStreamAuthorizer()
+	membership := &model.Membership{OrganizationID: organizationID, UserID: organizationMember.ID, Role: model.OrganizationRoleMember}
+	// expect
+	streamAuthorizer.On("Reauthorize", mock.Anything).Return(membership, nil).Once()
+	streamAuthorizer.On("Reauthorize", mock.Anything).Return(nil, errors.New("session revoked")).Once()
+	// when
+	recorder := streamSimpleChanges(t, streamAuthorizer, 10*time.Millisecond, time.Now().Add(time.Hour))
+	// then
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, 1, strings.Count(recorder.Body.String(), ": heartbeat"))
+	streamAuthorizer.AssertExpectations(t)
+}
+
+func TestStream_EndsWhenTokenExpires(t *testing.T) {
+	// given
+	streamAuthorizer := mockService.NewMockStreamAuthorizer()
+	// when
+	recorder := streamSimpleChanges(t, streamAuthorizer, time.Hour, time.Now().Add(50*time.Millisecond))
+	// then
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), ": heartbeat")
+	streamAuthorizer.AssertNotCalled(t, "Reauthorize", mock.Anything)
+}
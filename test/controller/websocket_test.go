@@ -0,0 +1,326 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/config"
+	"github.com/Verano-20/stage-zero/internal/controller"
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/telemetry"
+	"github.com/Verano-20/stage-zero/test/mocks/repository"
+	mockService "github.com/Verano-20/stage-zero/test/mocks/service"
+	"github.com/Verano-20/stage-zero/test/testutils"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const organizationID uint = 7
+
+var (
+	organizationMember = model.User{ID: 2, Email: "member@example.com"}
+	webSocketConfig    = controller.WebSocketConfig{
+		SendQueueSize:       8,
+		HeartbeatTimeout:    time.Minute,
+		WriteTimeout:        time.Second,
+		MaxSubscriptions:    2,
+		AllowedOrigins:      []string{"https://app.example.com"},
+		ReauthorizeInterval: time.Minute,
+	}
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("ENABLE_OTLP", "false")
+	os.Setenv("METRIC_INTERVAL", "1h")
+	os.Setenv("JWT_SECRET", "controller-test-secret-key-0123456789")
+	config.InitConfig()
+	logger.InitLogger()
+	telemetry.InitTelemetry()
+	os.Exit(m.Run())
+}
+
+type webSocketServer struct {
+	url              string
+	simpleService    *mockService.MockSimpleService
+	simpleRepository *repository.MockSimpleRepository
+	streamAuthorizer *mockService.MockStreamAuthorizer
+	handle           func(*model.SimpleChangeNotification)
+}
+
+// Serves the WebSocket API to the organization member, streaming the changes passed to handle, until the test ends.
+func startWebSocketServer(t *testing.T) *webSocketServer {
+	return startWebSocketServerWithConfig(t, webSocketConfig, time.Now().Add(time.Hour))
+}
+
+// Serves the WebSocket API like startWebSocketServer, with the config and a token expiring at expiresAt.
+func startWebSocketServerWithConfig(t *testing.T, config controller.WebSocketConfig, expiresAt time.Time) *webSocketServer {
+	simpleService := mockService.NewMockSimpleService()
+	streamAuthorizer := mockService.NewMockStreamAuthorizer()
+	simpleRepository := repository.NewMockSimpleRepository()
+	simpleChangeListener := repository.NewMockSimpleChangeListener()
+	simpleStream := service.NewSimpleStream(simpleRepository, simpleChangeListener, service.SimpleStreamConfig{LogSize: 10, BufferSize: 10, RetryDelay: time.Hour})
+
+	handles := make(chan func(*model.SimpleChangeNotification), 1)
+	simpleChangeListener.On("Listen", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(func(uint64))(0)
+		handles <- args.Get(2).(func(*model.SimpleChangeNotification))
+		<-args.Get(0).(context.Context).Done()
+	}).Return(nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		simpleStream.Run(ctx)
+		close(stopped)
+	}()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.GET("/ws", func(ctx *gin.Context) {
+		testutils.SetAuthenticatedUser(ctx, &organizationMember, &organizationMember)
+		testutils.SetMembership(ctx, &model.Membership{OrganizationID: organizationID, UserID: organizationMember.ID, Role: model.OrganizationRoleMember})
+		testutils.SetTokenExpiry(ctx, expiresAt)
+	}, controller.NewWebSocketController(simpleService, simpleStream, streamAuthorizer, config).Connect)
+	// Connections are hijacked, so the server does not wait for their handlers when closed.
+	var handlers sync.WaitGroup
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		handlers.Add(1)
+		defer handlers.Done()
+		router.ServeHTTP(writer, request)
+	}))
+
+	t.Cleanup(func() {
+		cancel()
+		<-stopped
+		handlers.Wait()
+		server.Close()
+		simpleService.AssertExpectations(t)
+		simpleChangeListener.AssertExpectations(t)
+		streamAuthorizer.AssertExpectations(t)
+	})
+	return &webSocketServer{
+		url:              "ws" + strings.TrimPrefix(server.URL, "http") + "/ws",
+		simpleService:    simpleService,
+		simpleRepository: simpleRepository,
+		streamAuthorizer: streamAuthorizer,
+		handle:           <-handles,
+	}
+}
+
+func (s *webSocketServer) dial(t *testing.T) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial(s.url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func (s *webSocketServer) notify(eventID uint64, simple *model.Simple) {
+	s.simpleRepository.On("GetChanged", mock.Anything, simple.ID).Return(simple, nil).Once()
+	s.handle(&model.SimpleChangeNotification{EventID: eventID, Type: model.EventSimpleUpdated, OrganizationID: simple.OrganizationID, SimpleID: simple.ID})
+}
+
+func ownedSimple(id uint) *model.Simple {
+	return &model.Simple{ID: id, OrganizationID: organizationID, OwnerID: &organizationMember.ID, Name: "Simple"}
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) *model.WebSocketServerMessage {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var message model.WebSocketServerMessage
+	require.NoError(t, conn.ReadJSON(&message))
+	return &message
+}
+
+func TestWebSocket_PushesChangesToSubscribedSimples(t *testing.T) {
+	// given
+	server := startWebSocketServer(t)
+	conn := server.dial(t)
+	// expect
+	server.simpleService.On("GetSimpleByID", mock.Anything, uint64(1)).Return(ownedSimple(1), nil).Once()
+	server.simpleService.On("GetSimpleByID", mock.Anything, uint64(9)).Return(nil, gorm.ErrRecordNotFound).Once()
+	// when
+	require.NoError(t, conn.WriteJSON(model.WebSocketClientMessage{Type: model.WebSocketMessageSubscribe, SimpleIDs: []uint{1, 9}}))
+	notFound := readMessage(t, conn)
+	subscribed := readMessage(t, conn)
+	server.notify(11, ownedSimple(2))
+	server.notify(12, ownedSimple(1))
+	change := readMessage(t, conn)
+	// then
+	assert.Equal(t, &model.WebSocketServerMessage{Type: model.WebSocketMessageError, SimpleIDs: []uint{9}, Error: "Simple not found"}, notFound)
+	assert.Equal(t, &model.WebSocketServerMessage{Type: model.WebSocketMessageSubscribed, SimpleIDs: []uint{1}}, subscribed)
+	assert.Equal(t, model.WebSocketMessageChange, change.Type)
+	assert.Equal(t, uint64(12), change.EventID)
+	assert.Equal(t, uint(1), change.Change.Simple.ID)
+}
+
+func TestWebSocket_StopsPushingUnsubscribedSimples(t *testing.T) {
+	// given
+	server := startWebSocketServer(t)
+	conn := server.dial(t)
+	server.simpleService.On("GetSimpleByID", mock.Anything, uint64(1)).Return(ownedSimple(1), nil).Once()
+	server.simpleService.On("GetSimpleByID", mock.Anything, uint64(2)).Return(ownedSimple(2), nil).Once()
+	require.NoError(t, conn.WriteJSON(model.WebSocketClientMessage{Type: model.WebSocketMessageSubscribe, SimpleIDs: []uint{1, 2}}))
+	readMessage(t, conn)
+	// when
+	require.NoError(t, conn.WriteJSON(model.WebSocketClientMessage{Type: model.WebSocketMessageUnsubscribe, SimpleIDs: []uint{1}}))
+	unsubscribed := readMessage(t, conn)
+	server.notify(11, ownedSimple(1))
+	server.notify(12, ownedSimple(2))
+	change := readMessage(t, conn)
+	// then
+	assert.Equal(t, &model.WebSocketServerMessage{Type: model.WebSocketMessageUnsubscribed, SimpleIDs: []uint{1}}, unsubscribed)
+	assert.Equal(t, uint64(12), change.EventID)
+}
+
+func TestWebSocket_LimitsSubscriptions(t *testing.T) {
+	// given
+	server := startWebSocketServer(t)
+	conn := server.dial(t)
+	// expect
+	server.simpleService.On("GetSimpleByID", mock.Anything, uint64(1)).Return(ownedSimple(1), nil).Once()
+	server.simpleService.On("GetSimpleByID", mock.Anything, uint64(2)).Return(ownedSimple(2), nil).Once()
+	// when
+	require.NoError(t, conn.WriteJSON(model.WebSocketClientMessage{Type: model.WebSocketMessageSubscribe, SimpleIDs: []uint{1, 2, 3}}))
+	overLimit := readMessage(t, conn)
+	subscribed := readMessage(t, conn)
+	// then
+	assert.Equal(t, &model.WebSocketServerMessage{Type: model.WebSocketMessageError, SimpleIDs: []uint{3}, Error: "Too many subscriptions"}, overLimit)
+	assert.Equal(t, &model.WebSocketServerMessage{Type: model.WebSocketMessageSubscribed, SimpleIDs: []uint{1, 2}}, subscribed)
+}
+
+func TestWebSocket_AnswersHeartbeats(t *testing.T) {
+	// given
+	server := startWebSocketServer(t)
+	conn := server.dial(t)
+	// when
+	require.NoError(t, conn.WriteJSON(model.WebSocketClientMessage{Type: model.WebSocketMessagePing}))
+	pong := readMessage(t, conn)
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("not json")))
+	invalid := readMessage(t, conn)
+	// then
+	assert.Equal(t, &model.WebSocketServerMessage{Type: model.WebSocketMessagePong}, pong)
+	assert.Equal(t, &model.WebSocketServerMessage{Type: model.WebSocketMessageError, Error: "Invalid message"}, invalid)
+}
+
+func TestWebSocket_ChecksOrigin(t *testing.T) {
+	tests := []struct {
+		name      string
+		origin    string
+		wantError bool
+	}{
+		{name: "no origin", origin: ""},
+		{name: "allowed origin", origin: "https://app.example.com"},
+		{name: "cross-site origin", origin: "https://evil.example.com", wantError: true},
+	}
+	server := startWebSocketServer(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			header := http.Header{}
+			if tt.origin != "" {
+				header.Set("Origin", tt.origin)
+			}
+			// when
+			conn, response, err := websocket.DefaultDialer.Dial(server.url, header)
+			// then
+			if tt.wantError {
+				assert.Error(t, err)
+				assert.Equal(t, http.StatusForbidden, response.StatusCode)
+				return
+			}
+			require.NoError(t, err)
+			conn.Close()
+		})
+	}
+}
+
+// Reads until the server closes the connection and returns its close message.
+func readCloseError(t *testing.T, conn *websocket.Conn) *websocket.CloseError {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		var closeErr *websocket.CloseError
+		require.ErrorAs(t, err, &closeErr)
+		return closeErr
+	}
+}
+
+func TestWebSocket_ClosesWhenTokenExpires(t *testing.T) {
+	// given
+	server := startWebSocketServerWithConfig(t, webSocketConfig, time.Now().Add(100*time.Millisecond))
+	conn := server.dial(t)
+	// when
+	closeErr := readCloseError(t, conn)
+	// then
+	assert.Equal(t, websocket.ClosePolicyViolation, closeErr.Code)
+	assert.Equal(t, "token expired", closeErr.Text)
+	server.streamAuthorizer.AssertNotCalled(t, "Reauthorize", mock.Anything)
+}
+
+func TestWebSocket_ClosesWhenNoLongerAuthorized(t *testing.T) {
+	tests := []struct {
+		name   string
+		reason string
+	}{
+		{name: "session revoked", reason: "session revoked"},
+		{name: "user disabled", reason: "account disabled"},
+		{name: "membership removed", reason: "not a member of this organization"},
+	}
+	config := webSocketConfig
+	config.ReauthorizeInterval = 20 * time.Millisecond
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			server := startWebSocketServerWithConfig(t, config, time.Now().Add(time.Hour))
+			// expect
+			server.streamAuthorizer.On("Reauthorize", mock.Anything).Return(nil, errors.New(tt.reason)).Once()
+			// when
+			conn := server.dial(t)
+			closeErr := readCloseError(t, conn)
+			// then
+			assert.Equal(t, websocket.ClosePolicyViolation, closeErr.Code)
+			assert.Equal(t, "no longer authorized", closeErr.Text)
+		})
+	}
+}
+
+func TestWebSocket_UnsubscribesSimplesNoLongerVisible(t *testing.T) {
+	// given
+	config := webSocketConfig
+	config.ReauthorizeInterval = 20 * time.Millisecond
+	server := startWebSocketServerWithConfig(t, config, time.Now().Add(time.Hour))
+	membership := &model.Membership{OrganizationID: organizationID, UserID: organizationMember.ID, Role: model.OrganizationRoleMember}
+	// expect
+	server.streamAuthorizer.On("Reauthorize", mock.Anything).Return(membership, nil)
+	server.simpleService.On("GetSimpleByID", mock.Anything, uint64(1)).Return(ownedSimple(1), nil)
+	server.simpleService.On("GetSimpleByID", mock.Anything, uint64(2)).Return(ownedSimple(2), nil).Once()
+	server.simpleService.On("GetSimpleByID", mock.Anything, uint64(2)).Return(nil, gorm.ErrRecordNotFound).Once()
+	// when
+	conn := server.dial(t)
+	require.NoError(t, conn.WriteJSON(model.WebSocketClientMessage{Type: model.WebSocketMessageSubscribe, SimpleIDs: []uint{1, 2}}))
+	subscribed := readMessage(t, conn)
+	unsubscribed := readMessage(t, conn)
+	server.notify(11, ownedSimple(2))
+	server.notify(12, ownedSimple(1))
+	change := readMessage(t, conn)
+	// then
+	assert.Equal(t, &model.WebSocketServerMessage{Type: model.WebSocketMessageSubscribed, SimpleIDs: []uint{1, 2}}, subscribed)
+	assert.Equal(t, &model.WebSocketServerMessage{Type: model.WebSocketMessageUnsubscribed, SimpleIDs: []uint{2}}, unsubscribed)
+	assert.Equal(t, uint64(12), change.EventID)
+}
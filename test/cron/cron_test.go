@@ -0,0 +1,148 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/cron"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// A Wednesday
+var from = time.Date(2025, time.January, 15, 10, 30, 45, 0, time.UTC)
+
+func TestScheduleNext(t *testing.T) {
+	tests := []struct {
+		testName   string
+		expression string
+		after      time.Time
+		expected   time.Time
+	}{
+		{
+			testName:   "Every minute",
+			expression: "* * * * *",
+			after:      from,
+			expected:   time.Date(2025, time.January, 15, 10, 31, 0, 0, time.UTC),
+		},
+		{
+			testName:   "Strictly after an exact match",
+			expression: "30 10 * * *",
+			after:      time.Date(2025, time.January, 15, 10, 30, 0, 0, time.UTC),
+			expected:   time.Date(2025, time.January, 16, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			testName:   "Steps",
+			expression: "*/20 * * * *",
+			after:      from,
+			expected:   time.Date(2025, time.January, 15, 10, 40, 0, 0, time.UTC),
+		},
+		{
+			testName:   "Value with step",
+			expression: "5/20 * * * *",
+			after:      from,
+			expected:   time.Date(2025, time.January, 15, 10, 45, 0, 0, time.UTC),
+		},
+		{
+			testName:   "Lists and ranges",
+			expression: "0 9-10,14 * * *",
+			after:      from,
+			expected:   time.Date(2025, time.January, 15, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			testName:   "Day of week names",
+			expression: "0 8 * * mon-fri",
+			after:      time.Date(2025, time.January, 17, 9, 0, 0, 0, time.UTC),
+			expected:   time.Date(2025, time.January, 20, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			testName:   "Sunday as 7",
+			expression: "0 0 * * 7",
+			after:      from,
+			expected:   time.Date(2025, time.January, 19, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			testName:   "Month names roll over the year",
+			expression: "0 0 1 jan *",
+			after:      from,
+			expected:   time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			testName:   "Day of month or day of week",
+			expression: "0 0 20 * fri",
+			after:      from,
+			expected:   time.Date(2025, time.January, 17, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			testName:   "Skips short months",
+			expression: "0 0 31 * *",
+			after:      time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC),
+			expected:   time.Date(2025, time.March, 31, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			testName:   "Leap day",
+			expression: "0 0 29 2 *",
+			after:      from,
+			expected:   time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			testName:   "Macro",
+			expression: "@daily",
+			after:      from,
+			expected:   time.Date(2025, time.January, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			testName:   "Never",
+			expression: "0 0 30 2 *",
+			after:      from,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			// given
+			schedule, err := cron.Parse(tt.expression)
+			require.NoError(t, err)
+			// when
+			next := schedule.Next(tt.after)
+			// then
+			assert.Equal(t, tt.expected, next)
+			assert.Equal(t, tt.expression, schedule.String())
+		})
+	}
+}
+
+func TestScheduleNext_KeepsLocation(t *testing.T) {
+	// given
+	location := time.FixedZone("UTC+2", 2*60*60)
+	schedule := cron.MustParse("0 9 * * *")
+	// when
+	next := schedule.Next(from.In(location))
+	// then
+	assert.Equal(t, time.Date(2025, time.January, 16, 9, 0, 0, 0, location), next)
+}
+
+func TestParse_RejectsInvalidExpressions(t *testing.T) {
+	for _, expression := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"@every 5m",
+	} {
+		t.Run(expression, func(t *testing.T) {
+			// when
+			schedule, err := cron.Parse(expression)
+			// then
+			assert.Error(t, err)
+			assert.Nil(t, schedule)
+		})
+	}
+}
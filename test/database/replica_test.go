@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/database"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"github.com/Verano-20/stage-zero/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Opens a database on a primary that routes reads to two replicas. The databases share a log of the statements
+// sent to them, which tells the tests where reads were sent.
+func createRoutedDB(t *testing.T, config database.ReplicaRouterConfig) (*gorm.DB, *database.ReplicaRouter, []*testutils.ConnPool, *[]string) {
+	log := &[]string{}
+	replicas := []*testutils.ConnPool{{Name: "replica1", Log: log}, {Name: "replica2", Log: log}}
+	router := database.NewReplicaRouter([]database.ReplicaPool{replicas[0], replicas[1]}, config)
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: &testutils.ConnPool{Name: "primary", Log: log}}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(router))
+	return db, router, replicas, log
+}
+
+// Reads a user and returns where the read was sent.
+func readUser(ctx context.Context, db *gorm.DB, log *[]string) string {
+	var user model.User
+	_ = database.PreferReplica(db.WithContext(ctx)).First(&user, "id = ?", 1).Error
+	target, _, _ := strings.Cut((*log)[len(*log)-1], ": ")
+	return target
+}
+
+func TestReplicaRouter_RoutesReadsRoundRobin(t *testing.T) {
+	// given
+	db, _, _, log := createRoutedDB(t, database.ReplicaRouterConfig{HealthCheckInterval: time.Second})
+	ctx := context.Background()
+	// when
+	targets := []string{readUser(ctx, db, log), readUser(ctx, db, log), readUser(ctx, db, log)}
+	// then
+	assert.Equal(t, []string{"replica1", "replica2", "replica1"}, targets)
+}
+
+func TestReplicaRouter_SendsOtherQueriesToPrimary(t *testing.T) {
+	// given
+	db, _, _, log := createRoutedDB(t, database.ReplicaRouterConfig{HealthCheckInterval: time.Second})
+	// when
+	var user model.User
+	_ = db.WithContext(context.Background()).First(&user, "id = ?", 1).Error
+	// then
+	require.Len(t, *log, 1)
+	assert.True(t, strings.HasPrefix((*log)[0], `primary: SELECT * FROM "users"`))
+}
+
+func TestReplicaRouter_EjectsAndRestoresReplicas(t *testing.T) {
+	// given
+	db, router, replicas, log := createRoutedDB(t, database.ReplicaRouterConfig{HealthCheckInterval: time.Second})
+	ctx := context.Background()
+	replicas[0].PingErr = errors.New("connection refused")
+	// when
+	router.CheckHealth(ctx)
+	ejectedTargets := []string{readUser(ctx, db, log), readUser(ctx, db, log)}
+	replicas[0].PingErr = nil
+	router.CheckHealth(ctx)
+	restoredTargets := []string{readUser(ctx, db, log), readUser(ctx, db, log)}
+	// then
+	assert.Equal(t, []string{"replica2", "replica2"}, ejectedTargets)
+	assert.ElementsMatch(t, []string{"replica1", "replica2"}, restoredTargets)
+}
+
+func TestReplicaRouter_FallsBackToPrimaryWithoutHealthyReplica(t *testing.T) {
+	// given
+	db, router, replicas, log := createRoutedDB(t, database.ReplicaRouterConfig{HealthCheckInterval: time.Second})
+	ctx := context.Background()
+	for _, replica := range replicas {
+		replica.PingErr = errors.New("connection refused")
+	}
+	// when
+	router.CheckHealth(ctx)
+	// then
+	assert.Equal(t, "primary", readUser(ctx, db, log))
+}
+
+func TestReplicaRouter_ReadsInTransactionUsePrimary(t *testing.T) {
+	// given
+	db, _, _, log := createRoutedDB(t, database.ReplicaRouterConfig{HealthCheckInterval: time.Second})
+	// when
+	var target string
+	_ = db.Transaction(func(tx *gorm.DB) error {
+		target = readUser(context.Background(), tx, log)
+		return nil
+	})
+	// then
+	assert.Equal(t, "primary tx", target)
+}
+
+func TestReplicaRouter_ReadsInStateChangingRequestsUsePrimary(t *testing.T) {
+	tests := []struct {
+		testName       string
+		method         string
+		expectedTarget string
+	}{
+		{testName: "GET reads from a replica", method: http.MethodGet, expectedTarget: "replica1"},
+		{testName: "PUT reads from the primary", method: http.MethodPut, expectedTarget: "primary"},
+		{testName: "DELETE reads from the primary", method: http.MethodDelete, expectedTarget: "primary"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			db, _, _, log := createRoutedDB(t, database.ReplicaRouterConfig{HealthCheckInterval: time.Second})
+			ctx := utils.WithRequestInfo(context.Background(), &utils.RequestInfo{Method: test.method, Path: "/simples/1"})
+			// when
+			target := readUser(ctx, db, log)
+			// then
+			assert.Equal(t, test.expectedTarget, target)
+		})
+	}
+}
+
+func TestReplicaRouter_ReadsYourWrites(t *testing.T) {
+	// given
+	db, _, _, log := createRoutedDB(t, database.ReplicaRouterConfig{HealthCheckInterval: time.Second, ReadYourWritesWindow: 50 * time.Millisecond})
+	writer := &model.User{ID: 1}
+	writerCtx := utils.WithAuthenticatedUser(context.Background(), writer, writer)
+	otherUser := &model.User{ID: 2}
+	otherCtx := utils.WithAuthenticatedUser(context.Background(), otherUser, otherUser)
+	// when
+	require.NoError(t, db.WithContext(writerCtx).Model(writer).Update("email", "new@example.com").Error)
+	writerTarget := readUser(writerCtx, db, log)
+	otherTarget := readUser(otherCtx, db, log)
+	time.Sleep(60 * time.Millisecond)
+	laterWriterTarget := readUser(writerCtx, db, log)
+	// then
+	assert.Equal(t, "primary", writerTarget)
+	assert.Contains(t, otherTarget, "replica")
+	assert.Contains(t, laterWriterTarget, "replica")
+}
+
+func TestReplicaRouter_LabelsQueriesWithTarget(t *testing.T) {
+	// given
+	db, recorder := createTracedDB(t, false)
+	router := database.NewReplicaRouter([]database.ReplicaPool{&testutils.ConnPool{Name: "replica1"}}, database.ReplicaRouterConfig{HealthCheckInterval: time.Second})
+	require.NoError(t, db.Use(router))
+	ctx := context.Background()
+	// when
+	var user model.User
+	_ = database.PreferReplica(db.WithContext(ctx)).First(&user, "id = ?", 1).Error
+	_ = db.WithContext(ctx).First(&user, "id = ?", 1).Error
+	// then
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "replica1", spanAttribute(spans[0], "db.target").AsString())
+	assert.Equal(t, database.PrimaryTarget, spanAttribute(spans[1], "db.target").AsString())
+}
+
+func TestReplicaRouter_UserLookupsUsePrimary(t *testing.T) {
+	// given
+	db, _, _, log := createRoutedDB(t, database.ReplicaRouterConfig{HealthCheckInterval: time.Second})
+	target := repository.NewUserRepository(db)
+	// A GET request would read from a replica, which may still see a disabled user as enabled.
+	ctx := utils.WithRequestInfo(context.Background(), &utils.RequestInfo{Method: http.MethodGet, Path: "/simples"})
+	// when
+	_, getByIDErr := target.GetByID(ctx, 1)
+	_, getByEmailErr := target.GetByEmail(ctx, "test1@example.com")
+	// then
+	assert.ErrorIs(t, getByIDErr, testutils.ErrConnPool)
+	assert.ErrorIs(t, getByEmailErr, testutils.ErrConnPool)
+	require.Len(t, *log, 2)
+	for _, statement := range *log {
+		assert.True(t, strings.HasPrefix(statement, `primary: SELECT * FROM "users"`), statement)
+	}
+	assert.Equal(t, "replica1", readUser(ctx, db, log))
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/Verano-20/stage-zero/internal/config"
+	"github.com/Verano-20/stage-zero/internal/database"
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/telemetry"
+	"github.com/Verano-20/stage-zero/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("ENABLE_OTLP", "false")
+	os.Setenv("METRIC_INTERVAL", "1h")
+	config.InitConfig()
+	logger.InitLogger()
+	telemetry.InitTelemetry()
+	os.Exit(m.Run())
+}
+
+// Opens a database with the telemetry plugin, and records the spans it ends. A dry run database builds statements
+// without executing them, otherwise every statement fails.
+func createTracedDB(t *testing.T, dryRun bool) (*gorm.DB, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: &testutils.ConnPool{ExecErr: testutils.ErrConnPool}}), &gorm.Config{
+		DryRun:                 dryRun,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(database.TelemetryPlugin{}))
+	return db, recorder
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTelemetryPlugin_TracesQueryUnderContextSpan(t *testing.T) {
+	// given
+	db, recorder := createTracedDB(t, true)
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	// when
+	var users model.Users
+	err := db.WithContext(ctx).Where("email = ?", "secret@example.com").Find(&users).Error
+	parent.End()
+	// then
+	require.NoError(t, err)
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	span := spans[0]
+	assert.Equal(t, "select users", span.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	assert.Equal(t, "users", spanAttribute(span, "db.collection.name").AsString())
+	assert.Equal(t, codes.Unset, span.Status().Code)
+}
+
+func TestTelemetryPlugin_RedactsStatementParameters(t *testing.T) {
+	// given
+	db, recorder := createTracedDB(t, true)
+	// when
+	err := db.WithContext(context.Background()).Create(&model.User{Email: "secret@example.com", PasswordHash: "secret-hash"}).Error
+	// then
+	require.NoError(t, err)
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	statement := spanAttribute(spans[0], "db.statement").AsString()
+	assert.Contains(t, statement, `INSERT INTO "users"`)
+	assert.Contains(t, statement, "$1")
+	assert.NotContains(t, statement, "secret")
+}
+
+func TestTelemetryPlugin_RecordsFailedQuery(t *testing.T) {
+	// given
+	db, recorder := createTracedDB(t, false)
+	// when
+	var user model.User
+	err := db.WithContext(context.Background()).First(&user, "id = ?", 1).Error
+	// then
+	assert.ErrorIs(t, err, testutils.ErrConnPool)
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, testutils.ErrConnPool.Error(), spans[0].Status().Description)
+}
+
+func TestTelemetryPlugin_DoesNotNestQueriesOnReusedStatement(t *testing.T) {
+	// given
+	db, recorder := createTracedDB(t, true)
+	query := db.WithContext(context.Background()).Model(&model.User{}).Where("email = ?", "user@example.com")
+	// when
+	var count int64
+	var users model.Users
+	require.NoError(t, query.Count(&count).Error)
+	require.NoError(t, query.Find(&users).Error)
+	// then
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	for _, span := range spans {
+		assert.False(t, span.Parent().IsValid(), span.Name())
+	}
+}
@@ -0,0 +1,166 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/events"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createDomainEvent() *model.DomainEvent {
+	return &model.DomainEvent{
+		ID:            11,
+		Type:          model.EventSimpleCreated,
+		AggregateType: model.AggregateTypeSimple,
+		AggregateID:   "3",
+		OccurredAt:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Data:          model.JSONMap{"id": float64(3), "name": "Simple"},
+	}
+}
+
+type natsMessage struct {
+	Subject string
+	Payload []byte
+}
+
+// Starts a minimal NATS server that accepts one connection, records the CONNECT options and published messages, and
+// answers each PING with the given reply.
+func startNATSStandIn(t *testing.T, pingReply string) (string, <-chan string, <-chan natsMessage) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	connects := make(chan string, 1)
+	messages := make(chan natsMessage, 10)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		conn.Write([]byte(`INFO {"server_id":"stand-in","max_payload":1048576}` + "\r\n"))
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			switch {
+			case strings.HasPrefix(line, "CONNECT "):
+				connects <- strings.TrimPrefix(line, "CONNECT ")
+			case strings.HasPrefix(line, "PUB "):
+				fields := strings.Fields(line)
+				size, _ := strconv.Atoi(fields[len(fields)-1])
+				payload := make([]byte, size+2)
+				if _, err := io.ReadFull(reader, payload); err != nil {
+					return
+				}
+				messages <- natsMessage{Subject: fields[1], Payload: payload[:size]}
+			case line == "PING":
+				conn.Write([]byte(pingReply + "\r\n"))
+			}
+		}
+	}()
+	return "nats://user:secret@" + listener.Addr().String(), connects, messages
+}
+
+func TestNATSPublisher_PublishesEventToSubject(t *testing.T) {
+	// given
+	url, connects, messages := startNATSStandIn(t, "PONG")
+	target, err := events.NewNATSPublisher(events.NATSConfig{URL: url, SubjectPrefix: "stage-zero", Timeout: time.Second})
+	require.NoError(t, err)
+	event := createDomainEvent()
+	// when
+	err = target.Publish(context.Background(), event)
+	// then
+	require.NoError(t, err)
+	var options map[string]any
+	require.NoError(t, json.Unmarshal([]byte(<-connects), &options))
+	assert.Equal(t, "user", options["user"])
+	assert.Equal(t, "secret", options["pass"])
+	message := <-messages
+	assert.Equal(t, "stage-zero.simple.created", message.Subject)
+	var published model.DomainEvent
+	require.NoError(t, json.Unmarshal(message.Payload, &published))
+	assert.Equal(t, *event, published)
+}
+
+func TestNATSPublisher_ServerError(t *testing.T) {
+	// given
+	url, _, _ := startNATSStandIn(t, "-ERR 'Permissions Violation for Publish'")
+	target, err := events.NewNATSPublisher(events.NATSConfig{URL: url, Timeout: time.Second})
+	require.NoError(t, err)
+	// when
+	err = target.Publish(context.Background(), createDomainEvent())
+	// then
+	assert.EqualError(t, err, "NATS server error: 'Permissions Violation for Publish'")
+}
+
+func TestNewNATSPublisher_InvalidURL(t *testing.T) {
+	for _, url := range []string{"http://localhost:4222", "nats://", "://"} {
+		t.Run(url, func(t *testing.T) {
+			// when
+			target, err := events.NewNATSPublisher(events.NATSConfig{URL: url, Timeout: time.Second})
+			// then
+			assert.Error(t, err)
+			assert.Nil(t, target)
+		})
+	}
+}
+
+func TestWebhookPublisher_SignsAndPostsEvent(t *testing.T) {
+	// given
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	target := events.NewWebhookPublisher(server.URL, "secret", nil)
+	event := createDomainEvent()
+	// when
+	err := target.Publish(context.Background(), event)
+	// then
+	require.NoError(t, err)
+	require.NotNil(t, received)
+	assert.Equal(t, "11", received.Header.Get(events.HeaderEventID))
+	assert.Equal(t, model.EventSimpleCreated, received.Header.Get(events.HeaderEventType))
+	signature := received.Header.Get(events.HeaderSignature)
+	timestamp, _, _ := strings.Cut(strings.TrimPrefix(signature, "t="), ",")
+	unix, parseErr := strconv.ParseInt(timestamp, 10, 64)
+	require.NoError(t, parseErr)
+	assert.Equal(t, utils.SignRequestBody("secret", time.Unix(unix, 0), receivedBody), signature)
+	var published model.DomainEvent
+	require.NoError(t, json.Unmarshal(receivedBody, &published))
+	assert.Equal(t, *event, published)
+}
+
+func TestWebhookPublisher_UnexpectedStatus(t *testing.T) {
+	// given
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	target := events.NewWebhookPublisher(server.URL, "", nil)
+	// when
+	err := target.Publish(context.Background(), createDomainEvent())
+	// then
+	assert.EqualError(t, err, "unexpected status 502")
+}
@@ -1,8 +1,11 @@
 package middleware
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"net/http"
 	"testing"
@@ -10,31 +13,60 @@ import (
 
 	"github.com/Verano-20/stage-zero/internal/middleware"
 	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/utils"
 	"github.com/Verano-20/stage-zero/test/mocks/repository"
+	mockService "github.com/Verano-20/stage-zero/test/mocks/service"
 	"github.com/Verano-20/stage-zero/test/testutils"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 var (
-	user1 = model.User{ID: 1, Email: "test1@example.com"}
-	user2 = model.User{ID: 2, Email: "test2@example.com"}
+	user1    = model.User{ID: 1, Email: "test1@example.com"}
+	user2    = model.User{ID: 2, Email: "test2@example.com"}
+	session1 = model.Session{ID: 10, UserID: user1.ID}
 )
 
-func createMiddlewareAndMockRepo(t *testing.T) (*middleware.AuthMiddleware, *repository.MockUserRepository) {
+func createMiddlewareAndMockRepo(t *testing.T) (*middleware.AuthMiddleware, *repository.MockUserRepository, *repository.MockSessionRepository) {
+	target, userRepository, sessionRepository, _ := createMiddlewareAndMockRepos(t)
+	return target, userRepository, sessionRepository
+}
+
+func createMiddlewareAndMockRepos(t *testing.T) (*middleware.AuthMiddleware, *repository.MockUserRepository, *repository.MockSessionRepository, *repository.MockImpersonationRepository) {
+	auditLogger := mockService.NewMockAuditLogger()
+	auditLogger.On("Record", mock.Anything, mock.Anything).Maybe()
+	return createMiddlewareWithAuditLogger(t, auditLogger)
+}
+
+func createMiddlewareWithAuditLogger(t *testing.T, auditLogger *mockService.MockAuditLogger) (*middleware.AuthMiddleware, *repository.MockUserRepository, *repository.MockSessionRepository, *repository.MockImpersonationRepository) {
 	userRepository := repository.NewMockUserRepository()
+	sessionRepository := repository.NewMockSessionRepository()
+	impersonationRepository := repository.NewMockImpersonationRepository()
 	defer userRepository.AssertExpectations(t)
-	target := middleware.NewAuthMiddleware([]byte("test-secret-key"), userRepository)
-	return target, userRepository
+	defer sessionRepository.AssertExpectations(t)
+	defer impersonationRepository.AssertExpectations(t)
+	target := middleware.NewAuthMiddleware([]byte("test-secret-key"), "session", userRepository, sessionRepository, repository.NewMockMembershipRepository(), impersonationRepository, auditLogger)
+	return target, userRepository, sessionRepository, impersonationRepository
+}
+
+func createMiddlewareAndMockMembershipRepo(t *testing.T) (*middleware.AuthMiddleware, *repository.MockMembershipRepository) {
+	membershipRepository := repository.NewMockMembershipRepository()
+	auditLogger := mockService.NewMockAuditLogger()
+	auditLogger.On("Record", mock.Anything, mock.Anything).Maybe()
+	defer membershipRepository.AssertExpectations(t)
+	target := middleware.NewAuthMiddleware([]byte("test-secret-key"), "session", repository.NewMockUserRepository(), repository.NewMockSessionRepository(), membershipRepository, repository.NewMockImpersonationRepository(), auditLogger)
+	return target, membershipRepository
 }
 
 func TestAuthenticateRequest_Success(t *testing.T) {
 	// given
-	validAuthHeader := "Bearer " + createHmacSignedToken(int64Ptr(time.Now().Add(time.Minute*1).Unix()), uintPtr(user1.ID))
+	validAuthHeader := "Bearer " + createSessionToken(user1.ID, session1.ID)
 	ctx, recorder := testutils.CreateTestContextWithAuthHeader(validAuthHeader)
-	target, userRepository := createMiddlewareAndMockRepo(t)
+	target, userRepository, sessionRepository := createMiddlewareAndMockRepo(t)
 	// expect
 	userRepository.On("GetByID", ctx, user1.ID).Return(&user1, nil).Once()
+	sessionRepository.On("GetByID", ctx, session1.ID).Return(createActiveSession(session1.ID, user1.ID), nil).Once()
 	// when
 	target.AuthenticateRequest(ctx)
 	// then
@@ -42,6 +74,86 @@ func TestAuthenticateRequest_Success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, recorder.Code)
 	assert.Equal(t, user1.ID, ctx.GetUint("user_id"))
 	assert.Equal(t, user1.Email, ctx.GetString("user_email"))
+	assert.Equal(t, session1.ID, utils.GetSessionID(ctx))
+}
+
+func TestAuthenticateRequest_RevokedToken(t *testing.T) {
+	// given
+	tokensValidAfter := time.Now().Truncate(time.Second)
+	revokedUser := model.User{ID: 3, Email: "test3@example.com", TokensValidAfter: &tokensValidAfter}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": revokedUser.ID,
+		"iat": tokensValidAfter.Add(-time.Minute).Unix(),
+		"exp": time.Now().Add(time.Minute).Unix(),
+	})
+	tokenString, _ := token.SignedString([]byte("test-secret-key"))
+	ctx, recorder := testutils.CreateTestContextWithAuthHeader("Bearer " + tokenString)
+	target, userRepository, _ := createMiddlewareAndMockRepo(t)
+	// expect
+	userRepository.On("GetByID", ctx, revokedUser.ID).Return(&revokedUser, nil).Once()
+	// when
+	target.AuthenticateRequest(ctx)
+	// then
+	assert.True(t, ctx.IsAborted())
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "token revoked")
+}
+
+func TestAuthenticateRequest_TokensRevokedWithinSameSecond(t *testing.T) {
+	issuedAt := time.Now()
+	tokensValidAfter := issuedAt.Add(time.Millisecond)
+	revokedUser := model.User{ID: user1.ID, Email: user1.Email, TokensValidAfter: &tokensValidAfter}
+	admin := model.User{ID: 10, Email: "admin@example.com", Role: model.UserRoleAdmin}
+	sessionBeforeRevocation := createActiveSession(session1.ID, user1.ID)
+	sessionBeforeRevocation.CreatedAt = issuedAt
+	sessionAfterRevocation := createActiveSession(session1.ID, user1.ID)
+	sessionAfterRevocation.CreatedAt = tokensValidAfter.Add(time.Millisecond)
+	tests := []struct {
+		testName             string
+		tokenString          string
+		session              *model.Session
+		expectedStatusCode   int
+		expectedErrorMessage string
+	}{
+		{
+			testName:             "Session Started Before Revocation",
+			tokenString:          createSessionToken(user1.ID, session1.ID),
+			session:              sessionBeforeRevocation,
+			expectedStatusCode:   http.StatusUnauthorized,
+			expectedErrorMessage: "session revoked",
+		},
+		{
+			testName:           "Session Started After Revocation",
+			tokenString:        createSessionToken(user1.ID, session1.ID),
+			session:            sessionAfterRevocation,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			testName:             "Impersonation Token Issued Before Revocation",
+			tokenString:          createImpersonationToken(user1.ID, admin.ID),
+			expectedStatusCode:   http.StatusUnauthorized,
+			expectedErrorMessage: "token revoked",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx, recorder := testutils.CreateTestContextWithAuthHeader("Bearer " + test.tokenString)
+			target, userRepository, sessionRepository := createMiddlewareAndMockRepo(t)
+			// expect
+			userRepository.On("GetByID", ctx, user1.ID).Return(&revokedUser, nil).Once()
+			if test.session != nil {
+				sessionRepository.On("GetByID", ctx, session1.ID).Return(test.session, nil).Once()
+			}
+			// when
+			target.AuthenticateRequest(ctx)
+			// then
+			assert.Equal(t, test.expectedStatusCode, recorder.Code)
+			assert.Equal(t, test.expectedErrorMessage != "", ctx.IsAborted())
+			assert.Contains(t, recorder.Body.String(), test.expectedErrorMessage)
+		})
+	}
 }
 
 func TestAuthenticateRequest_Failure(t *testing.T) {
@@ -99,7 +211,7 @@ func TestAuthenticateRequest_Failure(t *testing.T) {
 		t.Run(test.testName, func(t *testing.T) {
 			// given
 			ctx, recorder := testutils.CreateTestContextWithAuthHeader(test.authHeader)
-			target, userRepository := createMiddlewareAndMockRepo(t)
+			target, userRepository, _ := createMiddlewareAndMockRepo(t)
 			// expect
 			userRepository.On("GetByID", ctx, user1.ID).Return(&user1, nil).Maybe()
 			userRepository.On("GetByID", ctx, user2.ID).Return(nil, errors.New("user not found")).Maybe()
@@ -113,6 +225,306 @@ func TestAuthenticateRequest_Failure(t *testing.T) {
 	}
 }
 
+func TestAuthenticateRequest_SessionCookie(t *testing.T) {
+	// given
+	tokenString := createSessionToken(user1.ID, session1.ID)
+	ctx, recorder := testutils.CreateTestContextWithSessionCookie(http.MethodGet, "session", tokenString)
+	target, userRepository, sessionRepository := createMiddlewareAndMockRepo(t)
+	// expect
+	userRepository.On("GetByID", ctx, user1.ID).Return(&user1, nil).Once()
+	sessionRepository.On("GetByID", ctx, session1.ID).Return(createActiveSession(session1.ID, user1.ID), nil).Once()
+	// when
+	target.AuthenticateRequest(ctx)
+	// then
+	assert.False(t, ctx.IsAborted())
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, user1.ID, ctx.GetUint("user_id"))
+	assert.True(t, utils.IsCookieAuthenticated(ctx))
+}
+
+func TestAuthenticateRequest_SessionCookieCSRF(t *testing.T) {
+	tokenString := createSessionToken(user1.ID, session1.ID)
+	otherTokenString := createSessionToken(user1.ID, session1.ID+1)
+	tests := []struct {
+		testName        string
+		csrfToken       string
+		expectedAborted bool
+	}{
+		{testName: "Missing CSRF Token", csrfToken: "", expectedAborted: true},
+		{testName: "Invalid CSRF Token", csrfToken: "not-a-csrf-token", expectedAborted: true},
+		{testName: "CSRF Token For Another Session", csrfToken: utils.GenerateCSRFToken(otherTokenString, []byte("test-secret-key")), expectedAborted: true},
+		{testName: "CSRF Token Keyed With Signing Secret", csrfToken: hmacSHA256(tokenString, []byte("test-secret-key")), expectedAborted: true},
+		{testName: "Valid CSRF Token", csrfToken: utils.GenerateCSRFToken(tokenString, []byte("test-secret-key")), expectedAborted: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx, recorder := testutils.CreateTestContextWithSessionCookie(http.MethodPost, "session", tokenString)
+			ctx.Request.Header.Set(utils.CSRFHeaderName, test.csrfToken)
+			target, userRepository, sessionRepository := createMiddlewareAndMockRepo(t)
+			// expect
+			if !test.expectedAborted {
+				userRepository.On("GetByID", ctx, user1.ID).Return(&user1, nil).Once()
+				sessionRepository.On("GetByID", ctx, session1.ID).Return(createActiveSession(session1.ID, user1.ID), nil).Once()
+			}
+			// when
+			target.AuthenticateRequest(ctx)
+			// then
+			assert.Equal(t, test.expectedAborted, ctx.IsAborted())
+			if test.expectedAborted {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+				assert.Contains(t, recorder.Body.String(), "invalid CSRF token")
+			}
+		})
+	}
+}
+
+func TestAuthenticateRequest_BearerTokenSkipsCSRF(t *testing.T) {
+	// given
+	tokenString := createSessionToken(user1.ID, session1.ID)
+	ctx, recorder := testutils.CreateTestContextWithSessionCookie(http.MethodPost, "session", "stale-cookie-token")
+	ctx.Request.Header.Set("Authorization", "Bearer "+tokenString)
+	target, userRepository, sessionRepository := createMiddlewareAndMockRepo(t)
+	// expect
+	userRepository.On("GetByID", ctx, user1.ID).Return(&user1, nil).Once()
+	sessionRepository.On("GetByID", ctx, session1.ID).Return(createActiveSession(session1.ID, user1.ID), nil).Once()
+	// when
+	target.AuthenticateRequest(ctx)
+	// then
+	assert.False(t, ctx.IsAborted())
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.False(t, utils.IsCookieAuthenticated(ctx))
+}
+
+func TestAuthenticateRequest_SessionFailure(t *testing.T) {
+	revokedAt := time.Now()
+	revokedSession := createActiveSession(session1.ID, user1.ID)
+	revokedSession.RevokedAt = &revokedAt
+	expiredSession := createActiveSession(session1.ID, user1.ID)
+	expiredSession.ExpiresAt = time.Now().Add(-time.Minute)
+	tests := []struct {
+		testName             string
+		tokenString          string
+		session              *model.Session
+		sessionErr           error
+		expectedErrorMessage string
+	}{
+		{
+			testName:             "Missing Session Claim",
+			tokenString:          createHmacSignedToken(int64Ptr(time.Now().Add(time.Minute*1).Unix()), uintPtr(user1.ID)),
+			expectedErrorMessage: "invalid token claims",
+		},
+		{
+			testName:             "Session Not Found",
+			tokenString:          createSessionToken(user1.ID, session1.ID),
+			sessionErr:           errors.New("record not found"),
+			expectedErrorMessage: "invalid session",
+		},
+		{
+			testName:             "Session Revoked",
+			tokenString:          createSessionToken(user1.ID, session1.ID),
+			session:              revokedSession,
+			expectedErrorMessage: "session revoked",
+		},
+		{
+			testName:             "Session Expired",
+			tokenString:          createSessionToken(user1.ID, session1.ID),
+			session:              expiredSession,
+			expectedErrorMessage: "session revoked",
+		},
+		{
+			testName:             "Session Of Another User",
+			tokenString:          createSessionToken(user1.ID, session1.ID),
+			session:              createActiveSession(session1.ID, user2.ID),
+			expectedErrorMessage: "session revoked",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx, recorder := testutils.CreateTestContextWithAuthHeader("Bearer " + test.tokenString)
+			target, userRepository, sessionRepository := createMiddlewareAndMockRepo(t)
+			// expect
+			userRepository.On("GetByID", ctx, user1.ID).Return(&user1, nil).Once()
+			if test.session != nil || test.sessionErr != nil {
+				sessionRepository.On("GetByID", ctx, session1.ID).Return(test.session, test.sessionErr).Once()
+			}
+			// when
+			target.AuthenticateRequest(ctx)
+			// then
+			assert.True(t, ctx.IsAborted())
+			assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), test.expectedErrorMessage)
+		})
+	}
+}
+
+func TestAuthenticateRequest_TouchesStaleSession(t *testing.T) {
+	// given
+	session := createActiveSession(session1.ID, user1.ID)
+	session.LastSeenAt = time.Now().Add(-time.Hour)
+	ctx, _ := testutils.CreateTestContextWithAuthHeader("Bearer " + createSessionToken(user1.ID, session1.ID))
+	target, userRepository, sessionRepository := createMiddlewareAndMockRepo(t)
+	// expect
+	userRepository.On("GetByID", ctx, user1.ID).Return(&user1, nil).Once()
+	sessionRepository.On("GetByID", ctx, session1.ID).Return(session, nil).Once()
+	sessionRepository.On("Touch", ctx, session1.ID, mock.AnythingOfType("time.Time")).Return(nil).Once()
+	// when
+	target.AuthenticateRequest(ctx)
+	// then
+	assert.False(t, ctx.IsAborted())
+	sessionRepository.AssertExpectations(t)
+}
+
+func TestAuthenticateRequest_Impersonation(t *testing.T) {
+	// given
+	admin := model.User{ID: 10, Email: "admin@example.com", Role: model.UserRoleAdmin}
+	ctx, recorder := testutils.CreateTestContextWithAuthHeader("Bearer " + createImpersonationToken(user1.ID, admin.ID))
+	target, userRepository, _, impersonationRepository := createMiddlewareAndMockRepos(t)
+	// expect
+	userRepository.On("GetByID", ctx, user1.ID).Return(&user1, nil).Once()
+	userRepository.On("GetByID", ctx, admin.ID).Return(&admin, nil).Once()
+	impersonationRepository.On("Create", ctx, mock.MatchedBy(func(event *model.ImpersonationEvent) bool {
+		return event.ActorID == admin.ID && event.UserID == user1.ID && event.Action == model.ImpersonationActionRequest
+	})).Return(&model.ImpersonationEvent{ID: 1}, nil).Once()
+	// when
+	target.AuthenticateRequest(ctx)
+	// then
+	assert.False(t, ctx.IsAborted())
+	assert.Equal(t, user1.ID, ctx.GetUint("user_id"))
+	assert.Equal(t, &user1, utils.GetAuthenticatedUser(ctx))
+	assert.Equal(t, &admin, utils.GetRealUser(ctx))
+	assert.True(t, utils.IsImpersonating(ctx))
+	assert.Equal(t, admin.Email, recorder.Header().Get("X-Impersonated-By"))
+}
+
+func TestAuthenticateRequest_ImpersonationActorNotAdmin(t *testing.T) {
+	// given
+	ctx, recorder := testutils.CreateTestContextWithAuthHeader("Bearer " + createImpersonationToken(user1.ID, user2.ID))
+	target, userRepository, _, impersonationRepository := createMiddlewareAndMockRepos(t)
+	// expect
+	userRepository.On("GetByID", ctx, user1.ID).Return(&user1, nil).Once()
+	userRepository.On("GetByID", ctx, user2.ID).Return(&user2, nil).Once()
+	// when
+	target.AuthenticateRequest(ctx)
+	// then
+	assert.True(t, ctx.IsAborted())
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "impersonation no longer allowed")
+	// and
+	impersonationRepository.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestForbidImpersonation(t *testing.T) {
+	admin := model.User{ID: 10, Email: "admin@example.com", Role: model.UserRoleAdmin}
+	tests := []struct {
+		testName        string
+		realUser        *model.User
+		expectedAborted bool
+	}{
+		{
+			testName:        "Not Impersonating",
+			realUser:        &user1,
+			expectedAborted: false,
+		},
+		{
+			testName:        "Impersonating",
+			realUser:        &admin,
+			expectedAborted: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx, recorder := testutils.CreateTestContext()
+			testutils.SetAuthenticatedUser(ctx, &user1, test.realUser)
+			target, _, _ := createMiddlewareAndMockRepo(t)
+			// when
+			target.ForbidImpersonation(ctx)
+			// then
+			assert.Equal(t, test.expectedAborted, ctx.IsAborted())
+			if test.expectedAborted {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+			}
+		})
+	}
+}
+
+func TestAuthenticateRequest_RecordsAuditEvent(t *testing.T) {
+	// given
+	ctx, _ := testutils.CreateTestContextWithAuthHeader("Bearer invalid-token")
+	auditLogger := mockService.NewMockAuditLogger()
+	target, _, _, _ := createMiddlewareWithAuditLogger(t, auditLogger)
+	// expect
+	auditLogger.On("Record", ctx, mock.MatchedBy(func(event *model.AuditEvent) bool {
+		return event.Action == model.AuditActionAuthenticate &&
+			event.Outcome == model.AuditOutcomeFailure &&
+			event.Reason == "invalid token" &&
+			event.TargetType == model.AuditTargetTypeRoute
+	})).Once()
+	// when
+	target.AuthenticateRequest(ctx)
+	// then
+	assert.True(t, ctx.IsAborted())
+	auditLogger.AssertExpectations(t)
+}
+
+func TestRequireAdmin_RecordsAuditEvent(t *testing.T) {
+	// given
+	ctx, recorder := testutils.CreateTestContext()
+	testutils.SetAuthenticatedUser(ctx, &user1, &user1)
+	auditLogger := mockService.NewMockAuditLogger()
+	target, _, _, _ := createMiddlewareWithAuditLogger(t, auditLogger)
+	// expect
+	auditLogger.On("Record", ctx, mock.MatchedBy(func(event *model.AuditEvent) bool {
+		return event.Action == model.AuditActionAuthorize && event.Outcome == model.AuditOutcomeFailure
+	})).Once()
+	// when
+	target.RequireAdmin(ctx)
+	// then
+	assert.True(t, ctx.IsAborted())
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	auditLogger.AssertExpectations(t)
+}
+
+func createImpersonationToken(sub uint, actorID uint) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": sub,
+		"act": map[string]any{"sub": actorID},
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Minute).Unix(),
+	})
+
+	tokenString, err := token.SignedString([]byte("test-secret-key"))
+	if err != nil {
+		panic(err)
+	}
+	return tokenString
+}
+
+func createSessionToken(sub uint, sid uint) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": sub,
+		"sid": sid,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Minute).Unix(),
+	})
+
+	tokenString, err := token.SignedString([]byte("test-secret-key"))
+	if err != nil {
+		panic(err)
+	}
+	return tokenString
+}
+
+func createActiveSession(id uint, userID uint) *model.Session {
+	now := time.Now()
+	return &model.Session{ID: id, UserID: userID, CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}
+}
+
 func createRsaSignedToken() string {
 	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
 	if err != nil {
@@ -144,5 +556,223 @@ func createHmacSignedToken(exp *int64, sub *uint) string {
 	return tokenString
 }
 
+// Returns the HMAC of message keyed directly with secret, as CSRF tokens were before their key was derived.
+func hmacSHA256(message string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(message))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
 func int64Ptr(i int64) *int64 { return &i }
 func uintPtr(i uint) *uint    { return &i }
+
+func TestAuthenticateRequest_AccountRestricted(t *testing.T) {
+	disabledAt := time.Now()
+	tests := []struct {
+		testName             string
+		user                 model.User
+		allowPasswordReset   bool
+		expectedAborted      bool
+		expectedErrorMessage string
+	}{
+		{
+			testName:             "Disabled",
+			user:                 model.User{ID: 4, Email: "test4@example.com", DisabledAt: &disabledAt},
+			expectedAborted:      true,
+			expectedErrorMessage: "account disabled",
+		},
+		{
+			testName:             "Password Reset Required",
+			user:                 model.User{ID: 5, Email: "test5@example.com", PasswordResetRequired: true},
+			expectedAborted:      true,
+			expectedErrorMessage: "password reset required",
+		},
+		{
+			testName:           "Password Reset Required On Password Change",
+			user:               model.User{ID: 5, Email: "test5@example.com", PasswordResetRequired: true},
+			allowPasswordReset: true,
+			expectedAborted:    false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			validAuthHeader := "Bearer " + createSessionToken(test.user.ID, session1.ID)
+			ctx, recorder := testutils.CreateTestContextWithAuthHeader(validAuthHeader)
+			target, userRepository, sessionRepository := createMiddlewareAndMockRepo(t)
+			// expect
+			userRepository.On("GetByID", ctx, test.user.ID).Return(&test.user, nil).Once()
+			sessionRepository.On("GetByID", ctx, session1.ID).Return(createActiveSession(session1.ID, test.user.ID), nil).Once()
+			// when
+			if test.allowPasswordReset {
+				target.AuthenticatePasswordChange(ctx)
+			} else {
+				target.AuthenticateRequest(ctx)
+			}
+			// then
+			assert.Equal(t, test.expectedAborted, ctx.IsAborted())
+			if test.expectedAborted {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+				assert.Contains(t, recorder.Body.String(), test.expectedErrorMessage)
+			}
+		})
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		testName        string
+		user            *model.User
+		expectedAborted bool
+	}{
+		{
+			testName:        "Admin",
+			user:            &model.User{ID: 1, Role: model.UserRoleAdmin},
+			expectedAborted: false,
+		},
+		{
+			testName:        "Regular User",
+			user:            &model.User{ID: 2, Role: model.UserRoleUser},
+			expectedAborted: true,
+		},
+		{
+			testName:        "Unauthenticated",
+			user:            nil,
+			expectedAborted: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx, recorder := testutils.CreateTestContext()
+			if test.user != nil {
+				testutils.SetAuthenticatedUser(ctx, test.user, test.user)
+			}
+			target, _, _ := createMiddlewareAndMockRepo(t)
+			// when
+			target.RequireAdmin(ctx)
+			// then
+			assert.Equal(t, test.expectedAborted, ctx.IsAborted())
+			if test.expectedAborted {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+				assert.Contains(t, recorder.Body.String(), "admin access required")
+			}
+		})
+	}
+}
+
+func TestRequireOrganization_Success(t *testing.T) {
+	membership := &model.Membership{OrganizationID: 7, UserID: user1.ID, Role: model.OrganizationRoleMember}
+	tests := []struct {
+		testName         string
+		header           string
+		expectMembership func(ctx any, membershipRepository *repository.MockMembershipRepository)
+	}{
+		{
+			testName: "Organization Header",
+			header:   "7",
+			expectMembership: func(ctx any, membershipRepository *repository.MockMembershipRepository) {
+				membershipRepository.On("Get", ctx, uint(7), user1.ID).Return(membership, nil).Once()
+			},
+		},
+		{
+			testName: "Only Membership",
+			expectMembership: func(ctx any, membershipRepository *repository.MockMembershipRepository) {
+				membershipRepository.On("ListByUserID", ctx, user1.ID).Return(model.Memberships{membership}, nil).Once()
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx, recorder := testutils.CreateTestContextWithAuthHeader("")
+			testutils.SetAuthenticatedUser(ctx, &user1, &user1)
+			if test.header != "" {
+				ctx.Request.Header.Set(middleware.OrganizationHeaderName, test.header)
+			}
+			target, membershipRepository := createMiddlewareAndMockMembershipRepo(t)
+			// expect
+			test.expectMembership(ctx, membershipRepository)
+			// when
+			target.RequireOrganization(ctx)
+			// then
+			assert.False(t, ctx.IsAborted())
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, uint(7), utils.GetOrganizationID(ctx))
+			assert.Equal(t, membership, utils.GetMembership(ctx))
+			membershipRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRequireOrganization_Failure(t *testing.T) {
+	tests := []struct {
+		testName             string
+		header               string
+		expectMembership     func(ctx any, membershipRepository *repository.MockMembershipRepository)
+		expectedStatusCode   int
+		expectedErrorMessage string
+	}{
+		{
+			testName:             "Invalid Organization Header",
+			header:               "not-an-id",
+			expectedStatusCode:   http.StatusBadRequest,
+			expectedErrorMessage: "invalid organization id",
+		},
+		{
+			testName: "Not A Member",
+			header:   "8",
+			expectMembership: func(ctx any, membershipRepository *repository.MockMembershipRepository) {
+				membershipRepository.On("Get", ctx, uint(8), user1.ID).Return(nil, errors.New("record not found")).Once()
+			},
+			expectedStatusCode:   http.StatusForbidden,
+			expectedErrorMessage: "not a member of this organization",
+		},
+		{
+			testName: "No Memberships",
+			expectMembership: func(ctx any, membershipRepository *repository.MockMembershipRepository) {
+				membershipRepository.On("ListByUserID", ctx, user1.ID).Return(model.Memberships{}, nil).Once()
+			},
+			expectedStatusCode:   http.StatusForbidden,
+			expectedErrorMessage: "organization membership required",
+		},
+		{
+			testName: "Several Memberships Without Header",
+			expectMembership: func(ctx any, membershipRepository *repository.MockMembershipRepository) {
+				membershipRepository.On("ListByUserID", ctx, user1.ID).Return(model.Memberships{
+					{OrganizationID: 7, UserID: user1.ID},
+					{OrganizationID: 8, UserID: user1.ID},
+				}, nil).Once()
+			},
+			expectedStatusCode:   http.StatusBadRequest,
+			expectedErrorMessage: "organization required",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx, recorder := testutils.CreateTestContextWithAuthHeader("")
+			testutils.SetAuthenticatedUser(ctx, &user1, &user1)
+			if test.header != "" {
+				ctx.Request.Header.Set(middleware.OrganizationHeaderName, test.header)
+			}
+			target, membershipRepository := createMiddlewareAndMockMembershipRepo(t)
+			// expect
+			if test.expectMembership != nil {
+				test.expectMembership(ctx, membershipRepository)
+			}
+			// when
+			target.RequireOrganization(ctx)
+			// then
+			assert.True(t, ctx.IsAborted())
+			assert.Equal(t, test.expectedStatusCode, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), test.expectedErrorMessage)
+			assert.Zero(t, utils.GetOrganizationID(ctx))
+			membershipRepository.AssertExpectations(t)
+		})
+	}
+}
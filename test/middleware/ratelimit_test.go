@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/middleware"
+	"github.com/Verano-20/stage-zero/test/testutils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestContextFromIP(clientIP string) (*gin.Context, *httptest.ResponseRecorder) {
+	ctx, recorder := testutils.CreateTestContext()
+	ctx.Request = httptest.NewRequest("POST", "/auth/login", nil)
+	ctx.Request.RemoteAddr = clientIP + ":1234"
+	return ctx, recorder
+}
+
+func TestLimitRequest_AllowsRequestsWithinLimit(t *testing.T) {
+	// given
+	target := middleware.NewRateLimiter(2, time.Minute)
+	firstCtx, _ := createTestContextFromIP("10.0.0.1")
+	secondCtx, _ := createTestContextFromIP("10.0.0.1")
+	// when
+	target.LimitRequest(firstCtx)
+	target.LimitRequest(secondCtx)
+	// then
+	assert.False(t, firstCtx.IsAborted())
+	assert.False(t, secondCtx.IsAborted())
+}
+
+func TestLimitRequest_RejectsRequestsOverLimit(t *testing.T) {
+	// given
+	target := middleware.NewRateLimiter(1, time.Minute)
+	firstCtx, _ := createTestContextFromIP("10.0.0.1")
+	secondCtx, recorder := createTestContextFromIP("10.0.0.1")
+	// when
+	target.LimitRequest(firstCtx)
+	target.LimitRequest(secondCtx)
+	// then
+	assert.False(t, firstCtx.IsAborted())
+	assert.True(t, secondCtx.IsAborted())
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.NotEmpty(t, recorder.Header().Get("Retry-After"))
+	assert.Contains(t, recorder.Body.String(), "Too many requests")
+}
+
+func TestLimitRequest_TracksClientsSeparately(t *testing.T) {
+	// given
+	target := middleware.NewRateLimiter(1, time.Minute)
+	firstCtx, _ := createTestContextFromIP("10.0.0.1")
+	secondCtx, _ := createTestContextFromIP("10.0.0.2")
+	// when
+	target.LimitRequest(firstCtx)
+	target.LimitRequest(secondCtx)
+	// then
+	assert.False(t, firstCtx.IsAborted())
+	assert.False(t, secondCtx.IsAborted())
+}
+
+func TestLimitRequest_ResetsAfterWindow(t *testing.T) {
+	// given
+	target := middleware.NewRateLimiter(1, 10*time.Millisecond)
+	firstCtx, _ := createTestContextFromIP("10.0.0.1")
+	secondCtx, _ := createTestContextFromIP("10.0.0.1")
+	// when
+	target.LimitRequest(firstCtx)
+	time.Sleep(20 * time.Millisecond)
+	target.LimitRequest(secondCtx)
+	// then
+	assert.False(t, firstCtx.IsAborted())
+	assert.False(t, secondCtx.IsAborted())
+}
@@ -0,0 +1,24 @@
+package events
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/events"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockEventPublisher struct {
+	mock.Mock
+}
+
+var _ events.EventPublisher = &MockEventPublisher{}
+
+func NewMockEventPublisher() *MockEventPublisher {
+	return &MockEventPublisher{}
+}
+
+func (m *MockEventPublisher) Publish(ctx context.Context, event *model.DomainEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
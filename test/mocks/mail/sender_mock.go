@@ -0,0 +1,23 @@
+package mail
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/mail"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSender struct {
+	mock.Mock
+}
+
+var _ mail.Sender = &MockSender{}
+
+func NewMockSender() *MockSender {
+	return &MockSender{}
+}
+
+func (m *MockSender) Send(ctx context.Context, message mail.Message) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
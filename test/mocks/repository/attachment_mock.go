@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAttachmentRepository struct {
+	mock.Mock
+}
+
+var _ repository.AttachmentRepository = &MockAttachmentRepository{}
+
+func NewMockAttachmentRepository() *MockAttachmentRepository {
+	return &MockAttachmentRepository{}
+}
+
+func (m *MockAttachmentRepository) Create(ctx context.Context, attachment *model.Attachment) (*model.Attachment, error) {
+	args := m.Called(ctx, attachment)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) ListBySimpleID(ctx context.Context, simpleID uint) (model.Attachments, error) {
+	args := m.Called(ctx, simpleID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.Attachments), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) GetByID(ctx context.Context, simpleID uint, id uint) (*model.Attachment, error) {
+	args := m.Called(ctx, simpleID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAttachmentRepository) CountByHash(ctx context.Context, organizationID uint, sha256 string) (int64, error) {
+	args := m.Called(ctx, organizationID, sha256)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) LockContent(ctx context.Context, organizationID uint, sha256 string) error {
+	args := m.Called(ctx, organizationID, sha256)
+	return args.Error(0)
+}
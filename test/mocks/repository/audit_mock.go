@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+var _ repository.AuditRepository = &MockAuditRepository{}
+
+func NewMockAuditRepository() *MockAuditRepository {
+	return &MockAuditRepository{}
+}
+
+func (m *MockAuditRepository) Append(ctx context.Context, event *model.AuditEvent) (*model.AuditEvent, error) {
+	args := m.Called(ctx, event)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AuditEvent), args.Error(1)
+}
+
+func (m *MockAuditRepository) List(ctx context.Context, filter model.AuditEventFilter) (model.AuditEvents, int64, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).(model.AuditEvents), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockAuditRepository) ListAfter(ctx context.Context, afterID uint64, limit int) (model.AuditEvents, error) {
+	args := m.Called(ctx, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.AuditEvents), args.Error(1)
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockEmailVerificationRepository struct {
+	mock.Mock
+}
+
+var _ repository.EmailVerificationRepository = &MockEmailVerificationRepository{}
+
+func NewMockEmailVerificationRepository() *MockEmailVerificationRepository {
+	return &MockEmailVerificationRepository{}
+}
+
+func (m *MockEmailVerificationRepository) Create(ctx context.Context, verification *model.EmailVerification) (*model.EmailVerification, error) {
+	args := m.Called(ctx, verification)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.EmailVerification), args.Error(1)
+}
+
+func (m *MockEmailVerificationRepository) Consume(ctx context.Context, tokenHash string) (*model.EmailVerification, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.EmailVerification), args.Error(1)
+}
+
+func (m *MockEmailVerificationRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
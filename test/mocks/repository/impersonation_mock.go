@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockImpersonationRepository struct {
+	mock.Mock
+}
+
+var _ repository.ImpersonationRepository = &MockImpersonationRepository{}
+
+func NewMockImpersonationRepository() *MockImpersonationRepository {
+	return &MockImpersonationRepository{}
+}
+
+func (m *MockImpersonationRepository) Create(ctx context.Context, event *model.ImpersonationEvent) (*model.ImpersonationEvent, error) {
+	args := m.Called(ctx, event)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ImpersonationEvent), args.Error(1)
+}
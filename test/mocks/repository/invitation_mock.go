@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockInvitationRepository struct {
+	mock.Mock
+}
+
+var _ repository.InvitationRepository = &MockInvitationRepository{}
+
+func NewMockInvitationRepository() *MockInvitationRepository {
+	return &MockInvitationRepository{}
+}
+
+func (m *MockInvitationRepository) Create(ctx context.Context, invitation *model.Invitation) (*model.Invitation, error) {
+	args := m.Called(ctx, invitation)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepository) GetByID(ctx context.Context, organizationID uint, id uint) (*model.Invitation, error) {
+	args := m.Called(ctx, organizationID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepository) GetPendingByTokenHash(ctx context.Context, tokenHash string) (*model.Invitation, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepository) GetPendingByEmail(ctx context.Context, organizationID uint, email string) (*model.Invitation, error) {
+	args := m.Called(ctx, organizationID, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepository) ListPendingByOrganizationID(ctx context.Context, organizationID uint) (model.Invitations, error) {
+	args := m.Called(ctx, organizationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.Invitations), args.Error(1)
+}
+
+func (m *MockInvitationRepository) Reissue(ctx context.Context, invitation *model.Invitation) error {
+	args := m.Called(ctx, invitation)
+	return args.Error(0)
+}
+
+func (m *MockInvitationRepository) Respond(ctx context.Context, invitation *model.Invitation, status string) error {
+	args := m.Called(ctx, invitation, status)
+	return args.Error(0)
+}
+
+func (m *MockInvitationRepository) Accept(ctx context.Context, invitation *model.Invitation, userID uint) (*model.Membership, error) {
+	args := m.Called(ctx, invitation, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Membership), args.Error(1)
+}
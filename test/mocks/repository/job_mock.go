@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockJobRepository struct {
+	mock.Mock
+}
+
+var _ repository.JobRepository = &MockJobRepository{}
+
+func NewMockJobRepository() *MockJobRepository {
+	return &MockJobRepository{}
+}
+
+func (m *MockJobRepository) Create(ctx context.Context, job *model.Job) error {
+	args := m.Called(ctx, job)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) ClaimDue(ctx context.Context, types []string, limit int, lease time.Duration) (model.Jobs, error) {
+	args := m.Called(ctx, types, limit, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.Jobs), args.Error(1)
+}
+
+func (m *MockJobRepository) Complete(ctx context.Context, job *model.Job) error {
+	args := m.Called(ctx, job)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) SaveFailure(ctx context.Context, job *model.Job) error {
+	args := m.Called(ctx, job)
+	return args.Error(0)
+}
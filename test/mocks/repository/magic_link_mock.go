@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMagicLinkRepository struct {
+	mock.Mock
+}
+
+var _ repository.MagicLinkRepository = &MockMagicLinkRepository{}
+
+func NewMockMagicLinkRepository() *MockMagicLinkRepository {
+	return &MockMagicLinkRepository{}
+}
+
+func (m *MockMagicLinkRepository) Create(ctx context.Context, token *model.MagicLinkToken) (*model.MagicLinkToken, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.MagicLinkToken), args.Error(1)
+}
+
+func (m *MockMagicLinkRepository) Consume(ctx context.Context, tokenHash string) (*model.MagicLinkToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.MagicLinkToken), args.Error(1)
+}
+
+func (m *MockMagicLinkRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
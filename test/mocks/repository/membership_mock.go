@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMembershipRepository struct {
+	mock.Mock
+}
+
+var _ repository.MembershipRepository = &MockMembershipRepository{}
+
+func NewMockMembershipRepository() *MockMembershipRepository {
+	return &MockMembershipRepository{}
+}
+
+func (m *MockMembershipRepository) Create(ctx context.Context, membership *model.Membership) (*model.Membership, error) {
+	args := m.Called(ctx, membership)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Membership), args.Error(1)
+}
+
+func (m *MockMembershipRepository) Get(ctx context.Context, organizationID uint, userID uint) (*model.Membership, error) {
+	args := m.Called(ctx, organizationID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Membership), args.Error(1)
+}
+
+func (m *MockMembershipRepository) ListByUserID(ctx context.Context, userID uint) (model.Memberships, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.Memberships), args.Error(1)
+}
+
+func (m *MockMembershipRepository) ListByOrganizationID(ctx context.Context, organizationID uint) (model.Memberships, error) {
+	args := m.Called(ctx, organizationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.Memberships), args.Error(1)
+}
+
+func (m *MockMembershipRepository) LockOwners(ctx context.Context, organizationID uint) (int64, error) {
+	args := m.Called(ctx, organizationID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockMembershipRepository) Delete(ctx context.Context, membership *model.Membership) error {
+	args := m.Called(ctx, membership)
+	return args.Error(0)
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMetadataSchemaRepository struct {
+	mock.Mock
+}
+
+var _ repository.MetadataSchemaRepository = &MockMetadataSchemaRepository{}
+
+func NewMockMetadataSchemaRepository() *MockMetadataSchemaRepository {
+	return &MockMetadataSchemaRepository{}
+}
+
+func (m *MockMetadataSchemaRepository) Upsert(ctx context.Context, schema *model.MetadataSchema) (*model.MetadataSchema, error) {
+	args := m.Called(ctx, schema)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.MetadataSchema), args.Error(1)
+}
+
+func (m *MockMetadataSchemaRepository) ListByOrganizationID(ctx context.Context, organizationID uint) (model.MetadataSchemas, error) {
+	args := m.Called(ctx, organizationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.MetadataSchemas), args.Error(1)
+}
+
+func (m *MockMetadataSchemaRepository) ListForKind(ctx context.Context, organizationID uint, kind string) (model.MetadataSchemas, error) {
+	args := m.Called(ctx, organizationID, kind)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.MetadataSchemas), args.Error(1)
+}
+
+func (m *MockMetadataSchemaRepository) Delete(ctx context.Context, organizationID uint, id uint) error {
+	args := m.Called(ctx, organizationID, id)
+	return args.Error(0)
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOrganizationRepository struct {
+	mock.Mock
+}
+
+var _ repository.OrganizationRepository = &MockOrganizationRepository{}
+
+func NewMockOrganizationRepository() *MockOrganizationRepository {
+	return &MockOrganizationRepository{}
+}
+
+func (m *MockOrganizationRepository) Create(ctx context.Context, organization *model.Organization, ownerID uint) (*model.Organization, error) {
+	args := m.Called(ctx, organization, ownerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) GetByID(ctx context.Context, id uint) (*model.Organization, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Organization), args.Error(1)
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+var _ repository.OutboxRepository = &MockOutboxRepository{}
+
+func NewMockOutboxRepository() *MockOutboxRepository {
+	return &MockOutboxRepository{}
+}
+
+// Relay hands the events given to Return to publish, and returns how many events publish returned.
+func (m *MockOutboxRepository) Relay(ctx context.Context, limit int, publish func(events model.OutboxEvents) model.OutboxEvents) (int, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return 0, args.Error(1)
+	}
+	return len(publish(args.Get(0).(model.OutboxEvents))), args.Error(1)
+}
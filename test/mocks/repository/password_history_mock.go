@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPasswordHistoryRepository struct {
+	mock.Mock
+}
+
+var _ repository.PasswordHistoryRepository = &MockPasswordHistoryRepository{}
+
+func NewMockPasswordHistoryRepository() *MockPasswordHistoryRepository {
+	return &MockPasswordHistoryRepository{}
+}
+
+func (m *MockPasswordHistoryRepository) Create(ctx context.Context, entry *model.PasswordHistoryEntry) (*model.PasswordHistoryEntry, error) {
+	args := m.Called(ctx, entry)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PasswordHistoryEntry), args.Error(1)
+}
+
+func (m *MockPasswordHistoryRepository) ListRecent(ctx context.Context, userID uint, limit int) ([]*model.PasswordHistoryEntry, error) {
+	args := m.Called(ctx, userID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.PasswordHistoryEntry), args.Error(1)
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockScheduledTaskRepository struct {
+	mock.Mock
+}
+
+var _ repository.ScheduledTaskRepository = &MockScheduledTaskRepository{}
+
+func NewMockScheduledTaskRepository() *MockScheduledTaskRepository {
+	return &MockScheduledTaskRepository{}
+}
+
+func (m *MockScheduledTaskRepository) Sync(ctx context.Context, tasks model.ScheduledTasks) error {
+	args := m.Called(ctx, tasks)
+	return args.Error(0)
+}
+
+func (m *MockScheduledTaskRepository) ListAll(ctx context.Context) (model.ScheduledTasks, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.ScheduledTasks), args.Error(1)
+}
+
+func (m *MockScheduledTaskRepository) Start(ctx context.Context, run *model.ScheduledTaskRun, nextRunAt time.Time) (bool, error) {
+	args := m.Called(ctx, run, nextRunAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockScheduledTaskRepository) Finish(ctx context.Context, run *model.ScheduledTaskRun) error {
+	args := m.Called(ctx, run)
+	return args.Error(0)
+}
+
+func (m *MockScheduledTaskRepository) ListRuns(ctx context.Context, name string, limit int) (model.ScheduledTaskRuns, error) {
+	args := m.Called(ctx, name, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.ScheduledTaskRuns), args.Error(1)
+}
+
+type MockSchedulerLock struct {
+	mock.Mock
+}
+
+var _ repository.SchedulerLock = &MockSchedulerLock{}
+
+func NewMockSchedulerLock() *MockSchedulerLock {
+	return &MockSchedulerLock{}
+}
+
+func (m *MockSchedulerLock) Acquire(ctx context.Context) (bool, error) {
+	args := m.Called(ctx)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSchedulerLock) Release(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
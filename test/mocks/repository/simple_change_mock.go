@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSimpleChangeListener struct {
+	mock.Mock
+}
+
+var _ repository.SimpleChangeListener = &MockSimpleChangeListener{}
+
+func NewMockSimpleChangeListener() *MockSimpleChangeListener {
+	return &MockSimpleChangeListener{}
+}
+
+func (m *MockSimpleChangeListener) Listen(ctx context.Context, ready func(latestEventID uint64), handle func(notification *model.SimpleChangeNotification)) error {
+	args := m.Called(ctx, ready, handle)
+	return args.Error(0)
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSimpleGrantRepository struct {
+	mock.Mock
+}
+
+var _ repository.SimpleGrantRepository = &MockSimpleGrantRepository{}
+
+func NewMockSimpleGrantRepository() *MockSimpleGrantRepository {
+	return &MockSimpleGrantRepository{}
+}
+
+func (m *MockSimpleGrantRepository) Upsert(ctx context.Context, grant *model.SimpleGrant) (*model.SimpleGrant, error) {
+	args := m.Called(ctx, grant)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SimpleGrant), args.Error(1)
+}
+
+func (m *MockSimpleGrantRepository) ListBySimpleID(ctx context.Context, simpleID uint) (model.SimpleGrants, error) {
+	args := m.Called(ctx, simpleID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.SimpleGrants), args.Error(1)
+}
+
+func (m *MockSimpleGrantRepository) Delete(ctx context.Context, simpleID uint, userID uint) error {
+	args := m.Called(ctx, simpleID, userID)
+	return args.Error(0)
+}
@@ -1,9 +1,10 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/Verano-20/stage-zero/internal/model"
 	"github.com/Verano-20/stage-zero/internal/repository"
-	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -17,7 +18,7 @@ func NewMockSimpleRepository() *MockSimpleRepository {
 	return &MockSimpleRepository{}
 }
 
-func (m *MockSimpleRepository) Create(ctx *gin.Context, simple *model.Simple) (*model.Simple, error) {
+func (m *MockSimpleRepository) Create(ctx context.Context, simple *model.Simple) (*model.Simple, error) {
 	args := m.Called(ctx, simple)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -25,15 +26,23 @@ func (m *MockSimpleRepository) Create(ctx *gin.Context, simple *model.Simple) (*
 	return args.Get(0).(*model.Simple), args.Error(1)
 }
 
-func (m *MockSimpleRepository) GetAll(ctx *gin.Context) (model.Simples, error) {
-	args := m.Called(ctx)
+func (m *MockSimpleRepository) GetAll(ctx context.Context, filter model.SimpleFilter) (model.Simples, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.Simples), args.Error(1)
+}
+
+func (m *MockSimpleRepository) GetAllAccessibleBy(ctx context.Context, userID uint, filter model.SimpleFilter) (model.Simples, error) {
+	args := m.Called(ctx, userID, filter)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(model.Simples), args.Error(1)
 }
 
-func (m *MockSimpleRepository) GetByID(ctx *gin.Context, id uint) (*model.Simple, error) {
+func (m *MockSimpleRepository) GetByID(ctx context.Context, id uint) (*model.Simple, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -41,15 +50,23 @@ func (m *MockSimpleRepository) GetByID(ctx *gin.Context, id uint) (*model.Simple
 	return args.Get(0).(*model.Simple), args.Error(1)
 }
 
-func (m *MockSimpleRepository) Update(ctx *gin.Context, simple *model.Simple) (*model.Simple, error) {
-	args := m.Called(ctx, simple)
+func (m *MockSimpleRepository) Update(ctx context.Context, simple *model.Simple, tags model.Tags) (*model.Simple, error) {
+	args := m.Called(ctx, simple, tags)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.Simple), args.Error(1)
 }
 
-func (m *MockSimpleRepository) Delete(ctx *gin.Context, id uint) error {
+func (m *MockSimpleRepository) Delete(ctx context.Context, id uint) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
+
+func (m *MockSimpleRepository) GetChanged(ctx context.Context, id uint) (*model.Simple, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Simple), args.Error(1)
+}
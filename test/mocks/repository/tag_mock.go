@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTagRepository struct {
+	mock.Mock
+}
+
+var _ repository.TagRepository = &MockTagRepository{}
+
+func NewMockTagRepository() *MockTagRepository {
+	return &MockTagRepository{}
+}
+
+func (m *MockTagRepository) FindOrCreate(ctx context.Context, names []string) (model.Tags, error) {
+	args := m.Called(ctx, names)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.Tags), args.Error(1)
+}
+
+func (m *MockTagRepository) Suggest(ctx context.Context, filter model.TagSuggestionFilter, accessibleBy *uint) ([]*model.TagCount, error) {
+	args := m.Called(ctx, filter, accessibleBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.TagCount), args.Error(1)
+}
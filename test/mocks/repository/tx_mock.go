@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTxManager struct {
+	mock.Mock
+}
+
+var _ repository.TxManager = &MockTxManager{}
+
+func NewMockTxManager() *MockTxManager {
+	return &MockTxManager{}
+}
+
+// WithinTransaction runs fn with the context it is given, as there is no transaction to carry. The error returned
+// when fn succeeds is the one the mock is set up with, which stands in for a failed commit.
+func (m *MockTxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	args := m.Called(ctx)
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	return args.Error(0)
+}
@@ -1,9 +1,11 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/Verano-20/stage-zero/internal/model"
 	"github.com/Verano-20/stage-zero/internal/repository"
-	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -17,7 +19,7 @@ func NewMockUserRepository() *MockUserRepository {
 	return &MockUserRepository{}
 }
 
-func (m *MockUserRepository) Create(ctx *gin.Context, user *model.User) (*model.User, error) {
+func (m *MockUserRepository) Create(ctx context.Context, user *model.User) (*model.User, error) {
 	args := m.Called(ctx, user)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -25,7 +27,7 @@ func (m *MockUserRepository) Create(ctx *gin.Context, user *model.User) (*model.
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetByID(ctx *gin.Context, id uint) (*model.User, error) {
+func (m *MockUserRepository) GetByID(ctx context.Context, id uint) (*model.User, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -34,10 +36,46 @@ func (m *MockUserRepository) GetByID(ctx *gin.Context, id uint) (*model.User, er
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetByEmail(ctx *gin.Context, email string) (*model.User, error) {
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	args := m.Called(ctx, email)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.User), args.Error(1)
 }
+
+func (m *MockUserRepository) Update(ctx context.Context, user *model.User) (*model.User, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, user *model.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) List(ctx context.Context, filter model.UserFilter) (model.Users, int64, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).(model.Users), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) RecordFailedLogin(ctx context.Context, id uint, maxAttempts int, lockoutDuration time.Duration) error {
+	args := m.Called(ctx, id, maxAttempts, lockoutDuration)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ResetFailedLogins(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdatePasswordHash(ctx context.Context, id uint, currentHash string, newHash string) error {
+	args := m.Called(ctx, id, currentHash, newHash)
+	return args.Error(0)
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWebhookEndpointRepository struct {
+	mock.Mock
+}
+
+type MockWebhookDeliveryRepository struct {
+	mock.Mock
+}
+
+var _ repository.WebhookEndpointRepository = &MockWebhookEndpointRepository{}
+var _ repository.WebhookDeliveryRepository = &MockWebhookDeliveryRepository{}
+
+func NewMockWebhookEndpointRepository() *MockWebhookEndpointRepository {
+	return &MockWebhookEndpointRepository{}
+}
+
+func NewMockWebhookDeliveryRepository() *MockWebhookDeliveryRepository {
+	return &MockWebhookDeliveryRepository{}
+}
+
+func (m *MockWebhookEndpointRepository) Create(ctx context.Context, endpoint *model.WebhookEndpoint) (*model.WebhookEndpoint, error) {
+	args := m.Called(ctx, endpoint)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockWebhookEndpointRepository) ListByOrganizationID(ctx context.Context, organizationID uint) (model.WebhookEndpoints, error) {
+	args := m.Called(ctx, organizationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.WebhookEndpoints), args.Error(1)
+}
+
+func (m *MockWebhookEndpointRepository) ListSubscribed(ctx context.Context, organizationID uint, eventType string) (model.WebhookEndpoints, error) {
+	args := m.Called(ctx, organizationID, eventType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.WebhookEndpoints), args.Error(1)
+}
+
+func (m *MockWebhookEndpointRepository) GetByID(ctx context.Context, organizationID uint, id uint) (*model.WebhookEndpoint, error) {
+	args := m.Called(ctx, organizationID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockWebhookEndpointRepository) Update(ctx context.Context, endpoint *model.WebhookEndpoint) (*model.WebhookEndpoint, error) {
+	args := m.Called(ctx, endpoint)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockWebhookEndpointRepository) Delete(ctx context.Context, organizationID uint, id uint) error {
+	args := m.Called(ctx, organizationID, id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookDeliveryRepository) CreateMany(ctx context.Context, deliveries model.WebhookDeliveries) error {
+	args := m.Called(ctx, deliveries)
+	return args.Error(0)
+}
+
+func (m *MockWebhookDeliveryRepository) ListByEndpointID(ctx context.Context, endpointID uint, filter model.WebhookDeliveryFilter) (model.WebhookDeliveries, int64, error) {
+	args := m.Called(ctx, endpointID, filter)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).(model.WebhookDeliveries), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockWebhookDeliveryRepository) GetByID(ctx context.Context, endpointID uint, id uint64) (*model.WebhookDelivery, error) {
+	args := m.Called(ctx, endpointID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookDeliveryRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) (model.WebhookDeliveries, error) {
+	args := m.Called(ctx, limit, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.WebhookDeliveries), args.Error(1)
+}
+
+func (m *MockWebhookDeliveryRepository) SaveAttempt(ctx context.Context, delivery *model.WebhookDelivery, attempt *model.WebhookDeliveryAttempt) error {
+	args := m.Called(ctx, delivery, attempt)
+	return args.Error(0)
+}
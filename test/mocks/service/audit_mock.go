@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditLogger struct {
+	mock.Mock
+}
+
+var _ service.AuditLogger = &MockAuditLogger{}
+
+func NewMockAuditLogger() *MockAuditLogger {
+	return &MockAuditLogger{}
+}
+
+func (m *MockAuditLogger) Record(ctx context.Context, event *model.AuditEvent) {
+	m.Called(ctx, event)
+}
+
+func (m *MockAuditLogger) ListEvents(ctx context.Context, filter model.AuditEventFilter) (model.AuditEvents, int64, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).(model.AuditEvents), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockAuditLogger) VerifyChain(ctx context.Context) (*model.AuditChainVerification, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AuditChainVerification), args.Error(1)
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuthService struct {
+	mock.Mock
+}
+
+var _ service.AuthService = &MockAuthService{}
+
+func NewMockAuthService() *MockAuthService {
+	return &MockAuthService{}
+}
+
+func (m *MockAuthService) ValidateUserCredentials(ctx context.Context, userForm model.UserForm) (user *model.User, err error) {
+	args := m.Called(ctx, userForm)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockAuthService) GenerateTokenString(ctx context.Context, user *model.User, jwtSecret []byte) (tokenString string, err error) {
+	args := m.Called(ctx, user, jwtSecret)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuthService) GenerateImpersonationTokenString(ctx context.Context, user *model.User, actor *model.User, jwtSecret []byte, ttl time.Duration) (tokenString string, err error) {
+	args := m.Called(ctx, user, actor, jwtSecret, ttl)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuthService) RevokeTokenSession(ctx context.Context, tokenString string, jwtSecret []byte) error {
+	args := m.Called(ctx, tokenString, jwtSecret)
+	return args.Error(0)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockInvitationService struct {
+	mock.Mock
+}
+
+var _ service.InvitationService = &MockInvitationService{}
+
+func NewMockInvitationService() *MockInvitationService {
+	return &MockInvitationService{}
+}
+
+func (m *MockInvitationService) CreateInvitation(ctx context.Context, user *model.User, organizationID uint, invitationForm model.InvitationForm, secret []byte) (*model.Invitation, error) {
+	args := m.Called(ctx, user, organizationID, invitationForm, secret)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationService) ListInvitations(ctx context.Context, user *model.User, organizationID uint) (model.Invitations, error) {
+	args := m.Called(ctx, user, organizationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.Invitations), args.Error(1)
+}
+
+func (m *MockInvitationService) ResendInvitation(ctx context.Context, user *model.User, organizationID uint, invitationID uint, secret []byte) (*model.Invitation, error) {
+	args := m.Called(ctx, user, organizationID, invitationID, secret)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationService) RevokeInvitation(ctx context.Context, user *model.User, organizationID uint, invitationID uint) error {
+	args := m.Called(ctx, user, organizationID, invitationID)
+	return args.Error(0)
+}
+
+func (m *MockInvitationService) AcceptInvitation(ctx context.Context, acceptForm model.AcceptInvitationForm, secret []byte) (*model.Membership, error) {
+	args := m.Called(ctx, acceptForm, secret)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Membership), args.Error(1)
+}
+
+func (m *MockInvitationService) DeclineInvitation(ctx context.Context, token string, secret []byte) error {
+	args := m.Called(ctx, token, secret)
+	return args.Error(0)
+}
+
+func (m *MockInvitationService) SignUpWithInvitation(ctx context.Context, signUpForm model.SignUpForm, secret []byte) (*model.User, *model.Membership, error) {
+	args := m.Called(ctx, signUpForm, secret)
+	var user *model.User
+	if args.Get(0) != nil {
+		user = args.Get(0).(*model.User)
+	}
+	var membership *model.Membership
+	if args.Get(1) != nil {
+		membership = args.Get(1).(*model.Membership)
+	}
+	return user, membership, args.Error(2)
+}
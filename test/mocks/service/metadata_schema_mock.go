@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMetadataSchemaService struct {
+	mock.Mock
+}
+
+var _ service.MetadataSchemaService = &MockMetadataSchemaService{}
+
+func NewMockMetadataSchemaService() *MockMetadataSchemaService {
+	return &MockMetadataSchemaService{}
+}
+
+func (m *MockMetadataSchemaService) ListSchemas(ctx context.Context, user *model.User, organizationID uint) (model.MetadataSchemas, error) {
+	args := m.Called(ctx, user, organizationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.MetadataSchemas), args.Error(1)
+}
+
+func (m *MockMetadataSchemaService) PutSchema(ctx context.Context, user *model.User, organizationID uint, schemaForm model.MetadataSchemaForm) (*model.MetadataSchema, error) {
+	args := m.Called(ctx, user, organizationID, schemaForm)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.MetadataSchema), args.Error(1)
+}
+
+func (m *MockMetadataSchemaService) DeleteSchema(ctx context.Context, user *model.User, organizationID uint, schemaID uint) error {
+	args := m.Called(ctx, user, organizationID, schemaID)
+	return args.Error(0)
+}
+
+func (m *MockMetadataSchemaService) ValidateMetadata(ctx context.Context, organizationID uint, kind string, metadata model.JSONMap) error {
+	args := m.Called(ctx, organizationID, kind, metadata)
+	return args.Error(0)
+}
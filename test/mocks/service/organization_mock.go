@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOrganizationService struct {
+	mock.Mock
+}
+
+var _ service.OrganizationService = &MockOrganizationService{}
+
+func NewMockOrganizationService() *MockOrganizationService {
+	return &MockOrganizationService{}
+}
+
+func (m *MockOrganizationService) CreateOrganization(ctx context.Context, user *model.User, organizationForm model.OrganizationForm) (*model.Organization, error) {
+	args := m.Called(ctx, user, organizationForm)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationService) ListMemberships(ctx context.Context, user *model.User) (model.Memberships, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.Memberships), args.Error(1)
+}
+
+func (m *MockOrganizationService) ListMembers(ctx context.Context, user *model.User, organizationID uint) (model.Memberships, error) {
+	args := m.Called(ctx, user, organizationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.Memberships), args.Error(1)
+}
+
+func (m *MockOrganizationService) AddMember(ctx context.Context, user *model.User, organizationID uint, membershipForm model.MembershipForm) (*model.Membership, error) {
+	args := m.Called(ctx, user, organizationID, membershipForm)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Membership), args.Error(1)
+}
+
+func (m *MockOrganizationService) RemoveMember(ctx context.Context, user *model.User, organizationID uint, memberID uint) error {
+	args := m.Called(ctx, user, organizationID, memberID)
+	return args.Error(0)
+}
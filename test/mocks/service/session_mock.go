@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSessionService struct {
+	mock.Mock
+}
+
+var _ service.SessionService = &MockSessionService{}
+
+func NewMockSessionService() *MockSessionService {
+	return &MockSessionService{}
+}
+
+func (m *MockSessionService) CreateSession(ctx context.Context, user *model.User, expiresAt time.Time) (*model.Session, error) {
+	args := m.Called(ctx, user, expiresAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Session), args.Error(1)
+}
+
+func (m *MockSessionService) ListSessions(ctx context.Context, user *model.User) (model.Sessions, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.Sessions), args.Error(1)
+}
+
+func (m *MockSessionService) RevokeSession(ctx context.Context, user *model.User, sessionID uint) error {
+	args := m.Called(ctx, user, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockSessionService) EndSession(ctx context.Context, userID uint, sessionID uint) error {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Error(0)
+}
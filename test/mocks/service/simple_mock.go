@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSimpleService struct {
+	mock.Mock
+}
+
+var _ service.SimpleService = &MockSimpleService{}
+
+func NewMockSimpleService() *MockSimpleService {
+	return &MockSimpleService{}
+}
+
+func (m *MockSimpleService) CreateSimple(ctx context.Context, simpleForm model.SimpleForm) (*model.Simple, error) {
+	args := m.Called(ctx, simpleForm)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Simple), args.Error(1)
+}
+
+func (m *MockSimpleService) GetAllSimples(ctx context.Context, filter model.SimpleFilter) (model.Simples, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.Simples), args.Error(1)
+}
+
+func (m *MockSimpleService) GetSimpleByID(ctx context.Context, id uint64) (*model.Simple, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Simple), args.Error(1)
+}
+
+func (m *MockSimpleService) UpdateSimple(ctx context.Context, existingSimple *model.Simple, simpleForm model.SimpleForm) (*model.Simple, error) {
+	args := m.Called(ctx, existingSimple, simpleForm)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Simple), args.Error(1)
+}
+
+func (m *MockSimpleService) DeleteSimple(ctx context.Context, existingSimple *model.Simple) error {
+	args := m.Called(ctx, existingSimple)
+	return args.Error(0)
+}
+
+func (m *MockSimpleService) ListShares(ctx context.Context, existingSimple *model.Simple) (model.SimpleGrants, error) {
+	args := m.Called(ctx, existingSimple)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.SimpleGrants), args.Error(1)
+}
+
+func (m *MockSimpleService) ShareSimple(ctx context.Context, existingSimple *model.Simple, shareForm model.SimpleShareForm) (*model.SimpleGrant, error) {
+	args := m.Called(ctx, existingSimple, shareForm)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SimpleGrant), args.Error(1)
+}
+
+func (m *MockSimpleService) UnshareSimple(ctx context.Context, existingSimple *model.Simple, userID uint) error {
+	args := m.Called(ctx, existingSimple, userID)
+	return args.Error(0)
+}
+
+func (m *MockSimpleService) SuggestTags(ctx context.Context, filter model.TagSuggestionFilter) ([]*model.TagCount, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.TagCount), args.Error(1)
+}
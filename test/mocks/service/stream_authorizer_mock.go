@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockStreamAuthorizer struct {
+	mock.Mock
+}
+
+var _ service.StreamAuthorizer = &MockStreamAuthorizer{}
+
+func NewMockStreamAuthorizer() *MockStreamAuthorizer {
+	return &MockStreamAuthorizer{}
+}
+
+func (m *MockStreamAuthorizer) Reauthorize(ctx context.Context) (*model.Membership, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Membership), args.Error(1)
+}
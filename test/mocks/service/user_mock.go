@@ -1,9 +1,11 @@
 package service
 
 import (
+	"context"
+	"time"
+
 	"github.com/Verano-20/stage-zero/internal/model"
 	"github.com/Verano-20/stage-zero/internal/service"
-	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -17,7 +19,7 @@ func NewMockUserService() *MockUserService {
 	return &MockUserService{}
 }
 
-func (m *MockUserService) CreateUser(ctx *gin.Context, userForm model.UserForm) (user *model.User, createErr error) {
+func (m *MockUserService) CreateUser(ctx context.Context, userForm model.UserForm) (user *model.User, createErr error) {
 	args := m.Called(ctx, userForm)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -25,10 +27,105 @@ func (m *MockUserService) CreateUser(ctx *gin.Context, userForm model.UserForm)
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockUserService) GetUserByEmail(ctx *gin.Context, email string) (user *model.User, err error) {
+func (m *MockUserService) GetUserByID(ctx context.Context, id uint) (user *model.User, err error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserService) GetUserByEmail(ctx context.Context, email string) (user *model.User, err error) {
 	args := m.Called(ctx, email)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.User), args.Error(1)
 }
+
+func (m *MockUserService) ChangePassword(ctx context.Context, user *model.User, changePasswordForm model.ChangePasswordForm) (*model.User, error) {
+	args := m.Called(ctx, user, changePasswordForm)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserService) RequestEmailChange(ctx context.Context, user *model.User, changeEmailForm model.ChangeEmailForm) error {
+	args := m.Called(ctx, user, changeEmailForm)
+	return args.Error(0)
+}
+
+func (m *MockUserService) VerifyEmailChange(ctx context.Context, token string) (*model.User, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserService) DeleteUser(ctx context.Context, user *model.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ListUsers(ctx context.Context, filter model.UserFilter) (model.Users, int64, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).(model.Users), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserService) DisableUser(ctx context.Context, user *model.User) (*model.User, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserService) EnableUser(ctx context.Context, user *model.User) (*model.User, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserService) ForcePasswordReset(ctx context.Context, user *model.User) (*model.User, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserService) RecordFailedLogin(ctx context.Context, user *model.User, maxAttempts int, lockoutDuration time.Duration) error {
+	args := m.Called(ctx, user, maxAttempts, lockoutDuration)
+	return args.Error(0)
+}
+
+func (m *MockUserService) MarkEmailVerified(ctx context.Context, user *model.User) (*model.User, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserService) UnlockUser(ctx context.Context, user *model.User) (*model.User, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserService) RehashPassword(ctx context.Context, user *model.User, plainPassword string) (*model.User, error) {
+	args := m.Called(ctx, user, plainPassword)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
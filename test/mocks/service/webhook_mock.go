@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWebhookService struct {
+	mock.Mock
+}
+
+var _ service.WebhookService = &MockWebhookService{}
+
+func NewMockWebhookService() *MockWebhookService {
+	return &MockWebhookService{}
+}
+
+func (m *MockWebhookService) ListEndpoints(ctx context.Context, user *model.User, organizationID uint) (model.WebhookEndpoints, error) {
+	args := m.Called(ctx, user, organizationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(model.WebhookEndpoints), args.Error(1)
+}
+
+func (m *MockWebhookService) CreateEndpoint(ctx context.Context, user *model.User, organizationID uint, endpointForm model.WebhookEndpointForm) (*model.WebhookEndpoint, error) {
+	args := m.Called(ctx, user, organizationID, endpointForm)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockWebhookService) UpdateEndpoint(ctx context.Context, user *model.User, organizationID uint, endpointID uint, endpointForm model.WebhookEndpointForm) (*model.WebhookEndpoint, error) {
+	args := m.Called(ctx, user, organizationID, endpointID, endpointForm)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockWebhookService) DeleteEndpoint(ctx context.Context, user *model.User, organizationID uint, endpointID uint) error {
+	args := m.Called(ctx, user, organizationID, endpointID)
+	return args.Error(0)
+}
+
+func (m *MockWebhookService) ListDeliveries(ctx context.Context, user *model.User, organizationID uint, endpointID uint, filter model.WebhookDeliveryFilter) (model.WebhookDeliveries, int64, error) {
+	args := m.Called(ctx, user, organizationID, endpointID, filter)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).(model.WebhookDeliveries), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockWebhookService) Redeliver(ctx context.Context, user *model.User, organizationID uint, endpointID uint, deliveryID uint64) (*model.WebhookDelivery, error) {
+	args := m.Called(ctx, user, organizationID, endpointID, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookService) EnqueueSimpleEvent(ctx context.Context, eventType string, simple *model.Simple) error {
+	args := m.Called(ctx, eventType, simple)
+	return args.Error(0)
+}
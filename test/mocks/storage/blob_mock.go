@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"io"
+
+	"github.com/Verano-20/stage-zero/internal/storage"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockBlobStore struct {
+	mock.Mock
+}
+
+var _ storage.BlobStore = &MockBlobStore{}
+
+func NewMockBlobStore() *MockBlobStore {
+	return &MockBlobStore{}
+}
+
+func (m *MockBlobStore) Put(ctx context.Context, key string, content io.Reader, size int64) error {
+	args := m.Called(ctx, key, content, size)
+	return args.Error(0)
+}
+
+func (m *MockBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(io.ReadCloser), args.Error(1)
+}
+
+func (m *MockBlobStore) Exists(ctx context.Context, key string) (bool, error) {
+	args := m.Called(ctx, key)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockBlobStore) Delete(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
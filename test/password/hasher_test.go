@@ -0,0 +1,168 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Verano-20/stage-zero/internal/password"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var argon2idParams = password.Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+func createHashers() map[string]password.PasswordHasher {
+	return map[string]password.PasswordHasher{
+		password.AlgorithmBcrypt:   password.NewBcryptHasher(bcrypt.MinCost),
+		password.AlgorithmArgon2id: password.NewArgon2idHasher(argon2idParams),
+	}
+}
+
+/*
+ * Hash and Verify Tests
+ */
+
+func TestHashAndVerify(t *testing.T) {
+	tests := []struct {
+		testName string
+		password string
+	}{
+		{
+			testName: "Short Password",
+			password: "password1",
+		},
+		{
+			testName: "Long Password",
+			password: strings.Repeat("a", 200),
+		},
+		{
+			testName: "Multibyte Password",
+			password: strings.Repeat("é", 50),
+		},
+	}
+
+	for algorithm, target := range createHashers() {
+		for _, test := range tests {
+			t.Run(algorithm+" "+test.testName, func(t *testing.T) {
+				// when
+				encodedHash, err := target.Hash(test.password)
+				// then
+				assert.NoError(t, err)
+				assert.True(t, strings.HasPrefix(encodedHash, "$"))
+				assert.NoError(t, target.Verify(test.password, encodedHash))
+				assert.ErrorIs(t, target.Verify(test.password+"1", encodedHash), password.ErrPasswordMismatch)
+				assert.False(t, target.NeedsRehash(encodedHash))
+			})
+		}
+	}
+}
+
+func TestHash_Argon2idFormat(t *testing.T) {
+	// given
+	target := password.NewArgon2idHasher(argon2idParams)
+	// when
+	first, err := target.Hash("password1")
+	assert.NoError(t, err)
+	second, err := target.Hash("password1")
+	assert.NoError(t, err)
+	// then
+	assert.True(t, strings.HasPrefix(first, "$argon2id$v=19$m=1024,t=1,p=1$"))
+	assert.NotEqual(t, first, second)
+}
+
+func TestVerify_OtherAlgorithm(t *testing.T) {
+	// given
+	bcryptHash, _ := password.NewBcryptHasher(bcrypt.MinCost).Hash("password1")
+	argon2idHash, _ := password.NewArgon2idHasher(argon2idParams).Hash("password1")
+	// then
+	assert.NoError(t, password.NewArgon2idHasher(argon2idParams).Verify("password1", bcryptHash))
+	assert.NoError(t, password.NewBcryptHasher(bcrypt.MinCost).Verify("password1", argon2idHash))
+}
+
+func TestVerify_UnsupportedHash(t *testing.T) {
+	tests := []struct {
+		testName    string
+		encodedHash string
+	}{
+		{
+			testName:    "Empty",
+			encodedHash: "",
+		},
+		{
+			testName:    "Unknown Algorithm",
+			encodedHash: "$scrypt$ln=15,r=8,p=1$c2FsdA$aGFzaA",
+		},
+		{
+			testName:    "Malformed Argon2id Parameters",
+			encodedHash: "$argon2id$v=19$m=abc$c2FsdA$aGFzaA",
+		},
+		{
+			testName:    "Unsupported Argon2id Version",
+			encodedHash: "$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$aGFzaA",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			target := password.NewArgon2idHasher(argon2idParams)
+			// when
+			err := target.Verify("password1", test.encodedHash)
+			// then
+			assert.ErrorIs(t, err, password.ErrUnsupportedHash)
+			assert.True(t, target.NeedsRehash(test.encodedHash))
+		})
+	}
+}
+
+/*
+ * Needs Rehash Tests
+ */
+
+func TestNeedsRehash(t *testing.T) {
+	bcryptHash, _ := password.NewBcryptHasher(bcrypt.MinCost).Hash("password1")
+	argon2idHash, _ := password.NewArgon2idHasher(argon2idParams).Hash("password1")
+	tests := []struct {
+		testName    string
+		target      password.PasswordHasher
+		encodedHash string
+		expected    bool
+	}{
+		{
+			testName:    "Bcrypt Same Cost",
+			target:      password.NewBcryptHasher(bcrypt.MinCost),
+			encodedHash: bcryptHash,
+			expected:    false,
+		},
+		{
+			testName:    "Bcrypt Different Cost",
+			target:      password.NewBcryptHasher(bcrypt.MinCost + 1),
+			encodedHash: bcryptHash,
+			expected:    true,
+		},
+		{
+			testName:    "Bcrypt To Argon2id",
+			target:      password.NewArgon2idHasher(argon2idParams),
+			encodedHash: bcryptHash,
+			expected:    true,
+		},
+		{
+			testName:    "Argon2id Different Parameters",
+			target:      password.NewArgon2idHasher(password.Argon2idParams{Memory: 2048, Iterations: 1, Parallelism: 1}),
+			encodedHash: argon2idHash,
+			expected:    true,
+		},
+		{
+			testName:    "Argon2id To Bcrypt",
+			target:      password.NewBcryptHasher(bcrypt.MinCost),
+			encodedHash: argon2idHash,
+			expected:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.target.NeedsRehash(test.encodedHash))
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Verano-20/stage-zero/internal/password"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// The SHA-1 of "password1" in HIBP format, plus an unrelated lower case hash without a count
+const breachedCorpus = `# breached passwords
+E38AD214943DAAD1D64C102FAEC29DE4AFE9DA3D:2413945
+b4b5e6a4a6e0d0f6e1d3f0a4b1e5b2c3d4e5f6a7
+
+`
+
+func createPolicy(t *testing.T, config password.PolicyConfig) password.Policy {
+	breachedPasswords, err := password.NewBreachedPasswordCorpus(strings.NewReader(breachedCorpus))
+	assert.NoError(t, err)
+	return password.NewPolicy(config, password.NewBcryptHasher(bcrypt.MinCost), breachedPasswords)
+}
+
+/*
+ * Policy Check Tests
+ */
+
+func TestPolicyCheck(t *testing.T) {
+	strictConfig := password.PolicyConfig{
+		MinLength:        10,
+		MaxLength:        20,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		ForbidEmail:      true,
+	}
+	tests := []struct {
+		testName           string
+		config             password.PolicyConfig
+		password           string
+		email              string
+		expectedViolations []string
+	}{
+		{
+			testName: "Valid",
+			config:   strictConfig,
+			password: "Str0ng&Secure",
+			email:    "user@example.com",
+		},
+		{
+			testName:           "Too Short",
+			config:             strictConfig,
+			password:           "Sh0rt&",
+			email:              "user@example.com",
+			expectedViolations: []string{password.RuleMinLength},
+		},
+		{
+			testName:           "Too Long",
+			config:             strictConfig,
+			password:           "Much&T00LongToBeAccepted",
+			email:              "user@example.com",
+			expectedViolations: []string{password.RuleMaxLength},
+		},
+		{
+			testName:           "Missing Character Classes",
+			config:             strictConfig,
+			password:           "alllowercase",
+			email:              "user@example.com",
+			expectedViolations: []string{password.RuleUppercase, password.RuleDigit, password.RuleSymbol},
+		},
+		{
+			testName:           "Missing Lowercase",
+			config:             strictConfig,
+			password:           "ALLUPPER&123",
+			email:              "user@example.com",
+			expectedViolations: []string{password.RuleLowercase},
+		},
+		{
+			testName:           "Contains Email",
+			config:             strictConfig,
+			password:           "My&Jane.Doe1",
+			email:              "jane.doe@example.com",
+			expectedViolations: []string{password.RuleContainsEmail},
+		},
+		{
+			testName: "Short Local Part Allowed",
+			config:   strictConfig,
+			password: "Str0ng&Jo123",
+			email:    "jo@example.com",
+		},
+		{
+			testName: "Contains Email Allowed",
+			config:   password.PolicyConfig{MinLength: 8},
+			password: "jane.doe@example.com",
+			email:    "jane.doe@example.com",
+		},
+		{
+			testName:           "Breached",
+			config:             password.PolicyConfig{MinLength: 8},
+			password:           "password1",
+			email:              "user@example.com",
+			expectedViolations: []string{password.RuleBreached},
+		},
+		{
+			testName: "Length Counts Characters",
+			config:   password.PolicyConfig{MinLength: 8, MaxLength: 8},
+			password: "éééééééé",
+			email:    "user@example.com",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			target := createPolicy(t, test.config)
+			// when
+			violations := target.Check(test.password, test.email, nil)
+			// then
+			if test.expectedViolations == nil {
+				assert.Nil(t, violations)
+				return
+			}
+			assert.Len(t, violations, len(test.expectedViolations))
+			for _, rule := range test.expectedViolations {
+				assert.NotEmpty(t, violations[rule])
+			}
+		})
+	}
+}
+
+func TestPolicyCheck_History(t *testing.T) {
+	// given
+	hasher := password.NewBcryptHasher(bcrypt.MinCost)
+	bcryptHash, _ := hasher.Hash("oldPassword1")
+	argon2idHash, _ := password.NewArgon2idHasher(password.Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1}).Hash("olderPassword1")
+	target := password.NewPolicy(password.PolicyConfig{MinLength: 8, HistorySize: 2}, hasher, nil)
+	previousHashes := []string{bcryptHash, argon2idHash}
+	// then
+	assert.Contains(t, target.Check("oldPassword1", "user@example.com", previousHashes), password.RuleReused)
+	assert.Contains(t, target.Check("olderPassword1", "user@example.com", previousHashes), password.RuleReused)
+	assert.Nil(t, target.Check("newPassword1", "user@example.com", previousHashes))
+	assert.Equal(t, 2, target.HistorySize())
+}
+
+/*
+ * Breached Password Corpus Tests
+ */
+
+func TestNewBreachedPasswordCorpus_InvalidHash(t *testing.T) {
+	tests := []struct {
+		testName string
+		corpus   string
+	}{
+		{
+			testName: "Too Short",
+			corpus:   "E38AD214943DAAD1D64C102FAEC29DE4AFE9DA\n",
+		},
+		{
+			testName: "Not Hex",
+			corpus:   "Z38AD214943DAAD1D64C102FAEC29DE4AFE9DA3D:1\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// when
+			corpus, err := password.NewBreachedPasswordCorpus(strings.NewReader(test.corpus))
+			// then
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "line 1")
+			assert.Nil(t, corpus)
+		})
+	}
+}
+
+func TestBreachedPasswordCorpus_IsBreached(t *testing.T) {
+	// given
+	corpus, err := password.NewBreachedPasswordCorpus(strings.NewReader(breachedCorpus))
+	assert.NoError(t, err)
+	// then
+	assert.True(t, corpus.IsBreached("password1"))
+	assert.False(t, corpus.IsBreached("password2"))
+	assert.False(t, corpus.IsBreached("Password1"))
+}
+
+func TestLoadBreachedPasswordCorpus_MissingFile(t *testing.T) {
+	// when
+	corpus, err := password.LoadBreachedPasswordCorpus(t.TempDir() + "/missing.txt")
+	// then
+	assert.Error(t, err)
+	assert.Nil(t, corpus)
+}
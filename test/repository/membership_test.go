@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMembershipRepository_LockOwnersLocksOwnerMemberships(t *testing.T) {
+	// given
+	db, statements := createDryRunDB(t)
+	target := repository.NewMembershipRepository(db)
+	// when
+	owners, err := target.LockOwners(context.Background(), 7)
+	// then
+	require.NoError(t, err)
+	assert.Zero(t, owners)
+	require.Len(t, *statements, 1)
+	assert.Equal(t, `SELECT "user_id" FROM "memberships" WHERE organization_id = $1 AND role = $2 FOR UPDATE`, (*statements)[0].SQL)
+	assert.Equal(t, []any{uint(7), "owner"}, (*statements)[0].Vars)
+}
@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Verano-20/stage-zero/internal/config"
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/Verano-20/stage-zero/internal/telemetry"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"github.com/Verano-20/stage-zero/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type capturedStatement struct {
+	SQL  string
+	Vars []any
+}
+
+func TestMain(m *testing.M) {
+	os.Setenv("ENABLE_OTLP", "false")
+	os.Setenv("METRIC_INTERVAL", "1h")
+	config.InitConfig()
+	logger.InitLogger()
+	telemetry.InitTelemetry()
+	os.Exit(m.Run())
+}
+
+// Opens a dry run database that builds statements without executing them, and records every statement built.
+func createDryRunDB(t *testing.T) (*gorm.DB, *[]capturedStatement) {
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: &testutils.ConnPool{}}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+	})
+	require.NoError(t, err)
+
+	statements := &[]capturedStatement{}
+	capture := func(tx *gorm.DB) {
+		*statements = append(*statements, capturedStatement{SQL: tx.Statement.SQL.String(), Vars: tx.Statement.Vars})
+	}
+	require.NoError(t, db.Callback().Create().After("gorm:create").Register("test:capture_create", capture))
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:capture_query", capture))
+	require.NoError(t, db.Callback().Update().After("gorm:update").Register("test:capture_update", capture))
+	require.NoError(t, db.Callback().Delete().After("gorm:delete").Register("test:capture_delete", capture))
+	require.NoError(t, db.Callback().Raw().After("gorm:raw").Register("test:capture_raw", capture))
+	return db, statements
+}
+
+func createOrganizationContext(organizationID uint) context.Context {
+	if organizationID != 0 {
+		return utils.WithMembership(context.Background(), &model.Membership{OrganizationID: organizationID})
+	}
+	return context.Background()
+}
+
+func TestSimpleRepository_RequiresOrganization(t *testing.T) {
+	// given
+	db, statements := createDryRunDB(t)
+	target := repository.NewSimpleRepository(db)
+	ctx := createOrganizationContext(0)
+	// when
+	_, createErr := target.Create(ctx, &model.Simple{Name: "Simple"})
+	_, getAllErr := target.GetAll(ctx, model.SimpleFilter{})
+	_, getAccessibleErr := target.GetAllAccessibleBy(ctx, 1, model.SimpleFilter{})
+	_, getByIDErr := target.GetByID(ctx, 1)
+	_, updateErr := target.Update(ctx, &model.Simple{ID: 1, Name: "Simple"}, nil)
+	deleteErr := target.Delete(ctx, 1)
+	// then
+	for _, err := range []error{createErr, getAllErr, getAccessibleErr, getByIDErr, updateErr, deleteErr} {
+		assert.ErrorIs(t, err, repository.ErrOrganizationRequired)
+	}
+	assert.Empty(t, *statements)
+}
+
+func TestSimpleRepository_ScopesEveryStatementToOrganization(t *testing.T) {
+	const organizationID, otherOrganizationID uint = 7, 8
+	tests := []struct {
+		testName string
+		run      func(ctx context.Context, target repository.SimpleRepository)
+	}{
+		{
+			testName: "Create",
+			run: func(ctx context.Context, target repository.SimpleRepository) {
+				_, _ = target.Create(ctx, &model.Simple{Name: "Simple", OrganizationID: otherOrganizationID})
+			},
+		},
+		{
+			testName: "GetAll",
+			run: func(ctx context.Context, target repository.SimpleRepository) {
+				_, _ = target.GetAll(ctx, model.SimpleFilter{})
+			},
+		},
+		{
+			testName: "GetAllAccessibleBy",
+			run: func(ctx context.Context, target repository.SimpleRepository) {
+				_, _ = target.GetAllAccessibleBy(ctx, 1, model.SimpleFilter{})
+			},
+		},
+		{
+			testName: "GetByID",
+			run: func(ctx context.Context, target repository.SimpleRepository) {
+				_, _ = target.GetByID(ctx, 1)
+			},
+		},
+		{
+			testName: "Update",
+			run: func(ctx context.Context, target repository.SimpleRepository) {
+				_, _ = target.Update(ctx, &model.Simple{ID: 1, Name: "Simple", OrganizationID: otherOrganizationID}, nil)
+			},
+		},
+		{
+			testName: "Delete",
+			run: func(ctx context.Context, target repository.SimpleRepository) {
+				_ = target.Delete(ctx, 1)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			db, statements := createDryRunDB(t)
+			target := repository.NewSimpleRepository(db)
+			ctx := createOrganizationContext(organizationID)
+			// when
+			test.run(ctx, target)
+			// then
+			// Writes also append to the outbox in the same transaction, after the Simple's own statement.
+			require.NotEmpty(t, *statements)
+			statement := (*statements)[0]
+			assert.Contains(t, statement.SQL, "organization_id")
+			assert.Contains(t, statement.Vars, organizationID)
+			assert.NotContains(t, statement.Vars, otherOrganizationID)
+		})
+	}
+}
+
+func TestSimpleRepository_CreateRecordsChange(t *testing.T) {
+	// given
+	db, statements := createDryRunDB(t)
+	target := repository.NewSimpleRepository(db)
+	ctx := createOrganizationContext(7)
+	// when
+	_, err := target.Create(ctx, &model.Simple{ID: 3, Name: "Simple"})
+	// then
+	require.NoError(t, err)
+	require.Len(t, *statements, 3)
+	assert.True(t, strings.HasPrefix((*statements)[0].SQL, `INSERT INTO "simples"`))
+	outbox := (*statements)[1]
+	assert.True(t, strings.HasPrefix(outbox.SQL, `INSERT INTO "outbox_events"`))
+	assert.Contains(t, outbox.Vars, model.AggregateTypeSimple)
+	assert.Contains(t, outbox.Vars, "3")
+	assert.Contains(t, outbox.Vars, model.EventSimpleCreated)
+	notify := (*statements)[2]
+	assert.Equal(t, "SELECT pg_notify($1, $2)", notify.SQL)
+	require.Len(t, notify.Vars, 2)
+	assert.Equal(t, model.SimpleChangeChannel, notify.Vars[0])
+	assert.JSONEq(t, `{"event_id":0,"type":"simple.created","organization_id":7,"simple_id":3}`, notify.Vars[1].(string))
+}
+
+func TestSimpleRepository_UpdateNeverInserts(t *testing.T) {
+	// given
+	db, statements := createDryRunDB(t)
+	target := repository.NewSimpleRepository(db)
+	ctx := createOrganizationContext(7)
+	// when
+	result, err := target.Update(ctx, &model.Simple{ID: 1, Name: "Simple"}, nil)
+	// then
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.Nil(t, result)
+	require.Len(t, *statements, 1)
+	assert.Contains(t, (*statements)[0].SQL, "UPDATE")
+	assert.NotContains(t, (*statements)[0].SQL, "INSERT")
+}
+
+func TestSimpleRepository_DeleteReportsMissingSimple(t *testing.T) {
+	// given
+	db, statements := createDryRunDB(t)
+	target := repository.NewSimpleRepository(db)
+	ctx := createOrganizationContext(7)
+	// when
+	err := target.Delete(ctx, 1)
+	// then
+	// A dry run deletes no rows, as when the Simple is missing or belongs to another organization.
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	require.Len(t, *statements, 1)
+	assert.Contains(t, (*statements)[0].SQL, `SET "deleted_at"`)
+}
+
+func TestSimpleRepository_FiltersByTags(t *testing.T) {
+	tests := []struct {
+		testName       string
+		match          string
+		expectedHaving bool
+	}{
+		{testName: "Any", match: model.TagMatchAny, expectedHaving: false},
+		{testName: "All", match: model.TagMatchAll, expectedHaving: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			db, statements := createDryRunDB(t)
+			target := repository.NewSimpleRepository(db)
+			ctx := createOrganizationContext(7)
+			filter := model.SimpleFilter{Tags: []string{"urgent", "billing"}, Match: test.match}
+			// when
+			_, _ = target.GetAll(ctx, filter)
+			// then
+			// GORM renders the subquery through the query callbacks too, so the outer statement is the last captured.
+			require.NotEmpty(t, *statements)
+			statement := (*statements)[len(*statements)-1]
+			assert.True(t, strings.HasPrefix(statement.SQL, `SELECT * FROM "simples"`))
+			assert.Contains(t, statement.SQL, "simples.id IN (SELECT simple_tags.simple_id FROM")
+			assert.Contains(t, statement.Vars, uint(7))
+			assert.Equal(t, test.expectedHaving, strings.Contains(statement.SQL, "HAVING COUNT(DISTINCT tags.id) = "))
+		})
+	}
+}
+
+func TestTagRepository_RequiresOrganization(t *testing.T) {
+	// given
+	db, statements := createDryRunDB(t)
+	target := repository.NewTagRepository(db)
+	ctx := createOrganizationContext(0)
+	// when
+	_, findErr := target.FindOrCreate(ctx, []string{"urgent"})
+	_, suggestErr := target.Suggest(ctx, model.TagSuggestionFilter{Query: "urg", Limit: 10}, nil)
+	// then
+	assert.ErrorIs(t, findErr, repository.ErrOrganizationRequired)
+	assert.ErrorIs(t, suggestErr, repository.ErrOrganizationRequired)
+	assert.Empty(t, *statements)
+}
+
+func TestSimpleRepository_FiltersByMetadataContainment(t *testing.T) {
+	// given
+	db, statements := createDryRunDB(t)
+	target := repository.NewSimpleRepository(db)
+	ctx := createOrganizationContext(7)
+	filter := model.SimpleFilter{Kind: "ticket", Metadata: map[string]string{"priority": "high", "owner.team": "core", "estimate": "3"}}
+	// when
+	_, _ = target.GetAll(ctx, filter)
+	// then
+	require.Len(t, *statements, 1)
+	statement := (*statements)[0]
+	assert.Contains(t, statement.SQL, "simples.kind = ")
+	assert.Contains(t, statement.SQL, "(simples.metadata @> $3 OR simples.metadata @> $4)")
+	assert.Contains(t, statement.Vars, "ticket")
+	assert.Contains(t, statement.Vars, model.JSONMap{"estimate": "3"})
+	assert.Contains(t, statement.Vars, model.JSONMap{"estimate": float64(3)})
+	assert.Contains(t, statement.Vars, model.JSONMap{"owner": map[string]any{"team": "core"}})
+	assert.Contains(t, statement.Vars, model.JSONMap{"priority": "high"})
+	assert.NotContains(t, statement.Vars, model.JSONMap{"priority": nil})
+}
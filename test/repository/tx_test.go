@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Opens a database on a connection pool that records its statements and transactions. Savepoint names are random, so the log keeps only the
+// statement's first words.
+func createRecordingDB(t *testing.T) (*gorm.DB, func() []string) {
+	log := &[]string{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: &testutils.ConnPool{Log: log}}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+	})
+	require.NoError(t, err)
+
+	return db, func() []string {
+		statements := make([]string, len(*log))
+		for i, statement := range *log {
+			if strings.Contains(statement, "SAVEPOINT") {
+				statement = statement[:strings.LastIndex(statement, " ")]
+			} else if verb, _, found := strings.Cut(statement, ` "`); found {
+				statement = verb
+			}
+			statements[i] = statement
+		}
+		return statements
+	}
+}
+
+func TestTxManager_CommitsRepositoryWrites(t *testing.T) {
+	// given
+	db, statements := createRecordingDB(t)
+	target := repository.NewTxManager(db)
+	sessionRepository := repository.NewSessionRepository(db)
+	magicLinkRepository := repository.NewMagicLinkRepository(db)
+	// when
+	err := target.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		if err := sessionRepository.Revoke(ctx, 1); err != nil {
+			return err
+		}
+		_, err := magicLinkRepository.DeleteExpired(ctx, time.Now())
+		return err
+	})
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BEGIN", "tx: UPDATE", "tx: DELETE FROM", "COMMIT"}, statements())
+}
+
+func TestTxManager_RepositoriesOutsideUnitOfWorkDoNotJoin(t *testing.T) {
+	// given
+	db, statements := createRecordingDB(t)
+	target := repository.NewTxManager(db)
+	sessionRepository := repository.NewSessionRepository(db)
+	// when
+	err := target.WithinTransaction(context.Background(), func(context.Context) error {
+		return sessionRepository.Revoke(context.Background(), 1)
+	})
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BEGIN", "UPDATE", "COMMIT"}, statements())
+}
+
+func TestTxManager_RollsBackOnError(t *testing.T) {
+	// given
+	db, statements := createRecordingDB(t)
+	target := repository.NewTxManager(db)
+	sessionRepository := repository.NewSessionRepository(db)
+	workErr := errors.New("follow-up write failed")
+	// when
+	err := target.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		if err := sessionRepository.Revoke(ctx, 1); err != nil {
+			return err
+		}
+		return workErr
+	})
+	// then
+	assert.ErrorIs(t, err, workErr)
+	assert.Equal(t, []string{"BEGIN", "tx: UPDATE", "ROLLBACK"}, statements())
+}
+
+func TestTxManager_RollsBackOnPanic(t *testing.T) {
+	// given
+	db, statements := createRecordingDB(t)
+	target := repository.NewTxManager(db)
+	sessionRepository := repository.NewSessionRepository(db)
+	// when
+	run := func() {
+		_ = target.WithinTransaction(context.Background(), func(ctx context.Context) error {
+			_ = sessionRepository.Revoke(ctx, 1)
+			panic("unexpected")
+		})
+	}
+	// then
+	assert.PanicsWithValue(t, "unexpected", run)
+	assert.Equal(t, []string{"BEGIN", "tx: UPDATE", "ROLLBACK"}, statements())
+}
+
+func TestTxManager_NestedUnitOfWorkUsesSavepoint(t *testing.T) {
+	// given
+	db, statements := createRecordingDB(t)
+	target := repository.NewTxManager(db)
+	sessionRepository := repository.NewSessionRepository(db)
+	nestedErr := errors.New("nested write failed")
+	// when
+	var gotNestedErr error
+	err := target.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		if err := sessionRepository.Revoke(ctx, 1); err != nil {
+			return err
+		}
+		gotNestedErr = target.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := sessionRepository.Revoke(ctx, 2); err != nil {
+				return err
+			}
+			return nestedErr
+		})
+		return sessionRepository.Revoke(ctx, 3)
+	})
+	// then
+	require.NoError(t, err)
+	assert.ErrorIs(t, gotNestedErr, nestedErr)
+	assert.Equal(t, []string{
+		"BEGIN",
+		"tx: UPDATE",
+		"tx: SAVEPOINT",
+		"tx: UPDATE",
+		"tx: ROLLBACK TO SAVEPOINT",
+		"tx: UPDATE",
+		"COMMIT",
+	}, statements())
+}
+
+func TestTxManager_AuditEventsDoNotJoinUnitOfWork(t *testing.T) {
+	// given
+	db, statements := createRecordingDB(t)
+	target := repository.NewTxManager(db)
+	sessionRepository := repository.NewSessionRepository(db)
+	auditLogger := service.NewAuditLogger(repository.NewAuditRepository(db))
+	workErr := errors.New("follow-up write failed")
+	// when
+	err := target.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		if err := sessionRepository.Revoke(ctx, 1); err != nil {
+			return err
+		}
+		auditLogger.Record(ctx, &model.AuditEvent{Action: model.AuditActionSessionRevoke, Outcome: model.AuditOutcomeFailure})
+		return workErr
+	})
+	// then
+	// The pool cannot answer the audit log's query for the latest hash, but the event is written in a transaction of
+	// its own rather than a savepoint of the unit of work.
+	assert.ErrorIs(t, err, workErr)
+	assert.Equal(t, []string{
+		"BEGIN",
+		"tx: UPDATE",
+		"BEGIN",
+		"tx: SELECT pg_advisory_xact_lock($1)",
+		"tx: SELECT",
+		"ROLLBACK",
+		"ROLLBACK",
+	}, statements())
+}
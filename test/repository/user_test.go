@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestUserRepository_UpdateOnlyWritesAccountFields(t *testing.T) {
+	// given
+	db, statements := createDryRunDB(t)
+	target := repository.NewUserRepository(db)
+	user := &model.User{ID: 1, Email: "test1@example.com", PasswordHash: "hash", Role: model.UserRoleAdmin, FailedLoginAttempts: 2}
+	// when
+	result, err := target.Update(context.Background(), user)
+	// then
+	// A dry run updates no rows, as when the user is missing.
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.Nil(t, result)
+	require.Len(t, *statements, 1)
+	statement := (*statements)[0].SQL
+	assert.True(t, strings.HasPrefix(statement, `UPDATE "users" SET "email"=`))
+	assert.Contains(t, statement, `"password_hash"=`)
+	assert.Contains(t, statement, `"tokens_valid_after"=`)
+	assert.NotContains(t, statement, `"failed_login_attempts"`)
+	assert.NotContains(t, statement, `"locked_until"`)
+	assert.NotContains(t, statement, `"role"`)
+	assert.NotContains(t, statement, "INSERT")
+}
+
+func TestUserRepository_DeleteOnlyWritesEmail(t *testing.T) {
+	// given
+	db, statements := createDryRunDB(t)
+	target := repository.NewUserRepository(db)
+	user := &model.User{ID: 1, Email: "deleted-user-1@deleted.invalid", PasswordHash: "hash", Role: model.UserRoleAdmin}
+	// when
+	err := target.Delete(context.Background(), user)
+	// then
+	require.NoError(t, err)
+	require.Len(t, *statements, 3)
+	assert.True(t, strings.HasPrefix((*statements)[0].SQL, `UPDATE "users" SET "email"=$1,"updated_at"=$2 WHERE`))
+	assert.Equal(t, "deleted-user-1@deleted.invalid", (*statements)[0].Vars[0])
+	assert.True(t, strings.HasPrefix((*statements)[1].SQL, `UPDATE "users" SET "deleted_at"=$1 WHERE`))
+	assert.True(t, strings.HasPrefix((*statements)[2].SQL, `INSERT INTO "outbox_events"`))
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apiError "github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/storage"
+	"github.com/Verano-20/stage-zero/test/mocks/repository"
+	mockService "github.com/Verano-20/stage-zero/test/mocks/service"
+	mockStorage "github.com/Verano-20/stage-zero/test/mocks/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const attachmentMaxSize = 64
+
+var pdfContent = []byte("%PDF-1.7\n1 0 obj\n<<>>\nendobj\n")
+
+type attachmentServiceMocks struct {
+	txManager            *repository.MockTxManager
+	attachmentRepository *repository.MockAttachmentRepository
+	blobStore            *mockStorage.MockBlobStore
+	auditLogger          *mockService.MockAuditLogger
+}
+
+func createAttachmentServiceWithMocks(t *testing.T) (service.AttachmentService, *attachmentServiceMocks) {
+	mocks := &attachmentServiceMocks{
+		txManager:            repository.NewMockTxManager(),
+		attachmentRepository: repository.NewMockAttachmentRepository(),
+		blobStore:            mockStorage.NewMockBlobStore(),
+		auditLogger:          mockService.NewMockAuditLogger(),
+	}
+	mocks.txManager.On("WithinTransaction", mock.Anything).Return(nil).Maybe()
+	target := service.NewAttachmentService(mocks.txManager, mocks.attachmentRepository, mocks.blobStore, mocks.auditLogger, attachmentMaxSize, []string{"application/pdf", "text/plain"})
+	return target, mocks
+}
+
+func (mocks *attachmentServiceMocks) assertExpectations(t *testing.T) {
+	mocks.attachmentRepository.AssertExpectations(t)
+	mocks.blobStore.AssertExpectations(t)
+	mocks.auditLogger.AssertExpectations(t)
+}
+
+func sha256Hex(content []byte) string {
+	digest := sha256.Sum256(content)
+	return hex.EncodeToString(digest[:])
+}
+
+func createAttachment(id uint, content []byte) *model.Attachment {
+	return &model.Attachment{ID: id, OrganizationID: organizationID, SimpleID: 1, FileName: "file.pdf", ContentType: "application/pdf", Size: int64(len(content)), SHA256: sha256Hex(content)}
+}
+
+/*
+ * Upload Attachment Tests
+ */
+
+func TestUploadAttachment_Success(t *testing.T) {
+	tests := []struct {
+		testName    string
+		blobExists  bool
+		expectedPut bool
+	}{
+		{
+			testName:    "New content is stored",
+			blobExists:  false,
+			expectedPut: true,
+		},
+		{
+			testName:    "Content already stored is deduplicated",
+			blobExists:  true,
+			expectedPut: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := createSimpleContext(&organizationMember, model.OrganizationRoleMember)
+			target, mocks := createAttachmentServiceWithMocks(t)
+			simple := createOwnedSimple(1, organizationUser.ID, &model.SimpleGrant{UserID: organizationMember.ID, Permission: model.SimplePermissionWrite})
+			expectedAttachment := &model.Attachment{
+				OrganizationID: organizationID,
+				SimpleID:       1,
+				FileName:       "invoice.pdf",
+				ContentType:    "application/pdf",
+				Size:           int64(len(pdfContent)),
+				SHA256:         sha256Hex(pdfContent),
+				UploadedByID:   &organizationMember.ID,
+			}
+			blobKey := expectedAttachment.BlobKey()
+			// expect
+			mocks.attachmentRepository.On("LockContent", ctx, organizationID, expectedAttachment.SHA256).Return(nil).Once()
+			mocks.attachmentRepository.On("Create", ctx, expectedAttachment).Return(expectedAttachment, nil).Once()
+			mocks.blobStore.On("Exists", ctx, blobKey).Return(test.blobExists, nil).Once()
+			if test.expectedPut {
+				mocks.blobStore.On("Put", ctx, blobKey, mock.MatchedBy(func(content io.Reader) bool {
+					stored, _ := io.ReadAll(content)
+					return bytes.Equal(stored, pdfContent)
+				}), int64(len(pdfContent))).Return(nil).Once()
+			}
+			expectSimpleAuditEvent(mocks.auditLogger, ctx, model.AuditActionAttachAdd, "1", model.AuditOutcomeSuccess)
+			// when
+			result, err := target.UploadAttachment(ctx, simple, model.AttachmentUpload{FileName: "../../invoice.pdf", Content: bytes.NewReader(pdfContent)})
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, expectedAttachment, result)
+			mocks.assertExpectations(t)
+		})
+	}
+}
+
+func TestUploadAttachment_Rejected(t *testing.T) {
+	tests := []struct {
+		testName     string
+		user         *model.User
+		grants       []*model.SimpleGrant
+		content      []byte
+		expectedType string
+	}{
+		{
+			testName:     "Read only grant",
+			user:         &organizationMember,
+			grants:       []*model.SimpleGrant{{UserID: organizationMember.ID, Permission: model.SimplePermissionRead}},
+			content:      pdfContent,
+			expectedType: apiError.ErrorTypeSimpleAccess,
+		},
+		{
+			testName:     "Content type not allowed",
+			user:         &organizationUser,
+			content:      []byte("\x89PNG\r\n\x1a\n"),
+			expectedType: apiError.ErrorTypeAttachmentType,
+		},
+		{
+			testName:     "Content larger than the limit",
+			user:         &organizationUser,
+			content:      []byte(strings.Repeat("a", attachmentMaxSize+1)),
+			expectedType: apiError.ErrorTypeAttachmentTooLarge,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := createSimpleContext(test.user, model.OrganizationRoleMember)
+			target, mocks := createAttachmentServiceWithMocks(t)
+			simple := createOwnedSimple(1, organizationUser.ID, test.grants...)
+			// expect
+			expectSimpleAuditEvent(mocks.auditLogger, ctx, model.AuditActionAttachAdd, "1", model.AuditOutcomeFailure)
+			// when
+			result, err := target.UploadAttachment(ctx, simple, model.AttachmentUpload{FileName: "file", Content: bytes.NewReader(test.content)})
+			// then
+			assert.Nil(t, result)
+			assertApiErrorType(t, err, test.expectedType)
+			mocks.assertExpectations(t)
+		})
+	}
+}
+
+func TestUploadAttachment_StoreFailureRollsBack(t *testing.T) {
+	// given
+	ctx := createSimpleContext(&organizationUser, model.OrganizationRoleMember)
+	target, mocks := createAttachmentServiceWithMocks(t)
+	simple := createOwnedSimple(1, organizationUser.ID)
+	attachment := createAttachment(5, pdfContent)
+	expectedError := errors.New("storage unavailable")
+	// expect
+	mocks.attachmentRepository.On("LockContent", ctx, organizationID, attachment.SHA256).Return(nil).Once()
+	mocks.attachmentRepository.On("Create", ctx, mock.AnythingOfType("*model.Attachment")).Return(attachment, nil).Once()
+	mocks.blobStore.On("Exists", ctx, attachment.BlobKey()).Return(false, nil).Once()
+	mocks.blobStore.On("Put", ctx, attachment.BlobKey(), mock.Anything, attachment.Size).Return(expectedError).Once()
+	expectSimpleAuditEvent(mocks.auditLogger, ctx, model.AuditActionAttachAdd, "1", model.AuditOutcomeFailure)
+	// when
+	result, err := target.UploadAttachment(ctx, simple, model.AttachmentUpload{FileName: "file.pdf", Content: bytes.NewReader(pdfContent)})
+	// then
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, expectedError)
+	mocks.assertExpectations(t)
+}
+
+/*
+ * Delete Attachment Tests
+ */
+
+func TestDeleteAttachment_Success(t *testing.T) {
+	tests := []struct {
+		testName           string
+		remaining          int64
+		expectedBlobDelete bool
+	}{
+		{
+			testName:           "Last reference deletes the blob",
+			remaining:          0,
+			expectedBlobDelete: true,
+		},
+		{
+			testName:           "Shared content keeps the blob",
+			remaining:          1,
+			expectedBlobDelete: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := createSimpleContext(&organizationUser, model.OrganizationRoleMember)
+			target, mocks := createAttachmentServiceWithMocks(t)
+			simple := createOwnedSimple(1, organizationUser.ID)
+			attachment := createAttachment(5, pdfContent)
+			// expect
+			mocks.attachmentRepository.On("LockContent", ctx, organizationID, attachment.SHA256).Return(nil).Once()
+			mocks.attachmentRepository.On("Delete", ctx, uint(5)).Return(nil).Once()
+			mocks.attachmentRepository.On("CountByHash", ctx, organizationID, attachment.SHA256).Return(test.remaining, nil).Once()
+			if test.expectedBlobDelete {
+				// The blob is deleted after the attachment, in a transaction of its own that checks it is still unused.
+				mocks.attachmentRepository.On("LockContent", ctx, organizationID, attachment.SHA256).Return(nil).Once()
+				mocks.attachmentRepository.On("CountByHash", ctx, organizationID, attachment.SHA256).Return(int64(0), nil).Once()
+				mocks.blobStore.On("Delete", ctx, attachment.BlobKey()).Return(nil).Once()
+			}
+			expectSimpleAuditEvent(mocks.auditLogger, ctx, model.AuditActionAttachDelete, "1", model.AuditOutcomeSuccess)
+			// when
+			err := target.DeleteAttachment(ctx, simple, attachment)
+			// then
+			assert.NoError(t, err)
+			mocks.assertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteAttachment_Failure(t *testing.T) {
+	tests := []struct {
+		testName  string
+		countErr  error
+		commitErr error
+	}{
+		{testName: "Counting the blob's attachments fails", countErr: errors.New("database error")},
+		{testName: "Commit fails", commitErr: errors.New("commit failed")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := createSimpleContext(&organizationUser, model.OrganizationRoleMember)
+			txManager := repository.NewMockTxManager()
+			attachmentRepository := repository.NewMockAttachmentRepository()
+			blobStore := mockStorage.NewMockBlobStore()
+			auditLogger := mockService.NewMockAuditLogger()
+			target := service.NewAttachmentService(txManager, attachmentRepository, blobStore, auditLogger, attachmentMaxSize, []string{"application/pdf"})
+			simple := createOwnedSimple(1, organizationUser.ID)
+			attachment := createAttachment(5, pdfContent)
+			// expect
+			txManager.On("WithinTransaction", ctx).Return(test.commitErr).Once()
+			attachmentRepository.On("LockContent", ctx, organizationID, attachment.SHA256).Return(nil).Once()
+			attachmentRepository.On("Delete", ctx, uint(5)).Return(nil).Once()
+			attachmentRepository.On("CountByHash", ctx, organizationID, attachment.SHA256).Return(int64(0), test.countErr).Once()
+			expectSimpleAuditEvent(auditLogger, ctx, model.AuditActionAttachDelete, "1", model.AuditOutcomeFailure)
+			// when
+			err := target.DeleteAttachment(ctx, simple, attachment)
+			// then
+			assert.Error(t, err)
+			blobStore.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+			txManager.AssertExpectations(t)
+			attachmentRepository.AssertExpectations(t)
+			auditLogger.AssertExpectations(t)
+		})
+	}
+}
+
+// Locks content like pg_advisory_xact_lock, holding the lock until the unit of work it was taken in ends.
+type contentLockTxManager struct {
+	lock sync.Mutex
+}
+
+func (m *contentLockTxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	defer m.lock.Unlock()
+	return fn(ctx)
+}
+
+func TestDeleteAttachment_ConcurrentUploadKeepsBlob(t *testing.T) {
+	// given
+	ctx := createSimpleContext(&organizationUser, model.OrganizationRoleMember)
+	txManager := &contentLockTxManager{}
+	attachmentRepository := repository.NewMockAttachmentRepository()
+	auditLogger := mockService.NewMockAuditLogger()
+	blobStore, err := storage.NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+	target := service.NewAttachmentService(txManager, attachmentRepository, blobStore, auditLogger, attachmentMaxSize, []string{"application/pdf"})
+	simple := createOwnedSimple(1, organizationUser.ID)
+	deleted := createAttachment(5, pdfContent)
+	uploaded := createAttachment(6, pdfContent)
+	require.NoError(t, blobStore.Put(ctx, deleted.BlobKey(), bytes.NewReader(pdfContent), deleted.Size))
+	var references atomic.Int64
+	references.Store(1)
+	var startUpload sync.Once
+	uploadDone := make(chan error)
+	// expect
+	auditLogger.On("Record", mock.Anything, mock.Anything).Maybe()
+	attachmentRepository.On("LockContent", mock.Anything, organizationID, deleted.SHA256).Run(func(mock.Arguments) {
+		txManager.lock.Lock()
+	}).Return(nil).Times(3)
+	attachmentRepository.On("Delete", mock.Anything, deleted.ID).Run(func(mock.Arguments) {
+		references.Add(-1)
+	}).Return(nil).Once()
+	attachmentRepository.On("Create", mock.Anything, mock.AnythingOfType("*model.Attachment")).Run(func(mock.Arguments) {
+		references.Add(1)
+	}).Return(uploaded, nil).Once()
+	// The upload of the same content starts once the deleted attachment was the last one using the blob, and
+	// either reuses the blob before it is deleted or stores it again afterwards.
+	var countByHash *mock.Call
+	countByHash = attachmentRepository.On("CountByHash", mock.Anything, organizationID, deleted.SHA256).Run(func(mock.Arguments) {
+		startUpload.Do(func() {
+			go func() {
+				_, err := target.UploadAttachment(ctx, simple, model.AttachmentUpload{FileName: "file.pdf", Content: bytes.NewReader(pdfContent)})
+				uploadDone <- err
+			}()
+			time.Sleep(50 * time.Millisecond)
+		})
+		countByHash.ReturnArguments = mock.Arguments{references.Load(), nil}
+	}).Twice()
+	// when
+	deleteErr := target.DeleteAttachment(ctx, simple, deleted)
+	uploadErr := <-uploadDone
+	// then
+	assert.NoError(t, deleteErr)
+	assert.NoError(t, uploadErr)
+	exists, err := blobStore.Exists(ctx, uploaded.BlobKey())
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	attachmentRepository.AssertExpectations(t)
+}
+
+func TestDeleteAttachment_ReadOnlyGrant(t *testing.T) {
+	// given
+	ctx := createSimpleContext(&organizationMember, model.OrganizationRoleMember)
+	target, mocks := createAttachmentServiceWithMocks(t)
+	simple := createOwnedSimple(1, organizationUser.ID, &model.SimpleGrant{UserID: organizationMember.ID, Permission: model.SimplePermissionRead})
+	// expect
+	expectSimpleAuditEvent(mocks.auditLogger, ctx, model.AuditActionAttachDelete, "1", model.AuditOutcomeFailure)
+	// when
+	err := target.DeleteAttachment(ctx, simple, createAttachment(5, pdfContent))
+	// then
+	assertApiErrorType(t, err, apiError.ErrorTypeSimpleAccess)
+	mocks.assertExpectations(t)
+}
+
+/*
+ * Sanitize File Name Tests
+ */
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := map[string]string{
+		"invoice.pdf":            "invoice.pdf",
+		"../../etc/passwd":       "passwd",
+		`C:\Users\me\report.pdf`: "report.pdf",
+		"bad\"name\r\n.txt":      "badname.txt",
+		"  ":                     "attachment",
+		"dir/":                   "attachment",
+		"..":                     "attachment",
+	}
+
+	for fileName, expected := range tests {
+		t.Run(fileName, func(t *testing.T) {
+			// when
+			result := model.SanitizeFileName(fileName)
+			// then
+			assert.Equal(t, expected, result)
+		})
+	}
+}
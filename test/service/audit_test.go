@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"github.com/Verano-20/stage-zero/test/mocks/repository"
+	"github.com/Verano-20/stage-zero/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func createAuditLoggerWithMockDependencies(t *testing.T) (service.AuditLogger, *repository.MockAuditRepository) {
+	auditRepository := repository.NewMockAuditRepository()
+	defer auditRepository.AssertExpectations(t)
+	target := service.NewAuditLogger(auditRepository)
+	return target, auditRepository
+}
+
+// Builds a valid chain of count events, sealed in order as the repository would.
+func createAuditChain(count int) model.AuditEvents {
+	events := make(model.AuditEvents, 0, count)
+	prevHash := model.AuditChainGenesisHash
+	for i := 1; i <= count; i++ {
+		event := &model.AuditEvent{
+			ID:        uint64(i),
+			Action:    model.AuditActionLogin,
+			Outcome:   model.AuditOutcomeSuccess,
+			CreatedAt: time.Now(),
+		}
+		event.Seal(prevHash)
+		prevHash = event.Hash
+		events = append(events, event)
+	}
+	return events
+}
+
+/*
+ * Record Tests
+ */
+
+func TestRecord_FillsRequestDetails(t *testing.T) {
+	// given
+	user := &model.User{ID: 1, Email: testutils.UserForm1.Email}
+	admin := &model.User{ID: 10, Email: "admin@example.com", Role: model.UserRoleAdmin}
+	ctx := utils.WithRequestInfo(context.Background(), &utils.RequestInfo{Method: "DELETE", Path: "/simple/1", ClientIP: "192.0.2.1", UserAgent: "test-agent"})
+	ctx = utils.WithAuthenticatedUser(ctx, user, admin)
+	target, auditRepository := createAuditLoggerWithMockDependencies(t)
+	// expect
+	auditRepository.On("Append", ctx, mock.MatchedBy(func(event *model.AuditEvent) bool {
+		return *event.ActorID == user.ID &&
+			event.ActorEmail == user.Email &&
+			*event.ImpersonatorID == admin.ID &&
+			event.ClientIP == "192.0.2.1" &&
+			event.UserAgent == "test-agent" &&
+			!event.CreatedAt.IsZero()
+	})).Return(&model.AuditEvent{ID: 1}, nil).Once()
+	// when
+	target.Record(ctx, &model.AuditEvent{Action: model.AuditActionSimpleDelete, Outcome: model.AuditOutcomeSuccess})
+}
+
+func TestRecord_KeepsGivenActor(t *testing.T) {
+	// given
+	user := &model.User{ID: 1, Email: testutils.UserForm1.Email}
+	ctx := utils.WithAuthenticatedUser(context.Background(), user, user)
+	target, auditRepository := createAuditLoggerWithMockDependencies(t)
+	// expect
+	auditRepository.On("Append", ctx, mock.MatchedBy(func(event *model.AuditEvent) bool {
+		return event.ActorID == nil && event.ActorEmail == testutils.UserForm2.Email && event.ImpersonatorID == nil
+	})).Return(&model.AuditEvent{ID: 1}, nil).Once()
+	// when
+	target.Record(ctx, &model.AuditEvent{Action: model.AuditActionLogin, ActorEmail: testutils.UserForm2.Email, Outcome: model.AuditOutcomeFailure})
+}
+
+func TestRecord_AppendError(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, auditRepository := createAuditLoggerWithMockDependencies(t)
+	// expect
+	auditRepository.On("Append", ctx, mock.Anything).Return(nil, errors.New("database error")).Once()
+	// when
+	assert.NotPanics(t, func() {
+		target.Record(ctx, &model.AuditEvent{Action: model.AuditActionLogin, Outcome: model.AuditOutcomeFailure})
+	})
+}
+
+/*
+ * List Events Tests
+ */
+
+func TestListEvents_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, auditRepository := createAuditLoggerWithMockDependencies(t)
+	events := createAuditChain(2)
+	expectedFilter := model.AuditEventFilter{Action: model.AuditActionLogin, Page: 1, PageSize: 50}
+	// expect
+	auditRepository.On("List", ctx, expectedFilter).Return(events, int64(2), nil).Once()
+	// when
+	result, total, err := target.ListEvents(ctx, model.AuditEventFilter{Action: model.AuditActionLogin})
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, events, result)
+	assert.Equal(t, int64(2), total)
+}
+
+func TestListEvents_Error(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, auditRepository := createAuditLoggerWithMockDependencies(t)
+	expectedError := errors.New("database error")
+	// expect
+	auditRepository.On("List", ctx, mock.Anything).Return(nil, int64(0), expectedError).Once()
+	// when
+	result, total, err := target.ListEvents(ctx, model.AuditEventFilter{})
+	// then
+	assert.Equal(t, expectedError, err)
+	assert.Nil(t, result)
+	assert.Zero(t, total)
+}
+
+/*
+ * Verify Chain Tests
+ */
+
+func TestVerifyChain_Valid(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, auditRepository := createAuditLoggerWithMockDependencies(t)
+	events := createAuditChain(1003)
+	// expect
+	auditRepository.On("ListAfter", ctx, uint64(0), 1000).Return(events[:1000], nil).Once()
+	auditRepository.On("ListAfter", ctx, uint64(1000), 1000).Return(events[1000:], nil).Once()
+	// when
+	result, err := target.VerifyChain(ctx)
+	// then
+	assert.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, int64(1003), result.EventsChecked)
+	assert.Nil(t, result.FirstInvalidID)
+}
+
+func TestVerifyChain_Invalid(t *testing.T) {
+	tests := []struct {
+		testName        string
+		tamper          func(events model.AuditEvents) model.AuditEvents
+		expectedID      uint64
+		expectedReason  string
+		expectedChecked int64
+	}{
+		{
+			testName: "Modified Event",
+			tamper: func(events model.AuditEvents) model.AuditEvents {
+				events[2].Outcome = model.AuditOutcomeFailure
+				return events
+			},
+			expectedID:      3,
+			expectedReason:  "hash does not match the event contents",
+			expectedChecked: 3,
+		},
+		{
+			testName: "Deleted Event",
+			tamper: func(events model.AuditEvents) model.AuditEvents {
+				return append(events[:1], events[2:]...)
+			},
+			expectedID:      3,
+			expectedReason:  "previous hash does not match the preceding event",
+			expectedChecked: 2,
+		},
+		{
+			testName: "Resealed Event",
+			tamper: func(events model.AuditEvents) model.AuditEvents {
+				events[1].Reason = "edited"
+				events[1].Seal(events[0].Hash)
+				return events
+			},
+			expectedID:      3,
+			expectedReason:  "previous hash does not match the preceding event",
+			expectedChecked: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := context.Background()
+			target, auditRepository := createAuditLoggerWithMockDependencies(t)
+			events := test.tamper(createAuditChain(5))
+			// expect
+			auditRepository.On("ListAfter", ctx, uint64(0), 1000).Return(events, nil).Once()
+			// when
+			result, err := target.VerifyChain(ctx)
+			// then
+			assert.NoError(t, err)
+			assert.False(t, result.Valid)
+			assert.Equal(t, test.expectedID, *result.FirstInvalidID)
+			assert.Equal(t, test.expectedReason, result.Reason)
+			assert.Equal(t, test.expectedChecked, result.EventsChecked)
+		})
+	}
+}
+
+func TestVerifyChain_Error(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, auditRepository := createAuditLoggerWithMockDependencies(t)
+	expectedError := errors.New("database error")
+	// expect
+	auditRepository.On("ListAfter", ctx, uint64(0), 1000).Return(nil, expectedError).Once()
+	// when
+	result, err := target.VerifyChain(ctx)
+	// then
+	assert.Equal(t, expectedError, err)
+	assert.Nil(t, result)
+}
@@ -1,24 +1,40 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
 
+	apiError "github.com/Verano-20/stage-zero/internal/err"
 	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/password"
 	"github.com/Verano-20/stage-zero/internal/service"
 	mockService "github.com/Verano-20/stage-zero/test/mocks/service"
 	"github.com/Verano-20/stage-zero/test/testutils"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	maxFailedLogins = 5
+	lockoutDuration = 15 * time.Minute
+)
+
 func createAuthServiceWithMockDependencies(t *testing.T) (service.AuthService, *mockService.MockUserService) {
+	target, userService, _ := createAuthServiceWithAllMockDependencies(t)
+	return target, userService
+}
+
+func createAuthServiceWithAllMockDependencies(t *testing.T) (service.AuthService, *mockService.MockUserService, *mockService.MockSessionService) {
 	userService := mockService.NewMockUserService()
+	sessionService := mockService.NewMockSessionService()
 	defer userService.AssertExpectations(t)
-	target := service.NewAuthService(userService)
-	return target, userService
+	defer sessionService.AssertExpectations(t)
+	target := service.NewAuthService(userService, sessionService, testutils.PasswordHasher, maxFailedLogins, lockoutDuration)
+	return target, userService, sessionService
 }
 
 /*
@@ -27,7 +43,7 @@ func createAuthServiceWithMockDependencies(t *testing.T) (service.AuthService, *
 
 func TestValidateUserCredentials_Success(t *testing.T) {
 	// given
-	ctx, _ := testutils.CreateTestContext()
+	ctx := context.Background()
 	target, userService := createAuthServiceWithMockDependencies(t)
 	// expect
 	userService.On("GetUserByEmail", ctx, testutils.UserForm1.Email).Return(testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1), nil).Once()
@@ -59,7 +75,7 @@ func TestValidateUserCredentials_GetUserFailure(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.testName, func(t *testing.T) {
 			// given
-			ctx, _ := testutils.CreateTestContext()
+			ctx := context.Background()
 			target, userService := createAuthServiceWithMockDependencies(t)
 			// expect
 			userService.On("GetUserByEmail", ctx, test.userForm.Email).Return(nil, errors.New(test.expectedError)).Once()
@@ -84,24 +100,26 @@ func TestValidateUserCredentials_PasswordFailure(t *testing.T) {
 			testName:       "No Password",
 			userForm:       model.UserForm{Email: testutils.UserForm1.Email},
 			passwordToHash: testutils.UserForm1.Password,
-			expectedError:  "hashedPassword is not the hash of the given password",
+			expectedError:  password.ErrPasswordMismatch.Error(),
 		},
 		{
 			testName:       "Invalid Password",
 			userForm:       testutils.UserForm1,
 			passwordToHash: testutils.UserForm1.Password + "1",
-			expectedError:  "hashedPassword is not the hash of the given password",
+			expectedError:  password.ErrPasswordMismatch.Error(),
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.testName, func(t *testing.T) {
 			// given
-			ctx, _ := testutils.CreateTestContext()
+			ctx := context.Background()
 			target, userService := createAuthServiceWithMockDependencies(t)
 			passwordHash, _ := bcrypt.GenerateFromPassword([]byte(test.passwordToHash), bcrypt.DefaultCost)
 			// expect
-			userService.On("GetUserByEmail", ctx, test.userForm.Email).Return(testutils.UserForm1.ToModel(string(passwordHash)), nil)
+			user := testutils.UserForm1.ToModel(string(passwordHash))
+			userService.On("GetUserByEmail", ctx, test.userForm.Email).Return(user, nil)
+			userService.On("RecordFailedLogin", ctx, user, maxFailedLogins, lockoutDuration).Return(nil).Once()
 			// when
 			user, err := target.ValidateUserCredentials(ctx, test.userForm)
 			// then
@@ -112,16 +130,111 @@ func TestValidateUserCredentials_PasswordFailure(t *testing.T) {
 	}
 }
 
+func TestValidateUserCredentials_AccountUnavailable(t *testing.T) {
+	lockedUntil := time.Now().Add(time.Minute)
+	disabledAt := time.Now()
+	tests := []struct {
+		testName          string
+		disabledAt        *time.Time
+		lockedUntil       *time.Time
+		expectedErrorType string
+	}{
+		{
+			testName:          "Disabled",
+			disabledAt:        &disabledAt,
+			expectedErrorType: apiError.ErrorTypeAccountDisabled,
+		},
+		{
+			testName:          "Locked",
+			lockedUntil:       &lockedUntil,
+			expectedErrorType: apiError.ErrorTypeAccountLocked,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := context.Background()
+			target, userService := createAuthServiceWithMockDependencies(t)
+			user := testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1)
+			user.DisabledAt = test.disabledAt
+			user.LockedUntil = test.lockedUntil
+			// expect
+			userService.On("GetUserByEmail", ctx, testutils.UserForm1.Email).Return(user, nil).Once()
+			// when
+			result, err := target.ValidateUserCredentials(ctx, testutils.UserForm1)
+			// then
+			apiErr, ok := err.(*apiError.ApiError)
+			assert.True(t, ok)
+			assert.Equal(t, test.expectedErrorType, apiErr.Type)
+			assert.Nil(t, result)
+			// and
+			userService.AssertNotCalled(t, "RecordFailedLogin", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestValidateUserCredentials_ResetsFailedLogins(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userService := createAuthServiceWithMockDependencies(t)
+	user := testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1)
+	user.FailedLoginAttempts = 2
+	// expect
+	userService.On("GetUserByEmail", ctx, testutils.UserForm1.Email).Return(user, nil).Once()
+	userService.On("UnlockUser", ctx, user).Return(user, nil).Once()
+	// when
+	result, err := target.ValidateUserCredentials(ctx, testutils.UserForm1)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, user, result)
+}
+
+func TestValidateUserCredentials_RehashesOutdatedHash(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userService := createAuthServiceWithMockDependencies(t)
+	passwordHash, _ := password.NewBcryptHasher(bcrypt.MinCost).Hash(testutils.UserForm1.Password)
+	user := testutils.UserForm1.ToModel(passwordHash)
+	rehashedUser := testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1)
+	// expect
+	userService.On("GetUserByEmail", ctx, testutils.UserForm1.Email).Return(user, nil).Once()
+	userService.On("RehashPassword", ctx, user, testutils.UserForm1.Password).Return(rehashedUser, nil).Once()
+	// when
+	result, err := target.ValidateUserCredentials(ctx, testutils.UserForm1)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, rehashedUser, result)
+}
+
+func TestValidateUserCredentials_RehashFailure(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userService := createAuthServiceWithMockDependencies(t)
+	passwordHash, _ := password.NewBcryptHasher(bcrypt.MinCost).Hash(testutils.UserForm1.Password)
+	user := testutils.UserForm1.ToModel(passwordHash)
+	// expect
+	userService.On("GetUserByEmail", ctx, testutils.UserForm1.Email).Return(user, nil).Once()
+	userService.On("RehashPassword", ctx, user, testutils.UserForm1.Password).Return(nil, errors.New("database error")).Once()
+	// when
+	result, err := target.ValidateUserCredentials(ctx, testutils.UserForm1)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, user, result)
+}
+
 /*
  * Generate Token String Tests
  */
 
 func TestGenerateTokenString_Success(t *testing.T) {
 	// given
-	ctx, _ := testutils.CreateTestContext()
-	target, _ := createAuthServiceWithMockDependencies(t)
+	ctx := context.Background()
+	target, _, sessionService := createAuthServiceWithAllMockDependencies(t)
 	user := testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1)
 	user.ID = 1234
+	// expect
+	sessionService.On("CreateSession", ctx, user, mock.AnythingOfType("time.Time")).Return(&model.Session{ID: 42, UserID: user.ID}, nil).Once()
 	// when
 	tokenString, err := target.GenerateTokenString(ctx, user, testutils.JwtSecret)
 	// then
@@ -136,15 +249,33 @@ func TestGenerateTokenString_Success(t *testing.T) {
 	assert.NotNil(t, token)
 	assert.True(t, token.Valid)
 	assert.Equal(t, float64(user.ID), token.Claims.(jwt.MapClaims)["sub"])
+	assert.Equal(t, float64(42), token.Claims.(jwt.MapClaims)["sid"])
 	// and
 	iat := time.Unix(int64(token.Claims.(jwt.MapClaims)["iat"].(float64)), 0)
 	exp := time.Unix(int64(token.Claims.(jwt.MapClaims)["exp"].(float64)), 0)
 	assert.Equal(t, exp, iat.Add(time.Hour*24))
+	sessionService.AssertExpectations(t)
+}
+
+func TestGenerateTokenString_Failure_CreateSession(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, _, sessionService := createAuthServiceWithAllMockDependencies(t)
+	user := testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1)
+	// expect
+	sessionService.On("CreateSession", ctx, user, mock.AnythingOfType("time.Time")).Return(nil, errors.New("database error")).Once()
+	// when
+	tokenString, err := target.GenerateTokenString(ctx, user, testutils.JwtSecret)
+	// then
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "database error")
+	assert.Empty(t, tokenString)
+	sessionService.AssertExpectations(t)
 }
 
 func TestGenerateTokenString_Failure_NilJwtSecret(t *testing.T) {
 	// given
-	ctx, _ := testutils.CreateTestContext()
+	ctx := context.Background()
 	target, _ := createAuthServiceWithMockDependencies(t)
 	// when
 	tokenString, err := target.GenerateTokenString(ctx, testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1), nil)
@@ -153,3 +284,76 @@ func TestGenerateTokenString_Failure_NilJwtSecret(t *testing.T) {
 	assert.Contains(t, err.Error(), "jwtSecret is nil")
 	assert.Empty(t, tokenString)
 }
+
+func TestGenerateImpersonationTokenString_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, _ := createAuthServiceWithMockDependencies(t)
+	user := &model.User{ID: 1234}
+	actor := &model.User{ID: 10, Role: model.UserRoleAdmin}
+	// when
+	tokenString, err := target.GenerateImpersonationTokenString(ctx, user, actor, testutils.JwtSecret, 15*time.Minute)
+	// then
+	assert.NoError(t, err)
+	// and
+	token, err := jwt.NewParser().Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return testutils.JwtSecret, nil
+	})
+	assert.NoError(t, err)
+	claims := token.Claims.(jwt.MapClaims)
+	assert.Equal(t, float64(user.ID), claims["sub"])
+	assert.Equal(t, float64(actor.ID), claims["act"].(map[string]any)["sub"])
+	iat := time.Unix(int64(claims["iat"].(float64)), 0)
+	exp := time.Unix(int64(claims["exp"].(float64)), 0)
+	assert.Equal(t, exp, iat.Add(15*time.Minute))
+}
+
+/*
+ * Revoke Token Session Tests
+ */
+
+func TestRevokeTokenSession_EndsTokenSession(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, _, sessionService := createAuthServiceWithAllMockDependencies(t)
+	user := &model.User{ID: 1234}
+	sessionService.On("CreateSession", ctx, user, mock.AnythingOfType("time.Time")).Return(&model.Session{ID: 42, UserID: user.ID}, nil).Once()
+	tokenString, _ := target.GenerateTokenString(ctx, user, testutils.JwtSecret)
+	// expect
+	sessionService.On("EndSession", ctx, user.ID, uint(42)).Return(nil).Once()
+	// when
+	err := target.RevokeTokenSession(ctx, tokenString, testutils.JwtSecret)
+	// then
+	assert.NoError(t, err)
+	sessionService.AssertExpectations(t)
+}
+
+func TestRevokeTokenSession_NothingToRevoke(t *testing.T) {
+	ctx := context.Background()
+	expiredToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": 1234, "sid": 42, "exp": time.Now().Add(-time.Minute).Unix()}).SignedString(testutils.JwtSecret)
+	forgedToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": 1234, "sid": 42}).SignedString([]byte("another-secret-key"))
+	tests := []struct {
+		testName    string
+		tokenString func(service.AuthService) string
+	}{
+		{testName: "Malformed Token", tokenString: func(service.AuthService) string { return "not-a-token" }},
+		{testName: "Expired Token", tokenString: func(service.AuthService) string { return expiredToken }},
+		{testName: "Token Signed With Another Secret", tokenString: func(service.AuthService) string { return forgedToken }},
+		{testName: "Impersonation Token", tokenString: func(target service.AuthService) string {
+			tokenString, _ := target.GenerateImpersonationTokenString(ctx, &model.User{ID: 1234}, &model.User{ID: 10}, testutils.JwtSecret, time.Minute)
+			return tokenString
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			target, _, sessionService := createAuthServiceWithAllMockDependencies(t)
+			// when
+			err := target.RevokeTokenSession(ctx, test.tokenString(target), testutils.JwtSecret)
+			// then
+			assert.NoError(t, err)
+			sessionService.AssertNotCalled(t, "EndSession", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apiError "github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"github.com/Verano-20/stage-zero/test/mocks/repository"
+	mockService "github.com/Verano-20/stage-zero/test/mocks/service"
+	"github.com/Verano-20/stage-zero/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const impersonationTTL = 15 * time.Minute
+
+var adminUser = model.User{ID: 10, Email: "admin@example.com", Role: model.UserRoleAdmin}
+
+func createImpersonationServiceWithMockDependencies(t *testing.T) (service.ImpersonationService, *mockService.MockAuthService, *repository.MockImpersonationRepository) {
+	authService := mockService.NewMockAuthService()
+	impersonationRepository := repository.NewMockImpersonationRepository()
+	defer authService.AssertExpectations(t)
+	defer impersonationRepository.AssertExpectations(t)
+	target := service.NewImpersonationService(authService, impersonationRepository, impersonationTTL)
+	return target, authService, impersonationRepository
+}
+
+func createImpersonationRequestContext() context.Context {
+	return utils.WithRequestInfo(context.Background(), &utils.RequestInfo{Method: "POST", Path: "/admin/users/1/impersonate", ClientIP: "192.0.2.1"})
+}
+
+func TestStartImpersonation_Success(t *testing.T) {
+	// given
+	ctx := createImpersonationRequestContext()
+	target, authService, impersonationRepository := createImpersonationServiceWithMockDependencies(t)
+	actor := adminUser
+	user := &model.User{ID: 1, Email: testutils.UserForm1.Email, Role: model.UserRoleUser}
+	// expect
+	impersonationRepository.On("Create", ctx, mock.MatchedBy(func(event *model.ImpersonationEvent) bool {
+		return event.ActorID == actor.ID && event.UserID == user.ID && event.Action == model.ImpersonationActionStarted
+	})).Return(&model.ImpersonationEvent{ID: 1}, nil).Once()
+	authService.On("GenerateImpersonationTokenString", ctx, user, &actor, testutils.JwtSecret, impersonationTTL).Return("impersonation-token", nil).Once()
+	// when
+	tokenString, err := target.StartImpersonation(ctx, &actor, user, testutils.JwtSecret)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "impersonation-token", tokenString)
+}
+
+func TestStartImpersonation_NotAllowed(t *testing.T) {
+	disabledAt := time.Now()
+	tests := []struct {
+		testName string
+		user     *model.User
+	}{
+		{
+			testName: "Self",
+			user:     &adminUser,
+		},
+		{
+			testName: "Admin",
+			user:     &model.User{ID: 11, Role: model.UserRoleAdmin},
+		},
+		{
+			testName: "Disabled",
+			user:     &model.User{ID: 12, Role: model.UserRoleUser, DisabledAt: &disabledAt},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := createImpersonationRequestContext()
+			target, authService, impersonationRepository := createImpersonationServiceWithMockDependencies(t)
+			// when
+			tokenString, err := target.StartImpersonation(ctx, &adminUser, test.user, testutils.JwtSecret)
+			// then
+			apiErr, ok := err.(*apiError.ApiError)
+			assert.True(t, ok)
+			assert.Equal(t, apiError.ErrorTypeImpersonation, apiErr.Type)
+			assert.Empty(t, tokenString)
+			// and
+			impersonationRepository.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			authService.AssertNotCalled(t, "GenerateImpersonationTokenString", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestStartImpersonation_AuditFailure(t *testing.T) {
+	// given
+	ctx := createImpersonationRequestContext()
+	target, authService, impersonationRepository := createImpersonationServiceWithMockDependencies(t)
+	user := &model.User{ID: 1, Role: model.UserRoleUser}
+	expectedError := errors.New("database error")
+	// expect
+	impersonationRepository.On("Create", ctx, mock.Anything).Return(nil, expectedError).Once()
+	// when
+	tokenString, err := target.StartImpersonation(ctx, &adminUser, user, testutils.JwtSecret)
+	// then
+	assert.Equal(t, expectedError, err)
+	assert.Empty(t, tokenString)
+	// and
+	authService.AssertNotCalled(t, "GenerateImpersonationTokenString", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
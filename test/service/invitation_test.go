@@ -0,0 +1,429 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	apiError "github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/mail"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	mockMail "github.com/Verano-20/stage-zero/test/mocks/mail"
+	"github.com/Verano-20/stage-zero/test/mocks/repository"
+	mockService "github.com/Verano-20/stage-zero/test/mocks/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+const invitationURL = "http://localhost:3000/invitations/accept"
+
+var invitationSecret = []byte("invitation-test-secret-that-is-long-enough")
+
+type invitationServiceMocks struct {
+	invitationRepository   *repository.MockInvitationRepository
+	organizationRepository *repository.MockOrganizationRepository
+	membershipRepository   *repository.MockMembershipRepository
+	userService            *mockService.MockUserService
+	mailSender             *mockMail.MockSender
+	auditLogger            *mockService.MockAuditLogger
+}
+
+func createInvitationServiceWithMockDependencies(t *testing.T) (service.InvitationService, invitationServiceMocks) {
+	mocks := invitationServiceMocks{
+		invitationRepository:   repository.NewMockInvitationRepository(),
+		organizationRepository: repository.NewMockOrganizationRepository(),
+		membershipRepository:   repository.NewMockMembershipRepository(),
+		userService:            mockService.NewMockUserService(),
+		mailSender:             mockMail.NewMockSender(),
+		auditLogger:            mockService.NewMockAuditLogger(),
+	}
+	target := service.NewInvitationService(mocks.invitationRepository, mocks.organizationRepository, mocks.membershipRepository, mocks.userService, mocks.mailSender, mocks.auditLogger, invitationURL, 24*time.Hour)
+	return target, mocks
+}
+
+func (mocks invitationServiceMocks) assertExpectations(t *testing.T) {
+	mocks.invitationRepository.AssertExpectations(t)
+	mocks.organizationRepository.AssertExpectations(t)
+	mocks.membershipRepository.AssertExpectations(t)
+	mocks.userService.AssertExpectations(t)
+	mocks.mailSender.AssertExpectations(t)
+	mocks.auditLogger.AssertExpectations(t)
+}
+
+// Signs an invitation token the way the service does, so tests can present tokens with any expiry.
+func createInvitationToken(expiresAt time.Time, secret []byte) string {
+	return utils.SignToken("nonce."+strconv.FormatInt(expiresAt.Unix(), 10), secret)
+}
+
+func createInvitation(email string, role string, token string) *model.Invitation {
+	return &model.Invitation{
+		ID:             3,
+		OrganizationID: organizationID,
+		Email:          email,
+		Role:           role,
+		Status:         model.InvitationStatusPending,
+		TokenHash:      utils.HashToken(token),
+		ExpiresAt:      time.Now().Add(time.Hour),
+		Organization:   &model.Organization{ID: organizationID, Name: "Acme"},
+	}
+}
+
+/*
+ * Create Invitation Tests
+ */
+
+func TestCreateInvitation_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, mocks := createInvitationServiceWithMockDependencies(t)
+	invitationForm := model.InvitationForm{Email: organizationMember.Email, Role: model.OrganizationRoleMember}
+	var storedHash string
+	// expect
+	mocks.membershipRepository.On("Get", ctx, organizationID, organizationUser.ID).Return(createMembership(organizationUser.ID, model.OrganizationRoleAdmin), nil).Once()
+	mocks.userService.On("GetUserByEmail", ctx, invitationForm.Email).Return(nil, gorm.ErrRecordNotFound).Once()
+	mocks.invitationRepository.On("GetPendingByEmail", ctx, organizationID, invitationForm.Email).Return(nil, gorm.ErrRecordNotFound).Once()
+	mocks.invitationRepository.On("Create", ctx, mock.MatchedBy(func(invitation *model.Invitation) bool {
+		storedHash = invitation.TokenHash
+		return invitation.Email == invitationForm.Email && invitation.Role == invitationForm.Role && *invitation.InvitedByID == organizationUser.ID && invitation.ExpiresAt.After(time.Now())
+	})).Return(&model.Invitation{ID: 3, OrganizationID: organizationID, Email: invitationForm.Email, Role: invitationForm.Role}, nil).Once()
+	mocks.organizationRepository.On("GetByID", ctx, organizationID).Return(&model.Organization{ID: organizationID, Name: "Acme"}, nil).Once()
+	mocks.mailSender.On("Send", ctx, mock.MatchedBy(func(message mail.Message) bool {
+		if message.To != invitationForm.Email || !strings.Contains(message.Subject, "Acme") || !strings.Contains(message.Body, invitationURL+"?token=") {
+			return false
+		}
+		token := strings.TrimSpace(message.Body[strings.Index(message.Body, "?token=")+len("?token="):])
+		_, signed := utils.VerifySignedToken(token, invitationSecret)
+		return signed && utils.HashToken(token) == storedHash
+	})).Return(nil).Once()
+	mocks.auditLogger.On("Record", ctx, mock.MatchedBy(func(event *model.AuditEvent) bool {
+		return event.Action == model.AuditActionInviteCreate && event.TargetID == "3"
+	})).Once()
+	// when
+	invitation, err := target.CreateInvitation(ctx, &organizationUser, organizationID, invitationForm, invitationSecret)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), invitation.ID)
+	mocks.assertExpectations(t)
+}
+
+func TestCreateInvitation_Failure(t *testing.T) {
+	tests := []struct {
+		testName     string
+		actingRole   string
+		role         string
+		expect       func(ctx any, mocks invitationServiceMocks)
+		expectedType string
+	}{
+		{
+			testName:     "Member Cannot Invite",
+			actingRole:   model.OrganizationRoleMember,
+			role:         model.OrganizationRoleMember,
+			expectedType: apiError.ErrorTypeOrganizationAccess,
+		},
+		{
+			testName:     "Admin Cannot Invite Owners",
+			actingRole:   model.OrganizationRoleAdmin,
+			role:         model.OrganizationRoleOwner,
+			expectedType: apiError.ErrorTypeOrganizationAccess,
+		},
+		{
+			testName:   "Already A Member",
+			actingRole: model.OrganizationRoleOwner,
+			role:       model.OrganizationRoleMember,
+			expect: func(ctx any, mocks invitationServiceMocks) {
+				mocks.userService.On("GetUserByEmail", ctx, organizationMember.Email).Return(&organizationMember, nil).Once()
+				mocks.membershipRepository.On("Get", ctx, organizationID, organizationMember.ID).Return(createMembership(organizationMember.ID, model.OrganizationRoleMember), nil).Once()
+			},
+			expectedType: apiError.ErrorTypeMembershipExists,
+		},
+		{
+			testName:   "Already Invited",
+			actingRole: model.OrganizationRoleOwner,
+			role:       model.OrganizationRoleMember,
+			expect: func(ctx any, mocks invitationServiceMocks) {
+				mocks.userService.On("GetUserByEmail", ctx, organizationMember.Email).Return(nil, gorm.ErrRecordNotFound).Once()
+				mocks.invitationRepository.On("GetPendingByEmail", ctx, organizationID, organizationMember.Email).Return(&model.Invitation{ID: 3}, nil).Once()
+			},
+			expectedType: apiError.ErrorTypeInvitationPending,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := context.Background()
+			target, mocks := createInvitationServiceWithMockDependencies(t)
+			// expect
+			mocks.membershipRepository.On("Get", ctx, organizationID, organizationUser.ID).Return(createMembership(organizationUser.ID, test.actingRole), nil).Once()
+			if test.expect != nil {
+				test.expect(ctx, mocks)
+			}
+			// when
+			invitation, err := target.CreateInvitation(ctx, &organizationUser, organizationID, model.InvitationForm{Email: organizationMember.Email, Role: test.role}, invitationSecret)
+			// then
+			assert.Nil(t, invitation)
+			assertApiErrorType(t, err, test.expectedType)
+			mocks.invitationRepository.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			mocks.mailSender.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+			mocks.assertExpectations(t)
+		})
+	}
+}
+
+/*
+ * Resend And Revoke Invitation Tests
+ */
+
+func TestResendInvitation_InvalidatesPreviousToken(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, mocks := createInvitationServiceWithMockDependencies(t)
+	previousToken := createInvitationToken(time.Now().Add(time.Hour), invitationSecret)
+	invitation := createInvitation(organizationMember.Email, model.OrganizationRoleMember, previousToken)
+	// expect
+	mocks.membershipRepository.On("Get", ctx, organizationID, organizationUser.ID).Return(createMembership(organizationUser.ID, model.OrganizationRoleOwner), nil).Once()
+	mocks.invitationRepository.On("GetByID", ctx, organizationID, invitation.ID).Return(invitation, nil).Once()
+	mocks.invitationRepository.On("Reissue", ctx, mock.MatchedBy(func(reissued *model.Invitation) bool {
+		return reissued.TokenHash != utils.HashToken(previousToken)
+	})).Return(nil).Once()
+	mocks.organizationRepository.On("GetByID", ctx, organizationID).Return(invitation.Organization, nil).Once()
+	mocks.mailSender.On("Send", ctx, mock.Anything).Return(nil).Once()
+	// when
+	result, err := target.ResendInvitation(ctx, &organizationUser, organizationID, invitation.ID, invitationSecret)
+	// then
+	assert.NoError(t, err)
+	assert.NotEqual(t, utils.HashToken(previousToken), result.TokenHash)
+	mocks.assertExpectations(t)
+}
+
+func TestRevokeInvitation_Failure(t *testing.T) {
+	tests := []struct {
+		testName     string
+		actingRole   string
+		invitation   *model.Invitation
+		expectedType string
+	}{
+		{
+			testName:     "Already Answered",
+			actingRole:   model.OrganizationRoleOwner,
+			invitation:   &model.Invitation{ID: 3, OrganizationID: organizationID, Role: model.OrganizationRoleMember, Status: model.InvitationStatusAccepted},
+			expectedType: apiError.ErrorTypeInvitationNotFound,
+		},
+		{
+			testName:     "Admin Cannot Revoke Owner Invitation",
+			actingRole:   model.OrganizationRoleAdmin,
+			invitation:   &model.Invitation{ID: 3, OrganizationID: organizationID, Role: model.OrganizationRoleOwner, Status: model.InvitationStatusPending},
+			expectedType: apiError.ErrorTypeOrganizationAccess,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := context.Background()
+			target, mocks := createInvitationServiceWithMockDependencies(t)
+			// expect
+			mocks.membershipRepository.On("Get", ctx, organizationID, organizationUser.ID).Return(createMembership(organizationUser.ID, test.actingRole), nil).Once()
+			mocks.invitationRepository.On("GetByID", ctx, organizationID, test.invitation.ID).Return(test.invitation, nil).Once()
+			// when
+			err := target.RevokeInvitation(ctx, &organizationUser, organizationID, test.invitation.ID)
+			// then
+			assertApiErrorType(t, err, test.expectedType)
+			mocks.invitationRepository.AssertNotCalled(t, "Respond", mock.Anything, mock.Anything, mock.Anything)
+			mocks.assertExpectations(t)
+		})
+	}
+}
+
+/*
+ * Accept Invitation Tests
+ */
+
+func TestAcceptInvitation_ExistingUser(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, mocks := createInvitationServiceWithMockDependencies(t)
+	token := createInvitationToken(time.Now().Add(time.Hour), invitationSecret)
+	invitation := createInvitation(organizationMember.Email, model.OrganizationRoleAdmin, token)
+	member := &model.User{ID: organizationMember.ID, Email: organizationMember.Email}
+	// expect
+	mocks.invitationRepository.On("GetPendingByTokenHash", ctx, utils.HashToken(token)).Return(invitation, nil).Once()
+	mocks.userService.On("GetUserByEmail", ctx, invitation.Email).Return(member, nil).Once()
+	mocks.membershipRepository.On("Get", ctx, organizationID, member.ID).Return(nil, gorm.ErrRecordNotFound).Once()
+	mocks.invitationRepository.On("Accept", ctx, invitation, member.ID).Return(createMembership(member.ID, model.OrganizationRoleAdmin), nil).Once()
+	mocks.userService.On("MarkEmailVerified", ctx, member).Return(member, nil).Once()
+	mocks.auditLogger.On("Record", ctx, mock.MatchedBy(func(event *model.AuditEvent) bool {
+		return event.Action == model.AuditActionInviteAccept && *event.ActorID == member.ID
+	})).Once()
+	// when
+	membership, err := target.AcceptInvitation(ctx, model.AcceptInvitationForm{Token: token}, invitationSecret)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, model.OrganizationRoleAdmin, membership.Role)
+	assert.Equal(t, "Acme", membership.ToOrganizationDTO().Name)
+	mocks.userService.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+	mocks.assertExpectations(t)
+}
+
+func TestAcceptInvitation_CreatesUser(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, mocks := createInvitationServiceWithMockDependencies(t)
+	token := createInvitationToken(time.Now().Add(time.Hour), invitationSecret)
+	invitation := createInvitation(organizationMember.Email, model.OrganizationRoleMember, token)
+	member := &model.User{ID: organizationMember.ID, Email: organizationMember.Email}
+	// expect
+	mocks.invitationRepository.On("GetPendingByTokenHash", ctx, utils.HashToken(token)).Return(invitation, nil).Once()
+	mocks.userService.On("GetUserByEmail", ctx, invitation.Email).Return(nil, gorm.ErrRecordNotFound).Once()
+	mocks.userService.On("CreateUser", ctx, model.UserForm{Email: invitation.Email, Password: "securePassword1234"}).Return(member, nil).Once()
+	mocks.membershipRepository.On("Get", ctx, organizationID, member.ID).Return(nil, gorm.ErrRecordNotFound).Once()
+	mocks.invitationRepository.On("Accept", ctx, invitation, member.ID).Return(createMembership(member.ID, model.OrganizationRoleMember), nil).Once()
+	mocks.userService.On("MarkEmailVerified", ctx, member).Return(member, nil).Once()
+	mocks.auditLogger.On("Record", ctx, mock.Anything).Once()
+	// when
+	membership, err := target.AcceptInvitation(ctx, model.AcceptInvitationForm{Token: token, Password: "securePassword1234"}, invitationSecret)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, member.ID, membership.UserID)
+	mocks.assertExpectations(t)
+}
+
+func TestAcceptInvitation_Failure(t *testing.T) {
+	validToken := createInvitationToken(time.Now().Add(time.Hour), invitationSecret)
+	tests := []struct {
+		testName     string
+		token        string
+		expect       func(ctx any, mocks invitationServiceMocks)
+		expectedType string
+	}{
+		{
+			testName:     "Forged Signature",
+			token:        createInvitationToken(time.Now().Add(time.Hour), []byte("another-secret-that-is-long-enough-too")),
+			expectedType: apiError.ErrorTypeInvitationInvalid,
+		},
+		{
+			testName:     "Tampered Expiry",
+			token:        strings.Replace(validToken, "nonce.", "nonce.9", 1),
+			expectedType: apiError.ErrorTypeInvitationInvalid,
+		},
+		{
+			testName:     "Expired Token",
+			token:        createInvitationToken(time.Now().Add(-time.Minute), invitationSecret),
+			expectedType: apiError.ErrorTypeInvitationInvalid,
+		},
+		{
+			testName: "Answered Or Reissued",
+			token:    validToken,
+			expect: func(ctx any, mocks invitationServiceMocks) {
+				mocks.invitationRepository.On("GetPendingByTokenHash", ctx, utils.HashToken(validToken)).Return(nil, gorm.ErrRecordNotFound).Once()
+			},
+			expectedType: apiError.ErrorTypeInvitationInvalid,
+		},
+		{
+			testName: "No Account And No Password",
+			token:    validToken,
+			expect: func(ctx any, mocks invitationServiceMocks) {
+				invitation := createInvitation(organizationMember.Email, model.OrganizationRoleMember, validToken)
+				mocks.invitationRepository.On("GetPendingByTokenHash", ctx, utils.HashToken(validToken)).Return(invitation, nil).Once()
+				mocks.userService.On("GetUserByEmail", ctx, invitation.Email).Return(nil, gorm.ErrRecordNotFound).Once()
+			},
+			expectedType: apiError.ErrorTypePasswordRequired,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := context.Background()
+			target, mocks := createInvitationServiceWithMockDependencies(t)
+			// expect
+			if test.expect != nil {
+				test.expect(ctx, mocks)
+			}
+			// when
+			membership, err := target.AcceptInvitation(ctx, model.AcceptInvitationForm{Token: test.token}, invitationSecret)
+			// then
+			assert.Nil(t, membership)
+			assertApiErrorType(t, err, test.expectedType)
+			mocks.invitationRepository.AssertNotCalled(t, "Accept", mock.Anything, mock.Anything, mock.Anything)
+			mocks.assertExpectations(t)
+		})
+	}
+}
+
+/*
+ * Decline Invitation Tests
+ */
+
+func TestDeclineInvitation_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, mocks := createInvitationServiceWithMockDependencies(t)
+	token := createInvitationToken(time.Now().Add(time.Hour), invitationSecret)
+	invitation := createInvitation(organizationMember.Email, model.OrganizationRoleMember, token)
+	// expect
+	mocks.invitationRepository.On("GetPendingByTokenHash", ctx, utils.HashToken(token)).Return(invitation, nil).Once()
+	mocks.invitationRepository.On("Respond", ctx, invitation, model.InvitationStatusDeclined).Return(nil).Once()
+	mocks.auditLogger.On("Record", ctx, mock.MatchedBy(func(event *model.AuditEvent) bool {
+		return event.Action == model.AuditActionInviteDecline && event.ActorEmail == invitation.Email
+	})).Once()
+	// when
+	err := target.DeclineInvitation(ctx, token, invitationSecret)
+	// then
+	assert.NoError(t, err)
+	mocks.assertExpectations(t)
+}
+
+/*
+ * Sign Up With Invitation Tests
+ */
+
+func TestSignUpWithInvitation_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, mocks := createInvitationServiceWithMockDependencies(t)
+	token := createInvitationToken(time.Now().Add(time.Hour), invitationSecret)
+	invitation := createInvitation(organizationMember.Email, model.OrganizationRoleMember, token)
+	signUpForm := model.SignUpForm{UserForm: model.UserForm{Email: "TEST2@example.com", Password: "securePassword1234"}, InvitationToken: token}
+	member := &model.User{ID: organizationMember.ID, Email: signUpForm.Email}
+	verifiedAt := time.Now()
+	verifiedMember := &model.User{ID: member.ID, Email: member.Email, EmailVerifiedAt: &verifiedAt}
+	// expect
+	mocks.invitationRepository.On("GetPendingByTokenHash", ctx, utils.HashToken(token)).Return(invitation, nil).Once()
+	mocks.userService.On("CreateUser", ctx, signUpForm.UserForm).Return(member, nil).Once()
+	mocks.membershipRepository.On("Get", ctx, organizationID, member.ID).Return(nil, gorm.ErrRecordNotFound).Once()
+	mocks.invitationRepository.On("Accept", ctx, invitation, member.ID).Return(createMembership(member.ID, model.OrganizationRoleMember), nil).Once()
+	mocks.userService.On("MarkEmailVerified", ctx, member).Return(verifiedMember, nil).Once()
+	mocks.auditLogger.On("Record", ctx, mock.Anything).Once()
+	// when
+	user, membership, err := target.SignUpWithInvitation(ctx, signUpForm, invitationSecret)
+	// then
+	assert.NoError(t, err)
+	assert.NotNil(t, user.EmailVerifiedAt)
+	assert.Equal(t, organizationID, membership.OrganizationID)
+	mocks.assertExpectations(t)
+}
+
+func TestSignUpWithInvitation_EmailMismatch(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, mocks := createInvitationServiceWithMockDependencies(t)
+	token := createInvitationToken(time.Now().Add(time.Hour), invitationSecret)
+	invitation := createInvitation(organizationMember.Email, model.OrganizationRoleMember, token)
+	signUpForm := model.SignUpForm{UserForm: model.UserForm{Email: "someone.else@example.com", Password: "securePassword1234"}, InvitationToken: token}
+	// expect
+	mocks.invitationRepository.On("GetPendingByTokenHash", ctx, utils.HashToken(token)).Return(invitation, nil).Once()
+	// when
+	user, membership, err := target.SignUpWithInvitation(ctx, signUpForm, invitationSecret)
+	// then
+	assert.Nil(t, user)
+	assert.Nil(t, membership)
+	assertApiErrorType(t, err, apiError.ErrorTypeInvitationInvalid)
+	mocks.userService.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+	mocks.assertExpectations(t)
+}
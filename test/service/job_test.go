@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/config"
+	"github.com/Verano-20/stage-zero/internal/logger"
+	"github.com/Verano-20/stage-zero/internal/mail"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/telemetry"
+	mockMail "github.com/Verano-20/stage-zero/test/mocks/mail"
+	"github.com/Verano-20/stage-zero/test/mocks/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("ENABLE_OTLP", "false")
+	os.Setenv("METRIC_INTERVAL", "1h")
+	config.InitConfig()
+	logger.InitLogger()
+	telemetry.InitTelemetry()
+	os.Exit(m.Run())
+}
+
+var jobWorkerPoolConfig = service.JobWorkerPoolConfig{
+	Workers:      2,
+	PollInterval: 10 * time.Millisecond,
+	Timeout:      time.Second,
+	MaxAttempts:  3,
+	BaseBackoff:  time.Second,
+	MaxBackoff:   4 * time.Second,
+}
+
+type exportPayload struct {
+	OrganizationID uint   `json:"organization_id"`
+	Format         string `json:"format"`
+}
+
+var exportJob = service.JobKind[exportPayload]{Type: "export"}
+
+func createJobWorkerPool(t *testing.T) (service.JobWorkerPool, *repository.MockJobRepository) {
+	jobRepository := repository.NewMockJobRepository()
+	t.Cleanup(func() { jobRepository.AssertExpectations(t) })
+	return service.NewJobWorkerPool(jobRepository, jobWorkerPoolConfig), jobRepository
+}
+
+func createJob(id uint64, jobType string, attempts int, payload model.JSONMap) *model.Job {
+	return &model.Job{ID: id, Type: jobType, Payload: payload, Status: model.JobStatusRunning, Attempts: attempts, MaxAttempts: jobWorkerPoolConfig.MaxAttempts}
+}
+
+func TestEnqueue_StoresTypedPayload(t *testing.T) {
+	// given
+	target, jobRepository := createJobWorkerPool(t)
+	exportJob.Register(target, func(context.Context, exportPayload) error { return nil })
+	runAt := time.Now().Add(time.Hour)
+	// expect
+	jobRepository.On("Create", mock.Anything, mock.MatchedBy(func(job *model.Job) bool {
+		return job.Type == "export" && job.MaxAttempts == jobWorkerPoolConfig.MaxAttempts && job.RunAt.Equal(runAt) &&
+			assert.ObjectsAreEqual(model.JSONMap{"organization_id": float64(7), "format": "csv"}, job.Payload)
+	})).Return(nil).Once()
+	// when
+	job, err := exportJob.EnqueueAt(context.Background(), target, exportPayload{OrganizationID: 7, Format: "csv"}, runAt)
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, "export", job.Type)
+}
+
+func TestEnqueue_UsesMaxAttemptsOfKind(t *testing.T) {
+	// given
+	target, jobRepository := createJobWorkerPool(t)
+	kind := service.JobKind[exportPayload]{Type: "export", MaxAttempts: 10}
+	kind.Register(target, func(context.Context, exportPayload) error { return nil })
+	// expect
+	jobRepository.On("Create", mock.Anything, mock.MatchedBy(func(job *model.Job) bool {
+		return job.MaxAttempts == 10 && job.RunAt.IsZero()
+	})).Return(nil).Once()
+	// when
+	_, err := kind.Enqueue(context.Background(), target, exportPayload{})
+	// then
+	require.NoError(t, err)
+}
+
+func TestEnqueue_RejectsUnregisteredType(t *testing.T) {
+	// given
+	target, _ := createJobWorkerPool(t)
+	// when
+	job, err := exportJob.Enqueue(context.Background(), target, exportPayload{})
+	// then
+	assert.Error(t, err)
+	assert.Nil(t, job)
+}
+
+func TestRunDue_CompletesSucceededJobs(t *testing.T) {
+	// given
+	target, jobRepository := createJobWorkerPool(t)
+	var handled exportPayload
+	exportJob.Register(target, func(_ context.Context, payload exportPayload) error {
+		handled = payload
+		return nil
+	})
+	job := createJob(1, "export", 1, model.JSONMap{"organization_id": 7, "format": "csv"})
+	// expect
+	jobRepository.On("ClaimDue", mock.Anything, []string{"export"}, jobWorkerPoolConfig.Workers, mock.Anything).Return(model.Jobs{job}, nil).Once()
+	jobRepository.On("Complete", mock.Anything, job).Return(nil).Once()
+	// when
+	count, err := target.RunDue(context.Background())
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, exportPayload{OrganizationID: 7, Format: "csv"}, handled)
+}
+
+func TestRunDue_RetriesOrDeadLettersFailedJobs(t *testing.T) {
+	tests := []struct {
+		testName       string
+		attempts       int
+		handle         func(context.Context, exportPayload) error
+		expectedStatus string
+		expectedError  string
+		expectedDelay  time.Duration
+	}{
+		{
+			testName:       "Retries with backoff",
+			attempts:       2,
+			handle:         func(context.Context, exportPayload) error { return errors.New("storage unavailable") },
+			expectedStatus: model.JobStatusPending,
+			expectedError:  "storage unavailable",
+			expectedDelay:  2 * time.Second,
+		},
+		{
+			testName:       "Dead after last attempt",
+			attempts:       3,
+			handle:         func(context.Context, exportPayload) error { return errors.New("storage unavailable") },
+			expectedStatus: model.JobStatusDead,
+			expectedError:  "storage unavailable",
+		},
+		{
+			testName:       "Retries panics",
+			attempts:       1,
+			handle:         func(context.Context, exportPayload) error { panic("nil map") },
+			expectedStatus: model.JobStatusPending,
+			expectedError:  "job panicked: nil map",
+			expectedDelay:  time.Second,
+		},
+		{
+			testName:       "Dead when lease expired on last attempt",
+			attempts:       4,
+			handle:         func(context.Context, exportPayload) error { panic("must not run") },
+			expectedStatus: model.JobStatusDead,
+			expectedError:  "lease expired on the last of 3 attempts",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			// given
+			target, jobRepository := createJobWorkerPool(t)
+			exportJob.Register(target, tt.handle)
+			job := createJob(1, "export", tt.attempts, model.JSONMap{"format": "csv"})
+			start := time.Now()
+			// expect
+			jobRepository.On("ClaimDue", mock.Anything, []string{"export"}, jobWorkerPoolConfig.Workers, mock.Anything).Return(model.Jobs{job}, nil).Once()
+			jobRepository.On("SaveFailure", mock.Anything, job).Return(nil).Once()
+			// when
+			_, err := target.RunDue(context.Background())
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, job.Status)
+			assert.Equal(t, tt.expectedError, job.LastError)
+			assert.Nil(t, job.LockedUntil)
+			if tt.expectedStatus == model.JobStatusPending {
+				assert.WithinDuration(t, start.Add(tt.expectedDelay), job.RunAt, 500*time.Millisecond)
+				assert.Equal(t, model.JSONMap{"format": "csv"}, job.Payload)
+			} else {
+				assert.Empty(t, job.Payload)
+			}
+		})
+	}
+}
+
+func TestRunDue_TimesOutJobs(t *testing.T) {
+	// given
+	config := jobWorkerPoolConfig
+	config.Timeout = 10 * time.Millisecond
+	jobRepository := repository.NewMockJobRepository()
+	target := service.NewJobWorkerPool(jobRepository, config)
+	exportJob.Register(target, func(ctx context.Context, _ exportPayload) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	job := createJob(1, "export", 1, model.JSONMap{})
+	// expect
+	jobRepository.On("ClaimDue", mock.Anything, []string{"export"}, config.Workers, config.Timeout+time.Minute).Return(model.Jobs{job}, nil).Once()
+	jobRepository.On("SaveFailure", mock.Anything, job).Return(nil).Once()
+	// when
+	_, err := target.RunDue(context.Background())
+	// then
+	require.NoError(t, err)
+	jobRepository.AssertExpectations(t)
+	assert.Equal(t, model.JobStatusPending, job.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), job.LastError)
+}
+
+func TestRunDue_ReturnsClaimError(t *testing.T) {
+	// given
+	target, jobRepository := createJobWorkerPool(t)
+	exportJob.Register(target, func(context.Context, exportPayload) error { return nil })
+	expectedError := errors.New("database error")
+	// expect
+	jobRepository.On("ClaimDue", mock.Anything, []string{"export"}, jobWorkerPoolConfig.Workers, mock.Anything).Return(nil, expectedError).Once()
+	// when
+	count, err := target.RunDue(context.Background())
+	// then
+	assert.Equal(t, expectedError, err)
+	assert.Zero(t, count)
+}
+
+func TestRun_FinishesJobsInFlightWhenStopped(t *testing.T) {
+	// given
+	target, jobRepository := createJobWorkerPool(t)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var finished atomic.Bool
+	exportJob.Register(target, func(ctx context.Context, _ exportPayload) error {
+		close(started)
+		<-release
+		finished.Store(ctx.Err() == nil)
+		return nil
+	})
+	job := createJob(1, "export", 1, model.JSONMap{})
+	// expect
+	jobRepository.On("ClaimDue", mock.Anything, []string{"export"}, jobWorkerPoolConfig.Workers, mock.Anything).Return(model.Jobs{job}, nil).Once()
+	jobRepository.On("ClaimDue", mock.Anything, []string{"export"}, jobWorkerPoolConfig.Workers-1, mock.Anything).Return(model.Jobs{}, nil).Maybe()
+	jobRepository.On("Complete", mock.Anything, job).Return(nil).Once()
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	// when
+	go func() {
+		target.Run(ctx)
+		close(stopped)
+	}()
+	<-started
+	cancel()
+	close(release)
+	<-stopped
+	// then
+	assert.True(t, finished.Load())
+}
+
+func TestQueuedMailSender_EnqueuesSealedMailJob(t *testing.T) {
+	// given
+	secret := []byte("job-test-secret-key-0123456789abcdef")
+	target, jobRepository := createJobWorkerPool(t)
+	mailSender := mockMail.NewMockSender()
+	service.RegisterMailJob(target, mailSender, secret)
+	sender := service.NewQueuedMailSender(target, secret)
+	message := mail.Message{To: "test1@example.com", Subject: "Your login link", Body: "https://example.com/login?token=secret-token"}
+	// The job as stored, and then claimed.
+	claimed := &model.Job{}
+	// expect
+	jobRepository.On("Create", mock.Anything, mock.MatchedBy(func(job *model.Job) bool {
+		return job.Type == service.MailJob.Type
+	})).Run(func(args mock.Arguments) {
+		*claimed = *args.Get(1).(*model.Job)
+		claimed.ID, claimed.Status, claimed.Attempts = 1, model.JobStatusRunning, 1
+	}).Return(nil).Once()
+	jobRepository.On("ClaimDue", mock.Anything, []string{service.MailJob.Type}, jobWorkerPoolConfig.Workers, mock.Anything).Return(model.Jobs{claimed}, nil).Once()
+	jobRepository.On("Complete", mock.Anything, claimed).Return(nil).Once()
+	mailSender.On("Send", mock.Anything, message).Return(nil).Once()
+	// when
+	sendErr := sender.Send(context.Background(), message)
+	storedPayload := fmt.Sprint(claimed.Payload)
+	_, runErr := target.RunDue(context.Background())
+	// then
+	require.NoError(t, sendErr)
+	require.NoError(t, runErr)
+	assert.NotContains(t, storedPayload, "secret-token")
+	assert.NotContains(t, storedPayload, message.To)
+	mailSender.AssertExpectations(t)
+}
+
+func TestMailJob_FailsMessageSealedWithAnotherSecret(t *testing.T) {
+	// given
+	target, jobRepository := createJobWorkerPool(t)
+	mailSender := mockMail.NewMockSender()
+	service.RegisterMailJob(target, mailSender, []byte("job-test-secret-key-0123456789abcdef"))
+	sender := service.NewQueuedMailSender(target, []byte("another-secret-key-0123456789abcdef"))
+	claimed := &model.Job{}
+	// expect
+	jobRepository.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*claimed = *args.Get(1).(*model.Job)
+		claimed.ID, claimed.Status, claimed.Attempts = 1, model.JobStatusRunning, 1
+	}).Return(nil).Once()
+	jobRepository.On("ClaimDue", mock.Anything, []string{service.MailJob.Type}, jobWorkerPoolConfig.Workers, mock.Anything).Return(model.Jobs{claimed}, nil).Once()
+	jobRepository.On("SaveFailure", mock.Anything, claimed).Return(nil).Once()
+	// when
+	sendErr := sender.Send(context.Background(), mail.Message{To: "test1@example.com", Subject: "Welcome", Body: "Hello"})
+	_, runErr := target.RunDue(context.Background())
+	// then
+	require.NoError(t, sendErr)
+	require.NoError(t, runErr)
+	assert.Equal(t, "invalid message: sealed data cannot be opened", claimed.LastError)
+	mailSender.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+}
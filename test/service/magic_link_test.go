@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	apiError "github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/mail"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	mockMail "github.com/Verano-20/stage-zero/test/mocks/mail"
+	"github.com/Verano-20/stage-zero/test/mocks/repository"
+	mockService "github.com/Verano-20/stage-zero/test/mocks/service"
+	"github.com/Verano-20/stage-zero/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+const magicLinkURL = "http://localhost:3000/auth/magic-link"
+
+func createMagicLinkServiceWithMockDependencies(t *testing.T) (service.MagicLinkService, *mockService.MockUserService, *repository.MockMagicLinkRepository, *mockMail.MockSender) {
+	userService := mockService.NewMockUserService()
+	magicLinkRepository := repository.NewMockMagicLinkRepository()
+	mailSender := mockMail.NewMockSender()
+	defer userService.AssertExpectations(t)
+	defer magicLinkRepository.AssertExpectations(t)
+	defer mailSender.AssertExpectations(t)
+	target := service.NewMagicLinkService(userService, magicLinkRepository, mailSender, magicLinkURL, 15*time.Minute)
+	return target, userService, magicLinkRepository, mailSender
+}
+
+/*
+ * Send Magic Link Tests
+ */
+
+func TestSendMagicLink_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userService, magicLinkRepository, mailSender := createMagicLinkServiceWithMockDependencies(t)
+	user := &model.User{ID: 1, Email: testutils.UserForm1.Email}
+	var storedHash string
+	// expect
+	userService.On("GetUserByEmail", ctx, user.Email).Return(user, nil).Once()
+	magicLinkRepository.On("Create", ctx, mock.MatchedBy(func(token *model.MagicLinkToken) bool {
+		storedHash = token.TokenHash
+		return token.UserID == user.ID && token.ExpiresAt.After(time.Now())
+	})).Return(&model.MagicLinkToken{ID: 1, UserID: user.ID}, nil).Once()
+	mailSender.On("Send", ctx, mock.MatchedBy(func(message mail.Message) bool {
+		if message.To != user.Email || !strings.Contains(message.Body, magicLinkURL+"?token=") {
+			return false
+		}
+		token := strings.TrimSpace(message.Body[strings.Index(message.Body, "?token=")+len("?token="):])
+		return utils.HashToken(token) == storedHash
+	})).Return(nil).Once()
+	// when
+	err := target.SendMagicLink(ctx, model.MagicLinkForm{Email: user.Email})
+	// then
+	assert.NoError(t, err)
+}
+
+func TestSendMagicLink_UnknownEmail(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userService, magicLinkRepository, mailSender := createMagicLinkServiceWithMockDependencies(t)
+	// expect
+	userService.On("GetUserByEmail", ctx, testutils.UserForm2.Email).Return(nil, gorm.ErrRecordNotFound).Once()
+	// when
+	err := target.SendMagicLink(ctx, model.MagicLinkForm{Email: testutils.UserForm2.Email})
+	// then
+	assert.NoError(t, err)
+	// and
+	magicLinkRepository.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mailSender.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+}
+
+func TestSendMagicLink_MailError(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userService, magicLinkRepository, mailSender := createMagicLinkServiceWithMockDependencies(t)
+	user := &model.User{ID: 1, Email: testutils.UserForm1.Email}
+	expectedError := errors.New("mail error")
+	// expect
+	userService.On("GetUserByEmail", ctx, user.Email).Return(user, nil).Once()
+	magicLinkRepository.On("Create", ctx, mock.Anything).Return(&model.MagicLinkToken{ID: 1, UserID: user.ID}, nil).Once()
+	mailSender.On("Send", ctx, mock.Anything).Return(expectedError).Once()
+	// when
+	err := target.SendMagicLink(ctx, model.MagicLinkForm{Email: user.Email})
+	// then
+	assert.Equal(t, expectedError, err)
+}
+
+/*
+ * Consume Magic Link Tests
+ */
+
+func TestConsumeMagicLink_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userService, magicLinkRepository, _ := createMagicLinkServiceWithMockDependencies(t)
+	user := &model.User{ID: 1, Email: testutils.UserForm1.Email}
+	token := "magic-link-token"
+	// expect
+	magicLinkRepository.On("Consume", ctx, utils.HashToken(token)).Return(&model.MagicLinkToken{ID: 1, UserID: user.ID}, nil).Once()
+	userService.On("GetUserByID", ctx, user.ID).Return(user, nil).Once()
+	// when
+	result, err := target.ConsumeMagicLink(ctx, token)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, user, result)
+}
+
+func TestConsumeMagicLink_InvalidToken(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userService, magicLinkRepository, _ := createMagicLinkServiceWithMockDependencies(t)
+	token := "used-or-expired-token"
+	// expect
+	magicLinkRepository.On("Consume", ctx, utils.HashToken(token)).Return(nil, gorm.ErrRecordNotFound).Once()
+	// when
+	result, err := target.ConsumeMagicLink(ctx, token)
+	// then
+	apiErr, ok := err.(*apiError.ApiError)
+	assert.True(t, ok)
+	assert.Equal(t, apiError.ErrorTypeMagicLink, apiErr.Type)
+	assert.Nil(t, result)
+	// and
+	userService.AssertNotCalled(t, "GetUserByID", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	apiError "github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/test/mocks/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func createMetadataSchemaServiceWithMockDependencies(t *testing.T) (service.MetadataSchemaService, *repository.MockMetadataSchemaRepository, *repository.MockMembershipRepository) {
+	metadataSchemaRepository := repository.NewMockMetadataSchemaRepository()
+	membershipRepository := repository.NewMockMembershipRepository()
+	target := service.NewMetadataSchemaService(metadataSchemaRepository, membershipRepository)
+	return target, metadataSchemaRepository, membershipRepository
+}
+
+var ticketSchema = model.JSONMap{
+	"type":     "object",
+	"required": []any{"priority"},
+	"properties": map[string]any{
+		"priority": map[string]any{"enum": []any{"low", "high"}},
+		"estimate": map[string]any{"type": "integer", "minimum": 1},
+	},
+}
+
+/*
+ * Put Schema Tests
+ */
+
+func TestPutSchema_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, metadataSchemaRepository, membershipRepository := createMetadataSchemaServiceWithMockDependencies(t)
+	schemaForm := model.MetadataSchemaForm{Kind: "ticket", Schema: ticketSchema}
+	expectedSchema := &model.MetadataSchema{OrganizationID: organizationID, Kind: "ticket", Schema: ticketSchema, CreatedByID: &organizationUser.ID}
+	// expect
+	membershipRepository.On("Get", ctx, organizationID, organizationUser.ID).Return(createMembership(organizationUser.ID, model.OrganizationRoleAdmin), nil).Once()
+	metadataSchemaRepository.On("Upsert", ctx, expectedSchema).Return(expectedSchema, nil).Once()
+	// when
+	result, err := target.PutSchema(ctx, &organizationUser, organizationID, schemaForm)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, expectedSchema, result)
+	metadataSchemaRepository.AssertExpectations(t)
+	membershipRepository.AssertExpectations(t)
+}
+
+func TestPutSchema_Failure(t *testing.T) {
+	tests := []struct {
+		testName     string
+		role         string
+		schema       model.JSONMap
+		expectedType string
+	}{
+		{
+			testName:     "Member cannot manage schemas",
+			role:         model.OrganizationRoleMember,
+			schema:       ticketSchema,
+			expectedType: apiError.ErrorTypeOrganizationAccess,
+		},
+		{
+			testName:     "Schema does not compile",
+			role:         model.OrganizationRoleOwner,
+			schema:       model.JSONMap{"type": "not-a-type"},
+			expectedType: apiError.ErrorTypeSchemaInvalid,
+		},
+		{
+			testName:     "Schema references an external document",
+			role:         model.OrganizationRoleOwner,
+			schema:       model.JSONMap{"$ref": "file:///etc/passwd"},
+			expectedType: apiError.ErrorTypeSchemaInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := context.Background()
+			target, metadataSchemaRepository, membershipRepository := createMetadataSchemaServiceWithMockDependencies(t)
+			// expect
+			membershipRepository.On("Get", ctx, organizationID, organizationUser.ID).Return(createMembership(organizationUser.ID, test.role), nil).Once()
+			// when
+			result, err := target.PutSchema(ctx, &organizationUser, organizationID, model.MetadataSchemaForm{Schema: test.schema})
+			// then
+			assert.Nil(t, result)
+			assertApiErrorType(t, err, test.expectedType)
+			metadataSchemaRepository.AssertExpectations(t)
+			membershipRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteSchema_NotFound(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, metadataSchemaRepository, membershipRepository := createMetadataSchemaServiceWithMockDependencies(t)
+	// expect
+	membershipRepository.On("Get", ctx, organizationID, organizationUser.ID).Return(createMembership(organizationUser.ID, model.OrganizationRoleOwner), nil).Once()
+	metadataSchemaRepository.On("Delete", ctx, organizationID, uint(9)).Return(gorm.ErrRecordNotFound).Once()
+	// when
+	err := target.DeleteSchema(ctx, &organizationUser, organizationID, 9)
+	// then
+	assertApiErrorType(t, err, apiError.ErrorTypeSchemaNotFound)
+	metadataSchemaRepository.AssertExpectations(t)
+	membershipRepository.AssertExpectations(t)
+}
+
+/*
+ * Validate Metadata Tests
+ */
+
+func TestValidateMetadata_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, metadataSchemaRepository, _ := createMetadataSchemaServiceWithMockDependencies(t)
+	schemas := model.MetadataSchemas{{OrganizationID: organizationID, Kind: "ticket", Schema: ticketSchema}}
+	// expect
+	metadataSchemaRepository.On("ListForKind", ctx, organizationID, "ticket").Return(schemas, nil).Once()
+	// when
+	err := target.ValidateMetadata(ctx, organizationID, "ticket", model.JSONMap{"priority": "high", "estimate": 3})
+	// then
+	assert.NoError(t, err)
+	metadataSchemaRepository.AssertExpectations(t)
+}
+
+func TestValidateMetadata_NoSchemas(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, metadataSchemaRepository, _ := createMetadataSchemaServiceWithMockDependencies(t)
+	// expect
+	metadataSchemaRepository.On("ListForKind", ctx, organizationID, "").Return(model.MetadataSchemas{}, nil).Once()
+	// when
+	err := target.ValidateMetadata(ctx, organizationID, "", nil)
+	// then
+	assert.NoError(t, err)
+	metadataSchemaRepository.AssertExpectations(t)
+}
+
+func TestValidateMetadata_ReportsViolationsOfEverySchema(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, metadataSchemaRepository, _ := createMetadataSchemaServiceWithMockDependencies(t)
+	organizationSchema := model.JSONMap{
+		"type":       "object",
+		"properties": map[string]any{"team": map[string]any{"type": "string"}},
+	}
+	schemas := model.MetadataSchemas{
+		{OrganizationID: organizationID, Kind: "", Schema: organizationSchema},
+		{OrganizationID: organizationID, Kind: "ticket", Schema: ticketSchema},
+	}
+	// expect
+	metadataSchemaRepository.On("ListForKind", ctx, organizationID, "ticket").Return(schemas, nil).Once()
+	// when
+	err := target.ValidateMetadata(ctx, organizationID, "ticket", model.JSONMap{"team": 42, "priority": "whenever", "estimate": 0})
+	// then
+	assertApiErrorType(t, err, apiError.ErrorTypeMetadataInvalid)
+	var metadataErr *apiError.ApiError
+	require.ErrorAs(t, err, &metadataErr)
+	assert.Contains(t, metadataErr.Details, "metadata.team")
+	assert.Contains(t, metadataErr.Details, "metadata.priority")
+	assert.Contains(t, metadataErr.Details, "metadata.estimate")
+	metadataSchemaRepository.AssertExpectations(t)
+}
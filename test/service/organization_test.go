@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	apiError "github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/test/mocks/repository"
+	mockService "github.com/Verano-20/stage-zero/test/mocks/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+const organizationID uint = 7
+
+var (
+	organizationUser   = model.User{ID: 1, Email: "test1@example.com"}
+	organizationMember = model.User{ID: 2, Email: "test2@example.com"}
+)
+
+func createOrganizationServiceWithMockDependencies(t *testing.T) (service.OrganizationService, *repository.MockOrganizationRepository, *repository.MockMembershipRepository, *mockService.MockUserService) {
+	txManager := repository.NewMockTxManager()
+	txManager.On("WithinTransaction", mock.Anything).Return(nil).Maybe()
+	organizationRepository := repository.NewMockOrganizationRepository()
+	membershipRepository := repository.NewMockMembershipRepository()
+	userService := mockService.NewMockUserService()
+	defer organizationRepository.AssertExpectations(t)
+	defer membershipRepository.AssertExpectations(t)
+	defer userService.AssertExpectations(t)
+	target := service.NewOrganizationService(txManager, organizationRepository, membershipRepository, userService)
+	return target, organizationRepository, membershipRepository, userService
+}
+
+func createMembership(userID uint, role string) *model.Membership {
+	return &model.Membership{OrganizationID: organizationID, UserID: userID, Role: role}
+}
+
+func assertApiErrorType(t *testing.T, err error, expectedType string) {
+	var apiErr *apiError.ApiError
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, expectedType, apiErr.Type)
+	}
+}
+
+/*
+ * Create Organization Tests
+ */
+
+func TestCreateOrganization_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, organizationRepository, _, _ := createOrganizationServiceWithMockDependencies(t)
+	organization := &model.Organization{ID: organizationID, Name: "Acme"}
+	// expect
+	organizationRepository.On("Create", ctx, &model.Organization{Name: "Acme"}, organizationUser.ID).Return(organization, nil).Once()
+	// when
+	result, err := target.CreateOrganization(ctx, &organizationUser, model.OrganizationForm{Name: "Acme"})
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, organization, result)
+	organizationRepository.AssertExpectations(t)
+}
+
+/*
+ * Add Member Tests
+ */
+
+func TestAddMember_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, _, membershipRepository, userService := createOrganizationServiceWithMockDependencies(t)
+	membershipForm := model.MembershipForm{Email: organizationMember.Email, Role: model.OrganizationRoleMember}
+	// expect
+	membershipRepository.On("Get", ctx, organizationID, organizationUser.ID).Return(createMembership(organizationUser.ID, model.OrganizationRoleAdmin), nil).Once()
+	userService.On("GetUserByEmail", ctx, organizationMember.Email).Return(&organizationMember, nil).Once()
+	membershipRepository.On("Get", ctx, organizationID, organizationMember.ID).Return(nil, gorm.ErrRecordNotFound).Once()
+	membershipRepository.On("Create", ctx, createMembership(organizationMember.ID, model.OrganizationRoleMember)).Return(createMembership(organizationMember.ID, model.OrganizationRoleMember), nil).Once()
+	// when
+	membership, err := target.AddMember(ctx, &organizationUser, organizationID, membershipForm)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, organizationMember.ID, membership.UserID)
+	assert.Equal(t, organizationMember.Email, membership.ToMemberDTO().Email)
+	membershipRepository.AssertExpectations(t)
+	userService.AssertExpectations(t)
+}
+
+func TestAddMember_Failure(t *testing.T) {
+	tests := []struct {
+		testName     string
+		actingRole   string
+		role         string
+		expect       func(ctx any, membershipRepository *repository.MockMembershipRepository, userService *mockService.MockUserService)
+		expectedType string
+	}{
+		{
+			testName:     "Not A Member",
+			role:         model.OrganizationRoleMember,
+			expectedType: apiError.ErrorTypeMembershipNotFound,
+		},
+		{
+			testName:     "Member Cannot Add Members",
+			actingRole:   model.OrganizationRoleMember,
+			role:         model.OrganizationRoleMember,
+			expectedType: apiError.ErrorTypeOrganizationAccess,
+		},
+		{
+			testName:     "Admin Cannot Add Owners",
+			actingRole:   model.OrganizationRoleAdmin,
+			role:         model.OrganizationRoleOwner,
+			expectedType: apiError.ErrorTypeOrganizationAccess,
+		},
+		{
+			testName:   "User Not Found",
+			actingRole: model.OrganizationRoleOwner,
+			role:       model.OrganizationRoleOwner,
+			expect: func(ctx any, membershipRepository *repository.MockMembershipRepository, userService *mockService.MockUserService) {
+				userService.On("GetUserByEmail", ctx, organizationMember.Email).Return(nil, gorm.ErrRecordNotFound).Once()
+			},
+			expectedType: apiError.ErrorTypeUserNotFound,
+		},
+		{
+			testName:   "Already A Member",
+			actingRole: model.OrganizationRoleOwner,
+			role:       model.OrganizationRoleMember,
+			expect: func(ctx any, membershipRepository *repository.MockMembershipRepository, userService *mockService.MockUserService) {
+				userService.On("GetUserByEmail", ctx, organizationMember.Email).Return(&organizationMember, nil).Once()
+				membershipRepository.On("Get", ctx, organizationID, organizationMember.ID).Return(createMembership(organizationMember.ID, model.OrganizationRoleMember), nil).Once()
+			},
+			expectedType: apiError.ErrorTypeMembershipExists,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := context.Background()
+			target, _, membershipRepository, userService := createOrganizationServiceWithMockDependencies(t)
+			// expect
+			if test.actingRole == "" {
+				membershipRepository.On("Get", ctx, organizationID, organizationUser.ID).Return(nil, gorm.ErrRecordNotFound).Once()
+			} else {
+				membershipRepository.On("Get", ctx, organizationID, organizationUser.ID).Return(createMembership(organizationUser.ID, test.actingRole), nil).Once()
+			}
+			if test.expect != nil {
+				test.expect(ctx, membershipRepository, userService)
+			}
+			// when
+			membership, err := target.AddMember(ctx, &organizationUser, organizationID, model.MembershipForm{Email: organizationMember.Email, Role: test.role})
+			// then
+			assert.Nil(t, membership)
+			assertApiErrorType(t, err, test.expectedType)
+			membershipRepository.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			membershipRepository.AssertExpectations(t)
+			userService.AssertExpectations(t)
+		})
+	}
+}
+
+/*
+ * Remove Member Tests
+ */
+
+func TestRemoveMember_Success(t *testing.T) {
+	tests := []struct {
+		testName   string
+		actingRole string
+		memberID   uint
+		memberRole string
+		owners     int64
+	}{
+		{testName: "Member Leaves", actingRole: model.OrganizationRoleMember, memberID: organizationUser.ID},
+		{testName: "Owner Leaves With Another Owner", actingRole: model.OrganizationRoleOwner, memberID: organizationUser.ID, owners: 2},
+		{testName: "Admin Removes Member", actingRole: model.OrganizationRoleAdmin, memberID: organizationMember.ID, memberRole: model.OrganizationRoleMember},
+		{testName: "Owner Removes Owner", actingRole: model.OrganizationRoleOwner, memberID: organizationMember.ID, memberRole: model.OrganizationRoleOwner, owners: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := context.Background()
+			target, _, membershipRepository, _ := createOrganizationServiceWithMockDependencies(t)
+			actingMembership := createMembership(organizationUser.ID, test.actingRole)
+			removedMembership := actingMembership
+			// expect
+			membershipRepository.On("Get", ctx, organizationID, organizationUser.ID).Return(actingMembership, nil).Once()
+			if test.memberID != organizationUser.ID {
+				removedMembership = createMembership(test.memberID, test.memberRole)
+				membershipRepository.On("Get", ctx, organizationID, test.memberID).Return(removedMembership, nil).Once()
+			}
+			if test.owners != 0 {
+				membershipRepository.On("LockOwners", ctx, organizationID).Return(test.owners, nil).Once()
+			}
+			membershipRepository.On("Delete", ctx, removedMembership).Return(nil).Once()
+			// when
+			err := target.RemoveMember(ctx, &organizationUser, organizationID, test.memberID)
+			// then
+			assert.NoError(t, err)
+			membershipRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRemoveMember_Failure(t *testing.T) {
+	tests := []struct {
+		testName     string
+		actingRole   string
+		memberID     uint
+		memberRole   string
+		owners       int64
+		expectedType string
+	}{
+		{
+			testName:     "Last Owner Leaves",
+			actingRole:   model.OrganizationRoleOwner,
+			memberID:     organizationUser.ID,
+			owners:       1,
+			expectedType: apiError.ErrorTypeLastOwner,
+		},
+		{
+			testName:     "Member Removes Member",
+			actingRole:   model.OrganizationRoleMember,
+			memberID:     organizationMember.ID,
+			memberRole:   model.OrganizationRoleMember,
+			expectedType: apiError.ErrorTypeOrganizationAccess,
+		},
+		{
+			testName:     "Admin Removes Owner",
+			actingRole:   model.OrganizationRoleAdmin,
+			memberID:     organizationMember.ID,
+			memberRole:   model.OrganizationRoleOwner,
+			expectedType: apiError.ErrorTypeOrganizationAccess,
+		},
+		{
+			testName:     "Member Not Found",
+			actingRole:   model.OrganizationRoleOwner,
+			memberID:     organizationMember.ID,
+			expectedType: apiError.ErrorTypeMembershipNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := context.Background()
+			target, _, membershipRepository, _ := createOrganizationServiceWithMockDependencies(t)
+			// expect
+			membershipRepository.On("Get", ctx, organizationID, organizationUser.ID).Return(createMembership(organizationUser.ID, test.actingRole), nil).Once()
+			if test.memberID != organizationUser.ID {
+				if test.memberRole == "" {
+					membershipRepository.On("Get", ctx, organizationID, test.memberID).Return(nil, gorm.ErrRecordNotFound).Once()
+				} else {
+					membershipRepository.On("Get", ctx, organizationID, test.memberID).Return(createMembership(test.memberID, test.memberRole), nil).Once()
+				}
+			}
+			if test.owners != 0 {
+				membershipRepository.On("LockOwners", ctx, organizationID).Return(test.owners, nil).Once()
+			}
+			// when
+			err := target.RemoveMember(ctx, &organizationUser, organizationID, test.memberID)
+			// then
+			assertApiErrorType(t, err, test.expectedType)
+			membershipRepository.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+			membershipRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListMembers_NotAMember(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, _, membershipRepository, _ := createOrganizationServiceWithMockDependencies(t)
+	// expect
+	membershipRepository.On("Get", ctx, organizationID, organizationUser.ID).Return(nil, gorm.ErrRecordNotFound).Once()
+	// when
+	members, err := target.ListMembers(ctx, &organizationUser, organizationID)
+	// then
+	assert.Nil(t, members)
+	assertApiErrorType(t, err, apiError.ErrorTypeMembershipNotFound)
+	membershipRepository.AssertNotCalled(t, "ListByOrganizationID", mock.Anything, mock.Anything)
+}
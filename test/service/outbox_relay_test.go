@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	mockEvents "github.com/Verano-20/stage-zero/test/mocks/events"
+	"github.com/Verano-20/stage-zero/test/mocks/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var outboxRelayConfig = service.OutboxRelayConfig{
+	PollInterval: time.Second,
+	BatchSize:    10,
+	BaseBackoff:  time.Second,
+	MaxBackoff:   4 * time.Second,
+}
+
+func createOutboxEvent(id uint64, aggregateID string, attempts int) *model.OutboxEvent {
+	return &model.OutboxEvent{
+		ID:            id,
+		AggregateType: model.AggregateTypeSimple,
+		AggregateID:   aggregateID,
+		EventType:     model.EventSimpleUpdated,
+		Payload:       model.JSONMap{"id": aggregateID},
+		Attempts:      attempts,
+	}
+}
+
+func matchDomainEvent(id uint64) any {
+	return mock.MatchedBy(func(event *model.DomainEvent) bool { return event.ID == id })
+}
+
+func TestRelayPending_PublishesEventsInOrder(t *testing.T) {
+	// given
+	outboxRepository := repository.NewMockOutboxRepository()
+	publisher := mockEvents.NewMockEventPublisher()
+	target := service.NewOutboxRelay(outboxRepository, publisher, outboxRelayConfig)
+	first, second := createOutboxEvent(1, "3", 0), createOutboxEvent(2, "3", 0)
+	var published []uint64
+	// expect
+	outboxRepository.On("Relay", mock.Anything, outboxRelayConfig.BatchSize).Return(model.OutboxEvents{first, second}, nil).Once()
+	publisher.On("Publish", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		published = append(published, args.Get(1).(*model.DomainEvent).ID)
+	}).Return(nil).Twice()
+	// when
+	count, err := target.RelayPending(context.Background())
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []uint64{1, 2}, published)
+	for _, event := range []*model.OutboxEvent{first, second} {
+		assert.NotNil(t, event.PublishedAt)
+		assert.Equal(t, 1, event.Attempts)
+	}
+	outboxRepository.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+}
+
+func TestRelayPending_FailureHoldsBackLaterEventsOfAggregate(t *testing.T) {
+	// given
+	outboxRepository := repository.NewMockOutboxRepository()
+	publisher := mockEvents.NewMockEventPublisher()
+	target := service.NewOutboxRelay(outboxRepository, publisher, outboxRelayConfig)
+	failing, heldBack, other := createOutboxEvent(1, "3", 0), createOutboxEvent(2, "3", 0), createOutboxEvent(3, "4", 0)
+	// expect
+	outboxRepository.On("Relay", mock.Anything, outboxRelayConfig.BatchSize).Return(model.OutboxEvents{failing, heldBack, other}, nil).Once()
+	publisher.On("Publish", mock.Anything, matchDomainEvent(1)).Return(errors.New("broker unavailable")).Once()
+	publisher.On("Publish", mock.Anything, matchDomainEvent(3)).Return(nil).Once()
+	// when
+	before := time.Now()
+	count, err := target.RelayPending(context.Background())
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Nil(t, failing.PublishedAt)
+	assert.Equal(t, 1, failing.Attempts)
+	assert.Equal(t, "broker unavailable", failing.LastError)
+	assert.WithinDuration(t, before.Add(time.Second), failing.NextAttemptAt, time.Second)
+	assert.Nil(t, heldBack.PublishedAt)
+	assert.Zero(t, heldBack.Attempts)
+	assert.NotNil(t, other.PublishedAt)
+	outboxRepository.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+}
+
+func TestRelayPending_FailureBacksOff(t *testing.T) {
+	tests := []struct {
+		testName         string
+		previousAttempts int
+		expectedBackoff  time.Duration
+	}{
+		{testName: "First failure waits the base backoff", previousAttempts: 0, expectedBackoff: time.Second},
+		{testName: "Backoff doubles with each failure", previousAttempts: 1, expectedBackoff: 2 * time.Second},
+		{testName: "Backoff is capped", previousAttempts: 5, expectedBackoff: 4 * time.Second},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			outboxRepository := repository.NewMockOutboxRepository()
+			publisher := mockEvents.NewMockEventPublisher()
+			target := service.NewOutboxRelay(outboxRepository, publisher, outboxRelayConfig)
+			event := createOutboxEvent(1, "3", test.previousAttempts)
+			// expect
+			outboxRepository.On("Relay", mock.Anything, outboxRelayConfig.BatchSize).Return(model.OutboxEvents{event}, nil).Once()
+			publisher.On("Publish", mock.Anything, mock.Anything).Return(errors.New("broker unavailable")).Once()
+			// when
+			before := time.Now()
+			_, err := target.RelayPending(context.Background())
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, test.previousAttempts+1, event.Attempts)
+			assert.WithinDuration(t, before.Add(test.expectedBackoff), event.NextAttemptAt, 500*time.Millisecond)
+			outboxRepository.AssertExpectations(t)
+			publisher.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRelayPending_RepositoryError(t *testing.T) {
+	// given
+	outboxRepository := repository.NewMockOutboxRepository()
+	publisher := mockEvents.NewMockEventPublisher()
+	target := service.NewOutboxRelay(outboxRepository, publisher, outboxRelayConfig)
+	expectedError := errors.New("database error")
+	// expect
+	outboxRepository.On("Relay", mock.Anything, outboxRelayConfig.BatchSize).Return(nil, expectedError).Once()
+	// when
+	count, err := target.RelayPending(context.Background())
+	// then
+	assert.Equal(t, expectedError, err)
+	assert.Zero(t, count)
+	outboxRepository.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/cron"
+	apiErr "github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/test/mocks/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var schedulerConfig = service.SchedulerConfig{
+	PollInterval: 10 * time.Millisecond,
+	Timeout:      time.Second,
+	Instance:     "api-1",
+}
+
+func createScheduler(t *testing.T) (service.Scheduler, *repository.MockScheduledTaskRepository, *repository.MockSchedulerLock) {
+	scheduledTaskRepository := repository.NewMockScheduledTaskRepository()
+	schedulerLock := repository.NewMockSchedulerLock()
+	t.Cleanup(func() {
+		scheduledTaskRepository.AssertExpectations(t)
+		schedulerLock.AssertExpectations(t)
+	})
+	return service.NewScheduler(scheduledTaskRepository, schedulerLock, schedulerConfig), scheduledTaskRepository, schedulerLock
+}
+
+func TestSchedulerRunDue_DoesNothingWhenNotLeader(t *testing.T) {
+	// given
+	target, _, schedulerLock := createScheduler(t)
+	target.Register("report", cron.MustParse("@hourly"), func(context.Context) error { panic("must not run") })
+	// expect
+	schedulerLock.On("Acquire", mock.Anything).Return(false, nil).Once()
+	// when
+	ran, err := target.RunDue(context.Background())
+	// then
+	require.NoError(t, err)
+	assert.Zero(t, ran)
+	assert.False(t, target.IsLeader())
+}
+
+func TestSchedulerRunDue_SyncsTasksOnceElected(t *testing.T) {
+	// given
+	target, scheduledTaskRepository, schedulerLock := createScheduler(t)
+	target.Register("report", cron.MustParse("0 * * * *"), func(context.Context) error { return nil })
+	start := time.Now()
+	// expect
+	schedulerLock.On("Acquire", mock.Anything).Return(true, nil).Twice()
+	scheduledTaskRepository.On("Sync", mock.Anything, mock.MatchedBy(func(tasks model.ScheduledTasks) bool {
+		return len(tasks) == 1 && tasks[0].Name == "report" && tasks[0].Schedule == "0 * * * *" &&
+			tasks[0].NextRunAt.Equal(cron.MustParse("0 * * * *").Next(start))
+	})).Return(nil).Once()
+	scheduledTaskRepository.On("ListAll", mock.Anything).Return(model.ScheduledTasks{}, nil).Twice()
+	// when
+	_, firstErr := target.RunDue(context.Background())
+	_, secondErr := target.RunDue(context.Background())
+	// then
+	require.NoError(t, firstErr)
+	require.NoError(t, secondErr)
+	assert.True(t, target.IsLeader())
+}
+
+func TestSchedulerRunDue_RunsDueTasks(t *testing.T) {
+	// given
+	target, scheduledTaskRepository, schedulerLock := createScheduler(t)
+	schedule := cron.MustParse("*/5 * * * *")
+	ran := 0
+	target.Register("report", schedule, func(context.Context) error {
+		ran++
+		return nil
+	})
+	target.Register("later", schedule, func(context.Context) error { panic("must not run") })
+	dueAt := time.Now().Add(-time.Minute).Truncate(time.Minute)
+	tasks := model.ScheduledTasks{
+		{Name: "later", Schedule: "*/5 * * * *", NextRunAt: time.Now().Add(time.Hour)},
+		{Name: "removed", Schedule: "@daily", NextRunAt: dueAt},
+		{Name: "report", Schedule: "*/5 * * * *", NextRunAt: dueAt},
+	}
+	// expect
+	schedulerLock.On("Acquire", mock.Anything).Return(true, nil).Once()
+	scheduledTaskRepository.On("Sync", mock.Anything, mock.Anything).Return(nil).Once()
+	scheduledTaskRepository.On("ListAll", mock.Anything).Return(tasks, nil).Once()
+	scheduledTaskRepository.On("Start", mock.Anything, mock.MatchedBy(func(run *model.ScheduledTaskRun) bool {
+		return run.TaskName == "report" && run.ScheduledFor.Equal(dueAt) && run.Instance == "api-1"
+	}), mock.MatchedBy(func(nextRunAt time.Time) bool {
+		return nextRunAt.After(time.Now()) && nextRunAt.Minute()%5 == 0
+	})).Return(true, nil).Once()
+	scheduledTaskRepository.On("Finish", mock.Anything, mock.MatchedBy(func(run *model.ScheduledTaskRun) bool {
+		return run.TaskName == "report" && run.Outcome == model.ScheduledTaskOutcomeSucceeded && run.FinishedAt != nil
+	})).Return(nil).Once()
+	// when
+	count, err := target.RunDue(context.Background())
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 1, ran)
+}
+
+func TestSchedulerRunDue_SkipsRunsAlreadyStarted(t *testing.T) {
+	// given
+	target, scheduledTaskRepository, schedulerLock := createScheduler(t)
+	target.Register("report", cron.MustParse("@hourly"), func(context.Context) error { panic("must not run") })
+	// expect
+	schedulerLock.On("Acquire", mock.Anything).Return(true, nil).Once()
+	scheduledTaskRepository.On("Sync", mock.Anything, mock.Anything).Return(nil).Once()
+	scheduledTaskRepository.On("ListAll", mock.Anything).Return(model.ScheduledTasks{{Name: "report", NextRunAt: time.Now().Add(-time.Minute)}}, nil).Once()
+	scheduledTaskRepository.On("Start", mock.Anything, mock.Anything, mock.Anything).Return(false, nil).Once()
+	// when
+	count, err := target.RunDue(context.Background())
+	// then
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestSchedulerRunDue_RecordsFailedTasks(t *testing.T) {
+	tests := []struct {
+		testName      string
+		task          service.ScheduledTaskFunc
+		expectedError string
+	}{
+		{
+			testName:      "Error",
+			task:          func(context.Context) error { return errors.New("database error") },
+			expectedError: "database error",
+		},
+		{
+			testName:      "Panic",
+			task:          func(context.Context) error { panic("nil map") },
+			expectedError: "task panicked: nil map",
+		},
+		{
+			testName: "Timeout",
+			task: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			expectedError: context.DeadlineExceeded.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			// given
+			config := schedulerConfig
+			config.Timeout = 10 * time.Millisecond
+			scheduledTaskRepository := repository.NewMockScheduledTaskRepository()
+			schedulerLock := repository.NewMockSchedulerLock()
+			target := service.NewScheduler(scheduledTaskRepository, schedulerLock, config)
+			target.Register("report", cron.MustParse("@hourly"), tt.task)
+			var finished *model.ScheduledTaskRun
+			// expect
+			schedulerLock.On("Acquire", mock.Anything).Return(true, nil).Once()
+			scheduledTaskRepository.On("Sync", mock.Anything, mock.Anything).Return(nil).Once()
+			scheduledTaskRepository.On("ListAll", mock.Anything).Return(model.ScheduledTasks{{Name: "report", NextRunAt: time.Now().Add(-time.Minute)}}, nil).Once()
+			scheduledTaskRepository.On("Start", mock.Anything, mock.Anything, mock.Anything).Return(true, nil).Once()
+			scheduledTaskRepository.On("Finish", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				finished = args.Get(1).(*model.ScheduledTaskRun)
+			}).Return(nil).Once()
+			// when
+			count, err := target.RunDue(context.Background())
+			// then
+			require.NoError(t, err)
+			scheduledTaskRepository.AssertExpectations(t)
+			schedulerLock.AssertExpectations(t)
+			assert.Equal(t, 1, count)
+			require.NotNil(t, finished)
+			assert.Equal(t, model.ScheduledTaskOutcomeFailed, finished.Outcome)
+			assert.Equal(t, tt.expectedError, finished.Error)
+		})
+	}
+}
+
+func TestSchedulerRunDue_StepsDownWhenLockLost(t *testing.T) {
+	// given
+	target, scheduledTaskRepository, schedulerLock := createScheduler(t)
+	target.Register("report", cron.MustParse("@hourly"), func(context.Context) error { return nil })
+	expectedError := errors.New("connection reset")
+	// expect
+	schedulerLock.On("Acquire", mock.Anything).Return(true, nil).Once()
+	schedulerLock.On("Acquire", mock.Anything).Return(false, expectedError).Once()
+	scheduledTaskRepository.On("Sync", mock.Anything, mock.Anything).Return(nil).Once()
+	scheduledTaskRepository.On("ListAll", mock.Anything).Return(model.ScheduledTasks{}, nil).Once()
+	// when
+	_, firstErr := target.RunDue(context.Background())
+	wasLeader := target.IsLeader()
+	_, secondErr := target.RunDue(context.Background())
+	// then
+	require.NoError(t, firstErr)
+	assert.True(t, wasLeader)
+	assert.Equal(t, expectedError, secondErr)
+	assert.False(t, target.IsLeader())
+}
+
+func TestSchedulerRun_ReleasesLockWhenStopped(t *testing.T) {
+	// given
+	target, scheduledTaskRepository, schedulerLock := createScheduler(t)
+	target.Register("report", cron.MustParse("@hourly"), func(context.Context) error { return nil })
+	elected := make(chan struct{})
+	// expect
+	schedulerLock.On("Acquire", mock.Anything).Return(true, nil)
+	scheduledTaskRepository.On("Sync", mock.Anything, mock.Anything).Return(nil).Once()
+	scheduledTaskRepository.On("ListAll", mock.Anything).Return(model.ScheduledTasks{}, nil).Run(func(mock.Arguments) {
+		select {
+		case <-elected:
+		default:
+			close(elected)
+		}
+	})
+	schedulerLock.On("Release", mock.Anything).Return(nil).Once()
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	// when
+	go func() {
+		target.Run(ctx)
+		close(stopped)
+	}()
+	<-elected
+	cancel()
+	<-stopped
+	// then
+	assert.False(t, target.IsLeader())
+}
+
+func TestSchedulerListRuns_RejectsUnknownTasks(t *testing.T) {
+	// given
+	target, _, _ := createScheduler(t)
+	// when
+	runs, err := target.ListRuns(context.Background(), "unknown", 20)
+	// then
+	var apiError *apiErr.ApiError
+	require.ErrorAs(t, err, &apiError)
+	assert.Equal(t, apiErr.ErrorTypeTaskNotFound, apiError.Type)
+	assert.Nil(t, runs)
+}
+
+func TestSchedulerRegister_PanicsOnScheduleThatNeverFires(t *testing.T) {
+	// given
+	target, _, _ := createScheduler(t)
+	// then
+	assert.Panics(t, func() {
+		target.Register("never", cron.MustParse("0 0 30 2 *"), func(context.Context) error { return nil })
+	})
+}
+
+func TestTokenCleanupTask_DeletesTokensExpiredBeforeRetention(t *testing.T) {
+	// given
+	magicLinkRepository := repository.NewMockMagicLinkRepository()
+	emailVerificationRepository := repository.NewMockEmailVerificationRepository()
+	task := service.NewTokenCleanupTask(magicLinkRepository, emailVerificationRepository, 24*time.Hour)
+	before := mock.MatchedBy(func(before time.Time) bool {
+		return before.Before(time.Now().Add(-23*time.Hour)) && before.After(time.Now().Add(-25*time.Hour))
+	})
+	// expect
+	magicLinkRepository.On("DeleteExpired", mock.Anything, before).Return(int64(3), nil).Once()
+	emailVerificationRepository.On("DeleteExpired", mock.Anything, before).Return(int64(1), nil).Once()
+	// when
+	err := task(context.Background())
+	// then
+	require.NoError(t, err)
+	magicLinkRepository.AssertExpectations(t)
+	emailVerificationRepository.AssertExpectations(t)
+}
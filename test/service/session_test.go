@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apiError "github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"github.com/Verano-20/stage-zero/test/mocks/repository"
+	mockService "github.com/Verano-20/stage-zero/test/mocks/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+var sessionUser = model.User{ID: 1, Email: "test1@example.com"}
+
+func createSessionServiceWithMockDependencies(t *testing.T) (service.SessionService, *repository.MockSessionRepository, *mockService.MockAuditLogger) {
+	sessionRepository := repository.NewMockSessionRepository()
+	auditLogger := mockService.NewMockAuditLogger()
+	defer sessionRepository.AssertExpectations(t)
+	defer auditLogger.AssertExpectations(t)
+	target := service.NewSessionService(sessionRepository, auditLogger)
+	return target, sessionRepository, auditLogger
+}
+
+func createSession(id uint, userID uint) *model.Session {
+	now := time.Now()
+	return &model.Session{ID: id, UserID: userID, CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}
+}
+
+/*
+ * Create Session Tests
+ */
+
+func TestCreateSession_Success(t *testing.T) {
+	// given
+	ctx := utils.WithRequestInfo(context.Background(), &utils.RequestInfo{Method: "POST", Path: "/auth/login", ClientIP: "192.0.2.1", UserAgent: "test-agent"})
+	target, sessionRepository, _ := createSessionServiceWithMockDependencies(t)
+	expiresAt := time.Now().Add(time.Hour)
+	// expect
+	sessionRepository.On("Create", ctx, mock.MatchedBy(func(session *model.Session) bool {
+		return session.UserID == sessionUser.ID && session.UserAgent == "test-agent" && session.ClientIP != "" && session.ExpiresAt.Equal(expiresAt)
+	})).Return(createSession(1, sessionUser.ID), nil).Once()
+	// when
+	session, err := target.CreateSession(ctx, &sessionUser, expiresAt)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), session.ID)
+	sessionRepository.AssertExpectations(t)
+}
+
+func TestCreateSession_Error(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, sessionRepository, _ := createSessionServiceWithMockDependencies(t)
+	// expect
+	sessionRepository.On("Create", ctx, mock.Anything).Return(nil, errors.New("database error")).Once()
+	// when
+	session, err := target.CreateSession(ctx, &sessionUser, time.Now().Add(time.Hour))
+	// then
+	assert.Error(t, err)
+	assert.Nil(t, session)
+	sessionRepository.AssertExpectations(t)
+}
+
+/*
+ * List Sessions Tests
+ */
+
+func TestListSessions_OmitsSessionsStartedBeforeTokensRevoked(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, sessionRepository, _ := createSessionServiceWithMockDependencies(t)
+	tokensValidAfter := time.Now().Add(-time.Minute)
+	user := &model.User{ID: sessionUser.ID, TokensValidAfter: &tokensValidAfter}
+	oldSession := createSession(1, user.ID)
+	oldSession.CreatedAt = tokensValidAfter.Add(-time.Hour)
+	newSession := createSession(2, user.ID)
+	// expect
+	sessionRepository.On("ListActiveByUserID", ctx, user.ID).Return(model.Sessions{newSession, oldSession}, nil).Once()
+	// when
+	sessions, err := target.ListSessions(ctx, user)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, model.Sessions{newSession}, sessions)
+	sessionRepository.AssertExpectations(t)
+}
+
+func TestListSessions_Error(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, sessionRepository, _ := createSessionServiceWithMockDependencies(t)
+	// expect
+	sessionRepository.On("ListActiveByUserID", ctx, sessionUser.ID).Return(nil, errors.New("database error")).Once()
+	// when
+	sessions, err := target.ListSessions(ctx, &sessionUser)
+	// then
+	assert.Error(t, err)
+	assert.Nil(t, sessions)
+	sessionRepository.AssertExpectations(t)
+}
+
+/*
+ * Revoke Session Tests
+ */
+
+func TestRevokeSession_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, sessionRepository, auditLogger := createSessionServiceWithMockDependencies(t)
+	// expect
+	sessionRepository.On("GetByID", ctx, uint(1)).Return(createSession(1, sessionUser.ID), nil).Once()
+	sessionRepository.On("Revoke", ctx, uint(1)).Return(nil).Once()
+	auditLogger.On("Record", ctx, mock.MatchedBy(func(event *model.AuditEvent) bool {
+		return event.Action == model.AuditActionSessionRevoke && event.TargetID == "1" && event.Outcome == model.AuditOutcomeSuccess
+	})).Once()
+	// when
+	err := target.RevokeSession(ctx, &sessionUser, 1)
+	// then
+	assert.NoError(t, err)
+	sessionRepository.AssertExpectations(t)
+	auditLogger.AssertExpectations(t)
+}
+
+func TestRevokeSession_NotFound(t *testing.T) {
+	revokedAt := time.Now()
+	revokedSession := createSession(1, sessionUser.ID)
+	revokedSession.RevokedAt = &revokedAt
+	tests := []struct {
+		testName   string
+		session    *model.Session
+		sessionErr error
+	}{
+		{testName: "Missing Session", sessionErr: gorm.ErrRecordNotFound},
+		{testName: "Session Of Another User", session: createSession(1, 2)},
+		{testName: "Already Revoked Session", session: revokedSession},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := context.Background()
+			target, sessionRepository, _ := createSessionServiceWithMockDependencies(t)
+			// expect
+			sessionRepository.On("GetByID", ctx, uint(1)).Return(test.session, test.sessionErr).Once()
+			// when
+			err := target.RevokeSession(ctx, &sessionUser, 1)
+			// then
+			var apiErr *apiError.ApiError
+			assert.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, apiError.ErrorTypeSessionNotFound, apiErr.Type)
+			sessionRepository.AssertNotCalled(t, "Revoke", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+/*
+ * End Session Tests
+ */
+
+func TestEndSession_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, sessionRepository, auditLogger := createSessionServiceWithMockDependencies(t)
+	// expect
+	sessionRepository.On("GetByID", ctx, uint(1)).Return(createSession(1, sessionUser.ID), nil).Once()
+	sessionRepository.On("Revoke", ctx, uint(1)).Return(nil).Once()
+	auditLogger.On("Record", ctx, mock.MatchedBy(func(event *model.AuditEvent) bool {
+		return event.Action == model.AuditActionSessionRevoke && event.TargetID == "1" && event.Outcome == model.AuditOutcomeSuccess
+	})).Once()
+	// when
+	err := target.EndSession(ctx, sessionUser.ID, 1)
+	// then
+	assert.NoError(t, err)
+	sessionRepository.AssertExpectations(t)
+	auditLogger.AssertExpectations(t)
+}
+
+func TestEndSession_NothingToEnd(t *testing.T) {
+	revokedAt := time.Now()
+	revokedSession := createSession(1, sessionUser.ID)
+	revokedSession.RevokedAt = &revokedAt
+	tests := []struct {
+		testName   string
+		session    *model.Session
+		sessionErr error
+	}{
+		{testName: "Missing Session", sessionErr: gorm.ErrRecordNotFound},
+		{testName: "Session Of Another User", session: createSession(1, 2)},
+		{testName: "Already Revoked Session", session: revokedSession},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := context.Background()
+			target, sessionRepository, _ := createSessionServiceWithMockDependencies(t)
+			// expect
+			sessionRepository.On("GetByID", ctx, uint(1)).Return(test.session, test.sessionErr).Once()
+			// when
+			err := target.EndSession(ctx, sessionUser.ID, 1)
+			// then
+			assert.NoError(t, err)
+			sessionRepository.AssertNotCalled(t, "Revoke", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestEndSession_Error(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, sessionRepository, _ := createSessionServiceWithMockDependencies(t)
+	// expect
+	sessionRepository.On("GetByID", ctx, uint(1)).Return(nil, errors.New("database error")).Once()
+	// when
+	err := target.EndSession(ctx, sessionUser.ID, 1)
+	// then
+	assert.Error(t, err)
+	sessionRepository.AssertNotCalled(t, "Revoke", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/test/mocks/repository"
+	mockService "github.com/Verano-20/stage-zero/test/mocks/service"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	signUpSecret       = []byte("secret")
+	signUpUserForm     = model.UserForm{Email: "test@example.com", Password: "securePassword1234"}
+	signUpUser         = &model.User{ID: 1, Email: "test@example.com"}
+	signUpOrganization = model.OrganizationForm{Name: "test@example.com"}
+)
+
+type signUpDependencies struct {
+	txManager           *repository.MockTxManager
+	userService         *mockService.MockUserService
+	organizationService *mockService.MockOrganizationService
+	invitationService   *mockService.MockInvitationService
+}
+
+func createSignUpServiceWithMockDependencies(t *testing.T) (service.SignUpService, signUpDependencies) {
+	dependencies := signUpDependencies{
+		txManager:           repository.NewMockTxManager(),
+		userService:         mockService.NewMockUserService(),
+		organizationService: mockService.NewMockOrganizationService(),
+		invitationService:   mockService.NewMockInvitationService(),
+	}
+	t.Cleanup(func() {
+		dependencies.txManager.AssertExpectations(t)
+		dependencies.userService.AssertExpectations(t)
+		dependencies.organizationService.AssertExpectations(t)
+		dependencies.invitationService.AssertExpectations(t)
+	})
+	target := service.NewSignUpService(dependencies.txManager, dependencies.userService, dependencies.organizationService, dependencies.invitationService)
+	return target, dependencies
+}
+
+func TestSignUp_CreatesPersonalOrganization(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, dependencies := createSignUpServiceWithMockDependencies(t)
+	// expect
+	dependencies.txManager.On("WithinTransaction", ctx).Return(nil).Once()
+	dependencies.userService.On("CreateUser", ctx, signUpUserForm).Return(signUpUser, nil).Once()
+	dependencies.organizationService.On("CreateOrganization", ctx, signUpUser, signUpOrganization).Return(&model.Organization{ID: 7}, nil).Once()
+	// when
+	user, membership, err := target.SignUp(ctx, model.SignUpForm{UserForm: signUpUserForm}, signUpSecret)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, signUpUser, user)
+	assert.Nil(t, membership)
+}
+
+func TestSignUp_RollsBackUserWhenOrganizationFails(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, dependencies := createSignUpServiceWithMockDependencies(t)
+	organizationErr := errors.New("database error")
+	// expect
+	dependencies.txManager.On("WithinTransaction", ctx).Return(nil).Once()
+	dependencies.userService.On("CreateUser", ctx, signUpUserForm).Return(signUpUser, nil).Once()
+	dependencies.organizationService.On("CreateOrganization", ctx, signUpUser, signUpOrganization).Return(nil, organizationErr).Once()
+	// when
+	user, membership, err := target.SignUp(ctx, model.SignUpForm{UserForm: signUpUserForm}, signUpSecret)
+	// then
+	assert.ErrorIs(t, err, organizationErr)
+	assert.Nil(t, user)
+	assert.Nil(t, membership)
+}
+
+func TestSignUp_CreateUserFails(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, dependencies := createSignUpServiceWithMockDependencies(t)
+	createErr := errors.New("email exists")
+	// expect
+	dependencies.txManager.On("WithinTransaction", ctx).Return(nil).Once()
+	dependencies.userService.On("CreateUser", ctx, signUpUserForm).Return(nil, createErr).Once()
+	// when
+	user, _, err := target.SignUp(ctx, model.SignUpForm{UserForm: signUpUserForm}, signUpSecret)
+	// then
+	assert.ErrorIs(t, err, createErr)
+	assert.Nil(t, user)
+}
+
+func TestSignUp_CommitFails(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, dependencies := createSignUpServiceWithMockDependencies(t)
+	commitErr := errors.New("commit failed")
+	// expect
+	dependencies.txManager.On("WithinTransaction", ctx).Return(commitErr).Once()
+	dependencies.userService.On("CreateUser", ctx, signUpUserForm).Return(signUpUser, nil).Once()
+	dependencies.organizationService.On("CreateOrganization", ctx, signUpUser, signUpOrganization).Return(&model.Organization{ID: 7}, nil).Once()
+	// when
+	user, _, err := target.SignUp(ctx, model.SignUpForm{UserForm: signUpUserForm}, signUpSecret)
+	// then
+	assert.ErrorIs(t, err, commitErr)
+	assert.Nil(t, user)
+}
+
+func TestSignUp_WithInvitationJoinsOrganization(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, dependencies := createSignUpServiceWithMockDependencies(t)
+	signUpForm := model.SignUpForm{UserForm: signUpUserForm, InvitationToken: "token"}
+	invitedMembership := &model.Membership{OrganizationID: 7, UserID: signUpUser.ID, Role: model.OrganizationRoleMember}
+	// expect
+	dependencies.txManager.On("WithinTransaction", ctx).Return(nil).Once()
+	dependencies.invitationService.On("SignUpWithInvitation", ctx, signUpForm, signUpSecret).Return(signUpUser, invitedMembership, nil).Once()
+	// when
+	user, membership, err := target.SignUp(ctx, signUpForm, signUpSecret)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, signUpUser, user)
+	assert.Equal(t, invitedMembership, membership)
+}
+
+func TestSignUp_WithInvitationNotAcceptedCreatesPersonalOrganization(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, dependencies := createSignUpServiceWithMockDependencies(t)
+	signUpForm := model.SignUpForm{UserForm: signUpUserForm, InvitationToken: "token"}
+	// expect
+	dependencies.txManager.On("WithinTransaction", ctx).Return(nil).Once()
+	dependencies.invitationService.On("SignUpWithInvitation", ctx, signUpForm, signUpSecret).Return(signUpUser, nil, nil).Once()
+	dependencies.organizationService.On("CreateOrganization", ctx, signUpUser, signUpOrganization).Return(&model.Organization{ID: 8}, nil).Once()
+	// when
+	user, membership, err := target.SignUp(ctx, signUpForm, signUpSecret)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, signUpUser, user)
+	assert.Nil(t, membership)
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/test/mocks/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var simpleStreamConfig = service.SimpleStreamConfig{
+	LogSize:    3,
+	BufferSize: 3,
+	RetryDelay: time.Hour,
+}
+
+// Runs the stream until the test ends, with a listener that is listening as of latestEventID, and returns the handler
+// notifications are passed to.
+func startSimpleStream(t *testing.T, latestEventID uint64) (service.SimpleStream, *repository.MockSimpleRepository, func(*model.SimpleChangeNotification)) {
+	simpleRepository := repository.NewMockSimpleRepository()
+	simpleChangeListener := repository.NewMockSimpleChangeListener()
+	target := service.NewSimpleStream(simpleRepository, simpleChangeListener, simpleStreamConfig)
+
+	handlers := make(chan func(*model.SimpleChangeNotification), 1)
+	simpleChangeListener.On("Listen", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(func(uint64))(latestEventID)
+		handlers <- args.Get(2).(func(*model.SimpleChangeNotification))
+		<-args.Get(0).(context.Context).Done()
+	}).Return(nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		target.Run(ctx)
+		close(stopped)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-stopped
+		simpleRepository.AssertExpectations(t)
+		simpleChangeListener.AssertExpectations(t)
+	})
+	return target, simpleRepository, <-handlers
+}
+
+// Passes a change to the Simple to the stream, as if notified of it.
+func notifySimpleChange(simpleRepository *repository.MockSimpleRepository, handle func(*model.SimpleChangeNotification), eventID uint64, simple *model.Simple) {
+	simpleRepository.On("GetChanged", mock.Anything, simple.ID).Return(simple, nil).Once()
+	handle(&model.SimpleChangeNotification{EventID: eventID, Type: model.EventSimpleUpdated, OrganizationID: simple.OrganizationID, SimpleID: simple.ID})
+}
+
+func receivedChangeIDs(subscription *service.SimpleSubscription) []uint64 {
+	var ids []uint64
+	for {
+		select {
+		case change, ok := <-subscription.Changes:
+			if !ok {
+				return ids
+			}
+			ids = append(ids, change.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestSimpleStream_StreamsVisibleChanges(t *testing.T) {
+	// given
+	target, simpleRepository, handle := startSimpleStream(t, 10)
+	member := target.Subscribe(createSimpleContext(&organizationMember, model.OrganizationRoleMember), 0)
+	admin := target.Subscribe(createSimpleContext(&organizationUser, model.OrganizationRoleAdmin), 0)
+	defer member.Close()
+	defer admin.Close()
+	otherOrganizationSimple := createOwnedSimple(4, organizationMember.ID)
+	otherOrganizationSimple.OrganizationID = organizationID + 1
+	// when
+	notifySimpleChange(simpleRepository, handle, 11, createOwnedSimple(1, organizationMember.ID))
+	notifySimpleChange(simpleRepository, handle, 12, createOwnedSimple(2, organizationUser.ID))
+	notifySimpleChange(simpleRepository, handle, 13, createOwnedSimple(3, organizationUser.ID, &model.SimpleGrant{UserID: organizationMember.ID, Permission: model.SimplePermissionRead}))
+	notifySimpleChange(simpleRepository, handle, 14, otherOrganizationSimple)
+	// then
+	memberChange := <-member.Changes
+	assert.Equal(t, uint64(11), memberChange.ID)
+	assert.True(t, memberChange.Simple.Owned)
+	sharedChange := <-member.Changes
+	assert.Equal(t, uint64(13), sharedChange.ID)
+	assert.True(t, sharedChange.Simple.Shared)
+	assert.Equal(t, model.SimplePermissionRead, sharedChange.Simple.Permission)
+	assert.Empty(t, receivedChangeIDs(member))
+	assert.Equal(t, []uint64{11, 12, 13}, receivedChangeIDs(admin))
+}
+
+func TestSimpleStream_FiltersByUpdatedMembership(t *testing.T) {
+	// given
+	target, simpleRepository, handle := startSimpleStream(t, 10)
+	subscription := target.Subscribe(createSimpleContext(&organizationMember, model.OrganizationRoleAdmin), 0)
+	defer subscription.Close()
+	notifySimpleChange(simpleRepository, handle, 11, createOwnedSimple(1, organizationUser.ID))
+	// when
+	subscription.UpdateMembership(createMembership(organizationMember.ID, model.OrganizationRoleMember))
+	notifySimpleChange(simpleRepository, handle, 12, createOwnedSimple(2, organizationUser.ID))
+	// then
+	assert.Equal(t, []uint64{11}, receivedChangeIDs(subscription))
+}
+
+func TestSimpleStream_ResumesFromLastEventID(t *testing.T) {
+	// given
+	target, simpleRepository, handle := startSimpleStream(t, 10)
+	for eventID := uint64(11); eventID <= 13; eventID++ {
+		notifySimpleChange(simpleRepository, handle, eventID, createOwnedSimple(uint(eventID), organizationUser.ID))
+	}
+	// when
+	subscription := target.Subscribe(createSimpleContext(&organizationUser, model.OrganizationRoleMember), 11)
+	defer subscription.Close()
+	// then
+	assert.True(t, subscription.Resumed)
+	assert.Equal(t, []uint64{12, 13}, receivedChangeIDs(subscription))
+}
+
+func TestSimpleStream_CannotResumeFromBeforeLog(t *testing.T) {
+	tests := []struct {
+		testName    string
+		lastEventID uint64
+		changes     int
+	}{
+		{testName: "Before listening started", lastEventID: 9, changes: 0},
+		{testName: "Dropped from the log", lastEventID: 11, changes: 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			target, simpleRepository, handle := startSimpleStream(t, 10)
+			for i := 1; i <= test.changes; i++ {
+				notifySimpleChange(simpleRepository, handle, uint64(10+i), createOwnedSimple(uint(i), organizationUser.ID))
+			}
+			// when
+			subscription := target.Subscribe(createSimpleContext(&organizationUser, model.OrganizationRoleMember), test.lastEventID)
+			defer subscription.Close()
+			// then
+			assert.False(t, subscription.Resumed)
+			assert.Empty(t, receivedChangeIDs(subscription))
+		})
+	}
+}
+
+func TestSimpleStream_ClosesSubscriberThatFallsBehind(t *testing.T) {
+	// given
+	target, simpleRepository, handle := startSimpleStream(t, 10)
+	subscription := target.Subscribe(createSimpleContext(&organizationUser, model.OrganizationRoleMember), 0)
+	defer subscription.Close()
+	// when
+	for eventID := uint64(11); eventID <= 14; eventID++ {
+		notifySimpleChange(simpleRepository, handle, eventID, createOwnedSimple(uint(eventID), organizationUser.ID))
+	}
+	// then
+	assert.Equal(t, []uint64{11, 12, 13}, receivedChangeIDs(subscription))
+	_, open := <-subscription.Changes
+	assert.False(t, open)
+}
+
+func TestSimpleStream_SkipsChangeThatCannotBeLoaded(t *testing.T) {
+	// given
+	target, simpleRepository, handle := startSimpleStream(t, 10)
+	subscription := target.Subscribe(createSimpleContext(&organizationUser, model.OrganizationRoleAdmin), 0)
+	defer subscription.Close()
+	// expect
+	simpleRepository.On("GetChanged", mock.Anything, uint(1)).Return(nil, errors.New("database error")).Once()
+	// when
+	handle(&model.SimpleChangeNotification{EventID: 11, Type: model.EventSimpleCreated, OrganizationID: organizationID, SimpleID: 1})
+	// then
+	assert.Empty(t, receivedChangeIDs(subscription))
+}
+
+func TestSimpleStream_StopClosesSubscriptions(t *testing.T) {
+	// given
+	simpleChangeListener := repository.NewMockSimpleChangeListener()
+	target := service.NewSimpleStream(repository.NewMockSimpleRepository(), simpleChangeListener, simpleStreamConfig)
+	subscription := target.Subscribe(createSimpleContext(&organizationUser, model.OrganizationRoleAdmin), 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	// expect
+	simpleChangeListener.On("Listen", mock.Anything, mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		cancel()
+	}).Return(nil).Once()
+	// when
+	target.Run(ctx)
+	// then
+	_, open := <-subscription.Changes
+	assert.False(t, open)
+	closed := target.Subscribe(createSimpleContext(&organizationUser, model.OrganizationRoleAdmin), 0)
+	_, open = <-closed.Changes
+	assert.False(t, open)
+	require.NotPanics(t, closed.Close)
+	simpleChangeListener.AssertExpectations(t)
+}
@@ -1,53 +1,133 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"testing"
 
+	apiError "github.com/Verano-20/stage-zero/internal/err"
 	"github.com/Verano-20/stage-zero/internal/model"
 	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/utils"
 	"github.com/Verano-20/stage-zero/test/mocks/repository"
+	mockService "github.com/Verano-20/stage-zero/test/mocks/service"
 	"github.com/Verano-20/stage-zero/test/testutils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
+type simpleServiceMocks struct {
+	txManager             *repository.MockTxManager
+	simpleRepository      *repository.MockSimpleRepository
+	simpleGrantRepository *repository.MockSimpleGrantRepository
+	tagRepository         *repository.MockTagRepository
+	membershipRepository  *repository.MockMembershipRepository
+	userService           *mockService.MockUserService
+	metadataSchemaService *mockService.MockMetadataSchemaService
+	webhookService        *mockService.MockWebhookService
+	auditLogger           *mockService.MockAuditLogger
+}
+
 func createSimpleServiceWithMockDependencies(t *testing.T) (service.SimpleService, *repository.MockSimpleRepository) {
-	mockRepo := repository.NewMockSimpleRepository()
-	defer mockRepo.AssertExpectations(t)
-	target := service.NewSimpleService(mockRepo)
+	target, mockRepo, _ := createSimpleServiceWithAllMockDependencies(t)
 	return target, mockRepo
 }
 
+func createSimpleServiceWithAllMockDependencies(t *testing.T) (service.SimpleService, *repository.MockSimpleRepository, *mockService.MockAuditLogger) {
+	target, mocks := createSimpleServiceWithMocks(t)
+	mocks.webhookService.On("EnqueueSimpleEvent", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	return target, mocks.simpleRepository, mocks.auditLogger
+}
+
+func createSimpleServiceWithMocks(t *testing.T) (service.SimpleService, simpleServiceMocks) {
+	mocks := simpleServiceMocks{
+		txManager:             repository.NewMockTxManager(),
+		simpleRepository:      repository.NewMockSimpleRepository(),
+		simpleGrantRepository: repository.NewMockSimpleGrantRepository(),
+		tagRepository:         repository.NewMockTagRepository(),
+		membershipRepository:  repository.NewMockMembershipRepository(),
+		userService:           mockService.NewMockUserService(),
+		metadataSchemaService: mockService.NewMockMetadataSchemaService(),
+		webhookService:        mockService.NewMockWebhookService(),
+		auditLogger:           mockService.NewMockAuditLogger(),
+	}
+	mocks.txManager.On("WithinTransaction", mock.Anything).Return(nil).Maybe()
+	target := service.NewSimpleService(mocks.txManager, mocks.simpleRepository, mocks.simpleGrantRepository, mocks.tagRepository, mocks.membershipRepository, mocks.userService, mocks.metadataSchemaService, mocks.webhookService, mocks.auditLogger)
+	return target, mocks
+}
+
+func (mocks simpleServiceMocks) assertExpectations(t *testing.T) {
+	mocks.simpleRepository.AssertExpectations(t)
+	mocks.simpleGrantRepository.AssertExpectations(t)
+	mocks.tagRepository.AssertExpectations(t)
+	mocks.membershipRepository.AssertExpectations(t)
+	mocks.userService.AssertExpectations(t)
+	mocks.metadataSchemaService.AssertExpectations(t)
+	mocks.webhookService.AssertExpectations(t)
+	mocks.auditLogger.AssertExpectations(t)
+}
+
+// Returns a context for a user with the given role in the organization the Simples belong to.
+func createSimpleContext(user *model.User, role string) context.Context {
+	ctx := utils.WithAuthenticatedUser(context.Background(), user, user)
+	return utils.WithMembership(ctx, createMembership(user.ID, role))
+}
+
+func createOwnedSimple(id uint, ownerID uint, grants ...*model.SimpleGrant) *model.Simple {
+	return &model.Simple{ID: id, OrganizationID: organizationID, OwnerID: &ownerID, Name: "Simple", Grants: grants}
+}
+
+func expectSimpleAuditEvent(auditLogger *mockService.MockAuditLogger, ctx any, action string, targetID string, outcome string) {
+	auditLogger.On("Record", ctx, mock.MatchedBy(func(event *model.AuditEvent) bool {
+		return event.Action == action && event.TargetType == model.AuditTargetTypeSimple && event.TargetID == targetID && event.Outcome == outcome
+	})).Once()
+}
+
+func expectSimpleWebhookEvent(webhookService *mockService.MockWebhookService, ctx any, eventType string, simpleID uint) {
+	webhookService.On("EnqueueSimpleEvent", ctx, eventType, mock.MatchedBy(func(simple *model.Simple) bool {
+		return simple.ID == simpleID
+	})).Return(nil).Once()
+}
+
 /*
  * Create Simple Tests
  */
 
 func TestCreateSimple_Success(t *testing.T) {
 	// given
-	ctx, _ := testutils.CreateTestContext()
-	target, simpleRepository := createSimpleServiceWithMockDependencies(t)
+	ctx := createSimpleContext(&organizationUser, model.OrganizationRoleAdmin)
+	target, mocks := createSimpleServiceWithMocks(t)
+	simpleRepository, auditLogger := mocks.simpleRepository, mocks.auditLogger
 	// expect
+	mocks.metadataSchemaService.On("ValidateMetadata", ctx, organizationID, "", model.JSONMap(nil)).Return(nil).Once()
 	simpleRepository.On("Create", ctx, mock.MatchedBy(func(simple *model.Simple) bool {
-		return simple.Name == testutils.Simple1.Name
+		return simple.Name == testutils.Simple1.Name && *simple.OwnerID == organizationUser.ID
 	})).Return(&testutils.Simple1, nil).Once()
+	expectSimpleAuditEvent(auditLogger, ctx, model.AuditActionSimpleCreate, "1", model.AuditOutcomeSuccess)
+	expectSimpleWebhookEvent(mocks.webhookService, ctx, model.WebhookEventSimpleCreated, 1)
 	// when
 	result, err := target.CreateSimple(ctx, *testutils.Simple1.ToForm())
 	// then
 	assert.NoError(t, err)
 	assert.Equal(t, &testutils.Simple1, result)
 	simpleRepository.AssertExpectations(t)
+	auditLogger.AssertExpectations(t)
+	mocks.webhookService.AssertExpectations(t)
 }
 
 func TestCreateSimple_Error(t *testing.T) {
 	// given
-	ctx, _ := testutils.CreateTestContext()
-	target, simpleRepository := createSimpleServiceWithMockDependencies(t)
+	ctx := createSimpleContext(&organizationUser, model.OrganizationRoleAdmin)
+	target, mocks := createSimpleServiceWithMocks(t)
+	simpleRepository, auditLogger := mocks.simpleRepository, mocks.auditLogger
 	expectedError := errors.New("database error")
 	// expect
+	mocks.metadataSchemaService.On("ValidateMetadata", ctx, organizationID, "", model.JSONMap(nil)).Return(nil).Once()
 	simpleRepository.On("Create", ctx, mock.MatchedBy(func(simple *model.Simple) bool {
 		return simple.Name == testutils.Simple1.Name
 	})).Return(nil, expectedError).Once()
+	expectSimpleAuditEvent(auditLogger, ctx, model.AuditActionSimpleCreate, "", model.AuditOutcomeFailure)
 	// when
 	result, err := target.CreateSimple(ctx, *testutils.Simple1.ToForm())
 	// then
@@ -55,6 +135,7 @@ func TestCreateSimple_Error(t *testing.T) {
 	assert.Nil(t, result)
 	assert.Equal(t, expectedError, err)
 	simpleRepository.AssertExpectations(t)
+	auditLogger.AssertExpectations(t)
 }
 
 /*
@@ -63,13 +144,13 @@ func TestCreateSimple_Error(t *testing.T) {
 
 func TestGetAllSimples_Success(t *testing.T) {
 	// given
-	ctx, _ := testutils.CreateTestContext()
+	ctx := createSimpleContext(&organizationUser, model.OrganizationRoleAdmin)
 	target, simpleRepository := createSimpleServiceWithMockDependencies(t)
 	expectedSimples := model.Simples{&testutils.Simple1, &testutils.Simple2}
 	// expect
-	simpleRepository.On("GetAll", ctx).Return(expectedSimples, nil).Once()
+	simpleRepository.On("GetAll", ctx, model.SimpleFilter{Match: model.TagMatchAny}).Return(expectedSimples, nil).Once()
 	// when
-	result, err := target.GetAllSimples(ctx)
+	result, err := target.GetAllSimples(ctx, model.SimpleFilter{})
 	// then
 	assert.NoError(t, err)
 	assert.Equal(t, expectedSimples, result)
@@ -78,13 +159,13 @@ func TestGetAllSimples_Success(t *testing.T) {
 
 func TestGetAllSimples_Error(t *testing.T) {
 	// given
-	ctx, _ := testutils.CreateTestContext()
+	ctx := createSimpleContext(&organizationUser, model.OrganizationRoleAdmin)
 	target, simpleRepository := createSimpleServiceWithMockDependencies(t)
 	expectedError := errors.New("database error")
 	// expect
-	simpleRepository.On("GetAll", ctx).Return(nil, expectedError).Once()
+	simpleRepository.On("GetAll", ctx, model.SimpleFilter{Match: model.TagMatchAny}).Return(nil, expectedError).Once()
 	// when
-	result, err := target.GetAllSimples(ctx)
+	result, err := target.GetAllSimples(ctx, model.SimpleFilter{})
 	// then
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -98,7 +179,7 @@ func TestGetAllSimples_Error(t *testing.T) {
 
 func TestGetSimpleByID_Success(t *testing.T) {
 	// given
-	ctx, _ := testutils.CreateTestContext()
+	ctx := createSimpleContext(&organizationUser, model.OrganizationRoleAdmin)
 	target, simpleRepository := createSimpleServiceWithMockDependencies(t)
 	// expect
 	simpleRepository.On("GetByID", ctx, testutils.Simple1.ID).Return(&testutils.Simple1, nil).Once()
@@ -112,7 +193,7 @@ func TestGetSimpleByID_Success(t *testing.T) {
 
 func TestGetSimpleByID_Error(t *testing.T) {
 	// given
-	ctx, _ := testutils.CreateTestContext()
+	ctx := createSimpleContext(&organizationUser, model.OrganizationRoleAdmin)
 	target, simpleRepository := createSimpleServiceWithMockDependencies(t)
 	expectedError := errors.New("database error")
 	// expect
@@ -132,25 +213,28 @@ func TestGetSimpleByID_Error(t *testing.T) {
 
 func TestUpdateSimple_Success(t *testing.T) {
 	// given
-	ctx, _ := testutils.CreateTestContext()
-	target, simpleRepository := createSimpleServiceWithMockDependencies(t)
+	ctx := createSimpleContext(&organizationUser, model.OrganizationRoleAdmin)
+	target, simpleRepository, auditLogger := createSimpleServiceWithAllMockDependencies(t)
 	// expect
-	simpleRepository.On("Update", ctx, &testutils.Simple1).Return(&testutils.Simple1, nil).Once()
+	simpleRepository.On("Update", ctx, &testutils.Simple1, model.Tags(nil)).Return(&testutils.Simple1, nil).Once()
+	expectSimpleAuditEvent(auditLogger, ctx, model.AuditActionSimpleUpdate, "1", model.AuditOutcomeSuccess)
 	// when
 	result, err := target.UpdateSimple(ctx, &testutils.Simple1, *testutils.Simple1.ToForm())
 	// then
 	assert.NoError(t, err)
 	assert.Equal(t, &testutils.Simple1, result)
 	simpleRepository.AssertExpectations(t)
+	auditLogger.AssertExpectations(t)
 }
 
 func TestUpdateSimple_Error(t *testing.T) {
 	// given
-	ctx, _ := testutils.CreateTestContext()
-	target, simpleRepository := createSimpleServiceWithMockDependencies(t)
+	ctx := createSimpleContext(&organizationUser, model.OrganizationRoleAdmin)
+	target, simpleRepository, auditLogger := createSimpleServiceWithAllMockDependencies(t)
 	expectedError := errors.New("database error")
 	// expect
-	simpleRepository.On("Update", ctx, &testutils.Simple1).Return(nil, expectedError).Once()
+	simpleRepository.On("Update", ctx, &testutils.Simple1, model.Tags(nil)).Return(nil, expectedError).Once()
+	expectSimpleAuditEvent(auditLogger, ctx, model.AuditActionSimpleUpdate, "1", model.AuditOutcomeFailure)
 	// when
 	result, err := target.UpdateSimple(ctx, &testutils.Simple1, *testutils.Simple1.ToForm())
 	// then
@@ -158,6 +242,7 @@ func TestUpdateSimple_Error(t *testing.T) {
 	assert.Nil(t, result)
 	assert.Equal(t, expectedError, err)
 	simpleRepository.AssertExpectations(t)
+	auditLogger.AssertExpectations(t)
 }
 
 /*
@@ -166,28 +251,477 @@ func TestUpdateSimple_Error(t *testing.T) {
 
 func TestDeleteSimple_Success(t *testing.T) {
 	// given
-	ctx, _ := testutils.CreateTestContext()
-	target, simpleRepository := createSimpleServiceWithMockDependencies(t)
+	ctx := createSimpleContext(&organizationUser, model.OrganizationRoleAdmin)
+	target, simpleRepository, auditLogger := createSimpleServiceWithAllMockDependencies(t)
 	// expect
 	simpleRepository.On("Delete", ctx, testutils.Simple1.ID).Return(nil).Once()
+	expectSimpleAuditEvent(auditLogger, ctx, model.AuditActionSimpleDelete, "1", model.AuditOutcomeSuccess)
 	// when
 	err := target.DeleteSimple(ctx, &testutils.Simple1)
 	// then
 	assert.NoError(t, err)
 	simpleRepository.AssertExpectations(t)
+	auditLogger.AssertExpectations(t)
 }
 
 func TestDeleteSimple_Error(t *testing.T) {
 	// given
-	ctx, _ := testutils.CreateTestContext()
-	target, simpleRepository := createSimpleServiceWithMockDependencies(t)
+	ctx := createSimpleContext(&organizationUser, model.OrganizationRoleAdmin)
+	target, simpleRepository, auditLogger := createSimpleServiceWithAllMockDependencies(t)
 	expectedError := errors.New("database error")
 	// expect
 	simpleRepository.On("Delete", ctx, testutils.Simple1.ID).Return(expectedError).Once()
+	expectSimpleAuditEvent(auditLogger, ctx, model.AuditActionSimpleDelete, "1", model.AuditOutcomeFailure)
 	// when
 	err := target.DeleteSimple(ctx, &testutils.Simple1)
 	// then
 	assert.Error(t, err)
 	assert.Equal(t, expectedError, err)
 	simpleRepository.AssertExpectations(t)
+	auditLogger.AssertExpectations(t)
+}
+
+/*
+ * Simple Access Tests
+ */
+
+func TestWriteSimple_WebhookQueueFailure(t *testing.T) {
+	tests := []struct {
+		testName    string
+		auditAction string
+		auditTarget string
+		expect      func(ctx context.Context, mocks simpleServiceMocks)
+		write       func(ctx context.Context, target service.SimpleService) error
+	}{
+		{
+			testName:    "Create",
+			auditAction: model.AuditActionSimpleCreate,
+			expect: func(ctx context.Context, mocks simpleServiceMocks) {
+				mocks.metadataSchemaService.On("ValidateMetadata", ctx, organizationID, "", model.JSONMap(nil)).Return(nil).Once()
+				mocks.simpleRepository.On("Create", ctx, mock.AnythingOfType("*model.Simple")).Return(&testutils.Simple1, nil).Once()
+			},
+			write: func(ctx context.Context, target service.SimpleService) error {
+				_, err := target.CreateSimple(ctx, *testutils.Simple1.ToForm())
+				return err
+			},
+		},
+		{
+			testName:    "Update",
+			auditAction: model.AuditActionSimpleUpdate,
+			auditTarget: "1",
+			expect: func(ctx context.Context, mocks simpleServiceMocks) {
+				mocks.simpleRepository.On("Update", ctx, &testutils.Simple1, model.Tags(nil)).Return(&testutils.Simple1, nil).Once()
+			},
+			write: func(ctx context.Context, target service.SimpleService) error {
+				_, err := target.UpdateSimple(ctx, &testutils.Simple1, *testutils.Simple1.ToForm())
+				return err
+			},
+		},
+		{
+			testName:    "Delete",
+			auditAction: model.AuditActionSimpleDelete,
+			auditTarget: "1",
+			expect: func(ctx context.Context, mocks simpleServiceMocks) {
+				mocks.simpleRepository.On("Delete", ctx, testutils.Simple1.ID).Return(nil).Once()
+			},
+			write: func(ctx context.Context, target service.SimpleService) error {
+				return target.DeleteSimple(ctx, &testutils.Simple1)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := createSimpleContext(&organizationUser, model.OrganizationRoleAdmin)
+			target, mocks := createSimpleServiceWithMocks(t)
+			expectedError := errors.New("database error")
+			// expect
+			// The write and its deliveries share a transaction, so failing to queue the deliveries rolls the write back.
+			test.expect(ctx, mocks)
+			mocks.webhookService.On("EnqueueSimpleEvent", ctx, mock.Anything, mock.Anything).Return(expectedError).Once()
+			expectSimpleAuditEvent(mocks.auditLogger, ctx, test.auditAction, test.auditTarget, model.AuditOutcomeFailure)
+			// when
+			err := test.write(ctx, target)
+			// then
+			assert.Equal(t, expectedError, err)
+			mocks.assertExpectations(t)
+		})
+	}
+}
+
+func TestGetAllSimples_MemberSeesOwnedAndSharedSimples(t *testing.T) {
+	// given
+	ctx := createSimpleContext(&organizationMember, model.OrganizationRoleMember)
+	target, simpleRepository := createSimpleServiceWithMockDependencies(t)
+	owned := createOwnedSimple(1, organizationMember.ID)
+	shared := createOwnedSimple(2, organizationUser.ID, &model.SimpleGrant{SimpleID: 2, UserID: organizationMember.ID, Permission: model.SimplePermissionRead})
+	// expect
+	simpleRepository.On("GetAllAccessibleBy", ctx, organizationMember.ID, model.SimpleFilter{Match: model.TagMatchAny}).Return(model.Simples{owned, shared}, nil).Once()
+	// when
+	result, err := target.GetAllSimples(ctx, model.SimpleFilter{})
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, model.Simples{owned, shared}, result)
+	assert.True(t, owned.Owned)
+	assert.False(t, owned.Shared)
+	assert.Equal(t, model.SimplePermissionManage, owned.Permission)
+	assert.False(t, shared.Owned)
+	assert.True(t, shared.Shared)
+	assert.Equal(t, model.SimplePermissionRead, shared.Permission)
+	simpleRepository.AssertExpectations(t)
+}
+
+func TestGetSimpleByID_NotSharedWithMember(t *testing.T) {
+	// given
+	ctx := createSimpleContext(&organizationMember, model.OrganizationRoleMember)
+	target, simpleRepository := createSimpleServiceWithMockDependencies(t)
+	simple := createOwnedSimple(1, organizationUser.ID)
+	// expect
+	simpleRepository.On("GetByID", ctx, simple.ID).Return(simple, nil).Once()
+	// when
+	result, err := target.GetSimpleByID(ctx, uint64(simple.ID))
+	// then
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.Nil(t, result)
+	simpleRepository.AssertExpectations(t)
+}
+
+func TestUpdateSimple_WriteGrant(t *testing.T) {
+	// given
+	ctx := createSimpleContext(&organizationMember, model.OrganizationRoleMember)
+	target, simpleRepository, auditLogger := createSimpleServiceWithAllMockDependencies(t)
+	simple := createOwnedSimple(1, organizationUser.ID, &model.SimpleGrant{SimpleID: 1, UserID: organizationMember.ID, Permission: model.SimplePermissionWrite})
+	// expect
+	simpleRepository.On("Update", ctx, simple, model.Tags(nil)).Return(simple, nil).Once()
+	expectSimpleAuditEvent(auditLogger, ctx, model.AuditActionSimpleUpdate, "1", model.AuditOutcomeSuccess)
+	// when
+	result, err := target.UpdateSimple(ctx, simple, model.SimpleForm{Name: "Renamed"})
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "Renamed", result.Name)
+	simpleRepository.AssertExpectations(t)
+	auditLogger.AssertExpectations(t)
+}
+
+func TestSimpleAccess_Denied(t *testing.T) {
+	readGrant := &model.SimpleGrant{SimpleID: 1, UserID: organizationMember.ID, Permission: model.SimplePermissionRead}
+	writeGrant := &model.SimpleGrant{SimpleID: 1, UserID: organizationMember.ID, Permission: model.SimplePermissionWrite}
+	tests := []struct {
+		testName string
+		grant    *model.SimpleGrant
+		action   string
+		run      func(ctx context.Context, target service.SimpleService, simple *model.Simple) error
+	}{
+		{
+			testName: "Update with read grant",
+			grant:    readGrant,
+			action:   model.AuditActionSimpleUpdate,
+			run: func(ctx context.Context, target service.SimpleService, simple *model.Simple) error {
+				_, err := target.UpdateSimple(ctx, simple, model.SimpleForm{Name: "Renamed"})
+				return err
+			},
+		},
+		{
+			testName: "Delete with write grant",
+			grant:    writeGrant,
+			action:   model.AuditActionSimpleDelete,
+			run: func(ctx context.Context, target service.SimpleService, simple *model.Simple) error {
+				return target.DeleteSimple(ctx, simple)
+			},
+		},
+		{
+			testName: "Share with write grant",
+			grant:    writeGrant,
+			action:   model.AuditActionSimpleShare,
+			run: func(ctx context.Context, target service.SimpleService, simple *model.Simple) error {
+				_, err := target.ShareSimple(ctx, simple, model.SimpleShareForm{Email: "test3@example.com", Permission: model.SimplePermissionRead})
+				return err
+			},
+		},
+		{
+			testName: "Unshare another user with write grant",
+			grant:    writeGrant,
+			action:   model.AuditActionSimpleUnshare,
+			run: func(ctx context.Context, target service.SimpleService, simple *model.Simple) error {
+				return target.UnshareSimple(ctx, simple, 3)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := createSimpleContext(&organizationMember, model.OrganizationRoleMember)
+			target, mocks := createSimpleServiceWithMocks(t)
+			simple := createOwnedSimple(1, organizationUser.ID, test.grant)
+			// expect
+			expectSimpleAuditEvent(mocks.auditLogger, ctx, test.action, "1", model.AuditOutcomeFailure)
+			// when
+			err := test.run(ctx, target, simple)
+			// then
+			assertApiErrorType(t, err, apiError.ErrorTypeSimpleAccess)
+			mocks.assertExpectations(t)
+		})
+	}
+}
+
+/*
+ * Share Simple Tests
+ */
+
+func TestShareSimple_Success(t *testing.T) {
+	// given
+	ctx := createSimpleContext(&organizationUser, model.OrganizationRoleMember)
+	target, mocks := createSimpleServiceWithMocks(t)
+	simple := createOwnedSimple(1, organizationUser.ID)
+	shareForm := model.SimpleShareForm{Email: organizationMember.Email, Permission: model.SimplePermissionWrite}
+	expectedGrant := &model.SimpleGrant{SimpleID: simple.ID, UserID: organizationMember.ID, Permission: model.SimplePermissionWrite, GrantedByID: &organizationUser.ID}
+	// expect
+	mocks.userService.On("GetUserByEmail", ctx, organizationMember.Email).Return(&organizationMember, nil).Once()
+	mocks.membershipRepository.On("Get", ctx, organizationID, organizationMember.ID).Return(createMembership(organizationMember.ID, model.OrganizationRoleMember), nil).Once()
+	mocks.simpleGrantRepository.On("Upsert", ctx, expectedGrant).Return(expectedGrant, nil).Once()
+	expectSimpleAuditEvent(mocks.auditLogger, ctx, model.AuditActionSimpleShare, "1", model.AuditOutcomeSuccess)
+	// when
+	grant, err := target.ShareSimple(ctx, simple, shareForm)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, organizationMember.Email, grant.ToDTO().Email)
+	assert.Equal(t, model.SimplePermissionWrite, grant.Permission)
+	mocks.assertExpectations(t)
+}
+
+func TestShareSimple_Failure(t *testing.T) {
+	outsider := model.User{ID: 3, Email: "test3@example.com"}
+	tests := []struct {
+		testName     string
+		email        string
+		expect       func(ctx context.Context, mocks simpleServiceMocks)
+		expectedType string
+	}{
+		{
+			testName: "User not found",
+			email:    "missing@example.com",
+			expect: func(ctx context.Context, mocks simpleServiceMocks) {
+				mocks.userService.On("GetUserByEmail", ctx, "missing@example.com").Return(nil, gorm.ErrRecordNotFound).Once()
+			},
+			expectedType: apiError.ErrorTypeUserNotFound,
+		},
+		{
+			testName: "User owns the Simple",
+			email:    organizationUser.Email,
+			expect: func(ctx context.Context, mocks simpleServiceMocks) {
+				mocks.userService.On("GetUserByEmail", ctx, organizationUser.Email).Return(&organizationUser, nil).Once()
+			},
+			expectedType: apiError.ErrorTypeShareInvalid,
+		},
+		{
+			testName: "User is not a member of the organization",
+			email:    outsider.Email,
+			expect: func(ctx context.Context, mocks simpleServiceMocks) {
+				mocks.userService.On("GetUserByEmail", ctx, outsider.Email).Return(&outsider, nil).Once()
+				mocks.membershipRepository.On("Get", ctx, organizationID, outsider.ID).Return(nil, gorm.ErrRecordNotFound).Once()
+			},
+			expectedType: apiError.ErrorTypeShareInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := createSimpleContext(&organizationUser, model.OrganizationRoleMember)
+			target, mocks := createSimpleServiceWithMocks(t)
+			simple := createOwnedSimple(1, organizationUser.ID)
+			// expect
+			test.expect(ctx, mocks)
+			// when
+			grant, err := target.ShareSimple(ctx, simple, model.SimpleShareForm{Email: test.email, Permission: model.SimplePermissionRead})
+			// then
+			assert.Nil(t, grant)
+			assertApiErrorType(t, err, test.expectedType)
+			mocks.assertExpectations(t)
+		})
+	}
+}
+
+/*
+ * Unshare Simple Tests
+ */
+
+func TestUnshareSimple_GranteeRemovesOwnAccess(t *testing.T) {
+	// given
+	ctx := createSimpleContext(&organizationMember, model.OrganizationRoleMember)
+	target, mocks := createSimpleServiceWithMocks(t)
+	simple := createOwnedSimple(1, organizationUser.ID, &model.SimpleGrant{SimpleID: 1, UserID: organizationMember.ID, Permission: model.SimplePermissionRead})
+	// expect
+	mocks.simpleGrantRepository.On("Delete", ctx, simple.ID, organizationMember.ID).Return(nil).Once()
+	expectSimpleAuditEvent(mocks.auditLogger, ctx, model.AuditActionSimpleUnshare, "1", model.AuditOutcomeSuccess)
+	// when
+	err := target.UnshareSimple(ctx, simple, organizationMember.ID)
+	// then
+	assert.NoError(t, err)
+	mocks.assertExpectations(t)
+}
+
+func TestUnshareSimple_NotShared(t *testing.T) {
+	// given
+	ctx := createSimpleContext(&organizationUser, model.OrganizationRoleMember)
+	target, mocks := createSimpleServiceWithMocks(t)
+	simple := createOwnedSimple(1, organizationUser.ID)
+	// expect
+	mocks.simpleGrantRepository.On("Delete", ctx, simple.ID, organizationMember.ID).Return(gorm.ErrRecordNotFound).Once()
+	expectSimpleAuditEvent(mocks.auditLogger, ctx, model.AuditActionSimpleUnshare, "1", model.AuditOutcomeFailure)
+	// when
+	err := target.UnshareSimple(ctx, simple, organizationMember.ID)
+	// then
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	mocks.assertExpectations(t)
+}
+
+/*
+ * Simple Tag Tests
+ */
+
+func TestCreateSimple_WithTags(t *testing.T) {
+	// given
+	ctx := createSimpleContext(&organizationUser, model.OrganizationRoleMember)
+	target, mocks := createSimpleServiceWithMocks(t)
+	tags := model.Tags{{ID: 1, OrganizationID: organizationID, Name: "urgent"}, {ID: 2, OrganizationID: organizationID, Name: "billing"}}
+	simpleForm := model.SimpleForm{Name: "Tagged", Tags: []string{"Urgent", " urgent ", "billing", ""}}
+	// expect
+	mocks.metadataSchemaService.On("ValidateMetadata", ctx, organizationID, "", model.JSONMap(nil)).Return(nil).Once()
+	mocks.tagRepository.On("FindOrCreate", ctx, []string{"urgent", "billing"}).Return(tags, nil).Once()
+	mocks.simpleRepository.On("Create", ctx, mock.MatchedBy(func(simple *model.Simple) bool {
+		return simple.Name == "Tagged" && assert.ObjectsAreEqual(tags, simple.Tags)
+	})).Return(&model.Simple{ID: 3, OwnerID: &organizationUser.ID, Name: "Tagged", Tags: tags}, nil).Once()
+	expectSimpleAuditEvent(mocks.auditLogger, ctx, model.AuditActionSimpleCreate, "3", model.AuditOutcomeSuccess)
+	expectSimpleWebhookEvent(mocks.webhookService, ctx, model.WebhookEventSimpleCreated, 3)
+	// when
+	result, err := target.CreateSimple(ctx, simpleForm)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"urgent", "billing"}, result.ToDTO().Tags)
+	mocks.assertExpectations(t)
+}
+
+func TestUpdateSimple_ReplacesTags(t *testing.T) {
+	// given
+	ctx := createSimpleContext(&organizationUser, model.OrganizationRoleMember)
+	target, mocks := createSimpleServiceWithMocks(t)
+	simple := createOwnedSimple(1, organizationUser.ID)
+	simple.Tags = model.Tags{{ID: 1, OrganizationID: organizationID, Name: "urgent"}}
+	tags := model.Tags{{ID: 2, OrganizationID: organizationID, Name: "billing"}}
+	// expect
+	mocks.tagRepository.On("FindOrCreate", ctx, []string{"billing"}).Return(tags, nil).Once()
+	mocks.simpleRepository.On("Update", ctx, simple, tags).Return(simple, nil).Once()
+	expectSimpleAuditEvent(mocks.auditLogger, ctx, model.AuditActionSimpleUpdate, "1", model.AuditOutcomeSuccess)
+	expectSimpleWebhookEvent(mocks.webhookService, ctx, model.WebhookEventSimpleUpdated, 1)
+	// when
+	result, err := target.UpdateSimple(ctx, simple, model.SimpleForm{Name: "Simple", Tags: []string{"Billing"}})
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"billing"}, result.ToDTO().Tags)
+	mocks.assertExpectations(t)
+}
+
+func TestUpdateSimple_KeepsTagsWhenOmitted(t *testing.T) {
+	// given
+	ctx := createSimpleContext(&organizationUser, model.OrganizationRoleMember)
+	target, mocks := createSimpleServiceWithMocks(t)
+	simple := createOwnedSimple(1, organizationUser.ID)
+	simple.Tags = model.Tags{{ID: 1, OrganizationID: organizationID, Name: "urgent"}}
+	// expect
+	mocks.simpleRepository.On("Update", ctx, simple, model.Tags(nil)).Return(simple, nil).Once()
+	expectSimpleAuditEvent(mocks.auditLogger, ctx, model.AuditActionSimpleUpdate, "1", model.AuditOutcomeSuccess)
+	expectSimpleWebhookEvent(mocks.webhookService, ctx, model.WebhookEventSimpleUpdated, 1)
+	// when
+	result, err := target.UpdateSimple(ctx, simple, model.SimpleForm{Name: "Renamed"})
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"urgent"}, result.ToDTO().Tags)
+	mocks.assertExpectations(t)
+}
+
+func TestGetAllSimples_NormalizesTagFilter(t *testing.T) {
+	// given
+	ctx := createSimpleContext(&organizationUser, model.OrganizationRoleOwner)
+	target, simpleRepository := createSimpleServiceWithMockDependencies(t)
+	// expect
+	simpleRepository.On("GetAll", ctx, model.SimpleFilter{Tags: []string{"urgent", "billing"}, Match: model.TagMatchAll}).Return(model.Simples{}, nil).Once()
+	// when
+	result, err := target.GetAllSimples(ctx, model.SimpleFilter{Tags: []string{"URGENT", "billing", "urgent"}, Match: model.TagMatchAll})
+	// then
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+	simpleRepository.AssertExpectations(t)
+}
+
+func TestSuggestTags(t *testing.T) {
+	tests := []struct {
+		testName             string
+		role                 string
+		expectedAccessibleBy *uint
+	}{
+		{testName: "Admin counts every Simple", role: model.OrganizationRoleAdmin, expectedAccessibleBy: nil},
+		{testName: "Member counts accessible Simples", role: model.OrganizationRoleMember, expectedAccessibleBy: &organizationMember.ID},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := createSimpleContext(&organizationMember, test.role)
+			target, mocks := createSimpleServiceWithMocks(t)
+			expectedTags := []*model.TagCount{{Name: "urgent", Count: 3}}
+			// expect
+			mocks.tagRepository.On("Suggest", ctx, model.TagSuggestionFilter{Query: "urg", Limit: 10}, test.expectedAccessibleBy).Return(expectedTags, nil).Once()
+			// when
+			result, err := target.SuggestTags(ctx, model.TagSuggestionFilter{Query: " URG "})
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, expectedTags, result)
+			mocks.assertExpectations(t)
+		})
+	}
+}
+
+/*
+ * Simple Metadata Tests
+ */
+
+func TestCreateSimple_MetadataDoesNotMatchSchema(t *testing.T) {
+	// given
+	ctx := createSimpleContext(&organizationUser, model.OrganizationRoleMember)
+	target, mocks := createSimpleServiceWithMocks(t)
+	simpleForm := model.SimpleForm{Name: "Ticket", Kind: "ticket", Metadata: model.JSONMap{"priority": "whenever"}}
+	expectedError := apiError.NewMetadataInvalidError(errors.New("metadata does not match schema"), map[string]string{"metadata.priority": "value must be one of 'low', 'high'"})
+	// expect
+	mocks.metadataSchemaService.On("ValidateMetadata", ctx, organizationID, "ticket", simpleForm.Metadata).Return(expectedError).Once()
+	expectSimpleAuditEvent(mocks.auditLogger, ctx, model.AuditActionSimpleCreate, "", model.AuditOutcomeFailure)
+	// when
+	result, err := target.CreateSimple(ctx, simpleForm)
+	// then
+	assert.Nil(t, result)
+	assertApiErrorType(t, err, apiError.ErrorTypeMetadataInvalid)
+	mocks.assertExpectations(t)
+}
+
+func TestUpdateSimple_ValidatesMetadataAgainstKind(t *testing.T) {
+	// given
+	ctx := createSimpleContext(&organizationUser, model.OrganizationRoleMember)
+	target, mocks := createSimpleServiceWithMocks(t)
+	simple := createOwnedSimple(1, organizationUser.ID)
+	simple.Kind = "ticket"
+	metadata := model.JSONMap{"priority": "high"}
+	// expect
+	mocks.metadataSchemaService.On("ValidateMetadata", ctx, organizationID, "ticket", metadata).Return(nil).Once()
+	mocks.simpleRepository.On("Update", ctx, simple, model.Tags(nil)).Return(simple, nil).Once()
+	expectSimpleAuditEvent(mocks.auditLogger, ctx, model.AuditActionSimpleUpdate, "1", model.AuditOutcomeSuccess)
+	expectSimpleWebhookEvent(mocks.webhookService, ctx, model.WebhookEventSimpleUpdated, 1)
+	// when
+	result, err := target.UpdateSimple(ctx, simple, model.SimpleForm{Name: "Simple", Kind: "ignored", Metadata: metadata})
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, metadata, result.Metadata)
+	assert.Equal(t, "ticket", result.Kind)
+	mocks.assertExpectations(t)
 }
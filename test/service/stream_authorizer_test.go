@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	"github.com/Verano-20/stage-zero/test/mocks/repository"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var streamAdmin = model.User{ID: 3, Email: "admin@example.com", Role: model.UserRoleAdmin}
+
+func createStreamAuthorizerWithMocks(t *testing.T) (service.StreamAuthorizer, *repository.MockUserRepository, *repository.MockSessionRepository, *repository.MockMembershipRepository) {
+	userRepository := repository.NewMockUserRepository()
+	sessionRepository := repository.NewMockSessionRepository()
+	membershipRepository := repository.NewMockMembershipRepository()
+	t.Cleanup(func() {
+		userRepository.AssertExpectations(t)
+		sessionRepository.AssertExpectations(t)
+		membershipRepository.AssertExpectations(t)
+	})
+	target := service.NewStreamAuthorizer(userRepository, sessionRepository, membershipRepository)
+	return target, userRepository, sessionRepository, membershipRepository
+}
+
+// Returns the context of a stream started by the organization member with session 1 and a token expiring at expiresAt.
+func createStreamContext(expiresAt time.Time) context.Context {
+	ctx := createSimpleContext(&organizationMember, model.OrganizationRoleMember)
+	ctx = utils.WithSessionID(ctx, 1)
+	return utils.WithTokenExpiry(ctx, expiresAt)
+}
+
+func TestReauthorize_ReturnsCurrentMembership(t *testing.T) {
+	// given
+	ctx := createStreamContext(time.Now().Add(time.Hour))
+	target, userRepository, sessionRepository, membershipRepository := createStreamAuthorizerWithMocks(t)
+	user := organizationMember
+	membership := createMembership(organizationMember.ID, model.OrganizationRoleAdmin)
+	// expect
+	userRepository.On("GetByID", ctx, organizationMember.ID).Return(&user, nil).Once()
+	sessionRepository.On("GetByID", ctx, uint(1)).Return(createSession(1, organizationMember.ID), nil).Once()
+	membershipRepository.On("Get", ctx, organizationID, organizationMember.ID).Return(membership, nil).Once()
+	// when
+	result, err := target.Reauthorize(ctx)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, membership, result)
+}
+
+func TestReauthorize_Rejected(t *testing.T) {
+	now := time.Now()
+	revokedSession := createSession(1, organizationMember.ID)
+	revokedSession.RevokedAt = &now
+
+	tests := []struct {
+		testName  string
+		expiresAt time.Time
+		user      model.User
+		session   *model.Session
+		leftOrg   bool
+		wantError string
+	}{
+		{testName: "token expired", expiresAt: now.Add(-time.Second), wantError: "token expired"},
+		{testName: "user disabled", expiresAt: now.Add(time.Hour), user: model.User{ID: organizationMember.ID, DisabledAt: &now}, wantError: "account disabled"},
+		{testName: "tokens revoked", expiresAt: now.Add(time.Hour), user: model.User{ID: organizationMember.ID, TokensValidAfter: &now}, wantError: "token revoked"},
+		{testName: "session revoked", expiresAt: now.Add(time.Hour), user: organizationMember, session: revokedSession, wantError: "session revoked"},
+		{testName: "left organization", expiresAt: now.Add(time.Hour), user: organizationMember, session: createSession(1, organizationMember.ID), leftOrg: true, wantError: "not a member of this organization"},
+	}
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := createStreamContext(test.expiresAt)
+			target, userRepository, sessionRepository, membershipRepository := createStreamAuthorizerWithMocks(t)
+			user := test.user
+			// expect
+			if test.user.ID != 0 {
+				userRepository.On("GetByID", ctx, organizationMember.ID).Return(&user, nil).Once()
+			}
+			if test.session != nil {
+				sessionRepository.On("GetByID", ctx, uint(1)).Return(test.session, nil).Once()
+			}
+			if test.leftOrg {
+				membershipRepository.On("Get", ctx, organizationID, organizationMember.ID).Return(nil, gorm.ErrRecordNotFound).Once()
+			}
+			// when
+			membership, err := target.Reauthorize(ctx)
+			// then
+			assert.EqualError(t, err, test.wantError)
+			assert.Nil(t, membership)
+		})
+	}
+}
+
+func TestReauthorize_Impersonation(t *testing.T) {
+	tests := []struct {
+		testName  string
+		actor     model.User
+		wantError string
+	}{
+		{testName: "actor still admin", actor: streamAdmin},
+		{testName: "actor no longer admin", actor: model.User{ID: streamAdmin.ID}, wantError: "impersonation no longer allowed"},
+	}
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := utils.WithAuthenticatedUser(context.Background(), &organizationMember, &streamAdmin)
+			target, userRepository, _, _ := createStreamAuthorizerWithMocks(t)
+			user := organizationMember
+			actor := test.actor
+			// expect
+			userRepository.On("GetByID", ctx, organizationMember.ID).Return(&user, nil).Once()
+			userRepository.On("GetByID", ctx, streamAdmin.ID).Return(&actor, nil).Once()
+			// when
+			_, err := target.Reauthorize(ctx)
+			// then
+			if test.wantError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, test.wantError)
+		})
+	}
+}
+
+func TestReauthorize_MembershipError(t *testing.T) {
+	// given
+	ctx := createStreamContext(time.Now().Add(time.Hour))
+	target, userRepository, sessionRepository, membershipRepository := createStreamAuthorizerWithMocks(t)
+	user := organizationMember
+	// expect
+	userRepository.On("GetByID", ctx, organizationMember.ID).Return(&user, nil).Once()
+	sessionRepository.On("GetByID", ctx, uint(1)).Return(createSession(1, organizationMember.ID), nil).Once()
+	membershipRepository.On("Get", ctx, organizationID, organizationMember.ID).Return(nil, errors.New("database error")).Once()
+	// when
+	membership, err := target.Reauthorize(ctx)
+	// then
+	assert.EqualError(t, err, "database error")
+	assert.Nil(t, membership)
+}
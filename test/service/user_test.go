@@ -1,25 +1,69 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	apiError "github.com/Verano-20/stage-zero/internal/err"
+	"github.com/Verano-20/stage-zero/internal/mail"
 	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/password"
 	"github.com/Verano-20/stage-zero/internal/service"
+	"github.com/Verano-20/stage-zero/internal/utils"
+	mockMail "github.com/Verano-20/stage-zero/test/mocks/mail"
 	"github.com/Verano-20/stage-zero/test/mocks/repository"
 	"github.com/Verano-20/stage-zero/test/testutils"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
+const emailVerificationURL = "http://localhost:3000/auth/verify-email"
+
 func createUserServiceWithMockDependencies(t *testing.T) (service.UserService, *repository.MockUserRepository) {
+	target, mockRepo, _, _ := createUserServiceWithAllMockDependencies(t)
+	return target, mockRepo
+}
+
+func createUserServiceWithAllMockDependencies(t *testing.T) (service.UserService, *repository.MockUserRepository, *repository.MockEmailVerificationRepository, *mockMail.MockSender) {
+	target, mockRepo, emailVerificationRepository, _, mailSender := createUserServiceWithPasswordPolicy(t, createPasswordPolicy(0))
+	return target, mockRepo, emailVerificationRepository, mailSender
+}
+
+func createUserServiceWithPasswordPolicy(t *testing.T, passwordPolicy password.Policy) (service.UserService, *repository.MockUserRepository, *repository.MockEmailVerificationRepository, *repository.MockPasswordHistoryRepository, *mockMail.MockSender) {
+	target, mockRepo, emailVerificationRepository, passwordHistoryRepository, _, mailSender := createUserServiceWithMocks(t, passwordPolicy)
+	return target, mockRepo, emailVerificationRepository, passwordHistoryRepository, mailSender
+}
+
+func createUserServiceWithMockSessionRepository(t *testing.T, passwordPolicy password.Policy) (service.UserService, *repository.MockUserRepository, *repository.MockPasswordHistoryRepository, *repository.MockSessionRepository) {
+	target, mockRepo, _, passwordHistoryRepository, sessionRepository, _ := createUserServiceWithMocks(t, passwordPolicy)
+	return target, mockRepo, passwordHistoryRepository, sessionRepository
+}
+
+func createUserServiceWithMocks(t *testing.T, passwordPolicy password.Policy) (service.UserService, *repository.MockUserRepository, *repository.MockEmailVerificationRepository, *repository.MockPasswordHistoryRepository, *repository.MockSessionRepository, *mockMail.MockSender) {
 	mockRepo := repository.NewMockUserRepository()
+	emailVerificationRepository := repository.NewMockEmailVerificationRepository()
+	passwordHistoryRepository := repository.NewMockPasswordHistoryRepository()
+	sessionRepository := repository.NewMockSessionRepository()
+	txManager := repository.NewMockTxManager()
+	txManager.On("WithinTransaction", mock.Anything).Return(nil).Maybe()
+	mailSender := mockMail.NewMockSender()
 	defer mockRepo.AssertExpectations(t)
-	target := service.NewUserService(mockRepo)
-	return target, mockRepo
+	defer emailVerificationRepository.AssertExpectations(t)
+	defer passwordHistoryRepository.AssertExpectations(t)
+	defer sessionRepository.AssertExpectations(t)
+	defer mailSender.AssertExpectations(t)
+	target := service.NewUserService(txManager, mockRepo, emailVerificationRepository, passwordHistoryRepository, sessionRepository, repository.NewMockMembershipRepository(), mailSender, testutils.PasswordHasher, passwordPolicy, emailVerificationURL, 24*time.Hour)
+	return target, mockRepo, emailVerificationRepository, passwordHistoryRepository, sessionRepository, mailSender
+}
+
+func createPasswordPolicy(historySize int) password.Policy {
+	return password.NewPolicy(password.PolicyConfig{MinLength: 8, MaxLength: 256, ForbidEmail: true, HistorySize: historySize}, testutils.PasswordHasher, nil)
 }
 
 /*
@@ -28,7 +72,7 @@ func createUserServiceWithMockDependencies(t *testing.T) (service.UserService, *
 
 func TestCreateUser_Success(t *testing.T) {
 	// given
-	ctx, _ := testutils.CreateTestContext()
+	ctx := context.Background()
 	target, userRepository := createUserServiceWithMockDependencies(t)
 	expectedUser := testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1)
 	// expect
@@ -42,26 +86,61 @@ func TestCreateUser_Success(t *testing.T) {
 	assert.Equal(t, expectedUser, result)
 }
 
-func TestCreateUser_PasswordHashError(t *testing.T) {
+func TestCreateUser_LongPassword(t *testing.T) {
 	// given
-	ctx, _ := testutils.CreateTestContext()
+	ctx := context.Background()
 	target, userRepository := createUserServiceWithMockDependencies(t)
-	userForm := model.UserForm{Email: testutils.UserForm1.Email, Password: strings.Repeat("A", 73)} // length > 72 causes error
+	userForm := model.UserForm{Email: testutils.UserForm1.Email, Password: strings.Repeat("A", 100)}
+	// expect
+	userRepository.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(&model.User{ID: 1, Email: userForm.Email}, nil).Once()
 	// when
 	result, err := target.CreateUser(ctx, userForm)
 	// then
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	// and
+	createdUser := userRepository.Calls[0].Arguments.Get(1).(*model.User)
+	assert.NoError(t, testutils.PasswordHasher.Verify(userForm.Password, createdUser.PasswordHash))
+	assert.Error(t, testutils.PasswordHasher.Verify(strings.Repeat("A", 99), createdUser.PasswordHash))
+}
+
+func TestCreateUser_PasswordHashError(t *testing.T) {
+	// given
+	ctx := context.Background()
+	userRepository := repository.NewMockUserRepository()
+	target := service.NewUserService(repository.NewMockTxManager(), userRepository, repository.NewMockEmailVerificationRepository(), repository.NewMockPasswordHistoryRepository(), repository.NewMockSessionRepository(), repository.NewMockMembershipRepository(), mockMail.NewMockSender(), password.NewBcryptHasher(bcrypt.MaxCost+1), createPasswordPolicy(0), emailVerificationURL, 24*time.Hour)
+	// when
+	result, err := target.CreateUser(ctx, testutils.UserForm1)
+	// then
 	apiErr, ok := err.(*apiError.ApiError)
 	assert.True(t, ok)
 	assert.Equal(t, apiError.ErrorTypePasswordHash, apiErr.Type)
-	assert.Contains(t, err.Error(), "password length exceeds 72 bytes")
+	assert.Contains(t, err.Error(), "cost 32 is outside allowed")
 	assert.Nil(t, result)
 	// and
 	userRepository.AssertNotCalled(t, "Create", ctx, mock.Anything)
 }
 
+func TestCreateUser_PasswordPolicyViolation(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository := createUserServiceWithMockDependencies(t)
+	userForm := model.UserForm{Email: testutils.UserForm1.Email, Password: "myTest1Password"}
+	// when
+	result, err := target.CreateUser(ctx, userForm)
+	// then
+	apiErr, ok := err.(*apiError.ApiError)
+	assert.True(t, ok)
+	assert.Equal(t, apiError.ErrorTypePasswordPolicy, apiErr.Type)
+	assert.Contains(t, apiErr.Details, password.RuleContainsEmail)
+	assert.Nil(t, result)
+	// and
+	userRepository.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
 func TestCreateUser_EmailExists(t *testing.T) {
 	// given
-	ctx, _ := testutils.CreateTestContext()
+	ctx := context.Background()
 	target, userRepository := createUserServiceWithMockDependencies(t)
 	userForm := testutils.UserForm1
 	errorMessage := "email already exists"
@@ -82,7 +161,7 @@ func TestCreateUser_EmailExists(t *testing.T) {
 
 func TestCreateUser_DatabaseError(t *testing.T) {
 	// given
-	ctx, _ := testutils.CreateTestContext()
+	ctx := context.Background()
 	target, userRepository := createUserServiceWithMockDependencies(t)
 	expectedError := errors.New("database error")
 	// expect
@@ -97,13 +176,47 @@ func TestCreateUser_DatabaseError(t *testing.T) {
 	assert.Nil(t, result)
 }
 
+/*
+ * Get User By ID Tests
+ */
+
+func TestGetUserByID_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository := createUserServiceWithMockDependencies(t)
+	expectedUser := testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1)
+	expectedUser.ID = 1
+	// expect
+	userRepository.On("GetByID", ctx, expectedUser.ID).Return(expectedUser, nil).Once()
+	// when
+	result, err := target.GetUserByID(ctx, expectedUser.ID)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, expectedUser, result)
+}
+
+func TestGetUserByID_Error(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository := createUserServiceWithMockDependencies(t)
+	expectedError := errors.New("database error")
+	// expect
+	userRepository.On("GetByID", ctx, uint(1)).Return(nil, expectedError).Once()
+	// when
+	result, err := target.GetUserByID(ctx, 1)
+	// then
+	assert.Error(t, err)
+	assert.Equal(t, expectedError, err)
+	assert.Nil(t, result)
+}
+
 /*
  * Get User By Email Tests
  */
 
 func TestGetUserByEmail_Success(t *testing.T) {
 	// given
-	ctx, _ := testutils.CreateTestContext()
+	ctx := context.Background()
 	target, userRepository := createUserServiceWithMockDependencies(t)
 	expectedUser := testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1)
 	// expect
@@ -117,7 +230,7 @@ func TestGetUserByEmail_Success(t *testing.T) {
 
 func TestGetUserByEmail_Error(t *testing.T) {
 	// given
-	ctx, _ := testutils.CreateTestContext()
+	ctx := context.Background()
 	target, userRepository := createUserServiceWithMockDependencies(t)
 	expectedError := errors.New("database error")
 	// expect
@@ -129,3 +242,559 @@ func TestGetUserByEmail_Error(t *testing.T) {
 	assert.Equal(t, expectedError, err)
 	assert.Nil(t, result)
 }
+
+/*
+ * Change Password Tests
+ */
+
+func TestChangePassword_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository, _, sessionRepository := createUserServiceWithMockSessionRepository(t, createPasswordPolicy(0))
+	user := testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1)
+	form := model.ChangePasswordForm{CurrentPassword: testutils.UserForm1.Password, NewPassword: "newPassword1"}
+	// expect
+	userRepository.On("Update", ctx, user).Return(user, nil).Once()
+	sessionRepository.On("RevokeAllByUserID", ctx, user.ID).Return(nil).Once()
+	// when
+	result, err := target.ChangePassword(ctx, user, form)
+	// then
+	assert.NoError(t, err)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(result.PasswordHash), []byte(form.NewPassword)))
+	assert.NotNil(t, result.TokensValidAfter)
+	assert.WithinDuration(t, time.Now(), *result.TokensValidAfter, time.Second)
+}
+
+func TestChangePassword_RevokeSessionsError(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository, _, sessionRepository := createUserServiceWithMockSessionRepository(t, createPasswordPolicy(0))
+	user := testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1)
+	form := model.ChangePasswordForm{CurrentPassword: testutils.UserForm1.Password, NewPassword: "newPassword1"}
+	expectedError := errors.New("database error")
+	// expect
+	userRepository.On("Update", ctx, user).Return(user, nil).Once()
+	sessionRepository.On("RevokeAllByUserID", ctx, user.ID).Return(expectedError).Once()
+	// when
+	result, err := target.ChangePassword(ctx, user, form)
+	// then
+	assert.Equal(t, expectedError, err)
+	assert.Nil(t, result)
+}
+
+func TestChangePassword_RecordsPasswordHistory(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository, passwordHistoryRepository, sessionRepository := createUserServiceWithMockSessionRepository(t, createPasswordPolicy(3))
+	user := testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1)
+	previousHash := user.PasswordHash
+	form := model.ChangePasswordForm{CurrentPassword: testutils.UserForm1.Password, NewPassword: "newPassword1"}
+	// expect
+	passwordHistoryRepository.On("ListRecent", ctx, user.ID, 2).Return([]*model.PasswordHistoryEntry{}, nil).Once()
+	userRepository.On("Update", ctx, user).Return(user, nil).Once()
+	sessionRepository.On("RevokeAllByUserID", ctx, user.ID).Return(nil).Once()
+	passwordHistoryRepository.On("Create", ctx, &model.PasswordHistoryEntry{UserID: user.ID, PasswordHash: previousHash}).Return(&model.PasswordHistoryEntry{ID: 1}, nil).Once()
+	// when
+	result, err := target.ChangePassword(ctx, user, form)
+	// then
+	assert.NoError(t, err)
+	assert.NoError(t, testutils.PasswordHasher.Verify(form.NewPassword, result.PasswordHash))
+}
+
+func TestChangePassword_ReusedPassword(t *testing.T) {
+	oldHash, _ := testutils.PasswordHasher.Hash("oldPassword1")
+	tests := []struct {
+		testName       string
+		historySize    int
+		newPassword    string
+		historyEntries []*model.PasswordHistoryEntry
+	}{
+		{
+			testName:    "Current Password",
+			historySize: 1,
+			newPassword: testutils.UserForm1.Password,
+		},
+		{
+			testName:       "Previous Password",
+			historySize:    3,
+			newPassword:    "oldPassword1",
+			historyEntries: []*model.PasswordHistoryEntry{{UserID: 1, PasswordHash: oldHash}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := context.Background()
+			target, userRepository, _, passwordHistoryRepository, _ := createUserServiceWithPasswordPolicy(t, createPasswordPolicy(test.historySize))
+			user := testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1)
+			user.ID = 1
+			form := model.ChangePasswordForm{CurrentPassword: testutils.UserForm1.Password, NewPassword: test.newPassword}
+			// expect
+			if test.historyEntries != nil {
+				passwordHistoryRepository.On("ListRecent", ctx, user.ID, test.historySize-1).Return(test.historyEntries, nil).Once()
+			}
+			// when
+			result, err := target.ChangePassword(ctx, user, form)
+			// then
+			apiErr, ok := err.(*apiError.ApiError)
+			assert.True(t, ok)
+			assert.Equal(t, apiError.ErrorTypePasswordPolicy, apiErr.Type)
+			assert.Contains(t, apiErr.Details, password.RuleReused)
+			assert.Nil(t, result)
+			// and
+			userRepository.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+			passwordHistoryRepository.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestChangePassword_PasswordHistoryError(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository, _, passwordHistoryRepository, _ := createUserServiceWithPasswordPolicy(t, createPasswordPolicy(3))
+	user := testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1)
+	form := model.ChangePasswordForm{CurrentPassword: testutils.UserForm1.Password, NewPassword: "newPassword1"}
+	expectedError := errors.New("database error")
+	// expect
+	passwordHistoryRepository.On("ListRecent", ctx, user.ID, 2).Return(nil, expectedError).Once()
+	// when
+	result, err := target.ChangePassword(ctx, user, form)
+	// then
+	assert.Equal(t, expectedError, err)
+	assert.Nil(t, result)
+	// and
+	userRepository.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestChangePassword_RecordPasswordHistoryError(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository, passwordHistoryRepository, sessionRepository := createUserServiceWithMockSessionRepository(t, createPasswordPolicy(3))
+	user := testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1)
+	form := model.ChangePasswordForm{CurrentPassword: testutils.UserForm1.Password, NewPassword: "newPassword1"}
+	expectedError := errors.New("database error")
+	// expect
+	passwordHistoryRepository.On("ListRecent", ctx, user.ID, 2).Return([]*model.PasswordHistoryEntry{}, nil).Once()
+	userRepository.On("Update", ctx, user).Return(user, nil).Once()
+	sessionRepository.On("RevokeAllByUserID", ctx, user.ID).Return(nil).Once()
+	passwordHistoryRepository.On("Create", ctx, mock.AnythingOfType("*model.PasswordHistoryEntry")).Return(nil, expectedError).Once()
+	// when
+	result, err := target.ChangePassword(ctx, user, form)
+	// then
+	assert.Equal(t, expectedError, err)
+	assert.Nil(t, result)
+}
+
+func TestChangePassword_InvalidCurrentPassword(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository := createUserServiceWithMockDependencies(t)
+	user := testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1)
+	form := model.ChangePasswordForm{CurrentPassword: "wrongPassword", NewPassword: "newPassword1"}
+	// when
+	result, err := target.ChangePassword(ctx, user, form)
+	// then
+	apiErr, ok := err.(*apiError.ApiError)
+	assert.True(t, ok)
+	assert.Equal(t, apiError.ErrorTypeInvalidCredentials, apiErr.Type)
+	assert.Nil(t, result)
+	assert.Nil(t, user.TokensValidAfter)
+	// and
+	userRepository.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+/*
+ * Request Email Change Tests
+ */
+
+func TestRequestEmailChange_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository, emailVerificationRepository, mailSender := createUserServiceWithAllMockDependencies(t)
+	user := testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1)
+	user.ID = 1
+	form := model.ChangeEmailForm{Email: testutils.UserForm2.Email, CurrentPassword: testutils.UserForm1.Password}
+	// expect
+	userRepository.On("GetByEmail", ctx, form.Email).Return(nil, gorm.ErrRecordNotFound).Once()
+	emailVerificationRepository.On("Create", ctx, mock.MatchedBy(func(verification *model.EmailVerification) bool {
+		return verification.UserID == user.ID && verification.Email == form.Email && verification.TokenHash != ""
+	})).Return(&model.EmailVerification{ID: 1, UserID: user.ID, Email: form.Email}, nil).Once()
+	mailSender.On("Send", ctx, mock.MatchedBy(func(message mail.Message) bool {
+		return message.To == form.Email && strings.Contains(message.Body, emailVerificationURL+"?token=")
+	})).Return(nil).Once()
+	// when
+	err := target.RequestEmailChange(ctx, user, form)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, testutils.UserForm1.Email, user.Email)
+}
+
+func TestRequestEmailChange_EmailExists(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository, emailVerificationRepository, mailSender := createUserServiceWithAllMockDependencies(t)
+	user := testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1)
+	form := model.ChangeEmailForm{Email: testutils.UserForm2.Email, CurrentPassword: testutils.UserForm1.Password}
+	// expect
+	userRepository.On("GetByEmail", ctx, form.Email).Return(&model.User{ID: 2, Email: form.Email}, nil).Once()
+	// when
+	err := target.RequestEmailChange(ctx, user, form)
+	// then
+	apiErr, ok := err.(*apiError.ApiError)
+	assert.True(t, ok)
+	assert.Equal(t, apiError.ErrorTypeEmailExists, apiErr.Type)
+	// and
+	emailVerificationRepository.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mailSender.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+}
+
+func TestRequestEmailChange_InvalidCurrentPassword(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository := createUserServiceWithMockDependencies(t)
+	user := testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1)
+	form := model.ChangeEmailForm{Email: testutils.UserForm2.Email, CurrentPassword: "wrongPassword"}
+	// when
+	err := target.RequestEmailChange(ctx, user, form)
+	// then
+	apiErr, ok := err.(*apiError.ApiError)
+	assert.True(t, ok)
+	assert.Equal(t, apiError.ErrorTypeInvalidCredentials, apiErr.Type)
+	// and
+	userRepository.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+}
+
+/*
+ * Verify Email Change Tests
+ */
+
+func TestVerifyEmailChange_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository, emailVerificationRepository, _ := createUserServiceWithAllMockDependencies(t)
+	user := &model.User{ID: 1, Email: testutils.UserForm1.Email}
+	token := "email-verification-token"
+	// expect
+	emailVerificationRepository.On("Consume", ctx, utils.HashToken(token)).Return(&model.EmailVerification{ID: 1, UserID: user.ID, Email: testutils.UserForm2.Email}, nil).Once()
+	userRepository.On("GetByID", ctx, user.ID).Return(user, nil).Once()
+	userRepository.On("Update", ctx, mock.MatchedBy(func(updated *model.User) bool {
+		return updated.Email == testutils.UserForm2.Email
+	})).Return(user, nil).Once()
+	// when
+	result, err := target.VerifyEmailChange(ctx, token)
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, testutils.UserForm2.Email, result.Email)
+}
+
+func TestVerifyEmailChange_InvalidToken(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository, emailVerificationRepository, _ := createUserServiceWithAllMockDependencies(t)
+	token := "used-or-expired-token"
+	// expect
+	emailVerificationRepository.On("Consume", ctx, utils.HashToken(token)).Return(nil, gorm.ErrRecordNotFound).Once()
+	// when
+	result, err := target.VerifyEmailChange(ctx, token)
+	// then
+	apiErr, ok := err.(*apiError.ApiError)
+	assert.True(t, ok)
+	assert.Equal(t, apiError.ErrorTypeEmailVerification, apiErr.Type)
+	assert.Nil(t, result)
+	// and
+	userRepository.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+/*
+ * Delete User Tests
+ */
+
+func createUserServiceWithMockMembershipRepository(t *testing.T) (service.UserService, *repository.MockUserRepository, *repository.MockMembershipRepository) {
+	txManager := repository.NewMockTxManager()
+	txManager.On("WithinTransaction", mock.Anything).Return(nil).Maybe()
+	userRepository := repository.NewMockUserRepository()
+	membershipRepository := repository.NewMockMembershipRepository()
+	t.Cleanup(func() {
+		userRepository.AssertExpectations(t)
+		membershipRepository.AssertExpectations(t)
+	})
+	target := service.NewUserService(txManager, userRepository, repository.NewMockEmailVerificationRepository(), repository.NewMockPasswordHistoryRepository(), repository.NewMockSessionRepository(), membershipRepository, mockMail.NewMockSender(), testutils.PasswordHasher, createPasswordPolicy(0), emailVerificationURL, 24*time.Hour)
+	return target, userRepository, membershipRepository
+}
+
+func TestDeleteUser_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository, membershipRepository := createUserServiceWithMockMembershipRepository(t)
+	user := &model.User{ID: 42, Email: testutils.UserForm1.Email}
+	// expect
+	membershipRepository.On("ListByUserID", ctx, user.ID).Return(model.Memberships{}, nil).Once()
+	userRepository.On("Delete", ctx, mock.MatchedBy(func(deleted *model.User) bool {
+		return deleted.ID == user.ID && deleted.Email == "deleted-user-42@deleted.invalid"
+	})).Return(nil).Once()
+	// when
+	err := target.DeleteUser(ctx, user)
+	// then
+	assert.NoError(t, err)
+}
+
+func TestDeleteUser_RemovesMemberships(t *testing.T) {
+	tests := []struct {
+		testName string
+		role     string
+		owners   int64
+		members  int
+	}{
+		{testName: "Member", role: model.OrganizationRoleMember},
+		{testName: "Owner With Another Owner", role: model.OrganizationRoleOwner, owners: 2},
+		{testName: "Only Member", role: model.OrganizationRoleOwner, owners: 1, members: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := context.Background()
+			target, userRepository, membershipRepository := createUserServiceWithMockMembershipRepository(t)
+			user := &model.User{ID: organizationUser.ID, Email: organizationUser.Email}
+			membership := createMembership(user.ID, test.role)
+			// expect
+			membershipRepository.On("ListByUserID", ctx, user.ID).Return(model.Memberships{membership}, nil).Once()
+			if test.owners != 0 {
+				membershipRepository.On("LockOwners", ctx, organizationID).Return(test.owners, nil).Once()
+			}
+			if test.members != 0 {
+				membershipRepository.On("ListByOrganizationID", ctx, organizationID).Return(model.Memberships{membership}, nil).Once()
+			}
+			membershipRepository.On("Delete", ctx, membership).Return(nil).Once()
+			userRepository.On("Delete", ctx, user).Return(nil).Once()
+			// when
+			err := target.DeleteUser(ctx, user)
+			// then
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestDeleteUser_LastOwner(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository, membershipRepository := createUserServiceWithMockMembershipRepository(t)
+	user := &model.User{ID: organizationUser.ID, Email: organizationUser.Email}
+	membership := createMembership(user.ID, model.OrganizationRoleOwner)
+	// expect
+	membershipRepository.On("ListByUserID", ctx, user.ID).Return(model.Memberships{membership}, nil).Once()
+	membershipRepository.On("LockOwners", ctx, organizationID).Return(int64(1), nil).Once()
+	membershipRepository.On("ListByOrganizationID", ctx, organizationID).Return(model.Memberships{membership, createMembership(organizationMember.ID, model.OrganizationRoleMember)}, nil).Once()
+	// when
+	err := target.DeleteUser(ctx, user)
+	// then
+	assertApiErrorType(t, err, apiError.ErrorTypeLastOwner)
+	membershipRepository.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	userRepository.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestDeleteUser_DatabaseError(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository, membershipRepository := createUserServiceWithMockMembershipRepository(t)
+	user := &model.User{ID: 42, Email: testutils.UserForm1.Email}
+	expectedError := errors.New("database error")
+	// expect
+	membershipRepository.On("ListByUserID", ctx, user.ID).Return(model.Memberships{}, nil).Once()
+	userRepository.On("Delete", ctx, mock.Anything).Return(expectedError).Once()
+	// when
+	err := target.DeleteUser(ctx, user)
+	// then
+	assert.Equal(t, expectedError, err)
+}
+
+/*
+ * List Users Tests
+ */
+
+func TestListUsers_AppliesDefaultPaging(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository := createUserServiceWithMockDependencies(t)
+	users := model.Users{{ID: 1, Email: testutils.UserForm1.Email}}
+	// expect
+	userRepository.On("List", ctx, model.UserFilter{Search: "example", Page: 1, PageSize: 20}).Return(users, int64(1), nil).Once()
+	// when
+	result, total, err := target.ListUsers(ctx, model.UserFilter{Search: "example"})
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, users, result)
+	assert.Equal(t, int64(1), total)
+}
+
+/*
+ * Admin Account Status Tests
+ */
+
+func TestDisableUser_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository, _, sessionRepository := createUserServiceWithMockSessionRepository(t, createPasswordPolicy(0))
+	user := &model.User{ID: 1, Email: testutils.UserForm1.Email}
+	// expect
+	userRepository.On("Update", ctx, user).Return(user, nil).Once()
+	sessionRepository.On("RevokeAllByUserID", ctx, user.ID).Return(nil).Once()
+	// when
+	result, err := target.DisableUser(ctx, user)
+	// then
+	assert.NoError(t, err)
+	assert.True(t, result.IsDisabled())
+	assert.NotNil(t, result.TokensValidAfter)
+}
+
+func TestEnableUser_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository := createUserServiceWithMockDependencies(t)
+	disabledAt := time.Now()
+	user := &model.User{ID: 1, Email: testutils.UserForm1.Email, DisabledAt: &disabledAt}
+	// expect
+	userRepository.On("Update", ctx, user).Return(user, nil).Once()
+	// when
+	result, err := target.EnableUser(ctx, user)
+	// then
+	assert.NoError(t, err)
+	assert.False(t, result.IsDisabled())
+}
+
+func TestForcePasswordReset_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository, _, sessionRepository := createUserServiceWithMockSessionRepository(t, createPasswordPolicy(0))
+	user := &model.User{ID: 1, Email: testutils.UserForm1.Email}
+	// expect
+	userRepository.On("Update", ctx, user).Return(user, nil).Once()
+	sessionRepository.On("RevokeAllByUserID", ctx, user.ID).Return(nil).Once()
+	// when
+	result, err := target.ForcePasswordReset(ctx, user)
+	// then
+	assert.NoError(t, err)
+	assert.True(t, result.PasswordResetRequired)
+	assert.NotNil(t, result.TokensValidAfter)
+}
+
+func TestRevokingAccountActions_RevokeSessionsInUpdateTransaction(t *testing.T) {
+	tests := []struct {
+		testName string
+		action   func(target service.UserService, ctx context.Context, user *model.User) (*model.User, error)
+	}{
+		{testName: "Disable User", action: service.UserService.DisableUser},
+		{testName: "Force Password Reset", action: service.UserService.ForcePasswordReset},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			// given
+			ctx := context.Background()
+			txManager := repository.NewMockTxManager()
+			userRepository := repository.NewMockUserRepository()
+			sessionRepository := repository.NewMockSessionRepository()
+			target := service.NewUserService(txManager, userRepository, repository.NewMockEmailVerificationRepository(), repository.NewMockPasswordHistoryRepository(), sessionRepository, repository.NewMockMembershipRepository(), mockMail.NewMockSender(), testutils.PasswordHasher, createPasswordPolicy(0), emailVerificationURL, 24*time.Hour)
+			user := &model.User{ID: 1, Email: testutils.UserForm1.Email}
+			// expect
+			txManager.On("WithinTransaction", ctx).Return(nil).Once()
+			userRepository.On("Update", ctx, user).Return(user, nil).Once()
+			sessionRepository.On("RevokeAllByUserID", ctx, user.ID).Return(errors.New("database error")).Once()
+			// when
+			result, err := test.action(target, ctx, user)
+			// then
+			// The update was made in the transaction the failed revocation rolls back.
+			assert.EqualError(t, err, "database error")
+			assert.Nil(t, result)
+			txManager.AssertExpectations(t)
+			userRepository.AssertExpectations(t)
+			sessionRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestChangePassword_ClearsPasswordResetRequired(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository, _, sessionRepository := createUserServiceWithMockSessionRepository(t, createPasswordPolicy(0))
+	user := testutils.GetUserWithPasswordHashFromForm(testutils.UserForm1)
+	user.PasswordResetRequired = true
+	form := model.ChangePasswordForm{CurrentPassword: testutils.UserForm1.Password, NewPassword: "newPassword1"}
+	// expect
+	userRepository.On("Update", ctx, user).Return(user, nil).Once()
+	sessionRepository.On("RevokeAllByUserID", ctx, user.ID).Return(nil).Once()
+	// when
+	result, err := target.ChangePassword(ctx, user, form)
+	// then
+	assert.NoError(t, err)
+	assert.False(t, result.PasswordResetRequired)
+}
+
+func TestRecordFailedLogin_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository := createUserServiceWithMockDependencies(t)
+	user := &model.User{ID: 1, Email: testutils.UserForm1.Email}
+	// expect
+	userRepository.On("RecordFailedLogin", ctx, user.ID, 5, 15*time.Minute).Return(nil).Once()
+	// when
+	err := target.RecordFailedLogin(ctx, user, 5, 15*time.Minute)
+	// then
+	assert.NoError(t, err)
+}
+
+func TestUnlockUser_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository := createUserServiceWithMockDependencies(t)
+	lockedUntil := time.Now().Add(time.Minute)
+	user := &model.User{ID: 1, Email: testutils.UserForm1.Email, FailedLoginAttempts: 5, LockedUntil: &lockedUntil}
+	// expect
+	userRepository.On("ResetFailedLogins", ctx, user.ID).Return(nil).Once()
+	// when
+	result, err := target.UnlockUser(ctx, user)
+	// then
+	assert.NoError(t, err)
+	assert.False(t, result.IsLocked())
+	assert.Equal(t, 0, result.FailedLoginAttempts)
+}
+
+/*
+ * Rehash Password Tests
+ */
+
+func TestRehashPassword_Success(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository := createUserServiceWithMockDependencies(t)
+	oldHash, _ := password.NewBcryptHasher(bcrypt.MinCost).Hash(testutils.UserForm1.Password)
+	user := &model.User{ID: 1, Email: testutils.UserForm1.Email, PasswordHash: oldHash}
+	// expect
+	userRepository.On("UpdatePasswordHash", ctx, user.ID, oldHash, mock.AnythingOfType("string")).Return(nil).Once()
+	// when
+	result, err := target.RehashPassword(ctx, user, testutils.UserForm1.Password)
+	// then
+	assert.NoError(t, err)
+	assert.NotEqual(t, oldHash, result.PasswordHash)
+	assert.False(t, testutils.PasswordHasher.NeedsRehash(result.PasswordHash))
+	assert.NoError(t, testutils.PasswordHasher.Verify(testutils.UserForm1.Password, result.PasswordHash))
+	assert.Nil(t, result.TokensValidAfter)
+}
+
+func TestRehashPassword_DatabaseError(t *testing.T) {
+	// given
+	ctx := context.Background()
+	target, userRepository := createUserServiceWithMockDependencies(t)
+	oldHash, _ := password.NewBcryptHasher(bcrypt.MinCost).Hash(testutils.UserForm1.Password)
+	user := &model.User{ID: 1, Email: testutils.UserForm1.Email, PasswordHash: oldHash}
+	expectedError := errors.New("database error")
+	// expect
+	userRepository.On("UpdatePasswordHash", ctx, user.ID, oldHash, mock.AnythingOfType("string")).Return(expectedError).Once()
+	// when
+	result, err := target.RehashPassword(ctx, user, testutils.UserForm1.Password)
+	// then
+	assert.Equal(t, expectedError, err)
+	assert.Nil(t, result)
+	assert.Equal(t, oldHash, user.PasswordHash)
+}
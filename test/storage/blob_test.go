@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Verano-20/stage-zero/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testBucket      = "attachments"
+	testAccessKeyID = "test-access-key"
+)
+
+var s3AuthorizationPattern = regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=` + testAccessKeyID + `/\d{8}/us-east-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=[0-9a-f]{64}$`)
+
+// s3StandIn is a minimal in-memory S3 compatible server for path style object requests.
+type s3StandIn struct {
+	mutex    sync.Mutex
+	objects  map[string][]byte
+	requests []*http.Request
+}
+
+func (s *s3StandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.requests = append(s.requests, r)
+
+	if !s3AuthorizationPattern.MatchString(r.Header.Get("Authorization")) || r.Header.Get("X-Amz-Date") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+testBucket+"/")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		s.objects[key] = body
+	case http.MethodGet, http.MethodHead:
+		object, found := s.objects[key]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodGet {
+			w.Write(object)
+		}
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func createBlobStores(t *testing.T) map[string]storage.BlobStore {
+	localBlobStore, err := storage.NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	server := httptest.NewServer(&s3StandIn{objects: make(map[string][]byte)})
+	t.Cleanup(server.Close)
+	s3BlobStore, err := storage.NewS3BlobStore(storage.S3Config{
+		Endpoint:        server.URL,
+		Region:          "us-east-1",
+		Bucket:          testBucket,
+		AccessKeyID:     testAccessKeyID,
+		SecretAccessKey: "test-secret-key",
+		UsePathStyle:    true,
+	}, server.Client())
+	require.NoError(t, err)
+
+	return map[string]storage.BlobStore{
+		storage.BackendLocal: localBlobStore,
+		storage.BackendS3:    s3BlobStore,
+	}
+}
+
+/*
+ * Blob Store Tests
+ */
+
+func TestBlobStore_PutGetExistsDelete(t *testing.T) {
+	for backend, target := range createBlobStores(t) {
+		t.Run(backend, func(t *testing.T) {
+			// given
+			ctx := context.Background()
+			key := "organizations/7/sha256/ab/abcdef"
+			content := "attachment content"
+			// when
+			putErr := target.Put(ctx, key, strings.NewReader(content), int64(len(content)))
+			exists, existsErr := target.Exists(ctx, key)
+			reader, getErr := target.Get(ctx, key)
+			// then
+			require.NoError(t, putErr)
+			require.NoError(t, existsErr)
+			require.NoError(t, getErr)
+			assert.True(t, exists)
+			stored, _ := io.ReadAll(reader)
+			reader.Close()
+			assert.Equal(t, content, string(stored))
+
+			// when
+			deleteErr := target.Delete(ctx, key)
+			exists, existsErr = target.Exists(ctx, key)
+			_, getErr = target.Get(ctx, key)
+			// then
+			assert.NoError(t, deleteErr)
+			assert.NoError(t, existsErr)
+			assert.False(t, exists)
+			assert.ErrorIs(t, getErr, storage.ErrBlobNotFound)
+		})
+	}
+}
+
+func TestBlobStore_PutReplacesContent(t *testing.T) {
+	for backend, target := range createBlobStores(t) {
+		t.Run(backend, func(t *testing.T) {
+			// given
+			ctx := context.Background()
+			require.NoError(t, target.Put(ctx, "blob", strings.NewReader("first"), 5))
+			// when
+			err := target.Put(ctx, "blob", strings.NewReader("second"), 6)
+			// then
+			require.NoError(t, err)
+			reader, getErr := target.Get(ctx, "blob")
+			require.NoError(t, getErr)
+			stored, _ := io.ReadAll(reader)
+			reader.Close()
+			assert.Equal(t, "second", string(stored))
+		})
+	}
+}
+
+func TestBlobStore_DeleteMissingBlob(t *testing.T) {
+	for backend, target := range createBlobStores(t) {
+		t.Run(backend, func(t *testing.T) {
+			// when
+			err := target.Delete(context.Background(), "missing")
+			// then
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestBlobStore_RejectsInvalidKeys(t *testing.T) {
+	for backend, target := range createBlobStores(t) {
+		for _, key := range []string{"", "/absolute", "../escape", "nested/../../escape", "double//slash", `back\slash`} {
+			t.Run(backend+" "+key, func(t *testing.T) {
+				// when
+				err := target.Put(context.Background(), key, strings.NewReader("content"), 7)
+				// then
+				assert.ErrorIs(t, err, storage.ErrInvalidBlobKey)
+			})
+		}
+	}
+}
+
+func TestLocalBlobStore_RejectsShortContent(t *testing.T) {
+	// given
+	target, err := storage.NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+	// when
+	err = target.Put(context.Background(), "blob", strings.NewReader("short"), 10)
+	// then
+	assert.Error(t, err)
+	exists, _ := target.Exists(context.Background(), "blob")
+	assert.False(t, exists)
+}
+
+func TestS3BlobStore_SignsPathStyleRequests(t *testing.T) {
+	// given
+	standIn := &s3StandIn{objects: make(map[string][]byte)}
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+	target, err := storage.NewS3BlobStore(storage.S3Config{
+		Endpoint:        server.URL,
+		Region:          "us-east-1",
+		Bucket:          testBucket,
+		AccessKeyID:     testAccessKeyID,
+		SecretAccessKey: "test-secret-key",
+		UsePathStyle:    true,
+	}, server.Client())
+	require.NoError(t, err)
+	// when
+	err = target.Put(context.Background(), "organizations/7/file name", strings.NewReader("content"), 7)
+	// then
+	require.NoError(t, err)
+	require.Len(t, standIn.requests, 1)
+	request := standIn.requests[0]
+	assert.Equal(t, "/"+testBucket+"/organizations/7/file%20name", request.URL.EscapedPath())
+	assert.Equal(t, "UNSIGNED-PAYLOAD", request.Header.Get("X-Amz-Content-Sha256"))
+	assert.Equal(t, int64(7), request.ContentLength)
+	assert.Equal(t, []byte("content"), standIn.objects["organizations/7/file name"])
+}
+
+func TestS3BlobStore_ReportsServerErrors(t *testing.T) {
+	// given
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("<Error><Code>AccessDenied</Code></Error>"))
+	}))
+	defer server.Close()
+	target, err := storage.NewS3BlobStore(storage.S3Config{Endpoint: server.URL, Region: "us-east-1", Bucket: testBucket, UsePathStyle: true}, server.Client())
+	require.NoError(t, err)
+	// when
+	_, existsErr := target.Exists(context.Background(), "blob")
+	// then
+	assert.ErrorContains(t, existsErr, "status 403")
+}
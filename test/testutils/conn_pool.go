@@ -0,0 +1,103 @@
+package testutils
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrConnPool is the error a ConnPool fails queries with, unless it is given another.
+var ErrConnPool = errors.New("connection pool cannot execute statements")
+
+// ConnPool stands in for the connection pool of a database, so GORM can be used in tests without one. Statements are
+// never executed: queries fail with QueryErr, or ErrConnPool, and other statements succeed affecting one row, unless
+// ExecErr is set. Transactions begin, commit and roll back without doing anything.
+//
+// When Log is set, the pool appends every statement, and the start and end of every transaction, to it. Entries are
+// prefixed with the Name of the pool, if it has one, so pools sharing a log tell where statements were sent. The
+// statements of a transaction are prefixed with the pool's name followed by "tx".
+type ConnPool struct {
+	Name     string
+	QueryErr error
+	ExecErr  error
+	PingErr  error
+	Log      *[]string
+}
+
+// ConnPoolTx is a transaction begun on a ConnPool.
+type ConnPoolTx struct {
+	ConnPool
+	parent *ConnPool
+}
+
+var _ gorm.ConnPool = &ConnPool{}
+var _ gorm.TxCommitter = &ConnPoolTx{}
+
+func (p *ConnPool) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, p.queryErr()
+}
+
+func (p *ConnPool) ExecContext(_ context.Context, query string, _ ...any) (sql.Result, error) {
+	p.record(query)
+	if p.ExecErr != nil {
+		return nil, p.ExecErr
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (p *ConnPool) QueryContext(_ context.Context, query string, _ ...any) (*sql.Rows, error) {
+	p.record(query)
+	return nil, p.queryErr()
+}
+
+func (p *ConnPool) QueryRowContext(context.Context, string, ...any) *sql.Row {
+	return nil
+}
+
+func (p *ConnPool) PingContext(context.Context) error {
+	return p.PingErr
+}
+
+func (p *ConnPool) Close() error {
+	return nil
+}
+
+func (p *ConnPool) BeginTx(context.Context, *sql.TxOptions) (gorm.ConnPool, error) {
+	p.record("BEGIN")
+	tx := &ConnPoolTx{ConnPool: *p, parent: p}
+	tx.Name = "tx"
+	if p.Name != "" {
+		tx.Name = p.Name + " tx"
+	}
+	return tx, nil
+}
+
+func (tx *ConnPoolTx) Commit() error {
+	tx.parent.record("COMMIT")
+	return nil
+}
+
+func (tx *ConnPoolTx) Rollback() error {
+	tx.parent.record("ROLLBACK")
+	return nil
+}
+
+func (p *ConnPool) queryErr() error {
+	if p.QueryErr != nil {
+		return p.QueryErr
+	}
+	return ErrConnPool
+}
+
+func (p *ConnPool) record(statement string) {
+	if p.Log == nil {
+		return
+	}
+	if p.Name != "" {
+		statement = p.Name + ": " + statement
+	}
+	*p.Log = append(*p.Log, statement)
+}
@@ -1,25 +1,32 @@
 package testutils
 
 import (
+	"net/http"
 	"net/http/httptest"
+	"time"
 
 	"github.com/Verano-20/stage-zero/internal/model"
+	"github.com/Verano-20/stage-zero/internal/password"
+	"github.com/Verano-20/stage-zero/internal/utils"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 )
 
 var (
-	JwtSecret = []byte("test-secret-key")
-	UserForm1 = model.UserForm{Email: "test1@example.com", Password: "password1"}
-	UserForm2 = model.UserForm{Email: "test2@example.com", Password: "password2"}
-	Simple1   = model.Simple{ID: 1, Name: "Simple 1"}
-	Simple2   = model.Simple{ID: 2, Name: "Simple 2"}
+	JwtSecret      = []byte("test-secret-key")
+	PasswordHasher = password.NewBcryptHasher(bcrypt.DefaultCost)
+	UserForm1      = model.UserForm{Email: "test1@example.com", Password: "password1"}
+	UserForm2      = model.UserForm{Email: "test2@example.com", Password: "password2"}
+	Simple1        = model.Simple{ID: 1, Name: "Simple 1"}
+	Simple2        = model.Simple{ID: 2, Name: "Simple 2"}
 )
 
 func CreateTestContext() (*gin.Context, *httptest.ResponseRecorder) {
 	gin.SetMode(gin.TestMode)
 	recorder := httptest.NewRecorder()
-	ctx, _ := gin.CreateTestContext(recorder)
+	ctx, engine := gin.CreateTestContext(recorder)
+	engine.ContextWithFallback = true
+	ctx.Request = httptest.NewRequest("GET", "/test", nil)
 	return ctx, recorder
 }
 
@@ -30,6 +37,29 @@ func CreateTestContextWithAuthHeader(authHeader string) (*gin.Context, *httptest
 	return ctx, recorder
 }
 
+func CreateTestContextWithSessionCookie(method string, cookieName string, tokenString string) (*gin.Context, *httptest.ResponseRecorder) {
+	ctx, recorder := CreateTestContext()
+	ctx.Request = httptest.NewRequest(method, "/test", nil)
+	ctx.Request.AddCookie(&http.Cookie{Name: cookieName, Value: tokenString})
+	return ctx, recorder
+}
+
+// SetAuthenticatedUser authenticates the request as user, as the AuthMiddleware does. The real user is the admin
+// impersonating the user, if any.
+func SetAuthenticatedUser(ctx *gin.Context, user *model.User, realUser *model.User) {
+	utils.SetRequestContext(ctx, utils.WithAuthenticatedUser(ctx.Request.Context(), user, realUser))
+}
+
+// SetMembership resolves the request to the organization of the membership, as the AuthMiddleware does.
+func SetMembership(ctx *gin.Context, membership *model.Membership) {
+	utils.SetRequestContext(ctx, utils.WithMembership(ctx.Request.Context(), membership))
+}
+
+// SetTokenExpiry records when the request's token expires, as the AuthMiddleware does.
+func SetTokenExpiry(ctx *gin.Context, expiresAt time.Time) {
+	utils.SetRequestContext(ctx, utils.WithTokenExpiry(ctx.Request.Context(), expiresAt))
+}
+
 func GetUserWithPasswordHashFromForm(userForm model.UserForm) *model.User {
 	passwordHash, _ := bcrypt.GenerateFromPassword([]byte(userForm.Password), bcrypt.DefaultCost)
 	return userForm.ToModel(string(passwordHash))